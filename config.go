@@ -1,6 +1,7 @@
 package quic
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"time"
@@ -46,6 +47,17 @@ func validateConfig(config *Config) error {
 			return fmt.Errorf("invalid QUIC version: %s", v)
 		}
 	}
+	if pa := config.PreferredAddress; pa != nil {
+		if !pa.IPv4.IsValid() && !pa.IPv6.IsValid() {
+			return errors.New("invalid preferred address: neither IPv4 nor IPv6 address set")
+		}
+		if pa.IPv4.IsValid() && !pa.IPv4.Addr().Is4() {
+			return fmt.Errorf("invalid preferred address: %s is not an IPv4 address", pa.IPv4)
+		}
+		if pa.IPv6.IsValid() && (!pa.IPv6.Addr().Is6() || pa.IPv6.Addr().Is4In6()) {
+			return fmt.Errorf("invalid preferred address: %s is not an IPv6 address", pa.IPv6)
+		}
+	}
 	return nil
 }
 
@@ -122,8 +134,11 @@ func populateConfig(config *Config) *Config {
 		MaxIncomingUniStreams:          maxIncomingUniStreams,
 		TokenStore:                     config.TokenStore,
 		EnableDatagrams:                config.EnableDatagrams,
+		EnableMultipath:                config.EnableMultipath,
 		DisablePathMTUDiscovery:        config.DisablePathMTUDiscovery,
 		Allow0RTT:                      config.Allow0RTT,
+		PreferredAddress:               config.PreferredAddress,
+		CongestionControl:              config.CongestionControl,
 		Tracer:                         config.Tracer,
 	}
 }
@@ -5,9 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/netip"
 	"reflect"
 	"time"
 
+	"github.com/quic-go/quic-go/congestion"
 	"github.com/quic-go/quic-go/internal/protocol"
 	"github.com/quic-go/quic-go/logging"
 	"github.com/quic-go/quic-go/quicvarint"
@@ -51,6 +53,20 @@ var _ = Describe("Config", func() {
 			Expect(conf.MaxStreamReceiveWindow).To(BeEquivalentTo(uint64(quicvarint.Max)))
 			Expect(conf.MaxConnectionReceiveWindow).To(BeEquivalentTo(uint64(quicvarint.Max)))
 		})
+
+		It("validates the preferred address", func() {
+			Expect(validateConfig(&Config{PreferredAddress: &PreferredAddress{
+				IPv4: netip.MustParseAddrPort("1.2.3.4:1234"),
+				IPv6: netip.MustParseAddrPort("[2001:db8::1]:1234"),
+			}})).To(Succeed())
+			Expect(validateConfig(&Config{PreferredAddress: &PreferredAddress{}})).To(MatchError("invalid preferred address: neither IPv4 nor IPv6 address set"))
+			Expect(validateConfig(&Config{PreferredAddress: &PreferredAddress{
+				IPv4: netip.MustParseAddrPort("[2001:db8::1]:1234"),
+			}})).To(MatchError("invalid preferred address: [2001:db8::1]:1234 is not an IPv4 address"))
+			Expect(validateConfig(&Config{PreferredAddress: &PreferredAddress{
+				IPv6: netip.MustParseAddrPort("1.2.3.4:1234"),
+			}})).To(MatchError("invalid preferred address: 1.2.3.4:1234 is not an IPv6 address"))
+		})
 	})
 
 	configWithNonZeroNonFunctionFields := func() *Config {
@@ -66,7 +82,7 @@ var _ = Describe("Config", func() {
 			}
 
 			switch fn := typ.Field(i).Name; fn {
-			case "GetConfigForClient", "RequireAddressValidation", "GetLogWriter", "AllowConnectionWindowIncrease", "Tracer":
+			case "GetConfigForClient", "RequireAddressValidation", "GetLogWriter", "AllowConnectionWindowIncrease", "CongestionControl", "Tracer":
 				// Can't compare functions.
 			case "Versions":
 				f.Set(reflect.ValueOf([]VersionNumber{1, 2, 3}))
@@ -98,12 +114,16 @@ var _ = Describe("Config", func() {
 				f.Set(reflect.ValueOf(time.Second))
 			case "EnableDatagrams":
 				f.Set(reflect.ValueOf(true))
+			case "EnableMultipath":
+				f.Set(reflect.ValueOf(true))
 			case "DisableVersionNegotiationPackets":
 				f.Set(reflect.ValueOf(true))
 			case "DisablePathMTUDiscovery":
 				f.Set(reflect.ValueOf(true))
 			case "Allow0RTT":
 				f.Set(reflect.ValueOf(true))
+			case "PreferredAddress":
+				f.Set(reflect.ValueOf(&PreferredAddress{IPv4: netip.MustParseAddrPort("1.2.3.4:1234")}))
 			default:
 				Fail(fmt.Sprintf("all fields must be accounted for, but saw unknown field %q", fn))
 			}
@@ -118,11 +138,15 @@ var _ = Describe("Config", func() {
 
 	Context("cloning", func() {
 		It("clones function fields", func() {
-			var calledAddrValidation, calledAllowConnectionWindowIncrease, calledTracer bool
+			var calledAddrValidation, calledAllowConnectionWindowIncrease, calledCongestionControl, calledTracer bool
 			c1 := &Config{
 				GetConfigForClient:            func(info *ClientHelloInfo) (*Config, error) { return nil, errors.New("nope") },
 				AllowConnectionWindowIncrease: func(Connection, uint64) bool { calledAllowConnectionWindowIncrease = true; return true },
 				RequireAddressValidation:      func(net.Addr) bool { calledAddrValidation = true; return true },
+				CongestionControl: func(congestion.RTTStats, congestion.ByteCount) congestion.SendAlgorithm {
+					calledCongestionControl = true
+					return nil
+				},
 				Tracer: func(context.Context, logging.Perspective, ConnectionID) *logging.ConnectionTracer {
 					calledTracer = true
 					return nil
@@ -135,6 +159,8 @@ var _ = Describe("Config", func() {
 			Expect(calledAllowConnectionWindowIncrease).To(BeTrue())
 			_, err := c2.GetConfigForClient(&ClientHelloInfo{})
 			Expect(err).To(MatchError("nope"))
+			c2.CongestionControl(nil, 1000)
+			Expect(calledCongestionControl).To(BeTrue())
 			c2.Tracer(context.Background(), logging.PerspectiveClient, protocol.ConnectionID{})
 			Expect(calledTracer).To(BeTrue())
 		})
@@ -0,0 +1,99 @@
+// Package congestion defines the congestion control interface for quic-go.
+// This package should not be considered stable
+package congestion
+
+import (
+	"time"
+
+	"github.com/quic-go/quic-go/internal/congestion"
+	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/logging"
+)
+
+type (
+	// A ByteCount is used to count bytes.
+	ByteCount = protocol.ByteCount
+	// The PacketNumber is the packet number of a packet.
+	PacketNumber = protocol.PacketNumber
+	// The PacketNumberSpace is the packet number space a packet is sent in.
+	// Packet numbers are only unique within a packet number space.
+	PacketNumberSpace = protocol.PacketNumberSpace
+	// The RTTStats are a read-only view of the RTT estimates of a path.
+	// They are updated by quic-go.
+	RTTStats = congestion.RTTStats
+)
+
+const (
+	// PacketNumberSpaceInitial is the Initial packet number space
+	PacketNumberSpaceInitial = protocol.PacketNumberSpaceInitial
+	// PacketNumberSpaceHandshake is the Handshake packet number space
+	PacketNumberSpaceHandshake = protocol.PacketNumberSpaceHandshake
+	// PacketNumberSpaceApplicationData is the application data packet number space, used by 0-RTT and 1-RTT packets
+	PacketNumberSpaceApplicationData = protocol.PacketNumberSpaceApplicationData
+)
+
+// A SendAlgorithm performs congestion control (and pacing) for a single path of a QUIC connection.
+// All methods are called from the connection's run loop, implementations don't need to be safe for concurrent use.
+type SendAlgorithm interface {
+	// TimeUntilSend returns when the next packet can be sent, taking pacing into account.
+	TimeUntilSend(bytesInFlight ByteCount) time.Time
+	// HasPacingBudget says if the pacer allows sending a packet at this point in time.
+	HasPacingBudget(now time.Time) bool
+	// OnPacketSent is called for every packet that is sent.
+	// isRetransmittable is false for packets that only contain ACK frames, these packets are not counted towards bytes in flight.
+	OnPacketSent(sentTime time.Time, bytesInFlight ByteCount, pnSpace PacketNumberSpace, packetNumber PacketNumber, bytes ByteCount, isRetransmittable bool)
+	// CanSend says if the congestion window allows sending a packet.
+	CanSend(bytesInFlight ByteCount) bool
+	// MaybeExitSlowStart is called when an ACK is received, before OnPacketAcked is called for the newly acknowledged packets.
+	MaybeExitSlowStart()
+	// OnPacketAcked is called for every packet that is acknowledged.
+	OnPacketAcked(pnSpace PacketNumberSpace, number PacketNumber, ackedBytes ByteCount, priorInFlight ByteCount, eventTime time.Time)
+	// OnCongestionEvent is called when a packet is declared lost.
+	OnCongestionEvent(pnSpace PacketNumberSpace, number PacketNumber, lostBytes ByteCount, priorInFlight ByteCount)
+	// OnECNCongestionEvent is called when an ACK frame increases the ECN-CE count, once ECN validation succeeded.
+	// ceMarked is the number of newly CE-marked packets. It is called for every such ACK frame,
+	// it is up to the implementation to limit the reduction of the congestion window to once per round trip
+	// (RFC 9002, section 7.1), or to react in proportion to the number of CE marks (as L4S congestion controllers do).
+	// It is called before OnPacketAcked is called for the packets acknowledged by the ACK frame.
+	// ECN is only used for 1-RTT packets, largestAcked is a packet number in the application data packet number space.
+	OnECNCongestionEvent(largestAcked PacketNumber, ceMarked int64, priorInFlight ByteCount)
+	// OnPersistentCongestion is called when persistent congestion is detected (RFC 9002, section 7.6),
+	// after OnCongestionEvent was called for the lost packets.
+	// The congestion window should be reduced to its minimum value.
+	OnPersistentCongestion()
+	// OnRetransmissionTimeout is called when the retransmission timer fires.
+	OnRetransmissionTimeout(packetsRetransmitted bool)
+	// DropPacketNumberSpace is called when no more packets will be acknowledged or declared lost in a packet number space,
+	// i.e. when the Initial or Handshake keys are dropped, and when 0-RTT is rejected.
+	// Implementations that keep state for every sent packet should delete the state of the packets sent in this space.
+	DropPacketNumberSpace(PacketNumberSpace)
+	// SetMaxDatagramSize is called when the maximum datagram size changes, e.g. as a result of Path MTU Discovery.
+	SetMaxDatagramSize(ByteCount)
+	// GetCongestionWindow returns the current size of the congestion window.
+	GetCongestionWindow() ByteCount
+}
+
+// A SendAlgorithmWithTracer is a SendAlgorithm that reports changes of its state to a logging.ConnectionTracer.
+// If the connection is traced, SetTracer is called right after the SendAlgorithm was created.
+type SendAlgorithmWithTracer interface {
+	SendAlgorithm
+	SetTracer(*logging.ConnectionTracer)
+}
+
+// NewCubic creates a congestion controller implementing CUBIC (RFC 8312).
+func NewCubic(rttStats RTTStats, initialMaxDatagramSize ByteCount) SendAlgorithm {
+	return congestion.NewCubicSender(congestion.DefaultClock{}, rttStats, initialMaxDatagramSize, false, nil)
+}
+
+// NewReno creates a congestion controller implementing NewReno (RFC 9002).
+// This is the congestion controller that quic-go uses by default.
+func NewReno(rttStats RTTStats, initialMaxDatagramSize ByteCount) SendAlgorithm {
+	return congestion.NewCubicSender(congestion.DefaultClock{}, rttStats, initialMaxDatagramSize, true, nil)
+}
+
+// NewBBR creates a congestion controller implementing BBR (draft-cardwell-iccrg-bbr-congestion-control-02).
+// BBR paces packets based on its estimate of the bottleneck bandwidth and the minimum RTT,
+// and doesn't treat every packet loss as a signal of congestion.
+func NewBBR(rttStats RTTStats, initialMaxDatagramSize ByteCount) SendAlgorithm {
+	return congestion.NewBBRSender(congestion.DefaultClock{}, rttStats, initialMaxDatagramSize, nil)
+}
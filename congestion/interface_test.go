@@ -0,0 +1,68 @@
+package congestion_test
+
+import (
+	"time"
+
+	"github.com/quic-go/quic-go/congestion"
+	"github.com/quic-go/quic-go/logging"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// rttStats is an RTT estimate that never changes
+type rttStats struct{ rtt time.Duration }
+
+var _ congestion.RTTStats = &rttStats{}
+
+func (r *rttStats) MinRTT() time.Duration                     { return r.rtt }
+func (r *rttStats) LatestRTT() time.Duration                  { return r.rtt }
+func (r *rttStats) SmoothedRTT() time.Duration                { return r.rtt }
+func (r *rttStats) MeanDeviation() time.Duration              { return r.rtt / 2 }
+func (r *rttStats) MaxAckDelay() time.Duration                { return 0 }
+func (r *rttStats) PTO(includeMaxAckDelay bool) time.Duration { return 3 * r.rtt }
+
+var _ = Describe("Congestion Controllers", func() {
+	for name, constructor := range map[string]func(congestion.RTTStats, congestion.ByteCount) congestion.SendAlgorithm{
+		"Cubic":   congestion.NewCubic,
+		"NewReno": congestion.NewReno,
+	} {
+		newCongestionControl := constructor
+
+		Context(name, func() {
+			It("reduces the congestion window on a congestion event", func() {
+				cc := newCongestionControl(&rttStats{}, 1200)
+				initialWindow := cc.GetCongestionWindow()
+				Expect(initialWindow).To(BeNumerically(">=", 10*1200))
+				Expect(cc.CanSend(initialWindow - 1)).To(BeTrue())
+				Expect(cc.CanSend(initialWindow)).To(BeFalse())
+
+				for i := 1; i <= 10; i++ {
+					cc.OnPacketSent(time.Now(), congestion.ByteCount(i-1)*1200, congestion.PacketNumberSpaceApplicationData, congestion.PacketNumber(i), 1200, true)
+				}
+				cc.OnCongestionEvent(congestion.PacketNumberSpaceApplicationData, 1, 1200, 10*1200)
+				Expect(cc.GetCongestionWindow()).To(BeNumerically("<", initialWindow))
+			})
+		})
+	}
+
+	Context("BBR", func() {
+		It("starts with the initial congestion window", func() {
+			cc := congestion.NewBBR(&rttStats{}, 1200)
+			Expect(cc.GetCongestionWindow()).To(BeNumerically(">=", 10*1200))
+			Expect(cc.CanSend(0)).To(BeTrue())
+			Expect(cc.HasPacingBudget(time.Now())).To(BeTrue())
+		})
+
+		It("reports its state to the tracer", func() {
+			cc := congestion.NewBBR(&rttStats{}, 1200)
+			ccWithTracer, ok := cc.(congestion.SendAlgorithmWithTracer)
+			Expect(ok).To(BeTrue())
+			var states []logging.CongestionState
+			ccWithTracer.SetTracer(&logging.ConnectionTracer{
+				UpdatedCongestionState: func(s logging.CongestionState) { states = append(states, s) },
+			})
+			Expect(states).To(Equal([]logging.CongestionState{logging.CongestionStateStartup}))
+		})
+	})
+})
@@ -1,6 +1,7 @@
 package quic
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/quic-go/quic-go/internal/protocol"
@@ -9,12 +10,23 @@ import (
 	"github.com/quic-go/quic-go/internal/wire"
 )
 
+// connRunnerCallbacks are used to register the connection IDs with the packet handler map
+// of a Transport other than the one the connection was established on.
+type connRunnerCallbacks struct {
+	AddConnectionID    func(protocol.ConnectionID)
+	RemoveConnectionID func(protocol.ConnectionID)
+	RetireConnectionID func(protocol.ConnectionID)
+	ReplaceWithClosed  func([]protocol.ConnectionID, protocol.Perspective, []byte)
+}
+
 type connIDGenerator struct {
 	generator  ConnectionIDGenerator
 	highestSeq uint64
 
 	activeSrcConnIDs        map[uint64]protocol.ConnectionID
 	initialClientDestConnID *protocol.ConnectionID // nil for the client
+	// the connection ID sent in the preferred_address, until it is added to the runner
+	preferredAddressConnID *protocol.ConnectionID
 
 	addConnectionID        func(protocol.ConnectionID)
 	getStatelessResetToken func(protocol.ConnectionID) protocol.StatelessResetToken
@@ -22,6 +34,9 @@ type connIDGenerator struct {
 	retireConnectionID     func(protocol.ConnectionID)
 	replaceWithClosed      func([]protocol.ConnectionID, protocol.Perspective, []byte)
 	queueControlFrame      func(wire.Frame)
+
+	// runners for the Transports used by additional paths
+	connRunners map[pathID]connRunnerCallbacks
 }
 
 func newConnIDGenerator(
@@ -58,8 +73,6 @@ func (m *connIDGenerator) SetMaxActiveConnIDs(limit uint64) error {
 	// connection IDs the peer will store. This limit includes the connection ID
 	// used during the handshake, and the one sent in the preferred_address
 	// transport parameter.
-	// We currently don't send the preferred_address transport parameter,
-	// so we can issue (limit - 1) connection IDs.
 	for i := uint64(len(m.activeSrcConnIDs)); i < utils.Min(limit, protocol.MaxIssuedConnectionIDs); i++ {
 		if err := m.issueNewConnID(); err != nil {
 			return err
@@ -68,6 +81,33 @@ func (m *connIDGenerator) SetMaxActiveConnIDs(limit uint64) error {
 	return nil
 }
 
+// GenerateForPreferredAddress generates the connection ID sent in the preferred_address transport parameter.
+// This connection ID has sequence number 1, so this needs to be called before any other connection IDs are issued.
+// It is called while the connection is constructed, which is too early to add the connection ID to the runner.
+// This happens when AddPreferredAddressConnID is called.
+func (m *connIDGenerator) GenerateForPreferredAddress() (protocol.ConnectionID, protocol.StatelessResetToken, error) {
+	if m.highestSeq != 0 {
+		return protocol.ConnectionID{}, protocol.StatelessResetToken{}, errors.New("connection IDs were already issued")
+	}
+	connID, err := m.generator.GenerateConnectionID()
+	if err != nil {
+		return protocol.ConnectionID{}, protocol.StatelessResetToken{}, err
+	}
+	m.highestSeq = 1
+	m.activeSrcConnIDs[m.highestSeq] = connID
+	m.preferredAddressConnID = &connID
+	return connID, m.getStatelessResetToken(connID), nil
+}
+
+// AddPreferredAddressConnID adds the connection ID generated by GenerateForPreferredAddress to the runner.
+func (m *connIDGenerator) AddPreferredAddressConnID() {
+	if m.preferredAddressConnID == nil {
+		return
+	}
+	m.addConnectionID(*m.preferredAddressConnID)
+	m.preferredAddressConnID = nil
+}
+
 func (m *connIDGenerator) Retire(seq uint64, sentWithDestConnID protocol.ConnectionID) error {
 	if seq > m.highestSeq {
 		return &qerr.TransportError{
@@ -87,6 +127,9 @@ func (m *connIDGenerator) Retire(seq uint64, sentWithDestConnID protocol.Connect
 		}
 	}
 	m.retireConnectionID(connID)
+	for _, r := range m.connRunners {
+		r.RetireConnectionID(connID)
+	}
 	delete(m.activeSrcConnIDs, seq)
 	// Don't issue a replacement for the initial connection ID.
 	if seq == 0 {
@@ -102,6 +145,9 @@ func (m *connIDGenerator) issueNewConnID() error {
 	}
 	m.activeSrcConnIDs[m.highestSeq+1] = connID
 	m.addConnectionID(connID)
+	for _, r := range m.connRunners {
+		r.AddConnectionID(connID)
+	}
 	m.queueControlFrame(&wire.NewConnectionIDFrame{
 		SequenceNumber:      m.highestSeq + 1,
 		ConnectionID:        connID,
@@ -111,6 +157,16 @@ func (m *connIDGenerator) issueNewConnID() error {
 	return nil
 }
 
+// SequenceNumber returns the sequence number of an active connection ID.
+func (m *connIDGenerator) SequenceNumber(connID protocol.ConnectionID) (uint64, bool) {
+	for seq, c := range m.activeSrcConnIDs {
+		if c == connID {
+			return seq, true
+		}
+	}
+	return 0, false
+}
+
 func (m *connIDGenerator) SetHandshakeComplete() {
 	if m.initialClientDestConnID != nil {
 		m.retireConnectionID(*m.initialClientDestConnID)
@@ -124,6 +180,9 @@ func (m *connIDGenerator) RemoveAll() {
 	}
 	for _, connID := range m.activeSrcConnIDs {
 		m.removeConnectionID(connID)
+		for _, r := range m.connRunners {
+			r.RemoveConnectionID(connID)
+		}
 	}
 }
 
@@ -136,4 +195,31 @@ func (m *connIDGenerator) ReplaceWithClosed(pers protocol.Perspective, connClose
 		connIDs = append(connIDs, connID)
 	}
 	m.replaceWithClosed(connIDs, pers, connClose)
+	for _, r := range m.connRunners {
+		r.ReplaceWithClosed(connIDs, pers, connClose)
+	}
+}
+
+// AddConnRunner registers all active connection IDs with the runner,
+// and keeps it updated when connection IDs are issued or retired.
+func (m *connIDGenerator) AddConnRunner(id pathID, r connRunnerCallbacks) {
+	if m.connRunners == nil {
+		m.connRunners = make(map[pathID]connRunnerCallbacks)
+	}
+	m.connRunners[id] = r
+	for _, connID := range m.activeSrcConnIDs {
+		r.AddConnectionID(connID)
+	}
+}
+
+// RemoveConnRunner removes all active connection IDs from the runner.
+func (m *connIDGenerator) RemoveConnRunner(id pathID) {
+	r, ok := m.connRunners[id]
+	if !ok {
+		return
+	}
+	for _, connID := range m.activeSrcConnIDs {
+		r.RemoveConnectionID(connID)
+	}
+	delete(m.connRunners, id)
 }
@@ -67,6 +67,47 @@ var _ = Describe("Connection ID Generator", func() {
 		}
 	})
 
+	It("returns the sequence number of a connection ID", func() {
+		Expect(g.SetMaxActiveConnIDs(4)).To(Succeed())
+		seq, ok := g.SequenceNumber(initialConnID)
+		Expect(ok).To(BeTrue())
+		Expect(seq).To(BeZero())
+		seq, ok = g.SequenceNumber(addedConnIDs[1])
+		Expect(ok).To(BeTrue())
+		Expect(seq).To(BeEquivalentTo(2))
+		_, ok = g.SequenceNumber(protocol.ParseConnectionID([]byte{0xde, 0xad, 0xbe, 0xef}))
+		Expect(ok).To(BeFalse())
+	})
+
+	It("generates the connection ID for the preferred address", func() {
+		connID, token, err := g.GenerateForPreferredAddress()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(connID.Len()).To(Equal(7))
+		Expect(token).To(Equal(connIDToToken(connID)))
+		Expect(addedConnIDs).To(BeEmpty())
+		g.AddPreferredAddressConnID()
+		Expect(addedConnIDs).To(Equal([]protocol.ConnectionID{connID}))
+		g.AddPreferredAddressConnID()
+		Expect(addedConnIDs).To(HaveLen(1))
+		// the connection ID is not sent in a NEW_CONNECTION_ID frame
+		Expect(queuedFrames).To(BeEmpty())
+		// the preferred address connection ID counts towards the limit
+		Expect(g.SetMaxActiveConnIDs(4)).To(Succeed())
+		Expect(queuedFrames).To(HaveLen(2))
+		for i, f := range queuedFrames {
+			Expect(f.(*wire.NewConnectionIDFrame).SequenceNumber).To(BeEquivalentTo(i + 2))
+		}
+		// it can be retired like any other connection ID
+		Expect(g.Retire(1, protocol.ConnectionID{})).To(Succeed())
+		Expect(retiredConnIDs).To(Equal([]protocol.ConnectionID{connID}))
+	})
+
+	It("doesn't generate the connection ID for the preferred address after issuing connection IDs", func() {
+		Expect(g.SetMaxActiveConnIDs(4)).To(Succeed())
+		_, _, err := g.GenerateForPreferredAddress()
+		Expect(err).To(MatchError("connection IDs were already issued"))
+	})
+
 	It("limits the number of connection IDs that it issues", func() {
 		Expect(g.SetMaxActiveConnIDs(9999999)).To(Succeed())
 		Expect(retiredConnIDs).To(BeEmpty())
@@ -186,4 +227,45 @@ var _ = Describe("Connection ID Generator", func() {
 			Expect(replacedWithClosed).To(ContainElement(nf.ConnectionID))
 		}
 	})
+
+	It("registers connection IDs with additional runners", func() {
+		Expect(g.SetMaxActiveConnIDs(3)).To(Succeed())
+		Expect(queuedFrames).To(HaveLen(2))
+		var added, removed, retired, closed []protocol.ConnectionID
+		g.AddConnRunner(1, connRunnerCallbacks{
+			AddConnectionID:    func(c protocol.ConnectionID) { added = append(added, c) },
+			RemoveConnectionID: func(c protocol.ConnectionID) { removed = append(removed, c) },
+			RetireConnectionID: func(c protocol.ConnectionID) { retired = append(retired, c) },
+			ReplaceWithClosed: func(cs []protocol.ConnectionID, _ protocol.Perspective, _ []byte) {
+				closed = append(closed, cs...)
+			},
+		})
+		Expect(added).To(HaveLen(3))
+		Expect(added).To(ContainElement(initialConnID))
+		for _, f := range queuedFrames {
+			Expect(added).To(ContainElement(f.(*wire.NewConnectionIDFrame).ConnectionID))
+		}
+		// retiring a connection ID issues a new one
+		retiredConnID := queuedFrames[0].(*wire.NewConnectionIDFrame).ConnectionID
+		Expect(g.Retire(1, protocol.ConnectionID{})).To(Succeed())
+		Expect(retired).To(Equal([]protocol.ConnectionID{retiredConnID}))
+		Expect(added).To(HaveLen(4))
+		Expect(added[3]).To(Equal(queuedFrames[2].(*wire.NewConnectionIDFrame).ConnectionID))
+		g.ReplaceWithClosed(protocol.PerspectiveClient, []byte("foobar"))
+		Expect(closed).To(HaveLen(4)) // the initial client destination connection ID, and 3 active connection IDs
+	})
+
+	It("removes connection IDs from additional runners", func() {
+		Expect(g.SetMaxActiveConnIDs(3)).To(Succeed())
+		var added, removed []protocol.ConnectionID
+		g.AddConnRunner(1, connRunnerCallbacks{
+			AddConnectionID:    func(c protocol.ConnectionID) { added = append(added, c) },
+			RemoveConnectionID: func(c protocol.ConnectionID) { removed = append(removed, c) },
+		})
+		g.RemoveConnRunner(1)
+		Expect(removed).To(ConsistOf(added))
+		// the runner is not used any more
+		Expect(g.Retire(1, protocol.ConnectionID{})).To(Succeed())
+		Expect(added).To(HaveLen(3))
+	})
 })
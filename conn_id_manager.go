@@ -19,12 +19,17 @@ type newConnID struct {
 type connIDManager struct {
 	queue list.List[newConnID]
 
-	handshakeComplete         bool
+	handshakeComplete bool
+	// On multipath connections, the connection ID identifies the path, so it is never changed.
+	multipath                 bool
 	activeSequenceNumber      uint64
 	highestRetired            uint64
 	activeConnectionID        protocol.ConnectionID
 	activeStatelessResetToken *protocol.StatelessResetToken
 
+	// connection IDs that were handed out for probing new paths, keyed by the path
+	pathProbing map[pathID]newConnID
+
 	// We change the connection ID after sending on average
 	// protocol.PacketsPerConnectionID packets. The actual value is randomized
 	// hide the packet loss rate from on-path observers.
@@ -59,13 +64,21 @@ func (h *connIDManager) Add(f *wire.NewConnectionIDFrame) error {
 	if err := h.add(f); err != nil {
 		return err
 	}
-	if h.queue.Len() >= protocol.MaxActiveConnectionIDs {
+	if h.queue.Len()+len(h.pathProbing) >= protocol.MaxActiveConnectionIDs {
 		return &qerr.TransportError{ErrorCode: qerr.ConnectionIDLimitError}
 	}
 	return nil
 }
 
 func (h *connIDManager) add(f *wire.NewConnectionIDFrame) error {
+	if f.SequenceNumber == h.activeSequenceNumber && f.RetirePriorTo <= h.activeSequenceNumber {
+		return nil
+	}
+	for _, entry := range h.pathProbing {
+		if f.SequenceNumber == entry.SequenceNumber {
+			return nil
+		}
+	}
 	// If the NEW_CONNECTION_ID frame is reordered, such that its sequence number is smaller than the currently active
 	// connection ID or if it was already retired, send the RETIRE_CONNECTION_ID frame immediately.
 	if f.SequenceNumber < h.activeSequenceNumber || f.SequenceNumber < h.highestRetired {
@@ -91,6 +104,18 @@ func (h *connIDManager) add(f *wire.NewConnectionIDFrame) error {
 		}
 		h.highestRetired = f.RetirePriorTo
 	}
+	// Retire connection IDs used for probing paths.
+	// They might have a lower sequence number than the highest retired connection ID.
+	for id, entry := range h.pathProbing {
+		if entry.SequenceNumber >= f.RetirePriorTo {
+			continue
+		}
+		h.queueControlFrame(&wire.RetireConnectionIDFrame{
+			SequenceNumber: entry.SequenceNumber,
+		})
+		h.removeStatelessResetToken(entry.StatelessResetToken)
+		delete(h.pathProbing, id)
+	}
 
 	if f.SequenceNumber == h.activeSequenceNumber {
 		return nil
@@ -163,6 +188,9 @@ func (h *connIDManager) Close() {
 	if h.activeStatelessResetToken != nil {
 		h.removeStatelessResetToken(*h.activeStatelessResetToken)
 	}
+	for _, entry := range h.pathProbing {
+		h.removeStatelessResetToken(entry.StatelessResetToken)
+	}
 }
 
 // is called when the server performs a Retry
@@ -188,7 +216,7 @@ func (h *connIDManager) SentPacket() {
 }
 
 func (h *connIDManager) shouldUpdateConnID() bool {
-	if !h.handshakeComplete {
+	if !h.handshakeComplete || h.multipath {
 		return false
 	}
 	// initiate the first change as early as possible (after handshake completion)
@@ -212,3 +240,77 @@ func (h *connIDManager) Get() protocol.ConnectionID {
 func (h *connIDManager) SetHandshakeComplete() {
 	h.handshakeComplete = true
 }
+
+// SetMultipath is called when multipath was negotiated.
+// The connection ID of the initial path is not changed anymore.
+func (h *connIDManager) SetMultipath() {
+	h.multipath = true
+}
+
+// GetConnIDForPath returns the connection ID used for probing the path.
+// A connection ID is never used on more than one path, so the first call for a path
+// takes an unused connection ID from the queue.
+// It returns false if the peer hasn't provided an unused connection ID.
+func (h *connIDManager) GetConnIDForPath(id pathID) (protocol.ConnectionID, bool) {
+	// if we're using zero-length connection IDs, there's nothing to change
+	if h.activeConnectionID.Len() == 0 {
+		return h.activeConnectionID, true
+	}
+	if entry, ok := h.pathProbing[id]; ok {
+		return entry.ConnectionID, true
+	}
+	if h.queue.Len() == 0 {
+		return protocol.ConnectionID{}, false
+	}
+	if h.pathProbing == nil {
+		h.pathProbing = make(map[pathID]newConnID)
+	}
+	front := h.queue.Remove(h.queue.Front())
+	h.pathProbing[id] = front
+	h.addStatelessResetToken(front.StatelessResetToken)
+	return front.ConnectionID, true
+}
+
+// GetSequenceNumberForPath returns the sequence number of the connection ID used for probing the path.
+// It returns false if GetConnIDForPath didn't return a connection ID for this path.
+func (h *connIDManager) GetSequenceNumberForPath(id pathID) (uint64, bool) {
+	entry, ok := h.pathProbing[id]
+	if !ok {
+		return 0, false
+	}
+	return entry.SequenceNumber, true
+}
+
+// RetireConnIDForPath retires the connection ID that was used for probing the path.
+func (h *connIDManager) RetireConnIDForPath(id pathID) {
+	entry, ok := h.pathProbing[id]
+	if !ok {
+		return
+	}
+	h.queueControlFrame(&wire.RetireConnectionIDFrame{
+		SequenceNumber: entry.SequenceNumber,
+	})
+	h.removeStatelessResetToken(entry.StatelessResetToken)
+	delete(h.pathProbing, id)
+}
+
+// SwitchToPath makes the connection ID used for probing the path the active connection ID.
+// The previously active connection ID is retired.
+func (h *connIDManager) SwitchToPath(id pathID) {
+	entry, ok := h.pathProbing[id]
+	if !ok {
+		return
+	}
+	delete(h.pathProbing, id)
+	h.queueControlFrame(&wire.RetireConnectionIDFrame{
+		SequenceNumber: h.activeSequenceNumber,
+	})
+	if h.activeStatelessResetToken != nil {
+		h.removeStatelessResetToken(*h.activeStatelessResetToken)
+	}
+	h.activeSequenceNumber = entry.SequenceNumber
+	h.activeConnectionID = entry.ConnectionID
+	h.activeStatelessResetToken = &entry.StatelessResetToken
+	h.packetsSinceLastChange = 0
+	h.packetsPerConnectionID = protocol.PacketsPerConnectionID/2 + uint32(h.rand.Int31n(protocol.PacketsPerConnectionID))
+}
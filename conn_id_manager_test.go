@@ -259,6 +259,20 @@ var _ = Describe("Connection ID Manager", func() {
 		Expect(m.Get()).To(Equal(protocol.ParseConnectionID([]byte{1, 2, 3, 4})))
 	})
 
+	It("doesn't initiate connection ID updates on multipath connections", func() {
+		m.SetMultipath()
+		Expect(m.Add(&wire.NewConnectionIDFrame{
+			SequenceNumber:      1,
+			ConnectionID:        protocol.ParseConnectionID([]byte{1, 2, 3, 4}),
+			StatelessResetToken: protocol.StatelessResetToken{16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1},
+		})).To(Succeed())
+		m.SetHandshakeComplete()
+		for i := 0; i < 2*protocol.PacketsPerConnectionID; i++ {
+			m.SentPacket()
+		}
+		Expect(m.Get()).To(Equal(initialConnID))
+	})
+
 	It("initiates subsequent updates when enough packets are sent", func() {
 		var s uint8
 		for s = uint8(1); s < protocol.MaxActiveConnectionIDs; s++ {
@@ -361,4 +375,111 @@ var _ = Describe("Connection ID Manager", func() {
 		Expect(removedTokens).To(HaveLen(1))
 		Expect(removedTokens[0]).To(Equal(protocol.StatelessResetToken{16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1}))
 	})
+
+	Context("path probing", func() {
+		addConnIDs := func(seqs ...uint64) {
+			for _, seq := range seqs {
+				ExpectWithOffset(1, m.Add(&wire.NewConnectionIDFrame{
+					SequenceNumber:      seq,
+					ConnectionID:        protocol.ParseConnectionID([]byte{byte(seq), byte(seq), byte(seq), byte(seq)}),
+					StatelessResetToken: protocol.StatelessResetToken{byte(seq)},
+				})).To(Succeed())
+			}
+		}
+
+		It("hands out a separate connection ID for every path", func() {
+			addConnIDs(1, 2)
+			connID1, ok := m.GetConnIDForPath(1)
+			Expect(ok).To(BeTrue())
+			Expect(connID1).To(Equal(protocol.ParseConnectionID([]byte{1, 1, 1, 1})))
+			Expect(*tokenAdded).To(Equal(protocol.StatelessResetToken{1}))
+			// the same connection ID is returned when probing the path again
+			connID, ok := m.GetConnIDForPath(1)
+			Expect(ok).To(BeTrue())
+			Expect(connID).To(Equal(connID1))
+			connID2, ok := m.GetConnIDForPath(2)
+			Expect(ok).To(BeTrue())
+			Expect(connID2).To(Equal(protocol.ParseConnectionID([]byte{2, 2, 2, 2})))
+			_, ok = m.GetConnIDForPath(3)
+			Expect(ok).To(BeFalse())
+			// the connection IDs used for probing are not used on the active path
+			m.SetHandshakeComplete()
+			Expect(m.Get()).To(Equal(initialConnID))
+		})
+
+		It("returns the sequence number of the connection ID of a path", func() {
+			addConnIDs(1, 2)
+			_, ok := m.GetSequenceNumberForPath(1)
+			Expect(ok).To(BeFalse())
+			_, ok = m.GetConnIDForPath(1)
+			Expect(ok).To(BeTrue())
+			_, ok = m.GetConnIDForPath(2)
+			Expect(ok).To(BeTrue())
+			seq, ok := m.GetSequenceNumberForPath(2)
+			Expect(ok).To(BeTrue())
+			Expect(seq).To(BeEquivalentTo(2))
+		})
+
+		It("doesn't change the connection ID when zero-length connection IDs are used", func() {
+			m = newConnIDManager(protocol.ConnectionID{}, nil, nil, nil)
+			connID, ok := m.GetConnIDForPath(1)
+			Expect(ok).To(BeTrue())
+			Expect(connID.Len()).To(BeZero())
+		})
+
+		It("retires the connection ID of a path", func() {
+			addConnIDs(1)
+			_, ok := m.GetConnIDForPath(1)
+			Expect(ok).To(BeTrue())
+			m.RetireConnIDForPath(1)
+			Expect(frameQueue).To(Equal([]wire.Frame{&wire.RetireConnectionIDFrame{SequenceNumber: 1}}))
+			Expect(removedTokens).To(Equal([]protocol.StatelessResetToken{{1}}))
+			// retiring it again is a no-op
+			m.RetireConnIDForPath(1)
+			Expect(frameQueue).To(HaveLen(1))
+		})
+
+		It("ignores retransmissions of connection IDs used for probing", func() {
+			addConnIDs(1)
+			_, ok := m.GetConnIDForPath(1)
+			Expect(ok).To(BeTrue())
+			addConnIDs(1)
+			Expect(m.queue.Len()).To(BeZero())
+		})
+
+		It("retires connection IDs of paths when the peer asks for it", func() {
+			addConnIDs(1, 2)
+			_, ok := m.GetConnIDForPath(1)
+			Expect(ok).To(BeTrue())
+			Expect(m.Add(&wire.NewConnectionIDFrame{
+				SequenceNumber:      3,
+				RetirePriorTo:       2,
+				ConnectionID:        protocol.ParseConnectionID([]byte{3, 3, 3, 3}),
+				StatelessResetToken: protocol.StatelessResetToken{3},
+			})).To(Succeed())
+			Expect(frameQueue).To(ContainElement(&wire.RetireConnectionIDFrame{SequenceNumber: 1}))
+			Expect(removedTokens).To(ContainElement(protocol.StatelessResetToken{1}))
+			// the path now uses a new connection ID
+			connID, ok := m.GetConnIDForPath(1)
+			Expect(ok).To(BeTrue())
+			Expect(connID).To(Equal(protocol.ParseConnectionID([]byte{3, 3, 3, 3})))
+		})
+
+		It("switches to the connection ID of a path", func() {
+			m.SetStatelessResetToken(protocol.StatelessResetToken{42})
+			addConnIDs(1, 2)
+			_, ok := m.GetConnIDForPath(5)
+			Expect(ok).To(BeTrue())
+			m.SwitchToPath(5)
+			Expect(m.Get()).To(Equal(protocol.ParseConnectionID([]byte{1, 1, 1, 1})))
+			Expect(frameQueue).To(Equal([]wire.Frame{&wire.RetireConnectionIDFrame{SequenceNumber: 0}}))
+			Expect(removedTokens).To(Equal([]protocol.StatelessResetToken{{42}}))
+			Expect(*m.activeStatelessResetToken).To(Equal(protocol.StatelessResetToken{1}))
+			// retransmissions of the NEW_CONNECTION_ID frame for the active connection ID are ignored
+			frameQueue = nil
+			addConnIDs(1)
+			Expect(frameQueue).To(BeEmpty())
+			Expect(m.queue.Len()).To(Equal(1))
+		})
+	})
 })
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"net/netip"
 	"reflect"
 	"sync"
 	"sync/atomic"
@@ -27,6 +28,7 @@ import (
 type unpacker interface {
 	UnpackLongHeader(hdr *wire.Header, rcvTime time.Time, data []byte, v protocol.VersionNumber) (*unpackedPacket, error)
 	UnpackShortHeader(rcvTime time.Time, data []byte) (protocol.PacketNumber, protocol.PacketNumberLen, protocol.KeyPhaseBit, []byte, error)
+	UnpackShortHeaderOnPath(rcvTime time.Time, data []byte, pathID uint64) (protocol.PacketNumber, protocol.PacketNumberLen, protocol.KeyPhaseBit, []byte, error)
 }
 
 type streamGetter interface {
@@ -131,9 +133,18 @@ type connection struct {
 	version     protocol.VersionNumber
 	config      *Config
 
+	// conn is replaced when the client migrates to a new path.
+	// It's only modified on the run loop, but it's read from other go routines in LocalAddr and RemoteAddr.
+	connMutex sync.Mutex
 	conn      sendConn
 	sendQueue sender
 
+	pathManagerOutgoing *pathManagerOutgoing // only set for the client
+	pathManager         *pathManager         // only set for the server
+	multipath           *multipathManager    // only set if multipath was negotiated
+	// the state of the last validated path, while the path the connection migrated to is being validated
+	validatedPath *validatedPathState
+
 	streamsMap      streamManager
 	connIDManager   *connIDManager
 	connIDGenerator *connIDGenerator
@@ -162,6 +173,8 @@ type connection struct {
 
 	receivedPackets  chan receivedPacket
 	sendingScheduled chan struct{}
+	// a snapshot of the statistics, updated by the run loop
+	stats atomic.Pointer[ConnectionStats]
 
 	closeOnce sync.Once
 	// closeChan is used to notify the run loop that it should terminate
@@ -207,6 +220,9 @@ type connection struct {
 	connStateMutex sync.Mutex
 	connState      ConnectionState
 
+	packetsReceived uint64
+	bytesReceived   protocol.ByteCount
+
 	logID  string
 	tracer *logging.ConnectionTracer
 	logger utils.Logger
@@ -273,12 +289,14 @@ var newConnection = func(
 	)
 	s.preSetup()
 	s.ctx, s.ctxCancel = context.WithCancelCause(context.WithValue(context.Background(), ConnectionTracingKey, tracingID))
+	s.pathManager = newPathManager(s.conn)
 	s.sentPacketHandler, s.receivedPacketHandler = ackhandler.NewAckHandler(
 		0,
 		getMaxPacketSize(s.conn.RemoteAddr()),
 		s.rttStats,
 		clientAddressValidated,
 		s.conn.capabilities().ECN,
+		s.config.CongestionControl,
 		s.perspective,
 		s.tracer,
 		s.logger,
@@ -294,7 +312,6 @@ var newConnection = func(
 		MaxUniStreamNum:                 protocol.StreamNum(s.config.MaxIncomingUniStreams),
 		MaxAckDelay:                     protocol.MaxAckDelayInclGranularity,
 		AckDelayExponent:                protocol.AckDelayExponent,
-		DisableActiveMigration:          true,
 		StatelessResetToken:             &statelessResetToken,
 		OriginalDestinationConnectionID: origDestConnID,
 		// For interoperability with quic-go versions before May 2023, this value must be set to a value
@@ -305,12 +322,22 @@ var newConnection = func(
 		ActiveConnectionIDLimit:   protocol.MaxActiveConnectionIDs,
 		InitialSourceConnectionID: srcConnID,
 		RetrySourceConnectionID:   retrySrcConnID,
+		EnableMultipath:           s.enableMultipath(),
 	}
 	if s.config.EnableDatagrams {
 		params.MaxDatagramFrameSize = protocol.MaxDatagramFrameSize
 	} else {
 		params.MaxDatagramFrameSize = protocol.InvalidByteCount
 	}
+	// A server that uses zero-length connection IDs can't send a preferred_address.
+	if s.config.PreferredAddress != nil && srcConnID.Len() > 0 {
+		pa, err := s.newPreferredAddress(s.config.PreferredAddress)
+		if err != nil {
+			s.logger.Errorf("Not sending the preferred_address: %s", err)
+		} else {
+			params.PreferredAddress = pa
+		}
+	}
 	if s.tracer != nil && s.tracer.SentTransportParameters != nil {
 		s.tracer.SentTransportParameters(params)
 	}
@@ -382,12 +409,19 @@ var newClientConnection = func(
 	)
 	s.preSetup()
 	s.ctx, s.ctxCancel = context.WithCancelCause(context.WithValue(context.Background(), ConnectionTracingKey, tracingID))
+	s.pathManagerOutgoing = newPathManagerOutgoing(
+		s.connIDManager.GetConnIDForPath,
+		s.connIDManager.RetireConnIDForPath,
+		s.scheduleSending,
+		s.ctx.Done(),
+	)
 	s.sentPacketHandler, s.receivedPacketHandler = ackhandler.NewAckHandler(
 		initialPacketNumber,
 		getMaxPacketSize(s.conn.RemoteAddr()),
 		s.rttStats,
 		false, // has no effect
 		s.conn.capabilities().ECN,
+		s.config.CongestionControl,
 		s.perspective,
 		s.tracer,
 		s.logger,
@@ -412,6 +446,7 @@ var newClientConnection = func(
 		// See https://github.com/quic-go/quic-go/pull/3806.
 		ActiveConnectionIDLimit:   protocol.MaxActiveConnectionIDs,
 		InitialSourceConnectionID: srcConnID,
+		EnableMultipath:           s.enableMultipath(),
 	}
 	if s.config.EnableDatagrams {
 		params.MaxDatagramFrameSize = protocol.MaxDatagramFrameSize
@@ -499,6 +534,8 @@ func (s *connection) run() error {
 	}()
 
 	s.timer = *newTimer()
+	s.connIDGenerator.AddPreferredAddressConnID()
+	s.updateStats()
 
 	if err := s.cryptoStreamHandler.StartHandshake(); err != nil {
 		return err
@@ -506,11 +543,7 @@ func (s *connection) run() error {
 	if err := s.handleHandshakeEvents(); err != nil {
 		return err
 	}
-	go func() {
-		if err := s.sendQueue.Run(); err != nil {
-			s.destroyImpl(err)
-		}
-	}()
+	s.runSendQueue(s.sendQueue)
 
 	if s.perspective == protocol.PerspectiveClient {
 		s.scheduleSending() // so the ClientHello actually gets sent
@@ -527,6 +560,8 @@ runLoop:
 		default:
 		}
 
+		// Update the statistics before waiting for the next event.
+		s.updateStats()
 		s.maybeResetTimer()
 
 		var processedUndecryptablePacket bool
@@ -605,6 +640,17 @@ runLoop:
 				s.closeLocal(err)
 			}
 		}
+		if s.multipath != nil {
+			if err := s.multipath.OnLossDetectionTimeouts(now); err != nil {
+				s.closeLocal(err)
+			}
+		}
+
+		if s.pathManager != nil {
+			if deadline := s.pathManager.ValidationDeadline(); !deadline.IsZero() && !now.Before(deadline) {
+				s.handlePathValidationTimeout(now)
+			}
+		}
 
 		if keepAliveTime := s.nextKeepAliveTime(); !keepAliveTime.IsZero() && !now.Before(keepAliveTime) {
 			// send a PING frame since there is no activity in the connection
@@ -641,7 +687,11 @@ runLoop:
 
 	s.cryptoStreamHandler.Close()
 	s.sendQueue.Close() // close the send queue before sending the CONNECTION_CLOSE
+	if s.multipath != nil {
+		s.multipath.Close()
+	}
 	s.handleCloseError(&closeErr)
+	s.updateStats()
 	if s.tracer != nil && s.tracer.Close != nil {
 		if e := (&errCloseForRecreating{}); !errors.As(closeErr.err, &e) {
 			s.tracer.Close()
@@ -652,6 +702,14 @@ runLoop:
 	return closeErr.err
 }
 
+func (s *connection) runSendQueue(sendQueue sender) {
+	go func() {
+		if err := sendQueue.Run(); err != nil {
+			s.destroyImpl(err)
+		}
+	}()
+}
+
 // blocks until the early connection can be used
 func (s *connection) earlyConnReady() <-chan struct{} {
 	return s.earlyConnReadyChan
@@ -669,6 +727,12 @@ func (s *connection) supportsDatagrams() bool {
 	return s.peerParams.MaxDatagramFrameSize > 0
 }
 
+// enableMultipath says if we offer the use of multipath.
+// Multipath can't be used with zero-length connection IDs, since the connection ID identifies the path.
+func (s *connection) enableMultipath() bool {
+	return s.config.EnableMultipath && s.srcConnIDLen > 0
+}
+
 func (s *connection) ConnectionState() ConnectionState {
 	s.connStateMutex.Lock()
 	defer s.connStateMutex.Unlock()
@@ -695,6 +759,60 @@ func (s *connection) nextKeepAliveTime() time.Time {
 	return s.lastPacketReceivedTime.Add(keepAliveInterval)
 }
 
+// Stats returns the statistics of the connection.
+// It returns the snapshot taken by the run loop, so it doesn't block on the run loop.
+func (s *connection) Stats() ConnectionStats {
+	if stats := s.stats.Load(); stats != nil {
+		return *stats
+	}
+	return ConnectionStats{}
+}
+
+// updateStats takes a snapshot of the statistics.
+// It must only be called from the run loop.
+func (s *connection) updateStats() {
+	stats := s.getStats()
+	s.stats.Store(&stats)
+}
+
+func (s *connection) getStats() ConnectionStats {
+	sentStats := s.sentPacketHandler.Stats()
+	stats := ConnectionStats{
+		MinRTT:               s.rttStats.MinRTT(),
+		LatestRTT:            s.rttStats.LatestRTT(),
+		SmoothedRTT:          s.rttStats.SmoothedRTT(),
+		MeanDeviation:        s.rttStats.MeanDeviation(),
+		CongestionWindow:     uint64(sentStats.CongestionWindow),
+		BytesInFlight:        uint64(sentStats.BytesInFlight),
+		PacketsSent:          sentStats.PacketsSent,
+		BytesSent:            uint64(sentStats.BytesSent),
+		PacketsReceived:      s.packetsReceived,
+		BytesReceived:        uint64(s.bytesReceived),
+		PacketsLost:          sentStats.PacketsLost,
+		BytesLost:            uint64(sentStats.BytesLost),
+		PacketsRetransmitted: sentStats.PacketsRetransmitted,
+		PTOCount:             sentStats.PTOCount,
+	}
+	if s.multipath != nil {
+		for _, p := range s.multipath.Paths() {
+			pathStats := p.sentPacketHandler.Stats()
+			stats.PacketsSent += pathStats.PacketsSent
+			stats.BytesSent += uint64(pathStats.BytesSent)
+			stats.PacketsLost += pathStats.PacketsLost
+			stats.BytesLost += uint64(pathStats.BytesLost)
+			stats.PacketsRetransmitted += pathStats.PacketsRetransmitted
+			stats.PTOCount += pathStats.PTOCount
+		}
+	}
+	stats.MTU = uint64(s.mtuDiscoverer.CurrentSize())
+	fcStats := s.connFlowController.Stats()
+	stats.StreamDataSent = uint64(fcStats.BytesSent)
+	stats.SendWindow = uint64(fcStats.SendWindow)
+	stats.StreamDataReceived = uint64(fcStats.HighestReceived)
+	stats.ReceiveWindow = uint64(fcStats.ReceiveWindow)
+	return stats
+}
+
 func (s *connection) maybeResetTimer() {
 	var deadline time.Time
 	if !s.handshakeComplete {
@@ -708,6 +826,12 @@ func (s *connection) maybeResetTimer() {
 		} else {
 			deadline = s.nextIdleTimeoutTime()
 		}
+		if s.pathManager != nil {
+			deadline = utils.MinNonZeroTime(deadline, s.pathManager.ValidationDeadline())
+		}
+		if s.multipath != nil {
+			deadline = utils.MinNonZeroTime(deadline, s.multipath.NextTimeout(time.Now()))
+		}
 	}
 
 	s.timer.SetTimer(
@@ -771,6 +895,17 @@ func (s *connection) handleHandshakeConfirmed() error {
 	s.sentPacketHandler.SetHandshakeConfirmed()
 	s.cryptoStreamHandler.SetHandshakeConfirmed()
 
+	s.maybeStartMTUDiscovery()
+	// The client migrates to the server's preferred address once the handshake is confirmed,
+	// see section 9.6.1 of RFC 9000.
+	// Multipath connections don't migrate, the client keeps using the initial path.
+	if s.perspective == protocol.PerspectiveClient && s.peerParams.PreferredAddress != nil && s.multipath == nil {
+		s.migrateToPreferredAddress(s.peerParams.PreferredAddress)
+	}
+	return nil
+}
+
+func (s *connection) maybeStartMTUDiscovery() {
 	if !s.config.DisablePathMTUDiscovery && s.conn.capabilities().DF {
 		maxPacketSize := s.peerParams.MaxUDPPayloadSize
 		if maxPacketSize == 0 {
@@ -778,11 +913,16 @@ func (s *connection) handleHandshakeConfirmed() error {
 		}
 		s.mtuDiscoverer.Start(utils.Min(maxPacketSize, protocol.MaxPacketBufferSize))
 	}
-	return nil
 }
 
 func (s *connection) handlePacketImpl(rp receivedPacket) bool {
-	s.sentPacketHandler.ReceivedBytes(rp.Size())
+	// Packets received on a different path don't count towards the anti-amplification limit of the current path.
+	// If the client migrated, the packet is accounted for once the connection switched to the new path.
+	if s.isOnCurrentPath(rp.remoteAddr) {
+		s.sentPacketHandler.ReceivedBytes(rp.Size())
+	} else if s.multipath != nil {
+		s.multipath.ReceivedBytes(rp.remoteAddr, rp.Size())
+	}
 
 	if wire.IsVersionNegotiationPacket(rp.data) {
 		s.handleVersionNegotiationPacket(rp)
@@ -880,7 +1020,17 @@ func (s *connection) handleShortHeaderPacket(p receivedPacket, destConnID protoc
 		}
 	}()
 
-	pn, pnLen, keyPhase, data, err := s.unpacker.UnpackShortHeader(p.rcvTime, p.data)
+	rcvPathID := s.receivePathID(p.data)
+	var pn protocol.PacketNumber
+	var pnLen protocol.PacketNumberLen
+	var keyPhase protocol.KeyPhaseBit
+	var data []byte
+	var err error
+	if rcvPathID == 0 {
+		pn, pnLen, keyPhase, data, err = s.unpacker.UnpackShortHeader(p.rcvTime, p.data)
+	} else {
+		pn, pnLen, keyPhase, data, err = s.unpacker.UnpackShortHeaderOnPath(p.rcvTime, p.data, rcvPathID)
+	}
 	if err != nil {
 		wasQueued = s.handleUnpackError(err, p, logging.PacketType1RTT)
 		return false
@@ -891,13 +1041,19 @@ func (s *connection) handleShortHeaderPacket(p receivedPacket, destConnID protoc
 		wire.LogShortHeader(s.logger, destConnID, pn, pnLen, keyPhase)
 	}
 
-	if s.receivedPacketHandler.IsPotentiallyDuplicate(pn, protocol.Encryption1RTT) {
+	rph := s.receivedPacketHandler
+	if rcvPathID != 0 {
+		rph = s.multipath.ReceivedPacketHandler(rcvPathID, p.remoteAddr)
+	}
+	if rph.IsPotentiallyDuplicate(pn, protocol.Encryption1RTT) {
 		s.logger.Debugf("Dropping (potentially) duplicate packet.")
 		if s.tracer != nil && s.tracer.DroppedPacket != nil {
 			s.tracer.DroppedPacket(logging.PacketType1RTT, pn, p.Size(), logging.PacketDropDuplicate)
 		}
 		return false
 	}
+	s.packetsReceived++
+	s.bytesReceived += p.Size()
 
 	var log func([]logging.Frame)
 	if s.tracer != nil && s.tracer.ReceivedShortHeaderPacket != nil {
@@ -915,13 +1071,46 @@ func (s *connection) handleShortHeaderPacket(p receivedPacket, destConnID protoc
 			)
 		}
 	}
-	if err := s.handleUnpackedShortHeaderPacket(destConnID, pn, data, p.ecn, p.rcvTime, log); err != nil {
+	isNonProbing, err := s.handleUnpackedShortHeaderPacket(destConnID, rph, pn, data, p.ecn, p.rcvTime, p.remoteAddr, p.info, log)
+	if err != nil {
 		s.closeLocal(err)
 		return false
 	}
+	// The client's address might have changed, either because it migrated, or because of a NAT rebinding.
+	// Only a non-probing packet with the highest packet number moves the connection to the new path,
+	// see section 9.3 of RFC 9000.
+	// If the client migrated to the server's preferred address, only the local address changes.
+	// On multipath connections, packets received on additional paths don't change the initial path.
+	if s.pathManager != nil && rcvPathID == 0 && isNonProbing && s.handshakeConfirmed && s.pathManager.ReceivedNonProbingPacket(pn) {
+		if !s.isOnCurrentPath(p.remoteAddr) || !s.isOnCurrentLocalAddr(p.info) {
+			if err := s.handlePeerAddressChange(p); err != nil {
+				s.closeLocal(err)
+			}
+		}
+	}
+	if rcvPathID != 0 && s.perspective == protocol.PerspectiveServer {
+		if err := s.maybeRetryPathValidation(p.remoteAddr, p.rcvTime); err != nil {
+			s.closeLocal(err)
+		}
+	}
 	return true
 }
 
+// receivePathID returns the path that a short header packet was received on.
+// On multipath connections, this is the sequence number of the packet's destination connection ID.
+// Packets received on the initial path, and all packets received on other connections, use path 0.
+func (s *connection) receivePathID(data []byte) uint64 {
+	if s.multipath == nil {
+		return 0
+	}
+	connID, err := wire.ParseConnectionID(data, s.srcConnIDLen)
+	if err != nil {
+		return 0
+	}
+	seq, _ := s.connIDGenerator.SequenceNumber(connID)
+	return seq
+}
+
 func (s *connection) handleLongHeaderPacket(p receivedPacket, hdr *wire.Header) bool /* was the packet successfully processed */ {
 	var wasQueued bool
 
@@ -1192,6 +1381,8 @@ func (s *connection) handleUnpackedLongHeaderPacket(
 	s.lastPacketReceivedTime = rcvTime
 	s.firstAckElicitingPacketAfterIdleSentTime = time.Time{}
 	s.keepAlivePingSent = false
+	s.packetsReceived++
+	s.bytesReceived += packetSize
 
 	var log func([]logging.Frame)
 	if s.tracer != nil && s.tracer.ReceivedLongHeaderPacket != nil {
@@ -1199,30 +1390,41 @@ func (s *connection) handleUnpackedLongHeaderPacket(
 			s.tracer.ReceivedLongHeaderPacket(packet.hdr, packetSize, ecn, frames)
 		}
 	}
-	isAckEliciting, err := s.handleFrames(packet.data, packet.hdr.DestConnectionID, packet.encryptionLevel, log)
+	isAckEliciting, _, pathChallenge, err := s.handleFrames(packet.data, packet.hdr.DestConnectionID, packet.encryptionLevel, log)
 	if err != nil {
 		return err
 	}
+	if pathChallenge != nil {
+		s.handlePathChallengeFrame(pathChallenge)
+	}
 	return s.receivedPacketHandler.ReceivedPacket(packet.hdr.PacketNumber, ecn, packet.encryptionLevel, rcvTime, isAckEliciting)
 }
 
 func (s *connection) handleUnpackedShortHeaderPacket(
 	destConnID protocol.ConnectionID,
+	rph ackhandler.ReceivedPacketHandler,
 	pn protocol.PacketNumber,
 	data []byte,
 	ecn protocol.ECN,
 	rcvTime time.Time,
+	remoteAddr net.Addr,
+	info packetInfo,
 	log func([]logging.Frame),
-) error {
+) (isNonProbing bool, _ error) {
 	s.lastPacketReceivedTime = rcvTime
 	s.firstAckElicitingPacketAfterIdleSentTime = time.Time{}
 	s.keepAlivePingSent = false
 
-	isAckEliciting, err := s.handleFrames(data, destConnID, protocol.Encryption1RTT, log)
+	isAckEliciting, isNonProbing, pathChallenge, err := s.handleFrames(data, destConnID, protocol.Encryption1RTT, log)
 	if err != nil {
-		return err
+		return false, err
 	}
-	return s.receivedPacketHandler.ReceivedPacket(pn, ecn, protocol.Encryption1RTT, rcvTime, isAckEliciting)
+	if pathChallenge != nil {
+		if err := s.handlePathChallengeOnPath(pathChallenge, remoteAddr, info, rcvTime); err != nil {
+			return false, err
+		}
+	}
+	return isNonProbing, rph.ReceivedPacket(pn, ecn, protocol.Encryption1RTT, rcvTime, isAckEliciting)
 }
 
 func (s *connection) handleFrames(
@@ -1230,7 +1432,7 @@ func (s *connection) handleFrames(
 	destConnID protocol.ConnectionID,
 	encLevel protocol.EncryptionLevel,
 	log func([]logging.Frame),
-) (isAckEliciting bool, _ error) {
+) (isAckEliciting, isNonProbing bool, pathChallenge *wire.PathChallengeFrame, _ error) {
 	// Only used for tracing.
 	// If we're not tracing, this slice will always remain empty.
 	var frames []logging.Frame
@@ -1242,7 +1444,7 @@ func (s *connection) handleFrames(
 	for len(data) > 0 {
 		l, frame, err := s.frameParser.ParseNext(data, encLevel, s.version)
 		if err != nil {
-			return false, err
+			return false, false, nil, err
 		}
 		data = data[l:]
 		if frame == nil {
@@ -1251,6 +1453,12 @@ func (s *connection) handleFrames(
 		if ackhandler.IsFrameAckEliciting(frame) {
 			isAckEliciting = true
 		}
+		// see section 9.1 of RFC 9000 (PADDING frames are not returned by the frame parser)
+		switch frame.(type) {
+		case *wire.PathChallengeFrame, *wire.PathResponseFrame, *wire.NewConnectionIDFrame:
+		default:
+			isNonProbing = true
+		}
 		if log != nil {
 			frames = append(frames, logutils.ConvertFrame(frame))
 		}
@@ -1259,9 +1467,16 @@ func (s *connection) handleFrames(
 		if handleErr != nil {
 			continue
 		}
+		// PATH_CHALLENGE frames need to be answered on the path they were received on.
+		// This is up to the caller.
+		if f, ok := frame.(*wire.PathChallengeFrame); ok {
+			wire.LogFrame(s.logger, f, false)
+			pathChallenge = f
+			continue
+		}
 		if err := s.handleFrame(frame, encLevel, destConnID); err != nil {
 			if log == nil {
-				return false, err
+				return false, false, nil, err
 			}
 			// If we're logging, we need to keep parsing (but not handling) all frames.
 			handleErr = err
@@ -1271,7 +1486,7 @@ func (s *connection) handleFrames(
 	if log != nil {
 		log(frames)
 		if handleErr != nil {
-			return false, handleErr
+			return false, false, nil, handleErr
 		}
 	}
 
@@ -1281,7 +1496,7 @@ func (s *connection) handleFrames(
 	// and an ACK serialized after that CRYPTO frame. In this case, we still want to process the ACK frame.
 	if !handshakeWasComplete && s.handshakeComplete {
 		if err := s.handleHandshakeComplete(); err != nil {
-			return false, err
+			return false, false, nil, err
 		}
 	}
 
@@ -1317,8 +1532,7 @@ func (s *connection) handleFrame(f wire.Frame, encLevel protocol.EncryptionLevel
 	case *wire.PathChallengeFrame:
 		s.handlePathChallengeFrame(frame)
 	case *wire.PathResponseFrame:
-		// since we don't send PATH_CHALLENGEs, we don't expect PATH_RESPONSEs
-		err = errors.New("unexpected PATH_RESPONSE frame")
+		s.handlePathResponseFrame(frame)
 	case *wire.NewTokenFrame:
 		err = s.handleNewTokenFrame(frame)
 	case *wire.NewConnectionIDFrame:
@@ -1329,6 +1543,12 @@ func (s *connection) handleFrame(f wire.Frame, encLevel protocol.EncryptionLevel
 		err = s.handleHandshakeDoneFrame()
 	case *wire.DatagramFrame:
 		err = s.handleDatagramFrame(frame)
+	case *wire.PathAckFrame:
+		err = s.handlePathAckFrame(frame)
+	case *wire.PathAbandonFrame:
+		s.handlePathAbandonFrame(frame)
+	case *wire.PathStatusFrame:
+		s.multipath.HandlePathStatusFrame(frame)
 	default:
 		err = fmt.Errorf("unexpected frame type: %s", reflect.ValueOf(&frame).Elem().Type().Name())
 	}
@@ -1468,6 +1688,130 @@ func (s *connection) handlePathChallengeFrame(frame *wire.PathChallengeFrame) {
 	s.queueControlFrame(&wire.PathResponseFrame{Data: frame.Data})
 }
 
+// handlePathChallengeOnPath responds to a PATH_CHALLENGE on the path it was received on.
+// If it wasn't received on the active path, the PATH_RESPONSE is sent right away,
+// in a packet padded to 1200 bytes.
+func (s *connection) handlePathChallengeOnPath(frame *wire.PathChallengeFrame, remoteAddr net.Addr, info packetInfo, now time.Time) error {
+	if s.isOnCurrentPath(remoteAddr) {
+		s.handlePathChallengeFrame(frame)
+		return nil
+	}
+	if s.multipath != nil && s.perspective == protocol.PerspectiveServer {
+		return s.handlePathChallengeMultipath(frame, remoteAddr, info, now)
+	}
+	connID, ok := s.connIDForAddr(remoteAddr, now)
+	if !ok {
+		s.logger.Debugf("Not responding to PATH_CHALLENGE from %s: the client didn't provide an unused connection ID", remoteAddr)
+		return nil
+	}
+	buf, err := s.packPathProbePacket(connID, ackhandler.Frame{Frame: &wire.PathResponseFrame{Data: frame.Data}}, now)
+	if err != nil {
+		return err
+	}
+	defer buf.Release()
+	if err := s.conn.WriteTo(buf.Data, remoteAddr); err != nil {
+		s.logger.Debugf("Sending PATH_RESPONSE to %s failed: %s", remoteAddr, err)
+	}
+	return nil
+}
+
+// connIDForAddr returns the connection ID used for sending to a remote address other than the address of the current path.
+// Using the connection ID of the current path would allow an observer to link the two paths, see section 9.5 of RFC 9000.
+// It returns false if the peer hasn't provided an unused connection ID.
+func (s *connection) connIDForAddr(addr net.Addr, now time.Time) (protocol.ConnectionID, bool) {
+	if s.pathManager == nil {
+		return s.connIDManager.Get(), true
+	}
+	// If the client didn't migrate to an address it probed, the validation of that path failed or timed out.
+	for _, id := range s.pathManager.RemoveExpiredPathIDs(now) {
+		s.connIDManager.RetireConnIDForPath(id)
+	}
+	// The client validates the path within three PTOs, see section 8.2.4 of RFC 9000.
+	// Every PATH_CHALLENGE it sends extends the lifetime of the path.
+	id, ok := s.pathManager.PathIDForAddr(addr, now.Add(3*s.rttStats.PTO(false)))
+	if !ok {
+		return protocol.ConnectionID{}, false
+	}
+	return s.connIDManager.GetConnIDForPath(id)
+}
+
+// handlePathChallengeMultipath is called on the server when a PATH_CHALLENGE is received from a new address of the client.
+// On a multipath connection, this means that the client probes a new path.
+// The server opens a path to the client's address, and validates it.
+func (s *connection) handlePathChallengeMultipath(frame *wire.PathChallengeFrame, remoteAddr net.Addr, info packetInfo, now time.Time) error {
+	path, ok := s.multipath.PathForRemoteAddr(remoteAddr)
+	if !ok {
+		id := s.multipath.NextOutgoingID()
+		connID, ok := s.connIDManager.GetConnIDForPath(id)
+		if !ok {
+			s.logger.Debugf("Not opening a path to %s: the client didn't provide an unused connection ID", remoteAddr)
+			return nil
+		}
+		seq, _ := s.connIDManager.GetSequenceNumberForPath(id)
+		path = s.multipath.AddPath(seq, connID, id, s.conn.WithRemoteAddr(remoteAddr, info))
+		s.logger.Debugf("Opened path %d to %s", path.id, remoteAddr)
+		if s.tracer != nil && s.tracer.StartedPathValidation != nil {
+			s.tracer.StartedPathValidation(remoteAddr)
+		}
+	}
+	frames := []ackhandler.Frame{{Frame: &wire.PathResponseFrame{Data: frame.Data}}}
+	// The client retransmits its PATH_CHALLENGE until it receives the PATH_RESPONSE.
+	// Every time, a new PATH_CHALLENGE is sent, until the path is validated.
+	if !path.validated {
+		frames = append(frames, ackhandler.Frame{Frame: s.multipath.NewPathChallenge(path, now)})
+	}
+	return s.sendPathProbePacketOnPath(path, frames, now)
+}
+
+// maybeRetryPathValidation is called on the server when a packet is received on an additional path.
+// If the path to the client's address wasn't validated within a PTO, a new PATH_CHALLENGE is sent.
+func (s *connection) maybeRetryPathValidation(remoteAddr net.Addr, now time.Time) error {
+	path, ok := s.multipath.PathForRemoteAddr(remoteAddr)
+	if !ok || path.validated || now.Sub(path.pathChallengeSent) < s.rttStats.PTO(false) {
+		return nil
+	}
+	return s.sendPathProbePacketOnPath(path, []ackhandler.Frame{{Frame: s.multipath.NewPathChallenge(path, now)}}, now)
+}
+
+// sendPathProbePacketOnPath sends a path probe packet on an additional path of a multipath connection.
+// Path probe packets are not congestion controlled.
+func (s *connection) sendPathProbePacketOnPath(path *multipathPath, frames []ackhandler.Frame, now time.Time) error {
+	if path.sendQueue.WouldBlock() {
+		return nil
+	}
+	p, buf, err := s.packer.PackPathProbePacketOnPath(path.packerPath(), frames, s.version)
+	if err != nil {
+		return err
+	}
+	s.logShortHeaderPacket(p.DestConnID, p.Ack, p.Frames, p.StreamFrames, p.PacketNumber, p.PacketNumberLen, p.KeyPhase, protocol.ECNUnsupported, buf.Len(), false)
+	path.sentPacketHandler.SentPacket(now, p.PacketNumber, protocol.InvalidPacketNumber, p.StreamFrames, p.Frames, protocol.Encryption1RTT, protocol.ECNUnsupported, p.Length, false, true)
+	path.sendQueue.Send(buf, 0, protocol.ECNUnsupported)
+	return nil
+}
+
+func (s *connection) handlePathResponseFrame(frame *wire.PathResponseFrame) {
+	// A PATH_RESPONSE that doesn't match a PATH_CHALLENGE we sent is ignored.
+	if s.pathManagerOutgoing != nil {
+		s.pathManagerOutgoing.HandlePathResponseFrame(frame)
+	}
+	if s.multipath != nil {
+		if path, ok := s.multipath.HandlePathResponseFrame(frame); ok {
+			s.logger.Debugf("Validated path %d to %s", path.id, path.conn.RemoteAddr())
+			if s.tracer != nil && s.tracer.CompletedPathValidation != nil {
+				s.tracer.CompletedPathValidation(path.conn.RemoteAddr(), true)
+			}
+		}
+	}
+	if s.pathManager != nil && s.pathManager.HandlePathResponseFrame(frame) {
+		s.logger.Debugf("Validated path to %s", s.conn.RemoteAddr())
+		s.validatedPath = nil
+		s.sentPacketHandler.SetPathValidated()
+		if s.tracer != nil && s.tracer.CompletedPathValidation != nil {
+			s.tracer.CompletedPathValidation(s.conn.RemoteAddr(), true)
+		}
+	}
+}
+
 func (s *connection) handleNewTokenFrame(frame *wire.NewTokenFrame) error {
 	if s.perspective == protocol.PerspectiveServer {
 		return &qerr.TransportError{
@@ -1521,6 +1865,58 @@ func (s *connection) handleAckFrame(frame *wire.AckFrame, encLevel protocol.Encr
 	return s.cryptoStreamHandler.SetLargest1RTTAcked(frame.LargestAcked())
 }
 
+// handlePathAckFrame handles a PATH_ACK frame.
+// It acknowledges packets sent on the path identified by the sequence number of the connection ID used on that path.
+func (s *connection) handlePathAckFrame(frame *wire.PathAckFrame) error {
+	if frame.PathID == 0 {
+		return s.handleAckFrame(&frame.AckFrame, protocol.Encryption1RTT)
+	}
+	path, ok := s.multipath.Path(frame.PathID)
+	if !ok { // the path might already have been abandoned
+		return nil
+	}
+	_, err := path.sentPacketHandler.ReceivedAck(&frame.AckFrame, protocol.Encryption1RTT, s.lastPacketReceivedTime)
+	return err
+}
+
+// handlePathAbandonFrame handles a PATH_ABANDON frame.
+// The peer stopped sending packets on the path.
+// If the server can determine the path it sends to the client on the same 4-tuple, it abandons this path as well.
+// The initial path can't be abandoned.
+func (s *connection) handlePathAbandonFrame(frame *wire.PathAbandonFrame) {
+	if frame.PathID == 0 {
+		return
+	}
+	if path, ok := s.multipath.SendPathForReceivePath(frame.PathID); ok {
+		s.abandonPath(path, s.lastPacketReceivedTime)
+	}
+	s.multipath.DropReceivePath(frame.PathID)
+}
+
+// abandonPath stops using a path, and informs the peer using a PATH_ABANDON frame.
+// The connection ID used on the path is retired.
+func (s *connection) abandonPath(path *multipathPath, now time.Time) {
+	s.logger.Debugf("Abandoning path %d to %s", path.id, path.conn.RemoteAddr())
+	s.queueControlFrame(&wire.PathAbandonFrame{PathID: path.id})
+	s.connIDManager.RetireConnIDForPath(path.outgoingID)
+	s.multipath.AbandonPath(path, now)
+}
+
+// setPathStatus puts a path into standby mode, or makes it available again.
+// The peer is informed using a PATH_STATUS frame.
+func (s *connection) setPathStatus(path *multipathPath, standby bool) {
+	path.standby = standby
+	status := wire.PathStatusAvailable
+	if standby {
+		status = wire.PathStatusStandby
+	}
+	s.queueControlFrame(&wire.PathStatusFrame{
+		PathID:         path.id,
+		SequenceNumber: s.multipath.NextStatusSequenceNumber(),
+		Status:         status,
+	})
+}
+
 func (s *connection) handleDatagramFrame(f *wire.DatagramFrame) error {
 	if f.Length(s.version) > protocol.MaxDatagramFrameSize {
 		return &qerr.TransportError{
@@ -1726,6 +2122,9 @@ func (s *connection) checkTransportParameters(params *wire.TransportParameters)
 	if params.InitialSourceConnectionID != s.handshakeDestConnID {
 		return fmt.Errorf("expected initial_source_connection_id to equal %s, is %s", s.handshakeDestConnID, params.InitialSourceConnectionID)
 	}
+	if params.EnableMultipath && params.InitialSourceConnectionID.Len() == 0 {
+		return errors.New("enable_multipath used with a zero-length connection ID")
+	}
 
 	if s.perspective == protocol.PerspectiveServer {
 		return nil
@@ -1760,17 +2159,56 @@ func (s *connection) applyTransportParameters() {
 	if params.StatelessResetToken != nil {
 		s.connIDManager.SetStatelessResetToken(*params.StatelessResetToken)
 	}
-	// We don't support connection migration yet, so we don't have any use for the preferred_address.
+	// The connection ID is used to probe the preferred address once the handshake is confirmed.
 	if params.PreferredAddress != nil {
-		// Retire the connection ID.
 		s.connIDManager.AddFromPreferredAddress(params.PreferredAddress.ConnectionID, params.PreferredAddress.StatelessResetToken)
 	}
+	if s.enableMultipath() && params.EnableMultipath {
+		s.startMultipath()
+	}
+}
+
+// startMultipath is called when both endpoints enabled multipath.
+func (s *connection) startMultipath() {
+	s.logger.Debugf("Multipath negotiated")
+	s.multipath = newMultipathManager(s.perspective, s.rttStats, func(conn sendConn) sender {
+		q := newSendQueue(conn)
+		s.runSendQueue(q)
+		return q
+	}, s.config.CongestionControl, s.logger)
+	s.frameParser.EnableMultipath()
+	s.packer.EnableMultipath(s.multipath)
+	s.connIDManager.SetMultipath()
+	if s.pathManagerOutgoing != nil {
+		s.pathManagerOutgoing.SetMultipath()
+	}
+	s.connStateMutex.Lock()
+	s.connState.Multipath = true
+	s.connStateMutex.Unlock()
 }
 
 func (s *connection) triggerSending() error {
 	s.pacingDeadline = time.Time{}
 	now := time.Now()
 
+	// Path probe packets are not congestion controlled, and can be sent independent of the send mode.
+	if s.pathManagerOutgoing != nil && s.handshakeConfirmed {
+		if err := s.handleOutgoingPaths(now); err != nil {
+			return err
+		}
+	}
+	// On multipath connections, the path scheduler decides which path a packet is sent on.
+	// Sending of packets that can only be sent on the initial path is handled below.
+	if s.multipath != nil && s.handshakeConfirmed {
+		if err := s.sendOnPaths(now); err != nil {
+			return err
+		}
+		// The run loop triggers sending again once the send queue has space available.
+		if s.sendQueue.WouldBlock() {
+			return nil
+		}
+	}
+
 	sendMode := s.sentPacketHandler.SendMode(now)
 	//nolint:exhaustive // No need to handle pacing limited here.
 	switch sendMode {
@@ -1825,6 +2263,265 @@ func (s *connection) triggerSending() error {
 	}
 }
 
+func (s *connection) handleOutgoingPaths(now time.Time) error {
+	for _, id := range s.pathManagerOutgoing.ClosedPaths() {
+		if s.multipath != nil {
+			if path, ok := s.multipath.PathForOutgoingID(id); ok {
+				s.abandonPath(path, now)
+			}
+		}
+		s.connIDGenerator.RemoveConnRunner(id)
+	}
+	for _, u := range s.pathManagerOutgoing.StatusUpdates() {
+		if path, ok := s.multipath.PathForOutgoingID(u.id); ok {
+			s.setPathStatus(path, u.standby)
+		}
+	}
+	if id, conn, ok := s.pathManagerOutgoing.ShouldSwitchPath(); ok {
+		s.switchToPath(id, conn, now)
+	}
+	for {
+		id, connID, frame, conn, ok := s.pathManagerOutgoing.NextPathToProbe()
+		if !ok {
+			return nil
+		}
+		if s.multipath != nil {
+			if err := s.probePathMultipath(id, connID, frame, conn, now); err != nil {
+				return err
+			}
+			continue
+		}
+		buf, err := s.packPathProbePacket(connID, frame, now)
+		if err != nil {
+			return err
+		}
+		if err := conn.Write(buf.Data, 0, protocol.ECNUnsupported); err != nil {
+			s.logger.Debugf("Sending path probe packet from %s failed: %s", conn.LocalAddr(), err)
+		}
+		buf.Release()
+	}
+}
+
+// probePathMultipath probes a path on a multipath connection.
+// Every path uses its own packet number space, so the path is created when it is probed for the first time.
+// It can be used for sending as soon as it is validated.
+func (s *connection) probePathMultipath(id pathID, connID protocol.ConnectionID, frame ackhandler.Frame, conn sendConn, now time.Time) error {
+	path, ok := s.multipath.PathForOutgoingID(id)
+	if !ok {
+		seq, _ := s.connIDManager.GetSequenceNumberForPath(id)
+		path = s.multipath.AddPath(seq, connID, id, conn)
+	}
+	path.pathChallenges = append(path.pathChallenges, frame.Frame.(*wire.PathChallengeFrame).Data)
+	return s.sendPathProbePacketOnPath(path, []ackhandler.Frame{frame}, now)
+}
+
+// switchToPath switches to a new path.
+// The RTT estimate and the congestion controller are reset, as are the MTU discovery state.
+func (s *connection) switchToPath(id pathID, conn sendConn, now time.Time) {
+	s.logger.Debugf("Switching to path %d (local address: %s)", id, conn.LocalAddr())
+	s.connIDManager.SwitchToPath(id)
+	// the path was validated by probing it
+	s.migrateToPath(conn, true, false, now)
+}
+
+// newPreferredAddress generates the preferred_address transport parameter.
+func (s *connection) newPreferredAddress(conf *PreferredAddress) (*wire.PreferredAddress, error) {
+	connID, resetToken, err := s.connIDGenerator.GenerateForPreferredAddress()
+	if err != nil {
+		return nil, err
+	}
+	// An unset address family is sent as all zeros, see section 18.2 of RFC 9000.
+	pa := &wire.PreferredAddress{
+		IPv4:                net.IPv4zero,
+		IPv6:                net.IPv6zero,
+		ConnectionID:        connID,
+		StatelessResetToken: resetToken,
+	}
+	if conf.IPv4.IsValid() {
+		pa.IPv4 = conf.IPv4.Addr().AsSlice()
+		pa.IPv4Port = conf.IPv4.Port()
+	}
+	if conf.IPv6.IsValid() {
+		pa.IPv6 = conf.IPv6.Addr().AsSlice()
+		pa.IPv6Port = conf.IPv6.Port()
+	}
+	return pa, nil
+}
+
+// migrateToPreferredAddress validates the server's preferred address in the background,
+// and switches to it once the validation succeeded.
+// If validation fails, the connection continues using the current path.
+func (s *connection) migrateToPreferredAddress(pa *wire.PreferredAddress) {
+	addr := preferredAddressForFamily(pa, s.conn.RemoteAddr())
+	if addr == nil {
+		s.logger.Debugf("Not migrating to preferred address: no address of the same address family")
+		return
+	}
+	s.logger.Debugf("Migrating to preferred address %s", addr)
+	path := s.pathManagerOutgoing.NewPath(
+		s.conn.WithRemoteAddr(addr, packetInfo{}),
+		func(pathID) {}, // the path uses the same Transport
+	)
+	ctx, cancel := context.WithTimeout(s.ctx, preferredAddressValidationTimeout)
+	go func() {
+		defer cancel()
+		if err := path.Probe(ctx); err != nil {
+			s.logger.Debugf("Validating preferred address %s failed: %s", addr, err)
+			path.Close()
+			return
+		}
+		if err := path.Switch(); err != nil {
+			s.logger.Debugf("Switching to preferred address %s failed: %s", addr, err)
+		}
+	}()
+}
+
+// preferredAddressForFamily returns the preferred address of the same address family as remoteAddr.
+// It returns nil if the server didn't provide an address for this address family.
+func preferredAddressForFamily(pa *wire.PreferredAddress, remoteAddr net.Addr) *net.UDPAddr {
+	udpAddr, ok := remoteAddr.(*net.UDPAddr)
+	if !ok {
+		return nil
+	}
+	var addr *net.UDPAddr
+	if udpAddr.IP.To4() != nil {
+		addr = &net.UDPAddr{IP: pa.IPv4, Port: int(pa.IPv4Port)}
+	} else {
+		addr = &net.UDPAddr{IP: pa.IPv6, Port: int(pa.IPv6Port)}
+	}
+	if addr.IP.IsUnspecified() || addr.Port == 0 {
+		return nil
+	}
+	return addr
+}
+
+// validatedPathState is the state of a validated path that is restored
+// if the connection returns to this path, see section 9.3.2 of RFC 9000.
+type validatedPathState struct {
+	conn          sendConn
+	congestion    *ackhandler.PathCongestionState // nil if the congestion state was kept when migrating
+	mtuDiscoverer mtuDiscoverer
+}
+
+// handlePeerAddressChange is called on the server when a non-probing packet is received on a new path.
+// The server starts sending to the new address right away, but needs to validate the path.
+// The PATH_CHALLENGE is sent in a packet padded to 1200 bytes, to verify that the path supports
+// QUIC-sized datagrams, see section 8.2.1 of RFC 9000.
+func (s *connection) handlePeerAddressChange(p receivedPacket) error {
+	s.logger.Debugf("Path changed from %s to %s", s.conn.RemoteAddr(), p.remoteAddr)
+	conn := s.conn.WithRemoteAddr(p.remoteAddr, p.info)
+	oldPTO := s.rttStats.PTO(false)
+	// Switch to the connection ID that was used for responding to PATH_CHALLENGE frames from this address (if any).
+	if id, ok := s.pathManager.RemovePathIDForAddr(p.remoteAddr); ok {
+		s.connIDManager.SwitchToPath(id)
+	}
+	pathChallenge := s.pathManager.Migrated(conn)
+	if pathChallenge == nil {
+		// The client returned to the last validated path.
+		s.returnToValidatedPath(conn, p.rcvTime)
+		return nil
+	}
+	// If only the port changed, this is most likely a NAT rebinding.
+	// The RTT estimate and the congestion state still apply to the new path, see section 9.4 of RFC 9000.
+	s.migrateToPath(conn, false, isPortChange(s.conn.RemoteAddr(), p.remoteAddr), p.rcvTime)
+	// This packet is the first packet received on the new path.
+	// It counts towards the anti-amplification limit.
+	s.sentPacketHandler.ReceivedBytes(p.Size())
+	// The timeout is three times the larger of the PTO of the old and of the new path, see section 8.2.4 of RFC 9000.
+	s.pathManager.SetValidationDeadline(p.rcvTime.Add(3 * utils.Max(oldPTO, s.rttStats.PTO(false))))
+	if s.tracer != nil && s.tracer.StartedPathValidation != nil {
+		s.tracer.StartedPathValidation(p.remoteAddr)
+	}
+	buf, err := s.packPathProbePacket(s.connIDManager.Get(), ackhandler.Frame{Frame: pathChallenge}, p.rcvTime)
+	if err != nil {
+		return err
+	}
+	s.sendQueue.Send(buf, 0, protocol.ECNUnsupported)
+	return nil
+}
+
+// handlePathValidationTimeout is called on the server when the path to the client's new address couldn't be validated.
+// The connection switches back to the last validated path.
+func (s *connection) handlePathValidationTimeout(now time.Time) {
+	s.logger.Debugf("Validating path to %s timed out", s.conn.RemoteAddr())
+	if s.tracer != nil && s.tracer.CompletedPathValidation != nil {
+		s.tracer.CompletedPathValidation(s.conn.RemoteAddr(), false)
+	}
+	s.returnToValidatedPath(s.pathManager.ValidationFailed(), now)
+}
+
+// migrateToPath moves the connection to a new path.
+// Unless keepCongestionState is set, the RTT estimate and the congestion controller are reset,
+// and the path MTU needs to be discovered again.
+func (s *connection) migrateToPath(conn sendConn, pathValidated, keepCongestionState bool, now time.Time) {
+	state := s.sentPacketHandler.MigratedPath(now, pathValidated, keepCongestionState)
+	if pathValidated {
+		s.validatedPath = nil
+	} else if s.validatedPath == nil {
+		// The connection is migrating away from a validated path.
+		// Its state is restored if the connection returns to it.
+		s.validatedPath = &validatedPathState{
+			conn:          s.conn,
+			congestion:    state,
+			mtuDiscoverer: s.mtuDiscoverer,
+		}
+	}
+	s.switchConn(conn)
+
+	if !keepCongestionState {
+		s.mtuDiscoverer = newMTUDiscoverer(s.rttStats, getMaxPacketSize(conn.RemoteAddr()), s.sentPacketHandler.SetMaxDatagramSize)
+		s.maybeStartMTUDiscovery()
+	}
+	if s.tracer != nil && s.tracer.UpdatedPath != nil {
+		s.tracer.UpdatedPath(conn.LocalAddr(), conn.RemoteAddr())
+	}
+}
+
+// returnToValidatedPath moves the connection back to the last validated path,
+// and restores the RTT estimate, the congestion state and the MTU of that path.
+func (s *connection) returnToValidatedPath(conn sendConn, now time.Time) {
+	state := s.validatedPath
+	if state == nil {
+		s.migrateToPath(conn, true, false, now)
+		return
+	}
+	s.validatedPath = nil
+	s.sentPacketHandler.RestorePath(now, state.congestion)
+	s.switchConn(conn)
+	s.mtuDiscoverer = state.mtuDiscoverer
+	if s.tracer != nil && s.tracer.UpdatedPath != nil {
+		s.tracer.UpdatedPath(conn.LocalAddr(), conn.RemoteAddr())
+	}
+}
+
+// switchConn starts sending packets on a new path.
+func (s *connection) switchConn(conn sendConn) {
+	// Packets queued for the old path are still sent out, but the run loop doesn't wait for this.
+	go s.sendQueue.Close()
+	s.connMutex.Lock()
+	s.conn = conn
+	s.connMutex.Unlock()
+	s.sendQueue = newSendQueue(conn)
+	s.runSendQueue(s.sendQueue)
+}
+
+// isPortChange says if only the port of the peer's address changed.
+func isPortChange(a, b net.Addr) bool {
+	udpA, okA := a.(*net.UDPAddr)
+	udpB, okB := b.(*net.UDPAddr)
+	return okA && okB && udpA.IP.Equal(udpB.IP) && udpA.Zone == udpB.Zone && udpA.Port != udpB.Port
+}
+
+func (s *connection) packPathProbePacket(connID protocol.ConnectionID, frame ackhandler.Frame, now time.Time) (*packetBuffer, error) {
+	p, buf, err := s.packer.PackPathProbePacket(connID, []ackhandler.Frame{frame}, s.version)
+	if err != nil {
+		return nil, err
+	}
+	s.logShortHeaderPacket(p.DestConnID, p.Ack, p.Frames, p.StreamFrames, p.PacketNumber, p.PacketNumberLen, p.KeyPhase, protocol.ECNUnsupported, buf.Len(), false)
+	s.sentPacketHandler.SentPacket(now, p.PacketNumber, protocol.InvalidPacketNumber, p.StreamFrames, p.Frames, protocol.Encryption1RTT, protocol.ECNUnsupported, p.Length, false, true)
+	return buf, nil
+}
+
 func (s *connection) sendPackets(now time.Time) error {
 	// Path MTU Discovery
 	// Can't use GSO, since we need to send a single packet that's larger than our current maximum size.
@@ -1845,13 +2542,7 @@ func (s *connection) sendPackets(now time.Time) error {
 		return nil
 	}
 
-	if isBlocked, offset := s.connFlowController.IsNewlyBlocked(); isBlocked {
-		s.framer.QueueControlFrame(&wire.DataBlockedFrame{MaximumData: offset})
-	}
-	s.windowUpdateQueue.QueueAll()
-	if cf := s.cryptoStreamManager.GetPostHandshakeData(protocol.MaxPostHandshakeCryptoFrameSize); cf != nil {
-		s.queueControlFrame(cf)
-	}
+	s.queuePendingControlFrames()
 
 	if !s.handshakeConfirmed {
 		packet, err := s.packer.PackCoalescedPacket(false, s.mtuDiscoverer.CurrentSize(), s.version)
@@ -1877,6 +2568,98 @@ func (s *connection) sendPackets(now time.Time) error {
 	return s.sendPacketsWithoutGSO(now)
 }
 
+// queuePendingControlFrames queues the control frames that are generated right before sending.
+func (s *connection) queuePendingControlFrames() {
+	if isBlocked, offset := s.connFlowController.IsNewlyBlocked(); isBlocked {
+		s.framer.QueueControlFrame(&wire.DataBlockedFrame{MaximumData: offset})
+	}
+	s.windowUpdateQueue.QueueAll()
+	if cf := s.cryptoStreamManager.GetPostHandshakeData(protocol.MaxPostHandshakeCryptoFrameSize); cf != nil {
+		s.queueControlFrame(cf)
+	}
+}
+
+// sendOnPaths sends packets on a multipath connection.
+// For every packet, the path scheduler selects the path the packet is sent on,
+// taking into account the initial path as well as all validated additional paths.
+func (s *connection) sendOnPaths(now time.Time) error {
+	for _, path := range s.multipath.Paths() {
+		if err := s.maybeSendProbePacketsOnPath(path, now); err != nil {
+			return err
+		}
+	}
+
+	s.queuePendingControlFrames()
+	for {
+		sendMode := s.sentPacketHandler.SendMode(now)
+		if s.sendQueue.WouldBlock() {
+			sendMode = ackhandler.SendNone
+		}
+		paths := append(s.multipath.SchedulerPaths(now), schedulerPath{
+			id:       0,
+			rtt:      s.rttStats.SmoothedRTT(),
+			sendMode: sendMode,
+		})
+		id, ok := schedulePath(paths)
+		if !ok {
+			return nil
+		}
+		var err error
+		if id == 0 {
+			buf := getPacketBuffer()
+			ecn := s.sentPacketHandler.ECNMode(true)
+			if _, err = s.appendOneShortHeaderPacket(buf, s.mtuDiscoverer.CurrentSize(), ecn, now); err != nil {
+				buf.Release()
+			} else {
+				s.sendQueue.Send(buf, 0, ecn)
+			}
+		} else {
+			path, _ := s.multipath.Path(id)
+			err = s.sendPacketOnPath(path, now)
+		}
+		if err != nil {
+			if err == errNothingToPack {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// maybeSendProbePacketsOnPath sends probe packets on an additional path, if the PTO timer of the path expired.
+func (s *connection) maybeSendProbePacketsOnPath(path *multipathPath, now time.Time) error {
+	for path.sentPacketHandler.SendMode(now) == ackhandler.SendPTOAppData && !path.sendQueue.WouldBlock() {
+		path.sentPacketHandler.QueueProbePacket(protocol.Encryption1RTT)
+		err := s.sendPacketOnPath(path, now)
+		if err == errNothingToPack {
+			s.retransmissionQueue.AddPing(protocol.Encryption1RTT)
+			err = s.sendPacketOnPath(path, now)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendPacketOnPath packs a packet and sends it on an additional path of a multipath connection.
+func (s *connection) sendPacketOnPath(path *multipathPath, now time.Time) error {
+	buf := getPacketBuffer()
+	ecn := path.sentPacketHandler.ECNMode(true)
+	p, err := s.packer.AppendPacketOnPath(buf, path.packerPath(), path.maxPacketSize, s.version)
+	if err != nil {
+		buf.Release()
+		return err
+	}
+	s.logShortHeaderPacket(p.DestConnID, p.Ack, p.Frames, p.StreamFrames, p.PacketNumber, p.PacketNumberLen, p.KeyPhase, ecn, buf.Len(), false)
+	if s.firstAckElicitingPacketAfterIdleSentTime.IsZero() && (len(p.StreamFrames) > 0 || ackhandler.HasAckElicitingFrames(p.Frames)) {
+		s.firstAckElicitingPacketAfterIdleSentTime = now
+	}
+	path.sentPacketHandler.SentPacket(now, p.PacketNumber, protocol.InvalidPacketNumber, p.StreamFrames, p.Frames, protocol.Encryption1RTT, ecn, p.Length, false, false)
+	path.sendQueue.Send(buf, 0, ecn)
+	return nil
+}
+
 func (s *connection) sendPacketsWithoutGSO(now time.Time) error {
 	for {
 		buf := getPacketBuffer()
@@ -2059,7 +2842,7 @@ func (s *connection) registerPackedShortHeaderPacket(p shortHeaderPacket, ecn pr
 	if p.Ack != nil {
 		largestAcked = p.Ack.LargestAcked()
 	}
-	s.sentPacketHandler.SentPacket(now, p.PacketNumber, largestAcked, p.StreamFrames, p.Frames, protocol.Encryption1RTT, ecn, p.Length, p.IsPathMTUProbePacket)
+	s.sentPacketHandler.SentPacket(now, p.PacketNumber, largestAcked, p.StreamFrames, p.Frames, protocol.Encryption1RTT, ecn, p.Length, p.IsPathMTUProbePacket, false)
 	s.connIDManager.SentPacket()
 }
 
@@ -2073,7 +2856,7 @@ func (s *connection) sendPackedCoalescedPacket(packet *coalescedPacket, ecn prot
 		if p.ack != nil {
 			largestAcked = p.ack.LargestAcked()
 		}
-		s.sentPacketHandler.SentPacket(now, p.header.PacketNumber, largestAcked, p.streamFrames, p.frames, p.EncryptionLevel(), ecn, p.length, false)
+		s.sentPacketHandler.SentPacket(now, p.header.PacketNumber, largestAcked, p.streamFrames, p.frames, p.EncryptionLevel(), ecn, p.length, false, false)
 		if s.perspective == protocol.PerspectiveClient && p.EncryptionLevel() == protocol.EncryptionHandshake {
 			// On the client side, Initial keys are dropped as soon as the first Handshake packet is sent.
 			// See Section 4.9.1 of RFC 9001.
@@ -2090,7 +2873,7 @@ func (s *connection) sendPackedCoalescedPacket(packet *coalescedPacket, ecn prot
 		if p.Ack != nil {
 			largestAcked = p.Ack.LargestAcked()
 		}
-		s.sentPacketHandler.SentPacket(now, p.PacketNumber, largestAcked, p.StreamFrames, p.Frames, protocol.Encryption1RTT, ecn, p.Length, p.IsPathMTUProbePacket)
+		s.sentPacketHandler.SentPacket(now, p.PacketNumber, largestAcked, p.StreamFrames, p.Frames, protocol.Encryption1RTT, ecn, p.Length, p.IsPathMTUProbePacket, false)
 	}
 	s.connIDManager.SentPacket()
 	s.sendQueue.Send(packet.buffer, 0, ecn)
@@ -2332,8 +3115,8 @@ func (s *connection) onHasConnectionWindowUpdate() {
 	s.scheduleSending()
 }
 
-func (s *connection) onHasStreamData(id protocol.StreamID) {
-	s.framer.AddActiveStream(id)
+func (s *connection) onHasStreamData(id protocol.StreamID, priority StreamPriority) {
+	s.framer.AddActiveStream(id, priority)
 	s.scheduleSending()
 }
 
@@ -2349,8 +3132,8 @@ func (s *connection) SendDatagram(p []byte) error {
 	}
 
 	f := &wire.DatagramFrame{DataLenPresent: true}
-	if protocol.ByteCount(len(p)) > f.MaxDataLen(s.peerParams.MaxDatagramFrameSize, s.version) {
-		return errors.New("message too large")
+	if maxDataLen := f.MaxDataLen(s.peerParams.MaxDatagramFrameSize, s.version); protocol.ByteCount(len(p)) > maxDataLen {
+		return &DatagramTooLargeError{MaxDataLen: int64(maxDataLen)}
 	}
 	f.Data = make([]byte, len(p))
 	copy(f.Data, p)
@@ -2364,14 +3147,80 @@ func (s *connection) ReceiveDatagram(ctx context.Context) ([]byte, error) {
 	return s.datagramQueue.Receive(ctx)
 }
 
+func (s *connection) AddPath(t *Transport) (*Path, error) {
+	if s.perspective == protocol.PerspectiveServer {
+		return nil, errors.New("server cannot initiate connection migration")
+	}
+	select {
+	case <-s.HandshakeComplete():
+	default:
+		return nil, errors.New("handshake not yet complete")
+	}
+	if s.peerParams.DisableActiveMigration {
+		return nil, errors.New("server disabled connection migration")
+	}
+	if err := t.init(s.srcConnIDLen == 0); err != nil {
+		return nil, err
+	}
+	if t.connIDLen != s.srcConnIDLen {
+		return nil, fmt.Errorf("transport uses connection IDs of length %d, connection uses %d", t.connIDLen, s.srcConnIDLen)
+	}
+	conn := newSendConn(t.conn, s.RemoteAddr(), packetInfo{}, s.logger)
+	runner := t.handlerMap
+	return s.pathManagerOutgoing.NewPath(conn, func(id pathID) {
+		s.connIDGenerator.AddConnRunner(id, connRunnerCallbacks{
+			AddConnectionID:    func(connID protocol.ConnectionID) { runner.Add(connID, s) },
+			RemoveConnectionID: runner.Remove,
+			RetireConnectionID: runner.Retire,
+			ReplaceWithClosed:  runner.ReplaceWithClosed,
+		})
+	}), nil
+}
+
 func (s *connection) LocalAddr() net.Addr {
+	s.connMutex.Lock()
+	defer s.connMutex.Unlock()
 	return s.conn.LocalAddr()
 }
 
 func (s *connection) RemoteAddr() net.Addr {
+	s.connMutex.Lock()
+	defer s.connMutex.Unlock()
 	return s.conn.RemoteAddr()
 }
 
+// isOnCurrentPath says if a packet received from remoteAddr was received on the path currently used by the connection.
+// Packets without a remote address are assumed to have been received on the current path.
+func (s *connection) isOnCurrentPath(remoteAddr net.Addr) bool {
+	return remoteAddr == nil || addrsEqual(remoteAddr, s.conn.RemoteAddr())
+}
+
+// isOnCurrentLocalAddr says if a packet was received on the local address currently used by the connection.
+// This can only be determined if the packet info is available.
+func (s *connection) isOnCurrentLocalAddr(info packetInfo) bool {
+	if !info.addr.IsValid() {
+		return true
+	}
+	udpAddr, ok := s.conn.LocalAddr().(*net.UDPAddr)
+	if !ok {
+		return true
+	}
+	addr, _ := netip.AddrFromSlice(udpAddr.IP)
+	return addr.Unmap() == info.addr.Unmap()
+}
+
+func addrsEqual(a, b net.Addr) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	udpA, okA := a.(*net.UDPAddr)
+	udpB, okB := b.(*net.UDPAddr)
+	if okA && okB {
+		return udpA.IP.Equal(udpB.IP) && udpA.Port == udpB.Port && udpA.Zone == udpB.Zone
+	}
+	return a.Network() == b.Network() && a.String() == b.String()
+}
+
 func (s *connection) getPerspective() protocol.Perspective {
 	return s.perspective
 }
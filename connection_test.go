@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"net/netip"
 	"runtime/pprof"
 	"strings"
 	"time"
@@ -179,6 +180,7 @@ var _ = Describe("Connection", func() {
 			It("informs the SentPacketHandler about ACKs", func() {
 				f := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 2, Largest: 3}}}
 				sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+				sph.EXPECT().Stats().AnyTimes() // the run loop takes a snapshot of the statistics
 				sph.EXPECT().ReceivedAck(f, protocol.EncryptionHandshake, gomock.Any())
 				conn.sentPacketHandler = sph
 				err := conn.handleAckFrame(f, protocol.EncryptionHandshake)
@@ -303,9 +305,9 @@ var _ = Describe("Connection", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 
-		It("rejects PATH_RESPONSE frames", func() {
+		It("ignores PATH_RESPONSE frames that don't match a PATH_CHALLENGE", func() {
 			err := conn.handleFrame(&wire.PathResponseFrame{Data: [8]byte{1, 2, 3, 4, 5, 6, 7, 8}}, protocol.Encryption1RTT, protocol.ConnectionID{})
-			Expect(err).To(MatchError("unexpected PATH_RESPONSE frame"))
+			Expect(err).ToNot(HaveOccurred())
 		})
 
 		It("handles PATH_CHALLENGE frames", func() {
@@ -316,6 +318,58 @@ var _ = Describe("Connection", func() {
 			Expect(frames).To(Equal([]ackhandler.Frame{{Frame: &wire.PathResponseFrame{Data: data}}}))
 		})
 
+		It("responds to PATH_CHALLENGE frames received on a different path", func() {
+			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sph.EXPECT().Stats().AnyTimes() // the run loop takes a snapshot of the statistics
+			conn.sentPacketHandler = sph
+			unusedConnID := protocol.ParseConnectionID([]byte{1, 2, 3, 4})
+			connRunner.EXPECT().AddResetToken(gomock.Any(), gomock.Any())
+			Expect(conn.handleFrame(&wire.NewConnectionIDFrame{
+				SequenceNumber: 1,
+				ConnectionID:   unusedConnID,
+			}, protocol.Encryption1RTT, protocol.ConnectionID{})).To(Succeed())
+			data := [8]byte{1, 2, 3, 4, 5, 6, 7, 8}
+			newAddr := &net.UDPAddr{IP: net.IPv4(192, 168, 0, 1), Port: 4242}
+			buf := getPacketBuffer()
+			buf.Data = append(buf.Data, []byte("path response")...)
+			// the connection ID of the active path is not used, since that would allow linking the two paths
+			packer.EXPECT().PackPathProbePacket(unusedConnID, gomock.Any(), conn.version).DoAndReturn(
+				func(_ protocol.ConnectionID, frames []ackhandler.Frame, _ protocol.VersionNumber) (shortHeaderPacket, *packetBuffer, error) {
+					Expect(frames).To(Equal([]ackhandler.Frame{{Frame: &wire.PathResponseFrame{Data: data}}}))
+					return shortHeaderPacket{PacketNumber: 10, Frames: frames, Length: protocol.MinInitialPacketSize}, buf, nil
+				},
+			)
+			sph.EXPECT().SentPacket(gomock.Any(), protocol.PacketNumber(10), protocol.InvalidPacketNumber, gomock.Any(), gomock.Any(), protocol.Encryption1RTT, protocol.ECNUnsupported, protocol.ByteCount(protocol.MinInitialPacketSize), false, true)
+			tracer.EXPECT().SentShortHeaderPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), []logging.Frame{&logging.PathResponseFrame{Data: data}})
+			mconn.EXPECT().WriteTo([]byte("path response"), newAddr)
+			Expect(conn.handlePathChallengeOnPath(&wire.PathChallengeFrame{Data: data}, newAddr, packetInfo{}, time.Now())).To(Succeed())
+			// the PATH_RESPONSE is not sent on the active path
+			frames, _ := conn.framer.AppendControlFrames(nil, 1000, protocol.Version1)
+			Expect(frames).To(BeEmpty())
+		})
+
+		It("doesn't respond to PATH_CHALLENGE frames received on a different path if the client didn't provide an unused connection ID", func() {
+			newAddr := &net.UDPAddr{IP: net.IPv4(192, 168, 0, 1), Port: 4242}
+			Expect(conn.handlePathChallengeOnPath(&wire.PathChallengeFrame{Data: [8]byte{1, 2, 3, 4, 5, 6, 7, 8}}, newAddr, packetInfo{}, time.Now())).To(Succeed())
+			frames, _ := conn.framer.AppendControlFrames(nil, 1000, protocol.Version1)
+			Expect(frames).To(BeEmpty())
+		})
+
+		It("generates the preferred_address transport parameter", func() {
+			resetToken := protocol.StatelessResetToken{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+			connRunner.EXPECT().GetStatelessResetToken(gomock.Any()).Return(resetToken)
+			pa, err := conn.newPreferredAddress(&PreferredAddress{IPv4: netip.MustParseAddrPort("192.0.2.1:1234")})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pa.IPv4.Equal(net.IPv4(192, 0, 2, 1))).To(BeTrue())
+			Expect(pa.IPv4Port).To(BeEquivalentTo(1234))
+			Expect(pa.IPv6).To(Equal(net.IPv6zero))
+			Expect(pa.IPv6Port).To(BeZero())
+			Expect(pa.StatelessResetToken).To(Equal(resetToken))
+			// the connection ID is registered once the connection is running
+			connRunner.EXPECT().Add(pa.ConnectionID, conn)
+			conn.connIDGenerator.AddPreferredAddressConnID()
+		})
+
 		It("rejects NEW_TOKEN frames", func() {
 			err := conn.handleNewTokenFrame(&wire.NewTokenFrame{})
 			Expect(err).To(HaveOccurred())
@@ -484,6 +538,40 @@ var _ = Describe("Connection", func() {
 			Expect(conn.Context().Done()).To(BeClosed())
 		})
 
+		It("returns the statistics, also after the connection was closed", func() {
+			conn.rttStats.UpdateRTT(50*time.Millisecond, 0, time.Now())
+			conn.packetsReceived = 3
+			conn.bytesReceived = 3000
+			cwnd := conn.sentPacketHandler.Stats().CongestionWindow
+			Expect(cwnd).ToNot(BeZero())
+			mtu := conn.mtuDiscoverer.CurrentSize()
+			runConn()
+			stats := ConnectionStats{
+				MinRTT:           50 * time.Millisecond,
+				LatestRTT:        50 * time.Millisecond,
+				SmoothedRTT:      50 * time.Millisecond,
+				MeanDeviation:    25 * time.Millisecond,
+				CongestionWindow: uint64(cwnd),
+				MTU:              uint64(mtu),
+				PacketsReceived:  3,
+				BytesReceived:    3000,
+				ReceiveWindow:    uint64(conn.config.InitialConnectionReceiveWindow),
+			}
+			// the snapshot is taken by the run loop
+			Eventually(conn.Stats).Should(Equal(stats))
+
+			streamManager.EXPECT().CloseWithError(gomock.Any())
+			expectReplaceWithClosed()
+			cryptoSetup.EXPECT().Close()
+			packer.EXPECT().PackApplicationClose(gomock.Any(), gomock.Any(), conn.version).Return(&coalescedPacket{buffer: getPacketBuffer()}, nil)
+			mconn.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any())
+			tracer.EXPECT().ClosedConnection(gomock.Any())
+			tracer.EXPECT().Close()
+			conn.shutdown()
+			Eventually(areConnsRunning).Should(BeFalse())
+			Expect(conn.Stats()).To(Equal(stats))
+		})
+
 		It("closes with an error", func() {
 			runConn()
 			expectedErr := &qerr.ApplicationError{
@@ -597,7 +685,7 @@ var _ = Describe("Connection", func() {
 			// don't EXPECT any calls to packer.PackPacket()
 			conn.handlePacket(receivedPacket{
 				rcvTime:    time.Now(),
-				remoteAddr: &net.UDPAddr{},
+				remoteAddr: remoteAddr,
 				buffer:     getPacketBuffer(),
 				data:       b,
 			})
@@ -612,11 +700,12 @@ var _ = Describe("Connection", func() {
 			sconn.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any()).Return(io.ErrClosedPipe).AnyTimes()
 			conn.sendQueue = newSendQueue(sconn)
 			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sph.EXPECT().Stats().AnyTimes() // the run loop takes a snapshot of the statistics
 			sph.EXPECT().GetLossDetectionTimeout().Return(time.Now().Add(time.Hour)).AnyTimes()
 			sph.EXPECT().ECNMode(true).Return(protocol.ECT1).AnyTimes()
 			sph.EXPECT().SendMode(gomock.Any()).Return(ackhandler.SendAny).AnyTimes()
 			// only expect a single SentPacket() call
-			sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 			tracer.EXPECT().SentShortHeaderPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 			tracer.EXPECT().ClosedConnection(gomock.Any())
 			tracer.EXPECT().Close()
@@ -1053,12 +1142,249 @@ var _ = Describe("Connection", func() {
 		})
 
 		Context("updating the remote address", func() {
-			It("doesn't support connection migration", func() {
+			newAddr := &net.UDPAddr{IP: net.IPv4(192, 168, 0, 100), Port: 4242}
+
+			BeforeEach(func() {
+				conn.handshakeConfirmed = true
+			})
+
+			// receive a packet containing a PING frame (i.e. a non-probing packet)
+			receivePing := func(pn protocol.PacketNumber, addr net.Addr) receivedPacket {
+				unpacker.EXPECT().UnpackShortHeader(gomock.Any(), gomock.Any()).Return(pn, protocol.PacketNumberLen2, protocol.KeyPhaseZero, []byte{0x1} /* one PING frame */, nil)
+				packet := getShortHeaderPacket(srcConnID, pn, nil)
+				packet.remoteAddr = addr
+				tracer.EXPECT().ReceivedShortHeaderPacket(gomock.Any(), protocol.ByteCount(len(packet.data)), gomock.Any(), gomock.Any())
+				Expect(conn.handlePacketImpl(packet)).To(BeTrue())
+				return packet
+			}
+
+			migrate := func() (*mockackhandler.MockSentPacketHandler, *MockSendConn, *wire.PathChallengeFrame, *ackhandler.PathCongestionState) {
+				sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+				sph.EXPECT().Stats().AnyTimes() // the run loop takes a snapshot of the statistics
+				conn.sentPacketHandler = sph
+				sender := NewMockSender(mockCtrl)
+				conn.sendQueue = sender
+				newConn := NewMockSendConn(mockCtrl)
+				newConn.EXPECT().RemoteAddr().Return(newAddr).AnyTimes()
+				newConn.EXPECT().LocalAddr().Return(localAddr).AnyTimes()
+				mconn.EXPECT().WithRemoteAddr(newAddr, gomock.Any()).Return(newConn)
+				// the old send queue is closed asynchronously
+				senderClosed := make(chan struct{})
+				sender.EXPECT().Close().Do(func() { close(senderClosed) })
+				state := &ackhandler.PathCongestionState{}
+				sph.EXPECT().MigratedPath(gomock.Any(), false, false).Return(state)
+				tracer.EXPECT().UpdatedPath(localAddr, newAddr)
+				tracer.EXPECT().StartedPathValidation(newAddr)
+				var size protocol.ByteCount
+				sph.EXPECT().ReceivedBytes(gomock.Any()).Do(func(s protocol.ByteCount) { size = s })
+				// the PATH_CHALLENGE is sent in a packet padded to 1200 bytes
+				var pathChallenge *wire.PathChallengeFrame
+				buf := getPacketBuffer()
+				buf.Data = append(buf.Data, []byte("path challenge")...)
+				packer.EXPECT().PackPathProbePacket(gomock.Any(), gomock.Any(), conn.version).DoAndReturn(
+					func(_ protocol.ConnectionID, frames []ackhandler.Frame, _ protocol.VersionNumber) (shortHeaderPacket, *packetBuffer, error) {
+						Expect(frames).To(HaveLen(1))
+						Expect(frames[0].Frame).To(BeAssignableToTypeOf(&wire.PathChallengeFrame{}))
+						pathChallenge = frames[0].Frame.(*wire.PathChallengeFrame)
+						return shortHeaderPacket{PacketNumber: 10, Frames: frames, Length: protocol.MinInitialPacketSize}, buf, nil
+					},
+				)
+				sph.EXPECT().SentPacket(gomock.Any(), protocol.PacketNumber(10), protocol.InvalidPacketNumber, gomock.Any(), gomock.Any(), protocol.Encryption1RTT, protocol.ECNUnsupported, protocol.ByteCount(protocol.MinInitialPacketSize), false, true)
+				tracer.EXPECT().SentShortHeaderPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+				written := make(chan struct{})
+				newConn.EXPECT().Write([]byte("path challenge"), uint16(0), protocol.ECNUnsupported).Do(func([]byte, uint16, protocol.ECN) error {
+					close(written)
+					return nil
+				})
+				p := receivePing(10, newAddr)
+				Expect(size).To(Equal(p.Size()))
+				Expect(conn.RemoteAddr()).To(Equal(newAddr))
+				Expect(conn.pathManager.ValidationDeadline()).ToNot(BeZero())
+				Eventually(written).Should(BeClosed())
+				Eventually(senderClosed).Should(BeClosed())
+				Expect(pathChallenge).ToNot(BeNil())
+				// the PATH_CHALLENGE is not sent in a regular packet
+				frames, _ := conn.framer.AppendControlFrames(nil, 1000, protocol.Version1)
+				for _, f := range frames {
+					Expect(f.Frame).ToNot(BeAssignableToTypeOf(&wire.PathChallengeFrame{}))
+				}
+				return sph, newConn, pathChallenge, state
+			}
+
+			It("doesn't migrate when receiving a probing packet from a new address", func() {
 				unpacker.EXPECT().UnpackShortHeader(gomock.Any(), gomock.Any()).Return(protocol.PacketNumber(10), protocol.PacketNumberLen2, protocol.KeyPhaseZero, []byte{0} /* one PADDING frame */, nil)
 				packet := getShortHeaderPacket(srcConnID, 0x42, nil)
 				packet.remoteAddr = &net.IPAddr{IP: net.IPv4(192, 168, 0, 100)}
 				tracer.EXPECT().ReceivedShortHeaderPacket(gomock.Any(), protocol.ByteCount(len(packet.data)), gomock.Any(), gomock.Any())
 				Expect(conn.handlePacketImpl(packet)).To(BeTrue())
+				Expect(conn.RemoteAddr()).To(Equal(remoteAddr))
+			})
+
+			It("doesn't migrate before the handshake is confirmed", func() {
+				conn.handshakeConfirmed = false
+				receivePing(10, newAddr)
+				Expect(conn.RemoteAddr()).To(Equal(remoteAddr))
+			})
+
+			It("doesn't migrate when receiving a reordered packet from a new address", func() {
+				receivePing(10, remoteAddr)
+				receivePing(9, newAddr)
+				Expect(conn.RemoteAddr()).To(Equal(remoteAddr))
+			})
+
+			It("migrates and validates the new path", func() {
+				sph, _, f, _ := migrate()
+				defer conn.sendQueue.Close()
+				sph.EXPECT().SetPathValidated()
+				tracer.EXPECT().CompletedPathValidation(newAddr, true)
+				Expect(conn.handleFrame(&wire.PathResponseFrame{Data: f.Data}, protocol.Encryption1RTT, protocol.ConnectionID{})).To(Succeed())
+				Expect(conn.pathManager.ValidationDeadline()).To(BeZero())
+			})
+
+			It("migrates back to the old path when path validation fails", func() {
+				mtuDiscoverer := conn.mtuDiscoverer
+				sph, _, _, state := migrate()
+				Expect(conn.mtuDiscoverer).ToNot(BeIdenticalTo(mtuDiscoverer))
+				// the congestion state of the old path is restored
+				sph.EXPECT().RestorePath(gomock.Any(), state)
+				tracer.EXPECT().CompletedPathValidation(newAddr, false)
+				tracer.EXPECT().UpdatedPath(localAddr, remoteAddr)
+				conn.handlePathValidationTimeout(time.Now())
+				defer conn.sendQueue.Close()
+				Expect(conn.RemoteAddr()).To(Equal(remoteAddr))
+				Expect(conn.pathManager.ValidationDeadline()).To(BeZero())
+				Expect(conn.mtuDiscoverer).To(BeIdenticalTo(mtuDiscoverer))
+			})
+
+			It("keeps the congestion state when only the port changed", func() {
+				rebindAddr := &net.UDPAddr{IP: remoteAddr.IP, Port: remoteAddr.Port + 1}
+				mtuDiscoverer := conn.mtuDiscoverer
+				sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+				sph.EXPECT().Stats().AnyTimes() // the run loop takes a snapshot of the statistics
+				conn.sentPacketHandler = sph
+				sender := NewMockSender(mockCtrl)
+				conn.sendQueue = sender
+				newConn := NewMockSendConn(mockCtrl)
+				newConn.EXPECT().RemoteAddr().Return(rebindAddr).AnyTimes()
+				newConn.EXPECT().LocalAddr().Return(localAddr).AnyTimes()
+				mconn.EXPECT().WithRemoteAddr(rebindAddr, gomock.Any()).Return(newConn)
+				senderClosed := make(chan struct{})
+				sender.EXPECT().Close().Do(func() { close(senderClosed) })
+				sph.EXPECT().MigratedPath(gomock.Any(), false, true)
+				sph.EXPECT().ReceivedBytes(gomock.Any())
+				tracer.EXPECT().UpdatedPath(localAddr, rebindAddr)
+				tracer.EXPECT().StartedPathValidation(rebindAddr)
+				buf := getPacketBuffer()
+				buf.Data = append(buf.Data, []byte("path challenge")...)
+				packer.EXPECT().PackPathProbePacket(gomock.Any(), gomock.Any(), conn.version).Return(shortHeaderPacket{PacketNumber: 10, Length: protocol.MinInitialPacketSize}, buf, nil)
+				sph.EXPECT().SentPacket(gomock.Any(), protocol.PacketNumber(10), protocol.InvalidPacketNumber, gomock.Any(), gomock.Any(), protocol.Encryption1RTT, protocol.ECNUnsupported, protocol.ByteCount(protocol.MinInitialPacketSize), false, true)
+				tracer.EXPECT().SentShortHeaderPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+				newConn.EXPECT().Write([]byte("path challenge"), uint16(0), protocol.ECNUnsupported).AnyTimes()
+				receivePing(10, rebindAddr)
+				defer conn.sendQueue.Close()
+				Eventually(senderClosed).Should(BeClosed())
+				Expect(conn.RemoteAddr()).To(Equal(rebindAddr))
+				Expect(conn.mtuDiscoverer).To(BeIdenticalTo(mtuDiscoverer))
+			})
+
+			It("uses the connection ID that was used for responding to PATH_CHALLENGE frames from the new address", func() {
+				unusedConnID := protocol.ParseConnectionID([]byte{1, 2, 3, 4})
+				connRunner.EXPECT().AddResetToken(gomock.Any(), gomock.Any())
+				Expect(conn.handleFrame(&wire.NewConnectionIDFrame{
+					SequenceNumber: 1,
+					ConnectionID:   unusedConnID,
+				}, protocol.Encryption1RTT, protocol.ConnectionID{})).To(Succeed())
+				Expect(conn.connIDManager.Get()).ToNot(Equal(unusedConnID))
+				connID, ok := conn.connIDForAddr(newAddr, time.Now())
+				Expect(ok).To(BeTrue())
+				Expect(connID).To(Equal(unusedConnID))
+				migrate()
+				defer conn.sendQueue.Close()
+				Expect(conn.connIDManager.Get()).To(Equal(unusedConnID))
+			})
+
+			It("retires the connection IDs of probed addresses that the client didn't migrate to", func() {
+				for i := 1; i < protocol.MaxActiveConnectionIDs; i++ {
+					connRunner.EXPECT().AddResetToken(gomock.Any(), gomock.Any())
+					Expect(conn.handleFrame(&wire.NewConnectionIDFrame{
+						SequenceNumber: uint64(i),
+						ConnectionID:   protocol.ParseConnectionID([]byte{1, 2, 3, byte(i)}),
+					}, protocol.Encryption1RTT, protocol.ConnectionID{})).To(Succeed())
+				}
+				now := time.Now()
+				probedAddr := func(i int) net.Addr { return &net.UDPAddr{IP: net.IPv4(192, 168, 0, 10), Port: 1000 + i} }
+				for i := 1; i < protocol.MaxActiveConnectionIDs; i++ {
+					_, ok := conn.connIDForAddr(probedAddr(i), now)
+					Expect(ok).To(BeTrue())
+				}
+				// there's no unused connection ID left
+				_, ok := conn.connIDForAddr(probedAddr(protocol.MaxActiveConnectionIDs), now)
+				Expect(ok).To(BeFalse())
+				_, ok = conn.connIDForAddr(probedAddr(protocol.MaxActiveConnectionIDs+1), now)
+				Expect(ok).To(BeFalse())
+
+				// The client didn't migrate to any of these addresses.
+				connRunner.EXPECT().RemoveResetToken(gomock.Any()).Times(protocol.MaxActiveConnectionIDs - 1)
+				_, ok = conn.connIDForAddr(probedAddr(protocol.MaxActiveConnectionIDs+1), now.Add(3*conn.rttStats.PTO(false)))
+				Expect(ok).To(BeFalse())
+				var retired []uint64
+				frames, _ := conn.framer.AppendControlFrames(nil, 1000, protocol.Version1)
+				for _, f := range frames {
+					if rf, ok := f.Frame.(*wire.RetireConnectionIDFrame); ok {
+						retired = append(retired, rf.SequenceNumber)
+					}
+				}
+				Expect(retired).To(ConsistOf(uint64(1), uint64(2), uint64(3)))
+				// the client provides new connection IDs, which are used for the fifth address
+				connRunner.EXPECT().AddResetToken(gomock.Any(), gomock.Any())
+				newConnID := protocol.ParseConnectionID([]byte{4, 3, 2, 1})
+				Expect(conn.handleFrame(&wire.NewConnectionIDFrame{
+					SequenceNumber: 4,
+					ConnectionID:   newConnID,
+				}, protocol.Encryption1RTT, protocol.ConnectionID{})).To(Succeed())
+				connID, ok := conn.connIDForAddr(probedAddr(protocol.MaxActiveConnectionIDs+1), now.Add(3*conn.rttStats.PTO(false)))
+				Expect(ok).To(BeTrue())
+				Expect(connID).To(Equal(newConnID))
+			})
+
+			It("switches to the preferred address without validating the path", func() {
+				sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+				sph.EXPECT().Stats().AnyTimes() // the run loop takes a snapshot of the statistics
+				conn.sentPacketHandler = sph
+				sender := NewMockSender(mockCtrl)
+				conn.sendQueue = sender
+				preferredAddr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 2), Port: 7331}
+				newConn := NewMockSendConn(mockCtrl)
+				newConn.EXPECT().RemoteAddr().Return(remoteAddr).AnyTimes()
+				newConn.EXPECT().LocalAddr().Return(preferredAddr).AnyTimes()
+				info := packetInfo{addr: netip.AddrFrom4([4]byte{127, 0, 0, 2})}
+				mconn.EXPECT().WithRemoteAddr(remoteAddr, info).Return(newConn)
+				senderClosed := make(chan struct{})
+				sender.EXPECT().Close().Do(func() { close(senderClosed) })
+				sph.EXPECT().ReceivedBytes(gomock.Any())
+				sph.EXPECT().MigratedPath(gomock.Any(), true, false)
+				tracer.EXPECT().UpdatedPath(preferredAddr, remoteAddr)
+				unpacker.EXPECT().UnpackShortHeader(gomock.Any(), gomock.Any()).Return(protocol.PacketNumber(10), protocol.PacketNumberLen2, protocol.KeyPhaseZero, []byte{0x1} /* one PING frame */, nil)
+				packet := getShortHeaderPacket(srcConnID, 10, nil)
+				packet.remoteAddr = remoteAddr
+				packet.info = info
+				tracer.EXPECT().ReceivedShortHeaderPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+				Expect(conn.handlePacketImpl(packet)).To(BeTrue())
+				defer conn.sendQueue.Close()
+				Eventually(senderClosed).Should(BeClosed())
+				Expect(conn.LocalAddr()).To(Equal(preferredAddr))
+				Expect(conn.pathManager.ValidationDeadline()).To(BeZero())
+			})
+
+			It("doesn't validate the path again when the client returns to the old address", func() {
+				sph, newConn, _, state := migrate()
+				newConn.EXPECT().WithRemoteAddr(remoteAddr, gomock.Any()).Return(mconn)
+				sph.EXPECT().RestorePath(gomock.Any(), state)
+				tracer.EXPECT().UpdatedPath(localAddr, remoteAddr)
+				receivePing(11, remoteAddr)
+				defer conn.sendQueue.Close()
+				Expect(conn.RemoteAddr()).To(Equal(remoteAddr))
+				Expect(conn.pathManager.ValidationDeadline()).To(BeZero())
 			})
 		})
 
@@ -1205,6 +1531,7 @@ var _ = Describe("Connection", func() {
 			conn.sendQueue = sender
 			connDone = make(chan struct{})
 			sph = mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sph.EXPECT().Stats().AnyTimes() // the run loop takes a snapshot of the statistics
 			conn.sentPacketHandler = sph
 		})
 
@@ -1239,7 +1566,7 @@ var _ = Describe("Connection", func() {
 			sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
 			sph.EXPECT().SendMode(gomock.Any()).Return(ackhandler.SendAny).AnyTimes()
 			sph.EXPECT().ECNMode(true).Return(protocol.ECNNon).AnyTimes()
-			sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 			runConn()
 			p := shortHeaderPacket{
 				DestConnID:      protocol.ParseConnectionID([]byte{1, 2, 3}),
@@ -1295,8 +1622,9 @@ var _ = Describe("Connection", func() {
 			sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
 			sph.EXPECT().SendMode(gomock.Any()).Return(ackhandler.SendAny).AnyTimes()
 			sph.EXPECT().ECNMode(gomock.Any()).AnyTimes()
-			sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 			fc := mocks.NewMockConnectionFlowController(mockCtrl)
+			fc.EXPECT().Stats().AnyTimes()
 			fc.EXPECT().IsNewlyBlocked().Return(true, protocol.ByteCount(1337))
 			expectAppendPacket(packer, shortHeaderPacket{PacketNumber: 13}, []byte("foobar"))
 			packer.EXPECT().AppendPacket(gomock.Any(), gomock.Any(), conn.version).Return(shortHeaderPacket{}, errNothingToPack).AnyTimes()
@@ -1351,7 +1679,7 @@ var _ = Describe("Connection", func() {
 					sph.EXPECT().ECNMode(gomock.Any())
 					p := getCoalescedPacket(123, enc != protocol.Encryption1RTT)
 					packer.EXPECT().MaybePackProbePacket(encLevel, gomock.Any(), conn.version).Return(p, nil)
-					sph.EXPECT().SentPacket(gomock.Any(), protocol.PacketNumber(123), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+					sph.EXPECT().SentPacket(gomock.Any(), protocol.PacketNumber(123), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 					conn.sentPacketHandler = sph
 					runConn()
 					sent := make(chan struct{})
@@ -1374,7 +1702,7 @@ var _ = Describe("Connection", func() {
 					sph.EXPECT().QueueProbePacket(encLevel).Return(false)
 					p := getCoalescedPacket(123, enc != protocol.Encryption1RTT)
 					packer.EXPECT().MaybePackProbePacket(encLevel, gomock.Any(), conn.version).Return(p, nil)
-					sph.EXPECT().SentPacket(gomock.Any(), protocol.PacketNumber(123), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+					sph.EXPECT().SentPacket(gomock.Any(), protocol.PacketNumber(123), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 					runConn()
 					sent := make(chan struct{})
 					sender.EXPECT().Send(gomock.Any(), gomock.Any(), gomock.Any()).Do(func(*packetBuffer, uint16, protocol.ECN) { close(sent) })
@@ -1402,6 +1730,7 @@ var _ = Describe("Connection", func() {
 		BeforeEach(func() {
 			tracer.EXPECT().SentShortHeaderPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 			sph = mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sph.EXPECT().Stats().AnyTimes() // the run loop takes a snapshot of the statistics
 			sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
 			conn.handshakeConfirmed = true
 			conn.handshakeComplete = true
@@ -1427,7 +1756,7 @@ var _ = Describe("Connection", func() {
 		})
 
 		It("sends multiple packets one by one immediately", func() {
-			sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+			sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
 			sph.EXPECT().SendMode(gomock.Any()).Return(ackhandler.SendAny).Times(2)
 			sph.EXPECT().ECNMode(gomock.Any()).Times(2)
 			sph.EXPECT().SendMode(gomock.Any()).Return(ackhandler.SendPacingLimited)
@@ -1453,7 +1782,7 @@ var _ = Describe("Connection", func() {
 
 		It("sends multiple packets one by one immediately, with GSO", func() {
 			enableGSO()
-			sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+			sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
 			sph.EXPECT().ECNMode(true).Return(protocol.ECT1).Times(4)
 			sph.EXPECT().SendMode(gomock.Any()).Return(ackhandler.SendAny).Times(3)
 			payload1 := make([]byte, conn.mtuDiscoverer.CurrentSize())
@@ -1479,7 +1808,7 @@ var _ = Describe("Connection", func() {
 
 		It("stops appending packets when a smaller packet is packed, with GSO", func() {
 			enableGSO()
-			sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(3)
+			sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(3)
 			sph.EXPECT().SendMode(gomock.Any()).Return(ackhandler.SendAny).Times(3)
 			sph.EXPECT().SendMode(gomock.Any()).Return(ackhandler.SendNone)
 			sph.EXPECT().ECNMode(true).Times(4)
@@ -1511,7 +1840,7 @@ var _ = Describe("Connection", func() {
 
 		It("stops appending packets when the ECN marking changes, with GSO", func() {
 			enableGSO()
-			sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(3)
+			sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(3)
 			sph.EXPECT().SendMode(gomock.Any()).Return(ackhandler.SendAny).Times(3)
 			sph.EXPECT().SendMode(gomock.Any()).Return(ackhandler.SendNone)
 			sph.EXPECT().ECNMode(true).Return(protocol.ECT1).Times(2)
@@ -1543,7 +1872,7 @@ var _ = Describe("Connection", func() {
 		})
 
 		It("sends multiple packets, when the pacer allows immediate sending", func() {
-			sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 			sph.EXPECT().SendMode(gomock.Any()).Return(ackhandler.SendAny).Times(2)
 			sph.EXPECT().ECNMode(gomock.Any()).Times(2)
 			expectAppendPacket(packer, shortHeaderPacket{PacketNumber: 10}, []byte("packet10"))
@@ -1561,7 +1890,7 @@ var _ = Describe("Connection", func() {
 		})
 
 		It("allows an ACK to be sent when pacing limited", func() {
-			sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 			sph.EXPECT().TimeUntilSend().Return(time.Now().Add(time.Hour))
 			sph.EXPECT().SendMode(gomock.Any()).Return(ackhandler.SendPacingLimited)
 			sph.EXPECT().ECNMode(gomock.Any())
@@ -1582,7 +1911,7 @@ var _ = Describe("Connection", func() {
 		// when becoming congestion limited, at some point the SendMode will change from SendAny to SendAck
 		// we shouldn't send the ACK in the same run
 		It("doesn't send an ACK right after becoming congestion limited", func() {
-			sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 			sph.EXPECT().SendMode(gomock.Any()).Return(ackhandler.SendAny)
 			sph.EXPECT().SendMode(gomock.Any()).Return(ackhandler.SendAck)
 			sph.EXPECT().ECNMode(gomock.Any()).Times(2)
@@ -1605,13 +1934,13 @@ var _ = Describe("Connection", func() {
 				sph.EXPECT().SendMode(gomock.Any()).Return(ackhandler.SendAny),
 				sph.EXPECT().ECNMode(gomock.Any()),
 				expectAppendPacket(packer, shortHeaderPacket{PacketNumber: 100}, []byte("packet100")),
-				sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()),
+				sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()),
 				sph.EXPECT().SendMode(gomock.Any()).Return(ackhandler.SendPacingLimited),
 				sph.EXPECT().TimeUntilSend().Return(time.Now().Add(pacingDelay)),
 				sph.EXPECT().SendMode(gomock.Any()).Return(ackhandler.SendAny),
 				sph.EXPECT().ECNMode(gomock.Any()),
 				expectAppendPacket(packer, shortHeaderPacket{PacketNumber: 101}, []byte("packet101")),
-				sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()),
+				sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()),
 				sph.EXPECT().SendMode(gomock.Any()).Return(ackhandler.SendPacingLimited),
 				sph.EXPECT().TimeUntilSend().Return(time.Now().Add(time.Hour)),
 			)
@@ -1631,7 +1960,7 @@ var _ = Describe("Connection", func() {
 		})
 
 		It("sends multiple packets at once", func() {
-			sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(3)
+			sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(3)
 			sph.EXPECT().SendMode(gomock.Any()).Return(ackhandler.SendAny).Times(3)
 			sph.EXPECT().ECNMode(gomock.Any()).Times(3)
 			sph.EXPECT().SendMode(gomock.Any()).Return(ackhandler.SendPacingLimited)
@@ -1672,7 +2001,7 @@ var _ = Describe("Connection", func() {
 
 				written := make(chan struct{})
 				sender.EXPECT().WouldBlock().AnyTimes()
-				sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+				sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 				sph.EXPECT().SendMode(gomock.Any()).Return(ackhandler.SendAny).AnyTimes()
 				sph.EXPECT().ECNMode(gomock.Any()).AnyTimes()
 				expectAppendPacket(packer, shortHeaderPacket{PacketNumber: 1000}, []byte("packet1000"))
@@ -1694,7 +2023,7 @@ var _ = Describe("Connection", func() {
 
 			written := make(chan struct{})
 			sender.EXPECT().WouldBlock().AnyTimes()
-			sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Do(func(time.Time, protocol.PacketNumber, protocol.PacketNumber, []ackhandler.StreamFrame, []ackhandler.Frame, protocol.EncryptionLevel, protocol.ECN, protocol.ByteCount, bool) {
+			sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Do(func(time.Time, protocol.PacketNumber, protocol.PacketNumber, []ackhandler.StreamFrame, []ackhandler.Frame, protocol.EncryptionLevel, protocol.ECN, protocol.ByteCount, bool, bool) {
 				sph.EXPECT().ReceivedBytes(gomock.Any())
 				conn.handlePacket(receivedPacket{buffer: getPacketBuffer()})
 			})
@@ -1711,7 +2040,7 @@ var _ = Describe("Connection", func() {
 		})
 
 		It("stops sending when the send queue is full", func() {
-			sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 			sph.EXPECT().SendMode(gomock.Any()).Return(ackhandler.SendAny)
 			sph.EXPECT().ECNMode(gomock.Any())
 			expectAppendPacket(packer, shortHeaderPacket{PacketNumber: 1000}, []byte("packet1000"))
@@ -1732,7 +2061,7 @@ var _ = Describe("Connection", func() {
 			time.Sleep(scaleDuration(50 * time.Millisecond))
 
 			// now make room in the send queue
-			sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 			sph.EXPECT().SendMode(gomock.Any()).Return(ackhandler.SendAny).AnyTimes()
 			sph.EXPECT().ECNMode(gomock.Any()).AnyTimes()
 			sender.EXPECT().WouldBlock().AnyTimes()
@@ -1765,9 +2094,10 @@ var _ = Describe("Connection", func() {
 
 		It("sends a Path MTU probe packet", func() {
 			mtuDiscoverer := NewMockMTUDiscoverer(mockCtrl)
+			mtuDiscoverer.EXPECT().CurrentSize().Return(protocol.ByteCount(1234)).AnyTimes()
 			conn.mtuDiscoverer = mtuDiscoverer
 			conn.config.DisablePathMTUDiscovery = false
-			sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 			sph.EXPECT().SendMode(gomock.Any()).Return(ackhandler.SendAny)
 			sph.EXPECT().ECNMode(true)
 			sph.EXPECT().SendMode(gomock.Any()).Return(ackhandler.SendNone)
@@ -1786,7 +2116,6 @@ var _ = Describe("Connection", func() {
 			}()
 			conn.scheduleSending()
 			Eventually(written).Should(Receive())
-			mtuDiscoverer.EXPECT().CurrentSize().Return(protocol.ByteCount(1234))
 		})
 	})
 
@@ -1817,12 +2146,13 @@ var _ = Describe("Connection", func() {
 
 		It("sends when scheduleSending is called", func() {
 			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sph.EXPECT().Stats().AnyTimes() // the run loop takes a snapshot of the statistics
 			sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
 			sph.EXPECT().TimeUntilSend().AnyTimes()
 			sph.EXPECT().SendMode(gomock.Any()).Return(ackhandler.SendAny).AnyTimes()
 			sph.EXPECT().ECNMode(gomock.Any()).AnyTimes()
 
-			sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 			conn.sentPacketHandler = sph
 			expectAppendPacket(packer, shortHeaderPacket{PacketNumber: 1}, []byte("packet1"))
 			packer.EXPECT().AppendPacket(gomock.Any(), gomock.Any(), conn.version).Return(shortHeaderPacket{}, errNothingToPack)
@@ -1847,10 +2177,11 @@ var _ = Describe("Connection", func() {
 			expectAppendPacket(packer, shortHeaderPacket{PacketNumber: 1234}, []byte("packet1234"))
 			packer.EXPECT().AppendPacket(gomock.Any(), gomock.Any(), conn.version).Return(shortHeaderPacket{}, errNothingToPack)
 			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sph.EXPECT().Stats().AnyTimes() // the run loop takes a snapshot of the statistics
 			sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
 			sph.EXPECT().SendMode(gomock.Any()).Return(ackhandler.SendAny).AnyTimes()
 			sph.EXPECT().ECNMode(gomock.Any()).AnyTimes()
-			sph.EXPECT().SentPacket(gomock.Any(), protocol.PacketNumber(1234), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			sph.EXPECT().SentPacket(gomock.Any(), protocol.PacketNumber(1234), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 			conn.sentPacketHandler = sph
 			rph := mockackhandler.NewMockReceivedPacketHandler(mockCtrl)
 			rph.EXPECT().GetAlarmTimeout().Return(time.Now().Add(10 * time.Millisecond))
@@ -1875,6 +2206,7 @@ var _ = Describe("Connection", func() {
 		conn.handshakeComplete = false
 		conn.handshakeConfirmed = false
 		sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+		sph.EXPECT().Stats().AnyTimes() // the run loop takes a snapshot of the statistics
 		conn.sentPacketHandler = sph
 		buffer := getPacketBuffer()
 		buffer.Data = append(buffer.Data, []byte("foobar")...)
@@ -1904,8 +2236,8 @@ var _ = Describe("Connection", func() {
 		sph.EXPECT().ECNMode(false).Return(protocol.ECT1).AnyTimes()
 		sph.EXPECT().TimeUntilSend().Return(time.Now()).AnyTimes()
 		gomock.InOrder(
-			sph.EXPECT().SentPacket(gomock.Any(), protocol.PacketNumber(13), gomock.Any(), gomock.Any(), gomock.Any(), protocol.EncryptionInitial, protocol.ECT1, protocol.ByteCount(123), gomock.Any()),
-			sph.EXPECT().SentPacket(gomock.Any(), protocol.PacketNumber(37), gomock.Any(), gomock.Any(), gomock.Any(), protocol.EncryptionHandshake, protocol.ECT1, protocol.ByteCount(1234), gomock.Any()),
+			sph.EXPECT().SentPacket(gomock.Any(), protocol.PacketNumber(13), gomock.Any(), gomock.Any(), gomock.Any(), protocol.EncryptionInitial, protocol.ECT1, protocol.ByteCount(123), gomock.Any(), gomock.Any()),
+			sph.EXPECT().SentPacket(gomock.Any(), protocol.PacketNumber(37), gomock.Any(), gomock.Any(), gomock.Any(), protocol.EncryptionHandshake, protocol.ECT1, protocol.ByteCount(1234), gomock.Any(), gomock.Any()),
 		)
 		gomock.InOrder(
 			tracer.EXPECT().SentLongHeaderPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Do(func(hdr *wire.ExtendedHeader, _ protocol.ByteCount, _ logging.ECN, _ *wire.AckFrame, _ []logging.Frame) {
@@ -1944,6 +2276,7 @@ var _ = Describe("Connection", func() {
 	It("cancels the HandshakeComplete context when the handshake completes", func() {
 		packer.EXPECT().PackCoalescedPacket(false, gomock.Any(), conn.version).AnyTimes()
 		sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+		sph.EXPECT().Stats().AnyTimes() // the run loop takes a snapshot of the statistics
 		conn.sentPacketHandler = sph
 		tracer.EXPECT().DroppedEncryptionLevel(protocol.EncryptionHandshake)
 		sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
@@ -2013,12 +2346,13 @@ var _ = Describe("Connection", func() {
 
 	It("sends a HANDSHAKE_DONE frame when the handshake completes", func() {
 		sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+		sph.EXPECT().Stats().AnyTimes() // the run loop takes a snapshot of the statistics
 		sph.EXPECT().SendMode(gomock.Any()).Return(ackhandler.SendAny).AnyTimes()
 		sph.EXPECT().ECNMode(gomock.Any()).AnyTimes()
 		sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
 		sph.EXPECT().TimeUntilSend().AnyTimes()
 		sph.EXPECT().SetHandshakeConfirmed()
-		sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+		sph.EXPECT().SentPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 		mconn.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any())
 		tracer.EXPECT().SentShortHeaderPacket(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 		conn.sentPacketHandler = sph
@@ -2501,6 +2835,11 @@ var _ = Describe("Connection", func() {
 	It("returns the remote address", func() {
 		Expect(conn.RemoteAddr()).To(Equal(remoteAddr))
 	})
+
+	It("doesn't allow the server to add paths", func() {
+		_, err := conn.AddPath(&Transport{})
+		Expect(err).To(MatchError("server cannot initiate connection migration"))
+	})
 })
 
 var _ = Describe("Client Connection", func() {
@@ -2650,17 +2989,72 @@ var _ = Describe("Client Connection", func() {
 	It("handles HANDSHAKE_DONE frames", func() {
 		conn.peerParams = &wire.TransportParameters{}
 		sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+		sph.EXPECT().Stats().AnyTimes() // the run loop takes a snapshot of the statistics
+		conn.sentPacketHandler = sph
+		tracer.EXPECT().DroppedEncryptionLevel(protocol.EncryptionHandshake)
+		sph.EXPECT().DropPackets(protocol.EncryptionHandshake)
+		sph.EXPECT().SetHandshakeConfirmed()
+		cryptoSetup.EXPECT().SetHandshakeConfirmed()
+		Expect(conn.handleHandshakeDoneFrame()).To(Succeed())
+	})
+
+	It("migrates to the server's preferred address when the handshake is confirmed", func() {
+		preferredAddr := &net.UDPAddr{IP: net.ParseIP("2001:db8::1"), Port: 4242}
+		connID := protocol.ParseConnectionID([]byte{1, 2, 3, 4})
+		resetToken := protocol.StatelessResetToken{16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1}
+		conn.peerParams = &wire.TransportParameters{
+			PreferredAddress: &wire.PreferredAddress{
+				IPv4:                net.IPv4zero,
+				IPv6:                preferredAddr.IP,
+				IPv6Port:            4242,
+				ConnectionID:        connID,
+				StatelessResetToken: resetToken,
+			},
+		}
+		Expect(conn.connIDManager.AddFromPreferredAddress(connID, resetToken)).To(Succeed())
+		sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+		sph.EXPECT().Stats().AnyTimes() // the run loop takes a snapshot of the statistics
 		conn.sentPacketHandler = sph
 		tracer.EXPECT().DroppedEncryptionLevel(protocol.EncryptionHandshake)
 		sph.EXPECT().DropPackets(protocol.EncryptionHandshake)
 		sph.EXPECT().SetHandshakeConfirmed()
 		cryptoSetup.EXPECT().SetHandshakeConfirmed()
+		preferredConn := NewMockSendConn(mockCtrl)
+		mconn.EXPECT().WithRemoteAddr(preferredAddr, packetInfo{}).Return(preferredConn)
 		Expect(conn.handleHandshakeDoneFrame()).To(Succeed())
+
+		// the preferred address is probed using the connection ID from the preferred_address
+		connRunner.EXPECT().AddResetToken(resetToken, conn)
+		var c protocol.ConnectionID
+		var pathConn sendConn
+		Eventually(func() bool {
+			var ok bool
+			_, c, _, pathConn, ok = conn.pathManagerOutgoing.NextPathToProbe()
+			return ok
+		}).Should(BeTrue())
+		Expect(c).To(Equal(connID))
+		Expect(pathConn).To(Equal(preferredConn))
+		// stop probing
+		conn.ctxCancel(nil)
+	})
+
+	It("only migrates to a preferred address of the same address family", func() {
+		pa := &wire.PreferredAddress{
+			IPv4:     net.IPv4(192, 0, 2, 1),
+			IPv4Port: 1234,
+			IPv6:     net.IPv6zero,
+		}
+		Expect(preferredAddressForFamily(pa, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 443})).To(Equal(&net.UDPAddr{IP: pa.IPv4, Port: 1234}))
+		Expect(preferredAddressForFamily(pa, &net.UDPAddr{IP: net.IPv6loopback, Port: 443})).To(BeNil())
+		pa.IPv6 = net.ParseIP("2001:db8::1")
+		pa.IPv6Port = 4321
+		Expect(preferredAddressForFamily(pa, &net.UDPAddr{IP: net.IPv6loopback, Port: 443})).To(Equal(&net.UDPAddr{IP: pa.IPv6, Port: 4321}))
 	})
 
 	It("interprets an ACK for 1-RTT packets as confirmation of the handshake", func() {
 		conn.peerParams = &wire.TransportParameters{}
 		sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+		sph.EXPECT().Stats().AnyTimes() // the run loop takes a snapshot of the statistics
 		conn.sentPacketHandler = sph
 		ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 1, Largest: 3}}}
 		tracer.EXPECT().DroppedEncryptionLevel(protocol.EncryptionHandshake)
@@ -2693,6 +3087,18 @@ var _ = Describe("Client Connection", func() {
 		Eventually(areConnsRunning).Should(BeFalse())
 	})
 
+	It("doesn't add paths before the handshake completes", func() {
+		_, err := conn.AddPath(&Transport{})
+		Expect(err).To(MatchError("handshake not yet complete"))
+	})
+
+	It("doesn't add paths if the server disabled active migration", func() {
+		conn.handshakeCtxCancel()
+		conn.peerParams = &wire.TransportParameters{DisableActiveMigration: true}
+		_, err := conn.AddPath(&Transport{})
+		Expect(err).To(MatchError("server disabled connection migration"))
+	})
+
 	Context("handling tokens", func() {
 		var mockTokenStore *MockTokenStore
 
@@ -2726,6 +3132,7 @@ var _ = Describe("Client Connection", func() {
 
 		It("closes and returns the right error", func() {
 			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sph.EXPECT().Stats().AnyTimes() // the run loop takes a snapshot of the statistics
 			conn.sentPacketHandler = sph
 			sph.EXPECT().ReceivedBytes(gomock.Any())
 			sph.EXPECT().PeekPacketNumber(protocol.EncryptionInitial).Return(protocol.PacketNumber(128), protocol.PacketNumberLen4)
@@ -2828,6 +3235,7 @@ var _ = Describe("Client Connection", func() {
 		It("handles Retry packets", func() {
 			now := time.Now()
 			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sph.EXPECT().Stats().AnyTimes() // the run loop takes a snapshot of the statistics
 			conn.sentPacketHandler = sph
 			sph.EXPECT().ResetForRetry(now)
 			sph.EXPECT().ReceivedBytes(gomock.Any())
@@ -2951,6 +3359,44 @@ var _ = Describe("Client Connection", func() {
 			expectClose(true, false)
 		})
 
+		It("negotiates multipath", func() {
+			conn.config.EnableMultipath = true
+			params := &wire.TransportParameters{
+				OriginalDestinationConnectionID: destConnID,
+				InitialSourceConnectionID:       destConnID,
+				EnableMultipath:                 true,
+			}
+			packer.EXPECT().EnableMultipath(gomock.Any())
+			packer.EXPECT().PackCoalescedPacket(false, gomock.Any(), conn.version).MaxTimes(1)
+			processed := make(chan struct{})
+			tracer.EXPECT().ReceivedTransportParameters(params).Do(func(*wire.TransportParameters) { close(processed) })
+			paramsChan <- params
+			Eventually(processed).Should(BeClosed())
+			// close first
+			expectClose(true, false)
+			conn.shutdown()
+			// then check. Avoids race condition when accessing the multipathManager
+			Expect(conn.multipath).ToNot(BeNil())
+		})
+
+		It("errors if the transport parameters enable multipath with a zero-length connection ID", func() {
+			conn.handshakeDestConnID = protocol.ConnectionID{}
+			params := &wire.TransportParameters{
+				OriginalDestinationConnectionID: destConnID,
+				InitialSourceConnectionID:       protocol.ConnectionID{},
+				EnableMultipath:                 true,
+			}
+			expectClose(false, true)
+			processed := make(chan struct{})
+			tracer.EXPECT().ReceivedTransportParameters(params).Do(func(*wire.TransportParameters) { close(processed) })
+			paramsChan <- params
+			Eventually(processed).Should(BeClosed())
+			Eventually(errChan).Should(Receive(MatchError(&qerr.TransportError{
+				ErrorCode:    qerr.TransportParameterError,
+				ErrorMessage: "enable_multipath used with a zero-length connection ID",
+			})))
+		})
+
 		It("uses the minimum of the peers' idle timeouts", func() {
 			conn.config.MaxIdleTimeout = 19 * time.Second
 			params := &wire.TransportParameters{
@@ -3203,6 +3649,7 @@ var _ = Describe("Client Connection", func() {
 		// can cause subsequent real Initial packets to be ignored
 		It("ignores Initial packets which use original source id, after accepting a Retry", func() {
 			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sph.EXPECT().Stats().AnyTimes() // the run loop takes a snapshot of the statistics
 			conn.sentPacketHandler = sph
 			sph.EXPECT().ReceivedBytes(gomock.Any()).Times(2)
 			sph.EXPECT().ResetForRetry(gomock.Any())
@@ -2,10 +2,12 @@ package quic
 
 import (
 	"errors"
+	"sort"
 	"sync"
 
 	"github.com/quic-go/quic-go/internal/ackhandler"
 	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/internal/utils"
 	"github.com/quic-go/quic-go/internal/utils/ringbuffer"
 	"github.com/quic-go/quic-go/internal/wire"
 	"github.com/quic-go/quic-go/quicvarint"
@@ -17,19 +19,63 @@ type framer interface {
 	QueueControlFrame(wire.Frame)
 	AppendControlFrames([]ackhandler.Frame, protocol.ByteCount, protocol.VersionNumber) ([]ackhandler.Frame, protocol.ByteCount)
 
-	AddActiveStream(protocol.StreamID)
+	AddActiveStream(protocol.StreamID, StreamPriority)
 	AppendStreamFrames([]ackhandler.StreamFrame, protocol.ByteCount, protocol.VersionNumber) ([]ackhandler.StreamFrame, protocol.ByteCount)
 
 	Handle0RTTRejection() error
 }
 
+// the number of urgency levels defined in RFC 9218
+const numUrgencies = 8
+
+// The streamQueue holds the active streams of one urgency level.
+type streamQueue struct {
+	// non-incremental streams, sorted by stream ID
+	sequential []protocol.StreamID
+	// incremental streams, served in a round-robin fashion
+	incremental ringbuffer.RingBuffer[protocol.StreamID]
+}
+
+func (q *streamQueue) Add(id protocol.StreamID, incremental bool) {
+	if incremental {
+		q.incremental.PushBack(id)
+		return
+	}
+	i := sort.Search(len(q.sequential), func(i int) bool { return q.sequential[i] > id })
+	q.sequential = append(q.sequential, 0)
+	copy(q.sequential[i+1:], q.sequential[i:])
+	q.sequential[i] = id
+}
+
+func (q *streamQueue) Remove(id protocol.StreamID, incremental bool) {
+	if incremental {
+		for i, l := 0, q.incremental.Len(); i < l; i++ {
+			if str := q.incremental.PopFront(); str != id {
+				q.incremental.PushBack(str)
+			}
+		}
+		return
+	}
+	for i, str := range q.sequential {
+		if str == id {
+			q.sequential = append(q.sequential[:i], q.sequential[i+1:]...)
+			return
+		}
+	}
+}
+
+func (q *streamQueue) Clear() {
+	q.sequential = q.sequential[:0]
+	q.incremental.Clear()
+}
+
 type framerI struct {
 	mutex sync.Mutex
 
 	streamGetter streamGetter
 
-	activeStreams map[protocol.StreamID]struct{}
-	streamQueue   ringbuffer.RingBuffer[protocol.StreamID]
+	activeStreams map[protocol.StreamID]StreamPriority
+	streamQueues  [numUrgencies]streamQueue
 
 	controlFrameMutex sync.Mutex
 	controlFrames     []wire.Frame
@@ -40,13 +86,13 @@ var _ framer = &framerI{}
 func newFramer(streamGetter streamGetter) framer {
 	return &framerI{
 		streamGetter:  streamGetter,
-		activeStreams: make(map[protocol.StreamID]struct{}),
+		activeStreams: make(map[protocol.StreamID]StreamPriority),
 	}
 }
 
 func (f *framerI) HasData() bool {
 	f.mutex.Lock()
-	hasData := !f.streamQueue.Empty()
+	hasData := len(f.activeStreams) > 0
 	f.mutex.Unlock()
 	if hasData {
 		return true
@@ -80,12 +126,20 @@ func (f *framerI) AppendControlFrames(frames []ackhandler.Frame, maxLen protocol
 	return frames, length
 }
 
-func (f *framerI) AddActiveStream(id protocol.StreamID) {
+// AddActiveStream is called when a stream has data to send.
+// It is also called when the priority of a stream that has data to send changes.
+func (f *framerI) AddActiveStream(id protocol.StreamID, priority StreamPriority) {
+	priority.Urgency = utils.Min(priority.Urgency, numUrgencies-1)
 	f.mutex.Lock()
-	if _, ok := f.activeStreams[id]; !ok {
-		f.streamQueue.PushBack(id)
-		f.activeStreams[id] = struct{}{}
+	if oldPriority, ok := f.activeStreams[id]; ok {
+		if oldPriority == priority {
+			f.mutex.Unlock()
+			return
+		}
+		f.streamQueues[oldPriority.Urgency].Remove(id, oldPriority.Incremental)
 	}
+	f.activeStreams[id] = priority
+	f.streamQueues[priority.Urgency].Add(id, priority.Incremental)
 	f.mutex.Unlock()
 }
 
@@ -94,39 +148,11 @@ func (f *framerI) AppendStreamFrames(frames []ackhandler.StreamFrame, maxLen pro
 	var length protocol.ByteCount
 	f.mutex.Lock()
 	// pop STREAM frames, until less than MinStreamFrameSize bytes are left in the packet
-	numActiveStreams := f.streamQueue.Len()
-	for i := 0; i < numActiveStreams; i++ {
+	for i := range f.streamQueues {
 		if protocol.MinStreamFrameSize+length > maxLen {
 			break
 		}
-		id := f.streamQueue.PopFront()
-		// This should never return an error. Better check it anyway.
-		// The stream will only be in the streamQueue, if it enqueued itself there.
-		str, err := f.streamGetter.GetOrOpenSendStream(id)
-		// The stream can be nil if it completed after it said it had data.
-		if str == nil || err != nil {
-			delete(f.activeStreams, id)
-			continue
-		}
-		remainingLen := maxLen - length
-		// For the last STREAM frame, we'll remove the DataLen field later.
-		// Therefore, we can pretend to have more bytes available when popping
-		// the STREAM frame (which will always have the DataLen set).
-		remainingLen += quicvarint.Len(uint64(remainingLen))
-		frame, ok, hasMoreData := str.popStreamFrame(remainingLen, v)
-		if hasMoreData { // put the stream back in the queue (at the end)
-			f.streamQueue.PushBack(id)
-		} else { // no more data to send. Stream is not active
-			delete(f.activeStreams, id)
-		}
-		// The frame can be "nil"
-		// * if the receiveStream was canceled after it said it had data
-		// * the remaining size doesn't allow us to add another STREAM frame
-		if !ok {
-			continue
-		}
-		frames = append(frames, frame)
-		length += frame.Frame.Length(v)
+		frames, length = f.appendStreamFramesForUrgency(&f.streamQueues[i], frames, length, maxLen, v)
 	}
 	f.mutex.Unlock()
 	if len(frames) > startLen {
@@ -138,12 +164,86 @@ func (f *framerI) AppendStreamFrames(frames []ackhandler.StreamFrame, maxLen pro
 	return frames, length
 }
 
+// appendStreamFramesForUrgency pops STREAM frames from the streams of one urgency level.
+// Every stream is popped at most once.
+func (f *framerI) appendStreamFramesForUrgency(
+	q *streamQueue,
+	frames []ackhandler.StreamFrame,
+	length, maxLen protocol.ByteCount,
+	v protocol.VersionNumber,
+) ([]ackhandler.StreamFrame, protocol.ByteCount) {
+	// Non-incremental streams are sent one after another.
+	// The next stream is only considered if the first stream doesn't fill the packet.
+	for i := 0; i < len(q.sequential); {
+		if protocol.MinStreamFrameSize+length > maxLen {
+			return frames, length
+		}
+		id := q.sequential[i]
+		var hasMoreData bool
+		frames, length, hasMoreData = f.appendStreamFrame(id, frames, length, maxLen, v)
+		if hasMoreData {
+			i++
+			continue
+		}
+		q.sequential = append(q.sequential[:i], q.sequential[i+1:]...)
+		delete(f.activeStreams, id)
+	}
+
+	numActiveStreams := q.incremental.Len()
+	for i := 0; i < numActiveStreams; i++ {
+		if protocol.MinStreamFrameSize+length > maxLen {
+			break
+		}
+		id := q.incremental.PopFront()
+		var hasMoreData bool
+		frames, length, hasMoreData = f.appendStreamFrame(id, frames, length, maxLen, v)
+		if hasMoreData { // put the stream back in the queue (at the end)
+			q.incremental.PushBack(id)
+		} else { // no more data to send. Stream is not active
+			delete(f.activeStreams, id)
+		}
+	}
+	return frames, length
+}
+
+// appendStreamFrame pops a STREAM frame from a stream, and appends it to frames.
+// hasMoreData is false if the stream doesn't have any more data to send.
+func (f *framerI) appendStreamFrame(
+	id protocol.StreamID,
+	frames []ackhandler.StreamFrame,
+	length, maxLen protocol.ByteCount,
+	v protocol.VersionNumber,
+) (_ []ackhandler.StreamFrame, _ protocol.ByteCount, hasMoreData bool) {
+	// This should never return an error. Better check it anyway.
+	// The stream will only be in the streamQueue, if it enqueued itself there.
+	str, err := f.streamGetter.GetOrOpenSendStream(id)
+	// The stream can be nil if it completed after it said it had data.
+	if str == nil || err != nil {
+		return frames, length, false
+	}
+	remainingLen := maxLen - length
+	// For the last STREAM frame, we'll remove the DataLen field later.
+	// Therefore, we can pretend to have more bytes available when popping
+	// the STREAM frame (which will always have the DataLen set).
+	remainingLen += quicvarint.Len(uint64(remainingLen))
+	frame, ok, hasMoreData := str.popStreamFrame(remainingLen, v)
+	// The frame can be "nil"
+	// * if the receiveStream was canceled after it said it had data
+	// * the remaining size doesn't allow us to add another STREAM frame
+	if !ok {
+		return frames, length, hasMoreData
+	}
+	return append(frames, frame), length + frame.Frame.Length(v), hasMoreData
+}
+
 func (f *framerI) Handle0RTTRejection() error {
 	f.mutex.Lock()
 	defer f.mutex.Unlock()
 
 	f.controlFrameMutex.Lock()
-	f.streamQueue.Clear()
+	for i := range f.streamQueues {
+		f.streamQueues[i].Clear()
+	}
 	for id := range f.activeStreams {
 		delete(f.activeStreams, id)
 	}
@@ -124,7 +124,7 @@ var _ = Describe("Framer", func() {
 				DataLenPresent: true,
 			}
 			stream1.EXPECT().popStreamFrame(gomock.Any(), protocol.Version1).Return(ackhandler.StreamFrame{Frame: f}, true, false)
-			framer.AddActiveStream(id1)
+			framer.AddActiveStream(id1, defaultStreamPriority)
 			fs, length := framer.AppendStreamFrames(nil, 1000, protocol.Version1)
 			Expect(fs).To(HaveLen(1))
 			Expect(fs[0].Frame.DataLenPresent).To(BeFalse())
@@ -134,7 +134,7 @@ var _ = Describe("Framer", func() {
 		It("says if it has data", func() {
 			streamGetter.EXPECT().GetOrOpenSendStream(id1).Return(stream1, nil).Times(2)
 			Expect(framer.HasData()).To(BeFalse())
-			framer.AddActiveStream(id1)
+			framer.AddActiveStream(id1, defaultStreamPriority)
 			Expect(framer.HasData()).To(BeTrue())
 			f1 := &wire.StreamFrame{StreamID: id1, Data: []byte("foo")}
 			f2 := &wire.StreamFrame{StreamID: id1, Data: []byte("bar")}
@@ -158,7 +158,7 @@ var _ = Describe("Framer", func() {
 				DataLenPresent: true,
 			}
 			stream1.EXPECT().popStreamFrame(gomock.Any(), protocol.Version1).Return(ackhandler.StreamFrame{Frame: f}, true, false)
-			framer.AddActiveStream(id1)
+			framer.AddActiveStream(id1, defaultStreamPriority)
 			f0 := ackhandler.StreamFrame{Frame: &wire.StreamFrame{StreamID: 9999}}
 			frames := []ackhandler.StreamFrame{f0}
 			fs, length := framer.AppendStreamFrames(frames, 1000, protocol.Version1)
@@ -178,8 +178,8 @@ var _ = Describe("Framer", func() {
 				DataLenPresent: true,
 			}
 			stream2.EXPECT().popStreamFrame(gomock.Any(), protocol.Version1).Return(ackhandler.StreamFrame{Frame: f}, true, false)
-			framer.AddActiveStream(id1)
-			framer.AddActiveStream(id2)
+			framer.AddActiveStream(id1, defaultStreamPriority)
+			framer.AddActiveStream(id2, defaultStreamPriority)
 			frames, _ := framer.AppendStreamFrames(nil, 1000, protocol.Version1)
 			Expect(frames).To(HaveLen(1))
 			Expect(frames[0].Frame).To(Equal(f))
@@ -195,8 +195,8 @@ var _ = Describe("Framer", func() {
 			}
 			stream1.EXPECT().popStreamFrame(gomock.Any(), protocol.Version1).Return(ackhandler.StreamFrame{}, false, false)
 			stream2.EXPECT().popStreamFrame(gomock.Any(), protocol.Version1).Return(ackhandler.StreamFrame{Frame: f}, true, false)
-			framer.AddActiveStream(id1)
-			framer.AddActiveStream(id2)
+			framer.AddActiveStream(id1, defaultStreamPriority)
+			framer.AddActiveStream(id2, defaultStreamPriority)
 			frames, _ := framer.AppendStreamFrames(nil, 1000, protocol.Version1)
 			Expect(frames).To(HaveLen(1))
 			Expect(frames[0].Frame).To(Equal(f))
@@ -208,7 +208,7 @@ var _ = Describe("Framer", func() {
 			f2 := &wire.StreamFrame{StreamID: id1, Data: []byte("foobaz")}
 			stream1.EXPECT().popStreamFrame(gomock.Any(), protocol.Version1).Return(ackhandler.StreamFrame{Frame: f1}, true, true)
 			stream1.EXPECT().popStreamFrame(gomock.Any(), protocol.Version1).Return(ackhandler.StreamFrame{Frame: f2}, true, false)
-			framer.AddActiveStream(id1) // only add it once
+			framer.AddActiveStream(id1, defaultStreamPriority) // only add it once
 			frames, _ := framer.AppendStreamFrames(nil, protocol.MinStreamFrameSize, protocol.Version1)
 			Expect(frames).To(HaveLen(1))
 			Expect(frames[0].Frame).To(Equal(f1))
@@ -229,8 +229,8 @@ var _ = Describe("Framer", func() {
 			stream1.EXPECT().popStreamFrame(gomock.Any(), protocol.Version1).Return(ackhandler.StreamFrame{Frame: f11}, true, true)
 			stream1.EXPECT().popStreamFrame(gomock.Any(), protocol.Version1).Return(ackhandler.StreamFrame{Frame: f12}, true, false)
 			stream2.EXPECT().popStreamFrame(gomock.Any(), protocol.Version1).Return(ackhandler.StreamFrame{Frame: f2}, true, false)
-			framer.AddActiveStream(id1) // only add it once
-			framer.AddActiveStream(id2)
+			framer.AddActiveStream(id1, defaultStreamPriority) // only add it once
+			framer.AddActiveStream(id2, defaultStreamPriority)
 			// first a frame from stream 1
 			frames, _ := framer.AppendStreamFrames(nil, protocol.MinStreamFrameSize, protocol.Version1)
 			Expect(frames).To(HaveLen(1))
@@ -253,8 +253,8 @@ var _ = Describe("Framer", func() {
 			// both streams have more data, and will be re-queued
 			stream1.EXPECT().popStreamFrame(gomock.Any(), protocol.Version1).Return(ackhandler.StreamFrame{Frame: f1}, true, true)
 			stream2.EXPECT().popStreamFrame(gomock.Any(), protocol.Version1).Return(ackhandler.StreamFrame{Frame: f2}, true, true)
-			framer.AddActiveStream(id1)
-			framer.AddActiveStream(id2)
+			framer.AddActiveStream(id1, defaultStreamPriority)
+			framer.AddActiveStream(id2, defaultStreamPriority)
 			frames, length := framer.AppendStreamFrames(nil, 1000, protocol.Version1)
 			Expect(frames).To(HaveLen(2))
 			Expect(frames[0].Frame).To(Equal(f1))
@@ -269,8 +269,8 @@ var _ = Describe("Framer", func() {
 			f2 := &wire.StreamFrame{Data: []byte("foobaz")}
 			stream1.EXPECT().popStreamFrame(gomock.Any(), protocol.Version1).Return(ackhandler.StreamFrame{Frame: f1}, true, false)
 			stream2.EXPECT().popStreamFrame(gomock.Any(), protocol.Version1).Return(ackhandler.StreamFrame{Frame: f2}, true, false)
-			framer.AddActiveStream(id2)
-			framer.AddActiveStream(id1)
+			framer.AddActiveStream(id2, defaultStreamPriority)
+			framer.AddActiveStream(id1, defaultStreamPriority)
 			frames, _ := framer.AppendStreamFrames(nil, 1000, protocol.Version1)
 			Expect(frames).To(HaveLen(2))
 			Expect(frames[0].Frame).To(Equal(f2))
@@ -281,8 +281,8 @@ var _ = Describe("Framer", func() {
 			streamGetter.EXPECT().GetOrOpenSendStream(id1).Return(stream1, nil)
 			f := &wire.StreamFrame{Data: []byte("foobar")}
 			stream1.EXPECT().popStreamFrame(gomock.Any(), protocol.Version1).Return(ackhandler.StreamFrame{Frame: f}, true, false) // only one call to this function
-			framer.AddActiveStream(id1)
-			framer.AddActiveStream(id1)
+			framer.AddActiveStream(id1, defaultStreamPriority)
+			framer.AddActiveStream(id1, defaultStreamPriority)
 			frames, _ := framer.AppendStreamFrames(nil, 1000, protocol.Version1)
 			Expect(frames).To(HaveLen(1))
 		})
@@ -305,7 +305,7 @@ var _ = Describe("Framer", func() {
 					Expect(f.Length(version)).To(Equal(size))
 					return ackhandler.StreamFrame{Frame: f}, true, false
 				})
-				framer.AddActiveStream(id1)
+				framer.AddActiveStream(id1, defaultStreamPriority)
 				frames, _ := framer.AppendStreamFrames(nil, i, protocol.Version1)
 				Expect(frames).To(HaveLen(1))
 				f := frames[0].Frame
@@ -335,8 +335,8 @@ var _ = Describe("Framer", func() {
 					Expect(f.Length(version)).To(Equal(size))
 					return ackhandler.StreamFrame{Frame: f}, true, false
 				})
-				framer.AddActiveStream(id1)
-				framer.AddActiveStream(id2)
+				framer.AddActiveStream(id1, defaultStreamPriority)
+				framer.AddActiveStream(id2, defaultStreamPriority)
 				frames, _ := framer.AppendStreamFrames(nil, i, protocol.Version1)
 				Expect(frames).To(HaveLen(2))
 				f1 := frames[0].Frame
@@ -351,7 +351,7 @@ var _ = Describe("Framer", func() {
 			streamGetter.EXPECT().GetOrOpenSendStream(id1).Return(stream1, nil)
 			f := &wire.StreamFrame{Data: []byte("foobar")}
 			stream1.EXPECT().popStreamFrame(gomock.Any(), protocol.Version1).Return(ackhandler.StreamFrame{Frame: f}, true, false)
-			framer.AddActiveStream(id1)
+			framer.AddActiveStream(id1, defaultStreamPriority)
 			framer.AppendStreamFrames(nil, protocol.MinStreamFrameSize, protocol.Version1)
 		})
 
@@ -369,7 +369,7 @@ var _ = Describe("Framer", func() {
 				DataLenPresent: true,
 			}
 			stream1.EXPECT().popStreamFrame(gomock.Any(), protocol.Version1).Return(ackhandler.StreamFrame{Frame: f}, true, false)
-			framer.AddActiveStream(id1)
+			framer.AddActiveStream(id1, defaultStreamPriority)
 			fs, length := framer.AppendStreamFrames(nil, 500, protocol.Version1)
 			Expect(fs).To(HaveLen(1))
 			Expect(fs[0].Frame).To(Equal(f))
@@ -377,11 +377,125 @@ var _ = Describe("Framer", func() {
 		})
 
 		It("drops all STREAM frames when 0-RTT is rejected", func() {
-			framer.AddActiveStream(id1)
+			framer.AddActiveStream(id1, defaultStreamPriority)
 			Expect(framer.Handle0RTTRejection()).To(Succeed())
 			fs, length := framer.AppendStreamFrames(nil, protocol.MaxByteCount, protocol.Version1)
 			Expect(fs).To(BeEmpty())
 			Expect(length).To(BeZero())
 		})
 	})
+
+	Context("prioritizing streams", func() {
+		const id3 = protocol.StreamID(14)
+
+		var stream3 *MockSendStreamI
+
+		BeforeEach(func() {
+			stream3 = NewMockSendStreamI(mockCtrl)
+			streamGetter.EXPECT().GetOrOpenSendStream(id1).Return(stream1, nil).AnyTimes()
+			streamGetter.EXPECT().GetOrOpenSendStream(id2).Return(stream2, nil).AnyTimes()
+			streamGetter.EXPECT().GetOrOpenSendStream(id3).Return(stream3, nil).AnyTimes()
+		})
+
+		// expectPop makes the stream return a STREAM frame that fills exactly size bytes
+		expectPop := func(str *MockSendStreamI, id protocol.StreamID, size protocol.ByteCount, hasMoreData bool) {
+			str.EXPECT().popStreamFrame(gomock.Any(), protocol.Version1).DoAndReturn(func(protocol.ByteCount, protocol.VersionNumber) (ackhandler.StreamFrame, bool, bool) {
+				f := &wire.StreamFrame{StreamID: id, DataLenPresent: true}
+				f.Data = make([]byte, size-f.Length(protocol.Version1))
+				return ackhandler.StreamFrame{Frame: f}, true, hasMoreData
+			})
+		}
+
+		streamIDs := func(frames []ackhandler.StreamFrame) []protocol.StreamID {
+			ids := make([]protocol.StreamID, 0, len(frames))
+			for _, f := range frames {
+				ids = append(ids, f.Frame.StreamID)
+			}
+			return ids
+		}
+
+		It("sends data of streams with a lower urgency first", func() {
+			framer.AddActiveStream(id1, StreamPriority{Urgency: 5, Incremental: true})
+			framer.AddActiveStream(id2, StreamPriority{Urgency: 1, Incremental: true})
+			expectPop(stream2, id2, 500, true)
+			fs, _ := framer.AppendStreamFrames(nil, 500, protocol.Version1)
+			Expect(streamIDs(fs)).To(Equal([]protocol.StreamID{id2}))
+			expectPop(stream2, id2, 200, false)
+			expectPop(stream1, id1, 300, true)
+			fs, _ = framer.AppendStreamFrames(nil, 500, protocol.Version1)
+			Expect(streamIDs(fs)).To(Equal([]protocol.StreamID{id2, id1}))
+			Expect(framer.HasData()).To(BeTrue())
+		})
+
+		It("sends non-incremental streams one after another, ordered by stream ID", func() {
+			prio := StreamPriority{Urgency: 3}
+			framer.AddActiveStream(id3, prio)
+			framer.AddActiveStream(id1, prio)
+			framer.AddActiveStream(id2, prio)
+			expectPop(stream1, id1, 500, true)
+			fs, _ := framer.AppendStreamFrames(nil, 500, protocol.Version1)
+			Expect(streamIDs(fs)).To(Equal([]protocol.StreamID{id1}))
+			expectPop(stream1, id1, 500, true)
+			fs, _ = framer.AppendStreamFrames(nil, 500, protocol.Version1)
+			Expect(streamIDs(fs)).To(Equal([]protocol.StreamID{id1}))
+			expectPop(stream1, id1, 100, false)
+			expectPop(stream2, id2, 100, false)
+			expectPop(stream3, id3, 300, true)
+			fs, _ = framer.AppendStreamFrames(nil, 500, protocol.Version1)
+			Expect(streamIDs(fs)).To(Equal([]protocol.StreamID{id1, id2, id3}))
+		})
+
+		It("sends non-incremental streams before incremental streams of the same urgency", func() {
+			framer.AddActiveStream(id1, StreamPriority{Urgency: 3, Incremental: true})
+			framer.AddActiveStream(id2, StreamPriority{Urgency: 3})
+			expectPop(stream2, id2, 500, true)
+			fs, _ := framer.AppendStreamFrames(nil, 500, protocol.Version1)
+			Expect(streamIDs(fs)).To(Equal([]protocol.StreamID{id2}))
+		})
+
+		It("round-robins incremental streams of the same urgency", func() {
+			prio := StreamPriority{Urgency: 2, Incremental: true}
+			framer.AddActiveStream(id1, prio)
+			framer.AddActiveStream(id2, prio)
+			framer.AddActiveStream(id3, prio)
+			expectPop(stream1, id1, 500, true)
+			fs, _ := framer.AppendStreamFrames(nil, 500, protocol.Version1)
+			Expect(streamIDs(fs)).To(Equal([]protocol.StreamID{id1}))
+			expectPop(stream2, id2, 500, true)
+			fs, _ = framer.AppendStreamFrames(nil, 500, protocol.Version1)
+			Expect(streamIDs(fs)).To(Equal([]protocol.StreamID{id2}))
+			expectPop(stream3, id3, 500, true)
+			fs, _ = framer.AppendStreamFrames(nil, 500, protocol.Version1)
+			Expect(streamIDs(fs)).To(Equal([]protocol.StreamID{id3}))
+			expectPop(stream1, id1, 500, true)
+			fs, _ = framer.AppendStreamFrames(nil, 500, protocol.Version1)
+			Expect(streamIDs(fs)).To(Equal([]protocol.StreamID{id1}))
+		})
+
+		It("re-queues an active stream when its priority changes", func() {
+			framer.AddActiveStream(id1, StreamPriority{Urgency: 1, Incremental: true})
+			framer.AddActiveStream(id2, StreamPriority{Urgency: 4, Incremental: true})
+			framer.AddActiveStream(id2, StreamPriority{Urgency: 0})
+			expectPop(stream2, id2, 500, true)
+			fs, _ := framer.AppendStreamFrames(nil, 500, protocol.Version1)
+			Expect(streamIDs(fs)).To(Equal([]protocol.StreamID{id2}))
+			// the stream is only queued once
+			expectPop(stream2, id2, 200, false)
+			expectPop(stream1, id1, 300, false)
+			fs, _ = framer.AppendStreamFrames(nil, 500, protocol.Version1)
+			Expect(streamIDs(fs)).To(Equal([]protocol.StreamID{id2, id1}))
+			Expect(framer.HasData()).To(BeFalse())
+		})
+
+		It("treats urgencies larger than 7 as 7", func() {
+			framer.AddActiveStream(id1, StreamPriority{Urgency: 200, Incremental: true})
+			framer.AddActiveStream(id2, StreamPriority{Urgency: 7, Incremental: true})
+			expectPop(stream1, id1, 500, true)
+			fs, _ := framer.AppendStreamFrames(nil, 500, protocol.Version1)
+			Expect(streamIDs(fs)).To(Equal([]protocol.StreamID{id1}))
+			expectPop(stream2, id2, 500, true)
+			fs, _ = framer.AppendStreamFrames(nil, 500, protocol.Version1)
+			Expect(streamIDs(fs)).To(Equal([]protocol.StreamID{id2}))
+		})
+	})
 })
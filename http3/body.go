@@ -42,6 +42,9 @@ type body struct {
 	str quic.Stream
 
 	wasHijacked bool // set when HTTPStream is called
+
+	// only set for the http.Request: called before the body is read for the first time
+	onFirstRead func()
 }
 
 var (
@@ -63,6 +66,10 @@ func (r *body) wasStreamHijacked() bool {
 }
 
 func (r *body) Read(b []byte) (int, error) {
+	if r.onFirstRead != nil {
+		r.onFirstRead()
+		r.onFirstRead = nil
+	}
 	n, err := r.str.Read(b)
 	return n, maybeReplaceError(err)
 }
@@ -81,20 +88,28 @@ type hijackableBody struct {
 	// either when Read() errors, or when Close() is called.
 	reqDone       chan<- struct{}
 	reqDoneClosed bool
+
+	updatePriority func(Priority) error
+
+	// only set for responses to CONNECT requests
+	datagrams *streamDatagrammer
 }
 
 var (
-	_ Hijacker     = &hijackableBody{}
-	_ HTTPStreamer = &hijackableBody{}
+	_ Hijacker        = &hijackableBody{}
+	_ HTTPStreamer    = &hijackableBody{}
+	_ PriorityUpdater = &hijackableBody{}
+	_ HTTPDatagrammer = &hijackableBody{}
 )
 
-func newResponseBody(str Stream, conn quic.Connection, done chan<- struct{}) *hijackableBody {
+func newResponseBody(str Stream, conn quic.Connection, done chan<- struct{}, updatePriority func(Priority) error) *hijackableBody {
 	return &hijackableBody{
 		body: body{
 			str: str,
 		},
-		reqDone: done,
-		conn:    conn,
+		reqDone:        done,
+		conn:           conn,
+		updatePriority: updatePriority,
 	}
 }
 
@@ -102,6 +117,28 @@ func (r *hijackableBody) StreamCreator() StreamCreator {
 	return r.conn
 }
 
+// UpdatePriority changes the priority of the request, by sending a PRIORITY_UPDATE frame to the server.
+func (r *hijackableBody) UpdatePriority(p Priority) error {
+	return r.updatePriority(p)
+}
+
+// SendDatagram sends an HTTP Datagram associated with the request.
+// If QUIC datagrams are not available, it writes a DATAGRAM capsule to the request body.
+func (r *hijackableBody) SendDatagram(b []byte) error {
+	if r.datagrams == nil {
+		return errDatagramsNotSupported
+	}
+	return r.datagrams.SendDatagram(b)
+}
+
+// ReceiveDatagram receives an HTTP Datagram associated with the request.
+func (r *hijackableBody) ReceiveDatagram(ctx context.Context) ([]byte, error) {
+	if r.datagrams == nil {
+		return nil, errDatagramsNotSupported
+	}
+	return r.datagrams.ReceiveDatagram(ctx)
+}
+
 func (r *hijackableBody) Read(b []byte) (int, error) {
 	n, err := r.str.Read(b)
 	if err != nil {
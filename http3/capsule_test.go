@@ -3,6 +3,7 @@ package http3
 import (
 	"bytes"
 	"io"
+	"testing/iotest"
 
 	"github.com/quic-go/quic-go/quicvarint"
 
@@ -24,6 +25,19 @@ var _ = Describe("Capsule", func() {
 		Expect(string(val)).To(Equal("foobar"))
 	})
 
+	It("parses Capsules that are read in multiple chunks", func() {
+		b := quicvarint.Append(nil, 1337)
+		b = quicvarint.Append(b, 6)
+		b = append(b, []byte("foobar")...)
+
+		ct, r, err := ParseCapsule(quicvarint.NewReader(iotest.OneByteReader(bytes.NewReader(b))))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ct).To(BeEquivalentTo(1337))
+		val, err := io.ReadAll(r)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(val)).To(Equal("foobar"))
+	})
+
 	It("writes capsules", func() {
 		var buf bytes.Buffer
 		WriteCapsule(&buf, 1337, []byte("foobar"))
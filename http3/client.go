@@ -8,7 +8,10 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/http/httptrace"
+	"net/textproto"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -17,8 +20,6 @@ import (
 	"github.com/quic-go/quic-go/internal/protocol"
 	"github.com/quic-go/quic-go/internal/utils"
 	"github.com/quic-go/quic-go/quicvarint"
-
-	"github.com/quic-go/qpack"
 )
 
 // MethodGet0RTT allows a GET request to be sent using 0-RTT.
@@ -28,6 +29,8 @@ const MethodGet0RTT = "GET_0RTT"
 const (
 	defaultUserAgent              = "quic-go HTTP/3"
 	defaultMaxResponseHeaderBytes = 10 * 1 << 20 // 10 MB
+	// the maximum number of interim (1xx) responses accepted before the final response
+	max1xxResponses = 5
 )
 
 var defaultQuicConfig = &quic.Config{
@@ -40,12 +43,14 @@ type dialFunc func(ctx context.Context, addr string, tlsCfg *tls.Config, cfg *qu
 var dialAddr dialFunc = quic.DialAddrEarly
 
 type roundTripperOpts struct {
-	DisableCompression bool
-	EnableDatagram     bool
-	MaxHeaderBytes     int64
-	AdditionalSettings map[uint64]uint64
-	StreamHijacker     func(FrameType, quic.Connection, quic.Stream, error) (hijacked bool, err error)
-	UniStreamHijacker  func(StreamType, quic.Connection, quic.ReceiveStream, error) (hijacked bool)
+	DisableCompression    bool
+	EnableDatagram        bool
+	MaxHeaderBytes        int64
+	QPACKMaxTableCapacity int
+	ExpectContinueTimeout time.Duration
+	AdditionalSettings    map[uint64]uint64
+	StreamHijacker        func(FrameType, quic.Connection, quic.Stream, error) (hijacked bool, err error)
+	UniStreamHijacker     func(StreamType, quic.Connection, quic.ReceiveStream, error) (hijacked bool)
 }
 
 // client is a HTTP3 client doing requests
@@ -60,7 +65,30 @@ type client struct {
 
 	requestWriter *requestWriter
 
-	decoder *qpack.Decoder
+	// the maximum capacity of the QPACK dynamic tables, and the number of streams that may be blocked
+	maxTableCapacity  uint64
+	maxBlockedStreams uint64
+	encoder           *qpackEncoder
+	decoder           *qpackDecoder
+
+	// closed once the control stream was opened (or opening it failed)
+	controlStrReady chan struct{}
+	controlStr      quic.SendStream // nil if opening the control stream failed
+	controlStrMutex sync.Mutex      // serializes writes to the control stream
+
+	// closed when the server's SETTINGS frame was received
+	settingsReceived chan struct{}
+	settings         *settingsFrame // set before settingsReceived is closed
+
+	datagrams *datagramManager
+
+	mutex sync.Mutex
+	// closed when the first GOAWAY frame is received
+	goAwayReceived chan struct{}
+	// the stream ID from the most recent GOAWAY frame
+	goAwayStreamID quic.StreamID
+	// the number of requests that are still in flight
+	activeRequests int
 
 	hostname string
 	conn     atomic.Pointer[quic.EarlyConnection]
@@ -103,16 +131,27 @@ func newClient(hostname string, tlsConf *tls.Config, opts *roundTripperOpts, con
 	// Replace existing ALPNs by H3
 	tlsConf.NextProtos = []string{versionToALPN(conf.Versions[0])}
 
-	return &client{
-		hostname:      authorityAddr("https", hostname),
-		tlsConf:       tlsConf,
-		requestWriter: newRequestWriter(logger),
-		decoder:       qpack.NewDecoder(func(hf qpack.HeaderField) {}),
-		config:        conf,
-		opts:          opts,
-		dialer:        dialer,
-		logger:        logger,
-	}, nil
+	c := &client{
+		hostname:         authorityAddr("https", hostname),
+		tlsConf:          tlsConf,
+		maxTableCapacity: qpackTableCapacity(opts.QPACKMaxTableCapacity),
+		controlStrReady:  make(chan struct{}),
+		settingsReceived: make(chan struct{}),
+		goAwayReceived:   make(chan struct{}),
+		config:           conf,
+		opts:             opts,
+		dialer:           dialer,
+		logger:           logger,
+	}
+	if c.maxTableCapacity > 0 {
+		c.maxBlockedStreams = qpackMaxBlockedStreams
+	}
+	// The QPACK encoder and decoder streams are only opened once they're needed, which is after dialing.
+	openUniStream := func() (quic.SendStream, error) { return (*c.conn.Load()).OpenUniStream() }
+	c.encoder = newQPACKEncoder(c.maxTableCapacity, openUniStream)
+	c.decoder = newQPACKDecoder(c.maxTableCapacity, c.maxBlockedStreams, openUniStream)
+	c.requestWriter = newRequestWriter(c.encoder, logger)
+	return c, nil
 }
 
 func (c *client) dial(ctx context.Context) error {
@@ -136,6 +175,11 @@ func (c *client) dial(ctx context.Context) error {
 		}
 	}()
 
+	c.datagrams = newDatagramManager(conn, c.opts.EnableDatagram)
+	if c.opts.EnableDatagram {
+		go c.datagrams.Run()
+	}
+
 	if c.opts.StreamHijacker != nil {
 		go c.handleBidirectionalStreams(conn)
 	}
@@ -144,6 +188,7 @@ func (c *client) dial(ctx context.Context) error {
 }
 
 func (c *client) setupConn(conn quic.EarlyConnection) error {
+	defer close(c.controlStrReady)
 	// open the control stream
 	str, err := conn.OpenUniStream()
 	if err != nil {
@@ -152,8 +197,48 @@ func (c *client) setupConn(conn quic.EarlyConnection) error {
 	b := make([]byte, 0, 64)
 	b = quicvarint.Append(b, streamTypeControlStream)
 	// send the SETTINGS frame
-	b = (&settingsFrame{Datagram: c.opts.EnableDatagram, Other: c.opts.AdditionalSettings}).Append(b)
-	_, err = str.Write(b)
+	b = (&settingsFrame{
+		Datagram:              c.opts.EnableDatagram,
+		QPACKMaxTableCapacity: c.maxTableCapacity,
+		QPACKBlockedStreams:   c.maxBlockedStreams,
+		MaxFieldSectionSize:   c.maxHeaderBytes(),
+		Other:                 c.opts.AdditionalSettings,
+	}).Append(b)
+	if _, err := str.Write(b); err != nil {
+		return err
+	}
+	c.controlStr = str
+	return nil
+}
+
+var errNoControlStream = errors.New("http3: control stream not available")
+
+var errExtendedConnectNotEnabled = errors.New("http3: server didn't enable Extended CONNECT")
+
+var (
+	// errGoAway is returned when a request can't be sent, since the server sent a GOAWAY frame.
+	errGoAway = errors.New("http3: server is going away")
+	// errRequestNotProcessed is returned when the server didn't process a request,
+	// either because its stream ID was larger than the ID in the GOAWAY frame,
+	// or because it was rejected using H3_REQUEST_REJECTED.
+	errRequestNotProcessed = errors.New("http3: request not processed by the server")
+)
+
+func isRequestRejected(err error) bool {
+	var serr *quic.StreamError
+	return errors.As(err, &serr) && serr.Remote && serr.ErrorCode == quic.StreamErrorCode(ErrCodeRequestRejected)
+}
+
+// sendPriorityUpdate sends a PRIORITY_UPDATE frame for a request stream on the control stream.
+func (c *client) sendPriorityUpdate(id quic.StreamID, p Priority) error {
+	<-c.controlStrReady
+	if c.controlStr == nil {
+		return errNoControlStream
+	}
+	b := (&priorityUpdateFrame{PrioritizedElementID: uint64(id), PriorityFieldValue: p.String()}).Append(nil)
+	c.controlStrMutex.Lock()
+	defer c.controlStrMutex.Unlock()
+	_, err := c.controlStr.Write(b)
 	return err
 }
 
@@ -180,6 +265,8 @@ func (c *client) handleBidirectionalStreams(conn quic.EarlyConnection) {
 }
 
 func (c *client) handleUnidirectionalStreams(conn quic.EarlyConnection) {
+	var rcvdControlStr, rcvdQPACKEncoderStr, rcvdQPACKDecoderStr atomic.Bool
+
 	for {
 		str, err := conn.AcceptUniStream(context.Background())
 		if err != nil {
@@ -196,12 +283,26 @@ func (c *client) handleUnidirectionalStreams(conn quic.EarlyConnection) {
 				c.logger.Debugf("reading stream type on stream %d failed: %s", str.StreamID(), err)
 				return
 			}
-			// We're only interested in the control stream here.
+			// Only one stream of each of the critical stream types may be opened, see section 6.2 of RFC 9114 and section 4.2 of RFC 9204.
 			switch streamType {
 			case streamTypeControlStream:
-			case streamTypeQPACKEncoderStream, streamTypeQPACKDecoderStream:
-				// Our QPACK implementation doesn't use the dynamic table yet.
-				// TODO: check that only one stream of each type is opened.
+				if isFirst := rcvdControlStr.CompareAndSwap(false, true); !isFirst {
+					conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeStreamCreationError), "duplicate control stream")
+					return
+				}
+			case streamTypeQPACKEncoderStream:
+				if isFirst := rcvdQPACKEncoderStr.CompareAndSwap(false, true); !isFirst {
+					conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeStreamCreationError), "duplicate QPACK encoder stream")
+					return
+				}
+				handleQPACKStreamError(conn, c.decoder.HandleEncoderStream(str))
+				return
+			case streamTypeQPACKDecoderStream:
+				if isFirst := rcvdQPACKDecoderStr.CompareAndSwap(false, true); !isFirst {
+					conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeStreamCreationError), "duplicate QPACK decoder stream")
+					return
+				}
+				handleQPACKStreamError(conn, c.encoder.HandleDecoderStream(str))
 				return
 			case streamTypePushStream:
 				// We never increased the Push ID, so we don't expect any push streams.
@@ -214,9 +315,10 @@ func (c *client) handleUnidirectionalStreams(conn quic.EarlyConnection) {
 				str.CancelRead(quic.StreamErrorCode(ErrCodeStreamCreationError))
 				return
 			}
-			f, err := parseNextFrame(str, nil)
+			r := &controlStreamReader{ReceiveStream: str}
+			f, err := parseNextFrame(r, nil)
 			if err != nil {
-				conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeFrameError), "")
+				r.closeConnection(conn, err)
 				return
 			}
 			sf, ok := f.(*settingsFrame)
@@ -224,19 +326,143 @@ func (c *client) handleUnidirectionalStreams(conn quic.EarlyConnection) {
 				conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeMissingSettings), "")
 				return
 			}
-			if !sf.Datagram {
-				return
-			}
 			// If datagram support was enabled on our side as well as on the server side,
 			// we can expect it to have been negotiated both on the transport and on the HTTP/3 layer.
 			// Note: ConnectionState() will block until the handshake is complete (relevant when using 0-RTT).
-			if c.opts.EnableDatagram && !conn.ConnectionState().SupportsDatagrams {
+			if sf.Datagram && c.opts.EnableDatagram && !conn.ConnectionState().SupportsDatagrams {
 				conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeSettingsError), "missing QUIC Datagram support")
+				return
+			}
+			c.mutex.Lock()
+			select {
+			case <-c.settingsReceived:
+			default:
+				c.settings = sf
+				close(c.settingsReceived)
 			}
+			c.mutex.Unlock()
+			c.datagrams.HandleSettings(sf)
+			c.encoder.HandleSettings(sf.QPACKMaxTableCapacity, sf.MaxFieldSectionSize)
+			c.handleControlStream(conn, r)
 		}(str)
 	}
 }
 
+// handleControlStream handles the frames sent on the control stream after the SETTINGS frame.
+func (c *client) handleControlStream(conn quic.EarlyConnection, r *controlStreamReader) {
+	for {
+		f, err := parseNextFrame(r, nil)
+		if err != nil {
+			c.logger.Debugf("reading from the control stream failed: %s", err)
+			r.closeConnection(conn, err)
+			return
+		}
+		switch f := f.(type) {
+		case *goAwayFrame:
+			if err := c.handleGoAway(conn, f.StreamID); err != nil {
+				conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeIDError), err.Error())
+				return
+			}
+		case *skippedFrame:
+			// We never sent a MAX_PUSH_ID frame, so there are no push IDs that could be canceled.
+			if f.Type == frameTypeCancelPush {
+				conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeIDError), "CANCEL_PUSH frame for a push ID that was never allowed")
+				return
+			}
+			conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeFrameUnexpected), fmt.Sprintf("unexpected frame on the control stream: 0x%x", f.Type))
+			return
+		// Only clients send PRIORITY_UPDATE frames, see section 7 of RFC 9218.
+		case *dataFrame, *headersFrame, *settingsFrame, *priorityUpdateFrame:
+			conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeFrameUnexpected), fmt.Sprintf("unexpected frame on the control stream: %T", f))
+			return
+		}
+	}
+}
+
+// handleGoAway handles a GOAWAY frame, see section 5.2 of RFC 9114.
+// Requests on streams with IDs equal to or larger than the ID in the GOAWAY frame weren't processed by the server.
+// They are canceled by the goroutine started for every request, and retried by the RoundTripper.
+func (c *client) handleGoAway(conn quic.EarlyConnection, id quic.StreamID) error {
+	// only client-initiated bidirectional streams are request streams
+	if id%4 != 0 {
+		return fmt.Errorf("GOAWAY frame for an invalid stream ID: %d", id)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	select {
+	case <-c.goAwayReceived:
+		if id > c.goAwayStreamID {
+			return fmt.Errorf("GOAWAY frame increased the stream ID from %d to %d", c.goAwayStreamID, id)
+		}
+		c.goAwayStreamID = id
+	default:
+		c.goAwayStreamID = id
+		close(c.goAwayReceived)
+	}
+	if c.activeRequests == 0 {
+		conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeNoError), "")
+	}
+	return nil
+}
+
+// waitForExtendedConnect waits for the server's SETTINGS frame.
+// Extended CONNECT requests must only be sent if the server enabled SETTINGS_ENABLE_CONNECT_PROTOCOL, see section 3 of RFC 9220.
+func (c *client) waitForExtendedConnect(ctx context.Context, conn quic.EarlyConnection) error {
+	select {
+	case <-c.settingsReceived:
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-conn.Context().Done():
+		return context.Cause(conn.Context())
+	}
+	if !c.settings.ExtendedConnect {
+		return errExtendedConnectNotEnabled
+	}
+	return nil
+}
+
+// startRequest is called before a new request stream is opened.
+// Once a GOAWAY frame was received, no new requests are sent on this connection.
+func (c *client) startRequest() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	select {
+	case <-c.goAwayReceived:
+		return errGoAway
+	default:
+	}
+	c.activeRequests++
+	return nil
+}
+
+// finishRequest is called when a request is done.
+// If a GOAWAY frame was received, the connection is closed once all outstanding requests are done.
+func (c *client) finishRequest(conn quic.EarlyConnection) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.activeRequests--
+	select {
+	case <-c.goAwayReceived:
+		if c.activeRequests == 0 {
+			conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeNoError), "")
+		}
+	default:
+	}
+}
+
+// rejectedByGoAway says if the server announced that it won't process the request on this stream.
+func (c *client) rejectedByGoAway(str quic.Stream) bool {
+	select {
+	case <-c.goAwayReceived:
+	default:
+		return false
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return str.StreamID() >= c.goAwayStreamID
+}
+
 func (c *client) Close() error {
 	conn := c.conn.Load()
 	if conn == nil {
@@ -280,8 +506,18 @@ func (c *client) RoundTripOpt(req *http.Request, opt RoundTripOpt) (*http.Respon
 		}
 	}
 
+	if isExtendedConnectRequest(req) {
+		if err := c.waitForExtendedConnect(req.Context(), conn); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.startRequest(); err != nil {
+		return nil, err
+	}
 	str, err := conn.OpenStreamSync(req.Context())
 	if err != nil {
+		c.finishRequest(conn)
 		return nil, err
 	}
 
@@ -292,11 +528,27 @@ func (c *client) RoundTripOpt(req *http.Request, opt RoundTripOpt) (*http.Respon
 	done := make(chan struct{})
 	go func() {
 		defer close(done)
-		select {
-		case <-req.Context().Done():
-			str.CancelWrite(quic.StreamErrorCode(ErrCodeRequestCanceled))
-			str.CancelRead(quic.StreamErrorCode(ErrCodeRequestCanceled))
-		case <-reqDone:
+		defer c.finishRequest(conn)
+		if req.Method == http.MethodConnect && !opt.DontCloseRequestStream {
+			defer c.datagrams.RemoveStream(str.StreamID())
+		}
+		goAwayReceived := c.goAwayReceived
+		for {
+			select {
+			case <-req.Context().Done():
+				str.CancelWrite(quic.StreamErrorCode(ErrCodeRequestCanceled))
+				str.CancelRead(quic.StreamErrorCode(ErrCodeRequestCanceled))
+				return
+			case <-goAwayReceived:
+				goAwayReceived = nil
+				if c.rejectedByGoAway(str) {
+					str.CancelWrite(quic.StreamErrorCode(ErrCodeRequestCanceled))
+					str.CancelRead(quic.StreamErrorCode(ErrCodeRequestCanceled))
+					return
+				}
+			case <-reqDone:
+				return
+			}
 		}
 	}()
 
@@ -308,6 +560,12 @@ func (c *client) RoundTripOpt(req *http.Request, opt RoundTripOpt) (*http.Respon
 	if rerr.err != nil { // if any error occurred
 		close(reqDone)
 		<-done
+		if req.Method == http.MethodConnect {
+			c.datagrams.RemoveStream(str.StreamID())
+		}
+		if c.rejectedByGoAway(str) || isRequestRejected(rerr.err) {
+			return nil, errRequestNotProcessed
+		}
 		if rerr.streamErr != 0 { // if it was a stream error
 			str.CancelWrite(quic.StreamErrorCode(rerr.streamErr))
 		}
@@ -323,6 +581,13 @@ func (c *client) RoundTripOpt(req *http.Request, opt RoundTripOpt) (*http.Respon
 	if opt.DontCloseRequestStream {
 		close(reqDone)
 		<-done
+		if req.Method == http.MethodConnect {
+			// The stream is now owned by the application, and done once it closes the stream.
+			go func() {
+				<-str.Context().Done()
+				c.datagrams.RemoveStream(str.StreamID())
+			}()
+		}
 	}
 	return rsp, maybeReplaceError(rerr.err)
 }
@@ -368,9 +633,16 @@ func (c *client) sendRequestBody(str Stream, body io.ReadCloser, contentLength i
 
 func (c *client) doRequest(req *http.Request, conn quic.EarlyConnection, str quic.Stream, opt RoundTripOpt, reqDone chan<- struct{}) (*http.Response, requestError) {
 	var requestGzip bool
-	if !c.opts.DisableCompression && req.Method != "HEAD" && req.Header.Get("Accept-Encoding") == "" && req.Header.Get("Range") == "" {
+	if !c.opts.DisableCompression && req.Method != "HEAD" && req.Method != http.MethodConnect && req.Header.Get("Accept-Encoding") == "" && req.Header.Get("Range") == "" {
 		requestGzip = true
 	}
+	// The priority of the request is also used for sending the request body.
+	// QUIC streams are incremental by default, so the default priority of RFC 9218 needs to be set explicitly.
+	priority := DefaultPriority
+	if v, ok := req.Header["Priority"]; ok {
+		priority = ParsePriority(strings.Join(v, ","))
+	}
+	str.SetPriority(priority.streamPriority())
 	if err := c.requestWriter.WriteRequestHeader(str, req, requestGzip); err != nil {
 		return nil, newStreamError(ErrCodeInternalError, err)
 	}
@@ -379,10 +651,58 @@ func (c *client) doRequest(req *http.Request, conn quic.EarlyConnection, str qui
 		str.Close()
 	}
 
-	hstr := newStream(str, func() { conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeFrameUnexpected), "") })
+	// The trailers can only be received after the response header, so res is set by then.
+	var res *http.Response
+	hstr := newStream(
+		str,
+		func() { conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeFrameUnexpected), "") },
+		func(r io.Reader, l uint64) error {
+			trailer, err := decodeTrailers(req.Context(), r, l, c.maxHeaderBytes(), str.StreamID(), c.decoder)
+			if err != nil {
+				return err
+			}
+			mergeTrailers(&res.Trailer, trailer)
+			return nil
+		},
+	)
+	// HTTP Datagrams can only be associated with CONNECT streams, see section 2 of RFC 9297.
+	// If they can't be sent in QUIC DATAGRAM frames, they are sent as DATAGRAM capsules in the request body.
+	var datagrams *streamDatagrammer
+	if req.Method == http.MethodConnect {
+		datagrams = c.datagrams.AddStream(str.StreamID(), func(b []byte) error {
+			_, err := hstr.Write(b)
+			return err
+		}, hstr, func(code quic.StreamErrorCode) {
+			str.CancelRead(code)
+			str.CancelWrite(code)
+		})
+	}
+	// If the request has an "Expect: 100-continue" header, the body is only sent once the server sent a 100 Continue response,
+	// or when the timeout expires.
+	// The channel receives true when the body should be sent, and false when the server sent the final response.
+	var continueChan chan bool
+	if req.Body != nil && c.opts.ExpectContinueTimeout > 0 && expectsContinue(req.Header) {
+		continueChan = make(chan bool, 1)
+	}
 	if req.Body != nil {
 		// send the request body asynchronously
 		go func() {
+			if continueChan != nil {
+				timer := time.NewTimer(c.opts.ExpectContinueTimeout)
+				select {
+				case send := <-continueChan:
+					timer.Stop()
+					if !send {
+						// The server doesn't need the request body, see section 4.1 of RFC 9114.
+						str.CancelWrite(quic.StreamErrorCode(ErrCodeNoError))
+						return
+					}
+				case <-timer.C:
+				case <-req.Context().Done():
+					timer.Stop()
+					return
+				}
+			}
 			contentLength := int64(-1)
 			// According to the documentation for http.Request.ContentLength,
 			// a value of 0 with a non-nil Body is also treated as unknown content length.
@@ -391,37 +711,33 @@ func (c *client) doRequest(req *http.Request, conn quic.EarlyConnection, str qui
 			}
 			if err := c.sendRequestBody(hstr, req.Body, contentLength); err != nil {
 				c.logger.Errorf("Error writing request: %s", err)
+				if !opt.DontCloseRequestStream {
+					hstr.Close()
+				}
+				return
 			}
 			if !opt.DontCloseRequestStream {
+				// The values of the trailers may be updated while the body is read,
+				// so they can only be encoded now.
+				if len(req.Trailer) > 0 {
+					if err := c.requestWriter.WriteRequestTrailer(str, str.StreamID(), req); err != nil {
+						c.logger.Errorf("Error writing trailers: %s", err)
+					}
+				}
 				hstr.Close()
 			}
 		}()
 	}
 
-	frame, err := parseNextFrame(str, nil)
-	if err != nil {
-		return nil, newStreamError(ErrCodeFrameError, err)
+	res, rerr := c.readResponseHeaders(req, str, continueChan)
+	if rerr.err != nil {
+		return nil, rerr
 	}
-	hf, ok := frame.(*headersFrame)
-	if !ok {
-		return nil, newConnError(ErrCodeFrameUnexpected, errors.New("expected first frame to be a HEADERS frame"))
-	}
-	if hf.Length > c.maxHeaderBytes() {
-		return nil, newStreamError(ErrCodeFrameError, fmt.Errorf("HEADERS frame too large: %d bytes (max: %d)", hf.Length, c.maxHeaderBytes()))
-	}
-	headerBlock := make([]byte, hf.Length)
-	if _, err := io.ReadFull(str, headerBlock); err != nil {
-		return nil, newStreamError(ErrCodeRequestIncomplete, err)
-	}
-	hfs, err := c.decoder.DecodeFull(headerBlock)
-	if err != nil {
-		// TODO: use the right error code
-		return nil, newConnError(ErrCodeGeneralProtocolError, err)
-	}
-
-	res, err := responseFromHeaders(hfs)
-	if err != nil {
-		return nil, newStreamError(ErrCodeMessageError, err)
+	if continueChan != nil {
+		select {
+		case continueChan <- false:
+		default:
+		}
 	}
 	connState := conn.ConnectionState().TLS
 	res.TLS = &connState
@@ -434,7 +750,14 @@ func (c *client) doRequest(req *http.Request, conn quic.EarlyConnection, str qui
 	} else {
 		httpStr = hstr
 	}
-	respBody := newResponseBody(httpStr, conn, reqDone)
+	respBody := newResponseBody(httpStr, conn, reqDone, func(p Priority) error {
+		str.SetPriority(p.streamPriority())
+		return c.sendPriorityUpdate(str.StreamID(), p)
+	})
+	if datagrams != nil {
+		datagrams.capsuleProtocol = usesCapsuleProtocol(req.Header) || usesCapsuleProtocol(res.Header)
+		respBody.datagrams = datagrams
+	}
 
 	// Rules for when to set Content-Length are defined in https://tools.ietf.org/html/rfc7230#section-3.3.2.
 	_, hasTransferEncoding := res.Header["Transfer-Encoding"]
@@ -463,6 +786,75 @@ func (c *client) doRequest(req *http.Request, conn quic.EarlyConnection, str qui
 	return res, requestError{}
 }
 
+// readResponseHeaders reads the response header.
+// Interim (1xx) responses are reported using httptrace.ClientTrace.Got1xxResponse,
+// until the final response header is received.
+// If continueChan is set, it is notified when a 100 Continue response is received.
+func (c *client) readResponseHeaders(req *http.Request, str quic.Stream, continueChan chan<- bool) (*http.Response, requestError) {
+	trace := httptrace.ContextClientTrace(req.Context())
+	var num1xx int
+	for {
+		frame, err := parseNextFrame(str, nil)
+		if err != nil {
+			return nil, newStreamError(ErrCodeFrameError, err)
+		}
+		hf, ok := frame.(*headersFrame)
+		if !ok {
+			return nil, newConnError(ErrCodeFrameUnexpected, errors.New("expected first frame to be a HEADERS frame"))
+		}
+		if hf.Length > c.maxHeaderBytes() {
+			// The field section is never decoded, so the server's encoder can release its dynamic table references.
+			if err := c.decoder.cancelStream(str.StreamID()); err != nil {
+				return nil, newStreamError(ErrCodeExcessiveLoad, err)
+			}
+			return nil, newStreamError(ErrCodeExcessiveLoad, fmt.Errorf("HEADERS frame too large: %d bytes (max: %d)", hf.Length, c.maxHeaderBytes()))
+		}
+		headerBlock := make([]byte, hf.Length)
+		if _, err := io.ReadFull(str, headerBlock); err != nil {
+			return nil, newStreamError(ErrCodeRequestIncomplete, err)
+		}
+		hfs, err := c.decoder.Decode(req.Context(), str.StreamID(), headerBlock)
+		if err != nil {
+			var qerr *qpackError
+			if errors.As(err, &qerr) {
+				return nil, newConnError(qerr.code, err)
+			}
+			return nil, newStreamError(ErrCodeRequestIncomplete, err)
+		}
+		if size := fieldSectionSize(hfs); size > c.maxHeaderBytes() {
+			return nil, newStreamError(ErrCodeExcessiveLoad, fmt.Errorf("response header too large: %d bytes (max: %d)", size, c.maxHeaderBytes()))
+		}
+		res, err := responseFromHeaders(hfs)
+		if err != nil {
+			return nil, newStreamError(ErrCodeMessageError, err)
+		}
+		// The 101 status code is not applicable to HTTP/3, see section 4.5 of RFC 9114.
+		if res.StatusCode < 100 || res.StatusCode >= 200 || res.StatusCode == http.StatusSwitchingProtocols {
+			return res, requestError{}
+		}
+		if res.StatusCode == http.StatusContinue {
+			if continueChan != nil {
+				select {
+				case continueChan <- true:
+				default:
+				}
+			}
+			if trace != nil && trace.Got100Continue != nil {
+				trace.Got100Continue()
+			}
+		}
+		num1xx++
+		if num1xx > max1xxResponses {
+			return nil, newStreamError(ErrCodeExcessiveLoad, errors.New("too many 1xx informational responses"))
+		}
+		if trace != nil && trace.Got1xxResponse != nil {
+			if err := trace.Got1xxResponse(res.StatusCode, textproto.MIMEHeader(res.Header)); err != nil {
+				return nil, newStreamError(ErrCodeRequestCanceled, err)
+			}
+		}
+	}
+}
+
 func (c *client) HandshakeComplete() bool {
 	conn := c.conn.Load()
 	if conn == nil {
@@ -9,6 +9,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptrace"
+	"net/textproto"
 	"sync"
 	"time"
 
@@ -471,8 +473,9 @@ var _ = Describe("Client", func() {
 
 		It("parses the SETTINGS frame", func() {
 			b := quicvarint.Append(nil, streamTypeControlStream)
-			b = (&settingsFrame{}).Append(b)
-			r := bytes.NewReader(b)
+			b = (&settingsFrame{ExtendedConnect: true}).Append(b)
+			r, w := io.Pipe()
+			go w.Write(b)
 			controlStr := mockquic.NewMockStream(mockCtrl)
 			controlStr.EXPECT().Read(gomock.Any()).DoAndReturn(r.Read).AnyTimes()
 			conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
@@ -484,6 +487,8 @@ var _ = Describe("Client", func() {
 			})
 			_, err := cl.RoundTripOpt(req, RoundTripOpt{})
 			Expect(err).To(MatchError("done"))
+			Eventually(cl.settingsReceived).Should(BeClosed())
+			Expect(cl.settings.ExtendedConnect).To(BeTrue())
 			time.Sleep(scaleDuration(20 * time.Millisecond)) // don't EXPECT any calls to conn.CloseWithError
 		})
 
@@ -494,7 +499,25 @@ var _ = Describe("Client", func() {
 				name = "decoder"
 			}
 
-			It(fmt.Sprintf("ignores the QPACK %s streams", name), func() {
+			It(fmt.Sprintf("accepts the QPACK %s stream", name), func() {
+				r, w := io.Pipe()
+				go w.Write(quicvarint.Append(nil, streamType))
+				str := mockquic.NewMockStream(mockCtrl)
+				str.EXPECT().Read(gomock.Any()).DoAndReturn(r.Read).AnyTimes()
+
+				conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
+					return str, nil
+				})
+				conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
+					<-testDone
+					return nil, errors.New("test done")
+				})
+				_, err := cl.RoundTripOpt(req, RoundTripOpt{})
+				Expect(err).To(MatchError("done"))
+				time.Sleep(scaleDuration(20 * time.Millisecond)) // don't EXPECT any calls to str.CancelRead or conn.CloseWithError
+			})
+
+			It(fmt.Sprintf("errors when the QPACK %s stream is closed", name), func() {
 				buf := bytes.NewBuffer(quicvarint.Append(nil, streamType))
 				str := mockquic.NewMockStream(mockCtrl)
 				str.EXPECT().Read(gomock.Any()).DoAndReturn(buf.Read).AnyTimes()
@@ -506,12 +529,40 @@ var _ = Describe("Client", func() {
 					<-testDone
 					return nil, errors.New("test done")
 				})
+				done := make(chan struct{})
+				conn.EXPECT().CloseWithError(quic.ApplicationErrorCode(ErrCodeClosedCriticalStream), gomock.Any()).Do(func(quic.ApplicationErrorCode, string) error {
+					close(done)
+					return nil
+				})
 				_, err := cl.RoundTripOpt(req, RoundTripOpt{})
 				Expect(err).To(MatchError("done"))
-				time.Sleep(scaleDuration(20 * time.Millisecond)) // don't EXPECT any calls to str.CancelRead
+				Eventually(done).Should(BeClosed())
 			})
 		}
 
+		It("closes the connection when receiving an invalid QPACK decoder stream instruction", func() {
+			b := quicvarint.Append(nil, streamTypeQPACKDecoderStream)
+			b = append(b, 0x80) // Section Acknowledgment for stream 0, which didn't reference the dynamic table
+			str := mockquic.NewMockStream(mockCtrl)
+			str.EXPECT().Read(gomock.Any()).DoAndReturn(bytes.NewReader(b).Read).AnyTimes()
+
+			conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
+				return str, nil
+			})
+			conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
+				<-testDone
+				return nil, errors.New("test done")
+			})
+			done := make(chan struct{})
+			conn.EXPECT().CloseWithError(quic.ApplicationErrorCode(ErrCodeQPACKDecoderStreamError), gomock.Any()).Do(func(quic.ApplicationErrorCode, string) error {
+				close(done)
+				return nil
+			})
+			_, err := cl.RoundTripOpt(req, RoundTripOpt{})
+			Expect(err).To(MatchError("done"))
+			Eventually(done).Should(BeClosed())
+		})
+
 		It("resets streams Other than the control stream and the QPACK streams", func() {
 			buf := bytes.NewBuffer(quicvarint.Append(nil, 0x1337))
 			str := mockquic.NewMockStream(mockCtrl)
@@ -555,6 +606,32 @@ var _ = Describe("Client", func() {
 		})
 
 		It("errors when parsing the frame on the control stream fails", func() {
+			b := quicvarint.Append(nil, streamTypeControlStream)
+			b = quicvarint.Append(b, 0x4) // SETTINGS frame
+			b = quicvarint.Append(b, 4)
+			b = append(b, settingMaxFieldSectionSize, 0x1, settingMaxFieldSectionSize, 0x1) // duplicate setting
+			r, w := io.Pipe()
+			go w.Write(b)
+			controlStr := mockquic.NewMockStream(mockCtrl)
+			controlStr.EXPECT().Read(gomock.Any()).DoAndReturn(r.Read).AnyTimes()
+			conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
+				return controlStr, nil
+			})
+			conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
+				<-testDone
+				return nil, errors.New("test done")
+			})
+			done := make(chan struct{})
+			conn.EXPECT().CloseWithError(quic.ApplicationErrorCode(ErrCodeFrameError), gomock.Any()).Do(func(code quic.ApplicationErrorCode, _ string) error {
+				close(done)
+				return nil
+			})
+			_, err := cl.RoundTripOpt(req, RoundTripOpt{})
+			Expect(err).To(MatchError("done"))
+			Eventually(done).Should(BeClosed())
+		})
+
+		It("errors when the control stream is closed", func() {
 			b := quicvarint.Append(nil, streamTypeControlStream)
 			b = (&settingsFrame{}).Append(b)
 			r := bytes.NewReader(b[:len(b)-1])
@@ -568,7 +645,33 @@ var _ = Describe("Client", func() {
 				return nil, errors.New("test done")
 			})
 			done := make(chan struct{})
-			conn.EXPECT().CloseWithError(quic.ApplicationErrorCode(ErrCodeFrameError), gomock.Any()).Do(func(code quic.ApplicationErrorCode, _ string) error {
+			conn.EXPECT().CloseWithError(quic.ApplicationErrorCode(ErrCodeClosedCriticalStream), gomock.Any()).Do(func(quic.ApplicationErrorCode, string) error {
+				close(done)
+				return nil
+			})
+			_, err := cl.RoundTripOpt(req, RoundTripOpt{})
+			Expect(err).To(MatchError("done"))
+			Eventually(done).Should(BeClosed())
+		})
+
+		It("errors when the server opens a second control stream", func() {
+			b := quicvarint.Append(nil, streamTypeControlStream)
+			b = (&settingsFrame{}).Append(b)
+			for i := 0; i < 2; i++ {
+				r, w := io.Pipe()
+				go w.Write(b)
+				controlStr := mockquic.NewMockStream(mockCtrl)
+				controlStr.EXPECT().Read(gomock.Any()).DoAndReturn(r.Read).AnyTimes()
+				conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
+					return controlStr, nil
+				})
+			}
+			conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
+				<-testDone
+				return nil, errors.New("test done")
+			})
+			done := make(chan struct{})
+			conn.EXPECT().CloseWithError(quic.ApplicationErrorCode(ErrCodeStreamCreationError), "duplicate control stream").Do(func(quic.ApplicationErrorCode, string) error {
 				close(done)
 				return nil
 			})
@@ -600,6 +703,10 @@ var _ = Describe("Client", func() {
 
 		It("errors when the server advertises datagram support (and we enabled support for it)", func() {
 			cl.opts.EnableDatagram = true
+			conn.EXPECT().ReceiveDatagram(gomock.Any()).DoAndReturn(func(context.Context) ([]byte, error) {
+				<-testDone
+				return nil, errors.New("test done")
+			})
 			b := quicvarint.Append(nil, streamTypeControlStream)
 			b = (&settingsFrame{Datagram: true}).Append(b)
 			r := bytes.NewReader(b)
@@ -622,12 +729,112 @@ var _ = Describe("Client", func() {
 			Expect(err).To(MatchError("done"))
 			Eventually(done).Should(BeClosed())
 		})
+
+		// The frames are written to the control stream after the request returned,
+		// so that the request is not rejected.
+		serveControlStream := func(frames ...[]byte) {
+			pr, pw := io.Pipe()
+			controlStr := mockquic.NewMockStream(mockCtrl)
+			controlStr.EXPECT().Read(gomock.Any()).DoAndReturn(pr.Read).AnyTimes()
+			conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
+				return controlStr, nil
+			})
+			conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
+				<-testDone
+				return nil, errors.New("test done")
+			})
+			_, err := cl.RoundTripOpt(req, RoundTripOpt{})
+			Expect(err).To(MatchError("done"))
+			b := quicvarint.Append(nil, streamTypeControlStream)
+			b = (&settingsFrame{}).Append(b)
+			for _, f := range frames {
+				b = append(b, f...)
+			}
+			go pw.Write(b)
+		}
+
+		Context("frames not allowed on the control stream", func() {
+			appendFrame := func(t uint64, payload []byte) []byte {
+				b := quicvarint.Append(nil, t)
+				b = quicvarint.Append(b, uint64(len(payload)))
+				return append(b, payload...)
+			}
+
+			for _, f := range []struct {
+				name  string
+				frame []byte
+			}{
+				{name: "DATA", frame: (&dataFrame{Length: 0}).Append(nil)},
+				{name: "HEADERS", frame: (&headersFrame{Length: 0}).Append(nil)},
+				{name: "SETTINGS", frame: (&settingsFrame{}).Append(nil)},
+				{name: "PRIORITY_UPDATE", frame: (&priorityUpdateFrame{PrioritizedElementID: 0, PriorityFieldValue: "u=1"}).Append(nil)},
+				{name: "PUSH_PROMISE", frame: appendFrame(frameTypePushPromise, []byte{0})},
+				{name: "MAX_PUSH_ID", frame: appendFrame(frameTypeMaxPushID, []byte{42})},
+				{name: "HTTP/2 PING", frame: appendFrame(frameTypeHTTP2Ping, make([]byte, 8))},
+			} {
+				f := f
+
+				It(fmt.Sprintf("errors when receiving a %s frame", f.name), func() {
+					done := make(chan struct{})
+					conn.EXPECT().CloseWithError(quic.ApplicationErrorCode(ErrCodeFrameUnexpected), gomock.Any()).Do(func(quic.ApplicationErrorCode, string) error {
+						close(done)
+						return nil
+					})
+					serveControlStream(f.frame)
+					Eventually(done).Should(BeClosed())
+				})
+			}
+
+			It("errors when receiving a CANCEL_PUSH frame", func() {
+				done := make(chan struct{})
+				conn.EXPECT().CloseWithError(quic.ApplicationErrorCode(ErrCodeIDError), gomock.Any()).Do(func(quic.ApplicationErrorCode, string) error {
+					close(done)
+					return nil
+				})
+				serveControlStream(appendFrame(frameTypeCancelPush, []byte{0}))
+				Eventually(done).Should(BeClosed())
+			})
+		})
+
+		Context("GOAWAY frames", func() {
+			It("closes the connection when no requests are in flight", func() {
+				done := make(chan struct{})
+				conn.EXPECT().CloseWithError(quic.ApplicationErrorCode(ErrCodeNoError), gomock.Any()).Do(func(quic.ApplicationErrorCode, string) error {
+					close(done)
+					return nil
+				})
+				serveControlStream((&goAwayFrame{StreamID: 4}).Append(nil))
+				Eventually(done).Should(BeClosed())
+			})
+
+			It("errors when the GOAWAY frame contains an invalid stream ID", func() {
+				done := make(chan struct{})
+				conn.EXPECT().CloseWithError(quic.ApplicationErrorCode(ErrCodeIDError), gomock.Any()).Do(func(quic.ApplicationErrorCode, string) error {
+					close(done)
+					return nil
+				})
+				serveControlStream((&goAwayFrame{StreamID: 5}).Append(nil))
+				Eventually(done).Should(BeClosed())
+			})
+
+			It("errors when the stream ID in the GOAWAY frame increases", func() {
+				done := make(chan struct{})
+				conn.EXPECT().CloseWithError(quic.ApplicationErrorCode(ErrCodeNoError), gomock.Any())
+				conn.EXPECT().CloseWithError(quic.ApplicationErrorCode(ErrCodeIDError), gomock.Any()).Do(func(quic.ApplicationErrorCode, string) error {
+					close(done)
+					return nil
+				})
+				serveControlStream((&goAwayFrame{StreamID: 4}).Append(nil), (&goAwayFrame{StreamID: 8}).Append(nil))
+				Eventually(done).Should(BeClosed())
+			})
+		})
 	})
 
 	Context("Doing requests", func() {
 		var (
 			req                  *http.Request
 			str                  *mockquic.MockStream
+			controlStr           *mockquic.MockStream
 			conn                 *mockquic.MockEarlyConnection
 			settingsFrameWritten chan struct{}
 		)
@@ -655,8 +862,9 @@ var _ = Describe("Client", func() {
 		getResponse := func(status int) []byte {
 			buf := &bytes.Buffer{}
 			rstr := mockquic.NewMockStream(mockCtrl)
+			rstr.EXPECT().StreamID().AnyTimes()
 			rstr.EXPECT().Write(gomock.Any()).Do(buf.Write).AnyTimes()
-			rw := newResponseWriter(rstr, nil, utils.DefaultLogger)
+			rw := newResponseWriter(rstr, nil, newQPACKEncoder(0, nil), nil, utils.DefaultLogger)
 			rw.WriteHeader(status)
 			rw.Flush()
 			return buf.Bytes()
@@ -664,7 +872,7 @@ var _ = Describe("Client", func() {
 
 		BeforeEach(func() {
 			settingsFrameWritten = make(chan struct{})
-			controlStr := mockquic.NewMockStream(mockCtrl)
+			controlStr = mockquic.NewMockStream(mockCtrl)
 			controlStr.EXPECT().Write(gomock.Any()).Do(func(b []byte) (int, error) {
 				defer GinkgoRecover()
 				r := bytes.NewReader(b)
@@ -675,6 +883,7 @@ var _ = Describe("Client", func() {
 				return len(b), nil
 			}) // SETTINGS frame
 			str = mockquic.NewMockStream(mockCtrl)
+			str.EXPECT().SetPriority(DefaultPriority.streamPriority()).AnyTimes()
 			conn = mockquic.NewMockEarlyConnection(mockCtrl)
 			conn.EXPECT().OpenUniStream().Return(controlStr, nil)
 			conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
@@ -708,6 +917,7 @@ var _ = Describe("Client", func() {
 			req.Method = MethodGet0RTT
 			// don't EXPECT any calls to HandshakeComplete()
 			conn.EXPECT().OpenStreamSync(context.Background()).Return(str, nil)
+			str.EXPECT().StreamID().AnyTimes()
 			buf := &bytes.Buffer{}
 			str.EXPECT().Write(gomock.Any()).DoAndReturn(buf.Write).AnyTimes()
 			str.EXPECT().Close()
@@ -727,6 +937,7 @@ var _ = Describe("Client", func() {
 				conn.EXPECT().OpenStreamSync(context.Background()).Return(str, nil),
 				conn.EXPECT().ConnectionState().Return(quic.ConnectionState{}),
 			)
+			str.EXPECT().StreamID().AnyTimes()
 			str.EXPECT().Write(gomock.Any()).AnyTimes().DoAndReturn(func(p []byte) (int, error) { return len(p), nil })
 			str.EXPECT().Close()
 			str.EXPECT().Read(gomock.Any()).DoAndReturn(rspBuf.Read).AnyTimes()
@@ -738,6 +949,96 @@ var _ = Describe("Client", func() {
 			Expect(rsp.Request).ToNot(BeNil())
 		})
 
+		It("reports interim responses", func() {
+			rspBuf := &bytes.Buffer{}
+			rstr := mockquic.NewMockStream(mockCtrl)
+			rstr.EXPECT().StreamID().AnyTimes()
+			rstr.EXPECT().Write(gomock.Any()).Do(rspBuf.Write).AnyTimes()
+			rw := newResponseWriter(rstr, nil, newQPACKEncoder(0, nil), nil, utils.DefaultLogger)
+			rw.WriteHeader(http.StatusContinue)
+			rw.Header().Add("Link", "</style.css>; rel=preload; as=style")
+			rw.WriteHeader(http.StatusEarlyHints)
+			rw.WriteHeader(http.StatusTeapot)
+			rw.Flush()
+
+			var statusCodes []int
+			var headers []textproto.MIMEHeader
+			ctx := httptrace.WithClientTrace(context.Background(), &httptrace.ClientTrace{
+				Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+					statusCodes = append(statusCodes, code)
+					headers = append(headers, header)
+					return nil
+				},
+			})
+			gomock.InOrder(
+				conn.EXPECT().HandshakeComplete().Return(handshakeChan),
+				conn.EXPECT().OpenStreamSync(ctx).Return(str, nil),
+				conn.EXPECT().ConnectionState().Return(quic.ConnectionState{}),
+			)
+			str.EXPECT().StreamID().AnyTimes()
+			str.EXPECT().Write(gomock.Any()).AnyTimes().DoAndReturn(func(p []byte) (int, error) { return len(p), nil })
+			str.EXPECT().Close()
+			str.EXPECT().Read(gomock.Any()).DoAndReturn(rspBuf.Read).AnyTimes()
+			rsp, err := cl.RoundTripOpt(req.WithContext(ctx), RoundTripOpt{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rsp.StatusCode).To(Equal(http.StatusTeapot))
+			Expect(statusCodes).To(Equal([]int{http.StatusContinue, http.StatusEarlyHints}))
+			Expect(headers[0]).To(BeEmpty())
+			Expect(headers[1]).To(HaveKeyWithValue("Link", []string{"</style.css>; rel=preload; as=style"}))
+		})
+
+		It("errors when receiving too many interim responses", func() {
+			rspBuf := &bytes.Buffer{}
+			rstr := mockquic.NewMockStream(mockCtrl)
+			rstr.EXPECT().StreamID().AnyTimes()
+			rstr.EXPECT().Write(gomock.Any()).Do(rspBuf.Write).AnyTimes()
+			rw := newResponseWriter(rstr, nil, newQPACKEncoder(0, nil), nil, utils.DefaultLogger)
+			for i := 0; i <= max1xxResponses; i++ {
+				rw.WriteHeader(http.StatusEarlyHints)
+			}
+			gomock.InOrder(
+				conn.EXPECT().HandshakeComplete().Return(handshakeChan),
+				conn.EXPECT().OpenStreamSync(context.Background()).Return(str, nil),
+			)
+			str.EXPECT().StreamID().AnyTimes()
+			str.EXPECT().Write(gomock.Any()).AnyTimes().DoAndReturn(func(p []byte) (int, error) { return len(p), nil })
+			str.EXPECT().Close()
+			str.EXPECT().CancelWrite(quic.StreamErrorCode(ErrCodeExcessiveLoad))
+			str.EXPECT().Read(gomock.Any()).DoAndReturn(rspBuf.Read).AnyTimes()
+			_, err := cl.RoundTripOpt(req, RoundTripOpt{})
+			Expect(err).To(MatchError("too many 1xx informational responses"))
+		})
+
+		It("populates the response trailers after the body was read", func() {
+			buf := &bytes.Buffer{}
+			rstr := mockquic.NewMockStream(mockCtrl)
+			rstr.EXPECT().StreamID().AnyTimes()
+			rstr.EXPECT().Write(gomock.Any()).Do(buf.Write).AnyTimes()
+			rw := newResponseWriter(rstr, nil, newQPACKEncoder(0, nil), nil, utils.DefaultLogger)
+			rw.Header().Set("Trailer", "Foo")
+			rw.Write([]byte("foobar"))
+			rw.Header().Set("Foo", "bar")
+			rw.Flush()
+			Expect(rw.writeTrailers()).To(Succeed())
+
+			gomock.InOrder(
+				conn.EXPECT().HandshakeComplete().Return(handshakeChan),
+				conn.EXPECT().OpenStreamSync(context.Background()).Return(str, nil),
+				conn.EXPECT().ConnectionState().Return(quic.ConnectionState{}),
+			)
+			str.EXPECT().StreamID().AnyTimes()
+			str.EXPECT().Write(gomock.Any()).AnyTimes().DoAndReturn(func(p []byte) (int, error) { return len(p), nil })
+			str.EXPECT().Close()
+			str.EXPECT().Read(gomock.Any()).DoAndReturn(buf.Read).AnyTimes()
+			rsp, err := cl.RoundTripOpt(req, RoundTripOpt{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rsp.Trailer).To(Equal(http.Header{"Foo": nil}))
+			body, err := io.ReadAll(rsp.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(body).To(Equal([]byte("foobar")))
+			Expect(rsp.Trailer).To(Equal(http.Header{"Foo": []string{"bar"}}))
+		})
+
 		It("doesn't close the request stream, with DontCloseRequestStream set", func() {
 			rspBuf := bytes.NewBuffer(getResponse(418))
 			gomock.InOrder(
@@ -745,6 +1046,7 @@ var _ = Describe("Client", func() {
 				conn.EXPECT().OpenStreamSync(context.Background()).Return(str, nil),
 				conn.EXPECT().ConnectionState().Return(quic.ConnectionState{}),
 			)
+			str.EXPECT().StreamID().AnyTimes()
 			str.EXPECT().Write(gomock.Any()).AnyTimes().DoAndReturn(func(p []byte) (int, error) { return len(p), nil })
 			str.EXPECT().Read(gomock.Any()).DoAndReturn(rspBuf.Read).AnyTimes()
 			rsp, err := cl.RoundTripOpt(req, RoundTripOpt{DontCloseRequestStream: true})
@@ -754,6 +1056,178 @@ var _ = Describe("Client", func() {
 			Expect(rsp.StatusCode).To(Equal(418))
 		})
 
+		Context("GOAWAY", func() {
+			It("doesn't open new streams after receiving a GOAWAY frame", func() {
+				conn.EXPECT().CloseWithError(quic.ApplicationErrorCode(ErrCodeNoError), gomock.Any())
+				Expect(cl.handleGoAway(conn, 4)).To(Succeed())
+				conn.EXPECT().HandshakeComplete().Return(handshakeChan)
+				_, err := cl.RoundTripOpt(req, RoundTripOpt{})
+				Expect(err).To(MatchError(errGoAway))
+			})
+
+			It("cancels requests on streams that the server won't process", func() {
+				conn.EXPECT().HandshakeComplete().Return(handshakeChan)
+				conn.EXPECT().OpenStreamSync(context.Background()).Return(str, nil)
+				str.EXPECT().StreamID().Return(quic.StreamID(8)).AnyTimes()
+				requestSent := make(chan struct{})
+				str.EXPECT().Write(gomock.Any()).DoAndReturn(func(p []byte) (int, error) { return len(p), nil }).AnyTimes()
+				str.EXPECT().Close().Do(func() error { close(requestSent); return nil })
+				canceled := make(chan struct{})
+				str.EXPECT().CancelWrite(quic.StreamErrorCode(ErrCodeRequestCanceled))
+				str.EXPECT().CancelRead(quic.StreamErrorCode(ErrCodeRequestCanceled)).Do(func(quic.StreamErrorCode) { close(canceled) })
+				str.EXPECT().Read(gomock.Any()).DoAndReturn(func([]byte) (int, error) {
+					<-canceled
+					return 0, &quic.StreamError{StreamID: 8, ErrorCode: quic.StreamErrorCode(ErrCodeRequestCanceled)}
+				})
+				conn.EXPECT().CloseWithError(quic.ApplicationErrorCode(ErrCodeNoError), gomock.Any())
+
+				errChan := make(chan error, 1)
+				go func() {
+					_, err := cl.RoundTripOpt(req, RoundTripOpt{})
+					errChan <- err
+				}()
+				Eventually(requestSent).Should(BeClosed())
+				Expect(cl.handleGoAway(conn, 8)).To(Succeed())
+				Eventually(errChan).Should(Receive(MatchError(errRequestNotProcessed)))
+			})
+
+			It("completes requests on lower stream IDs, and then closes the connection", func() {
+				rspBuf := bytes.NewBuffer(getResponse(200))
+				conn.EXPECT().HandshakeComplete().Return(handshakeChan)
+				conn.EXPECT().OpenStreamSync(context.Background()).Return(str, nil)
+				conn.EXPECT().ConnectionState().Return(quic.ConnectionState{})
+				str.EXPECT().StreamID().Return(quic.StreamID(4)).AnyTimes()
+				str.EXPECT().Write(gomock.Any()).DoAndReturn(func(p []byte) (int, error) { return len(p), nil }).AnyTimes()
+				str.EXPECT().Close()
+				str.EXPECT().Read(gomock.Any()).DoAndReturn(rspBuf.Read).AnyTimes()
+				rsp, err := cl.RoundTripOpt(req, RoundTripOpt{})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(cl.handleGoAway(conn, 8)).To(Succeed())
+				time.Sleep(scaleDuration(10 * time.Millisecond)) // don't EXPECT any calls to conn.CloseWithError
+				closed := make(chan struct{})
+				str.EXPECT().CancelRead(gomock.Any())
+				conn.EXPECT().CloseWithError(quic.ApplicationErrorCode(ErrCodeNoError), gomock.Any()).Do(func(quic.ApplicationErrorCode, string) error {
+					close(closed)
+					return nil
+				})
+				Expect(rsp.Body.Close()).To(Succeed())
+				Eventually(closed).Should(BeClosed())
+			})
+
+			It("returns an error for requests that the server rejected", func() {
+				conn.EXPECT().HandshakeComplete().Return(handshakeChan)
+				conn.EXPECT().OpenStreamSync(context.Background()).Return(str, nil)
+				str.EXPECT().StreamID().AnyTimes()
+				str.EXPECT().Write(gomock.Any()).DoAndReturn(func(p []byte) (int, error) { return len(p), nil }).AnyTimes()
+				str.EXPECT().Close()
+				str.EXPECT().Read(gomock.Any()).Return(0, &quic.StreamError{StreamID: 4, ErrorCode: quic.StreamErrorCode(ErrCodeRequestRejected), Remote: true})
+				_, err := cl.RoundTripOpt(req, RoundTripOpt{})
+				Expect(err).To(MatchError(errRequestNotProcessed))
+			})
+		})
+
+		Context("Extended CONNECT", func() {
+			BeforeEach(func() {
+				var err error
+				req, err = http.NewRequest(http.MethodConnect, "https://quic.clemente.io:1337/chat", nil)
+				Expect(err).ToNot(HaveOccurred())
+				req.Proto = "websocket"
+				conn.EXPECT().HandshakeComplete().Return(handshakeChan)
+			})
+
+			It("waits for the server's SETTINGS frame before sending the request", func() {
+				conn.EXPECT().Context().Return(context.Background()).AnyTimes()
+				testErr := errors.New("stream open error")
+				conn.EXPECT().OpenStreamSync(gomock.Any()).Return(nil, testErr)
+				errChan := make(chan error, 1)
+				go func() {
+					_, err := cl.RoundTripOpt(req, RoundTripOpt{})
+					errChan <- err
+				}()
+				Consistently(errChan, scaleDuration(50*time.Millisecond)).ShouldNot(Receive())
+				cl.settings = &settingsFrame{ExtendedConnect: true}
+				close(cl.settingsReceived)
+				Eventually(errChan).Should(Receive(MatchError(testErr)))
+			})
+
+			It("errors if the server didn't enable Extended CONNECT", func() {
+				conn.EXPECT().Context().Return(context.Background()).AnyTimes()
+				cl.settings = &settingsFrame{}
+				close(cl.settingsReceived)
+				_, err := cl.RoundTripOpt(req, RoundTripOpt{})
+				Expect(err).To(MatchError(errExtendedConnectNotEnabled))
+			})
+
+			It("errors if the connection is closed before the SETTINGS frame was received", func() {
+				ctx, cancel := context.WithCancelCause(context.Background())
+				testErr := errors.New("connection closed")
+				cancel(testErr)
+				conn.EXPECT().Context().Return(ctx).AnyTimes()
+				_, err := cl.RoundTripOpt(req, RoundTripOpt{})
+				Expect(err).To(MatchError(testErr))
+			})
+		})
+
+		Context("priorities", func() {
+			It("uses the priority from the Priority header field for the request stream", func() {
+				req.Header.Set("Priority", "u=1, i")
+				rspBuf := bytes.NewBuffer(getResponse(200))
+				conn.EXPECT().HandshakeComplete().Return(handshakeChan)
+				conn.EXPECT().OpenStreamSync(context.Background()).Return(str, nil)
+				str.EXPECT().StreamID().AnyTimes()
+				conn.EXPECT().ConnectionState().Return(quic.ConnectionState{})
+				buf := &bytes.Buffer{}
+				gomock.InOrder(
+					str.EXPECT().SetPriority(quic.StreamPriority{Urgency: 1, Incremental: true}),
+					str.EXPECT().Write(gomock.Any()).DoAndReturn(buf.Write).AnyTimes(),
+				)
+				str.EXPECT().Close()
+				str.EXPECT().Read(gomock.Any()).DoAndReturn(rspBuf.Read).AnyTimes()
+				_, err := cl.RoundTripOpt(req, RoundTripOpt{})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(decodeHeader(buf)).To(HaveKeyWithValue("priority", "u=1, i"))
+			})
+
+			It("uses the default priority if the request doesn't have a Priority header field", func() {
+				str := mockquic.NewMockStream(mockCtrl)
+				rspBuf := bytes.NewBuffer(getResponse(200))
+				conn.EXPECT().HandshakeComplete().Return(handshakeChan)
+				conn.EXPECT().OpenStreamSync(context.Background()).Return(str, nil)
+				str.EXPECT().StreamID().AnyTimes()
+				conn.EXPECT().ConnectionState().Return(quic.ConnectionState{})
+				gomock.InOrder(
+					str.EXPECT().SetPriority(quic.StreamPriority{Urgency: 3, Incremental: false}),
+					str.EXPECT().Write(gomock.Any()).DoAndReturn(func(p []byte) (int, error) { return len(p), nil }).AnyTimes(),
+				)
+				str.EXPECT().Close()
+				str.EXPECT().Read(gomock.Any()).DoAndReturn(rspBuf.Read).AnyTimes()
+				_, err := cl.RoundTripOpt(req, RoundTripOpt{})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("sends a PRIORITY_UPDATE frame when the priority is updated", func() {
+				rspBuf := bytes.NewBuffer(getResponse(200))
+				conn.EXPECT().HandshakeComplete().Return(handshakeChan)
+				conn.EXPECT().OpenStreamSync(context.Background()).Return(str, nil)
+				conn.EXPECT().ConnectionState().Return(quic.ConnectionState{})
+				str.EXPECT().StreamID().Return(quic.StreamID(8)).AnyTimes()
+				str.EXPECT().Write(gomock.Any()).DoAndReturn(func(p []byte) (int, error) { return len(p), nil }).AnyTimes()
+				str.EXPECT().Close()
+				str.EXPECT().Read(gomock.Any()).DoAndReturn(rspBuf.Read).AnyTimes()
+				rsp, err := cl.RoundTripOpt(req, RoundTripOpt{})
+				Expect(err).ToNot(HaveOccurred())
+
+				controlBuf := &bytes.Buffer{}
+				controlStr.EXPECT().Write(gomock.Any()).DoAndReturn(controlBuf.Write)
+				str.EXPECT().SetPriority(quic.StreamPriority{Urgency: 0, Incremental: true})
+				Expect(rsp.Body.(PriorityUpdater).UpdatePriority(Priority{Urgency: 0, Incremental: true})).To(Succeed())
+				f, err := parseNextFrame(controlBuf, nil)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(f).To(Equal(&priorityUpdateFrame{PrioritizedElementID: 8, PriorityFieldValue: "u=0, i"}))
+			})
+		})
+
 		Context("requests containing a Body", func() {
 			var strBuf *bytes.Buffer
 
@@ -769,6 +1243,7 @@ var _ = Describe("Client", func() {
 				req, err = http.NewRequest("POST", "https://quic.clemente.io:1337/upload", body)
 				Expect(err).ToNot(HaveOccurred())
 				str.EXPECT().Write(gomock.Any()).DoAndReturn(strBuf.Write).AnyTimes()
+				str.EXPECT().StreamID().AnyTimes()
 			})
 
 			It("sends a request", func() {
@@ -789,6 +1264,105 @@ var _ = Describe("Client", func() {
 				Expect(hfs).To(HaveKeyWithValue(":path", "/upload"))
 			})
 
+			It("sends the trailers after the body", func() {
+				req.Trailer = http.Header{"Foo": []string{"bar"}}
+				done := make(chan struct{})
+				gomock.InOrder(
+					str.EXPECT().Close().Do(func() error { close(done); return nil }),
+					str.EXPECT().CancelWrite(gomock.Any()).MaxTimes(1), // when reading the response errors
+				)
+				str.EXPECT().Read(gomock.Any()).DoAndReturn(func([]byte) (int, error) {
+					<-done
+					return 0, errors.New("test done")
+				})
+				_, err := cl.RoundTripOpt(req, RoundTripOpt{})
+				Expect(err).To(MatchError("test done"))
+				Expect(decodeHeader(strBuf)).To(HaveKeyWithValue("trailer", "Foo"))
+				frame, err := parseNextFrame(strBuf, nil)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(frame).To(Equal(&dataFrame{Length: uint64(len("request body"))}))
+				Expect(string(strBuf.Next(len("request body")))).To(Equal("request body"))
+				Expect(decodeHeader(strBuf)).To(Equal(map[string]string{"foo": "bar"}))
+			})
+
+			Context("Expect: 100-continue", func() {
+				BeforeEach(func() {
+					req.Header.Set("Expect", "100-continue")
+				})
+
+				It("waits for the 100 Continue response before sending the body", func() {
+					cl.opts.ExpectContinueTimeout = time.Hour
+					rspReader, rspWriter := io.Pipe()
+					str.EXPECT().Read(gomock.Any()).DoAndReturn(rspReader.Read).AnyTimes()
+					conn.EXPECT().ConnectionState().Return(quic.ConnectionState{})
+					closed := make(chan struct{})
+					str.EXPECT().Close().Do(func() error { close(closed); return nil })
+					rspChan := make(chan *http.Response, 1)
+					go func() {
+						defer GinkgoRecover()
+						rsp, err := cl.RoundTripOpt(req, RoundTripOpt{})
+						Expect(err).ToNot(HaveOccurred())
+						rspChan <- rsp
+					}()
+					Consistently(closed, scaleDuration(50*time.Millisecond)).ShouldNot(BeClosed())
+
+					rspBuf := &bytes.Buffer{}
+					rstr := mockquic.NewMockStream(mockCtrl)
+					rstr.EXPECT().StreamID().AnyTimes()
+					rstr.EXPECT().Write(gomock.Any()).Do(rspBuf.Write).AnyTimes()
+					rw := newResponseWriter(rstr, nil, newQPACKEncoder(0, nil), nil, utils.DefaultLogger)
+					rw.writeContinue()
+					_, err := rspWriter.Write(rspBuf.Bytes())
+					Expect(err).ToNot(HaveOccurred())
+					Eventually(closed).Should(BeClosed())
+					hfs := decodeHeader(strBuf)
+					Expect(hfs).To(HaveKeyWithValue("expect", "100-continue"))
+					frame, err := parseNextFrame(strBuf, nil)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(frame).To(Equal(&dataFrame{Length: uint64(len("request body"))}))
+
+					_, err = rspWriter.Write(getResponse(http.StatusOK))
+					Expect(err).ToNot(HaveOccurred())
+					var rsp *http.Response
+					Eventually(rspChan).Should(Receive(&rsp))
+					Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+				})
+
+				It("doesn't send the body if the server sends a final response", func() {
+					cl.opts.ExpectContinueTimeout = time.Hour
+					rspBuf := bytes.NewBuffer(getResponse(http.StatusRequestEntityTooLarge))
+					str.EXPECT().Read(gomock.Any()).DoAndReturn(rspBuf.Read).AnyTimes()
+					conn.EXPECT().ConnectionState().Return(quic.ConnectionState{})
+					canceled := make(chan struct{})
+					str.EXPECT().CancelWrite(quic.StreamErrorCode(ErrCodeNoError)).Do(func(quic.StreamErrorCode) { close(canceled) })
+					rsp, err := cl.RoundTripOpt(req, RoundTripOpt{})
+					Expect(err).ToNot(HaveOccurred())
+					Expect(rsp.StatusCode).To(Equal(http.StatusRequestEntityTooLarge))
+					Eventually(canceled).Should(BeClosed())
+					decodeHeader(strBuf)
+					Expect(strBuf.Len()).To(BeZero())
+				})
+
+				It("sends the body when the timeout expires", func() {
+					cl.opts.ExpectContinueTimeout = scaleDuration(20 * time.Millisecond)
+					done := make(chan struct{})
+					gomock.InOrder(
+						str.EXPECT().Close().Do(func() error { close(done); return nil }),
+						str.EXPECT().CancelWrite(gomock.Any()).MaxTimes(1), // when reading the response errors
+					)
+					str.EXPECT().Read(gomock.Any()).DoAndReturn(func([]byte) (int, error) {
+						<-done
+						return 0, errors.New("test done")
+					})
+					_, err := cl.RoundTripOpt(req, RoundTripOpt{})
+					Expect(err).To(MatchError("test done"))
+					decodeHeader(strBuf)
+					frame, err := parseNextFrame(strBuf, nil)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(frame).To(Equal(&dataFrame{Length: uint64(len("request body"))}))
+				})
+			})
+
 			It("doesn't send more bytes than allowed by http.Request.ContentLength", func() {
 				req.ContentLength = 7
 				var once sync.Once
@@ -846,6 +1420,20 @@ var _ = Describe("Client", func() {
 				Eventually(closed).Should(BeClosed())
 			})
 
+			It("closes the connection when receiving a frame type reserved by HTTP/2", func() {
+				b := quicvarint.Append(nil, frameTypeHTTP2WindowUpdate)
+				b = quicvarint.Append(b, 4)
+				b = append(b, make([]byte, 4)...)
+				conn.EXPECT().CloseWithError(quic.ApplicationErrorCode(ErrCodeFrameUnexpected), gomock.Any())
+				closed := make(chan struct{})
+				r := bytes.NewReader(b)
+				str.EXPECT().Close().Do(func() error { close(closed); return nil })
+				str.EXPECT().Read(gomock.Any()).DoAndReturn(r.Read).AnyTimes()
+				_, err := cl.RoundTripOpt(req, RoundTripOpt{})
+				Expect(err).To(MatchError("expected first frame to be a HEADERS frame"))
+				Eventually(closed).Should(BeClosed())
+			})
+
 			It("cancels the stream when parsing the headers fails", func() {
 				headerBuf := &bytes.Buffer{}
 				enc := qpack.NewEncoder(headerBuf)
@@ -867,13 +1455,45 @@ var _ = Describe("Client", func() {
 			It("cancels the stream when the HEADERS frame is too large", func() {
 				b := (&headersFrame{Length: 1338}).Append(nil)
 				r := bytes.NewReader(b)
-				str.EXPECT().CancelWrite(quic.StreamErrorCode(ErrCodeFrameError))
+				str.EXPECT().CancelWrite(quic.StreamErrorCode(ErrCodeExcessiveLoad))
 				closed := make(chan struct{})
 				str.EXPECT().Close().Do(func() error { close(closed); return nil })
-				str.EXPECT().Read(gomock.Any()).DoAndReturn(r.Read).AnyTimes()
+				// make sure the control stream is opened before the QPACK decoder stream
+				str.EXPECT().Read(gomock.Any()).DoAndReturn(func(p []byte) (int, error) {
+					<-settingsFrameWritten
+					return r.Read(p)
+				}).AnyTimes()
+				// the field section isn't decoded, so a Stream Cancellation is sent on the QPACK decoder stream
+				decoderStr := mockquic.NewMockStream(mockCtrl)
+				var decoderStrBuf bytes.Buffer
+				decoderStr.EXPECT().Write(gomock.Any()).DoAndReturn(decoderStrBuf.Write)
+				conn.EXPECT().OpenUniStream().Return(decoderStr, nil)
 				_, err := cl.RoundTripOpt(req, RoundTripOpt{})
 				Expect(err).To(MatchError("HEADERS frame too large: 1338 bytes (max: 1337)"))
 				Eventually(closed).Should(BeClosed())
+				Expect(decoderStrBuf.Bytes()).To(Equal(appendQPACKInt(quicvarint.Append(nil, streamTypeQPACKDecoderStream), 6, 0x40, 0)))
+			})
+
+			It("cancels the stream when the decoded response header is too large", func() {
+				rspBuf := &bytes.Buffer{}
+				rstr := mockquic.NewMockStream(mockCtrl)
+				rstr.EXPECT().StreamID().AnyTimes()
+				rstr.EXPECT().Write(gomock.Any()).Do(rspBuf.Write).AnyTimes()
+				rw := newResponseWriter(rstr, nil, newQPACKEncoder(0, nil), nil, utils.DefaultLogger)
+				for i := 0; i < 50; i++ {
+					rw.Header().Add("X-Foo", "a")
+				}
+				rw.WriteHeader(http.StatusOK)
+				rw.Flush()
+				Expect(rspBuf.Len()).To(BeNumerically("<", 1337))
+
+				str.EXPECT().CancelWrite(quic.StreamErrorCode(ErrCodeExcessiveLoad))
+				closed := make(chan struct{})
+				str.EXPECT().Close().Do(func() error { close(closed); return nil })
+				str.EXPECT().Read(gomock.Any()).DoAndReturn(rspBuf.Read).AnyTimes()
+				_, err := cl.RoundTripOpt(req, RoundTripOpt{})
+				Expect(err).To(MatchError(ContainSubstring("response header too large")))
+				Eventually(closed).Should(BeClosed())
 			})
 		})
 
@@ -904,6 +1524,7 @@ var _ = Describe("Client", func() {
 						req := req.WithContext(ctx)
 						conn.EXPECT().HandshakeComplete().Return(handshakeChan)
 						conn.EXPECT().OpenStreamSync(ctx).Return(str, nil)
+						str.EXPECT().StreamID().AnyTimes()
 						buf := &bytes.Buffer{}
 						str.EXPECT().Close().MaxTimes(1)
 
@@ -935,6 +1556,7 @@ var _ = Describe("Client", func() {
 				req := req.WithContext(ctx)
 				conn.EXPECT().HandshakeComplete().Return(handshakeChan)
 				conn.EXPECT().OpenStreamSync(ctx).Return(str, nil)
+				str.EXPECT().StreamID().AnyTimes()
 				conn.EXPECT().ConnectionState().Return(quic.ConnectionState{})
 				buf := &bytes.Buffer{}
 				str.EXPECT().Close().MaxTimes(1)
@@ -958,6 +1580,7 @@ var _ = Describe("Client", func() {
 
 			It("adds the gzip header to requests", func() {
 				conn.EXPECT().OpenStreamSync(context.Background()).Return(str, nil)
+				str.EXPECT().StreamID().AnyTimes()
 				buf := &bytes.Buffer{}
 				str.EXPECT().Write(gomock.Any()).DoAndReturn(buf.Write)
 				gomock.InOrder(
@@ -975,6 +1598,7 @@ var _ = Describe("Client", func() {
 				client, err := newClient("quic.clemente.io:1337", nil, &roundTripperOpts{DisableCompression: true}, nil, nil)
 				Expect(err).ToNot(HaveOccurred())
 				conn.EXPECT().OpenStreamSync(context.Background()).Return(str, nil)
+				str.EXPECT().StreamID().AnyTimes()
 				buf := &bytes.Buffer{}
 				str.EXPECT().Write(gomock.Any()).DoAndReturn(buf.Write)
 				gomock.InOrder(
@@ -990,11 +1614,13 @@ var _ = Describe("Client", func() {
 
 			It("decompresses the response", func() {
 				conn.EXPECT().OpenStreamSync(context.Background()).Return(str, nil)
+				str.EXPECT().StreamID().AnyTimes()
 				conn.EXPECT().ConnectionState().Return(quic.ConnectionState{})
 				buf := &bytes.Buffer{}
 				rstr := mockquic.NewMockStream(mockCtrl)
+				rstr.EXPECT().StreamID().AnyTimes()
 				rstr.EXPECT().Write(gomock.Any()).Do(buf.Write).AnyTimes()
-				rw := newResponseWriter(rstr, nil, utils.DefaultLogger)
+				rw := newResponseWriter(rstr, nil, newQPACKEncoder(0, nil), nil, utils.DefaultLogger)
 				rw.Header().Set("Content-Encoding", "gzip")
 				gz := gzip.NewWriter(rw)
 				gz.Write([]byte("gzipped response"))
@@ -1016,11 +1642,13 @@ var _ = Describe("Client", func() {
 
 			It("only decompresses the response if the response contains the right content-encoding header", func() {
 				conn.EXPECT().OpenStreamSync(context.Background()).Return(str, nil)
+				str.EXPECT().StreamID().AnyTimes()
 				conn.EXPECT().ConnectionState().Return(quic.ConnectionState{})
 				buf := &bytes.Buffer{}
 				rstr := mockquic.NewMockStream(mockCtrl)
+				rstr.EXPECT().StreamID().AnyTimes()
 				rstr.EXPECT().Write(gomock.Any()).Do(buf.Write).AnyTimes()
-				rw := newResponseWriter(rstr, nil, utils.DefaultLogger)
+				rw := newResponseWriter(rstr, nil, newQPACKEncoder(0, nil), nil, utils.DefaultLogger)
 				rw.Write([]byte("not gzipped"))
 				rw.Flush()
 				str.EXPECT().Write(gomock.Any()).AnyTimes().DoAndReturn(func(p []byte) (int, error) { return len(p), nil })
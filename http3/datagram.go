@@ -0,0 +1,331 @@
+package http3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/quicvarint"
+)
+
+// The DATAGRAM capsule, see section 3.5 of RFC 9297.
+const capsuleTypeDatagram CapsuleType = 0x00
+
+// the number of datagrams that are queued for a stream before datagrams are dropped
+const streamDatagramQueueLen = 32
+
+// the maximum length of a DATAGRAM capsule payload that we accept
+const maxDatagramCapsuleLen = 1 << 16
+
+// Stream IDs are 62-bit integers, so the quarter stream ID can't be larger than 2^60-1, see section 2.1 of RFC 9297.
+const maxQuarterStreamID = 1<<60 - 1
+
+var (
+	errDatagramStreamClosed    = errors.New("http3: stream closed")
+	errDatagramsNotSupported   = errors.New("http3: HTTP Datagrams can only be used with CONNECT requests")
+	errDatagramCapsuleTooLarge = errors.New("http3: DATAGRAM capsule too large")
+)
+
+// The HTTPDatagrammer allows sending and receiving HTTP Datagrams associated with a request, see RFC 9297.
+// HTTP Datagrams can only be used with CONNECT requests (including Extended CONNECT).
+// The interface is implemented by:
+// * for the server: the http.ResponseWriter
+// * for the client: the http.Response.Body
+//
+// HTTP Datagrams are sent in QUIC DATAGRAM frames if both endpoints enabled datagram support.
+// Otherwise, they are sent in DATAGRAM capsules on the request stream. In that case the request
+// stream is used for the Capsule Protocol, and the application must not use it for other data.
+// The same applies if the request or response carries the Capsule-Protocol header (section 3.4 of RFC 9297):
+// DATAGRAM capsules are then accepted on the request stream as well, and ReceiveDatagram returns an error
+// once the peer closes the request stream. Datagrams too large for a QUIC DATAGRAM frame are sent in DATAGRAM capsules.
+type HTTPDatagrammer interface {
+	SendDatagram(b []byte) error
+	ReceiveDatagram(ctx context.Context) ([]byte, error)
+}
+
+type datagramQueue struct {
+	mutex   sync.Mutex
+	queue   [][]byte
+	err     error
+	hasData chan struct{}
+}
+
+func newDatagramQueue() *datagramQueue {
+	return &datagramQueue{hasData: make(chan struct{}, 1)}
+}
+
+func (q *datagramQueue) signal() {
+	select {
+	case q.hasData <- struct{}{}:
+	default:
+	}
+}
+
+// Add queues a datagram. It is dropped if the queue is full.
+func (q *datagramQueue) Add(b []byte) {
+	q.mutex.Lock()
+	if q.err == nil && len(q.queue) < streamDatagramQueueLen {
+		q.queue = append(q.queue, b)
+	}
+	q.mutex.Unlock()
+	q.signal()
+}
+
+// CloseWithError makes all future Receive calls return the error, once the queued datagrams were received.
+func (q *datagramQueue) CloseWithError(e error) {
+	q.mutex.Lock()
+	if q.err == nil {
+		q.err = e
+	}
+	q.mutex.Unlock()
+	q.signal()
+}
+
+func (q *datagramQueue) Receive(ctx context.Context) ([]byte, error) {
+	for {
+		q.mutex.Lock()
+		if len(q.queue) > 0 {
+			b := q.queue[0]
+			q.queue = q.queue[1:]
+			q.mutex.Unlock()
+			return b, nil
+		}
+		err := q.err
+		q.mutex.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		select {
+		case <-q.hasData:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// The datagramManager demultiplexes the HTTP Datagrams received on a QUIC connection.
+// The payload of every datagram starts with the quarter stream ID of the request stream it belongs to.
+// Datagrams for unknown request streams are dropped.
+type datagramManager struct {
+	conn quic.Connection
+	// if HTTP Datagrams were enabled on our side
+	enabled bool
+
+	// closed once the peer's SETTINGS frame was received
+	settingsReceived chan struct{}
+	settingsOnce     sync.Once
+	peerEnabled      bool // set before settingsReceived is closed
+
+	mutex   sync.Mutex
+	streams map[quic.StreamID]*datagramQueue
+	err     error // set once receiving datagrams failed
+}
+
+func newDatagramManager(conn quic.Connection, enabled bool) *datagramManager {
+	return &datagramManager{
+		conn:             conn,
+		enabled:          enabled,
+		settingsReceived: make(chan struct{}),
+		streams:          make(map[quic.StreamID]*datagramQueue),
+	}
+}
+
+// HandleSettings is called with the peer's SETTINGS frame.
+func (m *datagramManager) HandleSettings(sf *settingsFrame) {
+	m.settingsOnce.Do(func() {
+		m.peerEnabled = sf.Datagram
+		close(m.settingsReceived)
+	})
+}
+
+// Run receives datagrams from the QUIC connection until it is closed.
+// It must only be called if HTTP Datagrams were enabled.
+func (m *datagramManager) Run() {
+	for {
+		b, err := m.conn.ReceiveDatagram(context.Background())
+		if err != nil {
+			m.closeWithError(err)
+			return
+		}
+		r := bytes.NewReader(b)
+		quarterStreamID, err := quicvarint.Read(r)
+		if err != nil {
+			m.conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeDatagramError), "failed to parse quarter stream ID")
+			return
+		}
+		if quarterStreamID > maxQuarterStreamID {
+			m.conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeDatagramError), "invalid quarter stream ID")
+			return
+		}
+		m.mutex.Lock()
+		q, ok := m.streams[quic.StreamID(quarterStreamID*4)]
+		m.mutex.Unlock()
+		if ok {
+			q.Add(b[len(b)-r.Len():])
+		}
+	}
+}
+
+func (m *datagramManager) closeWithError(e error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.err = e
+	for _, q := range m.streams {
+		q.CloseWithError(e)
+	}
+	m.streams = nil
+}
+
+// quicDatagramsAvailable says if HTTP Datagrams can be sent in QUIC DATAGRAM frames.
+// This requires both endpoints to enable datagram support, so it blocks until the peer's SETTINGS were received.
+func (m *datagramManager) quicDatagramsAvailable(ctx context.Context) (bool, error) {
+	if !m.enabled {
+		return false, nil
+	}
+	select {
+	case <-m.settingsReceived:
+	case <-ctx.Done():
+		return false, ctx.Err()
+	case <-m.conn.Context().Done():
+		return false, context.Cause(m.conn.Context())
+	}
+	return m.peerEnabled && m.conn.ConnectionState().SupportsDatagrams, nil
+}
+
+// AddStream registers a request stream.
+// writeCapsule and capsules are used for sending and receiving DATAGRAM capsules,
+// if HTTP Datagrams can't be sent in QUIC DATAGRAM frames.
+// resetStream is called if the peer sends a DATAGRAM capsule that is too large.
+func (m *datagramManager) AddStream(id quic.StreamID, writeCapsule func([]byte) error, capsules io.Reader, resetStream func(quic.StreamErrorCode)) *streamDatagrammer {
+	q := newDatagramQueue()
+	if m.enabled {
+		m.mutex.Lock()
+		if m.err != nil {
+			q.CloseWithError(m.err)
+		} else {
+			m.streams[id] = q
+		}
+		m.mutex.Unlock()
+	}
+	return &streamDatagrammer{
+		manager:      m,
+		streamID:     id,
+		queue:        q,
+		writeCapsule: writeCapsule,
+		capsules:     capsules,
+		resetStream:  resetStream,
+	}
+}
+
+// RemoveStream is called once a request stream is done.
+func (m *datagramManager) RemoveStream(id quic.StreamID) {
+	m.mutex.Lock()
+	q, ok := m.streams[id]
+	delete(m.streams, id)
+	m.mutex.Unlock()
+	if ok {
+		q.CloseWithError(errDatagramStreamClosed)
+	}
+}
+
+// The streamDatagrammer sends and receives the HTTP Datagrams associated with a single request stream.
+type streamDatagrammer struct {
+	manager  *datagramManager
+	streamID quic.StreamID
+	queue    *datagramQueue
+
+	writeMutex   sync.Mutex
+	writeCapsule func([]byte) error
+
+	// set if the request stream uses the Capsule Protocol, independent of the availability of QUIC datagrams
+	capsuleProtocol  bool
+	capsules         io.Reader
+	resetStream      func(quic.StreamErrorCode)
+	readCapsulesOnce sync.Once
+}
+
+var _ HTTPDatagrammer = &streamDatagrammer{}
+
+func (d *streamDatagrammer) SendDatagram(b []byte) error {
+	useQUIC, err := d.manager.quicDatagramsAvailable(context.Background())
+	if err != nil {
+		return err
+	}
+	if useQUIC {
+		data := make([]byte, 0, int(quicvarint.Len(uint64(d.streamID/4)))+len(b))
+		data = quicvarint.Append(data, uint64(d.streamID/4))
+		data = append(data, b...)
+		err := d.manager.conn.SendDatagram(data)
+		// If the peer reads capsules from the request stream, datagrams that don't fit
+		// into a QUIC DATAGRAM frame can still be sent in a DATAGRAM capsule.
+		if !d.capsuleProtocol || !errors.Is(err, &quic.DatagramTooLargeError{}) {
+			return err
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCapsule(quicvarint.NewWriter(&buf), capsuleTypeDatagram, b); err != nil {
+		return err
+	}
+	d.writeMutex.Lock()
+	defer d.writeMutex.Unlock()
+	return d.writeCapsule(buf.Bytes())
+}
+
+func (d *streamDatagrammer) ReceiveDatagram(ctx context.Context) ([]byte, error) {
+	useQUIC, err := d.manager.quicDatagramsAvailable(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !useQUIC || d.capsuleProtocol {
+		d.readCapsulesOnce.Do(func() { go d.readCapsules() })
+	}
+	return d.queue.Receive(ctx)
+}
+
+// readCapsules reads the capsules sent on the request stream, and queues the payload of DATAGRAM capsules.
+// Other capsules are skipped.
+func (d *streamDatagrammer) readCapsules() {
+	r := quicvarint.NewReader(d.capsules)
+	for {
+		ct, cr, err := ParseCapsule(r)
+		if err != nil {
+			d.queue.CloseWithError(err)
+			return
+		}
+		if ct != capsuleTypeDatagram {
+			if _, err := io.Copy(io.Discard, cr); err != nil {
+				d.queue.CloseWithError(err)
+				return
+			}
+			continue
+		}
+		// Don't allocate a buffer for an arbitrarily large capsule.
+		if cr.(*exactReader).R.N > maxDatagramCapsuleLen {
+			d.resetStream(quic.StreamErrorCode(ErrCodeDatagramError))
+			d.queue.CloseWithError(errDatagramCapsuleTooLarge)
+			return
+		}
+		b, err := io.ReadAll(cr)
+		if err != nil {
+			d.queue.CloseWithError(err)
+			return
+		}
+		d.queue.Add(b)
+	}
+}
+
+// usesCapsuleProtocol says if the Capsule-Protocol header field is set to true, see section 3.4 of RFC 9297.
+// The value is a Structured Field boolean, which may be followed by (ignored) parameters.
+func usesCapsuleProtocol(h http.Header) bool {
+	v := h.Get("Capsule-Protocol")
+	if i := strings.IndexByte(v, ';'); i >= 0 {
+		v = v[:i]
+	}
+	return strings.TrimSpace(v) == "?1"
+}
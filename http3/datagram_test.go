@@ -0,0 +1,325 @@
+package http3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/quic-go/quic-go"
+	mockquic "github.com/quic-go/quic-go/internal/mocks/quic"
+	"github.com/quic-go/quic-go/quicvarint"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = Describe("Datagrams", func() {
+	Context("queue", func() {
+		It("queues datagrams", func() {
+			q := newDatagramQueue()
+			q.Add([]byte("foo"))
+			q.Add([]byte("bar"))
+			b, err := q.Receive(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(b).To(Equal([]byte("foo")))
+			b, err = q.Receive(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(b).To(Equal([]byte("bar")))
+		})
+
+		It("blocks until a datagram is queued", func() {
+			q := newDatagramQueue()
+			received := make(chan []byte)
+			go func() {
+				defer GinkgoRecover()
+				b, err := q.Receive(context.Background())
+				Expect(err).ToNot(HaveOccurred())
+				received <- b
+			}()
+			Consistently(received).ShouldNot(Receive())
+			q.Add([]byte("foobar"))
+			Eventually(received).Should(Receive(Equal([]byte("foobar"))))
+		})
+
+		It("drops datagrams when the queue is full", func() {
+			q := newDatagramQueue()
+			for i := 0; i < streamDatagramQueueLen+5; i++ {
+				q.Add([]byte{byte(i)})
+			}
+			for i := 0; i < streamDatagramQueueLen; i++ {
+				b, err := q.Receive(context.Background())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(b).To(Equal([]byte{byte(i)}))
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_, err := q.Receive(ctx)
+			Expect(err).To(MatchError(context.Canceled))
+		})
+
+		It("returns the error once all queued datagrams were received", func() {
+			q := newDatagramQueue()
+			q.Add([]byte("foobar"))
+			testErr := errors.New("test error")
+			q.CloseWithError(testErr)
+			q.Add([]byte("raboof"))
+			b, err := q.Receive(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(b).To(Equal([]byte("foobar")))
+			_, err = q.Receive(context.Background())
+			Expect(err).To(MatchError(testErr))
+		})
+	})
+
+	Context("demultiplexing", func() {
+		var (
+			conn      *mockquic.MockEarlyConnection
+			datagrams chan []byte
+			// closing the connection makes ReceiveDatagram return connErr
+			closeConn func()
+			m         *datagramManager
+		)
+
+		connErr := errors.New("connection closed")
+
+		BeforeEach(func() {
+			conn = mockquic.NewMockEarlyConnection(mockCtrl)
+			dgs := make(chan []byte, 10)
+			closed := make(chan struct{})
+			var once sync.Once
+			datagrams = dgs
+			closeConn = func() { once.Do(func() { close(closed) }) }
+			conn.EXPECT().ReceiveDatagram(gomock.Any()).DoAndReturn(func(context.Context) ([]byte, error) {
+				select {
+				case b := <-dgs:
+					return b, nil
+				case <-closed:
+					return nil, connErr
+				}
+			}).AnyTimes()
+			m = newDatagramManager(conn, true)
+		})
+
+		AfterEach(func() {
+			closeConn()
+		})
+
+		It("dispatches datagrams to the request streams", func() {
+			d1 := m.AddStream(0, nil, nil, nil)
+			d2 := m.AddStream(8, nil, nil, nil)
+			go m.Run()
+			m.HandleSettings(&settingsFrame{Datagram: true})
+			conn.EXPECT().Context().Return(context.Background()).AnyTimes()
+			conn.EXPECT().ConnectionState().Return(quic.ConnectionState{SupportsDatagrams: true}).AnyTimes()
+
+			datagrams <- append(quicvarint.Append(nil, 2), "foo"...)
+			datagrams <- append(quicvarint.Append(nil, 1), "unknown stream"...)
+			datagrams <- append(quicvarint.Append(nil, 0), "bar"...)
+			b, err := d2.ReceiveDatagram(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(b).To(Equal([]byte("foo")))
+			b, err = d1.ReceiveDatagram(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(b).To(Equal([]byte("bar")))
+		})
+
+		It("stops delivering datagrams when a stream is removed", func() {
+			d := m.AddStream(4, nil, nil, nil)
+			m.RemoveStream(4)
+			conn.EXPECT().Context().Return(context.Background()).AnyTimes()
+			conn.EXPECT().ConnectionState().Return(quic.ConnectionState{SupportsDatagrams: true}).AnyTimes()
+			m.HandleSettings(&settingsFrame{Datagram: true})
+			_, err := d.ReceiveDatagram(context.Background())
+			Expect(err).To(MatchError(errDatagramStreamClosed))
+		})
+
+		It("closes the connection when the quarter stream ID can't be parsed", func() {
+			done := make(chan struct{})
+			conn.EXPECT().CloseWithError(quic.ApplicationErrorCode(ErrCodeDatagramError), gomock.Any()).Do(func(quic.ApplicationErrorCode, string) error {
+				close(done)
+				return nil
+			})
+			datagrams <- []byte{}
+			go m.Run()
+			Eventually(done).Should(BeClosed())
+		})
+
+		It("closes the connection when the quarter stream ID is too large", func() {
+			done := make(chan struct{})
+			conn.EXPECT().CloseWithError(quic.ApplicationErrorCode(ErrCodeDatagramError), gomock.Any()).Do(func(quic.ApplicationErrorCode, string) error {
+				close(done)
+				return nil
+			})
+			datagrams <- append(quicvarint.Append(nil, maxQuarterStreamID+1), "foobar"...)
+			go m.Run()
+			Eventually(done).Should(BeClosed())
+		})
+
+		It("returns the connection error to all streams", func() {
+			d := m.AddStream(0, nil, nil, nil)
+			conn.EXPECT().Context().Return(context.Background()).AnyTimes()
+			conn.EXPECT().ConnectionState().Return(quic.ConnectionState{SupportsDatagrams: true}).AnyTimes()
+			m.HandleSettings(&settingsFrame{Datagram: true})
+			runDone := make(chan struct{})
+			go func() {
+				defer close(runDone)
+				m.Run()
+			}()
+			closeConn()
+			Eventually(runDone).Should(BeClosed())
+			_, err := d.ReceiveDatagram(context.Background())
+			Expect(err).To(MatchError(connErr))
+			// new streams receive the error as well
+			_, err = m.AddStream(4, nil, nil, nil).ReceiveDatagram(context.Background())
+			Expect(err).To(MatchError(connErr))
+		})
+	})
+
+	Context("sending", func() {
+		var conn *mockquic.MockEarlyConnection
+
+		BeforeEach(func() {
+			conn = mockquic.NewMockEarlyConnection(mockCtrl)
+			conn.EXPECT().Context().Return(context.Background()).AnyTimes()
+		})
+
+		It("sends QUIC datagrams prefixed with the quarter stream ID", func() {
+			m := newDatagramManager(conn, true)
+			m.HandleSettings(&settingsFrame{Datagram: true})
+			conn.EXPECT().ConnectionState().Return(quic.ConnectionState{SupportsDatagrams: true})
+			conn.EXPECT().SendDatagram(append(quicvarint.Append(nil, 3), "foobar"...))
+			Expect(m.AddStream(12, nil, nil, nil).SendDatagram([]byte("foobar"))).To(Succeed())
+		})
+
+		It("waits for the peer's SETTINGS", func() {
+			m := newDatagramManager(conn, true)
+			d := m.AddStream(12, nil, nil, nil)
+			errChan := make(chan error, 1)
+			go func() { errChan <- d.SendDatagram([]byte("foobar")) }()
+			Consistently(errChan).ShouldNot(Receive())
+			conn.EXPECT().ConnectionState().Return(quic.ConnectionState{SupportsDatagrams: true})
+			conn.EXPECT().SendDatagram(gomock.Any())
+			m.HandleSettings(&settingsFrame{Datagram: true})
+			Eventually(errChan).Should(Receive(BeNil()))
+		})
+
+		It("sends DATAGRAM capsules if the peer doesn't support HTTP Datagrams", func() {
+			m := newDatagramManager(conn, true)
+			m.HandleSettings(&settingsFrame{})
+			var capsules [][]byte
+			d := m.AddStream(12, func(b []byte) error {
+				capsules = append(capsules, b)
+				return nil
+			}, nil, nil)
+			Expect(d.SendDatagram([]byte("foobar"))).To(Succeed())
+			Expect(capsules).To(HaveLen(1))
+			ct, r, err := ParseCapsule(quicvarint.NewReader(bytes.NewReader(capsules[0])))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ct).To(Equal(capsuleTypeDatagram))
+			data, err := io.ReadAll(r)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(Equal([]byte("foobar")))
+		})
+
+		It("sends DATAGRAM capsules for large datagrams if the Capsule Protocol is used", func() {
+			m := newDatagramManager(conn, true)
+			m.HandleSettings(&settingsFrame{Datagram: true})
+			conn.EXPECT().ConnectionState().Return(quic.ConnectionState{SupportsDatagrams: true}).Times(2)
+			var capsules [][]byte
+			d := m.AddStream(12, func(b []byte) error {
+				capsules = append(capsules, b)
+				return nil
+			}, nil, nil)
+			conn.EXPECT().SendDatagram(gomock.Any()).Return(&quic.DatagramTooLargeError{MaxDataLen: 1000})
+			Expect(d.SendDatagram(make([]byte, 1337))).To(MatchError(&quic.DatagramTooLargeError{}))
+			Expect(capsules).To(BeEmpty())
+
+			d.capsuleProtocol = true
+			conn.EXPECT().SendDatagram(gomock.Any()).Return(&quic.DatagramTooLargeError{MaxDataLen: 1000})
+			Expect(d.SendDatagram(make([]byte, 1337))).To(Succeed())
+			Expect(capsules).To(HaveLen(1))
+		})
+
+		It("sends DATAGRAM capsules if HTTP Datagrams are disabled", func() {
+			m := newDatagramManager(conn, false)
+			var capsules [][]byte
+			d := m.AddStream(12, func(b []byte) error {
+				capsules = append(capsules, b)
+				return nil
+			}, nil, nil)
+			Expect(d.SendDatagram([]byte("foobar"))).To(Succeed())
+			Expect(capsules).To(HaveLen(1))
+		})
+	})
+
+	It("receives DATAGRAM capsules if HTTP Datagrams are disabled", func() {
+		conn := mockquic.NewMockEarlyConnection(mockCtrl)
+		m := newDatagramManager(conn, false)
+		buf := &bytes.Buffer{}
+		Expect(WriteCapsule(quicvarint.NewWriter(buf), capsuleTypeDatagram, []byte("foo"))).To(Succeed())
+		Expect(WriteCapsule(quicvarint.NewWriter(buf), 0x1337, []byte("unknown capsule"))).To(Succeed())
+		Expect(WriteCapsule(quicvarint.NewWriter(buf), capsuleTypeDatagram, []byte("bar"))).To(Succeed())
+		d := m.AddStream(0, nil, buf, nil)
+		b, err := d.ReceiveDatagram(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(b).To(Equal([]byte("foo")))
+		b, err = d.ReceiveDatagram(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(b).To(Equal([]byte("bar")))
+		// the stream ended
+		_, err = d.ReceiveDatagram(context.Background())
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("resets the stream when a DATAGRAM capsule is too large", func() {
+		conn := mockquic.NewMockEarlyConnection(mockCtrl)
+		m := newDatagramManager(conn, false)
+		buf := &bytes.Buffer{}
+		Expect(WriteCapsule(quicvarint.NewWriter(buf), capsuleTypeDatagram, []byte("foo"))).To(Succeed())
+		// only the capsule header is sent, the payload is never read
+		buf.Write(quicvarint.Append(nil, uint64(capsuleTypeDatagram)))
+		buf.Write(quicvarint.Append(nil, maxDatagramCapsuleLen+1))
+		var resetCode quic.StreamErrorCode
+		var reset bool
+		d := m.AddStream(0, nil, buf, func(code quic.StreamErrorCode) {
+			reset = true
+			resetCode = code
+		})
+		b, err := d.ReceiveDatagram(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(b).To(Equal([]byte("foo")))
+		_, err = d.ReceiveDatagram(context.Background())
+		Expect(err).To(MatchError(errDatagramCapsuleTooLarge))
+		Expect(reset).To(BeTrue())
+		Expect(resetCode).To(Equal(quic.StreamErrorCode(ErrCodeDatagramError)))
+	})
+
+	It("receives DATAGRAM capsules in addition to QUIC datagrams if the Capsule Protocol is used", func() {
+		conn := mockquic.NewMockEarlyConnection(mockCtrl)
+		conn.EXPECT().Context().Return(context.Background()).AnyTimes()
+		conn.EXPECT().ConnectionState().Return(quic.ConnectionState{SupportsDatagrams: true}).AnyTimes()
+		m := newDatagramManager(conn, true)
+		m.HandleSettings(&settingsFrame{Datagram: true})
+		buf := &bytes.Buffer{}
+		Expect(WriteCapsule(quicvarint.NewWriter(buf), capsuleTypeDatagram, []byte("foo"))).To(Succeed())
+		d := m.AddStream(0, nil, buf, nil)
+		d.capsuleProtocol = true
+		b, err := d.ReceiveDatagram(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(b).To(Equal([]byte("foo")))
+		// the request stream was closed
+		_, err = d.ReceiveDatagram(context.Background())
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("detects the Capsule-Protocol header", func() {
+		Expect(usesCapsuleProtocol(http.Header{})).To(BeFalse())
+		Expect(usesCapsuleProtocol(http.Header{"Capsule-Protocol": []string{"?0"}})).To(BeFalse())
+		Expect(usesCapsuleProtocol(http.Header{"Capsule-Protocol": []string{"?1"}})).To(BeTrue())
+		Expect(usesCapsuleProtocol(http.Header{"Capsule-Protocol": []string{"?1;foo=bar"}})).To(BeTrue())
+	})
+})
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/quic-go/quic-go"
 	"github.com/quic-go/quic-go/internal/protocol"
 	"github.com/quic-go/quic-go/quicvarint"
 )
@@ -58,10 +59,16 @@ func parseNextFrame(r io.Reader, unknownFrameHandler unknownFrameHandlerFunc) (f
 			return &headersFrame{Length: l}, nil
 		case 0x4:
 			return parseSettingsFrame(r, l)
-		case 0x3: // CANCEL_PUSH
-		case 0x5: // PUSH_PROMISE
-		case 0x7: // GOAWAY
-		case 0xd: // MAX_PUSH_ID
+		case 0x7:
+			return parseGoAwayFrame(r, l)
+		case frameTypePriorityUpdateRequest, frameTypePriorityUpdatePush:
+			return parsePriorityUpdateFrame(r, t, l)
+		case frameTypeCancelPush, frameTypePushPromise, frameTypeMaxPushID,
+			frameTypeHTTP2Priority, frameTypeHTTP2Ping, frameTypeHTTP2WindowUpdate, frameTypeHTTP2Continuation:
+			if _, err := io.CopyN(io.Discard, qr, int64(l)); err != nil {
+				return nil, err
+			}
+			return &skippedFrame{Type: t}, nil
 		}
 		// skip over unknown frames
 		if _, err := io.CopyN(io.Discard, qr, int64(l)); err != nil {
@@ -88,11 +95,24 @@ func (f *headersFrame) Append(b []byte) []byte {
 	return quicvarint.Append(b, f.Length)
 }
 
-const settingDatagram = 0x33
+const (
+	// SETTINGS_QPACK_MAX_TABLE_CAPACITY and SETTINGS_QPACK_BLOCKED_STREAMS, see section 5 of RFC 9204
+	settingQPACKMaxTableCapacity = 0x1
+	settingQPACKBlockedStreams   = 0x7
+	// SETTINGS_MAX_FIELD_SECTION_SIZE, see section 7.2.4.1 of RFC 9114
+	settingMaxFieldSectionSize = 0x6
+	// SETTINGS_ENABLE_CONNECT_PROTOCOL, see section 3 of RFC 9220
+	settingExtendedConnect = 0x8
+	settingDatagram        = 0x33
+)
 
 type settingsFrame struct {
-	Datagram bool
-	Other    map[uint64]uint64 // all settings that we don't explicitly recognize
+	Datagram              bool
+	ExtendedConnect       bool
+	QPACKMaxTableCapacity uint64
+	QPACKBlockedStreams   uint64
+	MaxFieldSectionSize   uint64            // 0 if the setting was not sent, i.e. there's no limit
+	Other                 map[uint64]uint64 // all settings that we don't explicitly recognize
 }
 
 func parseSettingsFrame(r io.Reader, l uint64) (*settingsFrame, error) {
@@ -108,7 +128,7 @@ func parseSettingsFrame(r io.Reader, l uint64) (*settingsFrame, error) {
 	}
 	frame := &settingsFrame{}
 	b := bytes.NewReader(buf)
-	var readDatagram bool
+	var readDatagram, readExtendedConnect, readQPACKMaxTableCapacity, readQPACKBlockedStreams, readMaxFieldSectionSize bool
 	for b.Len() > 0 {
 		id, err := quicvarint.Read(b)
 		if err != nil { // should not happen. We allocated the whole frame already.
@@ -120,6 +140,33 @@ func parseSettingsFrame(r io.Reader, l uint64) (*settingsFrame, error) {
 		}
 
 		switch id {
+		case settingQPACKMaxTableCapacity:
+			if readQPACKMaxTableCapacity {
+				return nil, fmt.Errorf("duplicate setting: %d", id)
+			}
+			readQPACKMaxTableCapacity = true
+			frame.QPACKMaxTableCapacity = val
+		case settingQPACKBlockedStreams:
+			if readQPACKBlockedStreams {
+				return nil, fmt.Errorf("duplicate setting: %d", id)
+			}
+			readQPACKBlockedStreams = true
+			frame.QPACKBlockedStreams = val
+		case settingMaxFieldSectionSize:
+			if readMaxFieldSectionSize {
+				return nil, fmt.Errorf("duplicate setting: %d", id)
+			}
+			readMaxFieldSectionSize = true
+			frame.MaxFieldSectionSize = val
+		case settingExtendedConnect:
+			if readExtendedConnect {
+				return nil, fmt.Errorf("duplicate setting: %d", id)
+			}
+			readExtendedConnect = true
+			if val != 0 && val != 1 {
+				return nil, fmt.Errorf("invalid value for SETTINGS_ENABLE_CONNECT_PROTOCOL: %d", val)
+			}
+			frame.ExtendedConnect = val == 1
 		case settingDatagram:
 			if readDatagram {
 				return nil, fmt.Errorf("duplicate setting: %d", id)
@@ -151,14 +198,166 @@ func (f *settingsFrame) Append(b []byte) []byte {
 	if f.Datagram {
 		l += quicvarint.Len(settingDatagram) + quicvarint.Len(1)
 	}
+	if f.ExtendedConnect {
+		l += quicvarint.Len(settingExtendedConnect) + quicvarint.Len(1)
+	}
+	if f.QPACKMaxTableCapacity > 0 {
+		l += quicvarint.Len(settingQPACKMaxTableCapacity) + quicvarint.Len(f.QPACKMaxTableCapacity)
+	}
+	if f.QPACKBlockedStreams > 0 {
+		l += quicvarint.Len(settingQPACKBlockedStreams) + quicvarint.Len(f.QPACKBlockedStreams)
+	}
+	if f.MaxFieldSectionSize > 0 {
+		l += quicvarint.Len(settingMaxFieldSectionSize) + quicvarint.Len(f.MaxFieldSectionSize)
+	}
 	b = quicvarint.Append(b, uint64(l))
 	if f.Datagram {
 		b = quicvarint.Append(b, settingDatagram)
 		b = quicvarint.Append(b, 1)
 	}
+	if f.ExtendedConnect {
+		b = quicvarint.Append(b, settingExtendedConnect)
+		b = quicvarint.Append(b, 1)
+	}
+	if f.QPACKMaxTableCapacity > 0 {
+		b = quicvarint.Append(b, settingQPACKMaxTableCapacity)
+		b = quicvarint.Append(b, f.QPACKMaxTableCapacity)
+	}
+	if f.QPACKBlockedStreams > 0 {
+		b = quicvarint.Append(b, settingQPACKBlockedStreams)
+		b = quicvarint.Append(b, f.QPACKBlockedStreams)
+	}
+	if f.MaxFieldSectionSize > 0 {
+		b = quicvarint.Append(b, settingMaxFieldSectionSize)
+		b = quicvarint.Append(b, f.MaxFieldSectionSize)
+	}
 	for id, val := range f.Other {
 		b = quicvarint.Append(b, id)
 		b = quicvarint.Append(b, val)
 	}
 	return b
 }
+
+const (
+	// frame types used for server push, see section 7.2 of RFC 9114
+	frameTypeCancelPush  = 0x3
+	frameTypePushPromise = 0x5
+	frameTypeMaxPushID   = 0xd
+	// frame types used in HTTP/2 that are reserved in HTTP/3, see section 7.2.8 of RFC 9114
+	frameTypeHTTP2Priority     = 0x2
+	frameTypeHTTP2Ping         = 0x6
+	frameTypeHTTP2WindowUpdate = 0x8
+	frameTypeHTTP2Continuation = 0x9
+)
+
+// A skippedFrame is a frame that we don't process, and whose payload was skipped:
+// We never use server push, and the frame types reserved by HTTP/2 must never be sent.
+// Depending on the stream it is received on, receiving such a frame is an error.
+type skippedFrame struct {
+	Type uint64
+}
+
+// The GOAWAY frame, as defined in section 7.2.6 of RFC 9114.
+// When sent by the server, it carries a stream ID, when sent by the client, it carries a push ID.
+type goAwayFrame struct {
+	StreamID quic.StreamID
+}
+
+func parseGoAwayFrame(r io.Reader, l uint64) (*goAwayFrame, error) {
+	if l > 8 {
+		return nil, fmt.Errorf("unexpected size for GOAWAY frame: %d", l)
+	}
+	buf := make([]byte, l)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+	b := bytes.NewReader(buf)
+	id, err := quicvarint.Read(b)
+	if err != nil || b.Len() > 0 {
+		return nil, errors.New("invalid GOAWAY frame")
+	}
+	return &goAwayFrame{StreamID: quic.StreamID(id)}, nil
+}
+
+func (f *goAwayFrame) Append(b []byte) []byte {
+	b = quicvarint.Append(b, 0x7)
+	b = quicvarint.Append(b, uint64(quicvarint.Len(uint64(f.StreamID))))
+	return quicvarint.Append(b, uint64(f.StreamID))
+}
+
+const (
+	frameTypePriorityUpdateRequest = 0xf0700
+	frameTypePriorityUpdatePush    = 0xf0701
+)
+
+// The PRIORITY_UPDATE frame, as defined in section 7.2 of RFC 9218.
+// It is only sent on the control stream.
+type priorityUpdateFrame struct {
+	IsPush bool
+	// the stream ID of the request stream, or the Push ID of the push stream
+	PrioritizedElementID uint64
+	// the Priority Field Value, using the same syntax as the Priority header field
+	PriorityFieldValue string
+}
+
+func parsePriorityUpdateFrame(r io.Reader, t, l uint64) (*priorityUpdateFrame, error) {
+	if l > 8*(1<<10) {
+		return nil, fmt.Errorf("unexpected size for PRIORITY_UPDATE frame: %d", l)
+	}
+	buf := make([]byte, l)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+	b := bytes.NewReader(buf)
+	id, err := quicvarint.Read(b)
+	if err != nil {
+		return nil, errors.New("PRIORITY_UPDATE frame too short")
+	}
+	return &priorityUpdateFrame{
+		IsPush:               t == frameTypePriorityUpdatePush,
+		PrioritizedElementID: id,
+		PriorityFieldValue:   string(buf[len(buf)-b.Len():]),
+	}, nil
+}
+
+func (f *priorityUpdateFrame) Append(b []byte) []byte {
+	if f.IsPush {
+		b = quicvarint.Append(b, frameTypePriorityUpdatePush)
+	} else {
+		b = quicvarint.Append(b, frameTypePriorityUpdateRequest)
+	}
+	b = quicvarint.Append(b, uint64(int(quicvarint.Len(f.PrioritizedElementID))+len(f.PriorityFieldValue)))
+	b = quicvarint.Append(b, f.PrioritizedElementID)
+	return append(b, f.PriorityFieldValue...)
+}
+
+// A controlStreamReader reads from the peer's control stream, and remembers if reading from the stream failed.
+// This allows distinguishing between the control stream being closed and the peer sending a malformed frame.
+type controlStreamReader struct {
+	quic.ReceiveStream
+	readErr error
+}
+
+func (r *controlStreamReader) Read(b []byte) (int, error) {
+	n, err := r.ReceiveStream.Read(b)
+	if err != nil && r.readErr == nil {
+		r.readErr = err
+	}
+	return n, err
+}
+
+// closeConnection closes the connection after parsing a frame on the control stream failed.
+// The control stream is a critical stream, the peer must not close it, see section 6.2.1 of RFC 9114.
+func (r *controlStreamReader) closeConnection(conn quic.Connection, err error) {
+	if r.readErr != nil {
+		conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeClosedCriticalStream), "control stream closed")
+		return
+	}
+	conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeFrameError), err.Error())
+}
@@ -29,6 +29,37 @@ var _ = Describe("Frames", func() {
 		Expect(frame.(*dataFrame).Length).To(Equal(uint64(0x1234)))
 	})
 
+	Context("push and reserved HTTP/2 frames", func() {
+		for _, t := range []uint64{
+			frameTypeCancelPush, frameTypePushPromise, frameTypeMaxPushID,
+			frameTypeHTTP2Priority, frameTypeHTTP2Ping, frameTypeHTTP2WindowUpdate, frameTypeHTTP2Continuation,
+		} {
+			frameType := t
+
+			It(fmt.Sprintf("skips the payload of frames of type 0x%x", frameType), func() {
+				b := quicvarint.Append(nil, frameType)
+				b = quicvarint.Append(b, 0x10)
+				b = append(b, make([]byte, 0x10)...)
+				b = (&dataFrame{Length: 0x1234}).Append(b)
+				r := bytes.NewReader(b)
+				frame, err := parseNextFrame(r, nil)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(frame).To(Equal(&skippedFrame{Type: frameType}))
+				frame, err = parseNextFrame(r, nil)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(frame).To(Equal(&dataFrame{Length: 0x1234}))
+			})
+		}
+
+		It("errors on EOF", func() {
+			b := quicvarint.Append(nil, frameTypeHTTP2Ping)
+			b = quicvarint.Append(b, 8)
+			b = append(b, make([]byte, 7)...)
+			_, err := parseNextFrame(bytes.NewReader(b), nil)
+			Expect(err).To(MatchError(io.EOF))
+		})
+	})
+
 	Context("DATA frames", func() {
 		It("parses", func() {
 			data := quicvarint.Append(nil, 0) // type byte
@@ -100,7 +131,7 @@ var _ = Describe("Frames", func() {
 
 		It("writes", func() {
 			sf := &settingsFrame{Other: map[uint64]uint64{
-				1:  2,
+				3:  2,
 				99: 999,
 				13: 37,
 			}}
@@ -127,6 +158,50 @@ var _ = Describe("Frames", func() {
 			}
 		})
 
+		Context("QPACK settings", func() {
+			It("reads and writes the QPACK settings", func() {
+				sf := &settingsFrame{QPACKMaxTableCapacity: 4096, QPACKBlockedStreams: 16}
+				frame, err := parseNextFrame(bytes.NewReader(sf.Append(nil)), nil)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(frame).To(Equal(sf))
+			})
+
+			It("rejects duplicate QPACK settings", func() {
+				for _, id := range []uint64{settingQPACKMaxTableCapacity, settingQPACKBlockedStreams} {
+					settings := quicvarint.Append(nil, id)
+					settings = quicvarint.Append(settings, 100)
+					settings = quicvarint.Append(settings, id)
+					settings = quicvarint.Append(settings, 100)
+					data := quicvarint.Append(nil, 4) // type byte
+					data = quicvarint.Append(data, uint64(len(settings)))
+					data = append(data, settings...)
+					_, err := parseNextFrame(bytes.NewReader(data), nil)
+					Expect(err).To(MatchError(fmt.Sprintf("duplicate setting: %d", id)))
+				}
+			})
+		})
+
+		Context("SETTINGS_MAX_FIELD_SECTION_SIZE", func() {
+			It("reads and writes the SETTINGS_MAX_FIELD_SECTION_SIZE value", func() {
+				sf := &settingsFrame{MaxFieldSectionSize: 1 << 20}
+				frame, err := parseNextFrame(bytes.NewReader(sf.Append(nil)), nil)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(frame).To(Equal(sf))
+			})
+
+			It("rejects duplicate SETTINGS_MAX_FIELD_SECTION_SIZE values", func() {
+				settings := quicvarint.Append(nil, settingMaxFieldSectionSize)
+				settings = quicvarint.Append(settings, 100)
+				settings = quicvarint.Append(settings, settingMaxFieldSectionSize)
+				settings = quicvarint.Append(settings, 100)
+				data := quicvarint.Append(nil, 4) // type byte
+				data = quicvarint.Append(data, uint64(len(settings)))
+				data = append(data, settings...)
+				_, err := parseNextFrame(bytes.NewReader(data), nil)
+				Expect(err).To(MatchError(fmt.Sprintf("duplicate setting: %d", settingMaxFieldSectionSize)))
+			})
+		})
+
 		Context("H3_DATAGRAM", func() {
 			It("reads the H3_DATAGRAM value", func() {
 				settings := quicvarint.Append(nil, settingDatagram)
@@ -170,6 +245,129 @@ var _ = Describe("Frames", func() {
 				Expect(frame).To(Equal(sf))
 			})
 		})
+
+		Context("SETTINGS_ENABLE_CONNECT_PROTOCOL", func() {
+			It("reads the SETTINGS_ENABLE_CONNECT_PROTOCOL value", func() {
+				settings := quicvarint.Append(nil, settingExtendedConnect)
+				settings = quicvarint.Append(settings, 1)
+				data := quicvarint.Append(nil, 4) // type byte
+				data = quicvarint.Append(data, uint64(len(settings)))
+				data = append(data, settings...)
+				f, err := parseNextFrame(bytes.NewReader(data), nil)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(f).To(BeAssignableToTypeOf(&settingsFrame{}))
+				sf := f.(*settingsFrame)
+				Expect(sf.ExtendedConnect).To(BeTrue())
+			})
+
+			It("rejects duplicate SETTINGS_ENABLE_CONNECT_PROTOCOL entries", func() {
+				settings := quicvarint.Append(nil, settingExtendedConnect)
+				settings = quicvarint.Append(settings, 1)
+				settings = quicvarint.Append(settings, settingExtendedConnect)
+				settings = quicvarint.Append(settings, 1)
+				data := quicvarint.Append(nil, 4) // type byte
+				data = quicvarint.Append(data, uint64(len(settings)))
+				data = append(data, settings...)
+				_, err := parseNextFrame(bytes.NewReader(data), nil)
+				Expect(err).To(MatchError(fmt.Sprintf("duplicate setting: %d", settingExtendedConnect)))
+			})
+
+			It("rejects invalid values for the SETTINGS_ENABLE_CONNECT_PROTOCOL entry", func() {
+				settings := quicvarint.Append(nil, settingExtendedConnect)
+				settings = quicvarint.Append(settings, 1337)
+				data := quicvarint.Append(nil, 4) // type byte
+				data = quicvarint.Append(data, uint64(len(settings)))
+				data = append(data, settings...)
+				_, err := parseNextFrame(bytes.NewReader(data), nil)
+				Expect(err).To(MatchError("invalid value for SETTINGS_ENABLE_CONNECT_PROTOCOL: 1337"))
+			})
+
+			It("writes the SETTINGS_ENABLE_CONNECT_PROTOCOL setting", func() {
+				sf := &settingsFrame{ExtendedConnect: true, Datagram: true}
+				frame, err := parseNextFrame(bytes.NewReader(sf.Append(nil)), nil)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(frame).To(Equal(sf))
+			})
+		})
+	})
+
+	Context("PRIORITY_UPDATE frames", func() {
+		It("writes and parses a frame for a request stream", func() {
+			f := &priorityUpdateFrame{PrioritizedElementID: 1337, PriorityFieldValue: "u=1, i"}
+			b := f.Append(nil)
+			Expect(b[:4]).To(Equal(quicvarint.Append(nil, 0xf0700)))
+			frame, err := parseNextFrame(bytes.NewReader(b), nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame).To(Equal(f))
+		})
+
+		It("writes and parses a frame for a push stream", func() {
+			f := &priorityUpdateFrame{IsPush: true, PrioritizedElementID: 42, PriorityFieldValue: "u=5"}
+			b := f.Append(nil)
+			Expect(b[:4]).To(Equal(quicvarint.Append(nil, 0xf0701)))
+			frame, err := parseNextFrame(bytes.NewReader(b), nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame).To(Equal(f))
+		})
+
+		It("parses a frame with an empty Priority Field Value", func() {
+			f := &priorityUpdateFrame{PrioritizedElementID: 4}
+			frame, err := parseNextFrame(bytes.NewReader(f.Append(nil)), nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame).To(Equal(f))
+		})
+
+		It("errors when the Prioritized Element ID is missing", func() {
+			data := quicvarint.Append(nil, 0xf0700)
+			data = quicvarint.Append(data, 0)
+			_, err := parseNextFrame(bytes.NewReader(data), nil)
+			Expect(err).To(MatchError("PRIORITY_UPDATE frame too short"))
+		})
+
+		It("rejects frames that are too large", func() {
+			data := quicvarint.Append(nil, 0xf0700)
+			data = quicvarint.Append(data, 1<<20)
+			_, err := parseNextFrame(bytes.NewReader(data), nil)
+			Expect(err).To(MatchError("unexpected size for PRIORITY_UPDATE frame: 1048576"))
+		})
+
+		It("errors on EOF", func() {
+			b := (&priorityUpdateFrame{PrioritizedElementID: 4, PriorityFieldValue: "u=2"}).Append(nil)
+			_, err := parseNextFrame(bytes.NewReader(b[:len(b)-1]), nil)
+			Expect(err).To(MatchError(io.EOF))
+		})
+	})
+
+	Context("GOAWAY frames", func() {
+		It("writes and parses", func() {
+			f := &goAwayFrame{StreamID: 100}
+			b := f.Append(nil)
+			Expect(b[0]).To(BeEquivalentTo(0x7))
+			frame, err := parseNextFrame(bytes.NewReader(b), nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame).To(Equal(f))
+		})
+
+		It("errors when the frame contains more data than the stream ID", func() {
+			data := quicvarint.Append(nil, 0x7)
+			data = quicvarint.Append(data, 2)
+			data = append(data, 0x4, 0x0)
+			_, err := parseNextFrame(bytes.NewReader(data), nil)
+			Expect(err).To(MatchError("invalid GOAWAY frame"))
+		})
+
+		It("rejects frames that are too large", func() {
+			data := quicvarint.Append(nil, 0x7)
+			data = quicvarint.Append(data, 9)
+			_, err := parseNextFrame(bytes.NewReader(data), nil)
+			Expect(err).To(MatchError("unexpected size for GOAWAY frame: 9"))
+		})
+
+		It("errors on EOF", func() {
+			b := (&goAwayFrame{StreamID: 1 << 20}).Append(nil)
+			_, err := parseNextFrame(bytes.NewReader(b[:len(b)-1]), nil)
+			Expect(err).To(MatchError(io.EOF))
+		})
 	})
 
 	Context("hijacking", func() {
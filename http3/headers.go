@@ -1,9 +1,12 @@
 package http3
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/textproto"
 	"net/url"
 	"strconv"
 	"strings"
@@ -11,6 +14,7 @@ import (
 	"golang.org/x/net/http/httpguts"
 
 	"github.com/quic-go/qpack"
+	"github.com/quic-go/quic-go"
 )
 
 type header struct {
@@ -101,6 +105,81 @@ func parseHeaders(headers []qpack.HeaderField, isRequest bool) (header, error) {
 	return hdr, nil
 }
 
+// parseTrailers parses the trailer field section.
+// Pseudo header fields are not allowed in trailers, see section 4.3 of RFC 9114.
+func parseTrailers(headers []qpack.HeaderField) (http.Header, error) {
+	h := make(http.Header, len(headers))
+	for _, field := range headers {
+		if field.IsPseudo() {
+			return nil, fmt.Errorf("received pseudo header in trailer: %s", field.Name)
+		}
+		if strings.ToLower(field.Name) != field.Name {
+			return nil, fmt.Errorf("trailer field is not lower-case: %s", field.Name)
+		}
+		if !httpguts.ValidHeaderFieldName(field.Name) {
+			return nil, fmt.Errorf("invalid trailer field name: %q", field.Name)
+		}
+		if !httpguts.ValidHeaderFieldValue(field.Value) {
+			return nil, fmt.Errorf("invalid trailer field value for %s: %q", field.Name, field.Value)
+		}
+		h.Add(field.Name, field.Value)
+	}
+	return h, nil
+}
+
+// decodeTrailers reads and decodes the payload of a HEADERS frame carrying the trailer field section.
+func decodeTrailers(ctx context.Context, r io.Reader, l, maxHeaderBytes uint64, streamID quic.StreamID, decoder *qpackDecoder) (http.Header, error) {
+	if l > maxHeaderBytes {
+		// The field section is never decoded, so the peer's encoder can release its dynamic table references.
+		if err := decoder.cancelStream(streamID); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("HEADERS frame too large: %d bytes (max: %d)", l, maxHeaderBytes)
+	}
+	b := make([]byte, l)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+	fields, err := decoder.Decode(ctx, streamID, b)
+	if err != nil {
+		return nil, err
+	}
+	if size := fieldSectionSize(fields); size > maxHeaderBytes {
+		return nil, fmt.Errorf("trailer too large: %d bytes (max: %d)", size, maxHeaderBytes)
+	}
+	return parseTrailers(fields)
+}
+
+// announcedTrailers returns the trailers announced in the Trailer header field, with nil values.
+// Fields that are not allowed in trailers are ignored.
+// This is what the standard library does, see readTrailer in net/http/transfer.go.
+func announcedTrailers(hdr http.Header) http.Header {
+	var trailer http.Header
+	for _, v := range hdr["Trailer"] {
+		for _, key := range strings.Split(v, ",") {
+			key = http.CanonicalHeaderKey(textproto.TrimString(key))
+			if key == "" || !httpguts.ValidTrailerHeader(key) {
+				continue
+			}
+			if trailer == nil {
+				trailer = make(http.Header)
+			}
+			trailer[key] = nil
+		}
+	}
+	return trailer
+}
+
+// mergeTrailers adds the received trailers to the trailers announced in the header.
+func mergeTrailers(announced *http.Header, received http.Header) {
+	if *announced == nil {
+		*announced = make(http.Header, len(received))
+	}
+	for k, vv := range received {
+		(*announced)[k] = vv
+	}
+}
+
 func requestFromHeaders(headerFields []qpack.HeaderField) (*http.Request, error) {
 	hdr, err := parseHeaders(headerFields, true)
 	if err != nil {
@@ -113,7 +192,11 @@ func requestFromHeaders(headerFields []qpack.HeaderField) (*http.Request, error)
 
 	isConnect := hdr.Method == http.MethodConnect
 	// Extended CONNECT, see https://datatracker.ietf.org/doc/html/rfc8441#section-4
+	// and https://datatracker.ietf.org/doc/html/rfc9220#section-3
 	isExtendedConnected := isConnect && hdr.Protocol != ""
+	if !isConnect && hdr.Protocol != "" {
+		return nil, errors.New(":protocol must only be used with the CONNECT method")
+	}
 	if isExtendedConnected {
 		if hdr.Scheme == "" || hdr.Path == "" || hdr.Authority == "" {
 			return nil, errors.New("extended CONNECT: :scheme, :path and :authority must not be empty")
@@ -153,6 +236,16 @@ func requestFromHeaders(headerFields []qpack.HeaderField) (*http.Request, error)
 		requestURI = hdr.Path
 	}
 
+	// The values of the trailers are set once the body was read.
+	trailer := announcedTrailers(hdr.Headers)
+	delete(hdr.Headers, "Trailer")
+
+	contentLength := hdr.ContentLength
+	// The data sent on a CONNECT stream is not bounded by a Content-Length.
+	if _, ok := hdr.Headers["Content-Length"]; isConnect && !ok {
+		contentLength = -1
+	}
+
 	return &http.Request{
 		Method:        hdr.Method,
 		URL:           u,
@@ -161,9 +254,10 @@ func requestFromHeaders(headerFields []qpack.HeaderField) (*http.Request, error)
 		ProtoMinor:    0,
 		Header:        hdr.Headers,
 		Body:          nil,
-		ContentLength: hdr.ContentLength,
+		ContentLength: contentLength,
 		Host:          hdr.Authority,
 		RequestURI:    requestURI,
+		Trailer:       trailer,
 	}, nil
 }
 
@@ -187,6 +281,8 @@ func responseFromHeaders(headerFields []qpack.HeaderField) (*http.Response, erro
 		ProtoMajor:    3,
 		Header:        hdr.Headers,
 		ContentLength: hdr.ContentLength,
+		// The values of the trailers are set once the body was read.
+		Trailer: announcedTrailers(hdr.Headers),
 	}
 	status, err := strconv.Atoi(hdr.Status)
 	if err != nil {
@@ -196,3 +292,18 @@ func responseFromHeaders(headerFields []qpack.HeaderField) (*http.Response, erro
 	rsp.Status = hdr.Status + " " + http.StatusText(status)
 	return rsp, nil
 }
+
+// expectsContinue reports whether the request has an "Expect: 100-continue" header.
+func expectsContinue(h http.Header) bool {
+	return httpguts.HeaderValuesContainsToken(h["Expect"], "100-continue")
+}
+
+// fieldSectionSize calculates the size of a field section, see section 4.2.2 of RFC 9114.
+// It is the same as the size of the fields when inserted into the QPACK dynamic table.
+func fieldSectionSize(fields []qpack.HeaderField) uint64 {
+	var size uint64
+	for _, hf := range fields {
+		size += qpackEntrySize(hf)
+	}
+	return size
+}
@@ -185,6 +185,20 @@ var _ = Describe("Request", func() {
 		}))
 	})
 
+	It("populates the trailers announced in the Trailer header", func() {
+		headers := []qpack.HeaderField{
+			{Name: ":path", Value: "/foo"},
+			{Name: ":authority", Value: "quic.clemente.io"},
+			{Name: ":method", Value: "POST"},
+			{Name: "trailer", Value: "foo, bar-baz"},
+			{Name: "trailer", Value: "content-length"}, // not allowed in trailers
+		}
+		req, err := requestFromHeaders(headers)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(req.Trailer).To(Equal(http.Header{"Foo": nil, "Bar-Baz": nil}))
+		Expect(req.Header).ToNot(HaveKey("Trailer"))
+	})
+
 	It("errors with missing path", func() {
 		headers := []qpack.HeaderField{
 			{Name: ":authority", Value: "quic.clemente.io"},
@@ -222,6 +236,7 @@ var _ = Describe("Request", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(req.Method).To(Equal(http.MethodConnect))
 			Expect(req.RequestURI).To(Equal("quic.clemente.io"))
+			Expect(req.ContentLength).To(BeEquivalentTo(-1))
 		})
 
 		It("errors with missing authority in CONNECT method", func() {
@@ -270,6 +285,18 @@ var _ = Describe("Request", func() {
 			_, err := requestFromHeaders(headers)
 			Expect(err).To(MatchError("extended CONNECT: :scheme, :path and :authority must not be empty"))
 		})
+
+		It("errors when the :protocol is used with a method other than CONNECT", func() {
+			headers := []qpack.HeaderField{
+				{Name: ":protocol", Value: "websocket"},
+				{Name: ":scheme", Value: "https"},
+				{Name: ":method", Value: http.MethodGet},
+				{Name: ":authority", Value: "quic.clemente.io"},
+				{Name: ":path", Value: "/foo"},
+			}
+			_, err := requestFromHeaders(headers)
+			Expect(err).To(MatchError(":protocol must only be used with the CONNECT method"))
+		})
 	})
 
 	Context("extracting the hostname from a request", func() {
@@ -319,6 +346,17 @@ var _ = Describe("Response", func() {
 		Expect(rsp.Status).To(Equal("200 OK"))
 	})
 
+	It("populates the trailers announced in the Trailer header", func() {
+		headers := []qpack.HeaderField{
+			{Name: ":status", Value: "200"},
+			{Name: "trailer", Value: "grpc-status,grpc-message"},
+		}
+		rsp, err := responseFromHeaders(headers)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rsp.Trailer).To(Equal(http.Header{"Grpc-Status": nil, "Grpc-Message": nil}))
+		Expect(rsp.Header.Get("Trailer")).To(Equal("grpc-status,grpc-message"))
+	})
+
 	It("rejects pseudo header fields after regular header fields", func() {
 		headers := []qpack.HeaderField{
 			{Name: "content-length", Value: "42"},
@@ -355,3 +393,43 @@ var _ = Describe("Response", func() {
 		Expect(err).To(MatchError("invalid response pseudo header: :method"))
 	})
 })
+
+var _ = Describe("Trailers", func() {
+	It("parses trailers", func() {
+		trailer, err := parseTrailers([]qpack.HeaderField{
+			{Name: "grpc-status", Value: "0"},
+			{Name: "foo", Value: "bar"},
+			{Name: "foo", Value: "baz"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(trailer).To(Equal(http.Header{
+			"Grpc-Status": []string{"0"},
+			"Foo":         []string{"bar", "baz"},
+		}))
+	})
+
+	It("rejects pseudo header fields", func() {
+		_, err := parseTrailers([]qpack.HeaderField{{Name: ":status", Value: "200"}})
+		Expect(err).To(MatchError("received pseudo header in trailer: :status"))
+	})
+
+	It("rejects upper-case fields", func() {
+		_, err := parseTrailers([]qpack.HeaderField{{Name: "Foo", Value: "bar"}})
+		Expect(err).To(MatchError("trailer field is not lower-case: Foo"))
+	})
+
+	It("rejects invalid field values", func() {
+		_, err := parseTrailers([]qpack.HeaderField{{Name: "foo", Value: "bar\x00"}})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("invalid trailer field value for foo"))
+	})
+
+	It("merges the received trailers into the announced trailers", func() {
+		announced := http.Header{"Foo": nil, "Bar": nil}
+		mergeTrailers(&announced, http.Header{"Foo": []string{"foo"}})
+		Expect(announced).To(Equal(http.Header{"Foo": []string{"foo"}, "Bar": nil}))
+		var notAnnounced http.Header
+		mergeTrailers(&notAnnounced, http.Header{"Foo": []string{"foo"}})
+		Expect(notAnnounced).To(Equal(http.Header{"Foo": []string{"foo"}}))
+	})
+})
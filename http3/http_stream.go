@@ -3,6 +3,7 @@ package http3
 import (
 	"errors"
 	"fmt"
+	"io"
 
 	"github.com/quic-go/quic-go"
 	"github.com/quic-go/quic-go/internal/utils"
@@ -21,14 +22,20 @@ type stream struct {
 
 	onFrameError          func()
 	bytesRemainingInFrame uint64
+
+	// parseTrailer is called with the payload of a HEADERS frame received after the header,
+	// i.e. with the trailer field section, see section 4.1 of RFC 9114.
+	parseTrailer  func(r io.Reader, length uint64) error
+	parsedTrailer bool
 }
 
 var _ Stream = &stream{}
 
-func newStream(str quic.Stream, onFrameError func()) *stream {
+func newStream(str quic.Stream, onFrameError func(), parseTrailer func(io.Reader, uint64) error) *stream {
 	return &stream{
 		Stream:       str,
 		onFrameError: onFrameError,
+		parseTrailer: parseTrailer,
 		buf:          make([]byte, 0, 16),
 	}
 }
@@ -43,9 +50,25 @@ func (s *stream) Read(b []byte) (int, error) {
 			}
 			switch f := frame.(type) {
 			case *headersFrame:
-				// skip HEADERS frames
-				continue
+				if s.parsedTrailer {
+					s.onFrameError()
+					return 0, errors.New("peer sent an additional HEADERS frame after the trailers")
+				}
+				s.parsedTrailer = true
+				if s.parseTrailer == nil {
+					if _, err := io.CopyN(io.Discard, s.Stream, int64(f.Length)); err != nil {
+						return 0, err
+					}
+					continue
+				}
+				if err := s.parseTrailer(s.Stream, f.Length); err != nil {
+					return 0, err
+				}
 			case *dataFrame:
+				if s.parsedTrailer {
+					s.onFrameError()
+					return 0, errors.New("peer sent a DATA frame after the trailers")
+				}
 				s.bytesRemainingInFrame = f.Length
 				break parseLoop
 			default:
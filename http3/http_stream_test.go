@@ -2,6 +2,7 @@ package http3
 
 import (
 	"bytes"
+	"errors"
 	"io"
 
 	"github.com/quic-go/quic-go"
@@ -34,7 +35,7 @@ var _ = Describe("Stream", func() {
 			qstr = mockquic.NewMockStream(mockCtrl)
 			qstr.EXPECT().Write(gomock.Any()).DoAndReturn(buf.Write).AnyTimes()
 			qstr.EXPECT().Read(gomock.Any()).DoAndReturn(buf.Read).AnyTimes()
-			str = newStream(qstr, errorCb)
+			str = newStream(qstr, errorCb, nil)
 		})
 
 		It("reads DATA frames in a single run", func() {
@@ -95,17 +96,64 @@ var _ = Describe("Stream", func() {
 			Expect(b[:n]).To(Equal([]byte("bar")))
 		})
 
-		It("skips HEADERS frames", func() {
+		It("parses the trailers", func() {
+			var trailer []byte
+			str = newStream(qstr, errorCb, func(r io.Reader, l uint64) error {
+				trailer = make([]byte, l)
+				_, err := io.ReadFull(r, trailer)
+				return err
+			})
+			b := getDataFrame([]byte("foobar"))
+			b = (&headersFrame{Length: 7}).Append(b)
+			b = append(b, []byte("trailer")...)
+			buf.Write(b)
+			data, err := io.ReadAll(str)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(Equal([]byte("foobar")))
+			Expect(trailer).To(Equal([]byte("trailer")))
+		})
+
+		It("skips the trailers if no callback is set", func() {
+			b := getDataFrame([]byte("foobar"))
+			b = (&headersFrame{Length: 7}).Append(b)
+			b = append(b, []byte("trailer")...)
+			buf.Write(b)
+			data, err := io.ReadAll(str)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(Equal([]byte("foobar")))
+		})
+
+		It("returns the error that occurred when parsing the trailers", func() {
+			str = newStream(qstr, errorCb, func(io.Reader, uint64) error { return errors.New("invalid trailer") })
+			b := getDataFrame([]byte("foobar"))
+			b = (&headersFrame{Length: 7}).Append(b)
+			b = append(b, []byte("trailer")...)
+			buf.Write(b)
+			_, err := io.ReadAll(str)
+			Expect(err).To(MatchError("invalid trailer"))
+		})
+
+		It("errors on DATA frames after the trailers", func() {
 			b := getDataFrame([]byte("foo"))
-			b = (&headersFrame{Length: 10}).Append(b)
-			b = append(b, make([]byte, 10)...)
+			b = (&headersFrame{Length: 7}).Append(b)
+			b = append(b, []byte("trailer")...)
 			b = append(b, getDataFrame([]byte("bar"))...)
 			buf.Write(b)
-			r := make([]byte, 6)
-			n, err := io.ReadFull(str, r)
-			Expect(err).ToNot(HaveOccurred())
-			Expect(n).To(Equal(6))
-			Expect(r).To(Equal([]byte("foobar")))
+			_, err := io.ReadAll(str)
+			Expect(err).To(MatchError("peer sent a DATA frame after the trailers"))
+			Expect(errorCbCalled).To(BeTrue())
+		})
+
+		It("errors on additional HEADERS frames after the trailers", func() {
+			b := getDataFrame([]byte("foo"))
+			b = (&headersFrame{Length: 7}).Append(b)
+			b = append(b, []byte("trailer")...)
+			b = (&headersFrame{Length: 7}).Append(b)
+			b = append(b, []byte("trailer")...)
+			buf.Write(b)
+			_, err := io.ReadAll(str)
+			Expect(err).To(MatchError("peer sent an additional HEADERS frame after the trailers"))
+			Expect(errorCbCalled).To(BeTrue())
 		})
 
 		It("errors when it can't parse the frame", func() {
@@ -128,7 +176,7 @@ var _ = Describe("Stream", func() {
 			buf := &bytes.Buffer{}
 			qstr := mockquic.NewMockStream(mockCtrl)
 			qstr.EXPECT().Write(gomock.Any()).DoAndReturn(buf.Write).AnyTimes()
-			str := newStream(qstr, nil)
+			str := newStream(qstr, nil, nil)
 			str.Write([]byte("foo"))
 			str.Write([]byte("foobar"))
 
@@ -163,7 +211,7 @@ var _ = Describe("length-limited streams", func() {
 		qstr = mockquic.NewMockStream(mockCtrl)
 		qstr.EXPECT().Write(gomock.Any()).DoAndReturn(buf.Write).AnyTimes()
 		qstr.EXPECT().Read(gomock.Any()).DoAndReturn(buf.Read).AnyTimes()
-		str = newStream(qstr, func() { Fail("didn't expect error callback to be called") })
+		str = newStream(qstr, func() { Fail("didn't expect error callback to be called") }, nil)
 	})
 
 	It("reads all frames", func() {
@@ -0,0 +1,246 @@
+package http3
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/quic-go/quic-go"
+)
+
+// Priority is the priority of a HTTP request or response, as defined in RFC 9218.
+// It is signaled using the Priority header field, and by PRIORITY_UPDATE frames.
+type Priority struct {
+	// Urgency is the urgency level, from 0 (highest) to 7 (lowest).
+	Urgency uint8
+	// Incremental says if the response can be processed incrementally,
+	// i.e. if it can be interleaved with the data of other responses of the same urgency.
+	Incremental bool
+}
+
+// DefaultPriority is the priority of a request that doesn't signal a priority, see section 4 of RFC 9218.
+var DefaultPriority = Priority{Urgency: 3}
+
+const maxUrgency = 7
+
+// ParsePriority parses the value of a Priority header field.
+// Parameters that are unknown or have an invalid value are ignored, see section 4 of RFC 9218.
+// If the value is not a valid Structured Fields Dictionary, the default priority is returned.
+func ParsePriority(v string) Priority {
+	p := DefaultPriority
+	for _, member := range splitDictionary(v) {
+		member = strings.Trim(member, " \t")
+		// we don't use any parameters
+		if i := strings.IndexByte(member, ';'); i >= 0 {
+			member = member[:i]
+		}
+		key, val, hasVal := strings.Cut(member, "=")
+		if !isValidDictionaryKey(key) {
+			return DefaultPriority
+		}
+		switch key {
+		case "u":
+			if !hasVal {
+				continue
+			}
+			u, err := strconv.ParseUint(val, 10, 8)
+			if err != nil || u > maxUrgency {
+				continue
+			}
+			p.Urgency = uint8(u)
+		case "i":
+			switch {
+			case !hasVal || val == "?1":
+				p.Incremental = true
+			case val == "?0":
+				p.Incremental = false
+			}
+		}
+	}
+	return p
+}
+
+// splitDictionary splits the members of a Structured Fields Dictionary.
+// Commas inside of strings don't separate members.
+func splitDictionary(v string) []string {
+	var members []string
+	var inString, escaped bool
+	var start int
+	for i := 0; i < len(v); i++ {
+		switch {
+		case escaped:
+			escaped = false
+		case inString && v[i] == '\\':
+			escaped = true
+		case v[i] == '"':
+			inString = !inString
+		case !inString && v[i] == ',':
+			members = append(members, v[start:i])
+			start = i + 1
+		}
+	}
+	if strings.Trim(v[start:], " \t") != "" {
+		members = append(members, v[start:])
+	}
+	return members
+}
+
+// isValidDictionaryKey checks if key is a valid key, see section 3.1.2 of RFC 8941.
+func isValidDictionaryKey(key string) bool {
+	if len(key) == 0 || (key[0] != '*' && (key[0] < 'a' || key[0] > 'z')) {
+		return false
+	}
+	for i := 1; i < len(key); i++ {
+		c := key[i]
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '_' && c != '-' && c != '.' && c != '*' {
+			return false
+		}
+	}
+	return true
+}
+
+// String serializes the priority, such that it can be used as the value of a Priority header field.
+func (p Priority) String() string {
+	s := "u=" + strconv.Itoa(int(p.Urgency))
+	if p.Incremental {
+		s += ", i"
+	}
+	return s
+}
+
+func (p Priority) streamPriority() quic.StreamPriority {
+	return quic.StreamPriority{Urgency: p.Urgency, Incremental: p.Incremental}
+}
+
+// A PrioritySetter allows handlers to set the priority of the response.
+// It is implemented by the http.ResponseWriter passed to the handler.
+// Once the handler set the priority, priority signals sent by the client are ignored.
+type PrioritySetter interface {
+	SetPriority(Priority)
+}
+
+// A PriorityUpdater allows changing the priority of a request after it was sent,
+// by sending a PRIORITY_UPDATE frame to the server.
+// It is implemented by the http.Response.Body.
+type PriorityUpdater interface {
+	UpdatePriority(Priority) error
+}
+
+// Limits the number of PRIORITY_UPDATE frames that are buffered
+// for request streams that the server didn't accept yet.
+const maxBufferedPriorityUpdates = 32
+
+var errInvalidPriorityUpdateStreamID = errors.New("PRIORITY_UPDATE frame for an invalid request stream ID")
+
+// The priorityTracker applies the priorities signaled by the client to the request streams of a connection.
+// It is only used by the server.
+type priorityTracker struct {
+	mutex sync.Mutex
+
+	streams map[quic.StreamID]*prioritizedStream
+	// Priorities received in PRIORITY_UPDATE frames for request streams that weren't accepted yet.
+	buffered map[quic.StreamID]Priority
+	// The highest request stream ID that was accepted, -1 if no stream was accepted yet.
+	highestStreamID quic.StreamID
+}
+
+type prioritizedStream struct {
+	str quic.SendStream
+	// set when a PRIORITY_UPDATE frame was received for this stream
+	updated bool
+	// set when the handler set the priority
+	fixed bool
+}
+
+func newPriorityTracker() *priorityTracker {
+	return &priorityTracker{
+		streams:         make(map[quic.StreamID]*prioritizedStream),
+		buffered:        make(map[quic.StreamID]Priority),
+		highestStreamID: -1,
+	}
+}
+
+// AddStream is called when a request stream is accepted.
+// Until the client signals a priority, the response is sent using the DefaultPriority.
+func (t *priorityTracker) AddStream(str quic.SendStream) {
+	id := str.StreamID()
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if id > t.highestStreamID {
+		t.highestStreamID = id
+	}
+	s := &prioritizedStream{str: str}
+	t.streams[id] = s
+	p := DefaultPriority
+	if bp, ok := t.buffered[id]; ok {
+		delete(t.buffered, id)
+		s.updated = true
+		p = bp
+	}
+	str.SetPriority(p.streamPriority())
+}
+
+// HandleRequestHeader applies the priority signaled in the Priority header field.
+// A priority signaled in a PRIORITY_UPDATE frame takes precedence over the header field.
+// If the client didn't signal any priority, the stream keeps the DefaultPriority.
+func (t *priorityTracker) HandleRequestHeader(str quic.SendStream, hdr http.Header) {
+	v, ok := hdr["Priority"]
+	if !ok {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if s, ok := t.streams[str.StreamID()]; ok && !s.updated && !s.fixed {
+		s.str.SetPriority(ParsePriority(strings.Join(v, ",")).streamPriority())
+	}
+}
+
+// RemoveStream is called when the request was handled.
+func (t *priorityTracker) RemoveStream(id quic.StreamID) {
+	t.mutex.Lock()
+	delete(t.streams, id)
+	t.mutex.Unlock()
+}
+
+// SetPriority sets the priority chosen by the handler.
+func (t *priorityTracker) SetPriority(str quic.SendStream, p Priority) {
+	t.mutex.Lock()
+	if s, ok := t.streams[str.StreamID()]; ok {
+		s.fixed = true
+	}
+	t.mutex.Unlock()
+	str.SetPriority(p.streamPriority())
+}
+
+// HandlePriorityUpdate handles a PRIORITY_UPDATE frame for a request stream.
+// PRIORITY_UPDATE frames for request streams that were already closed are ignored.
+func (t *priorityTracker) HandlePriorityUpdate(f *priorityUpdateFrame) error {
+	// only client-initiated bidirectional streams are request streams
+	if f.PrioritizedElementID%4 != 0 {
+		return errInvalidPriorityUpdateStreamID
+	}
+	id := quic.StreamID(f.PrioritizedElementID)
+	p := ParsePriority(f.PriorityFieldValue)
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if s, ok := t.streams[id]; ok {
+		s.updated = true
+		if !s.fixed {
+			s.str.SetPriority(p.streamPriority())
+		}
+		return nil
+	}
+	if id <= t.highestStreamID {
+		return nil
+	}
+	if _, ok := t.buffered[id]; ok || len(t.buffered) < maxBufferedPriorityUpdates {
+		t.buffered[id] = p
+	}
+	return nil
+}
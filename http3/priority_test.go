@@ -0,0 +1,148 @@
+package http3
+
+import (
+	"net/http"
+
+	"github.com/quic-go/quic-go"
+	mockquic "github.com/quic-go/quic-go/internal/mocks/quic"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Priorities", func() {
+	Context("parsing", func() {
+		It("uses the default priority if no parameters are set", func() {
+			Expect(ParsePriority("")).To(Equal(DefaultPriority))
+			Expect(DefaultPriority).To(Equal(Priority{Urgency: 3, Incremental: false}))
+		})
+
+		It("parses the urgency", func() {
+			Expect(ParsePriority("u=0")).To(Equal(Priority{Urgency: 0}))
+			Expect(ParsePriority("u=7")).To(Equal(Priority{Urgency: 7}))
+		})
+
+		It("parses the incremental parameter", func() {
+			Expect(ParsePriority("i")).To(Equal(Priority{Urgency: 3, Incremental: true}))
+			Expect(ParsePriority("i=?1")).To(Equal(Priority{Urgency: 3, Incremental: true}))
+			Expect(ParsePriority("i=?0")).To(Equal(Priority{Urgency: 3, Incremental: false}))
+		})
+
+		It("parses both parameters", func() {
+			Expect(ParsePriority("u=1, i")).To(Equal(Priority{Urgency: 1, Incremental: true}))
+			Expect(ParsePriority("i,u=5")).To(Equal(Priority{Urgency: 5, Incremental: true}))
+			Expect(ParsePriority(" u=2 ,\ti ")).To(Equal(Priority{Urgency: 2, Incremental: true}))
+		})
+
+		It("uses the last value if a parameter is repeated", func() {
+			Expect(ParsePriority("u=1, u=6")).To(Equal(Priority{Urgency: 6}))
+		})
+
+		It("ignores unknown parameters", func() {
+			Expect(ParsePriority(`foo="bar, baz", u=4, *x=1;a=b`)).To(Equal(Priority{Urgency: 4}))
+		})
+
+		It("ignores parameters of a member", func() {
+			Expect(ParsePriority("u=1;foo=bar, i;x")).To(Equal(Priority{Urgency: 1, Incremental: true}))
+		})
+
+		It("ignores invalid values", func() {
+			Expect(ParsePriority("u=8, i")).To(Equal(Priority{Urgency: 3, Incremental: true}))
+			Expect(ParsePriority("u=-1")).To(Equal(DefaultPriority))
+			Expect(ParsePriority("u=foo, i=1")).To(Equal(DefaultPriority))
+			Expect(ParsePriority("u")).To(Equal(DefaultPriority))
+		})
+
+		It("uses the default priority if the field value can't be parsed", func() {
+			Expect(ParsePriority("u=1, I")).To(Equal(DefaultPriority))
+			Expect(ParsePriority("u=1, ,i")).To(Equal(DefaultPriority))
+		})
+
+		It("serializes priorities", func() {
+			for _, p := range []Priority{{Urgency: 0}, {Urgency: 7, Incremental: true}, DefaultPriority} {
+				Expect(ParsePriority(p.String())).To(Equal(p))
+			}
+			Expect(Priority{Urgency: 1, Incremental: true}.String()).To(Equal("u=1, i"))
+			Expect(Priority{Urgency: 5}.String()).To(Equal("u=5"))
+		})
+	})
+
+	Context("tracking request streams", func() {
+		var tracker *priorityTracker
+
+		newStream := func(id quic.StreamID) *mockquic.MockStream {
+			str := mockquic.NewMockStream(mockCtrl)
+			str.EXPECT().StreamID().Return(id).AnyTimes()
+			return str
+		}
+
+		BeforeEach(func() {
+			tracker = newPriorityTracker()
+		})
+
+		It("applies the priority from the Priority header field", func() {
+			str := newStream(4)
+			str.EXPECT().SetPriority(DefaultPriority.streamPriority())
+			tracker.AddStream(str)
+			str.EXPECT().SetPriority(quic.StreamPriority{Urgency: 1, Incremental: true})
+			tracker.HandleRequestHeader(str, http.Header{"Priority": {"u=1, i"}})
+		})
+
+		It("uses the default priority if the client didn't signal a priority", func() {
+			str := newStream(4)
+			str.EXPECT().SetPriority(DefaultPriority.streamPriority())
+			tracker.AddStream(str)
+			tracker.HandleRequestHeader(str, http.Header{})
+		})
+
+		It("applies PRIORITY_UPDATE frames for active streams", func() {
+			str := newStream(4)
+			str.EXPECT().SetPriority(DefaultPriority.streamPriority())
+			tracker.AddStream(str)
+			str.EXPECT().SetPriority(quic.StreamPriority{Urgency: 6})
+			Expect(tracker.HandlePriorityUpdate(&priorityUpdateFrame{PrioritizedElementID: 4, PriorityFieldValue: "u=6"})).To(Succeed())
+		})
+
+		It("buffers PRIORITY_UPDATE frames for streams that weren't accepted yet", func() {
+			Expect(tracker.HandlePriorityUpdate(&priorityUpdateFrame{PrioritizedElementID: 8, PriorityFieldValue: "u=0"})).To(Succeed())
+			str := newStream(8)
+			str.EXPECT().SetPriority(quic.StreamPriority{Urgency: 0})
+			tracker.AddStream(str)
+			// the PRIORITY_UPDATE frame takes precedence over the header field
+			tracker.HandleRequestHeader(str, http.Header{"Priority": {"u=5"}})
+		})
+
+		It("limits the number of buffered PRIORITY_UPDATE frames", func() {
+			for i := 0; i < maxBufferedPriorityUpdates+10; i++ {
+				Expect(tracker.HandlePriorityUpdate(&priorityUpdateFrame{PrioritizedElementID: uint64(4 * (i + 1)), PriorityFieldValue: "u=1"})).To(Succeed())
+			}
+			Expect(tracker.buffered).To(HaveLen(maxBufferedPriorityUpdates))
+		})
+
+		It("ignores PRIORITY_UPDATE frames for streams that were already handled", func() {
+			str := newStream(4)
+			str.EXPECT().SetPriority(DefaultPriority.streamPriority())
+			tracker.AddStream(str)
+			tracker.RemoveStream(4)
+			Expect(tracker.HandlePriorityUpdate(&priorityUpdateFrame{PrioritizedElementID: 4, PriorityFieldValue: "u=1"})).To(Succeed())
+			Expect(tracker.HandlePriorityUpdate(&priorityUpdateFrame{PrioritizedElementID: 0, PriorityFieldValue: "u=1"})).To(Succeed())
+			Expect(tracker.buffered).To(BeEmpty())
+		})
+
+		It("rejects PRIORITY_UPDATE frames for stream IDs that are not request streams", func() {
+			for _, id := range []uint64{1, 2, 3, 5} {
+				Expect(tracker.HandlePriorityUpdate(&priorityUpdateFrame{PrioritizedElementID: id})).To(MatchError(errInvalidPriorityUpdateStreamID))
+			}
+		})
+
+		It("ignores priority signals after the handler set the priority", func() {
+			str := newStream(4)
+			str.EXPECT().SetPriority(DefaultPriority.streamPriority())
+			tracker.AddStream(str)
+			str.EXPECT().SetPriority(quic.StreamPriority{Urgency: 2, Incremental: true})
+			tracker.SetPriority(str, Priority{Urgency: 2, Incremental: true})
+			Expect(tracker.HandlePriorityUpdate(&priorityUpdateFrame{PrioritizedElementID: 4, PriorityFieldValue: "u=7"})).To(Succeed())
+			tracker.HandleRequestHeader(str, http.Header{"Priority": {"u=7"}})
+		})
+	})
+})
@@ -0,0 +1,223 @@
+package http3
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/net/http2/hpack"
+
+	"github.com/quic-go/qpack"
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/quicvarint"
+)
+
+const (
+	// the default capacity of the QPACK dynamic table
+	defaultQPACKMaxTableCapacity = 4096
+	// the number of streams that may be blocked waiting for QPACK encoder stream instructions,
+	// see section 2.1.2 of RFC 9204
+	qpackMaxBlockedStreams = 16
+)
+
+// qpackTableCapacity returns the maximum capacity of the QPACK dynamic table for the configured value:
+// zero means the default value, a negative value disables the dynamic table.
+func qpackTableCapacity(configured int) uint64 {
+	switch {
+	case configured == 0:
+		return defaultQPACKMaxTableCapacity
+	case configured < 0:
+		return 0
+	default:
+		return uint64(configured)
+	}
+}
+
+// qpackEntrySize is the size of a dynamic table entry, see section 3.2.1 of RFC 9204.
+func qpackEntrySize(hf qpack.HeaderField) uint64 {
+	return uint64(len(hf.Name)+len(hf.Value)) + 32
+}
+
+// A qpackError is an error that leads to the closure of the connection, see section 6 of RFC 9204.
+type qpackError struct {
+	code ErrCode
+	err  error
+}
+
+func (e *qpackError) Error() string { return fmt.Sprintf("%s: %s", e.code, e.err) }
+func (e *qpackError) Unwrap() error { return e.err }
+
+// handleQPACKStreamError closes the connection after processing a QPACK encoder or decoder stream failed.
+// These streams are critical streams, see section 4.2 of RFC 9204.
+func handleQPACKStreamError(conn quic.Connection, err error) {
+	var qerr *qpackError
+	if errors.As(err, &qerr) {
+		conn.CloseWithError(quic.ApplicationErrorCode(qerr.code), qerr.err.Error())
+		return
+	}
+	// If the connection was already closed, this is a no-op.
+	conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeClosedCriticalStream), "")
+}
+
+// The QPACK dynamic table, see section 3.2 of RFC 9204.
+type qpackDynamicTable struct {
+	entries  []qpack.HeaderField // the oldest entry first
+	dropped  uint64              // the number of evicted entries, i.e. the absolute index of entries[0]
+	size     uint64
+	capacity uint64
+}
+
+// insertCount is the total number of entries inserted into the table.
+func (t *qpackDynamicTable) insertCount() uint64 {
+	return t.dropped + uint64(len(t.entries))
+}
+
+// get returns the entry with the given absolute index.
+func (t *qpackDynamicTable) get(abs uint64) (qpack.HeaderField, bool) {
+	if abs < t.dropped || abs >= t.insertCount() {
+		return qpack.HeaderField{}, false
+	}
+	return t.entries[abs-t.dropped], true
+}
+
+// evict evicts the oldest entry.
+func (t *qpackDynamicTable) evict() {
+	t.size -= qpackEntrySize(t.entries[0])
+	t.entries[0] = qpack.HeaderField{}
+	t.entries = t.entries[1:]
+	t.dropped++
+}
+
+// evictUntil evicts entries until the table has room for size bytes.
+// It is only used by the decoder, the encoder needs to check that entries are evictable before.
+func (t *qpackDynamicTable) evictUntil(size uint64) error {
+	if size > t.capacity {
+		return fmt.Errorf("entry too large for the dynamic table: %d bytes (capacity: %d bytes)", size, t.capacity)
+	}
+	for t.size+size > t.capacity {
+		t.evict()
+	}
+	return nil
+}
+
+func (t *qpackDynamicTable) insert(hf qpack.HeaderField) {
+	t.entries = append(t.entries, hf)
+	t.size += qpackEntrySize(hf)
+}
+
+var errQPACKIntegerOverflow = errors.New("integer overflow")
+
+// appendQPACKInt appends an integer using an n-bit prefix, see section 4.1.1 of RFC 9204.
+// The bits of the first byte that are not used for the prefix are taken from flags.
+func appendQPACKInt(b []byte, n uint8, flags byte, v uint64) []byte {
+	max := uint64(1)<<n - 1
+	if v < max {
+		return append(b, flags|byte(v))
+	}
+	b = append(b, flags|byte(max))
+	v -= max
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+// readQPACKInt reads an integer with an n-bit prefix.
+// The first byte was already read, since it also determines the instruction or the representation.
+func readQPACKInt(r io.ByteReader, first byte, n uint8) (uint64, error) {
+	max := uint64(1)<<n - 1
+	v := uint64(first) & max
+	if v < max {
+		return v, nil
+	}
+	for shift := 0; ; shift += 7 {
+		if shift > 56 {
+			return 0, errQPACKIntegerOverflow
+		}
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		v += uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return v, nil
+		}
+	}
+}
+
+// appendQPACKString appends a string literal with an n-bit length prefix, see section 4.1.2 of RFC 9204.
+// Huffman encoding is used if it results in a shorter string.
+func appendQPACKString(b []byte, n uint8, flags byte, s string) []byte {
+	if l := hpack.HuffmanEncodeLength(s); l < uint64(len(s)) {
+		b = appendQPACKInt(b, n, flags|1<<n, l)
+		return hpack.AppendHuffmanString(b, s)
+	}
+	b = appendQPACKInt(b, n, flags, uint64(len(s)))
+	return append(b, s...)
+}
+
+// readQPACKString reads a string literal with an n-bit length prefix.
+// The Huffman flag is the bit right above the prefix.
+func readQPACKString(r quicvarint.Reader, first byte, n uint8, maxLen uint64) (string, error) {
+	l, err := readQPACKInt(r, first, n)
+	if err != nil {
+		return "", err
+	}
+	if l > maxLen {
+		return "", fmt.Errorf("string literal too long: %d bytes", l)
+	}
+	b := make([]byte, l)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", err
+	}
+	if first&(1<<n) == 0 {
+		return string(b), nil
+	}
+	return hpack.HuffmanDecodeToString(b)
+}
+
+// instructionReader reads instructions from a QPACK encoder or decoder stream.
+// It remembers if reading from the stream failed, in order to distinguish I/O errors
+// from malformed instructions.
+type instructionReader struct {
+	r     *bufio.Reader
+	ioErr error
+}
+
+var _ quicvarint.Reader = &instructionReader{}
+
+func newInstructionReader(r io.Reader) *instructionReader {
+	return &instructionReader{r: bufio.NewReader(r)}
+}
+
+func (r *instructionReader) ReadByte() (byte, error) {
+	b, err := r.r.ReadByte()
+	if err != nil {
+		r.ioErr = err
+	}
+	return b, err
+}
+
+func (r *instructionReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	if err != nil {
+		r.ioErr = err
+	}
+	return n, err
+}
+
+// Buffered says if there's more data that can be read without blocking.
+func (r *instructionReader) Buffered() bool {
+	return r.r.Buffered() > 0
+}
+
+// streamError returns the error that is used to close the connection if processing an instruction failed.
+// If the stream was closed, this is the I/O error, otherwise it's a qpackError with the given error code.
+func (r *instructionReader) streamError(code ErrCode, err error) error {
+	if r.ioErr != nil {
+		return r.ioErr
+	}
+	return &qpackError{code: code, err: err}
+}
@@ -0,0 +1,430 @@
+package http3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/quic-go/qpack"
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/quicvarint"
+)
+
+// A qpackDecoder decodes the field sections received on a connection, see RFC 9204.
+// The peer's encoder inserts entries into the dynamic table using instructions sent on its encoder stream.
+// Field sections that reference entries that weren't received yet block until the entries are inserted.
+// Field sections and inserts are acknowledged on our decoder stream, which is opened when it is first needed.
+type qpackDecoder struct {
+	// the values we sent in SETTINGS_QPACK_MAX_TABLE_CAPACITY and SETTINGS_QPACK_BLOCKED_STREAMS
+	maxTableCapacity  uint64
+	maxBlockedStreams uint64
+
+	openStream func() (quic.SendStream, error)
+
+	mutex sync.Mutex
+	table qpackDynamicTable
+	// closed (and replaced) when entries are inserted into the dynamic table
+	inserted   chan struct{}
+	numBlocked uint64
+	// the Known Received Count of the peer's encoder, see section 2.1.4 of RFC 9204
+	knownReceivedCount uint64
+
+	strMutex sync.Mutex
+	str      quic.SendStream // the decoder stream
+}
+
+func newQPACKDecoder(maxTableCapacity, maxBlockedStreams uint64, openStream func() (quic.SendStream, error)) *qpackDecoder {
+	return &qpackDecoder{
+		maxTableCapacity:  maxTableCapacity,
+		maxBlockedStreams: maxBlockedStreams,
+		openStream:        openStream,
+		inserted:          make(chan struct{}),
+	}
+}
+
+// Decode decodes a field section received on a stream.
+// If the field section references dynamic table entries that weren't received yet,
+// it blocks until these entries are inserted, or until the context is canceled.
+// Every field section starts with a prefix, so an empty field section is a decompression failure.
+func (d *qpackDecoder) Decode(ctx context.Context, streamID quic.StreamID, b []byte) ([]qpack.HeaderField, error) {
+	r := bytes.NewReader(b)
+	requiredInsertCount, base, err := d.decodePrefix(r)
+	if err != nil {
+		return nil, &qpackError{code: ErrCodeQPACKDecompressionFailed, err: err}
+	}
+	if requiredInsertCount > 0 {
+		if err := d.waitForInserts(ctx, streamID, requiredInsertCount); err != nil {
+			return nil, err
+		}
+	}
+	fields, err := d.decodeFieldLines(r, requiredInsertCount, base)
+	if err != nil {
+		return nil, &qpackError{code: ErrCodeQPACKDecompressionFailed, err: err}
+	}
+	if requiredInsertCount > 0 {
+		if err := d.acknowledgeSection(streamID, requiredInsertCount); err != nil {
+			return nil, err
+		}
+	}
+	return fields, nil
+}
+
+// decodePrefix decodes the Required Insert Count and the Base, see section 4.5.1 of RFC 9204.
+func (d *qpackDecoder) decodePrefix(r *bytes.Reader) (requiredInsertCount, base uint64, _ error) {
+	first, err := r.ReadByte()
+	if err != nil {
+		return 0, 0, err
+	}
+	encodedInsertCount, err := readQPACKInt(r, first, 8)
+	if err != nil {
+		return 0, 0, err
+	}
+	if encodedInsertCount != 0 {
+		maxEntries := d.maxTableCapacity / 32
+		fullRange := 2 * maxEntries
+		if encodedInsertCount > fullRange {
+			return 0, 0, fmt.Errorf("invalid Required Insert Count: %d", encodedInsertCount)
+		}
+		d.mutex.Lock()
+		maxValue := d.table.insertCount() + maxEntries
+		d.mutex.Unlock()
+		maxWrapped := (maxValue / fullRange) * fullRange
+		requiredInsertCount = maxWrapped + encodedInsertCount - 1
+		if requiredInsertCount > maxValue {
+			if requiredInsertCount <= fullRange {
+				return 0, 0, fmt.Errorf("invalid Required Insert Count: %d", encodedInsertCount)
+			}
+			requiredInsertCount -= fullRange
+		}
+		if requiredInsertCount == 0 {
+			return 0, 0, fmt.Errorf("invalid Required Insert Count: %d", encodedInsertCount)
+		}
+	}
+	first, err = r.ReadByte()
+	if err != nil {
+		return 0, 0, err
+	}
+	deltaBase, err := readQPACKInt(r, first, 7)
+	if err != nil {
+		return 0, 0, err
+	}
+	if first&0x80 == 0 {
+		return requiredInsertCount, requiredInsertCount + deltaBase, nil
+	}
+	if deltaBase >= requiredInsertCount {
+		return 0, 0, fmt.Errorf("invalid Base: Required Insert Count %d, Delta Base %d", requiredInsertCount, deltaBase)
+	}
+	return requiredInsertCount, requiredInsertCount - deltaBase - 1, nil
+}
+
+// waitForInserts blocks until the dynamic table contains the entries required to decode a field section.
+func (d *qpackDecoder) waitForInserts(ctx context.Context, streamID quic.StreamID, requiredInsertCount uint64) error {
+	d.mutex.Lock()
+	if d.table.insertCount() >= requiredInsertCount {
+		d.mutex.Unlock()
+		return nil
+	}
+	if d.numBlocked >= d.maxBlockedStreams {
+		d.mutex.Unlock()
+		return &qpackError{code: ErrCodeQPACKDecompressionFailed, err: errors.New("too many blocked streams")}
+	}
+	d.numBlocked++
+	defer func() {
+		d.mutex.Lock()
+		d.numBlocked--
+		d.mutex.Unlock()
+	}()
+	for d.table.insertCount() < requiredInsertCount {
+		inserted := d.inserted
+		d.mutex.Unlock()
+		select {
+		case <-inserted:
+		case <-ctx.Done():
+			// The field section will never be decoded, so the encoder can release its references.
+			if err := d.cancelStream(streamID); err != nil {
+				return err
+			}
+			return ctx.Err()
+		}
+		d.mutex.Lock()
+	}
+	d.mutex.Unlock()
+	return nil
+}
+
+func (d *qpackDecoder) decodeFieldLines(r *bytes.Reader, requiredInsertCount, base uint64) ([]qpack.HeaderField, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	// dynamic returns the entry with the given absolute index
+	dynamic := func(abs uint64) (qpack.HeaderField, error) {
+		if abs >= requiredInsertCount {
+			return qpack.HeaderField{}, fmt.Errorf("reference to dynamic table entry %d not covered by the Required Insert Count (%d)", abs, requiredInsertCount)
+		}
+		hf, ok := d.table.get(abs)
+		if !ok {
+			return qpack.HeaderField{}, fmt.Errorf("reference to evicted dynamic table entry %d", abs)
+		}
+		return hf, nil
+	}
+	// relative returns the entry with the given relative index, see section 3.2.5 of RFC 9204
+	relative := func(index uint64) (qpack.HeaderField, error) {
+		if index >= base {
+			return qpack.HeaderField{}, fmt.Errorf("invalid relative index %d (Base: %d)", index, base)
+		}
+		return dynamic(base - 1 - index)
+	}
+	static := func(index uint64) (qpack.HeaderField, error) {
+		if index >= uint64(len(qpackStaticTable)) {
+			return qpack.HeaderField{}, fmt.Errorf("invalid static table index: %d", index)
+		}
+		return qpackStaticTable[index], nil
+	}
+	maxLen := uint64(r.Len())
+
+	var fields []qpack.HeaderField
+	for r.Len() > 0 {
+		first, _ := r.ReadByte()
+		var hf qpack.HeaderField
+		var err error
+		switch {
+		case first&0x80 > 0: // 1Txxxxxx: Indexed Field Line
+			var index uint64
+			index, err = readQPACKInt(r, first, 6)
+			if err != nil {
+				return nil, err
+			}
+			if first&0x40 > 0 {
+				hf, err = static(index)
+			} else {
+				hf, err = relative(index)
+			}
+		case first&0xc0 == 0x40: // 01NTxxxx: Literal Field Line with Name Reference
+			var index uint64
+			index, err = readQPACKInt(r, first, 4)
+			if err != nil {
+				return nil, err
+			}
+			if first&0x10 > 0 {
+				hf, err = static(index)
+			} else {
+				hf, err = relative(index)
+			}
+			if err != nil {
+				return nil, err
+			}
+			hf.Value, err = d.readValue(r, maxLen)
+		case first&0xe0 == 0x20: // 001NHxxx: Literal Field Line with Literal Name
+			hf.Name, err = readQPACKString(r, first, 3, maxLen)
+			if err != nil {
+				return nil, err
+			}
+			hf.Value, err = d.readValue(r, maxLen)
+		case first&0xf0 == 0x10: // 0001xxxx: Indexed Field Line with Post-Base Index
+			var index uint64
+			index, err = readQPACKInt(r, first, 4)
+			if err != nil {
+				return nil, err
+			}
+			hf, err = dynamic(base + index)
+		default: // 0000Nxxx: Literal Field Line with Post-Base Name Reference
+			var index uint64
+			index, err = readQPACKInt(r, first, 3)
+			if err != nil {
+				return nil, err
+			}
+			hf, err = dynamic(base + index)
+			if err != nil {
+				return nil, err
+			}
+			hf.Value, err = d.readValue(r, maxLen)
+		}
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, hf)
+	}
+	return fields, nil
+}
+
+func (d *qpackDecoder) readValue(r *bytes.Reader, maxLen uint64) (string, error) {
+	first, err := r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	return readQPACKString(r, first, 7, maxLen)
+}
+
+// HandleEncoderStream processes the instructions received on the peer's encoder stream, see section 4.3 of RFC 9204.
+// The stream type was already consumed.
+// It returns a qpackError if the peer sent an invalid instruction, and the error reading from the stream otherwise.
+func (d *qpackDecoder) HandleEncoderStream(str io.Reader) error {
+	r := newInstructionReader(str)
+	for {
+		first, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if err := d.handleEncoderInstruction(r, first); err != nil {
+			return r.streamError(ErrCodeQPACKEncoderStreamError, err)
+		}
+		// Acknowledge the new entries once all instructions received so far were processed.
+		if !r.Buffered() {
+			if err := d.sendInsertCountIncrement(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (d *qpackDecoder) handleEncoderInstruction(r *instructionReader, first byte) error {
+	switch {
+	case first&0x80 > 0: // 1Txxxxxx: Insert with Name Reference
+		index, err := readQPACKInt(r, first, 6)
+		if err != nil {
+			return err
+		}
+		value, err := d.readEncoderStreamString(r, 7)
+		if err != nil {
+			return err
+		}
+		var hf qpack.HeaderField
+		if first&0x40 > 0 {
+			if index >= uint64(len(qpackStaticTable)) {
+				return fmt.Errorf("invalid static table index: %d", index)
+			}
+			hf = qpackStaticTable[index]
+		} else {
+			d.mutex.Lock()
+			hf, err = d.relativeEntry(index)
+			d.mutex.Unlock()
+			if err != nil {
+				return err
+			}
+		}
+		hf.Value = value
+		return d.insert(hf)
+	case first&0xc0 == 0x40: // 01Hxxxxx: Insert with Literal Name
+		name, err := readQPACKString(r, first, 5, d.maxTableCapacity)
+		if err != nil {
+			return err
+		}
+		value, err := d.readEncoderStreamString(r, 7)
+		if err != nil {
+			return err
+		}
+		return d.insert(qpack.HeaderField{Name: name, Value: value})
+	case first&0xe0 == 0x20: // 001xxxxx: Set Dynamic Table Capacity
+		capacity, err := readQPACKInt(r, first, 5)
+		if err != nil {
+			return err
+		}
+		if capacity > d.maxTableCapacity {
+			return fmt.Errorf("dynamic table capacity %d exceeds the maximum (%d)", capacity, d.maxTableCapacity)
+		}
+		d.mutex.Lock()
+		defer d.mutex.Unlock()
+		d.table.capacity = capacity
+		return d.table.evictUntil(0)
+	default: // 000xxxxx: Duplicate
+		index, err := readQPACKInt(r, first, 5)
+		if err != nil {
+			return err
+		}
+		d.mutex.Lock()
+		hf, err := d.relativeEntry(index)
+		d.mutex.Unlock()
+		if err != nil {
+			return err
+		}
+		return d.insert(hf)
+	}
+}
+
+func (d *qpackDecoder) readEncoderStreamString(r *instructionReader, n uint8) (string, error) {
+	first, err := r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	// entries larger than the table capacity can't be inserted anyway
+	return readQPACKString(r, first, n, d.maxTableCapacity)
+}
+
+// relativeEntry returns the entry with the given relative index, as used on the encoder stream.
+// It must be called with the mutex held.
+func (d *qpackDecoder) relativeEntry(index uint64) (qpack.HeaderField, error) {
+	insertCount := d.table.insertCount()
+	if index >= insertCount {
+		return qpack.HeaderField{}, fmt.Errorf("invalid relative index %d (Insert Count: %d)", index, insertCount)
+	}
+	hf, ok := d.table.get(insertCount - 1 - index)
+	if !ok {
+		return qpack.HeaderField{}, fmt.Errorf("reference to evicted dynamic table entry %d", insertCount-1-index)
+	}
+	return hf, nil
+}
+
+func (d *qpackDecoder) insert(hf qpack.HeaderField) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if err := d.table.evictUntil(qpackEntrySize(hf)); err != nil {
+		return err
+	}
+	d.table.insert(hf)
+	close(d.inserted)
+	d.inserted = make(chan struct{})
+	return nil
+}
+
+// acknowledgeSection sends a Section Acknowledgment instruction, see section 4.4.1 of RFC 9204.
+func (d *qpackDecoder) acknowledgeSection(streamID quic.StreamID, requiredInsertCount uint64) error {
+	d.mutex.Lock()
+	if requiredInsertCount > d.knownReceivedCount {
+		d.knownReceivedCount = requiredInsertCount
+	}
+	d.mutex.Unlock()
+	return d.writeInstruction(appendQPACKInt(nil, 7, 0x80, uint64(streamID)))
+}
+
+// cancelStream sends a Stream Cancellation instruction, see section 4.4.2 of RFC 9204.
+// It is used when a field section on the stream won't be decoded.
+// Without a dynamic table, the encoder can't hold any references, so no instruction is sent.
+func (d *qpackDecoder) cancelStream(streamID quic.StreamID) error {
+	if d.maxTableCapacity == 0 {
+		return nil
+	}
+	return d.writeInstruction(appendQPACKInt(nil, 6, 0x40, uint64(streamID)))
+}
+
+// sendInsertCountIncrement sends an Insert Count Increment instruction for all entries
+// that the encoder doesn't know were received yet, see section 4.4.3 of RFC 9204.
+func (d *qpackDecoder) sendInsertCountIncrement() error {
+	d.mutex.Lock()
+	increment := d.table.insertCount() - d.knownReceivedCount
+	d.knownReceivedCount = d.table.insertCount()
+	d.mutex.Unlock()
+	if increment == 0 {
+		return nil
+	}
+	return d.writeInstruction(appendQPACKInt(nil, 6, 0, increment))
+}
+
+func (d *qpackDecoder) writeInstruction(b []byte) error {
+	d.strMutex.Lock()
+	defer d.strMutex.Unlock()
+
+	if d.str == nil {
+		str, err := d.openStream()
+		if err != nil {
+			return fmt.Errorf("opening the QPACK decoder stream failed: %w", err)
+		}
+		d.str = str
+		b = append(quicvarint.Append(nil, streamTypeQPACKDecoderStream), b...)
+	}
+	_, err := d.str.Write(b)
+	return err
+}
@@ -0,0 +1,255 @@
+package http3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+
+	"github.com/quic-go/quic-go"
+	mockquic "github.com/quic-go/quic-go/internal/mocks/quic"
+
+	"github.com/quic-go/qpack"
+	"go.uber.org/mock/gomock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("QPACK Decoder", func() {
+	var (
+		decoder *qpackDecoder
+		// instructions written to the decoder stream
+		decoderStr         chan []byte
+		decoderStrTypeRead bool
+	)
+
+	BeforeEach(func() {
+		decoderStr = make(chan []byte, 100)
+		decoderStrTypeRead = false
+		str := mockquic.NewMockStream(mockCtrl)
+		str.EXPECT().Write(gomock.Any()).DoAndReturn(func(b []byte) (int, error) {
+			decoderStr <- append([]byte{}, b...)
+			return len(b), nil
+		}).AnyTimes()
+		var opened bool
+		decoder = newQPACKDecoder(4096, 2, func() (quic.SendStream, error) {
+			Expect(opened).To(BeFalse())
+			opened = true
+			return str, nil
+		})
+	})
+
+	// readDecoderStream reads all instructions written to the decoder stream, without the stream type
+	readDecoderStream := func() []byte {
+		var b []byte
+		for {
+			select {
+			case data := <-decoderStr:
+				b = append(b, data...)
+			default:
+				if len(b) > 0 && !decoderStrTypeRead {
+					Expect(b[0]).To(BeEquivalentTo(streamTypeQPACKDecoderStream))
+					decoderStrTypeRead = true
+					return b[1:]
+				}
+				return b
+			}
+		}
+	}
+
+	insertLiteral := func(b []byte, name, value string) []byte {
+		b = appendQPACKString(b, 5, 0x40, name)
+		return appendQPACKString(b, 7, 0, value)
+	}
+
+	// handleInstructions processes the instructions, until the end of the stream is reached
+	handleInstructions := func(b []byte) {
+		ExpectWithOffset(1, decoder.HandleEncoderStream(bytes.NewReader(b))).To(MatchError(io.EOF))
+	}
+
+	It("decodes field sections that only use the static table", func() {
+		buf := &bytes.Buffer{}
+		enc := qpack.NewEncoder(buf)
+		Expect(enc.WriteField(qpack.HeaderField{Name: ":status", Value: "200"})).To(Succeed())
+		Expect(enc.WriteField(qpack.HeaderField{Name: "content-type", Value: "text/plain"})).To(Succeed())
+		Expect(enc.WriteField(qpack.HeaderField{Name: "foo", Value: "bar"})).To(Succeed())
+		fields, err := decoder.Decode(context.Background(), 0, buf.Bytes())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fields).To(Equal([]qpack.HeaderField{
+			{Name: ":status", Value: "200"},
+			{Name: "content-type", Value: "text/plain"},
+			{Name: "foo", Value: "bar"},
+		}))
+		// no Section Acknowledgment is sent for field sections that don't reference the dynamic table
+		Expect(readDecoderStream()).To(BeEmpty())
+	})
+
+	It("rejects empty field sections", func() {
+		_, err := decoder.Decode(context.Background(), 0, nil)
+		Expect(err).To(MatchError(&qpackError{code: ErrCodeQPACKDecompressionFailed, err: io.EOF}))
+	})
+
+	It("inserts entries and acknowledges them", func() {
+		b := appendQPACKInt(nil, 5, 0x20, 4096)   // Set Dynamic Table Capacity
+		b = insertLiteral(b, "foo", "bar")        // absolute index 0
+		b = appendQPACKInt(b, 6, 0xc0, 0)         // Insert with Name Reference: :authority
+		b = appendQPACKString(b, 7, 0, "foo.com") // absolute index 1
+		b = appendQPACKInt(b, 6, 0x80, 1)         // Insert with Name Reference: relative index 1 (foo)
+		b = appendQPACKString(b, 7, 0, "baz")     // absolute index 2
+		b = appendQPACKInt(b, 5, 0, 2)            // Duplicate: relative index 2 (foo: bar), absolute index 3
+		handleInstructions(b)
+		// Insert Count Increment
+		Expect(readDecoderStream()).To(Equal(appendQPACKInt(nil, 6, 0, 4)))
+
+		// Required Insert Count 4, Base 2
+		data := []byte{0x5, 0x81}
+		data = append(data, 0x80)                            // Indexed Field Line: relative index 0 (absolute 1)
+		data = append(data, 0x10)                            // Indexed Field Line with Post-Base Index 0 (absolute 2)
+		data = appendQPACKInt(data, 3, 0x0, 1)               // Literal Field Line with Post-Base Name Reference 1 (absolute 3)
+		data = appendQPACKString(data, 7, 0, "qux")          //
+		data = appendQPACKInt(data, 4, 0x40, 1)              // Literal Field Line with Name Reference: relative index 1 (absolute 0)
+		data = appendQPACKString(data, 7, 0, "quux")         //
+		data = append(data, 0xc0|25)                         // Indexed Field Line: static index 25 (:status: 200)
+		data = appendQPACKString(data, 3, 0x20, "literal")   // Literal Field Line with Literal Name
+		data = appendQPACKString(data, 7, 0, "field value!") //
+		fields, err := decoder.Decode(context.Background(), 4, data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fields).To(Equal([]qpack.HeaderField{
+			{Name: ":authority", Value: "foo.com"},
+			{Name: "foo", Value: "baz"},
+			{Name: "foo", Value: "qux"},
+			{Name: "foo", Value: "quux"},
+			{Name: ":status", Value: "200"},
+			{Name: "literal", Value: "field value!"},
+		}))
+		// Section Acknowledgment
+		Expect(readDecoderStream()).To(Equal([]byte{0x84}))
+	})
+
+	It("evicts entries", func() {
+		b := appendQPACKInt(nil, 5, 0x20, 100)
+		b = insertLiteral(b, "foo", "bar") // 38 bytes
+		b = insertLiteral(b, "bar", "baz")
+		b = insertLiteral(b, "baz", "foo") // evicts the first entry
+		handleInstructions(b)
+		readDecoderStream()
+
+		// Required Insert Count 3, Base 3
+		_, err := decoder.Decode(context.Background(), 0, []byte{0x4, 0x0, 0x82})
+		Expect(err).To(MatchError(&qpackError{
+			code: ErrCodeQPACKDecompressionFailed,
+			err:  errors.New("reference to evicted dynamic table entry 0"),
+		}))
+		fields, err := decoder.Decode(context.Background(), 4, []byte{0x4, 0x0, 0x81, 0x80})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fields).To(Equal([]qpack.HeaderField{{Name: "bar", Value: "baz"}, {Name: "baz", Value: "foo"}}))
+	})
+
+	It("blocks until the referenced entries are received", func() {
+		// Required Insert Count 1, Base 1, relative index 0
+		done := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			defer close(done)
+			fields, err := decoder.Decode(context.Background(), 4, []byte{0x2, 0x0, 0x80})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fields).To(Equal([]qpack.HeaderField{{Name: "foo", Value: "bar"}}))
+		}()
+		Consistently(done).ShouldNot(BeClosed())
+		handleInstructions(insertLiteral(appendQPACKInt(nil, 5, 0x20, 4096), "foo", "bar"))
+		Eventually(done).Should(BeClosed())
+	})
+
+	It("sends a Stream Cancellation when decoding a blocked stream is aborted", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		errChan := make(chan error, 1)
+		go func() {
+			_, err := decoder.Decode(ctx, 8, []byte{0x2, 0x0, 0x80})
+			errChan <- err
+		}()
+		Consistently(errChan).ShouldNot(Receive())
+		cancel()
+		Eventually(errChan).Should(Receive(MatchError(context.Canceled)))
+		Expect(readDecoderStream()).To(Equal([]byte{0x48}))
+	})
+
+	It("limits the number of blocked streams", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		errChan := make(chan error, 2)
+		for i := 0; i < 2; i++ {
+			go func(id quic.StreamID) {
+				_, err := decoder.Decode(ctx, id, []byte{0x2, 0x0, 0x80})
+				errChan <- err
+			}(quic.StreamID(4 * i))
+		}
+		Eventually(func() uint64 {
+			decoder.mutex.Lock()
+			defer decoder.mutex.Unlock()
+			return decoder.numBlocked
+		}).Should(BeEquivalentTo(2))
+		_, err := decoder.Decode(ctx, 8, []byte{0x2, 0x0, 0x80})
+		Expect(err).To(MatchError(&qpackError{code: ErrCodeQPACKDecompressionFailed, err: errors.New("too many blocked streams")}))
+		cancel()
+		Eventually(errChan).Should(Receive(MatchError(context.Canceled)))
+		Eventually(errChan).Should(Receive(MatchError(context.Canceled)))
+	})
+
+	It("rejects references to entries not covered by the Required Insert Count", func() {
+		b := appendQPACKInt(nil, 5, 0x20, 4096)
+		b = insertLiteral(b, "foo", "bar")
+		b = insertLiteral(b, "bar", "baz")
+		handleInstructions(b)
+		// Required Insert Count 1, Base 1, post-base index 0
+		_, err := decoder.Decode(context.Background(), 0, []byte{0x2, 0x0, 0x10})
+		Expect(err).To(MatchError(&qpackError{
+			code: ErrCodeQPACKDecompressionFailed,
+			err:  errors.New("reference to dynamic table entry 1 not covered by the Required Insert Count (1)"),
+		}))
+	})
+
+	It("rejects invalid Required Insert Counts", func() {
+		_, err := decoder.Decode(context.Background(), 0, []byte{0xff, 0x2, 0x0})
+		Expect(err).To(MatchError(&qpackError{code: ErrCodeQPACKDecompressionFailed, err: errors.New("invalid Required Insert Count: 257")}))
+	})
+
+	It("rejects truncated field sections", func() {
+		data := appendQPACKString([]byte{0, 0}, 3, 0x20, "foo")
+		_, err := decoder.Decode(context.Background(), 0, data)
+		Expect(err).To(MatchError(&qpackError{code: ErrCodeQPACKDecompressionFailed, err: io.EOF}))
+	})
+
+	Context("encoder stream errors", func() {
+		handleInvalidInstructions := func(b []byte, msg string) {
+			err := decoder.HandleEncoderStream(bytes.NewReader(b))
+			ExpectWithOffset(1, err).To(MatchError(&qpackError{code: ErrCodeQPACKEncoderStreamError, err: errors.New(msg)}))
+		}
+
+		It("rejects capacities larger than the maximum", func() {
+			handleInvalidInstructions(appendQPACKInt(nil, 5, 0x20, 4097), "dynamic table capacity 4097 exceeds the maximum (4096)")
+		})
+
+		It("rejects entries larger than the capacity", func() {
+			b := appendQPACKInt(nil, 5, 0x20, 40)
+			handleInvalidInstructions(insertLiteral(b, "foo", "barbaz"), "entry too large for the dynamic table: 41 bytes (capacity: 40 bytes)")
+		})
+
+		It("rejects invalid static table references", func() {
+			b := appendQPACKInt(nil, 5, 0x20, 4096)
+			b = appendQPACKInt(b, 6, 0xc0, 99)
+			handleInvalidInstructions(appendQPACKString(b, 7, 0, "foo"), "invalid static table index: 99")
+		})
+
+		It("rejects invalid dynamic table references", func() {
+			b := appendQPACKInt(nil, 5, 0x20, 4096)
+			b = insertLiteral(b, "foo", "bar")
+			handleInvalidInstructions(appendQPACKInt(b, 5, 0, 1), "invalid relative index 1 (Insert Count: 1)")
+		})
+
+		It("returns the I/O error when the stream is closed in the middle of an instruction", func() {
+			b := appendQPACKInt(nil, 5, 0x20, 4096)
+			b = insertLiteral(b, "foo", "bar")
+			Expect(decoder.HandleEncoderStream(bytes.NewReader(b[:len(b)-1]))).To(MatchError(io.EOF))
+		})
+	})
+})
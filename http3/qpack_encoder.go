@@ -0,0 +1,386 @@
+package http3
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+
+	"github.com/quic-go/qpack"
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/quicvarint"
+)
+
+// Fields that are unlikely to be repeated on other streams are never inserted into the dynamic table.
+var qpackNeverIndexed = map[string]struct{}{
+	":path":             {},
+	"age":               {},
+	"content-length":    {},
+	"date":              {},
+	"etag":              {},
+	"if-modified-since": {},
+	"if-none-match":     {},
+	"last-modified":     {},
+}
+
+// A qpackSection is a field section that references the dynamic table, and that wasn't acknowledged yet.
+type qpackSection struct {
+	requiredInsertCount uint64
+	minRef              uint64 // the lowest absolute index referenced
+}
+
+// A qpackEncoder encodes the field sections sent on a connection, see RFC 9204.
+// Fields are inserted into the dynamic table using instructions on our encoder stream,
+// which is opened when the first entry is inserted.
+// Field sections only reference entries that the peer acknowledged, so that streams never block on the peer's side.
+type qpackEncoder struct {
+	maxTableCapacity uint64 // the maximum capacity we allow
+
+	openStream func() (quic.SendStream, error)
+
+	// writeMutex serializes writes to the encoder stream, which happen outside of mutex.
+	// It is acquired before mutex.
+	writeMutex sync.Mutex
+	str        quic.SendStream // the encoder stream
+
+	mutex sync.Mutex
+	// the values of SETTINGS_QPACK_MAX_TABLE_CAPACITY and SETTINGS_MAX_FIELD_SECTION_SIZE sent by the peer
+	peerMaxTableCapacity    uint64
+	peerMaxFieldSectionSize uint64
+	settingsReceived        bool
+	table                   qpackDynamicTable
+	// the absolute index of the newest entry for every field and every name in the table
+	fieldIndex map[qpack.HeaderField]uint64
+	nameIndex  map[string]uint64
+	// the Known Received Count, see section 2.1.4 of RFC 9204
+	knownReceivedCount uint64
+	// field sections that weren't acknowledged yet, in the order they were sent on each stream
+	pending map[quic.StreamID][]qpackSection
+	// instructions that weren't written to the encoder stream yet
+	queuedInstructions []byte
+	// set when opening or writing to the encoder stream fails
+	disabled bool
+}
+
+func newQPACKEncoder(maxTableCapacity uint64, openStream func() (quic.SendStream, error)) *qpackEncoder {
+	return &qpackEncoder{
+		maxTableCapacity: maxTableCapacity,
+		openStream:       openStream,
+		fieldIndex:       make(map[qpack.HeaderField]uint64),
+		nameIndex:        make(map[string]uint64),
+		pending:          make(map[quic.StreamID][]qpackSection),
+	}
+}
+
+// HandleSettings is called when the peer's SETTINGS frame is received.
+// Until then, the dynamic table is not used, and the size of field sections is not limited.
+func (e *qpackEncoder) HandleSettings(peerMaxTableCapacity, peerMaxFieldSectionSize uint64) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.settingsReceived {
+		return
+	}
+	e.settingsReceived = true
+	e.peerMaxTableCapacity = peerMaxTableCapacity
+	e.peerMaxFieldSectionSize = peerMaxFieldSectionSize
+	e.table.capacity = peerMaxTableCapacity
+	if e.maxTableCapacity < e.table.capacity {
+		e.table.capacity = e.maxTableCapacity
+	}
+}
+
+// CheckFieldSectionSize checks that a field section of the given size (see section 4.2.2 of RFC 9114)
+// doesn't exceed the limit that the peer sent in SETTINGS_MAX_FIELD_SECTION_SIZE.
+func (e *qpackEncoder) CheckFieldSectionSize(size uint64) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.peerMaxFieldSectionSize > 0 && size > e.peerMaxFieldSectionSize {
+		return fmt.Errorf("http3: field section too large: %d bytes (peer's limit: %d bytes)", size, e.peerMaxFieldSectionSize)
+	}
+	return nil
+}
+
+// A qpackFieldLine is the representation chosen for a field.
+type qpackFieldLine struct {
+	field qpack.HeaderField
+	// the static table index, or the absolute index of the dynamic table entry (only if indexed or nameRef are set)
+	index   uint64
+	static  bool
+	indexed bool // the field is indexed, otherwise the value is sent as a literal
+	nameRef bool // the name is taken from the table, otherwise it is sent as a literal
+}
+
+// Encode encodes a field section sent on a stream.
+// New fields are inserted into the dynamic table, and can be referenced once the peer acknowledged their insertion.
+func (e *qpackEncoder) Encode(streamID quic.StreamID, fields []qpack.HeaderField) []byte {
+	b, hasInstructions := e.encode(streamID, fields)
+	if hasInstructions {
+		e.writeInstructions()
+	}
+	return b
+}
+
+func (e *qpackEncoder) encode(streamID quic.StreamID, fields []qpack.HeaderField) ([]byte, bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	var requiredInsertCount uint64
+	minRef := uint64(math.MaxUint64)
+	var instructions []byte
+	lines := make([]qpackFieldLine, 0, len(fields))
+	for _, hf := range fields {
+		line := e.chooseRepresentation(hf)
+		if !line.static && (line.indexed || line.nameRef) {
+			if line.index+1 > requiredInsertCount {
+				requiredInsertCount = line.index + 1
+			}
+			if line.index < minRef {
+				minRef = line.index
+			}
+		}
+		lines = append(lines, line)
+		if !line.indexed {
+			instructions = e.maybeInsert(instructions, hf)
+		}
+	}
+	e.queuedInstructions = append(e.queuedInstructions, instructions...)
+
+	b := e.appendPrefix(nil, requiredInsertCount)
+	// Base is equal to the Required Insert Count, thus all references use relative indices.
+	base := requiredInsertCount
+	for _, line := range lines {
+		switch {
+		case line.indexed && line.static: // 11xxxxxx
+			b = appendQPACKInt(b, 6, 0xc0, line.index)
+		case line.indexed: // 10xxxxxx
+			b = appendQPACKInt(b, 6, 0x80, base-1-line.index)
+		case line.nameRef && line.static: // 0101xxxx
+			b = appendQPACKInt(b, 4, 0x50, line.index)
+			b = appendQPACKString(b, 7, 0, line.field.Value)
+		case line.nameRef: // 0100xxxx
+			b = appendQPACKInt(b, 4, 0x40, base-1-line.index)
+			b = appendQPACKString(b, 7, 0, line.field.Value)
+		default: // 0010xxxx
+			b = appendQPACKString(b, 3, 0x20, line.field.Name)
+			b = appendQPACKString(b, 7, 0, line.field.Value)
+		}
+	}
+	if requiredInsertCount > 0 {
+		e.pending[streamID] = append(e.pending[streamID], qpackSection{
+			requiredInsertCount: requiredInsertCount,
+			minRef:              minRef,
+		})
+	}
+	return b, len(instructions) > 0
+}
+
+// chooseRepresentation chooses how a field is encoded.
+// Only entries that were acknowledged by the peer are referenced.
+func (e *qpackEncoder) chooseRepresentation(hf qpack.HeaderField) qpackFieldLine {
+	if index, ok := qpackStaticIndex[hf]; ok {
+		return qpackFieldLine{field: hf, index: index, static: true, indexed: true}
+	}
+	if abs, ok := e.fieldIndex[hf]; ok && abs < e.knownReceivedCount {
+		return qpackFieldLine{field: hf, index: abs, indexed: true}
+	}
+	if index, ok := qpackStaticNameIndex[hf.Name]; ok {
+		return qpackFieldLine{field: hf, index: index, static: true, nameRef: true}
+	}
+	if abs, ok := e.nameIndex[hf.Name]; ok && abs < e.knownReceivedCount {
+		return qpackFieldLine{field: hf, index: abs, nameRef: true}
+	}
+	return qpackFieldLine{field: hf}
+}
+
+// maybeInsert inserts a field into the dynamic table, if there's enough room for it,
+// and appends the insert instruction.
+func (e *qpackEncoder) maybeInsert(b []byte, hf qpack.HeaderField) []byte {
+	if e.disabled || e.table.capacity == 0 {
+		return b
+	}
+	if _, ok := qpackNeverIndexed[hf.Name]; ok {
+		return b
+	}
+	if _, ok := e.fieldIndex[hf]; ok {
+		return b
+	}
+	size := qpackEntrySize(hf)
+	// Large entries would evict too many other entries.
+	if size > e.table.capacity/4 || !e.makeRoom(size) {
+		return b
+	}
+	if index, ok := qpackStaticNameIndex[hf.Name]; ok {
+		// Insert with Name Reference, using the static table: 11xxxxxx
+		b = appendQPACKInt(b, 6, 0xc0, index)
+	} else {
+		// Insert with Literal Name: 01xxxxxx
+		b = appendQPACKString(b, 5, 0x40, hf.Name)
+	}
+	b = appendQPACKString(b, 7, 0, hf.Value)
+	abs := e.table.insertCount()
+	e.table.insert(hf)
+	e.fieldIndex[hf] = abs
+	e.nameIndex[hf.Name] = abs
+	return b
+}
+
+// makeRoom evicts entries until there's room for an entry of the given size.
+// Entries can only be evicted after the peer acknowledged their insertion,
+// and if they're not referenced by unacknowledged field sections, see section 2.1.1 of RFC 9204.
+func (e *qpackEncoder) makeRoom(size uint64) bool {
+	if e.table.size+size <= e.table.capacity {
+		return true
+	}
+	limit := e.knownReceivedCount
+	for _, sections := range e.pending {
+		for _, s := range sections {
+			if s.minRef < limit {
+				limit = s.minRef
+			}
+		}
+	}
+	var freed uint64
+	var n int
+	for e.table.size-freed+size > e.table.capacity {
+		if e.table.dropped+uint64(n) >= limit {
+			return false
+		}
+		freed += qpackEntrySize(e.table.entries[n])
+		n++
+	}
+	for i := 0; i < n; i++ {
+		abs := e.table.dropped
+		hf := e.table.entries[0]
+		if e.fieldIndex[hf] == abs {
+			delete(e.fieldIndex, hf)
+		}
+		if e.nameIndex[hf.Name] == abs {
+			delete(e.nameIndex, hf.Name)
+		}
+		e.table.evict()
+	}
+	return true
+}
+
+// appendPrefix appends the Encoded Required Insert Count and the Base, see section 4.5.1 of RFC 9204.
+func (e *qpackEncoder) appendPrefix(b []byte, requiredInsertCount uint64) []byte {
+	var encodedInsertCount uint64
+	if requiredInsertCount > 0 {
+		maxEntries := e.peerMaxTableCapacity / 32
+		encodedInsertCount = requiredInsertCount%(2*maxEntries) + 1
+	}
+	b = appendQPACKInt(b, 8, 0, encodedInsertCount)
+	// the Base is equal to the Required Insert Count: the sign bit and the Delta Base are 0
+	return append(b, 0)
+}
+
+// writeInstructions writes the queued instructions to the encoder stream, opening the stream if necessary.
+// The instructions are written in the order they were queued,
+// but writing doesn't block encoding field sections for other streams.
+// Since field sections never reference entries that weren't acknowledged,
+// the dynamic table is just not used any more if this fails.
+func (e *qpackEncoder) writeInstructions() {
+	e.writeMutex.Lock()
+	defer e.writeMutex.Unlock()
+
+	e.mutex.Lock()
+	b := e.queuedInstructions
+	e.queuedInstructions = nil
+	capacity := e.table.capacity
+	e.mutex.Unlock()
+	// the instructions might already have been written by a concurrent call
+	if len(b) == 0 {
+		return
+	}
+
+	if e.str == nil {
+		str, err := e.openStream()
+		if err != nil {
+			e.disable()
+			return
+		}
+		e.str = str
+		prefix := quicvarint.Append(nil, streamTypeQPACKEncoderStream)
+		// Set Dynamic Table Capacity: 001xxxxx
+		prefix = appendQPACKInt(prefix, 5, 0x20, capacity)
+		b = append(prefix, b...)
+	}
+	if _, err := e.str.Write(b); err != nil {
+		e.disable()
+	}
+}
+
+func (e *qpackEncoder) disable() {
+	e.mutex.Lock()
+	e.disabled = true
+	e.mutex.Unlock()
+}
+
+// HandleDecoderStream processes the instructions received on the peer's decoder stream, see section 4.4 of RFC 9204.
+// The stream type was already consumed.
+// It returns a qpackError if the peer sent an invalid instruction, and the error reading from the stream otherwise.
+func (e *qpackEncoder) HandleDecoderStream(str io.Reader) error {
+	r := newInstructionReader(str)
+	for {
+		first, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if err := e.handleDecoderInstruction(r, first); err != nil {
+			return r.streamError(ErrCodeQPACKDecoderStreamError, err)
+		}
+	}
+}
+
+func (e *qpackEncoder) handleDecoderInstruction(r *instructionReader, first byte) error {
+	switch {
+	case first&0x80 > 0: // 1xxxxxxx: Section Acknowledgment
+		id, err := readQPACKInt(r, first, 7)
+		if err != nil {
+			return err
+		}
+		streamID := quic.StreamID(id)
+		e.mutex.Lock()
+		defer e.mutex.Unlock()
+		sections := e.pending[streamID]
+		if len(sections) == 0 {
+			return fmt.Errorf("Section Acknowledgment for stream %d without outstanding field sections", streamID)
+		}
+		if sections[0].requiredInsertCount > e.knownReceivedCount {
+			e.knownReceivedCount = sections[0].requiredInsertCount
+		}
+		if len(sections) == 1 {
+			delete(e.pending, streamID)
+		} else {
+			e.pending[streamID] = sections[1:]
+		}
+		return nil
+	case first&0xc0 == 0x40: // 01xxxxxx: Stream Cancellation
+		id, err := readQPACKInt(r, first, 6)
+		if err != nil {
+			return err
+		}
+		e.mutex.Lock()
+		delete(e.pending, quic.StreamID(id))
+		e.mutex.Unlock()
+		return nil
+	default: // 00xxxxxx: Insert Count Increment
+		increment, err := readQPACKInt(r, first, 6)
+		if err != nil {
+			return err
+		}
+		if increment == 0 {
+			return errors.New("Insert Count Increment of 0")
+		}
+		e.mutex.Lock()
+		defer e.mutex.Unlock()
+		if increment > e.table.insertCount()-e.knownReceivedCount {
+			return fmt.Errorf("Insert Count Increment of %d exceeds the number of unacknowledged inserts", increment)
+		}
+		e.knownReceivedCount += increment
+		return nil
+	}
+}
@@ -0,0 +1,247 @@
+package http3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	mockquic "github.com/quic-go/quic-go/internal/mocks/quic"
+	"github.com/quic-go/quic-go/quicvarint"
+
+	"github.com/quic-go/qpack"
+	"go.uber.org/mock/gomock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("QPACK Encoder", func() {
+	var (
+		encoder *qpackEncoder
+		decoder *qpackDecoder
+		// the data written to the encoder and the decoder stream
+		encoderStrBuf, decoderStrBuf           *bytes.Buffer
+		encoderStrTypeRead, decoderStrTypeRead bool
+	)
+
+	newStream := func(buf *bytes.Buffer) quic.SendStream {
+		str := mockquic.NewMockStream(mockCtrl)
+		str.EXPECT().Write(gomock.Any()).DoAndReturn(buf.Write).AnyTimes()
+		return str
+	}
+
+	BeforeEach(func() {
+		encoderStrBuf = &bytes.Buffer{}
+		decoderStrBuf = &bytes.Buffer{}
+		encoderStrTypeRead = false
+		decoderStrTypeRead = false
+		encoder = newQPACKEncoder(4096, func() (quic.SendStream, error) { return newStream(encoderStrBuf), nil })
+		decoder = newQPACKDecoder(4096, 16, func() (quic.SendStream, error) { return newStream(decoderStrBuf), nil })
+	})
+
+	// readStreamType reads the stream type from the beginning of a stream
+	readStreamType := func(buf *bytes.Buffer, typeRead *bool, expected uint64) {
+		if *typeRead {
+			return
+		}
+		t, err := quicvarint.Read(buf)
+		ExpectWithOffset(2, err).ToNot(HaveOccurred())
+		ExpectWithOffset(2, t).To(Equal(expected))
+		*typeRead = true
+	}
+
+	// The decoder processes the instructions on the encoder stream, and the encoder processes the instructions on the decoder stream.
+	exchangeInstructions := func() {
+		if encoderStrBuf.Len() > 0 {
+			readStreamType(encoderStrBuf, &encoderStrTypeRead, streamTypeQPACKEncoderStream)
+			ExpectWithOffset(1, decoder.HandleEncoderStream(encoderStrBuf)).To(MatchError(io.EOF))
+		}
+		if decoderStrBuf.Len() > 0 {
+			readStreamType(decoderStrBuf, &decoderStrTypeRead, streamTypeQPACKDecoderStream)
+			ExpectWithOffset(1, encoder.HandleDecoderStream(decoderStrBuf)).To(MatchError(io.EOF))
+		}
+	}
+
+	roundTrip := func(streamID quic.StreamID, fields []qpack.HeaderField) []byte {
+		data := encoder.Encode(streamID, fields)
+		decoded, err := decoder.Decode(context.Background(), streamID, data)
+		ExpectWithOffset(1, err).ToNot(HaveOccurred())
+		ExpectWithOffset(1, decoded).To(Equal(fields))
+		return data
+	}
+
+	fields := []qpack.HeaderField{
+		{Name: ":status", Value: "200"},
+		{Name: "content-type", Value: "text/html; charset=utf-8"},
+		{Name: "server", Value: "quic-go HTTP/3"},
+		{Name: "x-custom-header", Value: "foobar"},
+		{Name: "content-length", Value: "1337"},
+	}
+
+	It("only uses the static table before the SETTINGS frame is received", func() {
+		data := encoder.Encode(0, fields)
+		Expect(encoderStrBuf.Len()).To(BeZero())
+		// the representations can be decoded by a decoder that doesn't support the dynamic table
+		decoded, err := qpack.NewDecoder(nil).DecodeFull(data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decoded).To(Equal(fields))
+	})
+
+	It("doesn't use the dynamic table if the peer doesn't allow it", func() {
+		encoder.HandleSettings(0, 0)
+		encoder.Encode(0, fields)
+		Expect(encoderStrBuf.Len()).To(BeZero())
+	})
+
+	It("doesn't use the dynamic table if it is disabled locally", func() {
+		encoder = newQPACKEncoder(0, func() (quic.SendStream, error) {
+			Fail("didn't expect the encoder stream to be opened")
+			return nil, nil
+		})
+		encoder.HandleSettings(4096, 0)
+		encoder.Encode(0, fields)
+	})
+
+	It("uses the dynamic table once the peer acknowledged the inserts", func() {
+		encoder.HandleSettings(4096, 0)
+		first := roundTrip(0, fields)
+		// the stream type, followed by a Set Dynamic Table Capacity instruction
+		prefix := appendQPACKInt(quicvarint.Append(nil, streamTypeQPACKEncoderStream), 5, 0x20, 4096)
+		Expect(encoderStrBuf.String()).To(HavePrefix(string(prefix)))
+		exchangeInstructions()
+		// :status and content-type are in the static table, and content-length is never inserted
+		Expect(encoder.knownReceivedCount).To(BeEquivalentTo(2))
+
+		second := roundTrip(4, fields)
+		Expect(len(second)).To(BeNumerically("<", len(first)))
+		// Required Insert Count 2
+		Expect(second[0]).To(BeEquivalentTo(3))
+		Expect(decoderStrBuf.Len()).ToNot(BeZero())
+		exchangeInstructions()
+		Expect(encoder.pending).To(BeEmpty())
+
+		// the fields were inserted before, so no new instructions are sent
+		roundTrip(8, fields)
+		Expect(encoderStrBuf.Len()).To(BeZero())
+	})
+
+	It("references the name of dynamic table entries", func() {
+		encoder.HandleSettings(4096, 0)
+		roundTrip(0, []qpack.HeaderField{{Name: "x-custom-header", Value: "foo"}})
+		exchangeInstructions()
+		roundTrip(4, []qpack.HeaderField{{Name: "x-custom-header", Value: "bar"}})
+		exchangeInstructions()
+		Expect(encoder.table.insertCount()).To(BeEquivalentTo(2))
+		data := roundTrip(8, []qpack.HeaderField{{Name: "x-custom-header", Value: "baz"}})
+		// Literal Field Line with Name Reference, using the dynamic table
+		Expect(data[2] & 0xf0).To(BeEquivalentTo(0x40))
+	})
+
+	It("doesn't evict entries that are referenced by unacknowledged field sections", func() {
+		encoder.HandleSettings(4096, 0)
+		encoder.maxTableCapacity = 200
+		encoder.table.capacity = 200 // room for 4 entries of 50 bytes
+		field := func(i int) qpack.HeaderField {
+			return qpack.HeaderField{Name: "x-header", Value: string(rune('a'+i)) + "123456789"}
+		}
+		roundTrip(0, []qpack.HeaderField{field(0), field(1)})
+		exchangeInstructions()
+		Expect(encoder.table.insertCount()).To(BeEquivalentTo(2))
+		// This field section references the entries, but it's not acknowledged yet.
+		data := encoder.Encode(4, []qpack.HeaderField{field(0), field(1)})
+		roundTrip(8, []qpack.HeaderField{field(2), field(3)})
+		exchangeInstructions()
+		Expect(encoder.table.insertCount()).To(BeEquivalentTo(4))
+		// There's no room for a new entry without evicting the first entry.
+		encoder.Encode(12, []qpack.HeaderField{field(4)})
+		Expect(encoder.table.insertCount()).To(BeEquivalentTo(4))
+		// Once the field section is acknowledged, the entry can be evicted.
+		_, err := decoder.Decode(context.Background(), 4, data)
+		Expect(err).ToNot(HaveOccurred())
+		exchangeInstructions()
+		encoder.Encode(16, []qpack.HeaderField{field(4)})
+		Expect(encoder.table.insertCount()).To(BeEquivalentTo(5))
+		Expect(encoder.table.dropped).To(BeEquivalentTo(1))
+		exchangeInstructions()
+		roundTrip(20, []qpack.HeaderField{field(1), field(2), field(3), field(4)})
+	})
+
+	It("releases the references of canceled streams", func() {
+		encoder.HandleSettings(4096, 0)
+		roundTrip(0, fields)
+		exchangeInstructions()
+		encoder.Encode(4, fields)
+		Expect(encoder.pending).To(HaveKey(quic.StreamID(4)))
+		Expect(encoder.HandleDecoderStream(bytes.NewReader(appendQPACKInt(nil, 6, 0x40, 4)))).To(MatchError(io.EOF))
+		Expect(encoder.pending).To(BeEmpty())
+	})
+
+	It("stops using the dynamic table when the encoder stream can't be opened", func() {
+		encoder = newQPACKEncoder(4096, func() (quic.SendStream, error) { return nil, errors.New("test err") })
+		encoder.HandleSettings(4096, 0)
+		encoder.Encode(0, fields)
+		Expect(encoder.disabled).To(BeTrue())
+		roundTrip(4, fields)
+	})
+
+	It("doesn't block encoding while writing to the encoder stream", func() {
+		unblock := make(chan struct{})
+		writeStarted := make(chan struct{}, 1)
+		encoder = newQPACKEncoder(4096, func() (quic.SendStream, error) {
+			str := mockquic.NewMockStream(mockCtrl)
+			str.EXPECT().Write(gomock.Any()).DoAndReturn(func(b []byte) (int, error) {
+				writeStarted <- struct{}{}
+				<-unblock
+				return encoderStrBuf.Write(b)
+			}).AnyTimes()
+			return str, nil
+		})
+		encoder.HandleSettings(4096, 0)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			encoder.Encode(0, fields)
+		}()
+		Eventually(writeStarted).Should(Receive())
+		// encoding a field section that doesn't need any instructions doesn't block
+		roundTrip(4, []qpack.HeaderField{{Name: ":status", Value: "200"}})
+		// instructions are queued and written once the first write completes
+		encoded := make(chan []byte, 1)
+		go func() { encoded <- encoder.Encode(8, []qpack.HeaderField{{Name: "x-other-header", Value: "foo"}}) }()
+		Consistently(encoded, scaleDuration(20*time.Millisecond)).ShouldNot(Receive())
+		close(unblock)
+		Eventually(done).Should(BeClosed())
+		Eventually(encoded).Should(Receive())
+
+		exchangeInstructions()
+		Expect(decoder.table.entries).To(ContainElements(
+			qpack.HeaderField{Name: "x-custom-header", Value: "foobar"},
+			qpack.HeaderField{Name: "x-other-header", Value: "foo"},
+		))
+	})
+
+	Context("decoder stream errors", func() {
+		handleInvalidInstructions := func(b []byte, msg string) {
+			err := encoder.HandleDecoderStream(bytes.NewReader(b))
+			ExpectWithOffset(1, err).To(MatchError(&qpackError{code: ErrCodeQPACKDecoderStreamError, err: errors.New(msg)}))
+		}
+
+		It("rejects Section Acknowledgments for streams without outstanding field sections", func() {
+			handleInvalidInstructions([]byte{0x84}, "Section Acknowledgment for stream 4 without outstanding field sections")
+		})
+
+		It("rejects Insert Count Increments of 0", func() {
+			handleInvalidInstructions([]byte{0x0}, "Insert Count Increment of 0")
+		})
+
+		It("rejects Insert Count Increments exceeding the number of inserts", func() {
+			encoder.HandleSettings(4096, 0)
+			encoder.Encode(0, fields)
+			handleInvalidInstructions([]byte{0x4}, "Insert Count Increment of 4 exceeds the number of unacknowledged inserts")
+		})
+	})
+})
@@ -0,0 +1,100 @@
+package http3
+
+import (
+	"bytes"
+
+	"github.com/quic-go/qpack"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("QPACK", func() {
+	Context("integers", func() {
+		It("encodes integers that fit into the prefix", func() {
+			// example from section C.1.1 of RFC 7541
+			Expect(appendQPACKInt(nil, 5, 0xe0, 10)).To(Equal([]byte{0xea}))
+		})
+
+		It("encodes integers that don't fit into the prefix", func() {
+			// example from section C.1.2 of RFC 7541
+			Expect(appendQPACKInt(nil, 5, 0, 1337)).To(Equal([]byte{0x1f, 0x9a, 0x0a}))
+		})
+
+		It("reads integers", func() {
+			for _, n := range []uint8{3, 4, 5, 6, 7, 8} {
+				for _, v := range []uint64{0, 1, 1<<n - 2, 1<<n - 1, 1 << n, 1337, 1 << 40} {
+					b := appendQPACKInt(nil, n, 0, v)
+					r := bytes.NewReader(b[1:])
+					val, err := readQPACKInt(r, b[0], n)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(val).To(Equal(v))
+					Expect(r.Len()).To(BeZero())
+				}
+			}
+		})
+
+		It("errors on integers that overflow", func() {
+			b := []byte{0x1f}
+			for i := 0; i < 10; i++ {
+				b = append(b, 0xff)
+			}
+			b = append(b, 0x1)
+			_, err := readQPACKInt(bytes.NewReader(b[1:]), b[0], 5)
+			Expect(err).To(MatchError(errQPACKIntegerOverflow))
+		})
+	})
+
+	Context("strings", func() {
+		It("uses Huffman encoding if it makes the string shorter", func() {
+			b := appendQPACKString(nil, 7, 0, "www.example.com")
+			Expect(b[0] & 0x80).ToNot(BeZero())
+			Expect(len(b)).To(BeNumerically("<", 1+len("www.example.com")))
+			r := bytes.NewReader(b[1:])
+			s, err := readQPACKString(r, b[0], 7, 100)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(s).To(Equal("www.example.com"))
+		})
+
+		It("doesn't use Huffman encoding if it doesn't make the string shorter", func() {
+			b := appendQPACKString(nil, 3, 0x20, "\x00\x01")
+			Expect(b).To(Equal([]byte{0x22, 0x00, 0x01}))
+			s, err := readQPACKString(bytes.NewReader(b[1:]), b[0], 3, 100)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(s).To(Equal("\x00\x01"))
+		})
+
+		It("rejects strings that are too long", func() {
+			b := appendQPACKString(nil, 7, 0, "foobar")
+			_, err := readQPACKString(bytes.NewReader(b[1:]), b[0], 7, 3)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("dynamic table", func() {
+		It("evicts the oldest entries", func() {
+			t := &qpackDynamicTable{capacity: 100}
+			Expect(t.evictUntil(38)).To(Succeed())
+			t.insert(qpack.HeaderField{Name: "foo", Value: "bar"}) // 38 bytes
+			Expect(t.evictUntil(38)).To(Succeed())
+			t.insert(qpack.HeaderField{Name: "bar", Value: "baz"})
+			Expect(t.insertCount()).To(BeEquivalentTo(2))
+			Expect(t.evictUntil(38)).To(Succeed())
+			t.insert(qpack.HeaderField{Name: "baz", Value: "foo"})
+			Expect(t.insertCount()).To(BeEquivalentTo(3))
+			Expect(t.size).To(BeEquivalentTo(76))
+			_, ok := t.get(0)
+			Expect(ok).To(BeFalse())
+			hf, ok := t.get(2)
+			Expect(ok).To(BeTrue())
+			Expect(hf).To(Equal(qpack.HeaderField{Name: "baz", Value: "foo"}))
+			_, ok = t.get(3)
+			Expect(ok).To(BeFalse())
+		})
+
+		It("rejects entries larger than the capacity", func() {
+			t := &qpackDynamicTable{capacity: 40}
+			Expect(t.evictUntil(41)).To(MatchError("entry too large for the dynamic table: 41 bytes (capacity: 40 bytes)"))
+		})
+	})
+})
@@ -7,12 +7,11 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
-	"sync"
 
 	"golang.org/x/net/http/httpguts"
-	"golang.org/x/net/http2/hpack"
 	"golang.org/x/net/idna"
 
 	"github.com/quic-go/qpack"
@@ -23,71 +22,122 @@ import (
 const bodyCopyBufferSize = 8 * 1024
 
 type requestWriter struct {
-	mutex     sync.Mutex
-	encoder   *qpack.Encoder
-	headerBuf *bytes.Buffer
+	encoder *qpackEncoder
 
 	logger utils.Logger
 }
 
-func newRequestWriter(logger utils.Logger) *requestWriter {
-	headerBuf := &bytes.Buffer{}
-	encoder := qpack.NewEncoder(headerBuf)
+func newRequestWriter(encoder *qpackEncoder, logger utils.Logger) *requestWriter {
 	return &requestWriter{
-		encoder:   encoder,
-		headerBuf: headerBuf,
-		logger:    logger,
+		encoder: encoder,
+		logger:  logger,
 	}
 }
 
 func (w *requestWriter) WriteRequestHeader(str quic.Stream, req *http.Request, gzip bool) error {
-	// TODO: figure out how to add support for trailers
 	buf := &bytes.Buffer{}
-	if err := w.writeHeaders(buf, req, gzip); err != nil {
+	if err := w.writeHeaders(buf, str.StreamID(), req, gzip); err != nil {
 		return err
 	}
 	_, err := str.Write(buf.Bytes())
 	return err
 }
 
-func (w *requestWriter) writeHeaders(wr io.Writer, req *http.Request, gzip bool) error {
-	w.mutex.Lock()
-	defer w.mutex.Unlock()
-	defer w.encoder.Close()
-	defer w.headerBuf.Reset()
+func (w *requestWriter) writeHeaders(wr io.Writer, streamID quic.StreamID, req *http.Request, gzip bool) error {
+	trailers, err := commaSeparatedTrailers(req)
+	if err != nil {
+		return err
+	}
+	fields, err := w.encodeHeaders(req, gzip, trailers, actualContentLength(req))
+	if err != nil {
+		return err
+	}
+	return w.writeFieldSection(wr, streamID, fields)
+}
 
-	if err := w.encodeHeaders(req, gzip, "", actualContentLength(req)); err != nil {
+// WriteRequestTrailer writes the trailers of the request, see section 4.1 of RFC 9114.
+// It must only be called after the request body was sent.
+func (w *requestWriter) WriteRequestTrailer(wr io.Writer, streamID quic.StreamID, req *http.Request) error {
+	for k, vv := range req.Trailer {
+		if !httpguts.ValidHeaderFieldName(k) {
+			return fmt.Errorf("invalid HTTP trailer name %q", k)
+		}
+		for _, v := range vv {
+			if !httpguts.ValidHeaderFieldValue(v) {
+				return fmt.Errorf("invalid HTTP trailer value %q for trailer %q", v, k)
+			}
+		}
+	}
+	var fields []qpack.HeaderField
+	for k, vv := range req.Trailer {
+		for _, v := range vv {
+			fields = append(fields, qpack.HeaderField{Name: strings.ToLower(k), Value: v})
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	if err := w.encoder.CheckFieldSectionSize(fieldSectionSize(fields)); err != nil {
 		return err
 	}
+	return w.writeFieldSection(wr, streamID, fields)
+}
 
+// writeFieldSection encodes the fields and writes them in a HEADERS frame.
+func (w *requestWriter) writeFieldSection(wr io.Writer, streamID quic.StreamID, fields []qpack.HeaderField) error {
+	payload := w.encoder.Encode(streamID, fields)
 	b := make([]byte, 0, 128)
-	b = (&headersFrame{Length: uint64(w.headerBuf.Len())}).Append(b)
+	b = (&headersFrame{Length: uint64(len(payload))}).Append(b)
 	if _, err := wr.Write(b); err != nil {
 		return err
 	}
-	_, err := wr.Write(w.headerBuf.Bytes())
+	_, err := wr.Write(payload)
 	return err
 }
 
+// copied from net/http2/transport.go
+func commaSeparatedTrailers(req *http.Request) (string, error) {
+	keys := make([]string, 0, len(req.Trailer))
+	for k := range req.Trailer {
+		k = http.CanonicalHeaderKey(k)
+		switch k {
+		case "Transfer-Encoding", "Trailer", "Content-Length":
+			return "", fmt.Errorf("invalid Trailer key %q", k)
+		}
+		keys = append(keys, k)
+	}
+	if len(keys) > 0 {
+		sort.Strings(keys)
+		return strings.Join(keys, ","), nil
+	}
+	return "", nil
+}
+
+// isExtendedConnectRequest says if the request is an Extended CONNECT request, see RFC 9220.
+// The protocol is taken from the Proto field.
+func isExtendedConnectRequest(req *http.Request) bool {
+	// http.NewRequest sets this field to HTTP/1.1
+	return req.Method == http.MethodConnect && req.Proto != "" && req.Proto != "HTTP/1.1"
+}
+
 // copied from net/transport.go
 // Modified to support Extended CONNECT:
 // Contrary to what the godoc for the http.Request says,
 // we do respect the Proto field if the method is CONNECT.
-func (w *requestWriter) encodeHeaders(req *http.Request, addGzipHeader bool, trailers string, contentLength int64) error {
+func (w *requestWriter) encodeHeaders(req *http.Request, addGzipHeader bool, trailers string, contentLength int64) ([]qpack.HeaderField, error) {
 	host := req.Host
 	if host == "" {
 		host = req.URL.Host
 	}
 	host, err := httpguts.PunycodeHostPort(host)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if !httpguts.ValidHostHeader(host) {
-		return errors.New("http3: invalid Host header")
+		return nil, errors.New("http3: invalid Host header")
 	}
 
-	// http.NewRequest sets this field to HTTP/1.1
-	isExtendedConnect := req.Method == http.MethodConnect && req.Proto != "" && req.Proto != "HTTP/1.1"
+	isExtendedConnect := isExtendedConnectRequest(req)
 
 	var path string
 	if req.Method != http.MethodConnect || isExtendedConnect {
@@ -97,9 +147,9 @@ func (w *requestWriter) encodeHeaders(req *http.Request, addGzipHeader bool, tra
 			path = strings.TrimPrefix(path, req.URL.Scheme+"://"+host)
 			if !validPseudoPath(path) {
 				if req.URL.Opaque != "" {
-					return fmt.Errorf("invalid request :path %q from URL.Opaque = %q", orig, req.URL.Opaque)
+					return nil, fmt.Errorf("invalid request :path %q from URL.Opaque = %q", orig, req.URL.Opaque)
 				} else {
-					return fmt.Errorf("invalid request :path %q", orig)
+					return nil, fmt.Errorf("invalid request :path %q", orig)
 				}
 			}
 		}
@@ -110,11 +160,11 @@ func (w *requestWriter) encodeHeaders(req *http.Request, addGzipHeader bool, tra
 	// continue to reuse the hpack encoder for future requests)
 	for k, vv := range req.Header {
 		if !httpguts.ValidHeaderFieldName(k) {
-			return fmt.Errorf("invalid HTTP header name %q", k)
+			return nil, fmt.Errorf("invalid HTTP header name %q", k)
 		}
 		for _, v := range vv {
 			if !httpguts.ValidHeaderFieldValue(v) {
-				return fmt.Errorf("invalid HTTP header value %q for header %q", v, k)
+				return nil, fmt.Errorf("invalid HTTP header value %q for header %q", v, k)
 			}
 		}
 	}
@@ -184,33 +234,31 @@ func (w *requestWriter) encodeHeaders(req *http.Request, addGzipHeader bool, tra
 	}
 
 	// Do a first pass over the headers counting bytes to ensure
-	// we don't exceed cc.peerMaxHeaderListSize. This is done as a
-	// separate pass before encoding the headers to prevent
-	// modifying the hpack state.
-	hlSize := uint64(0)
+	// we don't exceed the peer's SETTINGS_MAX_FIELD_SECTION_SIZE.
+	// This is done as a separate pass before encoding the headers
+	// to prevent modifying the QPACK state.
+	var hlSize uint64
 	enumerateHeaders(func(name, value string) {
-		hf := hpack.HeaderField{Name: name, Value: value}
-		hlSize += uint64(hf.Size())
+		hlSize += qpackEntrySize(qpack.HeaderField{Name: name, Value: value})
 	})
-
-	// TODO: check maximum header list size
-	// if hlSize > cc.peerMaxHeaderListSize {
-	// 	return errRequestHeaderListSize
-	// }
+	if err := w.encoder.CheckFieldSectionSize(hlSize); err != nil {
+		return nil, err
+	}
 
 	// trace := httptrace.ContextClientTrace(req.Context())
 	// traceHeaders := traceHasWroteHeaderField(trace)
 
 	// Header list size is ok. Write the headers.
+	var fields []qpack.HeaderField
 	enumerateHeaders(func(name, value string) {
 		name = strings.ToLower(name)
-		w.encoder.WriteField(qpack.HeaderField{Name: name, Value: value})
+		fields = append(fields, qpack.HeaderField{Name: name, Value: value})
 		// if traceHeaders {
 		// 	traceWroteHeaderField(trace, name, value)
 		// }
 	})
 
-	return nil
+	return fields, nil
 }
 
 // authorityAddr returns a given authority (a host/IP, or host:port / ip:port)
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"io"
 	"net/http"
+	"strings"
 
 	mockquic "github.com/quic-go/quic-go/internal/mocks/quic"
 	"github.com/quic-go/quic-go/internal/utils"
@@ -41,10 +42,11 @@ var _ = Describe("Request Writer", func() {
 	}
 
 	BeforeEach(func() {
-		rw = newRequestWriter(utils.DefaultLogger)
+		rw = newRequestWriter(newQPACKEncoder(0, nil), utils.DefaultLogger)
 		strBuf = &bytes.Buffer{}
 		str = mockquic.NewMockStream(mockCtrl)
 		str.EXPECT().Write(gomock.Any()).DoAndReturn(strBuf.Write).AnyTimes()
+		str.EXPECT().StreamID().AnyTimes()
 	})
 
 	It("writes a GET request", func() {
@@ -116,4 +118,69 @@ var _ = Describe("Request Writer", func() {
 		Expect(headerFields).To(HaveKeyWithValue(":scheme", "https"))
 		Expect(headerFields).To(HaveKeyWithValue(":protocol", "webtransport"))
 	})
+	It("rejects headers exceeding the peer's SETTINGS_MAX_FIELD_SECTION_SIZE", func() {
+		encoder := newQPACKEncoder(0, nil)
+		encoder.HandleSettings(0, 300)
+		rw = newRequestWriter(encoder, utils.DefaultLogger)
+		req, err := http.NewRequest(http.MethodGet, "https://quic.clemente.io/", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rw.WriteRequestHeader(str, req, false)).To(Succeed())
+		Expect(strBuf.Len()).ToNot(BeZero())
+		strBuf.Reset()
+		req.Header.Set("Foo", strings.Repeat("a", 100))
+		Expect(rw.WriteRequestHeader(str, req, false)).To(MatchError(ContainSubstring("http3: field section too large")))
+		Expect(strBuf.Len()).To(BeZero())
+	})
+
+	Context("trailers", func() {
+		It("announces the trailers in the Trailer header", func() {
+			req, err := http.NewRequest(http.MethodPost, "https://quic.clemente.io/", nil)
+			Expect(err).ToNot(HaveOccurred())
+			req.Trailer = http.Header{"Foo": nil, "grpc-status": nil}
+			Expect(rw.WriteRequestHeader(str, req, false)).To(Succeed())
+			headerFields := decode(strBuf)
+			Expect(headerFields).To(HaveKeyWithValue("trailer", "Foo,Grpc-Status"))
+		})
+
+		It("rejects trailers that are not allowed", func() {
+			req, err := http.NewRequest(http.MethodPost, "https://quic.clemente.io/", nil)
+			Expect(err).ToNot(HaveOccurred())
+			req.Trailer = http.Header{"Content-Length": nil}
+			Expect(rw.WriteRequestHeader(str, req, false)).To(MatchError(`invalid Trailer key "Content-Length"`))
+		})
+
+		It("writes the trailers", func() {
+			req, err := http.NewRequest(http.MethodPost, "https://quic.clemente.io/", nil)
+			Expect(err).ToNot(HaveOccurred())
+			req.Trailer = http.Header{"Foo": []string{"bar"}}
+			Expect(rw.WriteRequestTrailer(str, 0, req)).To(Succeed())
+			Expect(decode(strBuf)).To(Equal(map[string]string{"foo": "bar"}))
+		})
+
+		It("doesn't write anything if no trailer values were set", func() {
+			req, err := http.NewRequest(http.MethodPost, "https://quic.clemente.io/", nil)
+			Expect(err).ToNot(HaveOccurred())
+			req.Trailer = http.Header{"Foo": nil}
+			Expect(rw.WriteRequestTrailer(str, 0, req)).To(Succeed())
+			Expect(strBuf.Len()).To(BeZero())
+		})
+
+		It("rejects trailers exceeding the peer's SETTINGS_MAX_FIELD_SECTION_SIZE", func() {
+			encoder := newQPACKEncoder(0, nil)
+			encoder.HandleSettings(0, 100)
+			rw = newRequestWriter(encoder, utils.DefaultLogger)
+			req, err := http.NewRequest(http.MethodPost, "https://quic.clemente.io/", nil)
+			Expect(err).ToNot(HaveOccurred())
+			req.Trailer = http.Header{"Foo": []string{strings.Repeat("a", 100)}}
+			Expect(rw.WriteRequestTrailer(str, 0, req)).To(MatchError("http3: field section too large: 135 bytes (peer's limit: 100 bytes)"))
+			Expect(strBuf.Len()).To(BeZero())
+		})
+
+		It("rejects invalid trailer values", func() {
+			req, err := http.NewRequest(http.MethodPost, "https://quic.clemente.io/", nil)
+			Expect(err).ToNot(HaveOccurred())
+			req.Trailer = http.Header{"Foo": []string{"bar\r\n"}}
+			Expect(rw.WriteRequestTrailer(str, 0, req)).To(MatchError(`invalid HTTP trailer value "bar\r\n" for trailer "Foo"`))
+		})
+	})
 })
@@ -2,13 +2,17 @@ package http3
 
 import (
 	"bufio"
-	"bytes"
+	"context"
 	"fmt"
 	"net/http"
+	"net/textproto"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"golang.org/x/net/http/httpguts"
+
 	"github.com/quic-go/quic-go"
 	"github.com/quic-go/quic-go/internal/utils"
 
@@ -22,38 +26,67 @@ const frameHeaderLen = 16
 // headerWriter wraps the stream, so that the first Write call flushes the header to the stream
 type headerWriter struct {
 	str     quic.Stream
+	encoder *qpackEncoder
 	header  http.Header
-	status  int // status code passed to WriteHeader
+	status  int // the final (non-1xx) status code passed to WriteHeader
 	written bool
 
+	trailers []string // the trailers announced in the Trailer header field
+
 	logger utils.Logger
 }
 
 // writeHeader encodes and flush header to the stream
-func (hw *headerWriter) writeHeader() error {
-	var headers bytes.Buffer
-	enc := qpack.NewEncoder(&headers)
-	enc.WriteField(qpack.HeaderField{Name: ":status", Value: strconv.Itoa(hw.status)})
-
+func (hw *headerWriter) writeHeader(status int) error {
+	var fields []qpack.HeaderField
 	for k, v := range hw.header {
+		// trailers are sent after the body
+		if strings.HasPrefix(k, http.TrailerPrefix) || hw.isTrailer(k) {
+			continue
+		}
 		for index := range v {
-			enc.WriteField(qpack.HeaderField{Name: strings.ToLower(k), Value: v[index]})
+			fields = append(fields, qpack.HeaderField{Name: strings.ToLower(k), Value: v[index]})
+		}
+	}
+	return hw.writeHeaderFields(status, fields)
+}
+
+// writeHeaderFields encodes the status and the header fields, and writes them to the stream in a HEADERS frame
+func (hw *headerWriter) writeHeaderFields(status int, hdrFields []qpack.HeaderField) error {
+	fields := make([]qpack.HeaderField, 0, 1+len(hdrFields))
+	fields = append(fields, qpack.HeaderField{Name: ":status", Value: strconv.Itoa(status)})
+	fields = append(fields, hdrFields...)
+	if err := hw.encoder.CheckFieldSectionSize(fieldSectionSize(fields)); err != nil {
+		// Without the final response header, the client can't make sense of the response.
+		if status >= 200 {
+			hw.str.CancelWrite(quic.StreamErrorCode(ErrCodeInternalError))
 		}
+		return err
 	}
+	headers := hw.encoder.Encode(hw.str.StreamID(), fields)
 
-	buf := make([]byte, 0, frameHeaderLen+headers.Len())
-	buf = (&headersFrame{Length: uint64(headers.Len())}).Append(buf)
-	hw.logger.Infof("Responding with %d", hw.status)
-	buf = append(buf, headers.Bytes()...)
+	buf := make([]byte, 0, frameHeaderLen+len(headers))
+	buf = (&headersFrame{Length: uint64(len(headers))}).Append(buf)
+	hw.logger.Infof("Responding with %d", status)
+	buf = append(buf, headers...)
 
 	_, err := hw.str.Write(buf)
 	return err
 }
 
+func (hw *headerWriter) isTrailer(k string) bool {
+	for _, t := range hw.trailers {
+		if t == k {
+			return true
+		}
+	}
+	return false
+}
+
 // first Write will trigger flushing header
 func (hw *headerWriter) Write(p []byte) (int, error) {
 	if !hw.written {
-		if err := hw.writeHeader(); err != nil {
+		if err := hw.writeHeader(hw.status); err != nil {
 			return 0, err
 		}
 		hw.written = true
@@ -63,13 +96,21 @@ func (hw *headerWriter) Write(p []byte) (int, error) {
 
 type responseWriter struct {
 	*headerWriter
-	conn        quic.Connection
+	conn       quic.Connection
+	priorities *priorityTracker
+	datagrams  *streamDatagrammer
+	// the request body of a CONNECT request, used to take over the stream for a tunnel
+	connectBody *body
 	bufferedStr *bufio.Writer
 	buf         []byte
 
+	// The 100 Continue response is written from the goroutine reading the request body.
+	// writeContinueMu serializes it with WriteHeader, which Write and FlushError call before writing to the stream.
+	writeContinueMu sync.Mutex
+
 	contentLen    int64 // if handler set valid Content-Length header
 	numWritten    int64 // bytes written
-	headerWritten bool
+	headerWritten bool  // only set while holding writeContinueMu
 	isHead        bool
 }
 
@@ -77,18 +118,23 @@ var (
 	_ http.ResponseWriter = &responseWriter{}
 	_ http.Flusher        = &responseWriter{}
 	_ Hijacker            = &responseWriter{}
+	_ PrioritySetter      = &responseWriter{}
+	_ HTTPDatagrammer     = &responseWriter{}
+	_ Tunneler            = &responseWriter{}
 )
 
-func newResponseWriter(str quic.Stream, conn quic.Connection, logger utils.Logger) *responseWriter {
+func newResponseWriter(str quic.Stream, conn quic.Connection, encoder *qpackEncoder, priorities *priorityTracker, logger utils.Logger) *responseWriter {
 	hw := &headerWriter{
-		str:    str,
-		header: http.Header{},
-		logger: logger,
+		str:     str,
+		encoder: encoder,
+		header:  http.Header{},
+		logger:  logger,
 	}
 	return &responseWriter{
 		headerWriter: hw,
 		buf:          make([]byte, frameHeaderLen),
 		conn:         conn,
+		priorities:   priorities,
 		bufferedStr:  bufio.NewWriter(hw),
 	}
 }
@@ -98,6 +144,9 @@ func (w *responseWriter) Header() http.Header {
 }
 
 func (w *responseWriter) WriteHeader(status int) {
+	w.writeContinueMu.Lock()
+	defer w.writeContinueMu.Unlock()
+
 	if w.headerWritten {
 		return
 	}
@@ -107,30 +156,58 @@ func (w *responseWriter) WriteHeader(status int) {
 		panic(fmt.Sprintf("invalid WriteHeader code %v", status))
 	}
 
-	if status >= 200 {
-		w.headerWritten = true
-		// Add Date header.
-		// This is what the standard library does.
-		// Can be disabled by setting the Date header to nil.
-		if _, ok := w.header["Date"]; !ok {
-			w.header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	// The 101 status code is not applicable to HTTP/3, see section 4.5 of RFC 9114.
+	if status == http.StatusSwitchingProtocols {
+		w.logger.Errorf("ignoring WriteHeader with status code %d", status)
+		return
+	}
+
+	// Interim (1xx) responses are sent immediately.
+	// Any number of them can be sent before the final response.
+	if status < 200 {
+		if err := w.writeHeader(status); err != nil {
+			w.logger.Errorf("could not write interim response: %s", err.Error())
 		}
-		// Content-Length checking
-		// use ParseUint instead of ParseInt, as negative values are invalid
-		if clen := w.header.Get("Content-Length"); clen != "" {
-			if cl, err := strconv.ParseUint(clen, 10, 63); err == nil {
-				w.contentLen = int64(cl)
-			} else {
-				// emit a warning for malformed Content-Length and remove it
-				w.logger.Errorf("Malformed Content-Length %s", clen)
-				w.header.Del("Content-Length")
-			}
+		return
+	}
+
+	w.headerWritten = true
+	// Add Date header.
+	// This is what the standard library does.
+	// Can be disabled by setting the Date header to nil.
+	if _, ok := w.header["Date"]; !ok {
+		w.header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	}
+	for _, v := range w.header["Trailer"] {
+		for _, k := range strings.Split(v, ",") {
+			w.declareTrailer(http.CanonicalHeaderKey(textproto.TrimString(k)))
+		}
+	}
+	// Content-Length checking
+	// use ParseUint instead of ParseInt, as negative values are invalid
+	if clen := w.header.Get("Content-Length"); clen != "" {
+		if cl, err := strconv.ParseUint(clen, 10, 63); err == nil {
+			w.contentLen = int64(cl)
+		} else {
+			// emit a warning for malformed Content-Length and remove it
+			w.logger.Errorf("Malformed Content-Length %s", clen)
+			w.header.Del("Content-Length")
 		}
 	}
 	w.status = status
+}
 
-	if !w.headerWritten {
-		w.writeHeader()
+// writeContinue sends a 100 Continue response, unless the final response header was already written.
+func (w *responseWriter) writeContinue() {
+	w.writeContinueMu.Lock()
+	defer w.writeContinueMu.Unlock()
+
+	if w.headerWritten {
+		return
+	}
+	// The header fields set by the handler belong to the final response.
+	if err := w.writeHeaderFields(http.StatusContinue, nil); err != nil {
+		w.logger.Debugf("could not write 100 Continue response: %s", err.Error())
 	}
 }
 
@@ -182,7 +259,7 @@ func (w *responseWriter) FlushError() error {
 		w.WriteHeader(http.StatusOK)
 	}
 	if !w.written {
-		if err := w.writeHeader(); err != nil {
+		if err := w.writeHeader(w.status); err != nil {
 			return maybeReplaceError(err)
 		}
 		w.written = true
@@ -196,10 +273,83 @@ func (w *responseWriter) Flush() {
 	}
 }
 
+func (w *responseWriter) declareTrailer(k string) {
+	if k == "" {
+		return
+	}
+	if !httpguts.ValidTrailerHeader(k) {
+		// Forbidden by RFC 9110, section 6.5.1.
+		w.logger.Debugf("ignoring invalid trailer %q", k)
+		return
+	}
+	if !w.isTrailer(k) {
+		w.trailers = append(w.trailers, k)
+	}
+}
+
+// writeTrailers sends the trailers in a HEADERS frame after the body, see section 4.1 of RFC 9114.
+// Trailers are either announced in the Trailer header field before the header is written,
+// or set using keys with the http.TrailerPrefix.
+func (w *responseWriter) writeTrailers() error {
+	for k, vv := range w.header {
+		if !strings.HasPrefix(k, http.TrailerPrefix) {
+			continue
+		}
+		trailerKey := http.CanonicalHeaderKey(strings.TrimPrefix(k, http.TrailerPrefix))
+		w.declareTrailer(trailerKey)
+		w.header[trailerKey] = vv
+	}
+	if len(w.trailers) == 0 || w.isHead {
+		return nil
+	}
+
+	var fields []qpack.HeaderField
+	for _, k := range w.trailers {
+		for _, v := range w.header[k] {
+			fields = append(fields, qpack.HeaderField{Name: strings.ToLower(k), Value: v})
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	if err := w.encoder.CheckFieldSectionSize(fieldSectionSize(fields)); err != nil {
+		return err
+	}
+	headers := w.encoder.Encode(w.str.StreamID(), fields)
+	buf := make([]byte, 0, frameHeaderLen+len(headers))
+	buf = (&headersFrame{Length: uint64(len(headers))}).Append(buf)
+	buf = append(buf, headers...)
+	_, err := w.str.Write(buf)
+	return maybeReplaceError(err)
+}
+
 func (w *responseWriter) StreamCreator() StreamCreator {
 	return w.conn
 }
 
+// SetPriority sets the priority of the response.
+// It overrides the priority signaled by the client.
+func (w *responseWriter) SetPriority(p Priority) {
+	w.priorities.SetPriority(w.str, p)
+}
+
+// SendDatagram sends an HTTP Datagram associated with the request.
+// If QUIC datagrams are not available, it writes a DATAGRAM capsule to the response body.
+func (w *responseWriter) SendDatagram(b []byte) error {
+	if w.datagrams == nil {
+		return errDatagramsNotSupported
+	}
+	return w.datagrams.SendDatagram(b)
+}
+
+// ReceiveDatagram receives an HTTP Datagram associated with the request.
+func (w *responseWriter) ReceiveDatagram(ctx context.Context) ([]byte, error) {
+	if w.datagrams == nil {
+		return nil, errDatagramsNotSupported
+	}
+	return w.datagrams.ReceiveDatagram(ctx)
+}
+
 func (w *responseWriter) SetReadDeadline(deadline time.Time) error {
 	return w.str.SetReadDeadline(deadline)
 }
@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/quic-go/quic-go"
 	mockquic "github.com/quic-go/quic-go/internal/mocks/quic"
 	"github.com/quic-go/quic-go/internal/utils"
 
@@ -26,9 +28,10 @@ var _ = Describe("Response Writer", func() {
 		strBuf = &bytes.Buffer{}
 		str := mockquic.NewMockStream(mockCtrl)
 		str.EXPECT().Write(gomock.Any()).DoAndReturn(strBuf.Write).AnyTimes()
+		str.EXPECT().StreamID().AnyTimes()
 		str.EXPECT().SetReadDeadline(gomock.Any()).Return(nil).AnyTimes()
 		str.EXPECT().SetWriteDeadline(gomock.Any()).Return(nil).AnyTimes()
-		rw = newResponseWriter(str, nil, utils.DefaultLogger)
+		rw = newResponseWriter(str, nil, newQPACKEncoder(0, nil), nil, utils.DefaultLogger)
 	})
 
 	decodeHeader := func(str io.Reader) map[string][]string {
@@ -139,14 +142,62 @@ var _ = Describe("Response Writer", func() {
 
 		// According to the spec, headers sent in the informational response must also be included in the final response
 		fields = decodeHeader(strBuf)
-		Expect(fields).To(HaveLen(3))
+		Expect(fields).To(HaveLen(4))
 		Expect(fields).To(HaveKeyWithValue(":status", []string{"200"}))
 		Expect(fields).To(HaveKey("date"))
+		Expect(fields).To(HaveKeyWithValue("content-type", []string{"text/plain; charset=utf-8"}))
 		Expect(fields).To(HaveKeyWithValue("link", []string{"</style.css>; rel=preload; as=style", "</script.js>; rel=preload; as=script"}))
 
 		Expect(getData(strBuf)).To(Equal([]byte("foobar")))
 	})
 
+	It("sends multiple interim responses", func() {
+		rw.WriteHeader(http.StatusContinue)
+		rw.WriteHeader(http.StatusEarlyHints)
+		rw.WriteHeader(http.StatusEarlyHints)
+		rw.WriteHeader(http.StatusOK)
+		Expect(decodeHeader(strBuf)).To(HaveKeyWithValue(":status", []string{"100"}))
+		Expect(decodeHeader(strBuf)).To(HaveKeyWithValue(":status", []string{"103"}))
+		Expect(decodeHeader(strBuf)).To(HaveKeyWithValue(":status", []string{"103"}))
+		Expect(decodeHeader(strBuf)).To(HaveKeyWithValue(":status", []string{"200"}))
+	})
+
+	It("sends the 100 Continue response without the header fields of the final response", func() {
+		rw.Header().Set("Content-Type", "text/plain")
+		rw.writeContinue()
+		Expect(decodeHeader(strBuf)).To(Equal(map[string][]string{":status": {"100"}}))
+		rw.WriteHeader(http.StatusOK)
+		Expect(decodeHeader(strBuf)).To(HaveKeyWithValue("content-type", []string{"text/plain"}))
+	})
+
+	It("doesn't send the 100 Continue response if it exceeds the peer's SETTINGS_MAX_FIELD_SECTION_SIZE", func() {
+		encoder := newQPACKEncoder(0, nil)
+		encoder.HandleSettings(0, 1)
+		str := mockquic.NewMockStream(mockCtrl)
+		str.EXPECT().StreamID().AnyTimes()
+		rw = newResponseWriter(str, nil, encoder, nil, utils.DefaultLogger)
+		rw.writeContinue() // no calls to Write or CancelWrite expected
+	})
+
+	It("ignores the 101 status code", func() {
+		rw.WriteHeader(http.StatusSwitchingProtocols)
+		Expect(strBuf.Len()).To(BeZero())
+		rw.WriteHeader(http.StatusOK)
+		Expect(decodeHeader(strBuf)).To(HaveKeyWithValue(":status", []string{"200"}))
+	})
+
+	It("resets the stream if the header exceeds the peer's SETTINGS_MAX_FIELD_SECTION_SIZE", func() {
+		encoder := newQPACKEncoder(0, nil)
+		encoder.HandleSettings(0, 100)
+		str := mockquic.NewMockStream(mockCtrl)
+		str.EXPECT().StreamID().AnyTimes()
+		rw = newResponseWriter(str, nil, encoder, nil, utils.DefaultLogger)
+		rw.Header().Set("Foo", strings.Repeat("a", 100))
+		str.EXPECT().CancelWrite(quic.StreamErrorCode(ErrCodeInternalError))
+		rw.WriteHeader(http.StatusOK)
+		Expect(rw.FlushError()).To(MatchError(ContainSubstring("http3: field section too large")))
+	})
+
 	It("doesn't allow writes if the status code doesn't allow a body", func() {
 		rw.WriteHeader(304)
 		n, err := rw.Write([]byte("foobar"))
@@ -163,6 +214,21 @@ var _ = Describe("Response Writer", func() {
 		Expect(fields).To(HaveKeyWithValue("content-type", []string{"text/html; charset=utf-8"}))
 	})
 
+	It("sniffs the Content-Type after sending an interim response", func() {
+		rw.WriteHeader(http.StatusEarlyHints)
+		n, err := rw.Write([]byte("<html></html>"))
+		Expect(n).To(Equal(13))
+		Expect(err).ToNot(HaveOccurred())
+
+		fields := decodeHeader(strBuf)
+		Expect(fields).To(HaveKeyWithValue(":status", []string{"103"}))
+		Expect(fields).ToNot(HaveKey("content-type"))
+		fields = decodeHeader(strBuf)
+		Expect(fields).To(HaveKeyWithValue(":status", []string{"200"}))
+		Expect(fields).To(HaveKeyWithValue("content-type", []string{"text/html; charset=utf-8"}))
+		Expect(getData(strBuf)).To(Equal([]byte("<html></html>")))
+	})
+
 	It(`is compatible with "net/http".ResponseController`, func() {
 		Expect(rw.SetReadDeadline(time.Now().Add(1 * time.Second))).To(BeNil())
 		Expect(rw.SetWriteDeadline(time.Now().Add(1 * time.Second))).To(BeNil())
@@ -179,6 +245,53 @@ var _ = Describe("Response Writer", func() {
 		Expect(err).To(Equal(http.ErrContentLength))
 	})
 
+	It("writes announced trailers after the body", func() {
+		rw.Header().Set("Trailer", "Foo, Bar")
+		rw.WriteHeader(http.StatusOK)
+		_, err := rw.Write([]byte("foobar"))
+		Expect(err).ToNot(HaveOccurred())
+		rw.Header().Set("Foo", "foo")
+		rw.Header().Set("Bar", "bar")
+		fields := decodeHeader(strBuf)
+		Expect(fields).To(HaveKeyWithValue("trailer", []string{"Foo, Bar"}))
+		Expect(fields).ToNot(HaveKey("foo"))
+		Expect(getData(strBuf)).To(Equal([]byte("foobar")))
+		Expect(rw.writeTrailers()).To(Succeed())
+		trailers := decodeHeader(strBuf)
+		Expect(trailers).To(HaveLen(2))
+		Expect(trailers).To(HaveKeyWithValue("foo", []string{"foo"}))
+		Expect(trailers).To(HaveKeyWithValue("bar", []string{"bar"}))
+	})
+
+	It("writes trailers set using the TrailerPrefix", func() {
+		rw.Header().Set(http.TrailerPrefix+"Foo", "foo")
+		_, err := rw.Write([]byte("foobar"))
+		Expect(err).ToNot(HaveOccurred())
+		fields := decodeHeader(strBuf)
+		Expect(fields).ToNot(HaveKey("foo"))
+		Expect(fields).ToNot(HaveKey(strings.ToLower(http.TrailerPrefix + "Foo")))
+		Expect(getData(strBuf)).To(Equal([]byte("foobar")))
+		Expect(rw.writeTrailers()).To(Succeed())
+		Expect(decodeHeader(strBuf)).To(Equal(map[string][]string{"foo": {"foo"}}))
+	})
+
+	It("ignores trailers that are not allowed", func() {
+		rw.Header().Set("Trailer", "Content-Length")
+		rw.WriteHeader(http.StatusOK)
+		decodeHeader(strBuf)
+		Expect(rw.writeTrailers()).To(Succeed())
+		Expect(strBuf.Len()).To(BeZero())
+	})
+
+	It("doesn't write trailers when none were set", func() {
+		_, err := rw.Write([]byte("foobar"))
+		Expect(err).ToNot(HaveOccurred())
+		decodeHeader(strBuf)
+		getData(strBuf)
+		Expect(rw.writeTrailers()).To(Succeed())
+		Expect(strBuf.Len()).To(BeZero())
+	})
+
 	It(`panics when writing invalid status`, func() {
 		Expect(func() { rw.WriteHeader(99) }).To(Panic())
 		Expect(func() { rw.WriteHeader(1000) }).To(Panic())
@@ -11,6 +11,7 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"golang.org/x/net/http/httpguts"
 
@@ -82,8 +83,19 @@ type RoundTripper struct {
 	// MaxResponseHeaderBytes specifies a limit on how many response bytes are
 	// allowed in the server's response header.
 	// Zero means to use a default limit.
+	// The limit is advertised to the server in SETTINGS_MAX_FIELD_SECTION_SIZE.
 	MaxResponseHeaderBytes int64
 
+	// QPACKMaxTableCapacity is the maximum capacity of the QPACK dynamic tables, see RFC 9204.
+	// It limits the table used to decode the response headers, as well as the table used to encode the request headers.
+	// If zero, a capacity of 4096 bytes is used. If negative, the dynamic table is not used.
+	QPACKMaxTableCapacity int
+
+	// ExpectContinueTimeout, if non-zero, specifies the amount of time to wait for the server's first response headers
+	// after sending the request headers, if the request has an "Expect: 100-continue" header.
+	// Zero means no timeout and causes the body to be sent immediately, without waiting for the server to approve.
+	ExpectContinueTimeout time.Duration
+
 	newClient func(hostname string, tlsConf *tls.Config, opts *roundTripperOpts, conf *quic.Config, dialer dialFunc) (roundTripCloser, error) // so we can mock it in tests
 	clients   map[string]*roundTripCloserWithCount
 	transport *quic.Transport
@@ -149,7 +161,19 @@ func (r *RoundTripper) RoundTripOpt(req *http.Request, opt RoundTripOpt) (*http.
 	defer cl.useCount.Add(-1)
 	rsp, err := cl.RoundTripOpt(req, opt)
 	if err != nil {
-		r.removeClient(hostname)
+		r.removeClient(hostname, cl)
+		// The server sent a GOAWAY frame (or rejected the request).
+		// The request wasn't processed, so it can be retried on a new connection.
+		switch err {
+		case errGoAway:
+			return r.RoundTripOpt(req, opt)
+		case errRequestNotProcessed:
+			newReq, rerr := rewindBody(req)
+			if rerr != nil {
+				return nil, err
+			}
+			return r.RoundTripOpt(newReq, opt)
+		}
 		if isReused {
 			if nerr, ok := err.(net.Error); ok && nerr.Timeout() {
 				return r.RoundTripOpt(req, opt)
@@ -159,6 +183,26 @@ func (r *RoundTripper) RoundTripOpt(req *http.Request, opt RoundTripOpt) (*http.
 	return rsp, err
 }
 
+var errCannotRewindBody = errors.New("http3: cannot rewind the request body for a retry")
+
+// rewindBody returns a request that can be used to retry a request.
+// If the request has a body, it is obtained using GetBody.
+func rewindBody(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	if req.GetBody == nil {
+		return nil, errCannotRewindBody
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	newReq := *req
+	newReq.Body = body
+	return &newReq, nil
+}
+
 // RoundTrip does a round trip.
 func (r *RoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 	return r.RoundTripOpt(req, RoundTripOpt{})
@@ -197,11 +241,13 @@ func (r *RoundTripper) getClient(hostname string, onlyCached bool) (rtc *roundTr
 			hostname,
 			r.TLSClientConfig,
 			&roundTripperOpts{
-				EnableDatagram:     r.EnableDatagrams,
-				DisableCompression: r.DisableCompression,
-				MaxHeaderBytes:     r.MaxResponseHeaderBytes,
-				StreamHijacker:     r.StreamHijacker,
-				UniStreamHijacker:  r.UniStreamHijacker,
+				EnableDatagram:        r.EnableDatagrams,
+				DisableCompression:    r.DisableCompression,
+				MaxHeaderBytes:        r.MaxResponseHeaderBytes,
+				QPACKMaxTableCapacity: r.QPACKMaxTableCapacity,
+				ExpectContinueTimeout: r.ExpectContinueTimeout,
+				StreamHijacker:        r.StreamHijacker,
+				UniStreamHijacker:     r.UniStreamHijacker,
 			},
 			r.QuicConfig,
 			dial,
@@ -218,10 +264,13 @@ func (r *RoundTripper) getClient(hostname string, onlyCached bool) (rtc *roundTr
 	return client, isReused, nil
 }
 
-func (r *RoundTripper) removeClient(hostname string) {
+// removeClient removes the client from the pool.
+// Another client might already have been created for the same host (e.g. after the server sent a GOAWAY frame),
+// in which case that client is kept.
+func (r *RoundTripper) removeClient(hostname string, cl *roundTripCloserWithCount) {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
-	if r.clients == nil {
+	if r.clients == nil || r.clients[hostname] != cl {
 		return
 	}
 	delete(r.clients, hostname)
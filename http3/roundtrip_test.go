@@ -194,6 +194,71 @@ var _ = Describe("RoundTripper", func() {
 			Expect(count).To(Equal(1))
 		})
 
+		It("retries a request on a new client after the server sent a GOAWAY frame", func() {
+			cl1 := NewMockRoundTripCloser(mockCtrl)
+			cl1.EXPECT().RoundTripOpt(gomock.Any(), gomock.Any()).Return(nil, errGoAway)
+			cl2 := NewMockRoundTripCloser(mockCtrl)
+			cl2.EXPECT().RoundTripOpt(gomock.Any(), gomock.Any()).DoAndReturn(func(req *http.Request, _ RoundTripOpt) (*http.Response, error) {
+				return &http.Response{Request: req}, nil
+			})
+			var count int
+			rt.newClient = func(string, *tls.Config, *roundTripperOpts, *quic.Config, dialFunc) (roundTripCloser, error) {
+				count++
+				if count == 1 {
+					return cl1, nil
+				}
+				return cl2, nil
+			}
+			rsp, err := rt.RoundTrip(req1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rsp.Request).To(Equal(req1))
+			Expect(count).To(Equal(2))
+		})
+
+		It("retries a request that the server didn't process, rewinding the body", func() {
+			req, err := http.NewRequest(http.MethodPost, "https://quic.clemente.io/upload", bytes.NewReader([]byte("foobar")))
+			Expect(err).ToNot(HaveOccurred())
+			cl1 := NewMockRoundTripCloser(mockCtrl)
+			cl1.EXPECT().RoundTripOpt(gomock.Any(), gomock.Any()).DoAndReturn(func(req *http.Request, _ RoundTripOpt) (*http.Response, error) {
+				io.ReadAll(req.Body)
+				return nil, errRequestNotProcessed
+			})
+			cl2 := NewMockRoundTripCloser(mockCtrl)
+			cl2.EXPECT().RoundTripOpt(gomock.Any(), gomock.Any()).DoAndReturn(func(req *http.Request, _ RoundTripOpt) (*http.Response, error) {
+				body, err := io.ReadAll(req.Body)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(body)).To(Equal("foobar"))
+				return &http.Response{Request: req}, nil
+			})
+			var count int
+			rt.newClient = func(string, *tls.Config, *roundTripperOpts, *quic.Config, dialFunc) (roundTripCloser, error) {
+				count++
+				if count == 1 {
+					return cl1, nil
+				}
+				return cl2, nil
+			}
+			_, err = rt.RoundTrip(req)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(2))
+		})
+
+		It("doesn't retry a request if the body can't be rewound", func() {
+			req, err := http.NewRequest(http.MethodPost, "https://quic.clemente.io/upload", bytes.NewReader([]byte("foobar")))
+			Expect(err).ToNot(HaveOccurred())
+			req.GetBody = nil
+			var count int
+			rt.newClient = func(string, *tls.Config, *roundTripperOpts, *quic.Config, dialFunc) (roundTripCloser, error) {
+				count++
+				cl := NewMockRoundTripCloser(mockCtrl)
+				cl.EXPECT().RoundTripOpt(gomock.Any(), gomock.Any()).Return(nil, errRequestNotProcessed)
+				return cl, nil
+			}
+			_, err = rt.RoundTrip(req)
+			Expect(err).To(MatchError(errRequestNotProcessed))
+			Expect(count).To(Equal(1))
+		})
+
 		It("handles a burst of requests", func() {
 			wait := make(chan struct{})
 			reqs := make(chan struct{}, 2)
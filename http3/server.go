@@ -12,14 +12,13 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/quic-go/quic-go"
 	"github.com/quic-go/quic-go/internal/protocol"
 	"github.com/quic-go/quic-go/internal/utils"
 	"github.com/quic-go/quic-go/quicvarint"
-
-	"github.com/quic-go/qpack"
 )
 
 // allows mocking of quic.Listen and quic.ListenAddr
@@ -180,8 +179,15 @@ type Server struct {
 	// read parsing the request HEADERS frame. It does not limit the size of
 	// the request body. If zero or negative, http.DefaultMaxHeaderBytes is
 	// used.
+	// The limit is advertised to the client in SETTINGS_MAX_FIELD_SECTION_SIZE,
+	// and requests with a larger header are rejected with a 431 status code.
 	MaxHeaderBytes int
 
+	// QPACKMaxTableCapacity is the maximum capacity of the QPACK dynamic tables, see RFC 9204.
+	// It limits the table used to decode the request headers, as well as the table used to encode the response headers.
+	// If zero, a capacity of 4096 bytes is used. If negative, the dynamic table is not used.
+	QPACKMaxTableCapacity int
+
 	// AdditionalSettings specifies additional HTTP/3 settings.
 	// It is invalid to specify any settings defined by the HTTP/3 draft and the datagram draft.
 	AdditionalSettings map[uint64]uint64
@@ -205,11 +211,30 @@ type Server struct {
 
 	closed bool
 
+	initOnce sync.Once
+	// canceled when CloseGracefully is called: no new requests are accepted after that
+	graceCtx    context.Context
+	graceCancel context.CancelFunc
+	// canceled when the timeout passed to CloseGracefully expires
+	closeCtx    context.Context
+	closeCancel context.CancelFunc
+
+	activeConns int
+	// closed when activeConns drops to zero, nil if nobody is waiting for that
+	noActiveConns chan struct{}
+
 	altSvcHeader string
 
 	logger utils.Logger
 }
 
+func (s *Server) init() {
+	s.initOnce.Do(func() {
+		s.graceCtx, s.graceCancel = context.WithCancel(context.Background())
+		s.closeCtx, s.closeCancel = context.WithCancel(context.Background())
+	})
+}
+
 // ListenAndServe listens on the UDP address s.Addr and calls s.Handler to handle HTTP/3 requests on incoming connections.
 //
 // If s.Addr is blank, ":https" is used.
@@ -257,8 +282,9 @@ func (s *Server) ServeQUICConn(conn quic.Connection) error {
 // Make sure you use http3.ConfigureTLSConfig to configure a tls.Config
 // and use it to construct a http3-friendly QUIC listener.
 // Closing the server does close the listener.
-// ServeListener always returns a non-nil error. After Shutdown or Close, the returned error is http.ErrServerClosed.
+// ServeListener always returns a non-nil error. After CloseGracefully or Close, the returned error is http.ErrServerClosed.
 func (s *Server) ServeListener(ln QUICEarlyListener) error {
+	s.init()
 	if err := s.addListener(&ln); err != nil {
 		return err
 	}
@@ -271,6 +297,12 @@ func (s *Server) ServeListener(ln QUICEarlyListener) error {
 		if err != nil {
 			return err
 		}
+		// When shutting down gracefully, the listener is only closed once all connections were closed.
+		// New connections are refused in the meantime.
+		if s.graceCtx.Err() != nil {
+			conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeNoError), "")
+			continue
+		}
 		go func() {
 			if err := s.handleConn(conn); err != nil {
 				s.logger.Debugf(err.Error())
@@ -424,34 +456,108 @@ func (s *Server) removeListener(l *QUICEarlyListener) {
 	s.generateAltSvcHeader()
 }
 
+func (s *Server) trackConn() {
+	s.mutex.Lock()
+	s.activeConns++
+	s.mutex.Unlock()
+}
+
+func (s *Server) untrackConn() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.activeConns--
+	if s.activeConns == 0 && s.noActiveConns != nil {
+		close(s.noActiveConns)
+		s.noActiveConns = nil
+	}
+}
+
+// waitForConns waits until all connections were closed, or until the context is canceled.
+func (s *Server) waitForConns(ctx context.Context) {
+	s.mutex.Lock()
+	if s.activeConns == 0 {
+		s.mutex.Unlock()
+		return
+	}
+	if s.noActiveConns == nil {
+		s.noActiveConns = make(chan struct{})
+	}
+	noActiveConns := s.noActiveConns
+	s.mutex.Unlock()
+
+	select {
+	case <-noActiveConns:
+	case <-ctx.Done():
+	}
+}
+
 func (s *Server) handleConn(conn quic.Connection) error {
-	decoder := qpack.NewDecoder(nil)
+	s.init()
+	s.trackConn()
+	defer s.untrackConn()
+
+	maxTableCapacity := qpackTableCapacity(s.QPACKMaxTableCapacity)
+	var maxBlockedStreams uint64
+	if maxTableCapacity > 0 {
+		maxBlockedStreams = qpackMaxBlockedStreams
+	}
+	openUniStream := func() (quic.SendStream, error) { return conn.OpenUniStream() }
+	decoder := newQPACKDecoder(maxTableCapacity, maxBlockedStreams, openUniStream)
+	encoder := newQPACKEncoder(maxTableCapacity, openUniStream)
 
 	// send a SETTINGS frame
-	str, err := conn.OpenUniStream()
+	ctrlStr, err := conn.OpenUniStream()
 	if err != nil {
 		return fmt.Errorf("opening the control stream failed: %w", err)
 	}
 	b := make([]byte, 0, 64)
 	b = quicvarint.Append(b, streamTypeControlStream) // stream type
-	b = (&settingsFrame{Datagram: s.EnableDatagrams, Other: s.AdditionalSettings}).Append(b)
-	str.Write(b)
+	// Extended CONNECT is always enabled, it's up to the handler to accept or reject the :protocol.
+	b = (&settingsFrame{
+		Datagram:              s.EnableDatagrams,
+		ExtendedConnect:       true,
+		QPACKMaxTableCapacity: maxTableCapacity,
+		QPACKBlockedStreams:   maxBlockedStreams,
+		MaxFieldSectionSize:   s.maxHeaderBytes(),
+		Other:                 s.AdditionalSettings,
+	}).Append(b)
+	ctrlStr.Write(b)
+
+	priorities := newPriorityTracker()
+	datagrams := newDatagramManager(conn, s.EnableDatagrams)
+	if s.EnableDatagrams {
+		go datagrams.Run()
+	}
+	go s.handleUnidirectionalStreams(conn, decoder, encoder, priorities, datagrams)
 
-	go s.handleUnidirectionalStreams(conn)
+	// the stream ID of the next request stream that will be accepted
+	var nextStreamID quic.StreamID
+	// tracks the requests that are currently being handled on this connection
+	var activeRequests sync.WaitGroup
 
 	// Process all requests immediately.
 	// It's the client's responsibility to decide which requests are eligible for 0-RTT.
 	for {
-		str, err := conn.AcceptStream(context.Background())
+		str, err := conn.AcceptStream(s.graceCtx)
 		if err != nil {
+			if errors.Is(err, context.Canceled) && s.graceCtx.Err() != nil {
+				s.shutdownConn(conn, ctrlStr, nextStreamID, &activeRequests)
+				return nil
+			}
 			var appErr *quic.ApplicationError
 			if errors.As(err, &appErr) && appErr.ErrorCode == quic.ApplicationErrorCode(ErrCodeNoError) {
 				return nil
 			}
 			return fmt.Errorf("accepting stream failed: %w", err)
 		}
+		nextStreamID = str.StreamID() + 4
+		// Streams are accepted in order, so this needs to happen before handling the request.
+		priorities.AddStream(str)
+		activeRequests.Add(1)
 		go func() {
-			rerr := s.handleRequest(conn, str, decoder, func() {
+			defer activeRequests.Done()
+			defer priorities.RemoveStream(str.StreamID())
+			rerr := s.handleRequest(conn, str, decoder, encoder, priorities, datagrams, func() {
 				conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeFrameUnexpected), "")
 			})
 			if rerr.err == errHijacked {
@@ -476,7 +582,59 @@ func (s *Server) handleConn(conn quic.Connection) error {
 	}
 }
 
-func (s *Server) handleUnidirectionalStreams(conn quic.Connection) {
+// shutdownConn gracefully shuts down a connection.
+// It sends a GOAWAY frame, and rejects all requests on streams with IDs equal to or larger than the ID in the GOAWAY frame.
+// Requests on lower stream IDs are still handled. Once all of them have completed, and the client had the chance
+// to receive the responses, the connection is closed using H3_NO_ERROR.
+// If that doesn't happen before the timeout passed to CloseGracefully expires, the connection is closed right away.
+func (s *Server) shutdownConn(conn quic.Connection, ctrlStr quic.SendStream, nextStreamID quic.StreamID, activeRequests *sync.WaitGroup) {
+	ctrlStr.Write((&goAwayFrame{StreamID: nextStreamID}).Append(nil))
+
+	go func() {
+		for {
+			str, err := conn.AcceptStream(conn.Context())
+			if err != nil {
+				return
+			}
+			str.CancelRead(quic.StreamErrorCode(ErrCodeRequestRejected))
+			str.CancelWrite(quic.StreamErrorCode(ErrCodeRequestRejected))
+		}
+	}()
+
+	// No new requests are accepted anymore, so it's safe to wait for the WaitGroup here.
+	requestsDone := make(chan struct{})
+	go func() {
+		activeRequests.Wait()
+		close(requestsDone)
+	}()
+
+	select {
+	case <-conn.Context().Done():
+		return
+	case <-s.closeCtx.Done():
+		conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeNoError), "")
+		return
+	case <-requestsDone:
+	}
+
+	// Closing the connection right away would discard response data that wasn't acknowledged yet.
+	// Give the client a few PTOs to receive the responses (and to close the connection itself).
+	stats := conn.Stats()
+	pto := stats.SmoothedRTT + utils.Max(4*stats.MeanDeviation, protocol.TimerGranularity)
+	timer := time.NewTimer(3 * pto)
+	defer timer.Stop()
+	select {
+	case <-conn.Context().Done():
+	case <-timer.C:
+		conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeNoError), "")
+	case <-s.closeCtx.Done():
+		conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeNoError), "")
+	}
+}
+
+func (s *Server) handleUnidirectionalStreams(conn quic.Connection, decoder *qpackDecoder, encoder *qpackEncoder, priorities *priorityTracker, datagrams *datagramManager) {
+	var rcvdControlStr, rcvdQPACKEncoderStr, rcvdQPACKDecoderStr atomic.Bool
+
 	for {
 		str, err := conn.AcceptUniStream(context.Background())
 		if err != nil {
@@ -493,12 +651,26 @@ func (s *Server) handleUnidirectionalStreams(conn quic.Connection) {
 				s.logger.Debugf("reading stream type on stream %d failed: %s", str.StreamID(), err)
 				return
 			}
-			// We're only interested in the control stream here.
+			// Only one stream of each of the critical stream types may be opened, see section 6.2 of RFC 9114 and section 4.2 of RFC 9204.
 			switch streamType {
 			case streamTypeControlStream:
-			case streamTypeQPACKEncoderStream, streamTypeQPACKDecoderStream:
-				// Our QPACK implementation doesn't use the dynamic table yet.
-				// TODO: check that only one stream of each type is opened.
+				if isFirst := rcvdControlStr.CompareAndSwap(false, true); !isFirst {
+					conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeStreamCreationError), "duplicate control stream")
+					return
+				}
+			case streamTypeQPACKEncoderStream:
+				if isFirst := rcvdQPACKEncoderStr.CompareAndSwap(false, true); !isFirst {
+					conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeStreamCreationError), "duplicate QPACK encoder stream")
+					return
+				}
+				handleQPACKStreamError(conn, decoder.HandleEncoderStream(str))
+				return
+			case streamTypeQPACKDecoderStream:
+				if isFirst := rcvdQPACKDecoderStr.CompareAndSwap(false, true); !isFirst {
+					conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeStreamCreationError), "duplicate QPACK decoder stream")
+					return
+				}
+				handleQPACKStreamError(conn, encoder.HandleDecoderStream(str))
 				return
 			case streamTypePushStream: // only the server can push
 				conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeStreamCreationError), "")
@@ -510,9 +682,10 @@ func (s *Server) handleUnidirectionalStreams(conn quic.Connection) {
 				str.CancelRead(quic.StreamErrorCode(ErrCodeStreamCreationError))
 				return
 			}
-			f, err := parseNextFrame(str, nil)
+			r := &controlStreamReader{ReceiveStream: str}
+			f, err := parseNextFrame(r, nil)
 			if err != nil {
-				conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeFrameError), "")
+				r.closeConnection(conn, err)
 				return
 			}
 			sf, ok := f.(*settingsFrame)
@@ -520,19 +693,57 @@ func (s *Server) handleUnidirectionalStreams(conn quic.Connection) {
 				conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeMissingSettings), "")
 				return
 			}
-			if !sf.Datagram {
-				return
-			}
 			// If datagram support was enabled on our side as well as on the client side,
 			// we can expect it to have been negotiated both on the transport and on the HTTP/3 layer.
 			// Note: ConnectionState() will block until the handshake is complete (relevant when using 0-RTT).
-			if s.EnableDatagrams && !conn.ConnectionState().SupportsDatagrams {
+			if sf.Datagram && s.EnableDatagrams && !conn.ConnectionState().SupportsDatagrams {
 				conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeSettingsError), "missing QUIC Datagram support")
+				return
 			}
+			datagrams.HandleSettings(sf)
+			encoder.HandleSettings(sf.QPACKMaxTableCapacity, sf.MaxFieldSectionSize)
+			s.handleControlStream(conn, r, priorities)
 		}(str)
 	}
 }
 
+// handleControlStream handles the frames sent on the control stream after the SETTINGS frame.
+func (s *Server) handleControlStream(conn quic.Connection, r *controlStreamReader, priorities *priorityTracker) {
+	for {
+		f, err := parseNextFrame(r, nil)
+		if err != nil {
+			s.logger.Debugf("reading from the control stream failed: %s", err)
+			r.closeConnection(conn, err)
+			return
+		}
+		switch f := f.(type) {
+		case *priorityUpdateFrame:
+			// We never push, so there are no push streams that could be prioritized.
+			if f.IsPush {
+				conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeIDError), "PRIORITY_UPDATE frame for a push stream")
+				return
+			}
+			if err := priorities.HandlePriorityUpdate(f); err != nil {
+				conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeIDError), err.Error())
+				return
+			}
+		case *skippedFrame:
+			switch f.Type {
+			case frameTypeMaxPushID: // we never push, so the client's MAX_PUSH_ID can be ignored
+			case frameTypeCancelPush:
+				conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeIDError), "CANCEL_PUSH frame for a push ID that was never promised")
+				return
+			default:
+				conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeFrameUnexpected), fmt.Sprintf("unexpected frame on the control stream: 0x%x", f.Type))
+				return
+			}
+		case *dataFrame, *headersFrame, *settingsFrame:
+			conn.CloseWithError(quic.ApplicationErrorCode(ErrCodeFrameUnexpected), fmt.Sprintf("unexpected frame on the control stream: %T", f))
+			return
+		}
+	}
+}
+
 func (s *Server) maxHeaderBytes() uint64 {
 	if s.MaxHeaderBytes <= 0 {
 		return http.DefaultMaxHeaderBytes
@@ -540,7 +751,7 @@ func (s *Server) maxHeaderBytes() uint64 {
 	return uint64(s.MaxHeaderBytes)
 }
 
-func (s *Server) handleRequest(conn quic.Connection, str quic.Stream, decoder *qpack.Decoder, onFrameError func()) requestError {
+func (s *Server) handleRequest(conn quic.Connection, str quic.Stream, decoder *qpackDecoder, encoder *qpackEncoder, priorities *priorityTracker, datagrams *datagramManager, onFrameError func()) requestError {
 	var ufh unknownFrameHandlerFunc
 	if s.StreamHijacker != nil {
 		ufh = func(ft FrameType, e error) (processed bool, err error) { return s.StreamHijacker(ft, conn, str, e) }
@@ -557,33 +768,62 @@ func (s *Server) handleRequest(conn quic.Connection, str quic.Stream, decoder *q
 		return newConnError(ErrCodeFrameUnexpected, errors.New("expected first frame to be a HEADERS frame"))
 	}
 	if hf.Length > s.maxHeaderBytes() {
-		return newStreamError(ErrCodeFrameError, fmt.Errorf("HEADERS frame too large: %d bytes (max: %d)", hf.Length, s.maxHeaderBytes()))
+		s.logger.Debugf("HEADERS frame too large: %d bytes (max: %d)", hf.Length, s.maxHeaderBytes())
+		s.rejectRequestHeader(str, encoder)
+		// The field section is never decoded, so the client's encoder can release its dynamic table references.
+		if err := decoder.cancelStream(str.StreamID()); err != nil {
+			return requestError{err: err}
+		}
+		return requestError{}
 	}
 	headerBlock := make([]byte, hf.Length)
 	if _, err := io.ReadFull(str, headerBlock); err != nil {
 		return newStreamError(ErrCodeRequestIncomplete, err)
 	}
-	hfs, err := decoder.DecodeFull(headerBlock)
+	ctx := str.Context()
+	hfs, err := decoder.Decode(ctx, str.StreamID(), headerBlock)
 	if err != nil {
-		// TODO: use the right error code
-		return newConnError(ErrCodeGeneralProtocolError, err)
+		var qerr *qpackError
+		if errors.As(err, &qerr) {
+			return newConnError(qerr.code, err)
+		}
+		return newStreamError(ErrCodeRequestIncomplete, err)
+	}
+	if size := fieldSectionSize(hfs); size > s.maxHeaderBytes() {
+		s.logger.Debugf("request header too large: %d bytes (max: %d)", size, s.maxHeaderBytes())
+		s.rejectRequestHeader(str, encoder)
+		return requestError{}
 	}
 	req, err := requestFromHeaders(hfs)
 	if err != nil {
 		return newStreamError(ErrCodeMessageError, err)
 	}
 
+	priorities.HandleRequestHeader(str, req.Header)
+
 	connState := conn.ConnectionState().TLS
 	req.TLS = &connState
 	req.RemoteAddr = conn.RemoteAddr().String()
 
+	ctx = context.WithValue(ctx, ServerContextKey, s)
+	ctx = context.WithValue(ctx, http.LocalAddrContextKey, conn.LocalAddr())
+	req = req.WithContext(ctx)
+
+	hstr := newStream(str, onFrameError, func(r io.Reader, l uint64) error {
+		trailer, err := decodeTrailers(req.Context(), r, l, s.maxHeaderBytes(), str.StreamID(), decoder)
+		if err != nil {
+			return err
+		}
+		mergeTrailers(&req.Trailer, trailer)
+		return nil
+	})
 	// Check that the client doesn't send more data in DATA frames than indicated by the Content-Length header (if set).
 	// See section 4.1.2 of RFC 9114.
 	var httpStr Stream
 	if _, ok := req.Header["Content-Length"]; ok && req.ContentLength >= 0 {
-		httpStr = newLengthLimitedStream(newStream(str, onFrameError), req.ContentLength)
+		httpStr = newLengthLimitedStream(hstr, req.ContentLength)
 	} else {
-		httpStr = newStream(str, onFrameError)
+		httpStr = hstr
 	}
 	body := newRequestBody(httpStr)
 	req.Body = body
@@ -594,14 +834,33 @@ func (s *Server) handleRequest(conn quic.Connection, str quic.Stream, decoder *q
 		s.logger.Infof("%s %s%s", req.Method, req.Host, req.RequestURI)
 	}
 
-	ctx := str.Context()
-	ctx = context.WithValue(ctx, ServerContextKey, s)
-	ctx = context.WithValue(ctx, http.LocalAddrContextKey, conn.LocalAddr())
-	req = req.WithContext(ctx)
-	r := newResponseWriter(str, conn, s.logger)
+	r := newResponseWriter(str, conn, encoder, priorities, s.logger)
 	if req.Method == http.MethodHead {
 		r.isHead = true
 	}
+	// The client waits for a 100 Continue response before sending the body.
+	// It is sent when the handler reads the body for the first time.
+	if expectsContinue(req.Header) {
+		req.Header.Del("Expect")
+		if req.ContentLength != 0 {
+			body.onFirstRead = r.writeContinue
+		}
+	}
+	// HTTP Datagrams can only be associated with CONNECT streams, see section 2 of RFC 9297.
+	// If they can't be sent in QUIC DATAGRAM frames, they are sent as DATAGRAM capsules in the response body.
+	if req.Method == http.MethodConnect {
+		r.connectBody = body
+		r.datagrams = datagrams.AddStream(str.StreamID(), func(b []byte) error {
+			if _, err := r.Write(b); err != nil {
+				return err
+			}
+			return r.FlushError()
+		}, httpStr, func(code quic.StreamErrorCode) {
+			str.CancelRead(code)
+			str.CancelWrite(code)
+		})
+		r.datagrams.capsuleProtocol = usesCapsuleProtocol(req.Header)
+	}
 	handler := s.Handler
 	if handler == nil {
 		handler = http.DefaultServeMux
@@ -626,24 +885,48 @@ func (s *Server) handleRequest(conn quic.Connection, str quic.Stream, decoder *q
 	}()
 
 	if body.wasStreamHijacked() {
+		if r.datagrams != nil {
+			// The stream is now owned by the handler, and done once it closes the stream.
+			go func() {
+				<-str.Context().Done()
+				datagrams.RemoveStream(str.StreamID())
+			}()
+		}
 		return requestError{err: errHijacked}
 	}
+	if r.datagrams != nil {
+		defer datagrams.RemoveStream(str.StreamID())
+	}
 
 	// only write response when there is no panic
 	if !panicked {
 		// response not written to the client yet, set Content-Length
-		if !r.written {
+		// A 2xx response to a CONNECT request must not contain a Content-Length, see section 9.3.6 of RFC 9110.
+		if !r.written && req.Method != http.MethodConnect {
 			if _, haveCL := r.header["Content-Length"]; !haveCL {
 				r.header.Set("Content-Length", strconv.FormatInt(r.numWritten, 10))
 			}
 		}
 		r.Flush()
+		if err := r.writeTrailers(); err != nil {
+			s.logger.Debugf("writing trailers failed: %s", err)
+		}
 	}
 	// If the EOF was read by the handler, CancelRead() is a no-op.
 	str.CancelRead(quic.StreamErrorCode(ErrCodeNoError))
 	return requestError{}
 }
 
+// rejectRequestHeader responds with a 431 (Request Header Fields Too Large) status code,
+// when the request header exceeds the limit we advertised in SETTINGS_MAX_FIELD_SECTION_SIZE, see section 4.2.2 of RFC 9114.
+// The rest of the request is not needed any more.
+func (s *Server) rejectRequestHeader(str quic.Stream, encoder *qpackEncoder) {
+	r := newResponseWriter(str, nil, encoder, nil, s.logger)
+	r.WriteHeader(http.StatusRequestHeaderFieldsTooLarge)
+	r.Flush()
+	str.CancelRead(quic.StreamErrorCode(ErrCodeNoError))
+}
+
 // Close the server immediately, aborting requests and sending CONNECTION_CLOSE frames to connected clients.
 // Close in combination with ListenAndServe() (instead of Serve()) may race if it is called before a UDP socket is established.
 func (s *Server) Close() error {
@@ -662,10 +945,37 @@ func (s *Server) Close() error {
 }
 
 // CloseGracefully shuts down the server gracefully. The server sends a GOAWAY frame first, then waits for either timeout to trigger, or for all running requests to complete.
+// Requests sent after the GOAWAY frame are rejected. Connections are closed using H3_NO_ERROR once all of their running requests have completed,
+// or when the timeout expires.
+// Finally, the listeners are closed, and Serve returns http.ErrServerClosed.
 // CloseGracefully in combination with ListenAndServe() (instead of Serve()) may race if it is called before a UDP socket is established.
 func (s *Server) CloseGracefully(timeout time.Duration) error {
-	// TODO: implement
-	return nil
+	s.init()
+	s.mutex.Lock()
+	s.closed = true
+	// Closing a listener closes all connections accepted from it,
+	// so the listeners can only be closed once all connections were shut down.
+	listeners := make([]*QUICEarlyListener, 0, len(s.listeners))
+	for ln := range s.listeners {
+		listeners = append(listeners, ln)
+	}
+	s.mutex.Unlock()
+
+	s.graceCancel()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	s.waitForConns(ctx)
+	cancel()
+	// Close all connections that the clients didn't close yet.
+	s.closeCancel()
+	s.waitForConns(context.Background())
+
+	var err error
+	for _, ln := range listeners {
+		if cerr := (*ln).Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
 }
 
 // ErrNoAltSvcPort is the error returned by SetQuicHeaders when no port was found
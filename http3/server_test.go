@@ -82,7 +82,7 @@ var _ = Describe("Server", func() {
 
 	Context("handling requests", func() {
 		var (
-			qpackDecoder       *qpack.Decoder
+			decoder            *qpackDecoder
 			str                *mockquic.MockStream
 			conn               *mockquic.MockEarlyConnection
 			exampleGetRequest  *http.Request
@@ -113,7 +113,8 @@ var _ = Describe("Server", func() {
 			buf := &bytes.Buffer{}
 			str := mockquic.NewMockStream(mockCtrl)
 			str.EXPECT().Write(gomock.Any()).DoAndReturn(buf.Write).AnyTimes()
-			rw := newRequestWriter(utils.DefaultLogger)
+			str.EXPECT().StreamID().AnyTimes()
+			rw := newRequestWriter(newQPACKEncoder(0, nil), utils.DefaultLogger)
 			Expect(rw.WriteRequestHeader(str, req, false)).To(Succeed())
 			return buf.Bytes()
 		}
@@ -135,8 +136,10 @@ var _ = Describe("Server", func() {
 			examplePostRequest, err = http.NewRequest("POST", "https://www.example.com", bytes.NewReader([]byte("foobar")))
 			Expect(err).ToNot(HaveOccurred())
 
-			qpackDecoder = qpack.NewDecoder(nil)
+			decoder = newQPACKDecoder(0, 0, nil)
 			str = mockquic.NewMockStream(mockCtrl)
+			str.EXPECT().StreamID().Return(quic.StreamID(4)).AnyTimes()
+			str.EXPECT().SetPriority(DefaultPriority.streamPriority()).AnyTimes()
 			conn = mockquic.NewMockEarlyConnection(mockCtrl)
 			addr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1337}
 			conn.EXPECT().RemoteAddr().Return(addr).AnyTimes()
@@ -157,7 +160,7 @@ var _ = Describe("Server", func() {
 			}).AnyTimes()
 			str.EXPECT().CancelRead(gomock.Any())
 
-			Expect(s.handleRequest(conn, str, qpackDecoder, nil)).To(Equal(requestError{}))
+			Expect(s.handleRequest(conn, str, decoder, newQPACKEncoder(0, nil), newPriorityTracker(), newDatagramManager(conn, false), nil)).To(Equal(requestError{}))
 			var req *http.Request
 			Eventually(requestChan).Should(Receive(&req))
 			Expect(req.Host).To(Equal("www.example.com"))
@@ -165,6 +168,41 @@ var _ = Describe("Server", func() {
 			Expect(req.Context().Value(ServerContextKey)).To(Equal(s))
 		})
 
+		It("sets the priority signaled in the Priority header field", func() {
+			s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+			req := exampleGetRequest.Clone(context.Background())
+			req.Header.Set("Priority", "u=1, i")
+			setRequest(encodeRequest(req))
+			str.EXPECT().Context().Return(reqContext)
+			str.EXPECT().Write(gomock.Any()).DoAndReturn(func(p []byte) (int, error) { return len(p), nil }).AnyTimes()
+			str.EXPECT().CancelRead(gomock.Any())
+			str.EXPECT().SetPriority(quic.StreamPriority{Urgency: 1, Incremental: true})
+
+			priorities := newPriorityTracker()
+			priorities.AddStream(str)
+			Expect(s.handleRequest(conn, str, decoder, newQPACKEncoder(0, nil), priorities, newDatagramManager(conn, false), nil)).To(Equal(requestError{}))
+		})
+
+		It("allows the handler to set the priority", func() {
+			s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.(PrioritySetter).SetPriority(Priority{Urgency: 6})
+			})
+			req := exampleGetRequest.Clone(context.Background())
+			req.Header.Set("Priority", "u=1")
+			setRequest(encodeRequest(req))
+			str.EXPECT().Context().Return(reqContext)
+			str.EXPECT().Write(gomock.Any()).DoAndReturn(func(p []byte) (int, error) { return len(p), nil }).AnyTimes()
+			str.EXPECT().CancelRead(gomock.Any())
+			gomock.InOrder(
+				str.EXPECT().SetPriority(quic.StreamPriority{Urgency: 1}),
+				str.EXPECT().SetPriority(quic.StreamPriority{Urgency: 6}),
+			)
+
+			priorities := newPriorityTracker()
+			priorities.AddStream(str)
+			Expect(s.handleRequest(conn, str, decoder, newQPACKEncoder(0, nil), priorities, newDatagramManager(conn, false), nil)).To(Equal(requestError{}))
+		})
+
 		It("returns 200 with an empty handler", func() {
 			s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
 
@@ -174,7 +212,7 @@ var _ = Describe("Server", func() {
 			str.EXPECT().Write(gomock.Any()).DoAndReturn(responseBuf.Write).AnyTimes()
 			str.EXPECT().CancelRead(gomock.Any())
 
-			serr := s.handleRequest(conn, str, qpackDecoder, nil)
+			serr := s.handleRequest(conn, str, decoder, newQPACKEncoder(0, nil), newPriorityTracker(), newDatagramManager(conn, false), nil)
 			Expect(serr.err).ToNot(HaveOccurred())
 			hfs := decodeHeader(responseBuf)
 			Expect(hfs).To(HaveKeyWithValue(":status", []string{"200"}))
@@ -191,7 +229,7 @@ var _ = Describe("Server", func() {
 			str.EXPECT().Write(gomock.Any()).DoAndReturn(responseBuf.Write).AnyTimes()
 			str.EXPECT().CancelRead(gomock.Any())
 
-			serr := s.handleRequest(conn, str, qpackDecoder, nil)
+			serr := s.handleRequest(conn, str, decoder, newQPACKEncoder(0, nil), newPriorityTracker(), newDatagramManager(conn, false), nil)
 			Expect(serr.err).ToNot(HaveOccurred())
 			hfs := decodeHeader(responseBuf)
 			Expect(hfs).To(HaveKeyWithValue(":status", []string{"200"}))
@@ -200,6 +238,108 @@ var _ = Describe("Server", func() {
 			Expect(hfs).To(HaveLen(4))
 		})
 
+		It("doesn't set Content-Length for responses to CONNECT requests", func() {
+			s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+				Expect(r.Method).To(Equal(http.MethodConnect))
+				Expect(r.Proto).To(Equal("websocket"))
+				Expect(r.ContentLength).To(BeEquivalentTo(-1))
+			})
+
+			req, err := http.NewRequest(http.MethodConnect, "https://www.example.com/chat", nil)
+			Expect(err).ToNot(HaveOccurred())
+			req.Proto = "websocket"
+			responseBuf := &bytes.Buffer{}
+			setRequest(encodeRequest(req))
+			str.EXPECT().Context().Return(reqContext)
+			str.EXPECT().Write(gomock.Any()).DoAndReturn(responseBuf.Write).AnyTimes()
+			str.EXPECT().CancelRead(gomock.Any())
+
+			serr := s.handleRequest(conn, str, decoder, newQPACKEncoder(0, nil), newPriorityTracker(), newDatagramManager(conn, false), nil)
+			Expect(serr.err).ToNot(HaveOccurred())
+			hfs := decodeHeader(responseBuf)
+			Expect(hfs).To(HaveKeyWithValue(":status", []string{"200"}))
+			Expect(hfs).ToNot(HaveKey("content-length"))
+		})
+
+		It("lets the handler take over the stream of a CONNECT request", func() {
+			tunnelChan := make(chan net.Conn, 1)
+			s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+				Expect(r.Method).To(Equal(http.MethodConnect))
+				Expect(r.Host).To(Equal("www.example.com:443"))
+				conn, err := w.(Tunneler).Tunnel()
+				Expect(err).ToNot(HaveOccurred())
+				_, err = conn.Write([]byte("foobar"))
+				Expect(err).ToNot(HaveOccurred())
+				tunnelChan <- conn
+			})
+
+			req, err := http.NewRequest(http.MethodConnect, "https://www.example.com:443", nil)
+			Expect(err).ToNot(HaveOccurred())
+			responseBuf := &bytes.Buffer{}
+			setRequest(encodeRequest(req))
+			str.EXPECT().Context().Return(reqContext).AnyTimes()
+			str.EXPECT().Write(gomock.Any()).DoAndReturn(responseBuf.Write).AnyTimes()
+
+			serr := s.handleRequest(conn, str, decoder, newQPACKEncoder(0, nil), newPriorityTracker(), newDatagramManager(conn, false), nil)
+			Expect(serr.err).To(Equal(errHijacked))
+			hfs := decodeHeader(responseBuf)
+			Expect(hfs).To(HaveKeyWithValue(":status", []string{"200"}))
+			frame, err := parseNextFrame(responseBuf, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame).To(Equal(&dataFrame{Length: 6}))
+			Expect(responseBuf.String()).To(Equal("foobar"))
+
+			var tunnel net.Conn
+			Eventually(tunnelChan).Should(Receive(&tunnel))
+			Expect(tunnel.RemoteAddr().String()).To(Equal("127.0.0.1:1337"))
+			str.EXPECT().CancelRead(quic.StreamErrorCode(ErrCodeNoError))
+			str.EXPECT().Close()
+			Expect(tunnel.Close()).To(Succeed())
+		})
+
+		It("doesn't establish a tunnel if the handler sent a non-2xx status", func() {
+			s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+				w.WriteHeader(http.StatusForbidden)
+				_, err := w.(Tunneler).Tunnel()
+				Expect(err).To(MatchError("http3: cannot establish a tunnel after sending a 403 (Forbidden) response"))
+			})
+
+			req, err := http.NewRequest(http.MethodConnect, "https://www.example.com:443", nil)
+			Expect(err).ToNot(HaveOccurred())
+			responseBuf := &bytes.Buffer{}
+			setRequest(encodeRequest(req))
+			str.EXPECT().Context().Return(reqContext)
+			str.EXPECT().Write(gomock.Any()).DoAndReturn(responseBuf.Write).AnyTimes()
+			str.EXPECT().CancelRead(gomock.Any())
+
+			serr := s.handleRequest(conn, str, decoder, newQPACKEncoder(0, nil), newPriorityTracker(), newDatagramManager(conn, false), nil)
+			Expect(serr.err).ToNot(HaveOccurred())
+			hfs := decodeHeader(responseBuf)
+			Expect(hfs).To(HaveKeyWithValue(":status", []string{"403"}))
+		})
+
+		It("doesn't establish tunnels for requests other than CONNECT", func() {
+			s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+				_, err := w.(Tunneler).Tunnel()
+				Expect(err).To(MatchError(errNotConnect))
+			})
+
+			responseBuf := &bytes.Buffer{}
+			setRequest(encodeRequest(exampleGetRequest))
+			str.EXPECT().Context().Return(reqContext)
+			str.EXPECT().Write(gomock.Any()).DoAndReturn(responseBuf.Write).AnyTimes()
+			str.EXPECT().CancelRead(gomock.Any())
+
+			serr := s.handleRequest(conn, str, decoder, newQPACKEncoder(0, nil), newPriorityTracker(), newDatagramManager(conn, false), nil)
+			Expect(serr.err).ToNot(HaveOccurred())
+			hfs := decodeHeader(responseBuf)
+			Expect(hfs).To(HaveKeyWithValue(":status", []string{"200"}))
+		})
+
 		It("not sets Content-Length when the handler flushes to the client", func() {
 			s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				w.Write([]byte("foobar"))
@@ -213,7 +353,7 @@ var _ = Describe("Server", func() {
 			str.EXPECT().Write(gomock.Any()).DoAndReturn(responseBuf.Write).AnyTimes()
 			str.EXPECT().CancelRead(gomock.Any())
 
-			serr := s.handleRequest(conn, str, qpackDecoder, nil)
+			serr := s.handleRequest(conn, str, decoder, newQPACKEncoder(0, nil), newPriorityTracker(), newDatagramManager(conn, false), nil)
 			Expect(serr.err).ToNot(HaveOccurred())
 			hfs := decodeHeader(responseBuf)
 			Expect(hfs).To(HaveKeyWithValue(":status", []string{"200"}))
@@ -233,7 +373,7 @@ var _ = Describe("Server", func() {
 			str.EXPECT().Context().Return(reqContext)
 			str.EXPECT().Write(gomock.Any()).DoAndReturn(responseBuf.Write).AnyTimes()
 			str.EXPECT().CancelRead(gomock.Any())
-			serr := s.handleRequest(conn, str, qpackDecoder, nil)
+			serr := s.handleRequest(conn, str, decoder, newQPACKEncoder(0, nil), newPriorityTracker(), newDatagramManager(conn, false), nil)
 			Expect(serr.err).ToNot(HaveOccurred())
 			hfs := decodeHeader(responseBuf)
 			Expect(hfs).To(HaveKeyWithValue(":status", []string{"200"}))
@@ -252,7 +392,7 @@ var _ = Describe("Server", func() {
 			str.EXPECT().Context().Return(reqContext)
 			str.EXPECT().Write(gomock.Any()).DoAndReturn(responseBuf.Write).AnyTimes()
 			str.EXPECT().CancelRead(gomock.Any())
-			serr := s.handleRequest(conn, str, qpackDecoder, nil)
+			serr := s.handleRequest(conn, str, decoder, newQPACKEncoder(0, nil), newPriorityTracker(), newDatagramManager(conn, false), nil)
 			Expect(serr.err).ToNot(HaveOccurred())
 			hfs := decodeHeader(responseBuf)
 			Expect(hfs).To(HaveKeyWithValue(":status", []string{"200"}))
@@ -260,6 +400,171 @@ var _ = Describe("Server", func() {
 			Expect(hfs).To(HaveKeyWithValue("content-type", []string{"text/html; charset=utf-8"}))
 		})
 
+		It("populates the request trailers after the body was read", func() {
+			trailerChan := make(chan http.Header, 1)
+			s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+				Expect(r.Trailer).To(Equal(http.Header{"Foo": nil}))
+				Expect(r.Header).ToNot(HaveKey("Trailer"))
+				body, err := io.ReadAll(r.Body)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(body).To(Equal([]byte("foobar")))
+				trailerChan <- r.Trailer
+			})
+
+			req, err := http.NewRequest(http.MethodPost, "https://www.example.com", bytes.NewReader([]byte("foobar")))
+			Expect(err).ToNot(HaveOccurred())
+			req.Trailer = http.Header{"Foo": nil}
+			data := encodeRequest(req)
+			data = (&dataFrame{Length: 6}).Append(data)
+			data = append(data, []byte("foobar")...)
+			buf := &bytes.Buffer{}
+			req.Trailer.Set("Foo", "bar")
+			Expect(newRequestWriter(newQPACKEncoder(0, nil), utils.DefaultLogger).WriteRequestTrailer(buf, 0, req)).To(Succeed())
+			setRequest(append(data, buf.Bytes()...))
+			str.EXPECT().Context().Return(reqContext)
+			str.EXPECT().Write(gomock.Any()).DoAndReturn(func(p []byte) (int, error) {
+				return len(p), nil
+			}).AnyTimes()
+			str.EXPECT().CancelRead(gomock.Any()).AnyTimes()
+
+			serr := s.handleRequest(conn, str, decoder, newQPACKEncoder(0, nil), newPriorityTracker(), newDatagramManager(conn, false), nil)
+			Expect(serr.err).ToNot(HaveOccurred())
+			var trailer http.Header
+			Eventually(trailerChan).Should(Receive(&trailer))
+			Expect(trailer).To(Equal(http.Header{"Foo": []string{"bar"}}))
+		})
+
+		Context("Expect: 100-continue", func() {
+			It("sends 100 Continue when the handler reads the body", func() {
+				s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					defer GinkgoRecover()
+					Expect(r.Header).ToNot(HaveKey("Expect"))
+					body, err := io.ReadAll(r.Body)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(body).To(Equal([]byte("foobar")))
+				})
+
+				examplePostRequest.Header.Set("Expect", "100-continue")
+				data := encodeRequest(examplePostRequest)
+				data = (&dataFrame{Length: 6}).Append(data)
+				data = append(data, []byte("foobar")...)
+				setRequest(data)
+				responseBuf := &bytes.Buffer{}
+				str.EXPECT().Context().Return(reqContext)
+				str.EXPECT().Write(gomock.Any()).DoAndReturn(responseBuf.Write).AnyTimes()
+				str.EXPECT().CancelRead(gomock.Any())
+
+				serr := s.handleRequest(conn, str, decoder, newQPACKEncoder(0, nil), newPriorityTracker(), newDatagramManager(conn, false), nil)
+				Expect(serr.err).ToNot(HaveOccurred())
+				Expect(decodeHeader(responseBuf)).To(Equal(map[string][]string{":status": {"100"}}))
+				Expect(decodeHeader(responseBuf)).To(HaveKeyWithValue(":status", []string{"200"}))
+			})
+
+			It("doesn't send 100 Continue when the handler doesn't read the body", func() {
+				s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusRequestEntityTooLarge)
+				})
+
+				examplePostRequest.Header.Set("Expect", "100-continue")
+				setRequest(encodeRequest(examplePostRequest))
+				responseBuf := &bytes.Buffer{}
+				str.EXPECT().Context().Return(reqContext)
+				str.EXPECT().Write(gomock.Any()).DoAndReturn(responseBuf.Write).AnyTimes()
+				str.EXPECT().CancelRead(gomock.Any())
+
+				serr := s.handleRequest(conn, str, decoder, newQPACKEncoder(0, nil), newPriorityTracker(), newDatagramManager(conn, false), nil)
+				Expect(serr.err).ToNot(HaveOccurred())
+				Expect(decodeHeader(responseBuf)).To(HaveKeyWithValue(":status", []string{"413"}))
+			})
+
+			It("doesn't send 100 Continue after the response header was written", func() {
+				s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusOK)
+					io.ReadAll(r.Body)
+				})
+
+				examplePostRequest.Header.Set("Expect", "100-continue")
+				setRequest(encodeRequest(examplePostRequest))
+				responseBuf := &bytes.Buffer{}
+				str.EXPECT().Context().Return(reqContext)
+				str.EXPECT().Write(gomock.Any()).DoAndReturn(responseBuf.Write).AnyTimes()
+				str.EXPECT().CancelRead(gomock.Any())
+
+				serr := s.handleRequest(conn, str, decoder, newQPACKEncoder(0, nil), newPriorityTracker(), newDatagramManager(conn, false), nil)
+				Expect(serr.err).ToNot(HaveOccurred())
+				Expect(decodeHeader(responseBuf)).To(HaveKeyWithValue(":status", []string{"200"}))
+			})
+
+			// This test is only meaningful when run with the race detector.
+			It("synchronizes the 100 Continue response with the response written concurrently", func() {
+				s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					defer GinkgoRecover()
+					done := make(chan struct{})
+					go func() {
+						defer GinkgoRecover()
+						defer close(done)
+						body, err := io.ReadAll(r.Body)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(body).To(Equal([]byte("foobar")))
+					}()
+					w.Write([]byte("foo"))
+					w.(http.Flusher).Flush()
+					w.Write([]byte("bar"))
+					<-done
+				})
+
+				examplePostRequest.Header.Set("Expect", "100-continue")
+				data := encodeRequest(examplePostRequest)
+				data = (&dataFrame{Length: 6}).Append(data)
+				data = append(data, []byte("foobar")...)
+				setRequest(data)
+				responseBuf := &bytes.Buffer{}
+				str.EXPECT().Context().Return(reqContext)
+				str.EXPECT().Write(gomock.Any()).DoAndReturn(responseBuf.Write).AnyTimes()
+				str.EXPECT().CancelRead(gomock.Any()).AnyTimes()
+
+				serr := s.handleRequest(conn, str, decoder, newQPACKEncoder(0, nil), newPriorityTracker(), newDatagramManager(conn, false), nil)
+				Expect(serr.err).ToNot(HaveOccurred())
+				// The 100 Continue response is only sent if the body is read before the response header is written.
+				hfs := decodeHeader(responseBuf)
+				if hfs[":status"][0] == "100" {
+					hfs = decodeHeader(responseBuf)
+				}
+				Expect(hfs).To(HaveKeyWithValue(":status", []string{"200"}))
+			})
+		})
+
+		It("sends the trailers set by the handler after the body", func() {
+			s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Trailer", "Foo")
+				w.Write([]byte("foobar"))
+				w.Header().Set("Foo", "bar")
+				w.Header().Set(http.TrailerPrefix+"Bar", "baz")
+			})
+
+			responseBuf := &bytes.Buffer{}
+			setRequest(encodeRequest(exampleGetRequest))
+			str.EXPECT().Context().Return(reqContext)
+			str.EXPECT().Write(gomock.Any()).DoAndReturn(responseBuf.Write).AnyTimes()
+			str.EXPECT().CancelRead(gomock.Any())
+
+			serr := s.handleRequest(conn, str, decoder, newQPACKEncoder(0, nil), newPriorityTracker(), newDatagramManager(conn, false), nil)
+			Expect(serr.err).ToNot(HaveOccurred())
+			hfs := decodeHeader(responseBuf)
+			Expect(hfs).To(HaveKeyWithValue(":status", []string{"200"}))
+			Expect(hfs).To(HaveKeyWithValue("trailer", []string{"Foo"}))
+			Expect(hfs).ToNot(HaveKey("foo"))
+			frame, err := parseNextFrame(responseBuf, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame).To(Equal(&dataFrame{Length: 6}))
+			responseBuf.Next(6)
+			Expect(decodeHeader(responseBuf)).To(Equal(map[string][]string{
+				"foo": {"bar"},
+				"bar": {"baz"},
+			}))
+		})
+
 		It("handles a aborting handler", func() {
 			s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				panic(http.ErrAbortHandler)
@@ -271,7 +576,7 @@ var _ = Describe("Server", func() {
 			str.EXPECT().Write(gomock.Any()).DoAndReturn(responseBuf.Write).AnyTimes()
 			str.EXPECT().CancelRead(gomock.Any())
 
-			serr := s.handleRequest(conn, str, qpackDecoder, nil)
+			serr := s.handleRequest(conn, str, decoder, newQPACKEncoder(0, nil), newPriorityTracker(), newDatagramManager(conn, false), nil)
 			Expect(serr.err).ToNot(HaveOccurred())
 			Expect(responseBuf.Bytes()).To(HaveLen(0))
 		})
@@ -287,7 +592,7 @@ var _ = Describe("Server", func() {
 			str.EXPECT().Write(gomock.Any()).DoAndReturn(responseBuf.Write).AnyTimes()
 			str.EXPECT().CancelRead(gomock.Any())
 
-			serr := s.handleRequest(conn, str, qpackDecoder, nil)
+			serr := s.handleRequest(conn, str, decoder, newQPACKEncoder(0, nil), newPriorityTracker(), newDatagramManager(conn, false), nil)
 			Expect(serr.err).ToNot(HaveOccurred())
 			Expect(responseBuf.Bytes()).To(HaveLen(0))
 		})
@@ -319,6 +624,8 @@ var _ = Describe("Server", func() {
 				buf := bytes.NewBuffer(quicvarint.Append(nil, 0x41))
 				unknownStr := mockquic.NewMockStream(mockCtrl)
 				unknownStr.EXPECT().Read(gomock.Any()).DoAndReturn(buf.Read).AnyTimes()
+				unknownStr.EXPECT().StreamID().AnyTimes()
+				unknownStr.EXPECT().SetPriority(DefaultPriority.streamPriority())
 				conn.EXPECT().AcceptStream(gomock.Any()).Return(unknownStr, nil)
 				conn.EXPECT().AcceptStream(gomock.Any()).Return(nil, errors.New("done"))
 				conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
@@ -342,6 +649,8 @@ var _ = Describe("Server", func() {
 				unknownStr := mockquic.NewMockStream(mockCtrl)
 				unknownStr.EXPECT().Read(gomock.Any()).DoAndReturn(buf.Read).AnyTimes()
 				unknownStr.EXPECT().CancelWrite(quic.StreamErrorCode(ErrCodeRequestIncomplete))
+				unknownStr.EXPECT().StreamID().AnyTimes()
+				unknownStr.EXPECT().SetPriority(DefaultPriority.streamPriority())
 				conn.EXPECT().AcceptStream(gomock.Any()).Return(unknownStr, nil)
 				conn.EXPECT().AcceptStream(gomock.Any()).Return(nil, errors.New("done"))
 				conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
@@ -365,6 +674,8 @@ var _ = Describe("Server", func() {
 				unknownStr := mockquic.NewMockStream(mockCtrl)
 				unknownStr.EXPECT().Read(gomock.Any()).DoAndReturn(buf.Read).AnyTimes()
 				unknownStr.EXPECT().CancelWrite(quic.StreamErrorCode(ErrCodeRequestIncomplete))
+				unknownStr.EXPECT().StreamID().AnyTimes()
+				unknownStr.EXPECT().SetPriority(DefaultPriority.streamPriority())
 				conn.EXPECT().AcceptStream(gomock.Any()).Return(unknownStr, nil)
 				conn.EXPECT().AcceptStream(gomock.Any()).Return(nil, errors.New("done"))
 				conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
@@ -389,6 +700,8 @@ var _ = Describe("Server", func() {
 				}
 
 				unknownStr.EXPECT().Read(gomock.Any()).Return(0, testErr).AnyTimes()
+				unknownStr.EXPECT().StreamID().AnyTimes()
+				unknownStr.EXPECT().SetPriority(DefaultPriority.streamPriority())
 				conn.EXPECT().AcceptStream(gomock.Any()).Return(unknownStr, nil)
 				conn.EXPECT().AcceptStream(gomock.Any()).Return(nil, errors.New("done"))
 				conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
@@ -510,7 +823,8 @@ var _ = Describe("Server", func() {
 				b := quicvarint.Append(nil, streamTypeControlStream)
 				b = (&settingsFrame{}).Append(b)
 				controlStr := mockquic.NewMockStream(mockCtrl)
-				r := bytes.NewReader(b)
+				r, w := io.Pipe()
+				go w.Write(b)
 				controlStr.EXPECT().Read(gomock.Any()).DoAndReturn(r.Read).AnyTimes()
 				conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
 					return controlStr, nil
@@ -530,7 +844,24 @@ var _ = Describe("Server", func() {
 					name = "decoder"
 				}
 
-				It(fmt.Sprintf("ignores the QPACK %s streams", name), func() {
+				It(fmt.Sprintf("accepts the QPACK %s stream", name), func() {
+					r, w := io.Pipe()
+					go w.Write(quicvarint.Append(nil, streamType))
+					str := mockquic.NewMockStream(mockCtrl)
+					str.EXPECT().Read(gomock.Any()).DoAndReturn(r.Read).AnyTimes()
+
+					conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
+						return str, nil
+					})
+					conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
+						<-testDone
+						return nil, errors.New("test done")
+					})
+					s.handleConn(conn)
+					time.Sleep(scaleDuration(20 * time.Millisecond)) // don't EXPECT any calls to str.CancelRead or conn.CloseWithError
+				})
+
+				It(fmt.Sprintf("errors when the QPACK %s stream is closed", name), func() {
 					buf := bytes.NewBuffer(quicvarint.Append(nil, streamType))
 					str := mockquic.NewMockStream(mockCtrl)
 					str.EXPECT().Read(gomock.Any()).DoAndReturn(buf.Read).AnyTimes()
@@ -542,11 +873,65 @@ var _ = Describe("Server", func() {
 						<-testDone
 						return nil, errors.New("test done")
 					})
+					done := make(chan struct{})
+					conn.EXPECT().CloseWithError(quic.ApplicationErrorCode(ErrCodeClosedCriticalStream), gomock.Any()).Do(func(quic.ApplicationErrorCode, string) error {
+						close(done)
+						return nil
+					})
+					s.handleConn(conn)
+					Eventually(done).Should(BeClosed())
+				})
+
+				It(fmt.Sprintf("errors when a second QPACK %s stream is opened", name), func() {
+					r, w := io.Pipe()
+					go w.Write(quicvarint.Append(nil, streamType))
+					str1 := mockquic.NewMockStream(mockCtrl)
+					str1.EXPECT().Read(gomock.Any()).DoAndReturn(r.Read).AnyTimes()
+					buf := bytes.NewBuffer(quicvarint.Append(nil, streamType))
+					str2 := mockquic.NewMockStream(mockCtrl)
+					str2.EXPECT().Read(gomock.Any()).DoAndReturn(buf.Read).AnyTimes()
+
+					conn.EXPECT().AcceptUniStream(gomock.Any()).Return(str1, nil)
+					conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
+						time.Sleep(scaleDuration(10 * time.Millisecond)) // make sure the first stream is processed first
+						return str2, nil
+					})
+					conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
+						<-testDone
+						return nil, errors.New("test done")
+					})
+					done := make(chan struct{})
+					conn.EXPECT().CloseWithError(quic.ApplicationErrorCode(ErrCodeStreamCreationError), gomock.Any()).Do(func(quic.ApplicationErrorCode, string) error {
+						close(done)
+						return nil
+					})
 					s.handleConn(conn)
-					time.Sleep(scaleDuration(20 * time.Millisecond)) // don't EXPECT any calls to str.CancelRead
+					Eventually(done).Should(BeClosed())
 				})
 			}
 
+			It("closes the connection when receiving an invalid QPACK encoder stream instruction", func() {
+				b := quicvarint.Append(nil, streamTypeQPACKEncoderStream)
+				b = append(b, 0x3f, 0xe2, 0x1f) // Set Dynamic Table Capacity to 4097, which exceeds our maximum
+				str := mockquic.NewMockStream(mockCtrl)
+				str.EXPECT().Read(gomock.Any()).DoAndReturn(bytes.NewReader(b).Read).AnyTimes()
+
+				conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
+					return str, nil
+				})
+				conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
+					<-testDone
+					return nil, errors.New("test done")
+				})
+				done := make(chan struct{})
+				conn.EXPECT().CloseWithError(quic.ApplicationErrorCode(ErrCodeQPACKEncoderStreamError), gomock.Any()).Do(func(quic.ApplicationErrorCode, string) error {
+					close(done)
+					return nil
+				})
+				s.handleConn(conn)
+				Eventually(done).Should(BeClosed())
+			})
+
 			It("reset streams other than the control stream and the QPACK streams", func() {
 				buf := bytes.NewBuffer(quicvarint.Append(nil, 0o1337))
 				str := mockquic.NewMockStream(mockCtrl)
@@ -589,8 +974,11 @@ var _ = Describe("Server", func() {
 
 			It("errors when parsing the frame on the control stream fails", func() {
 				b := quicvarint.Append(nil, streamTypeControlStream)
-				b = (&settingsFrame{}).Append(b)
-				r := bytes.NewReader(b[:len(b)-1])
+				b = quicvarint.Append(b, 0x4) // SETTINGS frame
+				b = quicvarint.Append(b, 4)
+				b = append(b, settingMaxFieldSectionSize, 0x1, settingMaxFieldSectionSize, 0x1) // duplicate setting
+				r, w := io.Pipe()
+				go w.Write(b)
 				controlStr := mockquic.NewMockStream(mockCtrl)
 				controlStr.EXPECT().Read(gomock.Any()).DoAndReturn(r.Read).AnyTimes()
 				conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
@@ -609,6 +997,51 @@ var _ = Describe("Server", func() {
 				Eventually(done).Should(BeClosed())
 			})
 
+			It("errors when the control stream is closed", func() {
+				b := quicvarint.Append(nil, streamTypeControlStream)
+				b = (&settingsFrame{}).Append(b)
+				r := bytes.NewReader(b)
+				controlStr := mockquic.NewMockStream(mockCtrl)
+				controlStr.EXPECT().Read(gomock.Any()).DoAndReturn(r.Read).AnyTimes()
+				conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
+					return controlStr, nil
+				})
+				conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
+					<-testDone
+					return nil, errors.New("test done")
+				})
+				done := make(chan struct{})
+				conn.EXPECT().CloseWithError(quic.ApplicationErrorCode(ErrCodeClosedCriticalStream), gomock.Any()).Do(func(quic.ApplicationErrorCode, string) error {
+					close(done)
+					return nil
+				})
+				s.handleConn(conn)
+				Eventually(done).Should(BeClosed())
+			})
+
+			It("errors when a second control stream is opened", func() {
+				b := quicvarint.Append(nil, streamTypeControlStream)
+				b = (&settingsFrame{}).Append(b)
+				for i := 0; i < 2; i++ {
+					r, w := io.Pipe()
+					go w.Write(b)
+					controlStr := mockquic.NewMockStream(mockCtrl)
+					controlStr.EXPECT().Read(gomock.Any()).DoAndReturn(r.Read).AnyTimes()
+					conn.EXPECT().AcceptUniStream(gomock.Any()).Return(controlStr, nil)
+				}
+				conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
+					<-testDone
+					return nil, errors.New("test done")
+				})
+				done := make(chan struct{})
+				conn.EXPECT().CloseWithError(quic.ApplicationErrorCode(ErrCodeStreamCreationError), "duplicate control stream").Do(func(quic.ApplicationErrorCode, string) error {
+					close(done)
+					return nil
+				})
+				s.handleConn(conn)
+				Eventually(done).Should(BeClosed())
+			})
+
 			It("errors when the client opens a push stream", func() {
 				b := quicvarint.Append(nil, streamTypePushStream)
 				b = (&dataFrame{}).Append(b)
@@ -633,6 +1066,10 @@ var _ = Describe("Server", func() {
 
 			It("errors when the client advertises datagram support (and we enabled support for it)", func() {
 				s.EnableDatagrams = true
+				conn.EXPECT().ReceiveDatagram(gomock.Any()).DoAndReturn(func(context.Context) ([]byte, error) {
+					<-testDone
+					return nil, errors.New("test done")
+				})
 				b := quicvarint.Append(nil, streamTypeControlStream)
 				b = (&settingsFrame{Datagram: true}).Append(b)
 				r := bytes.NewReader(b)
@@ -654,6 +1091,176 @@ var _ = Describe("Server", func() {
 				s.handleConn(conn)
 				Eventually(done).Should(BeClosed())
 			})
+			It("errors when the client sends a PRIORITY_UPDATE frame for a push stream", func() {
+				b := quicvarint.Append(nil, streamTypeControlStream)
+				b = (&settingsFrame{}).Append(b)
+				b = (&priorityUpdateFrame{IsPush: true, PrioritizedElementID: 1, PriorityFieldValue: "u=1"}).Append(b)
+				r := bytes.NewReader(b)
+				controlStr := mockquic.NewMockStream(mockCtrl)
+				controlStr.EXPECT().Read(gomock.Any()).DoAndReturn(r.Read).AnyTimes()
+				conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
+					return controlStr, nil
+				})
+				conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
+					<-testDone
+					return nil, errors.New("test done")
+				})
+				done := make(chan struct{})
+				conn.EXPECT().CloseWithError(quic.ApplicationErrorCode(ErrCodeIDError), gomock.Any()).Do(func(quic.ApplicationErrorCode, string) error {
+					close(done)
+					return nil
+				})
+				s.handleConn(conn)
+				Eventually(done).Should(BeClosed())
+			})
+
+			It("errors when the client sends a PRIORITY_UPDATE frame for a stream that's not a request stream", func() {
+				b := quicvarint.Append(nil, streamTypeControlStream)
+				b = (&settingsFrame{}).Append(b)
+				b = (&priorityUpdateFrame{PrioritizedElementID: 2, PriorityFieldValue: "u=1"}).Append(b)
+				r := bytes.NewReader(b)
+				controlStr := mockquic.NewMockStream(mockCtrl)
+				controlStr.EXPECT().Read(gomock.Any()).DoAndReturn(r.Read).AnyTimes()
+				conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
+					return controlStr, nil
+				})
+				conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
+					<-testDone
+					return nil, errors.New("test done")
+				})
+				done := make(chan struct{})
+				conn.EXPECT().CloseWithError(quic.ApplicationErrorCode(ErrCodeIDError), gomock.Any()).Do(func(quic.ApplicationErrorCode, string) error {
+					close(done)
+					return nil
+				})
+				s.handleConn(conn)
+				Eventually(done).Should(BeClosed())
+			})
+
+			It("accepts PRIORITY_UPDATE frames for request streams", func() {
+				b := quicvarint.Append(nil, streamTypeControlStream)
+				b = (&settingsFrame{}).Append(b)
+				b = (&priorityUpdateFrame{PrioritizedElementID: 4, PriorityFieldValue: "u=1"}).Append(b)
+				r, w := io.Pipe()
+				readDone := make(chan struct{})
+				go func() {
+					defer close(readDone)
+					w.Write(b)
+				}()
+				controlStr := mockquic.NewMockStream(mockCtrl)
+				controlStr.EXPECT().Read(gomock.Any()).DoAndReturn(r.Read).AnyTimes()
+				conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
+					return controlStr, nil
+				})
+				conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
+					<-testDone
+					return nil, errors.New("test done")
+				})
+				s.handleConn(conn)
+				Eventually(readDone).Should(BeClosed())
+				time.Sleep(scaleDuration(20 * time.Millisecond)) // don't EXPECT any calls to conn.CloseWithError
+			})
+
+			It("errors when the client sends a DATA frame on the control stream", func() {
+				b := quicvarint.Append(nil, streamTypeControlStream)
+				b = (&settingsFrame{}).Append(b)
+				b = (&dataFrame{Length: 6}).Append(b)
+				b = append(b, []byte("foobar")...)
+				r := bytes.NewReader(b)
+				controlStr := mockquic.NewMockStream(mockCtrl)
+				controlStr.EXPECT().Read(gomock.Any()).DoAndReturn(r.Read).AnyTimes()
+				conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
+					return controlStr, nil
+				})
+				conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
+					<-testDone
+					return nil, errors.New("test done")
+				})
+				done := make(chan struct{})
+				conn.EXPECT().CloseWithError(quic.ApplicationErrorCode(ErrCodeFrameUnexpected), gomock.Any()).Do(func(quic.ApplicationErrorCode, string) error {
+					close(done)
+					return nil
+				})
+				s.handleConn(conn)
+				Eventually(done).Should(BeClosed())
+			})
+
+			Context("frames sent after the SETTINGS frame", func() {
+				appendFrame := func(t uint64, payload []byte) []byte {
+					b := quicvarint.Append(nil, t)
+					b = quicvarint.Append(b, uint64(len(payload)))
+					return append(b, payload...)
+				}
+
+				// serveControlStream keeps the control stream open after the frames were read,
+				// and returns a channel that is closed once all frames were consumed.
+				serveControlStream := func(frames ...[]byte) <-chan struct{} {
+					b := quicvarint.Append(nil, streamTypeControlStream)
+					b = (&settingsFrame{}).Append(b)
+					for _, f := range frames {
+						b = append(b, f...)
+					}
+					r, w := io.Pipe()
+					readDone := make(chan struct{})
+					go func() {
+						defer close(readDone)
+						w.Write(b)
+					}()
+					controlStr := mockquic.NewMockStream(mockCtrl)
+					controlStr.EXPECT().Read(gomock.Any()).DoAndReturn(r.Read).AnyTimes()
+					conn.EXPECT().AcceptUniStream(gomock.Any()).Return(controlStr, nil)
+					conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
+						<-testDone
+						return nil, errors.New("test done")
+					})
+					s.handleConn(conn)
+					return readDone
+				}
+
+				for _, f := range []struct {
+					name  string
+					frame []byte
+				}{
+					{name: "HEADERS", frame: (&headersFrame{Length: 0}).Append(nil)},
+					{name: "SETTINGS", frame: (&settingsFrame{}).Append(nil)},
+					{name: "PUSH_PROMISE", frame: appendFrame(frameTypePushPromise, []byte{0})},
+					{name: "HTTP/2 PRIORITY", frame: appendFrame(frameTypeHTTP2Priority, make([]byte, 5))},
+					{name: "HTTP/2 PING", frame: appendFrame(frameTypeHTTP2Ping, make([]byte, 8))},
+					{name: "HTTP/2 WINDOW_UPDATE", frame: appendFrame(frameTypeHTTP2WindowUpdate, make([]byte, 4))},
+					{name: "HTTP/2 CONTINUATION", frame: appendFrame(frameTypeHTTP2Continuation, nil)},
+				} {
+					f := f
+
+					It(fmt.Sprintf("errors when the client sends a %s frame", f.name), func() {
+						done := make(chan struct{})
+						conn.EXPECT().CloseWithError(quic.ApplicationErrorCode(ErrCodeFrameUnexpected), gomock.Any()).Do(func(quic.ApplicationErrorCode, string) error {
+							close(done)
+							return nil
+						})
+						serveControlStream(f.frame)
+						Eventually(done).Should(BeClosed())
+					})
+				}
+
+				It("errors when the client sends a CANCEL_PUSH frame", func() {
+					done := make(chan struct{})
+					conn.EXPECT().CloseWithError(quic.ApplicationErrorCode(ErrCodeIDError), gomock.Any()).Do(func(quic.ApplicationErrorCode, string) error {
+						close(done)
+						return nil
+					})
+					serveControlStream(appendFrame(frameTypeCancelPush, []byte{0}))
+					Eventually(done).Should(BeClosed())
+				})
+
+				It("ignores MAX_PUSH_ID and GOAWAY frames", func() {
+					readDone := serveControlStream(
+						appendFrame(frameTypeMaxPushID, []byte{42}),
+						(&goAwayFrame{StreamID: 0}).Append(nil),
+					)
+					Eventually(readDone).Should(BeClosed())
+					time.Sleep(scaleDuration(20 * time.Millisecond)) // don't EXPECT any calls to conn.CloseWithError
+				})
+			})
 		})
 
 		Context("stream- and connection-level errors", func() {
@@ -671,6 +1278,7 @@ var _ = Describe("Server", func() {
 					<-testDone
 					return nil, errors.New("test done")
 				})
+				str.EXPECT().StreamID().AnyTimes()
 				conn.EXPECT().AcceptStream(gomock.Any()).Return(str, nil)
 				conn.EXPECT().AcceptStream(gomock.Any()).Return(nil, errors.New("done"))
 				conn.EXPECT().RemoteAddr().Return(addr).AnyTimes()
@@ -723,7 +1331,7 @@ var _ = Describe("Server", func() {
 				Eventually(handlerCalled).Should(BeClosed())
 			})
 
-			It("errors when the client sends a too large header frame", func() {
+			It("responds with 431 when the client sends a too large HEADERS frame", func() {
 				s.MaxHeaderBytes = 20
 				s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 					Fail("Handler should not be called.")
@@ -736,52 +1344,61 @@ var _ = Describe("Server", func() {
 				setRequest(append(requestData, b...))
 				done := make(chan struct{})
 				str.EXPECT().Write(gomock.Any()).DoAndReturn(responseBuf.Write).AnyTimes()
-				str.EXPECT().CancelWrite(quic.StreamErrorCode(ErrCodeFrameError)).Do(func(quic.StreamErrorCode) { close(done) })
+				str.EXPECT().CancelRead(quic.StreamErrorCode(ErrCodeNoError))
+				str.EXPECT().Close().Do(func() error { close(done); return nil })
+				// the field section isn't decoded, so a Stream Cancellation is sent on the QPACK decoder stream
+				decoderStr := mockquic.NewMockStream(mockCtrl)
+				var decoderStrBuf bytes.Buffer
+				decoderStr.EXPECT().Write(gomock.Any()).DoAndReturn(decoderStrBuf.Write)
+				conn.EXPECT().OpenUniStream().Return(decoderStr, nil)
 
 				s.handleConn(conn)
 				Eventually(done).Should(BeClosed())
+				Expect(decodeHeader(responseBuf)).To(HaveKeyWithValue(":status", []string{"431"}))
+				Expect(decoderStrBuf.Bytes()).To(Equal(appendQPACKInt(quicvarint.Append(nil, streamTypeQPACKDecoderStream), 6, 0x40, uint64(str.StreamID()))))
 			})
 
-			It("handles a request for which the client immediately resets the stream", func() {
-				handlerCalled := make(chan struct{})
+			It("responds with 431 when the decoded request header is too large", func() {
+				requestData := encodeRequest(exampleGetRequest)
+				f, err := parseNextFrame(bytes.NewReader(requestData), nil)
+				Expect(err).ToNot(HaveOccurred())
+				// The HEADERS frame itself is small enough, but the decoded field section is larger.
+				s.MaxHeaderBytes = int(f.(*headersFrame).Length)
 				s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-					close(handlerCalled)
+					Fail("Handler should not be called.")
 				})
 
-				testErr := errors.New("stream reset")
+				responseBuf := &bytes.Buffer{}
+				setRequest(requestData)
 				done := make(chan struct{})
-				str.EXPECT().Read(gomock.Any()).Return(0, testErr)
-				str.EXPECT().CancelWrite(quic.StreamErrorCode(ErrCodeRequestIncomplete)).Do(func(quic.StreamErrorCode) { close(done) })
+				str.EXPECT().Context().Return(reqContext)
+				str.EXPECT().Write(gomock.Any()).DoAndReturn(responseBuf.Write).AnyTimes()
+				str.EXPECT().CancelRead(quic.StreamErrorCode(ErrCodeNoError))
+				str.EXPECT().Close().Do(func() error { close(done); return nil })
 
 				s.handleConn(conn)
-				Consistently(handlerCalled).ShouldNot(BeClosed())
+				Eventually(done).Should(BeClosed())
+				Expect(decodeHeader(responseBuf)).To(HaveKeyWithValue(":status", []string{"431"}))
 			})
 
-			It("closes the connection when the first frame is not a HEADERS frame", func() {
+			It("handles a request for which the client immediately resets the stream", func() {
 				handlerCalled := make(chan struct{})
 				s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 					close(handlerCalled)
 				})
 
-				b := (&dataFrame{}).Append(nil)
-				setRequest(b)
-				str.EXPECT().Write(gomock.Any()).DoAndReturn(func(p []byte) (int, error) {
-					return len(p), nil
-				}).AnyTimes()
-
+				testErr := errors.New("stream reset")
 				done := make(chan struct{})
-				conn.EXPECT().CloseWithError(quic.ApplicationErrorCode(ErrCodeFrameUnexpected), gomock.Any()).Do(func(quic.ApplicationErrorCode, string) error {
-					close(done)
-					return nil
-				})
+				str.EXPECT().Read(gomock.Any()).Return(0, testErr)
+				str.EXPECT().CancelWrite(quic.StreamErrorCode(ErrCodeRequestIncomplete)).Do(func(quic.StreamErrorCode) { close(done) })
+
 				s.handleConn(conn)
-				Eventually(done).Should(BeClosed())
+				Consistently(handlerCalled).ShouldNot(BeClosed())
 			})
 
-			It("closes the connection when the first frame is not a HEADERS frame", func() {
-				handlerCalled := make(chan struct{})
+			It("uses http.DefaultMaxHeaderBytes if MaxHeaderBytes is not set", func() {
 				s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-					close(handlerCalled)
+					Fail("Handler should not be called.")
 				})
 
 				// use 2*DefaultMaxHeaderBytes here. qpack will compress the requiest,
@@ -790,15 +1407,18 @@ var _ = Describe("Server", func() {
 				req, err := http.NewRequest(http.MethodGet, "https://"+string(url), nil)
 				Expect(err).ToNot(HaveOccurred())
 				setRequest(encodeRequest(req))
-				// str.EXPECT().Context().Return(reqContext)
-				str.EXPECT().Write(gomock.Any()).DoAndReturn(func(p []byte) (int, error) {
-					return len(p), nil
-				}).AnyTimes()
+				responseBuf := &bytes.Buffer{}
+				str.EXPECT().Write(gomock.Any()).DoAndReturn(responseBuf.Write).AnyTimes()
+				str.EXPECT().CancelRead(quic.StreamErrorCode(ErrCodeNoError))
 				done := make(chan struct{})
-				str.EXPECT().CancelWrite(quic.StreamErrorCode(ErrCodeFrameError)).Do(func(quic.StreamErrorCode) { close(done) })
+				str.EXPECT().Close().Do(func() error { close(done); return nil })
+				decoderStr := mockquic.NewMockStream(mockCtrl)
+				decoderStr.EXPECT().Write(gomock.Any()).DoAndReturn(func(p []byte) (int, error) { return len(p), nil })
+				conn.EXPECT().OpenUniStream().Return(decoderStr, nil)
 
 				s.handleConn(conn)
 				Eventually(done).Should(BeClosed())
+				Expect(decodeHeader(responseBuf)).To(HaveKeyWithValue(":status", []string{"431"}))
 			})
 		})
 
@@ -819,7 +1439,7 @@ var _ = Describe("Server", func() {
 			}).AnyTimes()
 			str.EXPECT().CancelRead(quic.StreamErrorCode(ErrCodeNoError))
 
-			serr := s.handleRequest(conn, str, qpackDecoder, nil)
+			serr := s.handleRequest(conn, str, decoder, newQPACKEncoder(0, nil), newPriorityTracker(), newDatagramManager(conn, false), nil)
 			Expect(serr.err).ToNot(HaveOccurred())
 			Eventually(handlerCalled).Should(BeClosed())
 		})
@@ -842,12 +1462,207 @@ var _ = Describe("Server", func() {
 			}).AnyTimes()
 			str.EXPECT().CancelRead(quic.StreamErrorCode(ErrCodeNoError))
 
-			serr := s.handleRequest(conn, str, qpackDecoder, nil)
+			serr := s.handleRequest(conn, str, decoder, newQPACKEncoder(0, nil), newPriorityTracker(), newDatagramManager(conn, false), nil)
 			Expect(serr.err).ToNot(HaveOccurred())
 			Eventually(handlerCalled).Should(BeClosed())
 		})
 	})
 
+	Context("closing gracefully", func() {
+		var (
+			conn       *mockquic.MockEarlyConnection
+			controlStr *mockquic.MockStream
+			str        *mockquic.MockStream
+			connCtx    context.Context
+			connCancel context.CancelFunc
+			// streams accepted after the first request stream
+			incomingStreams chan quic.Stream
+		)
+
+		BeforeEach(func() {
+			connCtx, connCancel = context.WithCancel(context.Background())
+			incomingStreams = make(chan quic.Stream, 1)
+			conn = mockquic.NewMockEarlyConnection(mockCtrl)
+			controlStr = mockquic.NewMockStream(mockCtrl)
+			controlStr.EXPECT().Write(gomock.Any()) // SETTINGS frame
+			conn.EXPECT().OpenUniStream().Return(controlStr, nil)
+			conn.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
+				<-connCtx.Done()
+				return nil, errors.New("connection closed")
+			})
+			conn.EXPECT().Context().Return(connCtx).AnyTimes()
+			conn.EXPECT().RemoteAddr().Return(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1337}).AnyTimes()
+			conn.EXPECT().LocalAddr().AnyTimes()
+			conn.EXPECT().ConnectionState().Return(quic.ConnectionState{}).AnyTimes()
+
+			str = mockquic.NewMockStream(mockCtrl)
+			str.EXPECT().StreamID().Return(quic.StreamID(8)).AnyTimes()
+			str.EXPECT().SetPriority(DefaultPriority.streamPriority())
+			var req bytes.Buffer
+			rw := newRequestWriter(newQPACKEncoder(0, nil), utils.DefaultLogger)
+			reqStr := mockquic.NewMockStream(mockCtrl)
+			reqStr.EXPECT().Write(gomock.Any()).DoAndReturn(req.Write).AnyTimes()
+			reqStr.EXPECT().StreamID().AnyTimes()
+			httpReq, err := http.NewRequest(http.MethodGet, "https://www.example.com", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rw.WriteRequestHeader(reqStr, httpReq, false)).To(Succeed())
+			str.EXPECT().Read(gomock.Any()).DoAndReturn(func(p []byte) (int, error) {
+				if req.Len() == 0 {
+					return 0, io.EOF
+				}
+				return req.Read(p)
+			}).AnyTimes()
+			str.EXPECT().Context().Return(context.Background())
+			str.EXPECT().Write(gomock.Any()).DoAndReturn(func(p []byte) (int, error) { return len(p), nil }).AnyTimes()
+			str.EXPECT().CancelRead(quic.StreamErrorCode(ErrCodeNoError)).AnyTimes()
+
+			conn.EXPECT().AcceptStream(gomock.Any()).Return(str, nil)
+			// AcceptStream might still be called after this test finished
+			incoming := incomingStreams
+			conn.EXPECT().AcceptStream(gomock.Any()).DoAndReturn(func(ctx context.Context) (quic.Stream, error) {
+				select {
+				case str := <-incoming:
+					return str, nil
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+			}).AnyTimes()
+		})
+
+		AfterEach(func() { connCancel() })
+
+		It("sends a GOAWAY frame and closes the connection once the running requests have completed", func() {
+			handlerStarted := make(chan struct{})
+			releaseHandler := make(chan struct{})
+			s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				close(handlerStarted)
+				<-releaseHandler
+			})
+			goAwaySent := make(chan struct{})
+			controlStr.EXPECT().Write((&goAwayFrame{StreamID: 12}).Append(nil)).Do(func([]byte) (int, error) {
+				close(goAwaySent)
+				return 0, nil
+			})
+			requestDone := make(chan time.Time, 1)
+			str.EXPECT().Close().Do(func() error { requestDone <- time.Now(); return nil })
+
+			go s.handleConn(conn)
+			Eventually(handlerStarted).Should(BeClosed())
+
+			closed := make(chan error, 1)
+			go func() { closed <- s.CloseGracefully(time.Minute) }()
+			Eventually(goAwaySent).Should(BeClosed())
+			Consistently(closed, scaleDuration(50*time.Millisecond)).ShouldNot(Receive())
+			connClosed := make(chan time.Time, 1)
+			conn.EXPECT().CloseWithError(quic.ApplicationErrorCode(ErrCodeNoError), gomock.Any()).Do(func(quic.ApplicationErrorCode, string) error {
+				connClosed <- time.Now()
+				connCancel()
+				return nil
+			})
+			conn.EXPECT().Stats().Return(quic.ConnectionStats{SmoothedRTT: scaleDuration(10 * time.Millisecond)})
+			close(releaseHandler)
+			var requestDoneTime time.Time
+			Eventually(requestDone).Should(Receive(&requestDoneTime))
+			Eventually(closed).Should(Receive(BeNil()))
+			// the client is given 3 PTOs to receive the response
+			var closeTime time.Time
+			Expect(connClosed).To(Receive(&closeTime))
+			Expect(closeTime.Sub(requestDoneTime)).To(BeNumerically(">=", 3*scaleDuration(10*time.Millisecond)))
+		})
+
+		It("doesn't close the connection if the client closes it after the running requests have completed", func() {
+			s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+			controlStr.EXPECT().Write((&goAwayFrame{StreamID: 12}).Append(nil))
+			requestDone := make(chan struct{})
+			str.EXPECT().Close().Do(func() error { close(requestDone); return nil })
+			conn.EXPECT().Stats().Return(quic.ConnectionStats{SmoothedRTT: time.Hour})
+
+			go s.handleConn(conn)
+			Eventually(requestDone).Should(BeClosed())
+			closed := make(chan error, 1)
+			go func() { closed <- s.CloseGracefully(time.Minute) }()
+			Consistently(closed, scaleDuration(50*time.Millisecond)).ShouldNot(Receive())
+			connCancel()
+			Eventually(closed).Should(Receive(BeNil()))
+		})
+
+		It("rejects requests sent after the GOAWAY frame", func() {
+			handlerCalled := make(chan struct{})
+			s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { close(handlerCalled) })
+			goAwaySent := make(chan struct{})
+			controlStr.EXPECT().Write((&goAwayFrame{StreamID: 12}).Append(nil)).Do(func([]byte) (int, error) {
+				close(goAwaySent)
+				return 0, nil
+			})
+			str.EXPECT().Close()
+			// There are no running requests, so the connection is closed right away.
+			// Streams that are still accepted until the connection is closed are rejected.
+			conn.EXPECT().Stats().Return(quic.ConnectionStats{})
+			conn.EXPECT().CloseWithError(quic.ApplicationErrorCode(ErrCodeNoError), gomock.Any())
+
+			go s.handleConn(conn)
+			Eventually(handlerCalled).Should(BeClosed())
+			closed := make(chan error, 1)
+			go func() { closed <- s.CloseGracefully(time.Minute) }()
+			Eventually(goAwaySent).Should(BeClosed())
+
+			rejected := make(chan struct{})
+			lateStr := mockquic.NewMockStream(mockCtrl)
+			lateStr.EXPECT().CancelRead(quic.StreamErrorCode(ErrCodeRequestRejected))
+			lateStr.EXPECT().CancelWrite(quic.StreamErrorCode(ErrCodeRequestRejected)).Do(func(quic.StreamErrorCode) { close(rejected) })
+			incomingStreams <- lateStr
+			Eventually(rejected).Should(BeClosed())
+			connCancel()
+			Eventually(closed).Should(Receive(BeNil()))
+		})
+
+		It("closes the connection when the timeout expires", func() {
+			handlerStarted := make(chan struct{})
+			releaseHandler := make(chan struct{})
+			s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				close(handlerStarted)
+				<-releaseHandler
+			})
+			controlStr.EXPECT().Write((&goAwayFrame{StreamID: 12}).Append(nil))
+			requestDone := make(chan struct{})
+			str.EXPECT().Close().Do(func() error { close(requestDone); return nil })
+			conn.EXPECT().CloseWithError(quic.ApplicationErrorCode(ErrCodeNoError), gomock.Any()).Do(func(quic.ApplicationErrorCode, string) error {
+				connCancel()
+				return nil
+			})
+
+			go s.handleConn(conn)
+			Eventually(handlerStarted).Should(BeClosed())
+
+			start := time.Now()
+			Expect(s.CloseGracefully(scaleDuration(100 * time.Millisecond))).To(Succeed())
+			Expect(time.Since(start)).To(BeNumerically(">=", scaleDuration(100*time.Millisecond)))
+			close(releaseHandler)
+			Eventually(requestDone).Should(BeClosed())
+		})
+	})
+
+	It("returns http.ErrServerClosed from ServeListener after CloseGracefully", func() {
+		ln := newMockAddrListener(":443")
+		ln.EXPECT().Addr().AnyTimes()
+		lnClosed := make(chan struct{})
+		ln.EXPECT().Accept(gomock.Any()).DoAndReturn(func(context.Context) (quic.EarlyConnection, error) {
+			<-lnClosed
+			return nil, quic.ErrServerClosed
+		})
+		ln.EXPECT().Close().Do(func() error { close(lnClosed); return nil })
+		served := make(chan error, 1)
+		go func() { served <- s.ServeListener(ln) }()
+		Eventually(func() int {
+			s.mutex.RLock()
+			defer s.mutex.RUnlock()
+			return len(s.listeners)
+		}).Should(Equal(1))
+
+		Expect(s.CloseGracefully(time.Second)).To(Succeed())
+		Eventually(served).Should(Receive(Equal(http.ErrServerClosed)))
+	})
+
 	Context("setting http headers", func() {
 		BeforeEach(func() {
 			s.QuicConfig = &quic.Config{Versions: []protocol.VersionNumber{protocol.Version1}}
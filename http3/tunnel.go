@@ -0,0 +1,71 @@
+package http3
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/quic-go/quic-go"
+)
+
+// A Tunneler allows handlers to take over the request stream of a CONNECT request, see section 4.4 of RFC 9114.
+// It is implemented by the http.ResponseWriter passed to the handler.
+//
+// Tunnel sends the response header (using status 200, unless the handler already set a status),
+// and returns a net.Conn that reads the data sent by the client and writes data to the client.
+// After Tunnel was called, the handler must not use the http.ResponseWriter and the http.Request.Body any more.
+// The stream is owned by the handler, and needs to be closed by closing the net.Conn.
+type Tunneler interface {
+	Tunnel() (net.Conn, error)
+}
+
+var errNotConnect = errors.New("http3: tunnels can only be established for CONNECT requests")
+
+// tunnelConn is a net.Conn for the request stream of a CONNECT request.
+// Data is sent and received in DATA frames.
+type tunnelConn struct {
+	Stream
+	localAddr, remoteAddr net.Addr
+}
+
+var _ net.Conn = &tunnelConn{}
+
+func (c *tunnelConn) Read(b []byte) (int, error) {
+	n, err := c.Stream.Read(b)
+	return n, maybeReplaceError(err)
+}
+
+func (c *tunnelConn) Write(b []byte) (int, error) {
+	n, err := c.Stream.Write(b)
+	return n, maybeReplaceError(err)
+}
+
+// Close closes the stream in both directions.
+// Data that was already written is still delivered to the peer.
+func (c *tunnelConn) Close() error {
+	c.Stream.CancelRead(quic.StreamErrorCode(ErrCodeNoError))
+	return c.Stream.Close()
+}
+
+func (c *tunnelConn) LocalAddr() net.Addr  { return c.localAddr }
+func (c *tunnelConn) RemoteAddr() net.Addr { return c.remoteAddr }
+
+// Tunnel takes over the request stream of a CONNECT request.
+func (w *responseWriter) Tunnel() (net.Conn, error) {
+	if w.connectBody == nil {
+		return nil, errNotConnect
+	}
+	if err := w.FlushError(); err != nil {
+		return nil, err
+	}
+	// A CONNECT request is only successful if a 2xx response is sent, see section 9.3.6 of RFC 9110.
+	if w.status < 200 || w.status >= 300 {
+		return nil, fmt.Errorf("http3: cannot establish a tunnel after sending a %d (%s) response", w.status, http.StatusText(w.status))
+	}
+	return &tunnelConn{
+		Stream:     w.connectBody.HTTPStream(),
+		localAddr:  w.conn.LocalAddr(),
+		remoteAddr: w.conn.RemoteAddr(),
+	}, nil
+}
@@ -0,0 +1,92 @@
+package self_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/congestion"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// countingSendAlgorithm counts the packets that were sent and acknowledged
+type countingSendAlgorithm struct {
+	congestion.SendAlgorithm
+	sent, acked *atomic.Int64
+}
+
+func (c *countingSendAlgorithm) OnPacketSent(t time.Time, bytesInFlight congestion.ByteCount, pnSpace congestion.PacketNumberSpace, pn congestion.PacketNumber, size congestion.ByteCount, isRetransmittable bool) {
+	c.sent.Add(1)
+	c.SendAlgorithm.OnPacketSent(t, bytesInFlight, pnSpace, pn, size, isRetransmittable)
+}
+
+func (c *countingSendAlgorithm) OnPacketAcked(pnSpace congestion.PacketNumberSpace, pn congestion.PacketNumber, ackedBytes, priorInFlight congestion.ByteCount, t time.Time) {
+	c.acked.Add(1)
+	c.SendAlgorithm.OnPacketAcked(pnSpace, pn, ackedBytes, priorInFlight, t)
+}
+
+var _ = Describe("Congestion Control", func() {
+	for name, constructor := range map[string]func(congestion.RTTStats, congestion.ByteCount) congestion.SendAlgorithm{
+		"Cubic":   congestion.NewCubic,
+		"NewReno": congestion.NewReno,
+		"BBR":     congestion.NewBBR,
+	} {
+		newCongestionControl := constructor
+
+		It(fmt.Sprintf("uses a custom congestion controller, wrapping %s", name), func() {
+			var numCreated, sent, acked atomic.Int64
+			conf := getQuicConfig(&quic.Config{
+				CongestionControl: func(rttStats congestion.RTTStats, initialMaxDatagramSize congestion.ByteCount) congestion.SendAlgorithm {
+					numCreated.Add(1)
+					return &countingSendAlgorithm{
+						SendAlgorithm: newCongestionControl(rttStats, initialMaxDatagramSize),
+						sent:          &sent,
+						acked:         &acked,
+					}
+				},
+			})
+			ln, err := quic.ListenAddr("localhost:0", getTLSConfig(), conf)
+			Expect(err).ToNot(HaveOccurred())
+			defer ln.Close()
+
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				defer close(done)
+				conn, err := ln.Accept(context.Background())
+				Expect(err).ToNot(HaveOccurred())
+				str, err := conn.OpenStream()
+				Expect(err).ToNot(HaveOccurred())
+				_, err = str.Write(PRDataLong)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(str.Close()).To(Succeed())
+			}()
+
+			conn, err := quic.DialAddr(
+				context.Background(),
+				fmt.Sprintf("localhost:%d", ln.Addr().(*net.UDPAddr).Port),
+				getTLSClientConfig(),
+				getQuicConfig(nil),
+			)
+			Expect(err).ToNot(HaveOccurred())
+			defer conn.CloseWithError(0, "")
+			str, err := conn.AcceptStream(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			data, err := io.ReadAll(str)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(Equal(PRDataLong))
+			Eventually(done).Should(BeClosed())
+
+			// one congestion controller for the server's connection
+			Expect(numCreated.Load()).To(BeEquivalentTo(1))
+			Expect(sent.Load()).To(BeNumerically(">", len(PRDataLong)/1500))
+			Eventually(acked.Load).Should(BeNumerically(">", len(PRDataLong)/1500))
+		})
+	}
+})
@@ -3,6 +3,7 @@ package self_test
 import (
 	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	mrand "math/rand"
 	"net"
@@ -175,4 +176,27 @@ var _ = Describe("Datagram test", func() {
 		close()
 		conn.CloseWithError(0, "")
 	})
+
+	It("rejects datagrams that are too large", func() {
+		ln, err := quic.ListenAddr("localhost:0", getTLSConfig(), getQuicConfig(&quic.Config{EnableDatagrams: true}))
+		Expect(err).ToNot(HaveOccurred())
+		defer ln.Close()
+		conn, err := quic.Dial(
+			context.Background(),
+			clientConn,
+			ln.Addr(),
+			getTLSClientConfig(),
+			getQuicConfig(&quic.Config{EnableDatagrams: true}),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		defer conn.CloseWithError(0, "")
+		Expect(conn.ConnectionState().SupportsDatagrams).To(BeTrue())
+
+		err = conn.SendDatagram(make([]byte, 1500))
+		Expect(err).To(MatchError(&quic.DatagramTooLargeError{}))
+		var tooLargeErr *quic.DatagramTooLargeError
+		Expect(errors.As(err, &tooLargeErr)).To(BeTrue())
+		Expect(tooLargeErr.MaxDataLen).To(BeNumerically("<", 1500))
+		Expect(conn.SendDatagram(make([]byte, tooLargeErr.MaxDataLen))).To(Succeed())
+	})
 })
@@ -10,6 +10,8 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/http/httptrace"
+	"net/textproto"
 	"os"
 	"strconv"
 	"time"
@@ -194,6 +196,31 @@ var _ = Describe("HTTP tests", func() {
 		Eventually(handlerCalled).Should(BeClosed())
 	})
 
+	It("uses priorities", func() {
+		mux.HandleFunc("/prioritized", func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			Expect(http3.ParsePriority(r.Header.Get("Priority"))).To(Equal(http3.Priority{Urgency: 1, Incremental: true}))
+			w.(http3.PrioritySetter).SetPriority(http3.Priority{Urgency: 2})
+			w.Write(PRData)
+		})
+
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("https://localhost:%d/prioritized", port), nil)
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set("Priority", http3.Priority{Urgency: 1, Incremental: true}.String())
+		resp, err := client.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(200))
+		Expect(resp.Body.(http3.PriorityUpdater).UpdatePriority(http3.Priority{Urgency: 6})).To(Succeed())
+		body, err := io.ReadAll(gbytes.TimeoutReader(resp.Body, 5*time.Second))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(body).To(Equal(PRData))
+
+		// make sure the connection is still usable after sending the PRIORITY_UPDATE frame
+		resp, err = client.Get(fmt.Sprintf("https://localhost:%d/hello", port))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(200))
+	})
+
 	It("sets and gets response headers", func() {
 		mux.HandleFunc("/headers/response", func(w http.ResponseWriter, r *http.Request) {
 			defer GinkgoRecover()
@@ -208,6 +235,67 @@ var _ = Describe("HTTP tests", func() {
 		Expect(resp.Header.Get("lorem")).To(Equal("ipsum"))
 	})
 
+	It("sends Early Hints", func() {
+		mux.HandleFunc("/early-hints", func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			w.Header().Set("Link", "</style.css>; rel=preload; as=style")
+			w.WriteHeader(http.StatusEarlyHints)
+			w.Header().Add("Link", "</script.js>; rel=preload; as=script")
+			w.WriteHeader(http.StatusEarlyHints)
+			w.Write([]byte("foobar"))
+		})
+
+		var links [][]string
+		ctx := httptrace.WithClientTrace(context.Background(), &httptrace.ClientTrace{
+			Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+				Expect(code).To(Equal(http.StatusEarlyHints))
+				links = append(links, header["Link"])
+				return nil
+			},
+		})
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("https://localhost:%d/early-hints", port), nil)
+		Expect(err).ToNot(HaveOccurred())
+		resp, err := client.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(200))
+		Expect(links).To(Equal([][]string{
+			{"</style.css>; rel=preload; as=style"},
+			{"</style.css>; rel=preload; as=style", "</script.js>; rel=preload; as=script"},
+		}))
+		Expect(resp.Header["Link"]).To(HaveLen(2))
+		body, err := io.ReadAll(gbytes.TimeoutReader(resp.Body, 3*time.Second))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(body)).To(Equal("foobar"))
+	})
+
+	It("sends and receives trailers", func() {
+		mux.HandleFunc("/trailers", func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			Expect(r.Trailer).To(HaveKey("Checksum"))
+			body, err := io.ReadAll(r.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(body)).To(Equal("foobar"))
+			Expect(r.Trailer.Get("Checksum")).To(Equal("1234"))
+			w.Header().Set("Trailer", "Grpc-Status")
+			w.Write([]byte("lorem ipsum"))
+			w.Header().Set("Grpc-Status", "0")
+			w.Header().Set(http.TrailerPrefix+"Grpc-Message", "ok")
+		})
+
+		req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("https://localhost:%d/trailers", port), bytes.NewReader([]byte("foobar")))
+		Expect(err).ToNot(HaveOccurred())
+		req.Trailer = http.Header{"Checksum": []string{"1234"}}
+		resp, err := client.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(200))
+		Expect(resp.Trailer).To(HaveKey("Grpc-Status"))
+		body, err := io.ReadAll(gbytes.TimeoutReader(resp.Body, 3*time.Second))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(body)).To(Equal("lorem ipsum"))
+		Expect(resp.Trailer.Get("Grpc-Status")).To(Equal("0"))
+		Expect(resp.Trailer.Get("Grpc-Message")).To(Equal("ok"))
+	})
+
 	It("downloads a small file", func() {
 		resp, err := client.Get(fmt.Sprintf("https://localhost:%d/prdata", port))
 		Expect(err).ToNot(HaveOccurred())
@@ -276,6 +364,45 @@ var _ = Describe("HTTP tests", func() {
 		Expect(body).To(Equal(PRData))
 	})
 
+	It("handles Expect: 100-continue", func() {
+		mux.HandleFunc("/upload", func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			if r.ContentLength > 1000 {
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+				return
+			}
+			body, err := io.ReadAll(r.Body)
+			Expect(err).ToNot(HaveOccurred())
+			w.Write(body)
+		})
+
+		rt.ExpectContinueTimeout = time.Hour
+		var got100 bool
+		ctx := httptrace.WithClientTrace(context.Background(), &httptrace.ClientTrace{
+			Got100Continue: func() { got100 = true },
+		})
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("https://localhost:%d/upload", port), bytes.NewReader([]byte("foobar")))
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set("Expect", "100-continue")
+		resp, err := client.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(200))
+		Expect(got100).To(BeTrue())
+		body, err := io.ReadAll(gbytes.TimeoutReader(resp.Body, 3*time.Second))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(body)).To(Equal("foobar"))
+
+		// The server rejects the request without reading the body.
+		// Since the body never ends, the request would time out if the client sent it.
+		req, err = http.NewRequest(http.MethodPost, fmt.Sprintf("https://localhost:%d/upload", port), io.LimitReader(neverEnding('a'), 1<<30))
+		Expect(err).ToNot(HaveOccurred())
+		req.ContentLength = 1 << 30
+		req.Header.Set("Expect", "100-continue")
+		resp, err = client.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(http.StatusRequestEntityTooLarge))
+	})
+
 	It("uses gzip compression", func() {
 		mux.HandleFunc("/gzipped/hello", func(w http.ResponseWriter, r *http.Request) {
 			defer GinkgoRecover()
@@ -410,6 +537,231 @@ var _ = Describe("HTTP tests", func() {
 		Expect(repl).To(Equal(data))
 	})
 
+	It("tunnels TCP connections using CONNECT", func() {
+		// the target of the tunnel echoes all data
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		defer ln.Close()
+		go func() {
+			defer GinkgoRecover()
+			conn, err := ln.Accept()
+			Expect(err).ToNot(HaveOccurred())
+			defer conn.Close()
+			io.Copy(conn, conn)
+		}()
+
+		// A CONNECT request doesn't have a :path, so it can't be routed by the http.ServeMux.
+		proxy := &http3.Server{
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+				Expect(r.Method).To(Equal(http.MethodConnect))
+				target, err := net.Dial("tcp", r.Host)
+				if err != nil {
+					w.WriteHeader(http.StatusBadGateway)
+					return
+				}
+				defer target.Close()
+				conn, err := w.(http3.Tunneler).Tunnel()
+				Expect(err).ToNot(HaveOccurred())
+				defer conn.Close()
+				go func() {
+					io.Copy(target, conn)
+					target.(*net.TCPConn).CloseWrite()
+				}()
+				io.Copy(conn, target)
+			}),
+			TLSConfig:  getTLSConfig(),
+			QuicConfig: getQuicConfig(nil),
+		}
+		udpConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 0})
+		Expect(err).ToNot(HaveOccurred())
+		defer udpConn.Close()
+		go proxy.Serve(udpConn)
+		defer proxy.Close()
+
+		pr, pw := io.Pipe()
+		req, err := http.NewRequest(http.MethodConnect, fmt.Sprintf("https://localhost:%d", udpConn.LocalAddr().(*net.UDPAddr).Port), pr)
+		Expect(err).ToNot(HaveOccurred())
+		req.Host = ln.Addr().String()
+		rsp, err := rt.RoundTrip(req)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+		defer rsp.Body.Close()
+
+		data := GeneratePRData(50 * 1024)
+		go func() {
+			defer GinkgoRecover()
+			_, err := pw.Write(data)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pw.Close()).To(Succeed())
+		}()
+		b, err := io.ReadAll(gbytes.TimeoutReader(rsp.Body, 5*time.Second))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(b).To(Equal(data))
+	})
+
+	It("tunnels data using Extended CONNECT", func() {
+		mux.HandleFunc("/chat", func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			Expect(r.Method).To(Equal(http.MethodConnect))
+			Expect(r.Proto).To(Equal("websocket"))
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			// echo everything sent by the client
+			b := make([]byte, 1024)
+			for {
+				n, err := r.Body.Read(b)
+				if n > 0 {
+					w.Write(b[:n])
+					w.(http.Flusher).Flush()
+				}
+				if err != nil {
+					Expect(err).To(Equal(io.EOF))
+					return
+				}
+			}
+		})
+
+		pr, pw := io.Pipe()
+		req, err := http.NewRequest(http.MethodConnect, fmt.Sprintf("https://localhost:%d/chat", port), pr)
+		Expect(err).ToNot(HaveOccurred())
+		req.Proto = "websocket"
+		rsp, err := rt.RoundTrip(req)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+		_, err = pw.Write([]byte("foobar"))
+		Expect(err).ToNot(HaveOccurred())
+		b := make([]byte, 6)
+		_, err = io.ReadFull(gbytes.TimeoutReader(rsp.Body, 3*time.Second), b)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(b).To(Equal([]byte("foobar")))
+		Expect(pw.Close()).To(Succeed())
+		_, err = io.ReadAll(gbytes.TimeoutReader(rsp.Body, 3*time.Second))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	Context("HTTP Datagrams", func() {
+		// handleDatagrams echoes all HTTP Datagrams received on CONNECT requests
+		handleDatagrams := func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			Expect(r.Method).To(Equal(http.MethodConnect))
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			datagrammer := w.(http3.HTTPDatagrammer)
+			for {
+				b, err := datagrammer.ReceiveDatagram(r.Context())
+				if err != nil {
+					return
+				}
+				datagrammer.SendDatagram(b)
+			}
+		}
+
+		sendConnectRequest := func(rt *http3.RoundTripper, port int) http3.HTTPDatagrammer {
+			req, err := http.NewRequest(http.MethodConnect, fmt.Sprintf("https://localhost:%d/datagrams", port), nil)
+			Expect(err).ToNot(HaveOccurred())
+			req.Proto = "connect-udp"
+			rsp, err := rt.RoundTripOpt(req, http3.RoundTripOpt{DontCloseRequestStream: true})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+			return rsp.Body.(http3.HTTPDatagrammer)
+		}
+
+		It("sends HTTP Datagrams in QUIC DATAGRAM frames", func() {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/datagrams", handleDatagrams)
+			server := &http3.Server{
+				Handler:         mux,
+				TLSConfig:       getTLSConfig(),
+				QuicConfig:      getQuicConfig(nil),
+				EnableDatagrams: true,
+			}
+			conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 0})
+			Expect(err).ToNot(HaveOccurred())
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				defer close(done)
+				server.Serve(conn)
+			}()
+			defer func() {
+				Expect(server.Close()).To(Succeed())
+				Eventually(done).Should(BeClosed())
+			}()
+
+			rt := &http3.RoundTripper{
+				TLSClientConfig: getTLSClientConfigWithoutServerName(),
+				QuicConfig:      getQuicConfig(nil),
+				EnableDatagrams: true,
+			}
+			defer rt.Close()
+			datagrammer := sendConnectRequest(rt, conn.LocalAddr().(*net.UDPAddr).Port)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			// QUIC datagrams might be lost, so send them until one is echoed
+			go func() {
+				for ctx.Err() == nil {
+					datagrammer.SendDatagram([]byte("foobar"))
+					time.Sleep(50 * time.Millisecond)
+				}
+			}()
+			b, err := datagrammer.ReceiveDatagram(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(b).To(Equal([]byte("foobar")))
+		})
+
+		It("sends HTTP Datagrams in DATAGRAM capsules if QUIC datagrams are not enabled", func() {
+			mux.HandleFunc("/datagrams", handleDatagrams)
+			datagrammer := sendConnectRequest(rt, port)
+			for i := 0; i < 3; i++ {
+				data := []byte(fmt.Sprintf("datagram %d", i))
+				Expect(datagrammer.SendDatagram(data)).To(Succeed())
+				ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+				b, err := datagrammer.ReceiveDatagram(ctx)
+				cancel()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(b).To(Equal(data))
+			}
+		})
+	})
+
+	It("completes running requests when closing gracefully", func() {
+		handlerStarted := make(chan struct{})
+		mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			close(handlerStarted)
+			time.Sleep(200 * time.Millisecond)
+			w.Write([]byte("done")) // don't check the error here. Stream may be reset.
+		})
+
+		type result struct {
+			body []byte
+			err  error
+		}
+		resChan := make(chan result, 1)
+		go func() {
+			resp, err := client.Get(fmt.Sprintf("https://localhost:%d/slow", port))
+			if err != nil {
+				resChan <- result{err: err}
+				return
+			}
+			body, err := io.ReadAll(resp.Body)
+			resChan <- result{body: body, err: err}
+		}()
+		Eventually(handlerStarted).Should(BeClosed())
+
+		closed := make(chan error, 1)
+		go func() { closed <- server.CloseGracefully(5 * time.Second) }()
+		var res result
+		Eventually(resChan).Should(Receive(&res))
+		Expect(res.err).ToNot(HaveOccurred())
+		Expect(string(res.body)).To(Equal("done"))
+		// the connection is closed once the response was received
+		Eventually(closed).Should(Receive(BeNil()))
+		Eventually(stoppedServing).Should(BeClosed())
+	})
+
 	It("serves other QUIC connections", func() {
 		tlsConf := getTLSConfig()
 		tlsConf.NextProtos = []string{http3.NextProtoH3}
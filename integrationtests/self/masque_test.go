@@ -0,0 +1,160 @@
+package self_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/quic-go/quic-go/masque"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MASQUE", func() {
+	for _, v := range []bool{true, false} {
+		enableDatagrams := v
+
+		Context(fmt.Sprintf("with datagrams %s on the proxy", map[bool]string{true: "enabled", false: "disabled"}[enableDatagrams]), func() {
+			var (
+				server         *http3.Server
+				proxy          *masque.Proxy
+				client         *masque.Client
+				template       *masque.Template
+				port           int
+				stoppedServing chan struct{}
+			)
+
+			BeforeEach(func() {
+				conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 0})
+				Expect(err).ToNot(HaveOccurred())
+				port = conn.LocalAddr().(*net.UDPAddr).Port
+				template, err = masque.NewTemplate(fmt.Sprintf("https://localhost:%d/masque?h={target_host}&p={target_port}", port))
+				Expect(err).ToNot(HaveOccurred())
+
+				proxy = &masque.Proxy{}
+				mux := http.NewServeMux()
+				mux.HandleFunc("/masque", func(w http.ResponseWriter, r *http.Request) {
+					req, err := masque.ParseRequest(r, template)
+					if err != nil {
+						w.WriteHeader(err.(*masque.RequestParseError).HTTPStatus)
+						return
+					}
+					proxy.Proxy(w, req)
+				})
+				server = &http3.Server{
+					Handler:         mux,
+					TLSConfig:       getTLSConfig(),
+					QuicConfig:      getQuicConfig(nil),
+					EnableDatagrams: enableDatagrams,
+				}
+				stoppedServing = make(chan struct{})
+				go func() {
+					defer GinkgoRecover()
+					server.Serve(conn)
+					close(stoppedServing)
+				}()
+
+				client = &masque.Client{
+					TLSClientConfig: getTLSClientConfigWithoutServerName(),
+					QuicConfig:      getQuicConfig(nil),
+				}
+			})
+
+			AfterEach(func() {
+				Expect(client.Close()).To(Succeed())
+				Expect(proxy.Close()).To(Succeed())
+				Expect(server.Close()).To(Succeed())
+				Eventually(stoppedServing).Should(BeClosed())
+			})
+
+			It("proxies UDP packets", func() {
+				target, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 0})
+				Expect(err).ToNot(HaveOccurred())
+				defer target.Close()
+				go func() {
+					// echo all packets
+					b := make([]byte, 1500)
+					for {
+						n, addr, err := target.ReadFrom(b)
+						if err != nil {
+							return
+						}
+						target.WriteTo(b[:n], addr)
+					}
+				}()
+
+				ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+				defer cancel()
+				rsp, conn, err := client.DialAddr(ctx, template, target.LocalAddr().String())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+				defer conn.Close()
+
+				for _, msg := range []string{"foo", "bar"} {
+					_, err = conn.WriteTo([]byte(msg), nil)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(conn.SetReadDeadline(time.Now().Add(3 * time.Second))).To(Succeed())
+					b := make([]byte, 1500)
+					n, addr, err := conn.ReadFrom(b)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(addr.String()).To(Equal(target.LocalAddr().String()))
+					Expect(string(b[:n])).To(Equal(msg))
+				}
+			})
+
+			It("refuses requests that don't match the template", func() {
+				otherTemplate, err := masque.NewTemplate(fmt.Sprintf("https://localhost:%d/foobar/{target_host}/{target_port}", port))
+				Expect(err).ToNot(HaveOccurred())
+				ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+				defer cancel()
+				rsp, _, err := client.DialAddr(ctx, otherTemplate, "localhost:1234")
+				Expect(err).To(HaveOccurred())
+				Expect(rsp.StatusCode).To(Equal(http.StatusNotFound))
+			})
+
+			It("dials QUIC connections through the proxy", func() {
+				ln, err := quic.ListenAddr("localhost:0", getTLSConfig(), getQuicConfig(nil))
+				Expect(err).ToNot(HaveOccurred())
+				defer ln.Close()
+				go func() {
+					defer GinkgoRecover()
+					conn, err := ln.Accept(context.Background())
+					Expect(err).ToNot(HaveOccurred())
+					str, err := conn.AcceptStream(context.Background())
+					Expect(err).ToNot(HaveOccurred())
+					_, err = io.Copy(str, str)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(str.Close()).To(Succeed())
+				}()
+
+				ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+				defer cancel()
+				rsp, pconn, err := client.Dial(ctx, template, ln.Addr().(*net.UDPAddr))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+				defer pconn.Close()
+				tr := &quic.Transport{Conn: pconn}
+				defer tr.Close()
+
+				conn, err := tr.Dial(ctx, ln.Addr(), getTLSClientConfig(), getQuicConfig(nil))
+				Expect(err).ToNot(HaveOccurred())
+				defer conn.CloseWithError(0, "")
+				str, err := conn.OpenStream()
+				Expect(err).ToNot(HaveOccurred())
+				data := GeneratePRData(50 * 1024)
+				_, err = str.Write(data)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(str.Close()).To(Succeed())
+				b, err := io.ReadAll(str)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(b).To(Equal(data))
+			})
+		})
+	}
+})
@@ -0,0 +1,182 @@
+package self_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"time"
+
+	"github.com/quic-go/quic-go"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Connection Migration", func() {
+	var (
+		server      *quic.Listener
+		serverConns chan quic.Connection
+	)
+
+	BeforeEach(func() {
+		ln, err := quic.ListenAddr("localhost:0", getTLSConfig(), getQuicConfig(nil))
+		Expect(err).ToNot(HaveOccurred())
+		server = ln
+		conns := make(chan quic.Connection, 10)
+		serverConns = conns
+		go func() {
+			defer GinkgoRecover()
+			for {
+				conn, err := ln.Accept(context.Background())
+				if err != nil {
+					return
+				}
+				conns <- conn
+				go func() {
+					defer GinkgoRecover()
+					for {
+						str, err := conn.AcceptStream(context.Background())
+						if err != nil {
+							return
+						}
+						go func() {
+							defer GinkgoRecover()
+							defer str.Close()
+							_, err := io.Copy(str, str)
+							Expect(err).ToNot(HaveOccurred())
+						}()
+					}
+				}()
+			}
+		}()
+	})
+
+	AfterEach(func() {
+		Expect(server.Close()).To(Succeed())
+	})
+
+	newTransport := func() *quic.Transport {
+		conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 0})
+		Expect(err).ToNot(HaveOccurred())
+		return &quic.Transport{Conn: conn}
+	}
+
+	echo := func(conn quic.Connection, data []byte) {
+		str, err := conn.OpenStream()
+		Expect(err).ToNot(HaveOccurred())
+		_, err = str.Write(data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(str.Close()).To(Succeed())
+		b, err := io.ReadAll(str)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(b).To(Equal(data))
+	}
+
+	It("migrates to a new path", func() {
+		tr1 := newTransport()
+		defer tr1.Close()
+		tr2 := newTransport()
+		defer tr2.Close()
+
+		conn, err := tr1.Dial(
+			context.Background(),
+			&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: server.Addr().(*net.UDPAddr).Port},
+			getTLSClientConfig(),
+			getQuicConfig(nil),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		defer conn.CloseWithError(0, "")
+		echo(conn, PRData)
+		Expect(conn.LocalAddr()).To(Equal(tr1.Conn.LocalAddr()))
+		var serverConn quic.Connection
+		Eventually(serverConns).Should(Receive(&serverConn))
+		Expect(serverConn.RemoteAddr().(*net.UDPAddr).Port).To(Equal(tr1.Conn.LocalAddr().(*net.UDPAddr).Port))
+
+		path, err := conn.AddPath(tr2)
+		Expect(err).ToNot(HaveOccurred())
+		// switching requires the path to be validated first
+		Expect(path.Switch()).To(MatchError(quic.ErrPathNotValidated))
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		Expect(path.Probe(ctx)).To(Succeed())
+		Expect(path.Switch()).To(Succeed())
+		Eventually(conn.LocalAddr).Should(Equal(tr2.Conn.LocalAddr()))
+
+		// the connection continues working on the new path
+		echo(conn, PRData)
+		Expect(conn.Context().Err()).ToNot(HaveOccurred())
+		// the server switched to the client's new address
+		Eventually(func() int { return serverConn.RemoteAddr().(*net.UDPAddr).Port }).Should(Equal(tr2.Conn.LocalAddr().(*net.UDPAddr).Port))
+	})
+
+	It("migrates to the server's preferred address", func() {
+		// The server listens on the unspecified address, so it receives packets sent to the preferred address as well.
+		udpConn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4zero, Port: 0})
+		Expect(err).ToNot(HaveOccurred())
+		tr := &quic.Transport{Conn: udpConn}
+		defer tr.Close()
+		port := udpConn.LocalAddr().(*net.UDPAddr).Port
+		preferredAddr := netip.AddrPortFrom(netip.AddrFrom4([4]byte{127, 0, 0, 2}), uint16(port))
+		ln, err := tr.Listen(getTLSConfig(), getQuicConfig(&quic.Config{
+			PreferredAddress: &quic.PreferredAddress{IPv4: preferredAddr},
+		}))
+		Expect(err).ToNot(HaveOccurred())
+		defer ln.Close()
+		serverConnChan := make(chan quic.Connection, 1)
+		go func() {
+			defer GinkgoRecover()
+			conn, err := ln.Accept(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			serverConnChan <- conn
+			str, err := conn.AcceptStream(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			defer str.Close()
+			_, err = io.Copy(str, str)
+			Expect(err).ToNot(HaveOccurred())
+		}()
+
+		conn, err := quic.DialAddr(
+			context.Background(),
+			fmt.Sprintf("127.0.0.1:%d", port),
+			getTLSClientConfig(),
+			getQuicConfig(nil),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		defer conn.CloseWithError(0, "")
+		Eventually(func() string { return conn.RemoteAddr().String() }).Should(Equal(preferredAddr.String()))
+		echo(conn, PRData)
+
+		var serverConn quic.Connection
+		Eventually(serverConnChan).Should(Receive(&serverConn))
+		Eventually(func() string { return serverConn.LocalAddr().String() }).Should(Equal(preferredAddr.String()))
+	})
+
+	It("closes a path that was probed", func() {
+		tr1 := newTransport()
+		defer tr1.Close()
+		tr2 := newTransport()
+		defer tr2.Close()
+
+		conn, err := tr1.Dial(
+			context.Background(),
+			&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: server.Addr().(*net.UDPAddr).Port},
+			getTLSClientConfig(),
+			getQuicConfig(nil),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		defer conn.CloseWithError(0, "")
+
+		path, err := conn.AddPath(tr2)
+		Expect(err).ToNot(HaveOccurred())
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		Expect(path.Probe(ctx)).To(Succeed())
+		Expect(path.Close()).To(Succeed())
+		Expect(path.Switch()).To(MatchError(quic.ErrPathClosed))
+
+		echo(conn, PRData)
+		Expect(conn.LocalAddr()).To(Equal(tr1.Conn.LocalAddr()))
+	})
+})
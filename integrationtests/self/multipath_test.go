@@ -0,0 +1,212 @@
+package self_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/quic-go/quic-go"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// countingPacketConn counts the packets sent and received
+type countingPacketConn struct {
+	net.PacketConn
+	sent, rcvd atomic.Int64
+}
+
+func (c *countingPacketConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	n, addr, err := c.PacketConn.ReadFrom(b)
+	if err == nil {
+		c.rcvd.Add(1)
+	}
+	return n, addr, err
+}
+
+func (c *countingPacketConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	c.sent.Add(1)
+	return c.PacketConn.WriteTo(b, addr)
+}
+
+var _ = Describe("Multipath", func() {
+	runServer := func(conf *quic.Config) (*quic.Listener, <-chan quic.Connection) {
+		ln, err := quic.ListenAddr("localhost:0", getTLSConfig(), getQuicConfig(conf))
+		Expect(err).ToNot(HaveOccurred())
+		conns := make(chan quic.Connection, 1)
+		go func() {
+			defer GinkgoRecover()
+			conn, err := ln.Accept(context.Background())
+			if err != nil {
+				return
+			}
+			conns <- conn
+			for {
+				str, err := conn.AcceptStream(context.Background())
+				if err != nil {
+					return
+				}
+				go func() {
+					defer GinkgoRecover()
+					defer str.Close()
+					_, err := io.Copy(str, str)
+					Expect(err).ToNot(HaveOccurred())
+				}()
+			}
+		}()
+		return ln, conns
+	}
+
+	newTransport := func() (*quic.Transport, *countingPacketConn) {
+		conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 0})
+		Expect(err).ToNot(HaveOccurred())
+		c := &countingPacketConn{PacketConn: conn}
+		return &quic.Transport{Conn: c}, c
+	}
+
+	echo := func(conn quic.Connection, data []byte) {
+		str, err := conn.OpenStream()
+		Expect(err).ToNot(HaveOccurred())
+		go func() {
+			defer GinkgoRecover()
+			_, err := str.Write(data)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(str.Close()).To(Succeed())
+		}()
+		b, err := io.ReadAll(str)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(b).To(Equal(data))
+	}
+
+	dial := func(tr *quic.Transport, addr net.Addr) quic.Connection {
+		conn, err := tr.Dial(
+			context.Background(),
+			&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: addr.(*net.UDPAddr).Port},
+			getTLSClientConfig(),
+			getQuicConfig(&quic.Config{EnableMultipath: true}),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		return conn
+	}
+
+	It("uses multiple paths at the same time", func() {
+		ln, serverConns := runServer(&quic.Config{EnableMultipath: true})
+		defer ln.Close()
+		tr1, _ := newTransport()
+		defer tr1.Close()
+		tr2, c2 := newTransport()
+		defer tr2.Close()
+
+		conn := dial(tr1, ln.Addr())
+		defer conn.CloseWithError(0, "")
+		Expect(conn.ConnectionState().Multipath).To(BeTrue())
+		var serverConn quic.Connection
+		Eventually(serverConns).Should(Receive(&serverConn))
+		Expect(serverConn.ConnectionState().Multipath).To(BeTrue())
+
+		path, err := conn.AddPath(tr2)
+		Expect(err).ToNot(HaveOccurred())
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		Expect(path.Probe(ctx)).To(Succeed())
+		// there's no need to switch paths, all validated paths are used
+		Expect(path.Switch()).ToNot(Succeed())
+
+		sent, rcvd := c2.sent.Load(), c2.rcvd.Load()
+		echo(conn, PRDataLong)
+		Expect(conn.Context().Err()).ToNot(HaveOccurred())
+		// both the client and the server sent packets on the new path
+		Expect(c2.sent.Load()).To(BeNumerically(">", sent+10))
+		Expect(c2.rcvd.Load()).To(BeNumerically(">", rcvd+10))
+		// the initial path is still used
+		Expect(conn.LocalAddr()).To(Equal(tr1.Conn.LocalAddr()))
+		Expect(serverConn.RemoteAddr().(*net.UDPAddr).Port).To(Equal(tr1.Conn.LocalAddr().(*net.UDPAddr).Port))
+	})
+
+	It("doesn't use a path in standby mode", func() {
+		ln, serverConns := runServer(&quic.Config{EnableMultipath: true})
+		defer ln.Close()
+		tr1, _ := newTransport()
+		defer tr1.Close()
+		tr2, c2 := newTransport()
+		defer tr2.Close()
+
+		conn := dial(tr1, ln.Addr())
+		defer conn.CloseWithError(0, "")
+		Eventually(serverConns).Should(Receive())
+
+		path, err := conn.AddPath(tr2)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(path.SetStandby(true)).To(MatchError(quic.ErrPathNotValidated))
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		Expect(path.Probe(ctx)).To(Succeed())
+		Expect(path.SetStandby(true)).To(Succeed())
+		// make sure the PATH_STATUS frame is received by the server
+		echo(conn, []byte("foobar"))
+
+		sent, rcvd := c2.sent.Load(), c2.rcvd.Load()
+		echo(conn, PRDataLong)
+		// Packets might still be sent to acknowledge packets received on the path.
+		Expect(c2.sent.Load()).To(BeNumerically("<=", sent+2))
+		Expect(c2.rcvd.Load()).To(BeNumerically("<=", rcvd+2))
+	})
+
+	It("closes a path", func() {
+		ln, serverConns := runServer(&quic.Config{EnableMultipath: true})
+		defer ln.Close()
+		tr1, _ := newTransport()
+		defer tr1.Close()
+		tr2, c2 := newTransport()
+		defer tr2.Close()
+
+		conn := dial(tr1, ln.Addr())
+		defer conn.CloseWithError(0, "")
+		Eventually(serverConns).Should(Receive())
+
+		path, err := conn.AddPath(tr2)
+		Expect(err).ToNot(HaveOccurred())
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		Expect(path.Probe(ctx)).To(Succeed())
+		echo(conn, PRData)
+		Expect(path.Close()).To(Succeed())
+		// make sure the PATH_ABANDON frame is received by the server
+		echo(conn, []byte("foobar"))
+		time.Sleep(scaleDuration(20 * time.Millisecond))
+
+		sent, rcvd := c2.sent.Load(), c2.rcvd.Load()
+		echo(conn, PRDataLong)
+		Expect(conn.Context().Err()).ToNot(HaveOccurred())
+		Expect(c2.sent.Load()).To(Equal(sent))
+		Expect(c2.rcvd.Load()).To(Equal(rcvd))
+	})
+
+	It("doesn't use multipath if the server didn't enable it", func() {
+		ln, serverConns := runServer(nil)
+		defer ln.Close()
+		tr1, _ := newTransport()
+		defer tr1.Close()
+		tr2, _ := newTransport()
+		defer tr2.Close()
+
+		conn := dial(tr1, ln.Addr())
+		defer conn.CloseWithError(0, "")
+		Expect(conn.ConnectionState().Multipath).To(BeFalse())
+		Eventually(serverConns).Should(Receive())
+
+		path, err := conn.AddPath(tr2)
+		Expect(err).ToNot(HaveOccurred())
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		Expect(path.Probe(ctx)).To(Succeed())
+		Expect(path.SetStandby(true)).To(MatchError("multipath not negotiated"))
+		// the path can be used for connection migration
+		Expect(path.Switch()).To(Succeed())
+		Eventually(conn.LocalAddr).Should(Equal(tr2.Conn.LocalAddr()))
+		echo(conn, PRData)
+	})
+})
@@ -0,0 +1,94 @@
+package self_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	quicproxy "github.com/quic-go/quic-go/integrationtests/tools/proxy"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Connection Statistics", func() {
+	It("collects statistics", func() {
+		ln, err := quic.ListenAddr("localhost:0", getTLSConfig(), getQuicConfig(nil))
+		Expect(err).ToNot(HaveOccurred())
+		defer ln.Close()
+
+		const rtt = 20 * time.Millisecond
+		var num atomic.Int32
+		proxy, err := quicproxy.NewQuicProxy("localhost:0", &quicproxy.Opts{
+			RemoteAddr:  fmt.Sprintf("localhost:%d", ln.Addr().(*net.UDPAddr).Port),
+			DelayPacket: func(quicproxy.Direction, []byte) time.Duration { return rtt / 2 },
+			// drop every 20th packet sent by the server
+			DropPacket: func(dir quicproxy.Direction, _ []byte) bool {
+				if dir != quicproxy.DirectionOutgoing {
+					return false
+				}
+				return num.Add(1)%20 == 0
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		defer proxy.Close()
+
+		serverConnChan := make(chan quic.Connection, 1)
+		go func() {
+			defer GinkgoRecover()
+			conn, err := ln.Accept(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			serverConnChan <- conn
+			str, err := conn.OpenStream()
+			Expect(err).ToNot(HaveOccurred())
+			_, err = str.Write(PRData)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(str.Close()).To(Succeed())
+		}()
+
+		conn, err := quic.DialAddr(
+			context.Background(),
+			fmt.Sprintf("localhost:%d", proxy.LocalPort()),
+			getTLSClientConfig(),
+			getQuicConfig(nil),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		str, err := conn.AcceptStream(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		data, err := io.ReadAll(str)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal(PRData))
+		var serverConn quic.Connection
+		Eventually(serverConnChan).Should(Receive(&serverConn))
+
+		stats := serverConn.Stats()
+		Expect(stats.SmoothedRTT).To(BeNumerically(">=", rtt))
+		Expect(stats.MinRTT).To(BeNumerically(">=", rtt))
+		Expect(stats.CongestionWindow).ToNot(BeZero())
+		Expect(stats.MTU).To(BeNumerically(">=", 1200))
+		Expect(stats.PacketsSent).To(BeNumerically(">", len(PRData)/1500))
+		Expect(stats.BytesSent).To(BeNumerically(">", len(PRData)))
+		Expect(stats.PacketsReceived).ToNot(BeZero())
+		Expect(stats.PacketsLost).ToNot(BeZero())
+		Expect(stats.BytesLost).ToNot(BeZero())
+		Expect(stats.PacketsRetransmitted).ToNot(BeZero())
+		Expect(stats.StreamDataSent).To(BeEquivalentTo(len(PRData)))
+		Expect(stats.SendWindow).To(BeNumerically(">=", len(PRData)))
+
+		clientStats := conn.Stats()
+		Expect(clientStats.PacketsReceived).To(BeNumerically("<", stats.PacketsSent))
+		Expect(clientStats.BytesReceived).To(BeNumerically(">", len(PRData)))
+		Expect(clientStats.StreamDataReceived).To(BeEquivalentTo(len(PRData)))
+		Expect(clientStats.ReceiveWindow).To(BeNumerically(">", len(PRData)))
+
+		// after the connection is closed, the last statistics are returned
+		conn.CloseWithError(0, "")
+		Eventually(conn.Context().Done()).Should(BeClosed())
+		Expect(conn.Stats().PacketsReceived).To(BeNumerically(">=", clientStats.PacketsReceived))
+		Expect(conn.Stats()).To(Equal(conn.Stats()))
+	})
+})
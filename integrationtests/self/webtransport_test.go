@@ -0,0 +1,258 @@
+package self_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/quic-go/quic-go/webtransport"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WebTransport", func() {
+	var (
+		server         *webtransport.Server
+		dialer         *webtransport.Dialer
+		mux            *http.ServeMux
+		port           int
+		stoppedServing chan struct{}
+	)
+
+	BeforeEach(func() {
+		mux = http.NewServeMux()
+		server = &webtransport.Server{
+			H3: http3.Server{
+				Handler:    mux,
+				TLSConfig:  getTLSConfig(),
+				QuicConfig: getQuicConfig(nil),
+			},
+		}
+		conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 0})
+		Expect(err).ToNot(HaveOccurred())
+		port = conn.LocalAddr().(*net.UDPAddr).Port
+		stoppedServing = make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			server.Serve(conn)
+			close(stoppedServing)
+		}()
+
+		dialer = &webtransport.Dialer{
+			TLSClientConfig: getTLSClientConfigWithoutServerName(),
+			QuicConfig:      getQuicConfig(&quic.Config{MaxIdleTimeout: 10 * time.Second}),
+		}
+	})
+
+	AfterEach(func() {
+		Expect(dialer.Close()).To(Succeed())
+		Expect(server.Close()).To(Succeed())
+		Eventually(stoppedServing).Should(BeClosed())
+	})
+
+	// handleSessions upgrades all requests to /webtransport, and calls handler for every session
+	handleSessions := func(handler func(*webtransport.Session)) {
+		mux.HandleFunc("/webtransport", func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			sess, err := server.Upgrade(w, r)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			handler(sess)
+		})
+	}
+
+	dial := func() *webtransport.Session {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		rsp, sess, err := dialer.Dial(ctx, fmt.Sprintf("https://localhost:%d/webtransport", port), nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+		return sess
+	}
+
+	It("echoes data on bidirectional streams", func() {
+		handleSessions(func(sess *webtransport.Session) {
+			for {
+				str, err := sess.AcceptStream(context.Background())
+				if err != nil {
+					return
+				}
+				go func() {
+					defer GinkgoRecover()
+					io.Copy(str, str)
+					str.Close()
+				}()
+			}
+		})
+
+		sess := dial()
+		for i := 0; i < 3; i++ {
+			str, err := sess.OpenStreamSync(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			_, err = str.Write(PRData)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(str.Close()).To(Succeed())
+			Expect(str.SetReadDeadline(time.Now().Add(3 * time.Second))).To(Succeed())
+			data, err := io.ReadAll(str)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(Equal(PRData))
+		}
+		Expect(sess.CloseWithError(0, "")).To(Succeed())
+	})
+
+	It("sends data on unidirectional streams", func() {
+		handleSessions(func(sess *webtransport.Session) {
+			str, err := sess.AcceptUniStream(context.Background())
+			if err != nil {
+				return
+			}
+			data, err := io.ReadAll(str)
+			Expect(err).ToNot(HaveOccurred())
+			// send the data back on a server-initiated stream
+			rstr, err := sess.OpenUniStreamSync(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			_, err = rstr.Write(data)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rstr.Close()).To(Succeed())
+			<-sess.Context().Done()
+		})
+
+		sess := dial()
+		str, err := sess.OpenUniStream()
+		Expect(err).ToNot(HaveOccurred())
+		_, err = str.Write([]byte("foobar"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(str.Close()).To(Succeed())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		rstr, err := sess.AcceptUniStream(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		data, err := io.ReadAll(rstr)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal([]byte("foobar")))
+		Expect(sess.CloseWithError(0, "")).To(Succeed())
+	})
+
+	It("sends datagrams", func() {
+		handleSessions(func(sess *webtransport.Session) {
+			for {
+				b, err := sess.ReceiveDatagram(context.Background())
+				if err != nil {
+					return
+				}
+				sess.SendDatagram(b)
+			}
+		})
+
+		sess := dial()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		// datagrams might be lost, so send them until one is echoed
+		go func() {
+			defer GinkgoRecover()
+			for ctx.Err() == nil {
+				sess.SendDatagram([]byte("foobar"))
+				time.Sleep(50 * time.Millisecond)
+			}
+		}()
+		b, err := sess.ReceiveDatagram(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(b).To(Equal([]byte("foobar")))
+		cancel()
+		Expect(sess.CloseWithError(0, "")).To(Succeed())
+	})
+
+	It("multiplexes sessions on a single connection", func() {
+		handleSessions(func(sess *webtransport.Session) {
+			str, err := sess.AcceptStream(context.Background())
+			if err != nil {
+				return
+			}
+			io.Copy(str, str)
+			str.Close()
+			<-sess.Context().Done()
+		})
+
+		sess1 := dial()
+		sess2 := dial()
+		Expect(sess1.RemoteAddr()).To(Equal(sess2.RemoteAddr()))
+		for _, sess := range []*webtransport.Session{sess1, sess2} {
+			str, err := sess.OpenStream()
+			Expect(err).ToNot(HaveOccurred())
+			_, err = str.Write([]byte("foobar"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(str.Close()).To(Succeed())
+			data, err := io.ReadAll(str)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(Equal([]byte("foobar")))
+		}
+		Expect(sess1.CloseWithError(0, "")).To(Succeed())
+		Expect(sess2.CloseWithError(0, "")).To(Succeed())
+	})
+
+	It("closes sessions with an error code and a message", func() {
+		handleSessions(func(sess *webtransport.Session) {
+			Expect(sess.CloseWithError(1337, "go away")).To(Succeed())
+		})
+
+		sess := dial()
+		Eventually(sess.Context().Done()).Should(BeClosed())
+		_, err := sess.AcceptStream(context.Background())
+		Expect(err).To(HaveOccurred())
+		var sessErr *webtransport.SessionError
+		Expect(errors.As(err, &sessErr)).To(BeTrue())
+		Expect(sessErr.Remote).To(BeTrue())
+		Expect(sessErr.ErrorCode).To(BeEquivalentTo(1337))
+		Expect(sessErr.Message).To(Equal("go away"))
+	})
+
+	It("resets streams when the session is closed", func() {
+		handleSessions(func(sess *webtransport.Session) {
+			str, err := sess.AcceptStream(context.Background())
+			if err != nil {
+				return
+			}
+			b := make([]byte, 6)
+			_, err = io.ReadFull(str, b)
+			Expect(err).ToNot(HaveOccurred())
+			sess.CloseWithError(0, "")
+		})
+
+		sess := dial()
+		str, err := sess.OpenStream()
+		Expect(err).ToNot(HaveOccurred())
+		_, err = str.Write([]byte("foobar"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(str.SetReadDeadline(time.Now().Add(3 * time.Second))).To(Succeed())
+		_, err = str.Read(make([]byte, 10))
+		Expect(err).To(HaveOccurred())
+		var sessErr *webtransport.SessionError
+		Expect(errors.As(err, &sessErr)).To(BeTrue())
+	})
+
+	It("rejects requests that are not WebTransport requests", func() {
+		handleSessions(func(*webtransport.Session) { Fail("didn't expect a session") })
+
+		rt := &http3.RoundTripper{TLSClientConfig: getTLSClientConfigWithoutServerName()}
+		defer rt.Close()
+		rsp, err := rt.RoundTrip(mustNewRequest(http.MethodGet, fmt.Sprintf("https://localhost:%d/webtransport", port)))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rsp.StatusCode).To(Equal(http.StatusBadRequest))
+	})
+})
+
+func mustNewRequest(method, url string) *http.Request {
+	req, err := http.NewRequest(method, url, nil)
+	Expect(err).ToNot(HaveOccurred())
+	return req
+}
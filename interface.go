@@ -6,8 +6,10 @@ import (
 	"errors"
 	"io"
 	"net"
+	"net/netip"
 	"time"
 
+	"github.com/quic-go/quic-go/congestion"
 	"github.com/quic-go/quic-go/internal/handshake"
 	"github.com/quic-go/quic-go/internal/protocol"
 	"github.com/quic-go/quic-go/logging"
@@ -131,6 +133,25 @@ type SendStream interface {
 	// some data was successfully written.
 	// A zero value for t means Write will not time out.
 	SetWriteDeadline(t time.Time) error
+	// SetPriority sets the priority of the stream.
+	// It determines the order in which data of different streams is sent, see StreamPriority.
+	// It can be called at any time, the new priority applies to all data that hasn't been sent yet.
+	SetPriority(StreamPriority)
+}
+
+// StreamPriority is the priority of a stream.
+// It uses the urgency and incremental parameters defined in RFC 9218.
+// Data of streams with a lower urgency value is sent before data of streams with a higher urgency value.
+// Among streams of the same urgency, non-incremental streams are sent one after another, in the order of their stream IDs,
+// before data of incremental streams is sent in a round-robin fashion.
+// By default, streams have an urgency of 3 and are incremental.
+// Control frames (and data sent on the crypto stream) are not subject to stream priorities.
+type StreamPriority struct {
+	// Urgency is the urgency level of the stream, from 0 (highest) to 7 (lowest).
+	// Values larger than 7 are treated as 7.
+	Urgency uint8
+	// Incremental says if data of this stream can be interleaved with data of other streams.
+	Incremental bool
 }
 
 // A Connection is a QUIC connection between two peers.
@@ -186,11 +207,22 @@ type Connection interface {
 	// ConnectionState returns basic details about the QUIC connection.
 	// Warning: This API should not be considered stable and might change soon.
 	ConnectionState() ConnectionState
+	// Stats returns statistics about the QUIC connection.
+	// It is cheap to call, and can be called concurrently with all other methods.
+	Stats() ConnectionStats
 
 	// SendDatagram sends a message as a datagram, as specified in RFC 9221.
+	// If the message is too large to fit into a DATAGRAM frame, a DatagramTooLargeError is returned.
 	SendDatagram([]byte) error
 	// ReceiveDatagram gets a message received in a datagram, as specified in RFC 9221.
 	ReceiveDatagram(context.Context) ([]byte, error)
+
+	// AddPath adds a new path, using the Transport to send and receive packets.
+	// It is only available for the client, once the handshake has completed,
+	// and if the server didn't disable active connection migration.
+	// The path needs to be probed (see Path.Probe) before the connection can switch to it.
+	// The Transport needs to stay open as long as the path is used.
+	AddPath(*Transport) (*Path, error)
 }
 
 // An EarlyConnection is a connection that is handshaking.
@@ -322,9 +354,33 @@ type Config struct {
 	// Allow0RTT allows the application to decide if a 0-RTT connection attempt should be accepted.
 	// Only valid for the server.
 	Allow0RTT bool
+	// PreferredAddress is advertised to the client in the preferred_address transport parameter.
+	// After completion of the handshake, the client validates the preferred address and migrates to it.
+	// Packets sent to the preferred address need to be received on the same Transport,
+	// for example by listening on the unspecified address.
+	// Only valid for the server.
+	PreferredAddress *PreferredAddress
 	// Enable QUIC datagram support (RFC 9221).
 	EnableDatagrams bool
-	Tracer          func(context.Context, logging.Perspective, ConnectionID) *logging.ConnectionTracer
+	// EnableMultipath enables the experimental multipath extension (draft-ietf-quic-multipath-04).
+	// If both endpoints enable it, the client can use multiple paths at the same time (see Connection.AddPath).
+	// Multipath can't be used with zero-length connection IDs.
+	EnableMultipath bool
+	// CongestionControl creates the congestion controller for a path of a connection.
+	// It is called with the RTT statistics of the path, and the initial maximum datagram size.
+	// On multipath connections, it is called for every path.
+	// If not set, NewReno (congestion.NewReno) is used.
+	// If the congestion controller implements congestion.SendAlgorithmWithTracer, its state changes are passed to the Tracer.
+	CongestionControl func(rttStats congestion.RTTStats, initialMaxDatagramSize congestion.ByteCount) congestion.SendAlgorithm
+	Tracer            func(context.Context, logging.Perspective, ConnectionID) *logging.ConnectionTracer
+}
+
+// PreferredAddress is the address a server would like the client to migrate to.
+// At least one of IPv4 and IPv6 needs to be set.
+// The client only migrates to the address of the address family it is currently using.
+type PreferredAddress struct {
+	IPv4 netip.AddrPort
+	IPv6 netip.AddrPort
 }
 
 type ClientHelloInfo struct {
@@ -346,4 +402,58 @@ type ConnectionState struct {
 	Version VersionNumber
 	// GSO says if generic segmentation offload is used
 	GSO bool
+	// Multipath says if the multipath extension was negotiated.
+	// This requires both nodes to enable it (via Config.EnableMultipath).
+	Multipath bool
+}
+
+// ConnectionStats contains statistics about a QUIC connection.
+// The values are updated by the connection as it sends and receives packets.
+// RTT, congestion window, bytes in flight and MTU refer to the path the connection is currently using.
+// On multipath connections, the packet and byte counters include all paths.
+type ConnectionStats struct {
+	// MinRTT is the minimum RTT observed on the path.
+	MinRTT time.Duration
+	// LatestRTT is the most recent RTT sample.
+	LatestRTT time.Duration
+	// SmoothedRTT is the smoothed RTT, as defined in RFC 9002.
+	SmoothedRTT time.Duration
+	// MeanDeviation is the mean deviation of the RTT samples (rttvar in RFC 9002).
+	MeanDeviation time.Duration
+
+	// CongestionWindow is the size of the congestion window, in bytes.
+	CongestionWindow uint64
+	// BytesInFlight is the number of bytes sent in ack-eliciting packets that were neither acknowledged nor declared lost.
+	BytesInFlight uint64
+	// MTU is the maximum size of a QUIC packet, as determined by Path MTU Discovery.
+	MTU uint64
+
+	// PacketsSent is the number of QUIC packets sent, including coalesced packets.
+	PacketsSent uint64
+	// BytesSent is the total size of the QUIC packets sent.
+	BytesSent uint64
+	// PacketsReceived is the number of QUIC packets received and successfully processed.
+	PacketsReceived uint64
+	// BytesReceived is the total size of the QUIC packets received and successfully processed.
+	BytesReceived uint64
+	// PacketsLost is the number of packets declared lost.
+	PacketsLost uint64
+	// BytesLost is the total size of the packets declared lost.
+	BytesLost uint64
+	// PacketsRetransmitted is the number of packets whose frames were retransmitted,
+	// either because the packet was declared lost, or because a Probe Timeout (PTO) fired.
+	PacketsRetransmitted uint64
+	// PTOCount is the number of times the Probe Timeout (PTO) fired.
+	PTOCount uint64
+
+	// StreamDataSent is the number of bytes of stream data sent,
+	// counted against the connection-level flow control limit granted by the peer.
+	StreamDataSent uint64
+	// SendWindow is the connection-level flow control limit granted by the peer.
+	SendWindow uint64
+	// StreamDataReceived is the number of bytes of stream data received,
+	// counted against the connection-level flow control limit granted to the peer.
+	StreamDataReceived uint64
+	// ReceiveWindow is the connection-level flow control limit granted to the peer.
+	ReceiveWindow uint64
 }
@@ -19,6 +19,9 @@ var _ = Describe("ack-eliciting frames", func() {
 		&wire.StreamFrame{}:          true,
 		&wire.MaxDataFrame{}:         true,
 		&wire.MaxStreamDataFrame{}:   true,
+		&wire.PathAckFrame{}:         false,
+		&wire.PathAbandonFrame{}:     true,
+		&wire.PathStatusFrame{}:      true,
 	} {
 		f := fl
 		e := el
@@ -1,6 +1,7 @@
 package ackhandler
 
 import (
+	"github.com/quic-go/quic-go/congestion"
 	"github.com/quic-go/quic-go/internal/protocol"
 	"github.com/quic-go/quic-go/internal/utils"
 	"github.com/quic-go/quic-go/logging"
@@ -9,16 +10,38 @@ import (
 // NewAckHandler creates a new SentPacketHandler and a new ReceivedPacketHandler.
 // clientAddressValidated indicates whether the address was validated beforehand by an address validation token.
 // clientAddressValidated has no effect for a client.
+// If newCongestionControl is nil, NewReno is used.
 func NewAckHandler(
 	initialPacketNumber protocol.PacketNumber,
 	initialMaxDatagramSize protocol.ByteCount,
 	rttStats *utils.RTTStats,
 	clientAddressValidated bool,
 	enableECN bool,
+	newCongestionControl func(congestion.RTTStats, protocol.ByteCount) congestion.SendAlgorithm,
 	pers protocol.Perspective,
 	tracer *logging.ConnectionTracer,
 	logger utils.Logger,
 ) (SentPacketHandler, ReceivedPacketHandler) {
-	sph := newSentPacketHandler(initialPacketNumber, initialMaxDatagramSize, rttStats, clientAddressValidated, enableECN, pers, tracer, logger)
+	sph := newSentPacketHandler(initialPacketNumber, initialMaxDatagramSize, rttStats, clientAddressValidated, enableECN, newCongestionControl, pers, tracer, logger)
 	return sph, newReceivedPacketHandler(sph, rttStats, logger)
 }
+
+// NewPathSentPacketHandler creates a SentPacketHandler for an additional path of a multipath connection.
+// Every path has its own packet number space, RTT estimate and congestion controller.
+// The path is only used after the handshake is confirmed.
+// pathValidated has no effect for a client.
+func NewPathSentPacketHandler(
+	initialMaxDatagramSize protocol.ByteCount,
+	rttStats *utils.RTTStats,
+	pathValidated bool,
+	newCongestionControl func(congestion.RTTStats, protocol.ByteCount) congestion.SendAlgorithm,
+	pers protocol.Perspective,
+	logger utils.Logger,
+) SentPacketHandler {
+	return newPathSentPacketHandler(initialMaxDatagramSize, rttStats, pathValidated, newCongestionControl, pers, logger)
+}
+
+// NewPathReceivedPacketHandler creates a ReceivedPacketHandler for the packets received on an additional path of a multipath connection.
+func NewPathReceivedPacketHandler(rttStats *utils.RTTStats, logger utils.Logger) ReceivedPacketHandler {
+	return newReceivedPacketHandler(pathSentPacketTracker{}, rttStats, logger)
+}
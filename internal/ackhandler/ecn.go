@@ -24,7 +24,7 @@ const numECNTestingPackets = 10
 type ecnHandler interface {
 	SentPacket(protocol.PacketNumber, protocol.ECN)
 	Mode() protocol.ECN
-	HandleNewlyAcked(packets []*packet, ect0, ect1, ecnce int64) (congested bool)
+	HandleNewlyAcked(packets []*packet, ect0, ect1, ecnce int64) (newlyCEMarked int64)
 	LostPacket(protocol.PacketNumber)
 }
 
@@ -144,9 +144,9 @@ func (e *ecnTracker) LostPacket(pn protocol.PacketNumber) {
 // HandleNewlyAcked handles the ECN counts on an ACK frame.
 // It must only be called for ACK frames that increase the largest acknowledged packet number,
 // see section 13.4.2.1 of RFC 9000.
-func (e *ecnTracker) HandleNewlyAcked(packets []*packet, ect0, ect1, ecnce int64) (congested bool) {
+func (e *ecnTracker) HandleNewlyAcked(packets []*packet, ect0, ect1, ecnce int64) (newlyCEMarked int64) {
 	if e.state == ecnStateFailed {
-		return false
+		return 0
 	}
 
 	// ECN validation can fail if the received total count for either ECT(0) or ECT(1) exceeds
@@ -157,7 +157,7 @@ func (e *ecnTracker) HandleNewlyAcked(packets []*packet, ect0, ect1, ecnce int64
 			e.tracer.ECNStateUpdated(logging.ECNStateFailed, logging.ECNFailedMoreECNCountsThanSent)
 		}
 		e.state = ecnStateFailed
-		return false
+		return 0
 	}
 
 	// Count ECT0 and ECT1 marks that we used when sending the packets that are now being acknowledged.
@@ -183,7 +183,7 @@ func (e *ecnTracker) HandleNewlyAcked(packets []*packet, ect0, ect1, ecnce int64
 			e.tracer.ECNStateUpdated(logging.ECNStateFailed, logging.ECNFailedNoECNCounts)
 		}
 		e.state = ecnStateFailed
-		return false
+		return 0
 	}
 
 	// Determine the increase in ECT0, ECT1 and ECNCE marks
@@ -200,7 +200,7 @@ func (e *ecnTracker) HandleNewlyAcked(packets []*packet, ect0, ect1, ecnce int64
 			e.tracer.ECNStateUpdated(logging.ECNStateFailed, logging.ECNFailedDecreasedECNCounts)
 		}
 		e.state = ecnStateFailed
-		return false
+		return 0
 	}
 
 	// ECN validation also fails if the sum of the increase in ECT(0) and ECN-CE counts is less than the number
@@ -212,7 +212,7 @@ func (e *ecnTracker) HandleNewlyAcked(packets []*packet, ect0, ect1, ecnce int64
 			e.tracer.ECNStateUpdated(logging.ECNStateFailed, logging.ECNFailedTooFewECNCounts)
 		}
 		e.state = ecnStateFailed
-		return false
+		return 0
 	}
 	// Similarly, ECN validation fails if the sum of the increases to ECT(1) and ECN-CE counts is less than
 	// the number of newly acknowledged packets sent with an ECT(1) marking.
@@ -222,7 +222,7 @@ func (e *ecnTracker) HandleNewlyAcked(packets []*packet, ect0, ect1, ecnce int64
 			e.tracer.ECNStateUpdated(logging.ECNStateFailed, logging.ECNFailedTooFewECNCounts)
 		}
 		e.state = ecnStateFailed
-		return false
+		return 0
 	}
 
 	// update our counters
@@ -235,7 +235,7 @@ func (e *ecnTracker) HandleNewlyAcked(packets []*packet, ect0, ect1, ecnce int64
 	if e.state == ecnStateUnknown {
 		e.failIfMangled()
 		if e.state == ecnStateFailed {
-			return false
+			return 0
 		}
 	}
 	if e.state == ecnStateTesting || e.state == ecnStateUnknown {
@@ -258,7 +258,10 @@ func (e *ecnTracker) HandleNewlyAcked(packets []*packet, ect0, ect1, ecnce int64
 
 	// Don't trust CE marks before having confirmed ECN capability of the path.
 	// Otherwise, mangling would be misinterpreted as actual congestion.
-	return e.state == ecnStateCapable && newECNCE > 0
+	if e.state != ecnStateCapable {
+		return 0
+	}
+	return newECNCE
 }
 
 // failIfMangled fails ECN validation if all testing packets are lost or CE-marked.
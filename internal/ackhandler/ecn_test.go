@@ -94,7 +94,7 @@ var _ = Describe("ECN tracker", func() {
 			ecnTracker.SentPacket(protocol.PacketNumber(i), protocol.ECT0)
 		}
 		tracer.EXPECT().ECNStateUpdated(logging.ECNStateCapable, logging.ECNTriggerNoTrigger)
-		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(3), 1, 0, 0)).To(BeFalse())
+		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(3), 1, 0, 0)).To(BeZero())
 		// make sure we continue sending ECT(0) packets
 		for i := 5; i < 100; i++ {
 			Expect(ecnTracker.Mode()).To(Equal(protocol.ECT0))
@@ -113,7 +113,7 @@ var _ = Describe("ECN tracker", func() {
 			ecnTracker.LostPacket(protocol.PacketNumber(i))
 		}
 		tracer.EXPECT().ECNStateUpdated(logging.ECNStateCapable, logging.ECNTriggerNoTrigger)
-		Expect(ecnTracker.HandleNewlyAcked([]*packet{{PacketNumber: 7}}, 1, 0, 0)).To(BeFalse())
+		Expect(ecnTracker.HandleNewlyAcked([]*packet{{PacketNumber: 7}}, 1, 0, 0)).To(BeZero())
 	})
 
 	It("fails ECN validation when the ACK contains more ECN counts than we sent packets", func() {
@@ -124,7 +124,7 @@ var _ = Describe("ECN tracker", func() {
 		}
 		// only 10 ECT(0) packets were sent, but the ACK claims to have received 12 of them
 		tracer.EXPECT().ECNStateUpdated(logging.ECNStateFailed, logging.ECNFailedMoreECNCountsThanSent)
-		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12), 12, 0, 0)).To(BeFalse())
+		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12), 12, 0, 0)).To(BeZero())
 	})
 
 	It("fails ECN validation when the ACK contains ECN counts for the wrong code point", func() {
@@ -135,7 +135,7 @@ var _ = Describe("ECN tracker", func() {
 		}
 		// We sent ECT(0), but this ACK acknowledges ECT(1).
 		tracer.EXPECT().ECNStateUpdated(logging.ECNStateFailed, logging.ECNFailedMoreECNCountsThanSent)
-		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12), 0, 1, 0)).To(BeFalse())
+		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12), 0, 1, 0)).To(BeZero())
 	})
 
 	It("fails ECN validation when the ACK doesn't contain ECN counts", func() {
@@ -145,10 +145,10 @@ var _ = Describe("ECN tracker", func() {
 			ecnTracker.SentPacket(protocol.PacketNumber(i), protocol.ECNNon)
 		}
 		// First only acknowledge packets sent without ECN marks.
-		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(12, 13, 14), 0, 0, 0)).To(BeFalse())
+		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(12, 13, 14), 0, 0, 0)).To(BeZero())
 		// Now acknowledge some packets sent with ECN marks.
 		tracer.EXPECT().ECNStateUpdated(logging.ECNStateFailed, logging.ECNFailedNoECNCounts)
-		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(1, 2, 3, 15), 0, 0, 0)).To(BeFalse())
+		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(1, 2, 3, 15), 0, 0, 0)).To(BeZero())
 	})
 
 	It("fails ECN validation when an ACK decreases ECN counts", func() {
@@ -158,12 +158,12 @@ var _ = Describe("ECN tracker", func() {
 			ecnTracker.SentPacket(protocol.PacketNumber(i), protocol.ECNNon)
 		}
 		tracer.EXPECT().ECNStateUpdated(logging.ECNStateCapable, logging.ECNTriggerNoTrigger)
-		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(1, 2, 3, 12), 3, 0, 0)).To(BeFalse())
+		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(1, 2, 3, 12), 3, 0, 0)).To(BeZero())
 		// Now acknowledge some more packets, but decrease the ECN counts. Obviously, this doesn't make any sense.
 		tracer.EXPECT().ECNStateUpdated(logging.ECNStateFailed, logging.ECNFailedDecreasedECNCounts)
-		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(4, 5, 6, 13), 2, 0, 0)).To(BeFalse())
+		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(4, 5, 6, 13), 2, 0, 0)).To(BeZero())
 		// make sure that new ACKs are ignored
-		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(7, 8, 9, 14), 5, 0, 0)).To(BeFalse())
+		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(7, 8, 9, 14), 5, 0, 0)).To(BeZero())
 	})
 
 	// This can happen if ACK are lost / reordered.
@@ -174,7 +174,7 @@ var _ = Describe("ECN tracker", func() {
 			ecnTracker.SentPacket(protocol.PacketNumber(i), protocol.ECNNon)
 		}
 		tracer.EXPECT().ECNStateUpdated(logging.ECNStateCapable, logging.ECNTriggerNoTrigger)
-		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(1, 2, 3, 12), 8, 0, 0)).To(BeFalse())
+		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(1, 2, 3, 12), 8, 0, 0)).To(BeZero())
 	})
 
 	It("fails ECN validation when the ACK doesn't contain enough ECN counts", func() {
@@ -185,11 +185,11 @@ var _ = Describe("ECN tracker", func() {
 		}
 		// First only acknowledge some packets sent with ECN marks.
 		tracer.EXPECT().ECNStateUpdated(logging.ECNStateCapable, logging.ECNTriggerNoTrigger)
-		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(1, 2, 3, 12), 2, 0, 1)).To(BeTrue())
+		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(1, 2, 3, 12), 2, 0, 1)).To(BeEquivalentTo(1))
 		// Now acknowledge some more packets sent with ECN marks, but don't increase the counters enough.
 		// This ACK acknowledges 3 more ECN-marked packets, but the counters only increase by 2.
 		tracer.EXPECT().ECNStateUpdated(logging.ECNStateFailed, logging.ECNFailedTooFewECNCounts)
-		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(4, 5, 6, 15), 3, 0, 2)).To(BeFalse())
+		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(4, 5, 6, 15), 3, 0, 2)).To(BeZero())
 	})
 
 	It("detects ECN mangling if all testing packets are marked CE", func() {
@@ -199,11 +199,11 @@ var _ = Describe("ECN tracker", func() {
 			ecnTracker.SentPacket(protocol.PacketNumber(i), protocol.ECNNon)
 		}
 		// ECN capability not confirmed yet, therefore CE marks are not regarded as congestion events
-		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(0, 1, 2, 3), 0, 0, 4)).To(BeFalse())
-		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(4, 5, 6, 10, 11, 12), 0, 0, 7)).To(BeFalse())
+		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(0, 1, 2, 3), 0, 0, 4)).To(BeZero())
+		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(4, 5, 6, 10, 11, 12), 0, 0, 7)).To(BeZero())
 		// With the next ACK, all testing packets will now have been marked CE.
 		tracer.EXPECT().ECNStateUpdated(logging.ECNStateFailed, logging.ECNFailedManglingDetected)
-		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(7, 8, 9, 13), 0, 0, 10)).To(BeFalse())
+		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(7, 8, 9, 13), 0, 0, 10)).To(BeZero())
 	})
 
 	It("only detects ECN mangling after sending all testing packets", func() {
@@ -211,7 +211,7 @@ var _ = Describe("ECN tracker", func() {
 		for i := 0; i < 9; i++ {
 			Expect(ecnTracker.Mode()).To(Equal(protocol.ECT0))
 			ecnTracker.SentPacket(protocol.PacketNumber(i), protocol.ECT0)
-			Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(protocol.PacketNumber(i)), 0, 0, int64(i+1))).To(BeFalse())
+			Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(protocol.PacketNumber(i)), 0, 0, int64(i+1))).To(BeZero())
 		}
 		// Send the last testing packet, and receive a
 		tracer.EXPECT().ECNStateUpdated(logging.ECNStateUnknown, logging.ECNTriggerNoTrigger)
@@ -219,7 +219,7 @@ var _ = Describe("ECN tracker", func() {
 		ecnTracker.SentPacket(9, protocol.ECT0)
 		// This ACK now reports the last testing packets as CE as well.
 		tracer.EXPECT().ECNStateUpdated(logging.ECNStateFailed, logging.ECNFailedManglingDetected)
-		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(9), 0, 0, 10)).To(BeFalse())
+		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(9), 0, 0, 10)).To(BeZero())
 	})
 
 	It("detects ECN mangling, if some testing packets are marked CE, and then others are lost", func() {
@@ -229,8 +229,8 @@ var _ = Describe("ECN tracker", func() {
 			ecnTracker.SentPacket(protocol.PacketNumber(i), protocol.ECNNon)
 		}
 		// ECN capability not confirmed yet, therefore CE marks are not regarded as congestion events
-		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(0, 1, 2, 3), 0, 0, 4)).To(BeFalse())
-		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(6, 7, 8, 9), 0, 0, 8)).To(BeFalse())
+		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(0, 1, 2, 3), 0, 0, 4)).To(BeZero())
+		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(6, 7, 8, 9), 0, 0, 8)).To(BeZero())
 		// Lose one of the two unacknowledged packets.
 		ecnTracker.LostPacket(4)
 		// By losing the last unacknowledged testing packets, we should detect the mangling.
@@ -249,10 +249,10 @@ var _ = Describe("ECN tracker", func() {
 		ecnTracker.LostPacket(1)
 		ecnTracker.LostPacket(2)
 		// ECN capability not confirmed yet, therefore CE marks are not regarded as congestion events
-		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(3, 4, 5, 6, 7, 8), 0, 0, 6)).To(BeFalse())
+		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(3, 4, 5, 6, 7, 8), 0, 0, 6)).To(BeZero())
 		// By CE-marking the last unacknowledged testing packets, we should detect the mangling.
 		tracer.EXPECT().ECNStateUpdated(logging.ECNStateFailed, logging.ECNFailedManglingDetected)
-		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(9), 0, 0, 7)).To(BeFalse())
+		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(9), 0, 0, 7)).To(BeZero())
 	})
 
 	It("declares congestion", func() {
@@ -263,10 +263,12 @@ var _ = Describe("ECN tracker", func() {
 		}
 		// Receive one CE count.
 		tracer.EXPECT().ECNStateUpdated(logging.ECNStateCapable, logging.ECNTriggerNoTrigger)
-		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(1, 2, 3, 12), 2, 0, 1)).To(BeTrue())
+		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(1, 2, 3, 12), 2, 0, 1)).To(BeEquivalentTo(1))
 		// No increase in CE. No congestion.
-		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(4, 5, 6, 13), 5, 0, 1)).To(BeFalse())
+		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(4, 5, 6, 13), 5, 0, 1)).To(BeZero())
 		// Increase in CE. More congestion.
-		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(7, 8, 9, 14), 7, 0, 2)).To(BeTrue())
+		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(7, 8, 9, 14), 7, 0, 2)).To(BeEquivalentTo(1))
+		// Multiple packets newly CE-marked.
+		Expect(ecnTracker.HandleNewlyAcked(getAckedPackets(15, 16, 17), 7, 0, 5)).To(BeEquivalentTo(3))
 	})
 })
@@ -3,14 +3,16 @@ package ackhandler
 import (
 	"time"
 
+	"github.com/quic-go/quic-go/congestion"
 	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/internal/utils"
 	"github.com/quic-go/quic-go/internal/wire"
 )
 
 // SentPacketHandler handles ACKs received for outgoing packets
 type SentPacketHandler interface {
 	// SentPacket may modify the packet
-	SentPacket(t time.Time, pn, largestAcked protocol.PacketNumber, streamFrames []StreamFrame, frames []Frame, encLevel protocol.EncryptionLevel, ecn protocol.ECN, size protocol.ByteCount, isPathMTUProbePacket bool)
+	SentPacket(t time.Time, pn, largestAcked protocol.PacketNumber, streamFrames []StreamFrame, frames []Frame, encLevel protocol.EncryptionLevel, ecn protocol.ECN, size protocol.ByteCount, isPathMTUProbePacket, isPathProbePacket bool)
 	// ReceivedAck processes an ACK frame.
 	// It does not store a copy of the frame.
 	ReceivedAck(f *wire.AckFrame, encLevel protocol.EncryptionLevel, rcvTime time.Time) (bool /* 1-RTT packet acked */, error)
@@ -18,6 +20,18 @@ type SentPacketHandler interface {
 	DropPackets(protocol.EncryptionLevel)
 	ResetForRetry(rcvTime time.Time) error
 	SetHandshakeConfirmed()
+	// MigratedPath is called when the connection switched to a new path.
+	// All packets sent on the old path are declared lost.
+	// Unless keepCongestionState is set, the RTT estimate and the congestion controller are reset,
+	// and the state of the old path is returned, so that it can be restored using RestorePath.
+	// If the path hasn't been validated yet, sending is limited by the anti-amplification limit.
+	MigratedPath(now time.Time, pathValidated, keepCongestionState bool) *PathCongestionState
+	// RestorePath is called when the connection returns to a path it migrated away from.
+	// If state is nil, the current RTT estimate and congestion state are kept.
+	// All packets sent on the current path are declared lost.
+	RestorePath(now time.Time, state *PathCongestionState)
+	// SetPathValidated is called when the path the connection migrated to was validated.
+	SetPathValidated()
 
 	// The SendMode determines if and what kind of packets can be sent.
 	SendMode(now time.Time) SendMode
@@ -35,6 +49,32 @@ type SentPacketHandler interface {
 
 	GetLossDetectionTimeout() time.Time
 	OnLossDetectionTimeout() error
+
+	// Stats returns statistics about the packets sent.
+	Stats() Stats
+}
+
+// Stats are the statistics collected by the SentPacketHandler.
+type Stats struct {
+	PacketsSent uint64
+	BytesSent   protocol.ByteCount
+	PacketsLost uint64
+	BytesLost   protocol.ByteCount
+	// PacketsRetransmitted is the number of packets whose frames were queued for retransmission,
+	// either because the packet was declared lost, or because it was retransmitted in a probe packet.
+	PacketsRetransmitted uint64
+	// PTOCount is the total number of times the PTO timer fired.
+	PTOCount uint64
+
+	CongestionWindow protocol.ByteCount
+	BytesInFlight    protocol.ByteCount
+}
+
+// PathCongestionState is the RTT estimate and the congestion state of a path.
+type PathCongestionState struct {
+	rttStats           utils.RTTStats
+	firstRTTSampleTime time.Time
+	congestion         congestion.SendAlgorithm
 }
 
 type sentPacketTracker interface {
@@ -18,6 +18,7 @@ type packet struct {
 	EncryptionLevel protocol.EncryptionLevel
 
 	IsPathMTUProbePacket bool // We don't report the loss of Path MTU probe packets to the congestion controller.
+	isPathProbePacket    bool // Path probe packets are sent on a different path, and are not subject to congestion control.
 
 	includedInBytesInFlight bool
 	declaredLost            bool
@@ -25,7 +26,7 @@ type packet struct {
 }
 
 func (p *packet) outstanding() bool {
-	return !p.declaredLost && !p.skippedPacket && !p.IsPathMTUProbePacket
+	return !p.declaredLost && !p.skippedPacket && !p.IsPathMTUProbePacket && !p.isPathProbePacket
 }
 
 var packetPool = sync.Pool{New: func() any { return &packet{} }}
@@ -40,6 +41,7 @@ func getPacket() *packet {
 	p.EncryptionLevel = protocol.EncryptionLevel(0)
 	p.SendTime = time.Time{}
 	p.IsPathMTUProbePacket = false
+	p.isPathProbePacket = false
 	p.includedInBytesInFlight = false
 	p.declaredLost = false
 	p.skippedPacket = false
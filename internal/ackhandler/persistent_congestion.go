@@ -0,0 +1,102 @@
+package ackhandler
+
+import (
+	"time"
+
+	"github.com/quic-go/quic-go/internal/utils"
+)
+
+type persistentCongestionEvent struct {
+	sendTime time.Time
+	lost     bool
+}
+
+// The persistentCongestionDetector keeps track of the send times of acknowledged and lost packets,
+// across all packet number spaces and across multiple runs of the loss detection.
+// Persistent congestion is established if two lost packets were sent more than the persistent congestion duration apart,
+// and none of the packets sent between them was acknowledged, see section 7.6.2 of RFC 9002.
+type persistentCongestionDetector struct {
+	// sorted by send time
+	events []persistentCongestionEvent
+}
+
+// Acked is called for every acknowledged packet.
+func (d *persistentCongestionDetector) Acked(sendTime time.Time) {
+	d.insert(persistentCongestionEvent{sendTime: sendTime})
+}
+
+// Lost is called for every lost packet that is considered for persistent congestion detection.
+func (d *persistentCongestionDetector) Lost(sendTime time.Time) {
+	d.insert(persistentCongestionEvent{sendTime: sendTime, lost: true})
+}
+
+func (d *persistentCongestionDetector) insert(e persistentCongestionEvent) {
+	// Packets are usually acknowledged and declared lost roughly in the order they were sent.
+	i := len(d.events)
+	for i > 0 && d.events[i-1].sendTime.After(e.sendTime) {
+		i--
+	}
+	// An acknowledgement for a packet that was sent at the same time as a lost packet takes precedence.
+	if !e.lost && i > 0 && d.events[i-1].sendTime.Equal(e.sendTime) {
+		d.events[i-1].lost = false
+		return
+	}
+	d.events = append(d.events, persistentCongestionEvent{})
+	copy(d.events[i+1:], d.events[i:])
+	d.events[i] = e
+}
+
+// LongestLostPeriod returns the longest period between the send times of two lost packets,
+// during which no packet that was acknowledged was sent.
+func (d *persistentCongestionDetector) LongestLostPeriod() time.Duration {
+	var (
+		longest time.Duration
+		start   time.Time
+	)
+	for _, e := range d.events {
+		if !e.lost {
+			start = time.Time{}
+			continue
+		}
+		if start.IsZero() {
+			start = e.sendTime
+		}
+		longest = utils.Max(longest, e.sendTime.Sub(start))
+	}
+	return longest
+}
+
+// Prune removes events that can't change the outcome of the persistent congestion detection any more.
+// It must be called with the send time of the first outstanding packet (across all packet number spaces),
+// or with the zero value if there are no outstanding packets.
+// All packets sent before this time have either been acknowledged or declared lost.
+func (d *persistentCongestionDetector) Prune(firstOutstanding time.Time) {
+	end := len(d.events)
+	if !firstOutstanding.IsZero() {
+		for end > 0 && !d.events[end-1].sendTime.Before(firstOutstanding) {
+			end--
+		}
+	}
+	// Only the lost packets sent since the last acknowledged packet might still be relevant.
+	// Of these, we only need to keep the first and the last one.
+	start := end
+	for start > 0 && d.events[start-1].lost {
+		start--
+	}
+	var n int
+	if end > start {
+		d.events[n] = d.events[start]
+		n++
+	}
+	if end > start+1 {
+		d.events[n] = d.events[end-1]
+		n++
+	}
+	n += copy(d.events[n:], d.events[end:])
+	d.events = d.events[:n]
+}
+
+// Reset is called after persistent congestion was declared, and when the connection migrates to a new path.
+func (d *persistentCongestionDetector) Reset() {
+	d.events = d.events[:0]
+}
@@ -0,0 +1,96 @@
+package ackhandler
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Persistent Congestion Detector", func() {
+	var (
+		d   *persistentCongestionDetector
+		now time.Time
+	)
+
+	at := func(d time.Duration) time.Time { return now.Add(d) }
+
+	BeforeEach(func() {
+		d = &persistentCongestionDetector{}
+		now = time.Now()
+	})
+
+	It("measures the period between the first and the last lost packet", func() {
+		Expect(d.LongestLostPeriod()).To(BeZero())
+		d.Lost(at(time.Second))
+		Expect(d.LongestLostPeriod()).To(BeZero())
+		d.Lost(at(3 * time.Second))
+		d.Lost(at(2 * time.Second))
+		Expect(d.LongestLostPeriod()).To(Equal(2 * time.Second))
+	})
+
+	It("ends the period at acknowledged packets", func() {
+		d.Lost(at(time.Second))
+		d.Lost(at(3 * time.Second))
+		d.Lost(at(5 * time.Second))
+		d.Acked(at(6 * time.Second))
+		d.Lost(at(7 * time.Second))
+		Expect(d.LongestLostPeriod()).To(Equal(4 * time.Second))
+		d.Acked(at(2 * time.Second))
+		Expect(d.LongestLostPeriod()).To(Equal(2 * time.Second))
+	})
+
+	It("handles packets that are declared lost after a packet sent later was acknowledged", func() {
+		d.Acked(at(10 * time.Second))
+		d.Lost(at(time.Second))
+		d.Lost(at(8 * time.Second))
+		Expect(d.LongestLostPeriod()).To(Equal(7 * time.Second))
+	})
+
+	It("ends the period at an acknowledgement for a packet sent at the same time as a lost packet", func() {
+		d.Lost(at(time.Second))
+		d.Lost(at(3 * time.Second))
+		d.Lost(at(5 * time.Second))
+		d.Acked(at(3 * time.Second))
+		Expect(d.LongestLostPeriod()).To(BeZero())
+	})
+
+	It("keeps the period when pruning", func() {
+		d.Lost(at(time.Second))
+		d.Acked(at(2 * time.Second))
+		d.Lost(at(3 * time.Second))
+		d.Lost(at(4 * time.Second))
+		d.Lost(at(5 * time.Second))
+		d.Lost(at(7 * time.Second))
+		d.Prune(at(6 * time.Second))
+		Expect(d.events).To(HaveLen(3))
+		Expect(d.LongestLostPeriod()).To(Equal(4 * time.Second))
+		// the packet sent at 6s is lost
+		d.Lost(at(6 * time.Second))
+		Expect(d.LongestLostPeriod()).To(Equal(4 * time.Second))
+		d.Prune(time.Time{})
+		Expect(d.events).To(HaveLen(2))
+		Expect(d.LongestLostPeriod()).To(Equal(4 * time.Second))
+		d.Lost(at(9 * time.Second))
+		Expect(d.LongestLostPeriod()).To(Equal(6 * time.Second))
+	})
+
+	It("drops all events when pruning after an acknowledged packet", func() {
+		d.Lost(at(time.Second))
+		d.Lost(at(2 * time.Second))
+		d.Acked(at(3 * time.Second))
+		d.Prune(at(4 * time.Second))
+		Expect(d.events).To(BeEmpty())
+		d.Lost(at(5 * time.Second))
+		Expect(d.LongestLostPeriod()).To(BeZero())
+	})
+
+	It("resets", func() {
+		d.Lost(at(time.Second))
+		d.Lost(at(5 * time.Second))
+		d.Reset()
+		Expect(d.LongestLostPeriod()).To(BeZero())
+		d.Lost(at(6 * time.Second))
+		Expect(d.LongestLostPeriod()).To(BeZero())
+	})
+})
@@ -21,6 +21,19 @@ type receivedPacketHandler struct {
 
 var _ ReceivedPacketHandler = &receivedPacketHandler{}
 
+// The pathSentPacketTracker is used for the additional paths of a multipath connection.
+// PATH_ACK frames can be sent on any path, so we never learn that the peer received them,
+// and we can't stop acknowledging packets.
+type pathSentPacketTracker struct{}
+
+var _ sentPacketTracker = pathSentPacketTracker{}
+
+func (pathSentPacketTracker) GetLowestPacketNotConfirmedAcked() protocol.PacketNumber {
+	return protocol.InvalidPacketNumber
+}
+
+func (pathSentPacketTracker) ReceivedPacket(protocol.EncryptionLevel) {}
+
 func newReceivedPacketHandler(
 	sentPackets sentPacketTracker,
 	rttStats *utils.RTTStats,
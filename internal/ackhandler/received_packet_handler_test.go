@@ -163,4 +163,20 @@ var _ = Describe("Received Packet Handler", func() {
 		Expect(handler.ReceivedPacket(4, protocol.ECNNon, protocol.Encryption1RTT, sendTime, true)).To(Succeed())
 		Expect(handler.IsPotentiallyDuplicate(4, protocol.Encryption1RTT)).To(BeTrue())
 	})
+
+	It("acknowledges packets on additional paths of a multipath connection", func() {
+		handler := NewPathReceivedPacketHandler(utils.NewRTTStats(), utils.DefaultLogger)
+		sendTime := time.Now().Add(-time.Second)
+		Expect(handler.ReceivedPacket(0, protocol.ECNNon, protocol.Encryption1RTT, sendTime, true)).To(Succeed())
+		Expect(handler.ReceivedPacket(1, protocol.ECNNon, protocol.Encryption1RTT, sendTime, true)).To(Succeed())
+		ack := handler.GetAckFrame(protocol.Encryption1RTT, true)
+		Expect(ack).ToNot(BeNil())
+		Expect(ack.AckRanges).To(Equal([]wire.AckRange{{Smallest: 0, Largest: 1}}))
+		// packets are never removed from the ACK ranges, since we don't know if the peer received the PATH_ACK frame
+		Expect(handler.ReceivedPacket(3, protocol.ECNNon, protocol.Encryption1RTT, sendTime, true)).To(Succeed())
+		ack = handler.GetAckFrame(protocol.Encryption1RTT, false)
+		Expect(ack).ToNot(BeNil())
+		Expect(ack.LowestAcked()).To(BeZero())
+		Expect(ack.LargestAcked()).To(Equal(protocol.PacketNumber(3)))
+	})
 })
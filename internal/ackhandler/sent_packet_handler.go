@@ -5,7 +5,8 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/quic-go/quic-go/internal/congestion"
+	"github.com/quic-go/quic-go/congestion"
+	internalcongestion "github.com/quic-go/quic-go/internal/congestion"
 	"github.com/quic-go/quic-go/internal/protocol"
 	"github.com/quic-go/quic-go/internal/qerr"
 	"github.com/quic-go/quic-go/internal/utils"
@@ -25,6 +26,8 @@ const (
 	minRTTAfterRetry = 5 * time.Millisecond
 	// The PTO duration uses exponential backoff, but is truncated to a maximum value, as allowed by RFC 8961, section 4.4.
 	maxPTODuration = 60 * time.Second
+	// Persistent congestion is declared if packets sent over a period longer than this number of PTOs are lost.
+	persistentCongestionThreshold = 3
 )
 
 type packetNumberSpace struct {
@@ -79,8 +82,14 @@ type sentPacketHandler struct {
 
 	bytesInFlight protocol.ByteCount
 
-	congestion congestion.SendAlgorithmWithDebugInfos
-	rttStats   *utils.RTTStats
+	congestion congestion.SendAlgorithm
+	// creates the congestion controller for a new path
+	newCongestionControl func() congestion.SendAlgorithm
+	rttStats             *utils.RTTStats
+	// The time when the first RTT sample was obtained.
+	// Only packets sent after this time are considered for persistent congestion detection.
+	firstRTTSampleTime   time.Time
+	persistentCongestion persistentCongestionDetector
 
 	// The number of times a PTO has been sent without receiving an ack.
 	ptoCount uint32
@@ -95,6 +104,8 @@ type sentPacketHandler struct {
 	enableECN  bool
 	ecnTracker ecnHandler
 
+	stats Stats
+
 	perspective protocol.Perspective
 
 	tracer *logging.ConnectionTracer
@@ -114,17 +125,27 @@ func newSentPacketHandler(
 	rttStats *utils.RTTStats,
 	clientAddressValidated bool,
 	enableECN bool,
+	newCongestionControl func(congestion.RTTStats, protocol.ByteCount) congestion.SendAlgorithm,
 	pers protocol.Perspective,
 	tracer *logging.ConnectionTracer,
 	logger utils.Logger,
 ) *sentPacketHandler {
-	congestion := congestion.NewCubicSender(
-		congestion.DefaultClock{},
-		rttStats,
-		initialMaxDatagramSize,
-		true, // use Reno
-		tracer,
-	)
+	newCC := func() congestion.SendAlgorithm {
+		if newCongestionControl == nil {
+			return internalcongestion.NewCubicSender(
+				internalcongestion.DefaultClock{},
+				rttStats,
+				initialMaxDatagramSize,
+				true, // use Reno
+				tracer,
+			)
+		}
+		cc := newCongestionControl(rttStats, initialMaxDatagramSize)
+		if t, ok := cc.(congestion.SendAlgorithmWithTracer); ok && tracer != nil {
+			t.SetTracer(tracer)
+		}
+		return cc
+	}
 
 	h := &sentPacketHandler{
 		peerCompletedAddressValidation: pers == protocol.PerspectiveServer,
@@ -133,11 +154,12 @@ func newSentPacketHandler(
 		handshakePackets:               newPacketNumberSpace(0, false),
 		appDataPackets:                 newPacketNumberSpace(0, true),
 		rttStats:                       rttStats,
-		congestion:                     congestion,
+		newCongestionControl:           newCC,
 		perspective:                    pers,
 		tracer:                         tracer,
 		logger:                         logger,
 	}
+	h.congestion = newCC()
 	if enableECN {
 		h.enableECN = true
 		h.ecnTracker = newECNTracker(logger, tracer)
@@ -145,6 +167,25 @@ func newSentPacketHandler(
 	return h
 }
 
+// newPathSentPacketHandler creates a sentPacketHandler for an additional path of a multipath connection.
+// It only uses the application data packet number space.
+// Events on additional paths are not traced.
+func newPathSentPacketHandler(
+	initialMaxDatagramSize protocol.ByteCount,
+	rttStats *utils.RTTStats,
+	pathValidated bool,
+	newCongestionControl func(congestion.RTTStats, protocol.ByteCount) congestion.SendAlgorithm,
+	pers protocol.Perspective,
+	logger utils.Logger,
+) *sentPacketHandler {
+	h := newSentPacketHandler(0, initialMaxDatagramSize, rttStats, pathValidated, false, newCongestionControl, pers, nil, logger)
+	h.initialPackets = nil
+	h.handshakePackets = nil
+	h.handshakeConfirmed = true
+	h.peerCompletedAddressValidation = true
+	return h
+}
+
 func (h *sentPacketHandler) removeFromBytesInFlight(p *packet) {
 	if p.includedInBytesInFlight {
 		if p.Length > h.bytesInFlight {
@@ -173,6 +214,7 @@ func (h *sentPacketHandler) DropPackets(encLevel protocol.EncryptionLevel) {
 			return true, nil
 		})
 	}
+	h.congestion.DropPacketNumberSpace(encLevel.PacketNumberSpace())
 	// drop the packet history
 	//nolint:exhaustive // Not every packet number space can be dropped.
 	switch encLevel {
@@ -240,8 +282,11 @@ func (h *sentPacketHandler) SentPacket(
 	ecn protocol.ECN,
 	size protocol.ByteCount,
 	isPathMTUProbePacket bool,
+	isPathProbePacket bool,
 ) {
 	h.bytesSent += size
+	h.stats.PacketsSent++
+	h.stats.BytesSent += size
 
 	pnSpace := h.getPacketNumberSpace(encLevel)
 	if h.logger.Debug() && pnSpace.history.HasOutstandingPackets() {
@@ -251,7 +296,22 @@ func (h *sentPacketHandler) SentPacket(
 	}
 
 	pnSpace.largestSent = pn
-	isAckEliciting := len(streamFrames) > 0 || len(frames) > 0
+
+	// Path probe packets are sent on a path that might never be used,
+	// they therefore neither count towards bytes in flight, nor are they subject to congestion control.
+	if isPathProbePacket {
+		p := getPacket()
+		p.SendTime = t
+		p.PacketNumber = pn
+		p.EncryptionLevel = encLevel
+		p.Length = size
+		p.LargestAcked = largestAcked
+		p.Frames = frames
+		p.isPathProbePacket = true
+		pnSpace.history.SentAckElicitingPacket(p)
+		return
+	}
+	isAckEliciting := len(streamFrames) > 0 || HasAckElicitingFrames(frames)
 
 	if isAckEliciting {
 		pnSpace.lastAckElicitingPacketTime = t
@@ -260,7 +320,7 @@ func (h *sentPacketHandler) SentPacket(
 			h.numProbesToSend--
 		}
 	}
-	h.congestion.OnPacketSent(t, h.bytesInFlight, pn, size, isAckEliciting)
+	h.congestion.OnPacketSent(t, h.bytesInFlight, encLevel.PacketNumberSpace(), pn, size, isAckEliciting)
 
 	if encLevel == protocol.Encryption1RTT && h.ecnTracker != nil {
 		h.ecnTracker.SentPacket(pn, ecn)
@@ -330,15 +390,23 @@ func (h *sentPacketHandler) ReceivedAck(ack *wire.AckFrame, encLevel protocol.En
 	if err != nil || len(ackedPackets) == 0 {
 		return false, err
 	}
+	for _, p := range ackedPackets {
+		if !p.isPathProbePacket {
+			h.persistentCongestion.Acked(p.SendTime)
+		}
+	}
 	// update the RTT, if the largest acked is newly acknowledged
 	if len(ackedPackets) > 0 {
-		if p := ackedPackets[len(ackedPackets)-1]; p.PacketNumber == ack.LargestAcked() {
+		if p := ackedPackets[len(ackedPackets)-1]; p.PacketNumber == ack.LargestAcked() && !p.isPathProbePacket {
 			// don't use the ack delay for Initial and Handshake packets
 			var ackDelay time.Duration
 			if encLevel == protocol.Encryption1RTT {
 				ackDelay = utils.Min(ack.DelayTime, h.rttStats.MaxAckDelay())
 			}
 			h.rttStats.UpdateRTT(rcvTime.Sub(p.SendTime), ackDelay, rcvTime)
+			if h.firstRTTSampleTime.IsZero() {
+				h.firstRTTSampleTime = rcvTime
+			}
 			if h.logger.Debug() {
 				h.logger.Debugf("\tupdated RTT: %s (σ: %s)", h.rttStats.SmoothedRTT(), h.rttStats.MeanDeviation())
 			}
@@ -348,9 +416,12 @@ func (h *sentPacketHandler) ReceivedAck(ack *wire.AckFrame, encLevel protocol.En
 
 	// Only inform the ECN tracker about new 1-RTT ACKs if the ACK increases the largest acked.
 	if encLevel == protocol.Encryption1RTT && h.ecnTracker != nil && largestAcked > pnSpace.largestAcked {
-		congested := h.ecnTracker.HandleNewlyAcked(ackedPackets, int64(ack.ECT0), int64(ack.ECT1), int64(ack.ECNCE))
-		if congested {
-			h.congestion.OnCongestionEvent(largestAcked, 0, priorInFlight)
+		if ceMarked := h.ecnTracker.HandleNewlyAcked(ackedPackets, int64(ack.ECT0), int64(ack.ECT1), int64(ack.ECNCE)); ceMarked > 0 {
+			cwnd := h.congestion.GetCongestionWindow()
+			h.congestion.OnECNCongestionEvent(largestAcked, ceMarked, priorInFlight)
+			if newCwnd := h.congestion.GetCongestionWindow(); newCwnd < cwnd && h.tracer != nil && h.tracer.ECNCongestionWindowReduced != nil {
+				h.tracer.ECNCongestionWindowReduced(ceMarked, newCwnd)
+			}
 		}
 	}
 
@@ -362,7 +433,7 @@ func (h *sentPacketHandler) ReceivedAck(ack *wire.AckFrame, encLevel protocol.En
 	var acked1RTTPacket bool
 	for _, p := range ackedPackets {
 		if p.includedInBytesInFlight && !p.declaredLost {
-			h.congestion.OnPacketAcked(p.PacketNumber, p.Length, priorInFlight, rcvTime)
+			h.congestion.OnPacketAcked(p.EncryptionLevel.PacketNumberSpace(), p.PacketNumber, p.Length, priorInFlight, rcvTime)
 		}
 		if p.EncryptionLevel == protocol.Encryption1RTT {
 			acked1RTTPacket = true
@@ -616,8 +687,9 @@ func (h *sentPacketHandler) detectLostPackets(now time.Time, encLevel protocol.E
 	// Packets sent before this time are deemed lost.
 	lostSendTime := now.Add(-lossDelay)
 
+	var lostPersistentCongestionCandidate bool
 	priorInFlight := h.bytesInFlight
-	return pnSpace.history.Iterate(func(p *packet) (bool, error) {
+	if err := pnSpace.history.Iterate(func(p *packet) (bool, error) {
 		if p.PacketNumber > pnSpace.largestAcked {
 			return false, nil
 		}
@@ -654,11 +726,20 @@ func (h *sentPacketHandler) detectLostPackets(now time.Time, encLevel protocol.E
 		if packetLost {
 			pnSpace.history.DeclareLost(p.PacketNumber)
 			if !p.skippedPacket {
+				h.stats.PacketsLost++
+				h.stats.BytesLost += p.Length
+				// Path probe packets are not included in the bytes in flight.
+				// Their loss says nothing about the congestion on the current path.
+				wasInFlight := p.includedInBytesInFlight
 				// the bytes in flight need to be reduced no matter if the frames in this packet will be retransmitted
 				h.removeFromBytesInFlight(p)
 				h.queueFramesForRetransmission(p)
-				if !p.IsPathMTUProbePacket {
-					h.congestion.OnCongestionEvent(p.PacketNumber, p.Length, priorInFlight)
+				if wasInFlight && !p.IsPathMTUProbePacket {
+					h.congestion.OnCongestionEvent(p.EncryptionLevel.PacketNumberSpace(), p.PacketNumber, p.Length, priorInFlight)
+					if !h.firstRTTSampleTime.IsZero() && p.SendTime.After(h.firstRTTSampleTime) {
+						h.persistentCongestion.Lost(p.SendTime)
+						lostPersistentCongestionCandidate = true
+					}
 				}
 				if encLevel == protocol.Encryption1RTT && h.ecnTracker != nil {
 					h.ecnTracker.LostPacket(p.PacketNumber)
@@ -666,7 +747,42 @@ func (h *sentPacketHandler) detectLostPackets(now time.Time, encLevel protocol.E
 			}
 		}
 		return true, nil
-	})
+	}); err != nil {
+		return err
+	}
+
+	// Persistent congestion is detected if all packets sent over a long enough period are lost,
+	// see section 7.6 of RFC 9002.
+	// Packets from all packet number spaces, lost in previous runs of the loss detection, are taken into account.
+	if lostPersistentCongestionCandidate &&
+		h.persistentCongestion.LongestLostPeriod() > h.rttStats.PTO(true)*persistentCongestionThreshold {
+		if h.logger.Debug() {
+			h.logger.Debugf("\tpersistent congestion detected")
+		}
+		h.persistentCongestion.Reset()
+		h.congestion.OnPersistentCongestion()
+		if h.tracer != nil && h.tracer.DetectedPersistentCongestion != nil {
+			h.tracer.DetectedPersistentCongestion(h.congestion.GetCongestionWindow())
+		}
+	}
+	h.persistentCongestion.Prune(h.firstOutstandingSendTime())
+	return nil
+}
+
+// firstOutstandingSendTime returns the send time of the first outstanding packet,
+// across all packet number spaces.
+// It returns the zero value if there are no outstanding packets.
+func (h *sentPacketHandler) firstOutstandingSendTime() time.Time {
+	var t time.Time
+	for _, pnSpace := range []*packetNumberSpace{h.initialPackets, h.handshakePackets, h.appDataPackets} {
+		if pnSpace == nil {
+			continue
+		}
+		if p := pnSpace.history.FirstOutstanding(); p != nil && (t.IsZero() || p.SendTime.Before(t)) {
+			t = p.SendTime
+		}
+	}
+	return t
 }
 
 func (h *sentPacketHandler) OnLossDetectionTimeout() error {
@@ -690,6 +806,7 @@ func (h *sentPacketHandler) OnLossDetectionTimeout() error {
 	// actually packets outstanding.
 	if h.bytesInFlight == 0 && !h.peerCompletedAddressValidation {
 		h.ptoCount++
+		h.stats.PTOCount++
 		h.numProbesToSend++
 		if h.initialPackets != nil {
 			h.ptoMode = SendPTOInitial
@@ -709,6 +826,7 @@ func (h *sentPacketHandler) OnLossDetectionTimeout() error {
 		return nil
 	}
 	h.ptoCount++
+	h.stats.PTOCount++
 	if h.logger.Debug() {
 		h.logger.Debugf("Loss detection alarm for %s fired in PTO mode. PTO count: %d", encLevel, h.ptoCount)
 	}
@@ -738,6 +856,13 @@ func (h *sentPacketHandler) OnLossDetectionTimeout() error {
 	return nil
 }
 
+func (h *sentPacketHandler) Stats() Stats {
+	stats := h.stats
+	stats.CongestionWindow = h.congestion.GetCongestionWindow()
+	stats.BytesInFlight = h.bytesInFlight
+	return stats
+}
+
 func (h *sentPacketHandler) GetLossDetectionTimeout() time.Time {
 	return h.alarm
 }
@@ -850,6 +975,7 @@ func (h *sentPacketHandler) queueFramesForRetransmission(p *packet) {
 	if len(p.Frames) == 0 && len(p.StreamFrames) == 0 {
 		panic("no frames")
 	}
+	h.stats.PacketsRetransmitted++
 	for _, f := range p.Frames {
 		if f.Handler != nil {
 			f.Handler.OnLost(f.Frame)
@@ -891,6 +1017,7 @@ func (h *sentPacketHandler) ResetForRetry(now time.Time) error {
 	if h.ptoCount == 0 {
 		// Don't set the RTT to a value lower than 5ms here.
 		h.rttStats.UpdateRTT(utils.Max(minRTTAfterRetry, now.Sub(firstPacketSendTime)), 0, now)
+		h.firstRTTSampleTime = now
 		if h.logger.Debug() {
 			h.logger.Debugf("\tupdated RTT: %s (σ: %s)", h.rttStats.SmoothedRTT(), h.rttStats.MeanDeviation())
 		}
@@ -914,6 +1041,73 @@ func (h *sentPacketHandler) ResetForRetry(now time.Time) error {
 	return nil
 }
 
+func (h *sentPacketHandler) MigratedPath(now time.Time, pathValidated, keepCongestionState bool) *PathCongestionState {
+	var state *PathCongestionState
+	if !keepCongestionState {
+		state = &PathCongestionState{
+			rttStats:           *h.rttStats,
+			firstRTTSampleTime: h.firstRTTSampleTime,
+			congestion:         h.congestion,
+		}
+		h.rttStats.OnConnectionMigration()
+		h.firstRTTSampleTime = time.Time{}
+		h.congestion = h.newCongestionControl()
+	}
+	h.switchedPath(now, pathValidated)
+	return state
+}
+
+func (h *sentPacketHandler) RestorePath(now time.Time, state *PathCongestionState) {
+	if state != nil {
+		*h.rttStats = state.rttStats
+		h.firstRTTSampleTime = state.firstRTTSampleTime
+		h.congestion = state.congestion
+	}
+	h.switchedPath(now, true)
+}
+
+// switchedPath is called when the connection switched to a different path.
+func (h *sentPacketHandler) switchedPath(now time.Time, pathValidated bool) {
+	// All packets sent on the old path are declared lost, and their frames are retransmitted on the new path.
+	// Path probe packets don't carry any frames that need to be retransmitted.
+	// The congestion controller is not notified, since the losses say nothing about the congestion on the new path.
+	h.appDataPackets.history.Iterate(func(p *packet) (bool, error) {
+		if p.declaredLost || p.skippedPacket || p.isPathProbePacket {
+			return true, nil
+		}
+		h.stats.PacketsLost++
+		h.stats.BytesLost += p.Length
+		h.removeFromBytesInFlight(p)
+		h.queueFramesForRetransmission(p)
+		h.appDataPackets.history.DeclareLost(p.PacketNumber)
+		return true, nil
+	})
+	h.appDataPackets.lossTime = time.Time{}
+	h.persistentCongestion.Reset()
+	// The anti-amplification limit applies to the new path until it is validated.
+	// Only bytes received on the new path count towards the limit.
+	h.peerAddressValidated = pathValidated
+	h.bytesReceived = 0
+	h.bytesSent = 0
+	if h.tracer != nil && h.tracer.UpdatedPTOCount != nil && h.ptoCount != 0 {
+		h.tracer.UpdatedPTOCount(0)
+	}
+	h.ptoCount = 0
+	h.numProbesToSend = 0
+	if h.tracer != nil && h.tracer.UpdatedMetrics != nil {
+		h.tracer.UpdatedMetrics(h.rttStats, h.congestion.GetCongestionWindow(), h.bytesInFlight, h.packetsInFlight())
+	}
+	h.setLossDetectionTimer()
+}
+
+func (h *sentPacketHandler) SetPathValidated() {
+	if h.peerAddressValidated {
+		return
+	}
+	h.peerAddressValidated = true
+	h.setLossDetectionTimer()
+}
+
 func (h *sentPacketHandler) SetHandshakeConfirmed() {
 	if h.initialPackets != nil {
 		panic("didn't drop initial correctly")
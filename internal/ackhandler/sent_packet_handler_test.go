@@ -4,11 +4,13 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/quic-go/quic-go/congestion"
 	"github.com/quic-go/quic-go/internal/mocks"
 	"github.com/quic-go/quic-go/internal/protocol"
 	"github.com/quic-go/quic-go/internal/qerr"
 	"github.com/quic-go/quic-go/internal/utils"
 	"github.com/quic-go/quic-go/internal/wire"
+	"github.com/quic-go/quic-go/logging"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -44,7 +46,7 @@ var _ = Describe("SentPacketHandler", func() {
 	JustBeforeEach(func() {
 		lostPackets = nil
 		rttStats := utils.NewRTTStats()
-		handler = newSentPacketHandler(42, protocol.InitialPacketSizeIPv4, rttStats, false, false, perspective, nil, utils.DefaultLogger)
+		handler = newSentPacketHandler(42, protocol.InitialPacketSizeIPv4, rttStats, false, false, nil, perspective, nil, utils.DefaultLogger)
 		streamFrame = wire.StreamFrame{
 			StreamID: 5,
 			Data:     []byte{0x13, 0x37},
@@ -106,7 +108,7 @@ var _ = Describe("SentPacketHandler", func() {
 	}
 
 	sentPacket := func(p *packet) {
-		handler.SentPacket(p.SendTime, p.PacketNumber, p.LargestAcked, p.StreamFrames, p.Frames, p.EncryptionLevel, protocol.ECNNon, p.Length, p.IsPathMTUProbePacket)
+		handler.SentPacket(p.SendTime, p.PacketNumber, p.LargestAcked, p.StreamFrames, p.Frames, p.EncryptionLevel, protocol.ECNNon, p.Length, p.IsPathMTUProbePacket, false)
 	}
 
 	expectInPacketHistory := func(expected []protocol.PacketNumber, encLevel protocol.EncryptionLevel) {
@@ -517,17 +519,77 @@ var _ = Describe("SentPacketHandler", func() {
 	})
 
 	Context("congestion", func() {
-		var cong *mocks.MockSendAlgorithmWithDebugInfos
+		var cong *mocks.MockSendAlgorithm
 
 		JustBeforeEach(func() {
-			cong = mocks.NewMockSendAlgorithmWithDebugInfos(mockCtrl)
+			cong = mocks.NewMockSendAlgorithm(mockCtrl)
 			handler.congestion = cong
 		})
 
+		It("uses the congestion controller created by the factory", func() {
+			rttStats := utils.NewRTTStats()
+			cc := mocks.NewMockSendAlgorithm(mockCtrl)
+			var calledWith protocol.ByteCount
+			h := newSentPacketHandler(
+				0,
+				1234,
+				rttStats,
+				false,
+				false,
+				func(r congestion.RTTStats, size protocol.ByteCount) congestion.SendAlgorithm {
+					Expect(r).To(BeIdenticalTo(rttStats))
+					calledWith = size
+					return cc
+				},
+				perspective,
+				nil,
+				utils.DefaultLogger,
+			)
+			Expect(calledWith).To(Equal(protocol.ByteCount(1234)))
+			cc.EXPECT().GetCongestionWindow().Return(protocol.ByteCount(5678))
+			Expect(h.Stats().CongestionWindow).To(Equal(protocol.ByteCount(5678)))
+		})
+
+		It("passes the tracer to the congestion controller", func() {
+			var states []logging.CongestionState
+			h := newSentPacketHandler(
+				0,
+				1234,
+				utils.NewRTTStats(),
+				false,
+				false,
+				congestion.NewBBR,
+				perspective,
+				&logging.ConnectionTracer{
+					UpdatedCongestionState: func(s logging.CongestionState) { states = append(states, s) },
+				},
+				utils.DefaultLogger,
+			)
+			Expect(h.congestion).ToNot(BeNil())
+			Expect(states).To(Equal([]logging.CongestionState{logging.CongestionStateStartup}))
+		})
+
+		It("tells the congestion controller about the packet number space", func() {
+			now := time.Now()
+			cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), protocol.PacketNumberSpaceInitial, protocol.PacketNumber(0), gomock.Any(), true)
+			cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), protocol.PacketNumberSpaceHandshake, protocol.PacketNumber(0), gomock.Any(), true)
+			cong.EXPECT().GetCongestionWindow().AnyTimes()
+			for _, encLevel := range []protocol.EncryptionLevel{protocol.EncryptionInitial, protocol.EncryptionHandshake} {
+				handler.SentPacket(now, 0, protocol.InvalidPacketNumber, nil, []Frame{{Frame: &wire.PingFrame{}}}, encLevel, protocol.ECNNon, 42, false, false)
+			}
+			cong.EXPECT().MaybeExitSlowStart()
+			cong.EXPECT().OnPacketAcked(protocol.PacketNumberSpaceHandshake, protocol.PacketNumber(0), protocol.ByteCount(42), gomock.Any(), gomock.Any())
+			_, err := handler.ReceivedAck(&wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 0, Largest: 0}}}, protocol.EncryptionHandshake, now.Add(time.Second))
+			Expect(err).ToNot(HaveOccurred())
+			cong.EXPECT().DropPacketNumberSpace(protocol.PacketNumberSpaceInitial)
+			handler.DropPackets(protocol.EncryptionInitial)
+		})
+
 		It("should call OnSent", func() {
 			cong.EXPECT().OnPacketSent(
 				gomock.Any(),
 				protocol.ByteCount(42),
+				protocol.PacketNumberSpaceApplicationData,
 				protocol.PacketNumber(1),
 				protocol.ByteCount(42),
 				true,
@@ -542,11 +604,11 @@ var _ = Describe("SentPacketHandler", func() {
 
 		It("should call MaybeExitSlowStart and OnPacketAcked", func() {
 			rcvTime := time.Now().Add(-5 * time.Second)
-			cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(3)
+			cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(3)
 			gomock.InOrder(
 				cong.EXPECT().MaybeExitSlowStart(), // must be called before packets are acked
-				cong.EXPECT().OnPacketAcked(protocol.PacketNumber(1), protocol.ByteCount(1), protocol.ByteCount(3), rcvTime),
-				cong.EXPECT().OnPacketAcked(protocol.PacketNumber(2), protocol.ByteCount(1), protocol.ByteCount(3), rcvTime),
+				cong.EXPECT().OnPacketAcked(protocol.PacketNumberSpaceApplicationData, protocol.PacketNumber(1), protocol.ByteCount(1), protocol.ByteCount(3), rcvTime),
+				cong.EXPECT().OnPacketAcked(protocol.PacketNumberSpaceApplicationData, protocol.PacketNumber(2), protocol.ByteCount(1), protocol.ByteCount(3), rcvTime),
 			)
 			sentPacket(ackElicitingPacket(&packet{PacketNumber: 1}))
 			sentPacket(ackElicitingPacket(&packet{PacketNumber: 2}))
@@ -557,14 +619,14 @@ var _ = Describe("SentPacketHandler", func() {
 		})
 
 		It("doesn't call OnPacketAcked when a retransmitted packet is acked", func() {
-			cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+			cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
 			sentPacket(ackElicitingPacket(&packet{PacketNumber: 1, SendTime: time.Now().Add(-time.Hour)}))
 			sentPacket(ackElicitingPacket(&packet{PacketNumber: 2}))
 			// lose packet 1
 			gomock.InOrder(
 				cong.EXPECT().MaybeExitSlowStart(),
-				cong.EXPECT().OnCongestionEvent(protocol.PacketNumber(1), protocol.ByteCount(1), protocol.ByteCount(2)),
-				cong.EXPECT().OnPacketAcked(protocol.PacketNumber(2), protocol.ByteCount(1), protocol.ByteCount(2), gomock.Any()),
+				cong.EXPECT().OnCongestionEvent(protocol.PacketNumberSpaceApplicationData, protocol.PacketNumber(1), protocol.ByteCount(1), protocol.ByteCount(2)),
+				cong.EXPECT().OnPacketAcked(protocol.PacketNumberSpaceApplicationData, protocol.PacketNumber(2), protocol.ByteCount(1), protocol.ByteCount(2), gomock.Any()),
 			)
 			ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 2, Largest: 2}}}
 			_, err := handler.ReceivedAck(ack, protocol.Encryption1RTT, time.Now())
@@ -576,7 +638,7 @@ var _ = Describe("SentPacketHandler", func() {
 		})
 
 		It("doesn't call OnCongestionEvent when a Path MTU probe packet is lost", func() {
-			cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+			cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
 			var mtuPacketDeclaredLost bool
 			sentPacket(ackElicitingPacket(&packet{
 				PacketNumber:         1,
@@ -593,7 +655,7 @@ var _ = Describe("SentPacketHandler", func() {
 			// lose packet 1, but don't EXPECT any calls to OnCongestionEvent()
 			gomock.InOrder(
 				cong.EXPECT().MaybeExitSlowStart(),
-				cong.EXPECT().OnPacketAcked(protocol.PacketNumber(2), protocol.ByteCount(1), protocol.ByteCount(2), gomock.Any()),
+				cong.EXPECT().OnPacketAcked(protocol.PacketNumberSpaceApplicationData, protocol.PacketNumber(2), protocol.ByteCount(1), protocol.ByteCount(2), gomock.Any()),
 			)
 			ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 2, Largest: 2}}}
 			_, err := handler.ReceivedAck(ack, protocol.Encryption1RTT, time.Now())
@@ -603,7 +665,7 @@ var _ = Describe("SentPacketHandler", func() {
 		})
 
 		It("calls OnPacketAcked and OnCongestionEvent with the right bytes_in_flight value", func() {
-			cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(4)
+			cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(4)
 			sentPacket(ackElicitingPacket(&packet{PacketNumber: 1, SendTime: time.Now().Add(-time.Hour)}))
 			sentPacket(ackElicitingPacket(&packet{PacketNumber: 2, SendTime: time.Now().Add(-30 * time.Minute)}))
 			sentPacket(ackElicitingPacket(&packet{PacketNumber: 3, SendTime: time.Now().Add(-30 * time.Minute)}))
@@ -611,8 +673,8 @@ var _ = Describe("SentPacketHandler", func() {
 			// receive the first ACK
 			gomock.InOrder(
 				cong.EXPECT().MaybeExitSlowStart(),
-				cong.EXPECT().OnCongestionEvent(protocol.PacketNumber(1), protocol.ByteCount(1), protocol.ByteCount(4)),
-				cong.EXPECT().OnPacketAcked(protocol.PacketNumber(2), protocol.ByteCount(1), protocol.ByteCount(4), gomock.Any()),
+				cong.EXPECT().OnCongestionEvent(protocol.PacketNumberSpaceApplicationData, protocol.PacketNumber(1), protocol.ByteCount(1), protocol.ByteCount(4)),
+				cong.EXPECT().OnPacketAcked(protocol.PacketNumberSpaceApplicationData, protocol.PacketNumber(2), protocol.ByteCount(1), protocol.ByteCount(4), gomock.Any()),
 			)
 			ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 2, Largest: 2}}}
 			_, err := handler.ReceivedAck(ack, protocol.Encryption1RTT, time.Now().Add(-30*time.Minute))
@@ -620,17 +682,154 @@ var _ = Describe("SentPacketHandler", func() {
 			// receive the second ACK
 			gomock.InOrder(
 				cong.EXPECT().MaybeExitSlowStart(),
-				cong.EXPECT().OnCongestionEvent(protocol.PacketNumber(3), protocol.ByteCount(1), protocol.ByteCount(2)),
-				cong.EXPECT().OnPacketAcked(protocol.PacketNumber(4), protocol.ByteCount(1), protocol.ByteCount(2), gomock.Any()),
+				cong.EXPECT().OnCongestionEvent(protocol.PacketNumberSpaceApplicationData, protocol.PacketNumber(3), protocol.ByteCount(1), protocol.ByteCount(2)),
+				cong.EXPECT().OnPacketAcked(protocol.PacketNumberSpaceApplicationData, protocol.PacketNumber(4), protocol.ByteCount(1), protocol.ByteCount(2), gomock.Any()),
 			)
 			ack = &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 4, Largest: 4}}}
 			_, err = handler.ReceivedAck(ack, protocol.Encryption1RTT, time.Now())
 			Expect(err).ToNot(HaveOccurred())
 		})
 
+		Context("persistent congestion", func() {
+			var now time.Time
+
+			JustBeforeEach(func() {
+				now = time.Now()
+				cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+				cong.EXPECT().OnPacketAcked(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+				cong.EXPECT().MaybeExitSlowStart().AnyTimes()
+				// obtain an RTT sample of 100ms
+				sentPacket(ackElicitingPacket(&packet{PacketNumber: 1, SendTime: now.Add(-10 * time.Second)}))
+				_, err := handler.ReceivedAck(
+					&wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 1, Largest: 1}}},
+					protocol.Encryption1RTT,
+					now.Add(-10*time.Second+100*time.Millisecond),
+				)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(handler.rttStats.SmoothedRTT()).To(Equal(100 * time.Millisecond))
+			})
+
+			It("detects persistent congestion", func() {
+				var cwnd protocol.ByteCount
+				handler.tracer = &logging.ConnectionTracer{
+					DetectedPersistentCongestion: func(c logging.ByteCount) { cwnd = c },
+				}
+				sentPacket(ackElicitingPacket(&packet{PacketNumber: 2, SendTime: now.Add(-8 * time.Second)}))
+				sentPacket(ackElicitingPacket(&packet{PacketNumber: 3, SendTime: now.Add(-6 * time.Second)}))
+				sentPacket(ackElicitingPacket(&packet{PacketNumber: 4, SendTime: now.Add(-5 * time.Second)}))
+				sentPacket(ackElicitingPacket(&packet{PacketNumber: 5, SendTime: now.Add(-50 * time.Millisecond)}))
+				gomock.InOrder(
+					cong.EXPECT().OnCongestionEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(3),
+					cong.EXPECT().OnPersistentCongestion(),
+					cong.EXPECT().GetCongestionWindow().Return(protocol.ByteCount(2400)),
+				)
+				_, err := handler.ReceivedAck(&wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 5, Largest: 5}}}, protocol.Encryption1RTT, now)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lostPackets).To(Equal([]protocol.PacketNumber{2, 3, 4}))
+				Expect(cwnd).To(Equal(protocol.ByteCount(2400)))
+			})
+
+			It("doesn't detect persistent congestion if the period is too short", func() {
+				sentPacket(ackElicitingPacket(&packet{PacketNumber: 2, SendTime: now.Add(-5 * time.Second)}))
+				sentPacket(ackElicitingPacket(&packet{PacketNumber: 3, SendTime: now.Add(-5*time.Second + 500*time.Millisecond)}))
+				sentPacket(ackElicitingPacket(&packet{PacketNumber: 4, SendTime: now.Add(-50 * time.Millisecond)}))
+				cong.EXPECT().OnCongestionEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+				_, err := handler.ReceivedAck(&wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 4, Largest: 4}}}, protocol.Encryption1RTT, now)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lostPackets).To(Equal([]protocol.PacketNumber{2, 3}))
+			})
+
+			It("doesn't detect persistent congestion if a packet sent in between was acknowledged", func() {
+				sentPacket(ackElicitingPacket(&packet{PacketNumber: 2, SendTime: now.Add(-8 * time.Second)}))
+				sentPacket(ackElicitingPacket(&packet{PacketNumber: 3, SendTime: now.Add(-6 * time.Second)}))
+				sentPacket(ackElicitingPacket(&packet{PacketNumber: 4, SendTime: now.Add(-5 * time.Second)}))
+				sentPacket(ackElicitingPacket(&packet{PacketNumber: 5, SendTime: now.Add(-50 * time.Millisecond)}))
+				cong.EXPECT().OnCongestionEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+				_, err := handler.ReceivedAck(
+					&wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 5, Largest: 5}, {Smallest: 3, Largest: 3}}},
+					protocol.Encryption1RTT,
+					now,
+				)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lostPackets).To(Equal([]protocol.PacketNumber{2, 4}))
+			})
+
+			It("detects persistent congestion when packets are declared lost in multiple steps", func() {
+				t := now.Add(-2 * time.Second)
+				sentPacket(ackElicitingPacket(&packet{PacketNumber: 2, SendTime: t}))
+				sentPacket(ackElicitingPacket(&packet{PacketNumber: 3, SendTime: t.Add(500 * time.Millisecond)}))
+				sentPacket(ackElicitingPacket(&packet{PacketNumber: 4, SendTime: t.Add(1500 * time.Millisecond)}))
+				sentPacket(ackElicitingPacket(&packet{PacketNumber: 5, SendTime: t.Add(1550 * time.Millisecond)}))
+				// packets 2 and 3 are lost, packet 4 will be declared lost when the loss timer fires
+				cong.EXPECT().OnCongestionEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+				_, err := handler.ReceivedAck(&wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 5, Largest: 5}}}, protocol.Encryption1RTT, t.Add(1600*time.Millisecond))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lostPackets).To(Equal([]protocol.PacketNumber{2, 3}))
+				Expect(handler.GetLossDetectionTimeout()).ToNot(BeZero())
+				gomock.InOrder(
+					cong.EXPECT().OnCongestionEvent(gomock.Any(), protocol.PacketNumber(4), gomock.Any(), gomock.Any()),
+					cong.EXPECT().OnPersistentCongestion(),
+				)
+				Expect(handler.OnLossDetectionTimeout()).To(Succeed())
+				Expect(lostPackets).To(Equal([]protocol.PacketNumber{2, 3, 4}))
+			})
+
+			It("detects persistent congestion across packet number spaces", func() {
+				t := now.Add(-2 * time.Second)
+				sentPacket(handshakePacket(&packet{PacketNumber: 0, SendTime: t}))
+				sentPacket(handshakePacket(&packet{PacketNumber: 1, SendTime: t.Add(500 * time.Millisecond)}))
+				sentPacket(ackElicitingPacket(&packet{PacketNumber: 2, SendTime: t.Add(1500 * time.Millisecond)}))
+				sentPacket(ackElicitingPacket(&packet{PacketNumber: 3, SendTime: t.Add(1550 * time.Millisecond)}))
+				sentPacket(handshakePacket(&packet{PacketNumber: 2, SendTime: t.Add(1560 * time.Millisecond)}))
+				cong.EXPECT().OnCongestionEvent(protocol.PacketNumberSpaceHandshake, gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+				_, err := handler.ReceivedAck(&wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 2, Largest: 2}}}, protocol.EncryptionHandshake, t.Add(1600*time.Millisecond))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lostPackets).To(Equal([]protocol.PacketNumber{0, 1}))
+				gomock.InOrder(
+					cong.EXPECT().OnCongestionEvent(protocol.PacketNumberSpaceApplicationData, protocol.PacketNumber(2), gomock.Any(), gomock.Any()),
+					cong.EXPECT().OnPersistentCongestion(),
+				)
+				_, err = handler.ReceivedAck(&wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 3, Largest: 3}}}, protocol.Encryption1RTT, t.Add(1650*time.Millisecond))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lostPackets).To(Equal([]protocol.PacketNumber{0, 1, 2}))
+			})
+
+			It("doesn't detect persistent congestion across loss detection runs if a packet sent in between was acknowledged", func() {
+				t := now.Add(-2 * time.Second)
+				sentPacket(ackElicitingPacket(&packet{PacketNumber: 2, SendTime: t}))
+				sentPacket(ackElicitingPacket(&packet{PacketNumber: 3, SendTime: t.Add(500 * time.Millisecond)}))
+				sentPacket(ackElicitingPacket(&packet{PacketNumber: 4, SendTime: t.Add(1000 * time.Millisecond)}))
+				sentPacket(ackElicitingPacket(&packet{PacketNumber: 5, SendTime: t.Add(1500 * time.Millisecond)}))
+				sentPacket(ackElicitingPacket(&packet{PacketNumber: 6, SendTime: t.Add(1550 * time.Millisecond)}))
+				cong.EXPECT().OnCongestionEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+				_, err := handler.ReceivedAck(
+					&wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 6, Largest: 6}, {Smallest: 4, Largest: 4}}},
+					protocol.Encryption1RTT,
+					t.Add(1600*time.Millisecond),
+				)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lostPackets).To(Equal([]protocol.PacketNumber{2, 3}))
+				cong.EXPECT().OnCongestionEvent(gomock.Any(), protocol.PacketNumber(5), gomock.Any(), gomock.Any())
+				Expect(handler.OnLossDetectionTimeout()).To(Succeed())
+				Expect(lostPackets).To(Equal([]protocol.PacketNumber{2, 3, 5}))
+			})
+
+			It("only considers packets sent after the first RTT sample", func() {
+				handler.firstRTTSampleTime = now.Add(-4 * time.Second)
+				sentPacket(ackElicitingPacket(&packet{PacketNumber: 2, SendTime: now.Add(-8 * time.Second)}))
+				sentPacket(ackElicitingPacket(&packet{PacketNumber: 3, SendTime: now.Add(-6 * time.Second)}))
+				sentPacket(ackElicitingPacket(&packet{PacketNumber: 4, SendTime: now.Add(-3 * time.Second)}))
+				sentPacket(ackElicitingPacket(&packet{PacketNumber: 5, SendTime: now.Add(-50 * time.Millisecond)}))
+				cong.EXPECT().OnCongestionEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(3)
+				_, err := handler.ReceivedAck(&wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 5, Largest: 5}}}, protocol.Encryption1RTT, now)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lostPackets).To(Equal([]protocol.PacketNumber{2, 3, 4}))
+			})
+		})
+
 		It("passes the bytes in flight to the congestion controller", func() {
 			handler.ReceivedPacket(protocol.EncryptionHandshake)
-			cong.EXPECT().OnPacketSent(gomock.Any(), protocol.ByteCount(42), gomock.Any(), protocol.ByteCount(42), true)
+			cong.EXPECT().OnPacketSent(gomock.Any(), protocol.ByteCount(42), gomock.Any(), gomock.Any(), protocol.ByteCount(42), true)
 			sentPacket(&packet{
 				Length:          42,
 				EncryptionLevel: protocol.EncryptionInitial,
@@ -655,7 +854,7 @@ var _ = Describe("SentPacketHandler", func() {
 			handler.ReceivedPacket(protocol.EncryptionHandshake)
 			cong.EXPECT().CanSend(gomock.Any()).Return(true).AnyTimes()
 			cong.EXPECT().HasPacingBudget(gomock.Any()).Return(true).AnyTimes()
-			cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+			cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 			for i := protocol.PacketNumber(0); i < protocol.MaxOutstandingSentPackets; i++ {
 				Expect(handler.SendMode(time.Now())).To(Equal(SendAny))
 				sentPacket(ackElicitingPacket(&packet{PacketNumber: i}))
@@ -915,6 +1114,139 @@ var _ = Describe("SentPacketHandler", func() {
 		})
 	})
 
+	Context("path migration", func() {
+		sentPathProbePacket := func(p *packet) {
+			handler.SentPacket(p.SendTime, p.PacketNumber, p.LargestAcked, p.StreamFrames, p.Frames, p.EncryptionLevel, protocol.ECNNon, p.Length, false, true)
+		}
+
+		JustBeforeEach(func() {
+			handler.ReceivedPacket(protocol.EncryptionHandshake)
+			setHandshakeConfirmed()
+		})
+
+		It("doesn't count path probe packets as bytes in flight", func() {
+			cong := mocks.NewMockSendAlgorithm(mockCtrl)
+			handler.congestion = cong
+			updateRTT(time.Second)
+			sentPathProbePacket(ackElicitingPacket(&packet{PacketNumber: 1, Length: 1200}))
+			Expect(handler.bytesInFlight).To(BeZero())
+			Expect(handler.GetLossDetectionTimeout()).To(BeZero())
+			// acknowledging the path probe packet doesn't update the RTT
+			ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 1, Largest: 1}}}
+			_, err := handler.ReceivedAck(ack, protocol.Encryption1RTT, time.Now().Add(time.Hour))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(handler.rttStats.LatestRTT()).To(Equal(time.Second))
+		})
+
+		It("declares all outstanding packets lost and resets the RTT and congestion state", func() {
+			updateRTT(time.Second)
+			sentPacket(ackElicitingPacket(&packet{PacketNumber: 1, Length: 100}))
+			sentPacket(ackElicitingPacket(&packet{PacketNumber: 2, Length: 100}))
+			sentPathProbePacket(ackElicitingPacket(&packet{PacketNumber: 3, Length: 1200}))
+			Expect(handler.bytesInFlight).To(BeEquivalentTo(200))
+
+			cong := mocks.NewMockSendAlgorithm(mockCtrl)
+			handler.congestion = cong
+			state := handler.MigratedPath(time.Now(), true, false)
+			Expect(state).ToNot(BeNil())
+			Expect(lostPackets).To(Equal([]protocol.PacketNumber{1, 2}))
+			Expect(handler.Stats().PacketsLost).To(BeEquivalentTo(2))
+			Expect(handler.Stats().BytesLost).To(BeEquivalentTo(200))
+			Expect(handler.bytesInFlight).To(BeZero())
+			Expect(handler.rttStats.SmoothedRTT()).To(BeZero())
+			Expect(handler.congestion).ToNot(Equal(cong))
+			Expect(handler.ptoCount).To(BeZero())
+			Expect(handler.GetLossDetectionTimeout()).To(BeZero())
+		})
+
+		It("keeps the RTT and congestion state", func() {
+			updateRTT(time.Second)
+			sentPacket(ackElicitingPacket(&packet{PacketNumber: 1, Length: 100}))
+			cong := mocks.NewMockSendAlgorithm(mockCtrl)
+			handler.congestion = cong
+			cong.EXPECT().CanSend(gomock.Any()).Return(true).AnyTimes()
+			Expect(handler.MigratedPath(time.Now(), true, true)).To(BeNil())
+			Expect(lostPackets).To(Equal([]protocol.PacketNumber{1}))
+			Expect(handler.rttStats.SmoothedRTT()).To(Equal(time.Second))
+			Expect(handler.congestion).To(Equal(cong))
+		})
+
+		It("restores the RTT and congestion state", func() {
+			updateRTT(time.Second)
+			cong := mocks.NewMockSendAlgorithm(mockCtrl)
+			handler.congestion = cong
+			state := handler.MigratedPath(time.Now(), false, false)
+			Expect(handler.rttStats.SmoothedRTT()).To(BeZero())
+			sentPacket(ackElicitingPacket(&packet{PacketNumber: 1, Length: 100}))
+			handler.RestorePath(time.Now(), state)
+			Expect(lostPackets).To(Equal([]protocol.PacketNumber{1}))
+			Expect(handler.rttStats.SmoothedRTT()).To(Equal(time.Second))
+			Expect(handler.congestion).To(Equal(cong))
+			// the old path was validated
+			Expect(handler.peerAddressValidated).To(BeTrue())
+		})
+
+		It("applies the anti-amplification limit until the new path is validated", func() {
+			handler.MigratedPath(time.Now(), false, false)
+			handler.ReceivedBytes(100)
+			sentPacket(ackElicitingPacket(&packet{PacketNumber: 1, Length: 299}))
+			Expect(handler.SendMode(time.Now())).To(Equal(SendAny))
+			sentPacket(ackElicitingPacket(&packet{PacketNumber: 2, Length: 1}))
+			Expect(handler.SendMode(time.Now())).To(Equal(SendNone))
+			// the loss detection timer is canceled when amplification limited
+			Expect(handler.GetLossDetectionTimeout()).To(BeZero())
+			handler.SetPathValidated()
+			Expect(handler.SendMode(time.Now())).To(Equal(SendAny))
+			Expect(handler.GetLossDetectionTimeout()).ToNot(BeZero())
+		})
+	})
+
+	Context("additional paths of a multipath connection", func() {
+		var pathValidated bool
+
+		BeforeEach(func() { pathValidated = true })
+
+		JustBeforeEach(func() {
+			handler = newPathSentPacketHandler(protocol.InitialPacketSizeIPv4, utils.NewRTTStats(), pathValidated, nil, perspective, utils.DefaultLogger)
+		})
+
+		It("only uses the application data packet number space", func() {
+			Expect(handler.initialPackets).To(BeNil())
+			Expect(handler.handshakePackets).To(BeNil())
+			pn, _ := handler.PeekPacketNumber(protocol.Encryption1RTT)
+			Expect(pn).To(BeZero())
+			sentPacket(ackElicitingPacket(&packet{PacketNumber: handler.PopPacketNumber(protocol.Encryption1RTT)}))
+			Expect(handler.SendMode(time.Now())).To(Equal(SendAny))
+		})
+
+		It("arms the PTO timer for 1-RTT packets right away", func() {
+			sentPacket(ackElicitingPacket(&packet{PacketNumber: handler.PopPacketNumber(protocol.Encryption1RTT), SendTime: time.Now().Add(-time.Hour)}))
+			Expect(handler.GetLossDetectionTimeout()).ToNot(BeZero())
+			Expect(handler.OnLossDetectionTimeout()).To(Succeed())
+			Expect(handler.SendMode(time.Now())).To(Equal(SendPTOAppData))
+		})
+
+		It("doesn't count packets only containing PATH_ACK frames as ack-eliciting", func() {
+			handler.SentPacket(time.Now(), 0, -1, nil, []Frame{{Frame: &wire.PathAckFrame{PathID: 2}}}, protocol.Encryption1RTT, protocol.ECNNon, 100, false, false)
+			Expect(handler.bytesInFlight).To(BeZero())
+			Expect(handler.GetLossDetectionTimeout()).To(BeZero())
+		})
+
+		Context("for the server, if the path wasn't validated", func() {
+			BeforeEach(func() { pathValidated = false })
+
+			It("is amplification limited until the path is validated", func() {
+				handler.ReceivedBytes(200)
+				sentPacket(ackElicitingPacket(&packet{PacketNumber: 0, Length: 599}))
+				Expect(handler.SendMode(time.Now())).To(Equal(SendAny))
+				sentPacket(ackElicitingPacket(&packet{PacketNumber: 1, Length: 1}))
+				Expect(handler.SendMode(time.Now())).To(Equal(SendNone))
+				handler.SetPathValidated()
+				Expect(handler.SendMode(time.Now())).To(Equal(SendAny))
+			})
+		})
+	})
+
 	Context("amplification limit, for the server", func() {
 		It("limits the window to 3x the bytes received, to avoid amplification attacks", func() {
 			now := time.Now()
@@ -984,7 +1316,7 @@ var _ = Describe("SentPacketHandler", func() {
 	Context("amplification limit, for the server, with validated address", func() {
 		JustBeforeEach(func() {
 			rttStats := utils.NewRTTStats()
-			handler = newSentPacketHandler(42, protocol.InitialPacketSizeIPv4, rttStats, true, false, perspective, nil, utils.DefaultLogger)
+			handler = newSentPacketHandler(42, protocol.InitialPacketSizeIPv4, rttStats, true, false, nil, perspective, nil, utils.DefaultLogger)
 		})
 
 		It("do not limits the window", func() {
@@ -1106,6 +1438,52 @@ var _ = Describe("SentPacketHandler", func() {
 		})
 	})
 
+	Context("statistics", func() {
+		It("counts sent and lost packets", func() {
+			now := time.Now()
+			for i := protocol.PacketNumber(1); i <= 6; i++ {
+				sentPacket(ackElicitingPacket(&packet{PacketNumber: i, Length: 100}))
+			}
+			sentPacket(nonAckElicitingPacket(&packet{PacketNumber: 7, Length: 50}))
+			stats := handler.Stats()
+			Expect(stats.PacketsSent).To(BeEquivalentTo(7))
+			Expect(stats.BytesSent).To(BeEquivalentTo(650))
+			Expect(stats.BytesInFlight).To(BeEquivalentTo(600))
+			Expect(stats.CongestionWindow).To(Equal(handler.congestion.GetCongestionWindow()))
+			ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 6, Largest: 6}}}
+			_, err := handler.ReceivedAck(ack, protocol.Encryption1RTT, now)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lostPackets).To(Equal([]protocol.PacketNumber{1, 2, 3}))
+			stats = handler.Stats()
+			Expect(stats.PacketsLost).To(BeEquivalentTo(3))
+			Expect(stats.BytesLost).To(BeEquivalentTo(300))
+			Expect(stats.PacketsRetransmitted).To(BeEquivalentTo(3))
+			Expect(stats.BytesInFlight).To(BeEquivalentTo(200))
+		})
+
+		It("counts PTOs and retransmissions in probe packets", func() {
+			handler.ReceivedPacket(protocol.EncryptionHandshake)
+			setHandshakeConfirmed()
+			now := time.Now()
+			sentPacket(ackElicitingPacket(&packet{PacketNumber: 1, SendTime: now.Add(-time.Minute)}))
+			handler.appDataPackets.pns.(*skippingPacketNumberGenerator).next = 2
+			Expect(handler.OnLossDetectionTimeout()).To(Succeed())
+			Expect(handler.SendMode(time.Now())).To(Equal(SendPTOAppData))
+			Expect(handler.QueueProbePacket(protocol.Encryption1RTT)).To(BeTrue())
+			stats := handler.Stats()
+			Expect(stats.PTOCount).To(BeEquivalentTo(1))
+			Expect(stats.PacketsRetransmitted).To(BeEquivalentTo(1))
+			// the packet wasn't declared lost by the loss detection
+			Expect(stats.PacketsLost).To(BeZero())
+			// the PTO count is not reset when an ACK is received
+			sentPacket(ackElicitingPacket(&packet{PacketNumber: 3}))
+			_, err := handler.ReceivedAck(&wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 3, Largest: 3}}}, protocol.Encryption1RTT, time.Now())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(handler.ptoCount).To(BeZero())
+			Expect(handler.Stats().PTOCount).To(BeEquivalentTo(1))
+		})
+	})
+
 	Context("Packet-based loss detection", func() {
 		It("declares packet below the packet loss threshold as lost", func() {
 			now := time.Now()
@@ -1432,48 +1810,48 @@ var _ = Describe("SentPacketHandler", func() {
 
 	Context("ECN handling", func() {
 		var ecnHandler *MockECNHandler
-		var cong *mocks.MockSendAlgorithmWithDebugInfos
+		var cong *mocks.MockSendAlgorithm
 
 		JustBeforeEach(func() {
-			cong = mocks.NewMockSendAlgorithmWithDebugInfos(mockCtrl)
-			cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
-			cong.EXPECT().OnPacketAcked(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+			cong = mocks.NewMockSendAlgorithm(mockCtrl)
+			cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+			cong.EXPECT().OnPacketAcked(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 			cong.EXPECT().MaybeExitSlowStart().AnyTimes()
 			ecnHandler = NewMockECNHandler(mockCtrl)
 			lostPackets = nil
 			rttStats := utils.NewRTTStats()
 			rttStats.UpdateRTT(time.Hour, 0, time.Now())
-			handler = newSentPacketHandler(42, protocol.InitialPacketSizeIPv4, rttStats, false, false, perspective, nil, utils.DefaultLogger)
+			handler = newSentPacketHandler(42, protocol.InitialPacketSizeIPv4, rttStats, false, false, nil, perspective, nil, utils.DefaultLogger)
 			handler.ecnTracker = ecnHandler
 			handler.congestion = cong
 		})
 
 		It("informs about sent packets", func() {
 			// Check that only 1-RTT packets are reported
-			handler.SentPacket(time.Now(), 100, -1, nil, nil, protocol.EncryptionInitial, protocol.ECT1, 1200, false)
-			handler.SentPacket(time.Now(), 101, -1, nil, nil, protocol.EncryptionHandshake, protocol.ECT0, 1200, false)
-			handler.SentPacket(time.Now(), 102, -1, nil, nil, protocol.Encryption0RTT, protocol.ECNCE, 1200, false)
+			handler.SentPacket(time.Now(), 100, -1, nil, nil, protocol.EncryptionInitial, protocol.ECT1, 1200, false, false)
+			handler.SentPacket(time.Now(), 101, -1, nil, nil, protocol.EncryptionHandshake, protocol.ECT0, 1200, false, false)
+			handler.SentPacket(time.Now(), 102, -1, nil, nil, protocol.Encryption0RTT, protocol.ECNCE, 1200, false, false)
 
 			ecnHandler.EXPECT().SentPacket(protocol.PacketNumber(103), protocol.ECT1)
-			handler.SentPacket(time.Now(), 103, -1, nil, nil, protocol.Encryption1RTT, protocol.ECT1, 1200, false)
+			handler.SentPacket(time.Now(), 103, -1, nil, nil, protocol.Encryption1RTT, protocol.ECT1, 1200, false, false)
 		})
 
 		It("informs about sent packets", func() {
 			// Check that only 1-RTT packets are reported
-			handler.SentPacket(time.Now(), 100, -1, nil, nil, protocol.EncryptionInitial, protocol.ECT1, 1200, false)
-			handler.SentPacket(time.Now(), 101, -1, nil, nil, protocol.EncryptionHandshake, protocol.ECT0, 1200, false)
-			handler.SentPacket(time.Now(), 102, -1, nil, nil, protocol.Encryption0RTT, protocol.ECNCE, 1200, false)
+			handler.SentPacket(time.Now(), 100, -1, nil, nil, protocol.EncryptionInitial, protocol.ECT1, 1200, false, false)
+			handler.SentPacket(time.Now(), 101, -1, nil, nil, protocol.EncryptionHandshake, protocol.ECT0, 1200, false, false)
+			handler.SentPacket(time.Now(), 102, -1, nil, nil, protocol.Encryption0RTT, protocol.ECNCE, 1200, false, false)
 
 			ecnHandler.EXPECT().SentPacket(protocol.PacketNumber(103), protocol.ECT1)
-			handler.SentPacket(time.Now(), 103, -1, nil, nil, protocol.Encryption1RTT, protocol.ECT1, 1200, false)
+			handler.SentPacket(time.Now(), 103, -1, nil, nil, protocol.Encryption1RTT, protocol.ECT1, 1200, false, false)
 		})
 
 		It("informs about lost packets", func() {
 			for i := 10; i < 20; i++ {
 				ecnHandler.EXPECT().SentPacket(protocol.PacketNumber(i), protocol.ECT1)
-				handler.SentPacket(time.Now(), protocol.PacketNumber(i), -1, []StreamFrame{{Frame: &streamFrame}}, nil, protocol.Encryption1RTT, protocol.ECT1, 1200, false)
+				handler.SentPacket(time.Now(), protocol.PacketNumber(i), -1, []StreamFrame{{Frame: &streamFrame}}, nil, protocol.Encryption1RTT, protocol.ECT1, 1200, false, false)
 			}
-			cong.EXPECT().OnCongestionEvent(gomock.Any(), gomock.Any(), gomock.Any()).Times(3)
+			cong.EXPECT().OnCongestionEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(3)
 			ecnHandler.EXPECT().LostPacket(protocol.PacketNumber(10))
 			ecnHandler.EXPECT().LostPacket(protocol.PacketNumber(11))
 			ecnHandler.EXPECT().LostPacket(protocol.PacketNumber(12))
@@ -1484,22 +1862,22 @@ var _ = Describe("SentPacketHandler", func() {
 
 		It("processes ACKs", func() {
 			// Check that we only care about 1-RTT packets.
-			handler.SentPacket(time.Now(), 100, -1, []StreamFrame{{Frame: &streamFrame}}, nil, protocol.EncryptionInitial, protocol.ECT1, 1200, false)
+			handler.SentPacket(time.Now(), 100, -1, []StreamFrame{{Frame: &streamFrame}}, nil, protocol.EncryptionInitial, protocol.ECT1, 1200, false, false)
 			_, err := handler.ReceivedAck(&wire.AckFrame{AckRanges: []wire.AckRange{{Largest: 100, Smallest: 100}}}, protocol.EncryptionInitial, time.Now())
 			Expect(err).ToNot(HaveOccurred())
 
 			for i := 10; i < 20; i++ {
 				ecnHandler.EXPECT().SentPacket(protocol.PacketNumber(i), protocol.ECT1)
-				handler.SentPacket(time.Now(), protocol.PacketNumber(i), -1, []StreamFrame{{Frame: &streamFrame}}, nil, protocol.Encryption1RTT, protocol.ECT1, 1200, false)
+				handler.SentPacket(time.Now(), protocol.PacketNumber(i), -1, []StreamFrame{{Frame: &streamFrame}}, nil, protocol.Encryption1RTT, protocol.ECT1, 1200, false, false)
 			}
-			ecnHandler.EXPECT().HandleNewlyAcked(gomock.Any(), int64(1), int64(2), int64(3)).DoAndReturn(func(packets []*packet, _, _, _ int64) bool {
+			ecnHandler.EXPECT().HandleNewlyAcked(gomock.Any(), int64(1), int64(2), int64(3)).DoAndReturn(func(packets []*packet, _, _, _ int64) int64 {
 				Expect(packets).To(HaveLen(5))
 				Expect(packets[0].PacketNumber).To(Equal(protocol.PacketNumber(10)))
 				Expect(packets[1].PacketNumber).To(Equal(protocol.PacketNumber(11)))
 				Expect(packets[2].PacketNumber).To(Equal(protocol.PacketNumber(12)))
 				Expect(packets[3].PacketNumber).To(Equal(protocol.PacketNumber(14)))
 				Expect(packets[4].PacketNumber).To(Equal(protocol.PacketNumber(15)))
-				return false
+				return 0
 			})
 			_, err = handler.ReceivedAck(&wire.AckFrame{
 				AckRanges: []wire.AckRange{
@@ -1516,13 +1894,13 @@ var _ = Describe("SentPacketHandler", func() {
 		It("ignores reordered ACKs", func() {
 			for i := 10; i < 20; i++ {
 				ecnHandler.EXPECT().SentPacket(protocol.PacketNumber(i), protocol.ECT1)
-				handler.SentPacket(time.Now(), protocol.PacketNumber(i), -1, []StreamFrame{{Frame: &streamFrame}}, nil, protocol.Encryption1RTT, protocol.ECT1, 1200, false)
+				handler.SentPacket(time.Now(), protocol.PacketNumber(i), -1, []StreamFrame{{Frame: &streamFrame}}, nil, protocol.Encryption1RTT, protocol.ECT1, 1200, false, false)
 			}
-			ecnHandler.EXPECT().HandleNewlyAcked(gomock.Any(), int64(1), int64(2), int64(3)).DoAndReturn(func(packets []*packet, _, _, _ int64) bool {
+			ecnHandler.EXPECT().HandleNewlyAcked(gomock.Any(), int64(1), int64(2), int64(3)).DoAndReturn(func(packets []*packet, _, _, _ int64) int64 {
 				Expect(packets).To(HaveLen(2))
 				Expect(packets[0].PacketNumber).To(Equal(protocol.PacketNumber(11)))
 				Expect(packets[1].PacketNumber).To(Equal(protocol.PacketNumber(12)))
-				return false
+				return 0
 			})
 			_, err := handler.ReceivedAck(&wire.AckFrame{
 				AckRanges: []wire.AckRange{{Largest: 12, Smallest: 11}},
@@ -1543,12 +1921,12 @@ var _ = Describe("SentPacketHandler", func() {
 		It("ignores ACKs that don't increase the largest acked", func() {
 			for i := 10; i < 20; i++ {
 				ecnHandler.EXPECT().SentPacket(protocol.PacketNumber(i), protocol.ECT1)
-				handler.SentPacket(time.Now(), protocol.PacketNumber(i), -1, []StreamFrame{{Frame: &streamFrame}}, nil, protocol.Encryption1RTT, protocol.ECT1, 1200, false)
+				handler.SentPacket(time.Now(), protocol.PacketNumber(i), -1, []StreamFrame{{Frame: &streamFrame}}, nil, protocol.Encryption1RTT, protocol.ECT1, 1200, false, false)
 			}
-			ecnHandler.EXPECT().HandleNewlyAcked(gomock.Any(), int64(1), int64(2), int64(3)).DoAndReturn(func(packets []*packet, _, _, _ int64) bool {
+			ecnHandler.EXPECT().HandleNewlyAcked(gomock.Any(), int64(1), int64(2), int64(3)).DoAndReturn(func(packets []*packet, _, _, _ int64) int64 {
 				Expect(packets).To(HaveLen(1))
 				Expect(packets[0].PacketNumber).To(Equal(protocol.PacketNumber(11)))
-				return false
+				return 0
 			})
 			_, err := handler.ReceivedAck(&wire.AckFrame{
 				AckRanges: []wire.AckRange{{Largest: 11, Smallest: 11}},
@@ -1568,12 +1946,38 @@ var _ = Describe("SentPacketHandler", func() {
 		It("informs the congestion controller about CE events", func() {
 			for i := 10; i < 20; i++ {
 				ecnHandler.EXPECT().SentPacket(protocol.PacketNumber(i), protocol.ECT0)
-				handler.SentPacket(time.Now(), protocol.PacketNumber(i), -1, []StreamFrame{{Frame: &streamFrame}}, nil, protocol.Encryption1RTT, protocol.ECT0, 1200, false)
+				handler.SentPacket(time.Now(), protocol.PacketNumber(i), -1, []StreamFrame{{Frame: &streamFrame}}, nil, protocol.Encryption1RTT, protocol.ECT0, 1200, false, false)
 			}
-			ecnHandler.EXPECT().HandleNewlyAcked(gomock.Any(), int64(0), int64(0), int64(0)).Return(true)
-			cong.EXPECT().OnCongestionEvent(protocol.PacketNumber(15), gomock.Any(), gomock.Any())
+			ecnHandler.EXPECT().HandleNewlyAcked(gomock.Any(), int64(0), int64(0), int64(0)).Return(int64(2))
+			gomock.InOrder(
+				cong.EXPECT().GetCongestionWindow().Return(protocol.ByteCount(10000)),
+				cong.EXPECT().OnECNCongestionEvent(protocol.PacketNumber(15), int64(2), protocol.ByteCount(12000)),
+				cong.EXPECT().GetCongestionWindow().Return(protocol.ByteCount(10000)),
+			)
+			_, err := handler.ReceivedAck(&wire.AckFrame{AckRanges: []wire.AckRange{{Largest: 15, Smallest: 10}}}, protocol.Encryption1RTT, time.Now())
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("traces congestion window reductions caused by CE marks", func() {
+			var ceMarked int64
+			var cwnd protocol.ByteCount
+			handler.tracer = &logging.ConnectionTracer{
+				ECNCongestionWindowReduced: func(m int64, c logging.ByteCount) { ceMarked, cwnd = m, c },
+			}
+			for i := 10; i < 20; i++ {
+				ecnHandler.EXPECT().SentPacket(protocol.PacketNumber(i), protocol.ECT0)
+				handler.SentPacket(time.Now(), protocol.PacketNumber(i), -1, []StreamFrame{{Frame: &streamFrame}}, nil, protocol.Encryption1RTT, protocol.ECT0, 1200, false, false)
+			}
+			ecnHandler.EXPECT().HandleNewlyAcked(gomock.Any(), int64(0), int64(0), int64(0)).Return(int64(1))
+			gomock.InOrder(
+				cong.EXPECT().GetCongestionWindow().Return(protocol.ByteCount(10000)),
+				cong.EXPECT().OnECNCongestionEvent(protocol.PacketNumber(15), int64(1), gomock.Any()),
+				cong.EXPECT().GetCongestionWindow().Return(protocol.ByteCount(7000)),
+			)
 			_, err := handler.ReceivedAck(&wire.AckFrame{AckRanges: []wire.AckRange{{Largest: 15, Smallest: 10}}}, protocol.Encryption1RTT, time.Now())
 			Expect(err).ToNot(HaveOccurred())
+			Expect(ceMarked).To(BeEquivalentTo(1))
+			Expect(cwnd).To(Equal(protocol.ByteCount(7000)))
 		})
 	})
 })
@@ -0,0 +1,1096 @@
+package congestion
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/internal/utils"
+	"github.com/quic-go/quic-go/logging"
+)
+
+// This is an implementation of BBR, as described in draft-cardwell-iccrg-bbr-congestion-control-02 (BBRv3).
+// quic-go reports every acknowledged packet separately, so every acknowledged packet is treated as an ACK by BBR.
+
+const (
+	bbrStartupPacingGain  = 2.77
+	bbrStartupCwndGain    = 2.0
+	bbrDrainPacingGain    = 0.35
+	bbrDefaultCwndGain    = 2.0
+	bbrProbeBWDownGain    = 0.9
+	bbrProbeBWUpGain      = 1.25
+	bbrProbeBWUpCwndGain  = 2.25
+	bbrProbeRTTCwndGain   = 0.5
+	bbrPacingMarginFactor = 0.99
+	// the maximum tolerated per-round loss rate when probing for bandwidth
+	bbrLossThresh = 0.02
+	// the multiplicative decrease applied to the model on loss
+	bbrBeta = 0.7
+	// the headroom left for other flows in ProbeBW_CRUISE
+	bbrHeadroom              = 0.15
+	bbrMinPipeCwndPackets    = 4
+	bbrFullBwThresh          = 1.25
+	bbrFullBwCount           = 3
+	bbrStartupFullLossCount  = 6
+	bbrMinRTTFilterLen       = 10 * time.Second
+	bbrProbeRTTInterval      = 5 * time.Second
+	bbrProbeRTTDuration      = 200 * time.Millisecond
+	bbrExtraAckedFilterRTTs  = 5 // the ACK aggregation filter uses two windows of this length
+	bbrMaxProbeUpRounds      = 30
+	bbrMaxRenoCoexistenceRTT = 63
+	bbrMaxSendQuantum        = 64 * 1024
+)
+
+type bbrState uint8
+
+const (
+	bbrStateStartup bbrState = iota
+	bbrStateDrain
+	bbrStateProbeBWDown
+	bbrStateProbeBWCruise
+	bbrStateProbeBWRefill
+	bbrStateProbeBWUp
+	bbrStateProbeRTT
+)
+
+func (s bbrState) congestionState() logging.CongestionState {
+	switch s {
+	case bbrStateStartup:
+		return logging.CongestionStateStartup
+	case bbrStateDrain:
+		return logging.CongestionStateDrain
+	case bbrStateProbeBWDown:
+		return logging.CongestionStateProbeBWDown
+	case bbrStateProbeBWCruise:
+		return logging.CongestionStateProbeBWCruise
+	case bbrStateProbeBWRefill:
+		return logging.CongestionStateProbeBWRefill
+	case bbrStateProbeBWUp:
+		return logging.CongestionStateProbeBWUp
+	case bbrStateProbeRTT:
+		return logging.CongestionStateProbeRTT
+	default:
+		panic(fmt.Sprintf("unknown BBR state: %d", s))
+	}
+}
+
+// The ackPhase tracks the state of the feedback for a bandwidth probe.
+type bbrAckPhase uint8
+
+const (
+	bbrAckPhaseInit bbrAckPhase = iota
+	bbrAckPhaseProbeStarting
+	bbrAckPhaseProbeFeedback
+	bbrAckPhaseProbeStopping
+	bbrAckPhaseRefilling
+)
+
+// bbrPacketKey identifies a sent packet.
+// Packet numbers are only unique within a packet number space.
+type bbrPacketKey struct {
+	pnSpace protocol.PacketNumberSpace
+	pn      protocol.PacketNumber
+}
+
+// bbrSentPacket is the state of the connection at the time a packet was sent.
+// It is used to generate a delivery rate sample when the packet is acknowledged.
+type bbrSentPacket struct {
+	pnSpace       protocol.PacketNumberSpace
+	size          protocol.ByteCount
+	sendTime      time.Time
+	delivered     protocol.ByteCount
+	deliveredTime time.Time
+	firstSentTime time.Time
+	lost          protocol.ByteCount
+	txInFlight    protocol.ByteCount
+	isAppLimited  bool
+}
+
+// A bbrRateSample is generated for every acknowledged (or lost) packet.
+type bbrRateSample struct {
+	deliveryRate   Bandwidth // 0 if the sample is invalid
+	isAppLimited   bool
+	delivered      protocol.ByteCount
+	priorDelivered protocol.ByteCount
+	txInFlight     protocol.ByteCount
+	lost           protocol.ByteCount
+	newlyAcked     protocol.ByteCount
+	rtt            time.Duration
+}
+
+type bbrSender struct {
+	clock    Clock
+	rttStats RTTStats
+	pacer    *pacer
+
+	initialMaxDatagramSize protocol.ByteCount
+	maxDatagramSize        protocol.ByteCount
+
+	// delivery rate estimation
+	sentPackets   map[bbrPacketKey]bbrSentPacket
+	bytesInFlight protocol.ByteCount
+	delivered     protocol.ByteCount
+	deliveredTime time.Time
+	firstSentTime time.Time
+	lost          protocol.ByteCount
+	appLimited    protocol.ByteCount // the value of delivered at which the application limited phase ends, 0 if not application limited
+
+	state      bbrState
+	pacingGain float64
+	cwndGain   float64
+	pacingRate Bandwidth
+	cwnd       protocol.ByteCount
+	priorCwnd  protocol.ByteCount
+
+	// round counting
+	nextRoundDelivered protocol.ByteCount
+	roundCount         uint64
+	roundStart         bool
+	idleRestart        bool
+
+	// the model
+	maxBwFilter        [2]Bandwidth
+	cycleCount         uint64
+	maxBw              Bandwidth
+	bwLo               Bandwidth
+	bw                 Bandwidth
+	bwLatest           Bandwidth
+	inflightHi         protocol.ByteCount
+	inflightLo         protocol.ByteCount
+	inflightLatest     protocol.ByteCount
+	minRTT             time.Duration
+	minRTTStamp        time.Time
+	probeRTTMinDelay   time.Duration
+	probeRTTMinStamp   time.Time
+	probeRTTExpired    bool
+	probeRTTDoneStamp  time.Time
+	probeRTTRoundDone  bool
+	extraAcked         [2]protocol.ByteCount
+	extraAckedWinIdx   int
+	extraAckedWinRTTs  uint64
+	extraAckedStart    time.Time
+	extraAckedBytes    protocol.ByteCount
+	lossRoundDelivered protocol.ByteCount
+	lossRoundStart     bool
+	lossInRound        bool
+	lossEventsInRound  int
+	// ECN
+	inECNRecovery        bool
+	ecnRecoveryDelivered protocol.ByteCount // packets sent after the last ECN response carry a larger delivered value
+
+	// Startup
+	fullBw        Bandwidth
+	fullBwCount   int
+	fullBwReached bool
+	fullBwNow     bool
+	isCwndLimited bool
+
+	// ProbeBW
+	ackPhase           bbrAckPhase
+	cycleStamp         time.Time
+	bwProbeWait        time.Duration
+	roundsSinceBwProbe uint64
+	bwProbeSamples     bool
+	bwProbeUpRounds    uint
+	bwProbeUpAcks      protocol.ByteCount
+	probeUpCount       protocol.ByteCount
+
+	tracer    *logging.ConnectionTracer
+	lastState logging.CongestionState
+}
+
+// NewBBRSender makes a new BBR sender
+func NewBBRSender(
+	clock Clock,
+	rttStats RTTStats,
+	initialMaxDatagramSize protocol.ByteCount,
+	tracer *logging.ConnectionTracer,
+) *bbrSender {
+	b := &bbrSender{
+		clock:                  clock,
+		rttStats:               rttStats,
+		initialMaxDatagramSize: initialMaxDatagramSize,
+	}
+	b.init()
+	b.SetTracer(tracer)
+	return b
+}
+
+// init initializes the state of the sender
+func (b *bbrSender) init() {
+	now := b.clock.Now()
+	b.maxDatagramSize = b.initialMaxDatagramSize
+	b.pacer = newRatePacer(b.pacingRateBytesPerSecond)
+	b.pacer.SetMaxDatagramSize(b.maxDatagramSize)
+	b.sentPackets = make(map[bbrPacketKey]bbrSentPacket)
+	b.bytesInFlight = 0
+	b.delivered = 0
+	b.deliveredTime = time.Time{}
+	b.firstSentTime = time.Time{}
+	b.lost = 0
+	b.appLimited = 0
+
+	b.cwnd = b.initialCwnd()
+	b.priorCwnd = 0
+	b.nextRoundDelivered = 0
+	b.roundCount = 0
+	b.roundStart = false
+	b.idleRestart = false
+
+	b.maxBwFilter = [2]Bandwidth{}
+	b.cycleCount = 0
+	b.maxBw = 0
+	b.bw = 0
+	b.bwLatest = 0
+	b.inflightLatest = 0
+	b.inflightHi = protocol.MaxByteCount
+	b.resetLowerBounds()
+	b.minRTT = utils.InfDuration
+	b.minRTTStamp = now
+	b.probeRTTMinDelay = utils.InfDuration
+	b.probeRTTMinStamp = now
+	b.probeRTTExpired = false
+	b.probeRTTDoneStamp = time.Time{}
+	b.probeRTTRoundDone = false
+	b.extraAcked = [2]protocol.ByteCount{}
+	b.extraAckedWinIdx = 0
+	b.extraAckedWinRTTs = 0
+	b.extraAckedStart = now
+	b.extraAckedBytes = 0
+	b.lossRoundDelivered = 0
+	b.lossRoundStart = false
+	b.lossInRound = false
+	b.lossEventsInRound = 0
+	b.inECNRecovery = false
+	b.ecnRecoveryDelivered = 0
+
+	b.fullBw = 0
+	b.fullBwCount = 0
+	b.fullBwReached = false
+	b.fullBwNow = false
+
+	b.ackPhase = bbrAckPhaseInit
+	b.cycleStamp = time.Time{}
+	b.bwProbeWait = 0
+	b.roundsSinceBwProbe = 0
+	b.bwProbeSamples = false
+	b.bwProbeUpRounds = 0
+	b.bwProbeUpAcks = 0
+	b.probeUpCount = protocol.MaxByteCount
+
+	b.initPacingRate()
+	b.enterStartup()
+}
+
+// SetTracer sets the tracer that congestion state changes are reported to.
+func (b *bbrSender) SetTracer(tracer *logging.ConnectionTracer) {
+	b.tracer = tracer
+	if b.tracer != nil && b.tracer.UpdatedCongestionState != nil {
+		b.lastState = b.state.congestionState()
+		b.tracer.UpdatedCongestionState(b.lastState)
+	}
+}
+
+func (b *bbrSender) initialCwnd() protocol.ByteCount {
+	return initialCongestionWindow * b.maxDatagramSize
+}
+
+func (b *bbrSender) minPipeCwnd() protocol.ByteCount {
+	return bbrMinPipeCwndPackets * b.maxDatagramSize
+}
+
+func (b *bbrSender) maxCongestionWindow() protocol.ByteCount {
+	return protocol.MaxCongestionWindowPackets * b.maxDatagramSize
+}
+
+// TimeUntilSend returns when the next packet should be sent.
+func (b *bbrSender) TimeUntilSend(_ protocol.ByteCount) time.Time {
+	return b.pacer.TimeUntilSend()
+}
+
+func (b *bbrSender) HasPacingBudget(now time.Time) bool {
+	return b.pacer.Budget(now) >= b.maxDatagramSize
+}
+
+func (b *bbrSender) CanSend(bytesInFlight protocol.ByteCount) bool {
+	return bytesInFlight < b.cwnd
+}
+
+func (b *bbrSender) GetCongestionWindow() protocol.ByteCount {
+	return b.cwnd
+}
+
+// MaybeExitSlowStart is a no-op, BBR exits startup based on its bandwidth estimate.
+func (b *bbrSender) MaybeExitSlowStart() {}
+
+func (b *bbrSender) OnPacketSent(
+	sentTime time.Time,
+	bytesInFlight protocol.ByteCount,
+	pnSpace protocol.PacketNumberSpace,
+	packetNumber protocol.PacketNumber,
+	bytes protocol.ByteCount,
+	isRetransmittable bool,
+) {
+	b.pacer.SentPacket(sentTime, bytes)
+	if !isRetransmittable {
+		return
+	}
+	if bytesInFlight == 0 {
+		b.firstSentTime = sentTime
+		b.deliveredTime = sentTime
+		// The sender was idle. We don't know when the application has data to send,
+		// so we consider the connection application limited until this packet is acknowledged.
+		if b.delivered > 0 {
+			b.appLimited = utils.Max(b.delivered+bytes, 1)
+			b.handleRestartFromIdle(sentTime)
+		}
+	}
+	b.bytesInFlight = bytesInFlight + bytes
+	b.sentPackets[bbrPacketKey{pnSpace: pnSpace, pn: packetNumber}] = bbrSentPacket{
+		pnSpace:       pnSpace,
+		size:          bytes,
+		sendTime:      sentTime,
+		delivered:     b.delivered,
+		deliveredTime: b.deliveredTime,
+		firstSentTime: b.firstSentTime,
+		lost:          b.lost,
+		txInFlight:    b.bytesInFlight,
+		isAppLimited:  b.appLimited != 0,
+	}
+}
+
+func (b *bbrSender) handleRestartFromIdle(now time.Time) {
+	b.idleRestart = true
+	b.extraAckedStart = now
+	if b.isInProbeBWState() {
+		b.setPacingRateWithGain(1)
+	} else if b.state == bbrStateProbeRTT {
+		b.checkProbeRTTDone(now)
+	}
+}
+
+func (b *bbrSender) OnPacketAcked(
+	pnSpace protocol.PacketNumberSpace,
+	number protocol.PacketNumber,
+	ackedBytes protocol.ByteCount,
+	priorInFlight protocol.ByteCount,
+	eventTime time.Time,
+) {
+	if b.bytesInFlight >= ackedBytes {
+		b.bytesInFlight -= ackedBytes
+	} else {
+		b.bytesInFlight = 0
+	}
+	b.isCwndLimited = priorInFlight+b.maxDatagramSize >= b.cwnd
+	b.delivered += ackedBytes
+	b.deliveredTime = eventTime
+	if b.appLimited != 0 && b.delivered > b.appLimited {
+		b.appLimited = 0
+	}
+	key := bbrPacketKey{pnSpace: pnSpace, pn: number}
+	p, ok := b.sentPackets[key]
+	if !ok {
+		return
+	}
+	delete(b.sentPackets, key)
+	// a packet sent after the last ECN response was acknowledged
+	if b.inECNRecovery && p.delivered > b.ecnRecoveryDelivered {
+		b.inECNRecovery = false
+	}
+
+	rs := bbrRateSample{
+		priorDelivered: p.delivered,
+		isAppLimited:   p.isAppLimited,
+		txInFlight:     p.txInFlight,
+		lost:           b.lost - p.lost,
+		delivered:      b.delivered - p.delivered,
+		newlyAcked:     ackedBytes,
+		rtt:            eventTime.Sub(p.sendTime),
+	}
+	b.firstSentTime = p.sendTime
+	// Use the longer of the send and the ACK interval, to avoid overestimating the bandwidth due to ACK compression.
+	interval := utils.Max(p.sendTime.Sub(p.firstSentTime), b.deliveredTime.Sub(p.deliveredTime))
+	// An interval smaller than the minimum RTT would also overestimate the bandwidth.
+	if interval > 0 && (b.minRTT == utils.InfDuration || interval >= b.minRTT) {
+		rs.deliveryRate = BandwidthFromDelta(rs.delivered, interval)
+	}
+
+	b.updateModelAndState(&rs, eventTime)
+	b.updateControlParameters(&rs)
+}
+
+func (b *bbrSender) OnCongestionEvent(pnSpace protocol.PacketNumberSpace, number protocol.PacketNumber, lostBytes, _ protocol.ByteCount) {
+	if b.bytesInFlight >= lostBytes {
+		b.bytesInFlight -= lostBytes
+	} else {
+		b.bytesInFlight = 0
+	}
+	b.lost += lostBytes
+	b.lossInRound = true
+	b.lossEventsInRound++
+	key := bbrPacketKey{pnSpace: pnSpace, pn: number}
+	p, ok := b.sentPackets[key]
+	if !ok {
+		return
+	}
+	delete(b.sentPackets, key)
+	if !b.bwProbeSamples {
+		return
+	}
+	rs := bbrRateSample{
+		txInFlight:   p.txInFlight,
+		lost:         b.lost - p.lost,
+		isAppLimited: p.isAppLimited,
+	}
+	if b.isInflightTooHigh(&rs) {
+		rs.txInFlight = b.inflightHiFromLostPacket(&rs, p.size)
+		b.handleInflightTooHigh(&rs, b.clock.Now())
+	}
+}
+
+// DropPacketNumberSpace removes the state kept for the packets that were dropped.
+func (b *bbrSender) DropPacketNumberSpace(pnSpace protocol.PacketNumberSpace) {
+	for key, p := range b.sentPackets {
+		if p.pnSpace == pnSpace {
+			delete(b.sentPackets, key)
+		}
+	}
+}
+
+// OnECNCongestionEvent treats CE marks like a packet loss, see section 7.1 of RFC 9002.
+// The congestion window is reduced, and the short-term model is reduced at the end of the round.
+// If BBR is probing for bandwidth, the probe is ended.
+// BBR responds at most once per round trip: CE marks are ignored until a packet sent after the last response is acknowledged.
+func (b *bbrSender) OnECNCongestionEvent(protocol.PacketNumber, int64, protocol.ByteCount) {
+	if b.inECNRecovery {
+		return
+	}
+	b.inECNRecovery = true
+	b.ecnRecoveryDelivered = b.delivered
+	b.lossInRound = true
+	switch {
+	case b.state == bbrStateStartup && !b.fullBwReached:
+		// the bottleneck queue is building up, there's no need to keep probing for bandwidth
+		b.fullBwReached = true
+		b.inflightHi = utils.Max(b.bdp(b.maxBw), b.inflightLatest)
+	case b.bwProbeSamples:
+		b.handleInflightTooHigh(&bbrRateSample{txInFlight: b.bytesInFlight}, b.clock.Now())
+	}
+	b.cwnd = utils.Max(protocol.ByteCount(bbrBeta*float64(b.cwnd)), b.minPipeCwnd())
+}
+
+// OnPersistentCongestion collapses the congestion window.
+// Unlike on a retransmission timeout, the window isn't restored when leaving ProbeRTT.
+func (b *bbrSender) OnPersistentCongestion() {
+	b.priorCwnd = 0
+	b.cwnd = b.minPipeCwnd()
+}
+
+// OnRetransmissionTimeout is called on an retransmission timeout
+func (b *bbrSender) OnRetransmissionTimeout(packetsRetransmitted bool) {
+	if !packetsRetransmitted {
+		return
+	}
+	b.saveCwnd()
+	b.cwnd = b.minPipeCwnd()
+}
+
+func (b *bbrSender) SetMaxDatagramSize(s protocol.ByteCount) {
+	if s < b.maxDatagramSize {
+		panic(fmt.Sprintf("congestion BUG: decreased max datagram size from %d to %d", b.maxDatagramSize, s))
+	}
+	b.maxDatagramSize = s
+	b.cwnd = utils.Max(b.cwnd, b.minPipeCwnd())
+	b.pacer.SetMaxDatagramSize(s)
+}
+
+func (b *bbrSender) setState(s bbrState) {
+	b.state = s
+	if b.tracer == nil || b.tracer.UpdatedCongestionState == nil {
+		return
+	}
+	if new := s.congestionState(); new != b.lastState {
+		b.tracer.UpdatedCongestionState(new)
+		b.lastState = new
+	}
+}
+
+func (b *bbrSender) enterStartup() {
+	b.setState(bbrStateStartup)
+	b.pacingGain = bbrStartupPacingGain
+	b.cwndGain = bbrStartupCwndGain
+}
+
+func (b *bbrSender) updateModelAndState(rs *bbrRateSample, now time.Time) {
+	b.updateRound(rs)
+	b.updateLatestDeliverySignals(rs)
+	b.updateCongestionSignals(rs)
+	b.updateACKAggregation(rs, now)
+	b.checkStartupDone(rs)
+	b.checkDrain(now)
+	b.updateProbeBWCyclePhase(rs, now)
+	b.updateMinRTT(rs, now)
+	b.checkProbeRTT(rs, now)
+	b.advanceLatestDeliverySignals(rs)
+	b.boundBWForModel()
+}
+
+func (b *bbrSender) updateControlParameters(rs *bbrRateSample) {
+	b.setPacingRateWithGain(b.pacingGain)
+	b.setCwnd(rs)
+}
+
+func (b *bbrSender) updateRound(rs *bbrRateSample) {
+	b.roundStart = false
+	if rs.priorDelivered >= b.nextRoundDelivered {
+		b.startRound()
+		b.roundCount++
+		b.roundsSinceBwProbe++
+		b.roundStart = true
+	}
+}
+
+func (b *bbrSender) startRound() {
+	b.nextRoundDelivered = b.delivered
+}
+
+func (b *bbrSender) updateLatestDeliverySignals(rs *bbrRateSample) {
+	b.lossRoundStart = false
+	b.bwLatest = utils.Max(b.bwLatest, rs.deliveryRate)
+	b.inflightLatest = utils.Max(b.inflightLatest, rs.delivered)
+	if rs.priorDelivered >= b.lossRoundDelivered {
+		b.lossRoundDelivered = b.delivered
+		b.lossRoundStart = true
+	}
+}
+
+func (b *bbrSender) advanceLatestDeliverySignals(rs *bbrRateSample) {
+	if b.lossRoundStart {
+		b.bwLatest = rs.deliveryRate
+		b.inflightLatest = rs.delivered
+	}
+}
+
+func (b *bbrSender) updateCongestionSignals(rs *bbrRateSample) {
+	b.updateMaxBw(rs)
+	if !b.lossRoundStart {
+		return
+	}
+	b.checkStartupHighLoss(rs)
+	b.adaptLowerBoundsFromCongestion()
+	b.lossInRound = false
+	b.lossEventsInRound = 0
+}
+
+func (b *bbrSender) updateMaxBw(rs *bbrRateSample) {
+	if rs.deliveryRate == 0 {
+		return
+	}
+	if rs.deliveryRate >= b.maxBw || !rs.isAppLimited {
+		idx := b.cycleCount % 2
+		b.maxBwFilter[idx] = utils.Max(b.maxBwFilter[idx], rs.deliveryRate)
+		b.maxBw = utils.Max(b.maxBwFilter[0], b.maxBwFilter[1])
+	}
+}
+
+func (b *bbrSender) advanceMaxBwFilter() {
+	b.cycleCount++
+	b.maxBwFilter[b.cycleCount%2] = 0
+	b.maxBw = utils.Max(b.maxBwFilter[0], b.maxBwFilter[1])
+}
+
+func (b *bbrSender) boundBWForModel() {
+	b.bw = utils.Min(b.maxBw, b.bwLo)
+}
+
+// adaptLowerBoundsFromCongestion reduces the short-term model after a round with losses
+func (b *bbrSender) adaptLowerBoundsFromCongestion() {
+	if b.isProbingBW() {
+		return
+	}
+	if !b.lossInRound {
+		return
+	}
+	if b.bwLo == infBandwidth {
+		b.bwLo = b.maxBw
+	}
+	if b.inflightLo == protocol.MaxByteCount {
+		b.inflightLo = b.cwnd
+	}
+	b.bwLo = utils.Max(b.bwLatest, Bandwidth(bbrBeta*float64(b.bwLo)))
+	b.inflightLo = utils.Max(b.inflightLatest, protocol.ByteCount(bbrBeta*float64(b.inflightLo)))
+}
+
+func (b *bbrSender) resetLowerBounds() {
+	b.bwLo = infBandwidth
+	b.inflightLo = protocol.MaxByteCount
+}
+
+func (b *bbrSender) resetCongestionSignals() {
+	b.lossInRound = false
+	b.bwLatest = 0
+	b.inflightLatest = 0
+}
+
+func (b *bbrSender) isProbingBW() bool {
+	return b.state == bbrStateStartup || b.state == bbrStateProbeBWRefill || b.state == bbrStateProbeBWUp
+}
+
+// updateACKAggregation estimates the number of bytes acknowledged in excess of the bandwidth estimate
+func (b *bbrSender) updateACKAggregation(rs *bbrRateSample, now time.Time) {
+	if b.roundStart {
+		b.extraAckedWinRTTs++
+		if b.extraAckedWinRTTs >= bbrExtraAckedFilterRTTs {
+			b.extraAckedWinRTTs = 0
+			b.extraAckedWinIdx = 1 - b.extraAckedWinIdx
+			b.extraAcked[b.extraAckedWinIdx] = 0
+		}
+	}
+	expected := b.bdpForInterval(now.Sub(b.extraAckedStart))
+	if b.extraAckedBytes <= expected {
+		b.extraAckedBytes = 0
+		b.extraAckedStart = now
+		expected = 0
+	}
+	b.extraAckedBytes += rs.newlyAcked
+	extra := utils.Min(b.extraAckedBytes-expected, b.cwnd)
+	b.extraAcked[b.extraAckedWinIdx] = utils.Max(b.extraAcked[b.extraAckedWinIdx], extra)
+}
+
+func (b *bbrSender) extraAckedEstimate() protocol.ByteCount {
+	return utils.Max(b.extraAcked[0], b.extraAcked[1])
+}
+
+func (b *bbrSender) checkStartupDone(rs *bbrRateSample) {
+	b.checkFullBWReached(rs)
+	if b.state == bbrStateStartup && b.fullBwReached {
+		b.setState(bbrStateDrain)
+		b.pacingGain = bbrDrainPacingGain
+		b.cwndGain = bbrStartupCwndGain
+	}
+}
+
+func (b *bbrSender) checkFullBWReached(rs *bbrRateSample) {
+	if b.fullBwNow || !b.roundStart || rs.isAppLimited {
+		return
+	}
+	if float64(b.maxBw) >= float64(b.fullBw)*bbrFullBwThresh {
+		b.fullBw = b.maxBw
+		b.fullBwCount = 0
+		return
+	}
+	b.fullBwCount++
+	b.fullBwNow = b.fullBwCount >= bbrFullBwCount
+	if b.fullBwNow {
+		b.fullBwReached = true
+	}
+}
+
+// checkStartupHighLoss exits startup if the loss rate in the last round was too high.
+// It is called at the start of every loss round.
+func (b *bbrSender) checkStartupHighLoss(rs *bbrRateSample) {
+	if b.fullBwReached || b.state != bbrStateStartup {
+		return
+	}
+	if b.lossEventsInRound >= bbrStartupFullLossCount && b.isInflightTooHigh(rs) {
+		b.fullBwReached = true
+		b.inflightHi = utils.Max(b.bdp(b.maxBw), b.inflightLatest)
+	}
+}
+
+func (b *bbrSender) checkDrain(now time.Time) {
+	if b.state == bbrStateDrain && b.bytesInFlight <= b.inflight(b.maxBw, 1) {
+		b.enterProbeBW(now)
+	}
+}
+
+func (b *bbrSender) enterProbeBW(now time.Time) {
+	b.cwndGain = bbrDefaultCwndGain
+	b.startProbeBWDown(now)
+}
+
+func (b *bbrSender) isInProbeBWState() bool {
+	switch b.state {
+	case bbrStateProbeBWDown, bbrStateProbeBWCruise, bbrStateProbeBWRefill, bbrStateProbeBWUp:
+		return true
+	default:
+		return false
+	}
+}
+
+func (b *bbrSender) startProbeBWDown(now time.Time) {
+	b.resetCongestionSignals()
+	b.probeUpCount = protocol.MaxByteCount
+	b.pickProbeWait()
+	b.cycleStamp = now
+	b.ackPhase = bbrAckPhaseProbeStopping
+	b.startRound()
+	b.setState(bbrStateProbeBWDown)
+	b.pacingGain = bbrProbeBWDownGain
+	b.cwndGain = bbrDefaultCwndGain
+}
+
+func (b *bbrSender) startProbeBWCruise() {
+	b.setState(bbrStateProbeBWCruise)
+	b.pacingGain = 1
+	b.cwndGain = bbrDefaultCwndGain
+}
+
+func (b *bbrSender) startProbeBWRefill() {
+	b.resetLowerBounds()
+	b.bwProbeUpRounds = 0
+	b.bwProbeUpAcks = 0
+	b.ackPhase = bbrAckPhaseRefilling
+	b.startRound()
+	b.setState(bbrStateProbeBWRefill)
+	b.pacingGain = 1
+	b.cwndGain = bbrDefaultCwndGain
+}
+
+func (b *bbrSender) startProbeBWUp(now time.Time) {
+	b.ackPhase = bbrAckPhaseProbeStarting
+	b.startRound()
+	b.fullBw = 0
+	b.fullBwCount = 0
+	b.fullBwNow = false
+	b.cycleStamp = now
+	b.setState(bbrStateProbeBWUp)
+	b.pacingGain = bbrProbeBWUpGain
+	b.cwndGain = bbrProbeBWUpCwndGain
+	b.raiseInflightHiSlope()
+}
+
+// pickProbeWait randomizes the time until the next bandwidth probe,
+// to desynchronize flows sharing a bottleneck.
+func (b *bbrSender) pickProbeWait() {
+	b.roundsSinceBwProbe = uint64(rand.Int63n(2))
+	b.bwProbeWait = 2*time.Second + time.Duration(rand.Int63n(int64(time.Second)))
+}
+
+func (b *bbrSender) updateProbeBWCyclePhase(rs *bbrRateSample, now time.Time) {
+	if !b.fullBwReached {
+		return
+	}
+	b.adaptUpperBounds(rs, now)
+	if !b.isInProbeBWState() {
+		return
+	}
+	switch b.state {
+	case bbrStateProbeBWDown:
+		if b.checkTimeToProbeBW(now) {
+			return
+		}
+		if b.checkTimeToCruise() {
+			b.startProbeBWCruise()
+		}
+	case bbrStateProbeBWCruise:
+		b.checkTimeToProbeBW(now)
+	case bbrStateProbeBWRefill:
+		// After one round of REFILL, start UP.
+		if b.roundStart {
+			b.bwProbeSamples = true
+			b.startProbeBWUp(now)
+		}
+	case bbrStateProbeBWUp:
+		if b.hasElapsedInPhase(now, b.minRTT) && b.bytesInFlight > b.inflight(b.maxBw, bbrProbeBWUpGain) {
+			b.startProbeBWDown(now)
+		}
+	}
+}
+
+func (b *bbrSender) hasElapsedInPhase(now time.Time, interval time.Duration) bool {
+	if interval == utils.InfDuration {
+		return false
+	}
+	return now.After(b.cycleStamp.Add(interval))
+}
+
+func (b *bbrSender) checkTimeToProbeBW(now time.Time) bool {
+	if b.hasElapsedInPhase(now, b.bwProbeWait) || b.isRenoCoexistenceProbeTime() {
+		b.startProbeBWRefill()
+		return true
+	}
+	return false
+}
+
+// isRenoCoexistenceProbeTime makes sure that BBR probes for bandwidth at least as often as Reno would.
+func (b *bbrSender) isRenoCoexistenceProbeTime() bool {
+	renoRounds := uint64(b.targetInflight() / b.maxDatagramSize)
+	rounds := utils.Min(renoRounds, bbrMaxRenoCoexistenceRTT)
+	return b.roundsSinceBwProbe >= rounds
+}
+
+func (b *bbrSender) checkTimeToCruise() bool {
+	if b.bytesInFlight > b.inflightWithHeadroom() {
+		return false // not enough headroom
+	}
+	return b.bytesInFlight <= b.inflight(b.maxBw, 1)
+}
+
+func (b *bbrSender) adaptUpperBounds(rs *bbrRateSample, now time.Time) {
+	if b.ackPhase == bbrAckPhaseProbeStarting && b.roundStart {
+		// starting to get bandwidth probing samples
+		b.ackPhase = bbrAckPhaseProbeFeedback
+	}
+	if b.ackPhase == bbrAckPhaseProbeStopping && b.roundStart {
+		// end of samples from bandwidth probing phase
+		b.bwProbeSamples = false
+		b.ackPhase = bbrAckPhaseInit
+		if b.isInProbeBWState() && !rs.isAppLimited {
+			b.advanceMaxBwFilter()
+		}
+	}
+	if b.checkInflightTooHigh(rs, now) {
+		return
+	}
+	if b.inflightHi == protocol.MaxByteCount {
+		return
+	}
+	if rs.txInFlight > b.inflightHi {
+		b.inflightHi = rs.txInFlight
+	}
+	if b.state == bbrStateProbeBWUp {
+		b.probeInflightHiUpward(rs)
+	}
+}
+
+func (b *bbrSender) checkInflightTooHigh(rs *bbrRateSample, now time.Time) bool {
+	if !b.isInflightTooHigh(rs) {
+		return false
+	}
+	if b.bwProbeSamples {
+		b.handleInflightTooHigh(rs, now)
+	}
+	return true
+}
+
+func (b *bbrSender) isInflightTooHigh(rs *bbrRateSample) bool {
+	return float64(rs.lost) > float64(rs.txInFlight)*bbrLossThresh
+}
+
+func (b *bbrSender) handleInflightTooHigh(rs *bbrRateSample, now time.Time) {
+	b.bwProbeSamples = false
+	if !rs.isAppLimited {
+		b.inflightHi = utils.Max(rs.txInFlight, protocol.ByteCount(float64(b.targetInflight())*bbrBeta))
+	}
+	if b.state == bbrStateProbeBWUp {
+		b.startProbeBWDown(now)
+	}
+}
+
+// inflightHiFromLostPacket estimates the number of bytes in flight at which the loss rate crossed bbrLossThresh.
+func (b *bbrSender) inflightHiFromLostPacket(rs *bbrRateSample, size protocol.ByteCount) protocol.ByteCount {
+	if rs.txInFlight < size || rs.lost < size {
+		return rs.txInFlight
+	}
+	inflightPrev := float64(rs.txInFlight - size)
+	lostPrev := float64(rs.lost - size)
+	lostPrefix := (bbrLossThresh*inflightPrev - lostPrev) / (1 - bbrLossThresh)
+	if lostPrefix < 0 {
+		return protocol.ByteCount(inflightPrev)
+	}
+	return protocol.ByteCount(inflightPrev + lostPrefix)
+}
+
+func (b *bbrSender) probeInflightHiUpward(rs *bbrRateSample) {
+	if !b.isCwndLimited || b.cwnd < b.inflightHi {
+		return // not fully using inflight_hi, so don't grow it
+	}
+	b.bwProbeUpAcks += rs.newlyAcked
+	if b.bwProbeUpAcks >= b.probeUpCount {
+		delta := b.bwProbeUpAcks / b.probeUpCount
+		b.bwProbeUpAcks -= delta * b.probeUpCount
+		b.inflightHi += delta * b.maxDatagramSize
+	}
+	if b.roundStart {
+		b.raiseInflightHiSlope()
+	}
+}
+
+// raiseInflightHiSlope doubles the growth of inflight_hi every round
+func (b *bbrSender) raiseInflightHiSlope() {
+	growthThisRound := b.maxDatagramSize << b.bwProbeUpRounds
+	b.bwProbeUpRounds = utils.Min(b.bwProbeUpRounds+1, bbrMaxProbeUpRounds)
+	b.probeUpCount = utils.Max(b.cwnd/growthThisRound, 1) * b.maxDatagramSize
+}
+
+func (b *bbrSender) updateMinRTT(rs *bbrRateSample, now time.Time) {
+	b.probeRTTExpired = now.After(b.probeRTTMinStamp.Add(bbrProbeRTTInterval))
+	if rs.rtt > 0 && (rs.rtt < b.probeRTTMinDelay || b.probeRTTExpired) {
+		b.probeRTTMinDelay = rs.rtt
+		b.probeRTTMinStamp = now
+	}
+	minRTTExpired := now.After(b.minRTTStamp.Add(bbrMinRTTFilterLen))
+	if b.probeRTTMinDelay < b.minRTT || minRTTExpired {
+		b.minRTT = b.probeRTTMinDelay
+		b.minRTTStamp = b.probeRTTMinStamp
+	}
+}
+
+func (b *bbrSender) checkProbeRTT(rs *bbrRateSample, now time.Time) {
+	if b.state != bbrStateProbeRTT && b.probeRTTExpired && !b.idleRestart {
+		b.saveCwnd()
+		b.probeRTTDoneStamp = time.Time{}
+		b.ackPhase = bbrAckPhaseProbeStopping
+		b.startRound()
+		b.setState(bbrStateProbeRTT)
+		b.pacingGain = 1
+		b.cwndGain = bbrProbeRTTCwndGain
+	}
+	if b.state == bbrStateProbeRTT {
+		b.handleProbeRTT(now)
+	}
+	if rs.delivered > 0 {
+		b.idleRestart = false
+	}
+}
+
+func (b *bbrSender) handleProbeRTT(now time.Time) {
+	if b.probeRTTDoneStamp.IsZero() && b.bytesInFlight <= b.probeRTTCwnd() {
+		// wait for at least ProbeRTTDuration and one round to elapse
+		b.probeRTTDoneStamp = now.Add(bbrProbeRTTDuration)
+		b.probeRTTRoundDone = false
+		b.startRound()
+	} else if !b.probeRTTDoneStamp.IsZero() {
+		if b.roundStart {
+			b.probeRTTRoundDone = true
+		}
+		if b.probeRTTRoundDone {
+			b.checkProbeRTTDone(now)
+		}
+	}
+}
+
+func (b *bbrSender) checkProbeRTTDone(now time.Time) {
+	if b.probeRTTDoneStamp.IsZero() || !now.After(b.probeRTTDoneStamp) {
+		return
+	}
+	// schedule the next ProbeRTT
+	b.probeRTTMinStamp = now
+	b.restoreCwnd()
+	b.resetLowerBounds()
+	if b.fullBwReached {
+		b.startProbeBWDown(now)
+		b.startProbeBWCruise()
+	} else {
+		b.enterStartup()
+	}
+}
+
+func (b *bbrSender) saveCwnd() {
+	if b.state != bbrStateProbeRTT {
+		b.priorCwnd = b.cwnd
+	} else {
+		b.priorCwnd = utils.Max(b.priorCwnd, b.cwnd)
+	}
+}
+
+func (b *bbrSender) restoreCwnd() {
+	b.cwnd = utils.Max(b.cwnd, b.priorCwnd)
+}
+
+func (b *bbrSender) probeRTTCwnd() protocol.ByteCount {
+	return utils.Max(b.bdpMultiple(b.bw, bbrProbeRTTCwndGain), b.minPipeCwnd())
+}
+
+// bdp calculates the bandwidth-delay product, using the minimum RTT
+func (b *bbrSender) bdp(bw Bandwidth) protocol.ByteCount {
+	return b.bdpMultiple(bw, 1)
+}
+
+func (b *bbrSender) bdpMultiple(bw Bandwidth, gain float64) protocol.ByteCount {
+	if b.minRTT == utils.InfDuration {
+		return b.initialCwnd() // no valid RTT samples yet
+	}
+	return protocol.ByteCount(gain * float64(bw) / float64(BytesPerSecond) * b.minRTT.Seconds())
+}
+
+func (b *bbrSender) bdpForInterval(interval time.Duration) protocol.ByteCount {
+	return protocol.ByteCount(float64(b.bw) / float64(BytesPerSecond) * interval.Seconds())
+}
+
+func (b *bbrSender) targetInflight() protocol.ByteCount {
+	return utils.Min(b.bdp(b.bw), b.cwnd)
+}
+
+// inflight calculates the number of bytes in flight needed to fully use the estimated bandwidth.
+func (b *bbrSender) inflight(bw Bandwidth, gain float64) protocol.ByteCount {
+	return b.quantizationBudget(b.bdpMultiple(bw, gain))
+}
+
+func (b *bbrSender) inflightWithHeadroom() protocol.ByteCount {
+	if b.inflightHi == protocol.MaxByteCount {
+		return protocol.MaxByteCount
+	}
+	headroom := utils.Max(b.maxDatagramSize, protocol.ByteCount(bbrHeadroom*float64(b.inflightHi)))
+	if b.inflightHi < headroom {
+		return b.minPipeCwnd()
+	}
+	return utils.Max(b.inflightHi-headroom, b.minPipeCwnd())
+}
+
+func (b *bbrSender) sendQuantum() protocol.ByteCount {
+	quantum := protocol.ByteCount(b.pacingRate / BytesPerSecond / 1000) // 1ms worth of data
+	return utils.Max(utils.Min(quantum, bbrMaxSendQuantum), 2*b.maxDatagramSize)
+}
+
+// quantizationBudget accounts for the offload budget, and makes sure that enough data is in flight in ProbeBW_UP
+func (b *bbrSender) quantizationBudget(inflight protocol.ByteCount) protocol.ByteCount {
+	inflight = utils.Max(inflight, 3*b.sendQuantum())
+	inflight = utils.Max(inflight, b.minPipeCwnd())
+	if b.state == bbrStateProbeBWUp {
+		inflight += 2 * b.maxDatagramSize
+	}
+	return inflight
+}
+
+func (b *bbrSender) initPacingRate() {
+	srtt := b.rttStats.SmoothedRTT()
+	if srtt == 0 {
+		srtt = time.Millisecond
+	}
+	nominalBandwidth := BandwidthFromDelta(b.initialCwnd(), srtt)
+	b.pacingRate = Bandwidth(bbrStartupPacingGain * float64(nominalBandwidth))
+}
+
+func (b *bbrSender) setPacingRateWithGain(gain float64) {
+	if b.bw == 0 {
+		return
+	}
+	rate := Bandwidth(gain * float64(b.bw) * bbrPacingMarginFactor)
+	if b.fullBwReached || rate > b.pacingRate {
+		b.pacingRate = rate
+	}
+}
+
+func (b *bbrSender) pacingRateBytesPerSecond() uint64 {
+	return utils.Max(uint64(b.pacingRate/BytesPerSecond), 1)
+}
+
+func (b *bbrSender) setCwnd(rs *bbrRateSample) {
+	maxInflight := b.quantizationBudget(b.bdpMultiple(b.bw, b.cwndGain) + b.extraAckedEstimate())
+	if b.fullBwReached {
+		b.cwnd = utils.Min(b.cwnd+rs.newlyAcked, maxInflight)
+	} else if b.cwnd < maxInflight || b.delivered < b.initialCwnd() {
+		b.cwnd += rs.newlyAcked
+	}
+	b.cwnd = utils.Max(b.cwnd, b.minPipeCwnd())
+	if b.state == bbrStateProbeRTT {
+		b.cwnd = utils.Min(b.cwnd, b.probeRTTCwnd())
+	}
+	b.boundCwndForModel()
+	b.cwnd = utils.Min(b.cwnd, b.maxCongestionWindow())
+}
+
+func (b *bbrSender) boundCwndForModel() {
+	limit := protocol.MaxByteCount
+	if b.isInProbeBWState() && b.state != bbrStateProbeBWCruise {
+		limit = b.inflightHi
+	} else if b.state == bbrStateProbeRTT || b.state == bbrStateProbeBWCruise {
+		limit = b.inflightWithHeadroom()
+	}
+	limit = utils.Min(limit, b.inflightLo)
+	limit = utils.Max(limit, b.minPipeCwnd())
+	b.cwnd = utils.Min(b.cwnd, limit)
+}
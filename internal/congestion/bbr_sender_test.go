@@ -0,0 +1,255 @@
+package congestion
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/internal/utils"
+	"github.com/quic-go/quic-go/logging"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// A bottleneckLink simulates a path with a bottleneck link.
+// Packets are queued at the bottleneck and served at a constant rate.
+// There's no limit on the size of the queue.
+type bottleneckLink struct {
+	bandwidth Bandwidth
+	rtt       time.Duration // the RTT, without the queueing and serialization delay
+	lossRate  float64
+	rand      *rand.Rand
+
+	lastDeparture time.Time
+	inFlight      []linkPacket
+}
+
+type linkPacket struct {
+	pn      protocol.PacketNumber
+	size    protocol.ByteCount
+	sent    time.Time
+	arrival time.Time // when the ACK (or the loss notification) arrives at the sender
+	lost    bool
+}
+
+func (l *bottleneckLink) Send(pn protocol.PacketNumber, size protocol.ByteCount, now time.Time) {
+	departure := utils.MaxTime(now, l.lastDeparture).Add(time.Duration(size) * time.Second / time.Duration(l.bandwidth/BytesPerSecond))
+	l.lastDeparture = departure
+	l.inFlight = append(l.inFlight, linkPacket{
+		pn:      pn,
+		size:    size,
+		sent:    now,
+		arrival: departure.Add(l.rtt),
+		lost:    l.rand.Float64() < l.lossRate,
+	})
+}
+
+// Receive returns the packets that were acknowledged or lost until now.
+func (l *bottleneckLink) Receive(now time.Time) []linkPacket {
+	var i int
+	for i < len(l.inFlight) && !l.inFlight[i].arrival.After(now) {
+		i++
+	}
+	packets := l.inFlight[:i]
+	l.inFlight = l.inFlight[i:]
+	return packets
+}
+
+var _ = Describe("BBR Sender", func() {
+	const packetSize = protocol.ByteCount(1200)
+
+	var (
+		sender        *bbrSender
+		clock         mockClock
+		rttStats      *utils.RTTStats
+		bytesInFlight protocol.ByteCount
+		packetNumber  protocol.PacketNumber
+		states        []logging.CongestionState
+	)
+
+	BeforeEach(func() {
+		clock = mockClock(time.Now())
+		rttStats = utils.NewRTTStats()
+		bytesInFlight = 0
+		packetNumber = 0
+		states = nil
+		sender = NewBBRSender(&clock, rttStats, packetSize, &logging.ConnectionTracer{
+			UpdatedCongestionState: func(s logging.CongestionState) { states = append(states, s) },
+		})
+	})
+
+	// run simulates the transfer of data over the link, and returns the number of bytes acknowledged
+	run := func(link *bottleneckLink, duration time.Duration) protocol.ByteCount {
+		const step = 100 * time.Microsecond
+		var delivered protocol.ByteCount
+		end := clock.Now().Add(duration)
+		for clock.Now().Before(end) {
+			now := clock.Now()
+			priorInFlight := bytesInFlight
+			for _, p := range link.Receive(now) {
+				bytesInFlight -= p.size
+				if p.lost {
+					sender.OnCongestionEvent(protocol.PacketNumberSpaceApplicationData, p.pn, p.size, priorInFlight)
+					continue
+				}
+				rttStats.UpdateRTT(now.Sub(p.sent), 0, now)
+				sender.OnPacketAcked(protocol.PacketNumberSpaceApplicationData, p.pn, p.size, priorInFlight, now)
+				delivered += p.size
+			}
+			for sender.CanSend(bytesInFlight) && sender.HasPacingBudget(now) {
+				sender.OnPacketSent(now, bytesInFlight, protocol.PacketNumberSpaceApplicationData, packetNumber, packetSize, true)
+				link.Send(packetNumber, packetSize, now)
+				packetNumber++
+				bytesInFlight += packetSize
+			}
+			clock.Advance(step)
+		}
+		return delivered
+	}
+
+	newLink := func(bandwidth Bandwidth, rtt time.Duration, lossRate float64) *bottleneckLink {
+		return &bottleneckLink{
+			bandwidth: bandwidth,
+			rtt:       rtt,
+			lossRate:  lossRate,
+			rand:      rand.New(rand.NewSource(1)),
+		}
+	}
+
+	It("has the right values at startup", func() {
+		Expect(sender.GetCongestionWindow()).To(Equal(initialCongestionWindow * packetSize))
+		Expect(sender.CanSend(0)).To(BeTrue())
+		Expect(sender.TimeUntilSend(0)).To(BeZero())
+		Expect(states).To(Equal([]logging.CongestionState{logging.CongestionStateStartup}))
+	})
+
+	It("estimates the bandwidth and the minimum RTT", func() {
+		const bandwidth = 10_000_000 * BitsPerSecond
+		link := newLink(bandwidth, 50*time.Millisecond, 0)
+		delivered := run(link, 10*time.Second)
+		Expect(sender.maxBw).To(BeNumerically("~", bandwidth, bandwidth/10))
+		Expect(sender.minRTT).To(BeNumerically("~", 51*time.Millisecond, 2*time.Millisecond))
+		Expect(sender.isInProbeBWState()).To(BeTrue())
+		// the pacing rate is close to the bottleneck bandwidth
+		Expect(sender.pacingRate).To(BeNumerically("~", bandwidth, bandwidth/4))
+		// the bottleneck is fully utilized
+		Expect(float64(delivered)).To(BeNumerically(">", 0.9*10*float64(bandwidth/BytesPerSecond)))
+		// the queue at the bottleneck stays short
+		Expect(sender.GetCongestionWindow()).To(BeNumerically("<", 3*sender.bdp(bandwidth)))
+	})
+
+	It("goes through startup, drain and the ProbeBW cycle", func() {
+		link := newLink(10_000_000*BitsPerSecond, 50*time.Millisecond, 0)
+		run(link, 10*time.Second)
+		Expect(len(states)).To(BeNumerically(">=", 5))
+		Expect(states[:3]).To(Equal([]logging.CongestionState{
+			logging.CongestionStateStartup,
+			logging.CongestionStateDrain,
+			logging.CongestionStateProbeBWDown,
+		}))
+		Expect(states).To(ContainElement(logging.CongestionStateProbeBWCruise))
+		Expect(states).To(ContainElement(logging.CongestionStateProbeBWRefill))
+		Expect(states).To(ContainElement(logging.CongestionStateProbeBWUp))
+	})
+
+	It("keeps the throughput high with random loss", func() {
+		const bandwidth = 20_000_000 * BitsPerSecond
+		link := newLink(bandwidth, 100*time.Millisecond, 0.01)
+		run(link, 5*time.Second) // startup
+		delivered := run(link, 10*time.Second)
+		// Reno would only achieve a small fraction of the bandwidth (about 6%)
+		Expect(float64(delivered)).To(BeNumerically(">", 0.6*10*float64(bandwidth/BytesPerSecond)))
+	})
+
+	It("exits startup when the loss rate is high", func() {
+		link := newLink(10_000_000*BitsPerSecond, 50*time.Millisecond, 0.1)
+		run(link, 3*time.Second)
+		Expect(sender.fullBwReached).To(BeTrue())
+		Expect(sender.inflightHi).ToNot(Equal(protocol.MaxByteCount))
+	})
+
+	It("enters ProbeRTT when the minimum RTT isn't refreshed", func() {
+		link := newLink(10_000_000*BitsPerSecond, 20*time.Millisecond, 0)
+		run(link, 3*time.Second)
+		Expect(sender.minRTT).To(BeNumerically("<", 25*time.Millisecond))
+		// the path changes, and the RTT increases
+		link.rtt = 80 * time.Millisecond
+		run(link, 12*time.Second)
+		Expect(states).To(ContainElement(logging.CongestionStateProbeRTT))
+		Expect(sender.minRTT).To(BeNumerically(">=", 80*time.Millisecond))
+		// ProbeRTT was left again
+		Expect(sender.state).ToNot(Equal(bbrStateProbeRTT))
+	})
+
+	It("reduces the congestion window once per round trip on ECN-CE marks", func() {
+		link := newLink(10_000_000*BitsPerSecond, 50*time.Millisecond, 0)
+		run(link, 2*time.Second)
+		cwnd := sender.GetCongestionWindow()
+		sender.OnECNCongestionEvent(packetNumber-1, 10, bytesInFlight)
+		reducedCwnd := sender.GetCongestionWindow()
+		Expect(reducedCwnd).To(BeNumerically("<", cwnd))
+		Expect(sender.lossInRound).To(BeTrue())
+		// CE marks reported in the same round trip are ignored
+		sender.OnECNCongestionEvent(packetNumber-1, 10, bytesInFlight)
+		Expect(sender.GetCongestionWindow()).To(Equal(reducedCwnd))
+		// once packets sent after the reduction are acknowledged, BBR responds to CE marks again
+		run(link, 200*time.Millisecond)
+		Expect(sender.inECNRecovery).To(BeFalse())
+		cwnd = sender.GetCongestionWindow()
+		sender.OnECNCongestionEvent(packetNumber-1, 1, bytesInFlight)
+		Expect(sender.GetCongestionWindow()).To(BeNumerically("<", cwnd))
+	})
+
+	It("leaves startup on ECN-CE marks", func() {
+		link := newLink(10_000_000*BitsPerSecond, 50*time.Millisecond, 0)
+		run(link, 100*time.Millisecond)
+		Expect(sender.state).To(Equal(bbrStateStartup))
+		sender.OnECNCongestionEvent(packetNumber-1, 1, bytesInFlight)
+		Expect(sender.fullBwReached).To(BeTrue())
+		Expect(sender.inflightHi).ToNot(Equal(protocol.MaxByteCount))
+		run(link, 100*time.Millisecond)
+		Expect(states).To(ContainElement(logging.CongestionStateDrain))
+	})
+
+	It("collapses the congestion window on persistent congestion", func() {
+		link := newLink(10_000_000*BitsPerSecond, 50*time.Millisecond, 0)
+		run(link, time.Second)
+		sender.OnPersistentCongestion()
+		Expect(sender.GetCongestionWindow()).To(Equal(sender.minPipeCwnd()))
+		Expect(sender.priorCwnd).To(BeZero())
+	})
+
+	It("distinguishes packets with the same packet number in different packet number spaces", func() {
+		now := clock.Now()
+		sender.OnPacketSent(now, 0, protocol.PacketNumberSpaceInitial, 0, packetSize, true)
+		sender.OnPacketSent(now, packetSize, protocol.PacketNumberSpaceHandshake, 0, packetSize, true)
+		sender.OnPacketSent(now, 2*packetSize, protocol.PacketNumberSpaceApplicationData, 0, packetSize, true)
+		Expect(sender.sentPackets).To(HaveLen(3))
+		clock.Advance(10 * time.Millisecond)
+		sender.OnPacketAcked(protocol.PacketNumberSpaceApplicationData, 0, packetSize, 3*packetSize, clock.Now())
+		Expect(sender.sentPackets).To(HaveLen(2))
+		Expect(sender.sentPackets).To(HaveKey(bbrPacketKey{pnSpace: protocol.PacketNumberSpaceInitial, pn: 0}))
+		Expect(sender.sentPackets).To(HaveKey(bbrPacketKey{pnSpace: protocol.PacketNumberSpaceHandshake, pn: 0}))
+		sender.OnCongestionEvent(protocol.PacketNumberSpaceHandshake, 0, packetSize, 2*packetSize)
+		Expect(sender.sentPackets).To(HaveLen(1))
+		Expect(sender.sentPackets).To(HaveKey(bbrPacketKey{pnSpace: protocol.PacketNumberSpaceInitial, pn: 0}))
+	})
+
+	It("removes the packets of a packet number space when it is dropped", func() {
+		now := clock.Now()
+		sender.OnPacketSent(now, 0, protocol.PacketNumberSpaceInitial, 0, packetSize, true)
+		sender.OnPacketSent(now, packetSize, protocol.PacketNumberSpaceInitial, 1, packetSize, true)
+		sender.OnPacketSent(now, 2*packetSize, protocol.PacketNumberSpaceHandshake, 0, packetSize, true)
+		sender.OnPacketSent(now, 3*packetSize, protocol.PacketNumberSpaceApplicationData, 0, packetSize, true)
+		sender.DropPacketNumberSpace(protocol.PacketNumberSpaceInitial)
+		Expect(sender.sentPackets).To(HaveLen(2))
+		sender.DropPacketNumberSpace(protocol.PacketNumberSpaceHandshake)
+		Expect(sender.sentPackets).To(HaveLen(1))
+		Expect(sender.sentPackets).To(HaveKey(bbrPacketKey{pnSpace: protocol.PacketNumberSpaceApplicationData, pn: 0}))
+	})
+
+	It("doesn't allow reductions of the maximum packet size", func() {
+		Expect(func() { sender.SetMaxDatagramSize(packetSize - 1) }).To(Panic())
+	})
+})
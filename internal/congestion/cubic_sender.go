@@ -21,7 +21,7 @@ const (
 
 type cubicSender struct {
 	hybridSlowStart HybridSlowStart
-	rttStats        *utils.RTTStats
+	rttStats        RTTStats
 	cubic           *Cubic
 	pacer           *pacer
 	clock           Clock
@@ -59,15 +59,10 @@ type cubicSender struct {
 	tracer    *logging.ConnectionTracer
 }
 
-var (
-	_ SendAlgorithm               = &cubicSender{}
-	_ SendAlgorithmWithDebugInfos = &cubicSender{}
-)
-
 // NewCubicSender makes a new cubic sender
 func NewCubicSender(
 	clock Clock,
-	rttStats *utils.RTTStats,
+	rttStats RTTStats,
 	initialMaxDatagramSize protocol.ByteCount,
 	reno bool,
 	tracer *logging.ConnectionTracer,
@@ -85,7 +80,7 @@ func NewCubicSender(
 
 func newCubicSender(
 	clock Clock,
-	rttStats *utils.RTTStats,
+	rttStats RTTStats,
 	reno bool,
 	initialMaxDatagramSize,
 	initialCongestionWindow,
@@ -104,15 +99,30 @@ func newCubicSender(
 		cubic:                      NewCubic(clock),
 		clock:                      clock,
 		reno:                       reno,
-		tracer:                     tracer,
 		maxDatagramSize:            initialMaxDatagramSize,
 	}
 	c.pacer = newPacer(c.BandwidthEstimate)
-	if c.tracer != nil && c.tracer.UpdatedCongestionState != nil {
+	c.SetTracer(tracer)
+	return c
+}
+
+// SetTracer sets the tracer that congestion state changes are reported to.
+func (c *cubicSender) SetTracer(tracer *logging.ConnectionTracer) {
+	c.tracer = tracer
+	if c.tracer == nil || c.tracer.UpdatedCongestionState == nil {
+		return
+	}
+	switch {
+	case c.InRecovery():
+		c.lastState = logging.CongestionStateRecovery
+	case c.InSlowStart() && c.hybridSlowStart.InConservativeSlowStart():
+		c.lastState = logging.CongestionStateConservativeSlowStart
+	case c.InSlowStart():
 		c.lastState = logging.CongestionStateSlowStart
-		c.tracer.UpdatedCongestionState(logging.CongestionStateSlowStart)
+	default:
+		c.lastState = logging.CongestionStateCongestionAvoidance
 	}
-	return c
+	c.tracer.UpdatedCongestionState(c.lastState)
 }
 
 // TimeUntilSend returns when the next packet should be sent.
@@ -135,6 +145,7 @@ func (c *cubicSender) minCongestionWindow() protocol.ByteCount {
 func (c *cubicSender) OnPacketSent(
 	sentTime time.Time,
 	_ protocol.ByteCount,
+	_ protocol.PacketNumberSpace,
 	packetNumber protocol.PacketNumber,
 	bytes protocol.ByteCount,
 	isRetransmittable bool,
@@ -164,8 +175,7 @@ func (c *cubicSender) GetCongestionWindow() protocol.ByteCount {
 }
 
 func (c *cubicSender) MaybeExitSlowStart() {
-	if c.InSlowStart() &&
-		c.hybridSlowStart.ShouldExitSlowStart(c.rttStats.LatestRTT(), c.rttStats.MinRTT(), c.GetCongestionWindow()/c.maxDatagramSize) {
+	if c.InSlowStart() && c.hybridSlowStart.ShouldExitSlowStart(c.rttStats.LatestRTT()) {
 		// exit slow start
 		c.slowStartThreshold = c.congestionWindow
 		c.maybeTraceStateChange(logging.CongestionStateCongestionAvoidance)
@@ -173,6 +183,7 @@ func (c *cubicSender) MaybeExitSlowStart() {
 }
 
 func (c *cubicSender) OnPacketAcked(
+	_ protocol.PacketNumberSpace,
 	ackedPacketNumber protocol.PacketNumber,
 	ackedBytes protocol.ByteCount,
 	priorInFlight protocol.ByteCount,
@@ -188,13 +199,14 @@ func (c *cubicSender) OnPacketAcked(
 	}
 }
 
-func (c *cubicSender) OnCongestionEvent(packetNumber protocol.PacketNumber, lostBytes, priorInFlight protocol.ByteCount) {
+func (c *cubicSender) OnCongestionEvent(_ protocol.PacketNumberSpace, packetNumber protocol.PacketNumber, lostBytes, priorInFlight protocol.ByteCount) {
 	// TCP NewReno (RFC6582) says that once a loss occurs, any losses in packets
 	// already sent should be treated as a single loss event, since it's expected.
 	if packetNumber <= c.largestSentAtLastCutback {
 		return
 	}
 	c.lastCutbackExitedSlowstart = c.InSlowStart()
+	c.hybridSlowStart.OnCongestionEvent()
 	c.maybeTraceStateChange(logging.CongestionStateRecovery)
 
 	if c.reno {
@@ -212,6 +224,17 @@ func (c *cubicSender) OnCongestionEvent(packetNumber protocol.PacketNumber, lost
 	c.numAckedPackets = 0
 }
 
+// OnECNCongestionEvent is called when an ACK reports newly CE-marked packets.
+// A CE mark is treated like a packet loss (RFC 9002, section 7.1).
+// Since the recovery period is entered with the largest acknowledged packet,
+// the congestion window is reduced at most once per round trip.
+func (c *cubicSender) OnECNCongestionEvent(largestAcked protocol.PacketNumber, _ int64, priorInFlight protocol.ByteCount) {
+	c.OnCongestionEvent(protocol.PacketNumberSpaceApplicationData, largestAcked, 0, priorInFlight)
+}
+
+// DropPacketNumberSpace is a no-op, the cubic sender doesn't keep state for sent packets.
+func (c *cubicSender) DropPacketNumberSpace(protocol.PacketNumberSpace) {}
+
 // Called when we receive an ack. Normal TCP tracks how many packets one ack
 // represents, but quic has a separate ack for each packet.
 func (c *cubicSender) maybeIncreaseCwnd(
@@ -231,6 +254,11 @@ func (c *cubicSender) maybeIncreaseCwnd(
 		return
 	}
 	if c.InSlowStart() {
+		if c.hybridSlowStart.InConservativeSlowStart() {
+			c.congestionWindow += c.maxDatagramSize / hybridStartCSSGrowthDivisor
+			c.maybeTraceStateChange(logging.CongestionStateConservativeSlowStart)
+			return
+		}
 		// TCP slow start, exponential growth, increase by one for each ACK.
 		c.congestionWindow += c.maxDatagramSize
 		c.maybeTraceStateChange(logging.CongestionStateSlowStart)
@@ -270,6 +298,17 @@ func (c *cubicSender) BandwidthEstimate() Bandwidth {
 	return BandwidthFromDelta(c.GetCongestionWindow(), srtt)
 }
 
+// OnPersistentCongestion collapses the congestion window to the minimum congestion window,
+// see section 7.6.2 of RFC 9002. The sender then restarts in slow start.
+func (c *cubicSender) OnPersistentCongestion() {
+	c.hybridSlowStart.OnCongestionEvent()
+	c.cubic.Reset()
+	c.largestSentAtLastCutback = protocol.InvalidPacketNumber
+	c.numAckedPackets = 0
+	c.congestionWindow = c.minCongestionWindow()
+	c.maybeTraceStateChange(logging.CongestionStateSlowStart)
+}
+
 // OnRetransmissionTimeout is called on an retransmission timeout
 func (c *cubicSender) OnRetransmissionTimeout(packetsRetransmitted bool) {
 	c.largestSentAtLastCutback = protocol.InvalidPacketNumber
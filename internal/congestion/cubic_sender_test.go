@@ -5,6 +5,7 @@ import (
 
 	"github.com/quic-go/quic-go/internal/protocol"
 	"github.com/quic-go/quic-go/internal/utils"
+	"github.com/quic-go/quic-go/logging"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -57,7 +58,7 @@ var _ = Describe("Cubic Sender", func() {
 	SendAvailableSendWindowLen := func(packetLength protocol.ByteCount) int {
 		var packetsSent int
 		for sender.CanSend(bytesInFlight) {
-			sender.OnPacketSent(clock.Now(), bytesInFlight, packetNumber, packetLength, true)
+			sender.OnPacketSent(clock.Now(), bytesInFlight, protocol.PacketNumberSpaceApplicationData, packetNumber, packetLength, true)
 			packetNumber++
 			packetsSent++
 			bytesInFlight += packetLength
@@ -71,7 +72,7 @@ var _ = Describe("Cubic Sender", func() {
 		sender.MaybeExitSlowStart()
 		for i := 0; i < n; i++ {
 			ackedPacketNumber++
-			sender.OnPacketAcked(ackedPacketNumber, maxDatagramSize, bytesInFlight, clock.Now())
+			sender.OnPacketAcked(protocol.PacketNumberSpaceApplicationData, ackedPacketNumber, maxDatagramSize, bytesInFlight, clock.Now())
 		}
 		bytesInFlight -= protocol.ByteCount(n) * maxDatagramSize
 		clock.Advance(time.Millisecond)
@@ -80,14 +81,14 @@ var _ = Describe("Cubic Sender", func() {
 	LoseNPacketsLen := func(n int, packetLength protocol.ByteCount) {
 		for i := 0; i < n; i++ {
 			ackedPacketNumber++
-			sender.OnCongestionEvent(ackedPacketNumber, packetLength, bytesInFlight)
+			sender.OnCongestionEvent(protocol.PacketNumberSpaceApplicationData, ackedPacketNumber, packetLength, bytesInFlight)
 		}
 		bytesInFlight -= protocol.ByteCount(n) * packetLength
 	}
 
 	// Does not increment acked_packet_number_.
 	LosePacket := func(number protocol.PacketNumber) {
-		sender.OnCongestionEvent(number, maxDatagramSize, bytesInFlight)
+		sender.OnCongestionEvent(protocol.PacketNumberSpaceApplicationData, number, maxDatagramSize, bytesInFlight)
 		bytesInFlight -= maxDatagramSize
 	}
 
@@ -372,6 +373,24 @@ var _ = Describe("Cubic Sender", func() {
 		Expect(postLossWindow).To(BeNumerically(">", sender.GetCongestionWindow()))
 	})
 
+	It("reduces the congestion window once per round trip when packets are CE-marked", func() {
+		SendAvailableSendWindow()
+		initialWindow := sender.GetCongestionWindow()
+		sender.OnECNCongestionEvent(2, 1, bytesInFlight)
+		postCEWindow := sender.GetCongestionWindow()
+		Expect(postCEWindow).To(Equal(protocol.ByteCount(float64(initialWindow) * renoBeta)))
+		// CE marks on packets sent before the reduction are ignored
+		sender.OnECNCongestionEvent(5, 2, bytesInFlight)
+		sender.OnECNCongestionEvent(packetNumber-1, 1, bytesInFlight)
+		Expect(sender.GetCongestionWindow()).To(Equal(postCEWindow))
+
+		// a CE mark on a packet sent after the reduction reduces the window again
+		AckNPackets(int(packetNumber - 1))
+		SendAvailableSendWindow()
+		sender.OnECNCongestionEvent(packetNumber-1, 1, bytesInFlight)
+		Expect(sender.GetCongestionWindow()).To(BeNumerically("<", postCEWindow))
+	})
+
 	It("1 connection congestion avoidance at end of recovery", func() {
 		// Ack 10 packets in 5 acks to raise the CWND to 20.
 		const numberOfAcks = 5
@@ -464,7 +483,7 @@ var _ = Describe("Cubic Sender", func() {
 
 		for i := 1; i < protocol.MaxCongestionWindowPackets; i++ {
 			sender.MaybeExitSlowStart()
-			sender.OnPacketAcked(protocol.PacketNumber(i), 1350, sender.GetCongestionWindow(), clock.Now())
+			sender.OnPacketAcked(protocol.PacketNumberSpaceApplicationData, protocol.PacketNumber(i), 1350, sender.GetCongestionWindow(), clock.Now())
 		}
 		Expect(sender.GetCongestionWindow()).To(Equal(initialMaxCongestionWindow))
 	})
@@ -479,7 +498,7 @@ var _ = Describe("Cubic Sender", func() {
 		const packetSize = initialMaxDatagramSize + 100
 		sender.SetMaxDatagramSize(packetSize)
 		for i := 1; i < protocol.MaxCongestionWindowPackets; i++ {
-			sender.OnPacketAcked(protocol.PacketNumber(i), packetSize, sender.GetCongestionWindow(), clock.Now())
+			sender.OnPacketAcked(protocol.PacketNumberSpaceApplicationData, protocol.PacketNumber(i), packetSize, sender.GetCongestionWindow(), clock.Now())
 		}
 		const maxCwnd = protocol.MaxCongestionWindowPackets * packetSize
 		Expect(sender.GetCongestionWindow()).To(And(
@@ -524,4 +543,94 @@ var _ = Describe("Cubic Sender", func() {
 		AckNPackets(2)
 		Expect(sender.GetCongestionWindow()).To(Equal(savedCwnd + maxDatagramSize))
 	})
+
+	It("collapses the congestion window on persistent congestion", func() {
+		var states []logging.CongestionState
+		sender.SetTracer(&logging.ConnectionTracer{
+			UpdatedCongestionState: func(s logging.CongestionState) { states = append(states, s) },
+		})
+		SendAvailableSendWindow()
+		AckNPackets(2)
+		LoseNPackets(3)
+		Expect(sender.InRecovery()).To(BeTrue())
+		ssthresh := sender.slowStartThreshold
+		sender.OnPersistentCongestion()
+		Expect(sender.GetCongestionWindow()).To(Equal(minCongestionWindowPackets * maxDatagramSize))
+		Expect(sender.slowStartThreshold).To(Equal(ssthresh))
+		Expect(sender.InRecovery()).To(BeFalse())
+		Expect(sender.InSlowStart()).To(BeTrue())
+		Expect(states).To(Equal([]logging.CongestionState{
+			logging.CongestionStateSlowStart,
+			logging.CongestionStateRecovery,
+			logging.CongestionStateSlowStart,
+		}))
+	})
+
+	It("uses HyStart++ to exit slow start", func() {
+		var states []logging.CongestionState
+		sender.SetTracer(&logging.ConnectionTracer{
+			UpdatedCongestionState: func(s logging.CongestionState) { states = append(states, s) },
+		})
+		// sends a full window, and acknowledges every packet with a separate ACK
+		ackRound := func(rtt time.Duration) {
+			SendAvailableSendWindow()
+			priorInFlight := bytesInFlight
+			for ackedPacketNumber < packetNumber-1 {
+				rttStats.UpdateRTT(rtt, 0, clock.Now())
+				sender.MaybeExitSlowStart()
+				ackedPacketNumber++
+				sender.OnPacketAcked(protocol.PacketNumberSpaceApplicationData, ackedPacketNumber, maxDatagramSize, priorInFlight, clock.Now())
+				bytesInFlight -= maxDatagramSize
+			}
+			clock.Advance(rtt)
+		}
+
+		ackRound(60 * time.Millisecond)
+		ackRound(60 * time.Millisecond)
+		Expect(sender.hybridSlowStart.InConservativeSlowStart()).To(BeFalse())
+		ackRound(80 * time.Millisecond)
+		Expect(sender.hybridSlowStart.InConservativeSlowStart()).To(BeTrue())
+		Expect(sender.InSlowStart()).To(BeTrue())
+		Expect(states).To(Equal([]logging.CongestionState{
+			logging.CongestionStateSlowStart,
+			logging.CongestionStateConservativeSlowStart,
+		}))
+
+		// during CSS, the congestion window grows at a quarter of the rate
+		SendAvailableSendWindow()
+		cwnd := sender.GetCongestionWindow()
+		sender.OnPacketAcked(protocol.PacketNumberSpaceApplicationData, ackedPacketNumber+1, maxDatagramSize, bytesInFlight, clock.Now())
+		ackedPacketNumber++
+		bytesInFlight -= maxDatagramSize
+		Expect(sender.GetCongestionWindow()).To(Equal(cwnd + maxDatagramSize/4))
+
+		for i := 0; i < hybridStartCSSRounds+1; i++ {
+			ackRound(80 * time.Millisecond)
+		}
+		Expect(sender.InSlowStart()).To(BeFalse())
+		Expect(sender.slowStartThreshold).To(BeNumerically("<", MaxCongestionWindow))
+		Expect(states).To(Equal([]logging.CongestionState{
+			logging.CongestionStateSlowStart,
+			logging.CongestionStateConservativeSlowStart,
+			logging.CongestionStateCongestionAvoidance,
+		}))
+	})
+
+	It("doesn't use HyStart++ after a loss", func() {
+		SendAvailableSendWindow()
+		LoseNPackets(1)
+		Expect(sender.hybridSlowStart.done).To(BeTrue())
+	})
+
+	It("reports the current state when the tracer is set", func() {
+		SendAvailableSendWindow()
+		AckNPackets(2)
+		LoseNPackets(1)
+		Expect(sender.InRecovery()).To(BeTrue())
+		var states []logging.CongestionState
+		sender.SetTracer(&logging.ConnectionTracer{
+			UpdatedCongestionState: func(s logging.CongestionState) { states = append(states, s) },
+		})
+		Expect(states).To(Equal([]logging.CongestionState{logging.CongestionStateRecovery}))
+	})
 })
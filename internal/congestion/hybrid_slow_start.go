@@ -7,37 +7,55 @@ import (
 	"github.com/quic-go/quic-go/internal/utils"
 )
 
-// Note(pwestin): the magic clamping numbers come from the original code in
-// tcp_cubic.c.
-const hybridStartLowWindow = protocol.ByteCount(16)
-
-// Number of delay samples for detecting the increase of delay.
+// Number of RTT samples required per round before an increase of the delay can be detected.
 const hybridStartMinSamples = uint32(8)
 
-// Exit slow start if the min rtt has increased by more than 1/8th.
-const hybridStartDelayFactorExp = 3 // 2^3 = 8
-// The original paper specifies 2 and 8ms, but those have changed over time.
+// The RTT increase threshold is 1/8th of the min RTT of the last round,
+// clamped to values between 4ms and 16ms.
 const (
-	hybridStartDelayMinThresholdUs = int64(4000)
-	hybridStartDelayMaxThresholdUs = int64(16000)
+	hybridStartMinRTTDivisor = 8
+	hybridStartMinRTTThresh  = 4 * time.Millisecond
+	hybridStartMaxRTTThresh  = 16 * time.Millisecond
 )
 
-// HybridSlowStart implements the TCP hybrid slow start algorithm
+// During Conservative Slow Start (CSS), the congestion window grows at a quarter of the slow start rate.
+const hybridStartCSSGrowthDivisor = 4
+
+// Number of rounds spent in Conservative Slow Start before entering congestion avoidance.
+const hybridStartCSSRounds = 5
+
+// HybridSlowStart implements HyStart++ (RFC 9406).
+// When an increase of the delay is detected, slow start isn't left right away.
+// Instead, the congestion window continues to grow at a slower rate for a few rounds (Conservative Slow Start).
+// If the delay decreases again during that time, the increase is considered spurious, and slow start is resumed.
+// HyStart++ is only used for the initial slow start. Once slow start was left, Restart needs to be called to use it again.
 type HybridSlowStart struct {
 	endPacketNumber      protocol.PacketNumber
 	lastSentPacketNumber protocol.PacketNumber
 	started              bool
-	currentMinRTT        time.Duration
-	rttSampleCount       uint32
-	hystartFound         bool
+
+	// The minimum RTT samples of the current and the last round, 0 if there's no sample (yet).
+	currentRoundMinRTT time.Duration
+	lastRoundMinRTT    time.Duration
+	rttSampleCount     uint32
+
+	inCSS             bool
+	cssBaselineMinRTT time.Duration
+	cssRounds         int
+
+	done bool
 }
 
 // StartReceiveRound is called for the start of each receive round (burst) in the slow start phase.
 func (s *HybridSlowStart) StartReceiveRound(lastSent protocol.PacketNumber) {
 	s.endPacketNumber = lastSent
-	s.currentMinRTT = 0
+	s.lastRoundMinRTT = s.currentRoundMinRTT
+	s.currentRoundMinRTT = 0
 	s.rttSampleCount = 0
 	s.started = true
+	if s.inCSS {
+		s.cssRounds++
+	}
 }
 
 // IsEndOfRound returns true if this ack is the last packet number of our current slow start round.
@@ -47,44 +65,51 @@ func (s *HybridSlowStart) IsEndOfRound(ack protocol.PacketNumber) bool {
 
 // ShouldExitSlowStart should be called on every new ack frame, since a new
 // RTT measurement can be made then.
-// rtt: the RTT for this ack packet.
-// minRTT: is the lowest delay (RTT) we have seen during the session.
-// congestionWindow: the congestion window in packets.
-func (s *HybridSlowStart) ShouldExitSlowStart(latestRTT time.Duration, minRTT time.Duration, congestionWindow protocol.ByteCount) bool {
+// It returns true once Conservative Slow Start has lasted for hybridStartCSSRounds rounds.
+func (s *HybridSlowStart) ShouldExitSlowStart(latestRTT time.Duration) bool {
+	if s.done {
+		return false
+	}
 	if !s.started {
-		// Time to start the hybrid slow start.
 		s.StartReceiveRound(s.lastSentPacketNumber)
 	}
-	if s.hystartFound {
+	// The round in which CSS was entered only counts partially.
+	if s.inCSS && s.cssRounds > hybridStartCSSRounds {
+		s.inCSS = false
+		s.done = true
 		return true
 	}
-	// Second detection parameter - delay increase detection.
-	// Compare the minimum delay (s.currentMinRTT) of the current
-	// burst of packets relative to the minimum delay during the session.
-	// Note: we only look at the first few(8) packets in each burst, since we
-	// only want to compare the lowest RTT of the burst relative to previous
-	// bursts.
 	s.rttSampleCount++
-	if s.rttSampleCount <= hybridStartMinSamples {
-		if s.currentMinRTT == 0 || s.currentMinRTT > latestRTT {
-			s.currentMinRTT = latestRTT
-		}
+	if s.currentRoundMinRTT == 0 || latestRTT < s.currentRoundMinRTT {
+		s.currentRoundMinRTT = latestRTT
 	}
-	// We only need to check this once per round.
-	if s.rttSampleCount == hybridStartMinSamples {
-		// Divide minRTT by 8 to get a rtt increase threshold for exiting.
-		minRTTincreaseThresholdUs := int64(minRTT / time.Microsecond >> hybridStartDelayFactorExp)
-		// Ensure the rtt threshold is never less than 2ms or more than 16ms.
-		minRTTincreaseThresholdUs = utils.Min(minRTTincreaseThresholdUs, hybridStartDelayMaxThresholdUs)
-		minRTTincreaseThreshold := time.Duration(utils.Max(minRTTincreaseThresholdUs, hybridStartDelayMinThresholdUs)) * time.Microsecond
-
-		if s.currentMinRTT > (minRTT + minRTTincreaseThreshold) {
-			s.hystartFound = true
+	if s.rttSampleCount < hybridStartMinSamples {
+		return false
+	}
+
+	if s.inCSS {
+		// The delay increase was spurious. Resume slow start.
+		if s.currentRoundMinRTT < s.cssBaselineMinRTT {
+			s.inCSS = false
+			s.cssBaselineMinRTT = 0
 		}
+		return false
+	}
+	if s.lastRoundMinRTT == 0 {
+		return false
+	}
+	rttThresh := utils.Max(hybridStartMinRTTThresh, utils.Min(s.lastRoundMinRTT/hybridStartMinRTTDivisor, hybridStartMaxRTTThresh))
+	if s.currentRoundMinRTT >= s.lastRoundMinRTT+rttThresh {
+		s.inCSS = true
+		s.cssBaselineMinRTT = s.currentRoundMinRTT
+		s.cssRounds = 0
 	}
-	// Exit from slow start if the cwnd is greater than 16 and
-	// increasing delay is found.
-	return congestionWindow >= hybridStartLowWindow && s.hystartFound
+	return false
+}
+
+// InConservativeSlowStart says if HyStart++ is in the Conservative Slow Start phase.
+func (s *HybridSlowStart) InConservativeSlowStart() bool {
+	return s.inCSS
 }
 
 // OnPacketSent is called when a packet was sent
@@ -101,6 +126,13 @@ func (s *HybridSlowStart) OnPacketAcked(ackedPacketNumber protocol.PacketNumber)
 	}
 }
 
+// OnCongestionEvent is called when slow start is left due to packet loss or ECN-CE marks.
+// HyStart++ isn't used for any subsequent slow start phases, see section 4.3 of RFC 9406.
+func (s *HybridSlowStart) OnCongestionEvent() {
+	s.inCSS = false
+	s.done = true
+}
+
 // Started returns true if started
 func (s *HybridSlowStart) Started() bool {
 	return s.started
@@ -108,6 +140,5 @@ func (s *HybridSlowStart) Started() bool {
 
 // Restart the slow start phase
 func (s *HybridSlowStart) Restart() {
-	s.started = false
-	s.hystartFound = false
+	*s = HybridSlowStart{lastSentPacketNumber: s.lastSentPacketNumber}
 }
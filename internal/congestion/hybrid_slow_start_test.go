@@ -46,28 +46,96 @@ var _ = Describe("Hybrid slow start", func() {
 		Expect(slowStart.IsEndOfRound(packetNumber)).To(BeTrue())
 	})
 
-	It("works with delay", func() {
-		rtt := 60 * time.Millisecond
-		// We expect to detect the increase at +1/8 of the RTT; hence at a typical
-		// RTT of 60ms the detection will happen at 67.5 ms.
-		const hybridStartMinSamples = 8 // Number of acks required to trigger.
-
-		endPacketNumber := protocol.PacketNumber(1)
-		endPacketNumber++
-		slowStart.StartReceiveRound(endPacketNumber)
+	// runRound simulates one round, with hybridStartMinSamples ACKs, each with the same RTT sample.
+	// It returns true if slow start should be exited.
+	var pn protocol.PacketNumber
+	runRound := func(rtt time.Duration) (exit bool) {
+		pn += 10
+		slowStart.OnPacketSent(pn)
+		for i := uint32(0); i < hybridStartMinSamples; i++ {
+			if slowStart.ShouldExitSlowStart(rtt) {
+				exit = true
+			}
+		}
+		slowStart.OnPacketAcked(pn + 1)
+		return exit
+	}
 
-		// Will not trigger since our lowest RTT in our burst is the same as the long
-		// term RTT provided.
-		for n := 0; n < hybridStartMinSamples; n++ {
-			Expect(slowStart.ShouldExitSlowStart(rtt+time.Duration(n)*time.Millisecond, rtt, 100)).To(BeFalse())
+	It("enters Conservative Slow Start when the delay increases, and exits slow start after a few rounds", func() {
+		Expect(runRound(60 * time.Millisecond)).To(BeFalse())
+		Expect(runRound(60 * time.Millisecond)).To(BeFalse())
+		Expect(slowStart.InConservativeSlowStart()).To(BeFalse())
+		// The threshold is 60ms / 8 = 7.5ms.
+		Expect(runRound(67 * time.Millisecond)).To(BeFalse())
+		Expect(slowStart.InConservativeSlowStart()).To(BeFalse())
+		Expect(runRound(76 * time.Millisecond)).To(BeFalse()) // threshold: 67ms + 67ms / 8
+		Expect(slowStart.InConservativeSlowStart()).To(BeTrue())
+		for i := 0; i < hybridStartCSSRounds; i++ {
+			Expect(runRound(80 * time.Millisecond)).To(BeFalse())
+			Expect(slowStart.InConservativeSlowStart()).To(BeTrue())
 		}
-		endPacketNumber++
-		slowStart.StartReceiveRound(endPacketNumber)
-		for n := 1; n < hybridStartMinSamples; n++ {
-			Expect(slowStart.ShouldExitSlowStart(rtt+(time.Duration(n)+10)*time.Millisecond, rtt, 100)).To(BeFalse())
+		Expect(runRound(80 * time.Millisecond)).To(BeTrue())
+		Expect(slowStart.InConservativeSlowStart()).To(BeFalse())
+		// HyStart++ is only used once
+		Expect(runRound(200 * time.Millisecond)).To(BeFalse())
+		Expect(runRound(400 * time.Millisecond)).To(BeFalse())
+		Expect(slowStart.InConservativeSlowStart()).To(BeFalse())
+	})
+
+	It("uses a minimum threshold of 4ms", func() {
+		Expect(runRound(10 * time.Millisecond)).To(BeFalse())
+		Expect(runRound(13 * time.Millisecond)).To(BeFalse())
+		Expect(slowStart.InConservativeSlowStart()).To(BeFalse())
+		Expect(runRound(17 * time.Millisecond)).To(BeFalse())
+		Expect(slowStart.InConservativeSlowStart()).To(BeTrue())
+	})
+
+	It("uses a maximum threshold of 16ms", func() {
+		Expect(runRound(400 * time.Millisecond)).To(BeFalse())
+		Expect(runRound(416 * time.Millisecond)).To(BeFalse())
+		Expect(slowStart.InConservativeSlowStart()).To(BeTrue())
+	})
+
+	It("needs enough RTT samples to detect a delay increase", func() {
+		Expect(runRound(60 * time.Millisecond)).To(BeFalse())
+		pn += 10
+		slowStart.OnPacketSent(pn)
+		for i := uint32(1); i < hybridStartMinSamples; i++ {
+			Expect(slowStart.ShouldExitSlowStart(100 * time.Millisecond)).To(BeFalse())
+			Expect(slowStart.InConservativeSlowStart()).To(BeFalse())
+		}
+		Expect(slowStart.ShouldExitSlowStart(100 * time.Millisecond)).To(BeFalse())
+		Expect(slowStart.InConservativeSlowStart()).To(BeTrue())
+	})
+
+	It("resumes slow start if the delay increase was spurious", func() {
+		Expect(runRound(60 * time.Millisecond)).To(BeFalse())
+		Expect(runRound(80 * time.Millisecond)).To(BeFalse())
+		Expect(slowStart.InConservativeSlowStart()).To(BeTrue())
+		Expect(runRound(79 * time.Millisecond)).To(BeFalse())
+		Expect(slowStart.InConservativeSlowStart()).To(BeFalse())
+		// the rounds spent in CSS don't count towards the next CSS phase
+		Expect(runRound(90 * time.Millisecond)).To(BeFalse())
+		Expect(slowStart.InConservativeSlowStart()).To(BeTrue())
+		for i := 0; i < hybridStartCSSRounds; i++ {
+			Expect(runRound(90 * time.Millisecond)).To(BeFalse())
 		}
-		// Expect to trigger since all packets in this burst was above the long term
-		// RTT provided.
-		Expect(slowStart.ShouldExitSlowStart(rtt+10*time.Millisecond, rtt, 100)).To(BeTrue())
+		Expect(runRound(90 * time.Millisecond)).To(BeTrue())
+	})
+
+	It("isn't used after a congestion event, until it is restarted", func() {
+		Expect(runRound(60 * time.Millisecond)).To(BeFalse())
+		Expect(runRound(80 * time.Millisecond)).To(BeFalse())
+		Expect(slowStart.InConservativeSlowStart()).To(BeTrue())
+		slowStart.OnCongestionEvent()
+		Expect(slowStart.InConservativeSlowStart()).To(BeFalse())
+		Expect(runRound(60 * time.Millisecond)).To(BeFalse())
+		Expect(runRound(80 * time.Millisecond)).To(BeFalse())
+		Expect(slowStart.InConservativeSlowStart()).To(BeFalse())
+
+		slowStart.Restart()
+		Expect(runRound(60 * time.Millisecond)).To(BeFalse())
+		Expect(runRound(80 * time.Millisecond)).To(BeFalse())
+		Expect(slowStart.InConservativeSlowStart()).To(BeTrue())
 	})
 })
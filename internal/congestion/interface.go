@@ -1,28 +1,20 @@
 package congestion
 
-import (
-	"time"
+import "time"
 
-	"github.com/quic-go/quic-go/internal/protocol"
-)
-
-// A SendAlgorithm performs congestion control
-type SendAlgorithm interface {
-	TimeUntilSend(bytesInFlight protocol.ByteCount) time.Time
-	HasPacingBudget(now time.Time) bool
-	OnPacketSent(sentTime time.Time, bytesInFlight protocol.ByteCount, packetNumber protocol.PacketNumber, bytes protocol.ByteCount, isRetransmittable bool)
-	CanSend(bytesInFlight protocol.ByteCount) bool
-	MaybeExitSlowStart()
-	OnPacketAcked(number protocol.PacketNumber, ackedBytes protocol.ByteCount, priorInFlight protocol.ByteCount, eventTime time.Time)
-	OnCongestionEvent(number protocol.PacketNumber, lostBytes protocol.ByteCount, priorInFlight protocol.ByteCount)
-	OnRetransmissionTimeout(packetsRetransmitted bool)
-	SetMaxDatagramSize(protocol.ByteCount)
-}
-
-// A SendAlgorithmWithDebugInfos is a SendAlgorithm that exposes some debug infos
-type SendAlgorithmWithDebugInfos interface {
-	SendAlgorithm
-	InSlowStart() bool
-	InRecovery() bool
-	GetCongestionWindow() protocol.ByteCount
+// RTTStats is a read-only view of the RTT estimates of a path.
+// The estimates are updated by the sent packet handler.
+type RTTStats interface {
+	// MinRTT is the smallest RTT sample observed on the path.
+	MinRTT() time.Duration
+	// LatestRTT is the most recent RTT sample.
+	LatestRTT() time.Duration
+	// SmoothedRTT is the exponentially weighted moving average of the RTT samples (RFC 9002, section 5.3).
+	SmoothedRTT() time.Duration
+	// MeanDeviation is the variation of the RTT samples.
+	MeanDeviation() time.Duration
+	// MaxAckDelay is the maximum delay the peer applies to acknowledging packets.
+	MaxAckDelay() time.Duration
+	// PTO is the probe timeout (RFC 9002, section 6.2.1).
+	PTO(includeMaxAckDelay bool) time.Duration
 }
@@ -96,6 +96,18 @@ func (c *connectionFlowController) EnsureMinimumWindowSize(inc protocol.ByteCoun
 	c.mutex.Unlock()
 }
 
+func (c *connectionFlowController) Stats() Stats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return Stats{
+		BytesSent:       c.bytesSent,
+		SendWindow:      c.sendWindow,
+		HighestReceived: c.highestReceived,
+		ReceiveWindow:   c.receiveWindow,
+	}
+}
+
 // Reset rests the flow controller. This happens when 0-RTT is rejected.
 // All stream data is invalidated, it's if we had never opened a stream and never sent any data.
 // At that point, we only have sent stream data, but we didn't have the keys to open 1-RTT keys yet.
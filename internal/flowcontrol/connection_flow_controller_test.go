@@ -160,6 +160,19 @@ var _ = Describe("Connection Flow controller", func() {
 		})
 	})
 
+	It("returns the statistics", func() {
+		controller.receiveWindow = 2000
+		controller.UpdateSendWindow(1000)
+		controller.AddBytesSent(400)
+		Expect(controller.IncrementHighestReceived(300)).To(Succeed())
+		Expect(controller.Stats()).To(Equal(Stats{
+			BytesSent:       400,
+			SendWindow:      1000,
+			HighestReceived: 300,
+			ReceiveWindow:   2000,
+		}))
+	})
+
 	Context("resetting", func() {
 		It("resets", func() {
 			const initialWindow protocol.ByteCount = 1337
@@ -30,6 +30,19 @@ type StreamFlowController interface {
 type ConnectionFlowController interface {
 	flowController
 	Reset() error
+	Stats() Stats
+}
+
+// Stats describes the state of the connection-level flow control.
+type Stats struct {
+	// BytesSent is the number of bytes of stream data sent.
+	BytesSent protocol.ByteCount
+	// SendWindow is the flow control limit granted by the peer.
+	SendWindow protocol.ByteCount
+	// HighestReceived is the highest offset of stream data received, summed over all streams.
+	HighestReceived protocol.ByteCount
+	// ReceiveWindow is the flow control limit granted to the peer.
+	ReceiveWindow protocol.ByteCount
 }
 
 type connectionFlowControllerI interface {
@@ -308,6 +308,9 @@ func (h *cryptoSetup) handleTransportParameters(data []byte) error {
 		return err
 	}
 	h.peerParams = &tp
+	if h.ourParams.EnableMultipath && tp.EnableMultipath {
+		h.aead.SetMultipath()
+	}
 	h.events = append(h.events, Event{Kind: EventReceivedTransportParameters, TransportParameters: h.peerParams})
 	return nil
 }
@@ -38,6 +38,10 @@ type ShortHeaderOpener interface {
 	headerDecryptor
 	DecodePacketNumber(wirePN protocol.PacketNumber, wirePNLen protocol.PacketNumberLen) protocol.PacketNumber
 	Open(dst, src []byte, rcvTime time.Time, pn protocol.PacketNumber, kp protocol.KeyPhaseBit, associatedData []byte) ([]byte, error)
+	// DecodePacketNumberOnPath and OpenOnPath are used on multipath connections.
+	// Every path uses its own packet number space, identified by the path ID.
+	DecodePacketNumberOnPath(pathID uint64, wirePN protocol.PacketNumber, wirePNLen protocol.PacketNumberLen) protocol.PacketNumber
+	OpenOnPath(dst, src []byte, rcvTime time.Time, pathID uint64, pn protocol.PacketNumber, kp protocol.KeyPhaseBit, associatedData []byte) ([]byte, error)
 }
 
 // LongHeaderSealer seals a long header packet
@@ -50,6 +54,9 @@ type LongHeaderSealer interface {
 // ShortHeaderSealer seals a short header packet
 type ShortHeaderSealer interface {
 	LongHeaderSealer
+	// SealOnPath is used on multipath connections.
+	// Every path uses its own packet number space, identified by the path ID.
+	SealOnPath(dst, src []byte, pathID uint64, packetNumber protocol.PacketNumber, associatedData []byte) []byte
 	KeyPhase() protocol.KeyPhaseBit
 }
 
@@ -40,10 +40,14 @@ type updatableAEAD struct {
 	firstRcvdWithCurrentKey protocol.PacketNumber
 	firstSentWithCurrentKey protocol.PacketNumber
 	highestRcvdPN           protocol.PacketNumber // highest packet number received (which could be successfully unprotected)
-	numRcvdWithCurrentKey   uint64
-	numSentWithCurrentKey   uint64
-	rcvAEAD                 cipher.AEAD
-	sendAEAD                cipher.AEAD
+	// On a multipath connection, every path (except for the initial path) uses a separate packet number space,
+	// see draft-ietf-quic-multipath-04.
+	multipath             bool
+	highestRcvdPNOnPath   map[uint64]protocol.PacketNumber
+	numRcvdWithCurrentKey uint64
+	numSentWithCurrentKey uint64
+	rcvAEAD               cipher.AEAD
+	sendAEAD              cipher.AEAD
 	// caches cipher.AEAD.Overhead(). This speeds up calls to Overhead().
 	aeadOverhead int
 
@@ -146,7 +150,8 @@ func (a *updatableAEAD) SetWriteKey(suite *cipherSuite, trafficSecret []byte) {
 }
 
 func (a *updatableAEAD) setAEADParameters(aead cipher.AEAD, suite *cipherSuite) {
-	a.nonceBuf = make([]byte, aead.NonceSize())
+	// Use the full nonce length, such that the path ID can be encoded on multipath connections.
+	a.nonceBuf = make([]byte, aeadNonceLength)
 	a.aeadOverhead = aead.Overhead()
 	a.suite = suite
 	switch suite.ID {
@@ -159,12 +164,34 @@ func (a *updatableAEAD) setAEADParameters(aead cipher.AEAD, suite *cipherSuite)
 	}
 }
 
+// SetMultipath is called when both endpoints enabled the multipath extension.
+// Since the key phase is tracked using the packet numbers of the initial path,
+// we don't initiate key updates on multipath connections.
+func (a *updatableAEAD) SetMultipath() {
+	a.multipath = true
+	a.highestRcvdPNOnPath = make(map[uint64]protocol.PacketNumber)
+}
+
 func (a *updatableAEAD) DecodePacketNumber(wirePN protocol.PacketNumber, wirePNLen protocol.PacketNumberLen) protocol.PacketNumber {
 	return protocol.DecodePacketNumber(wirePNLen, a.highestRcvdPN, wirePN)
 }
 
+// DecodePacketNumberOnPath decodes the packet number of a packet received on a path of a multipath connection.
+func (a *updatableAEAD) DecodePacketNumberOnPath(pathID uint64, wirePN protocol.PacketNumber, wirePNLen protocol.PacketNumberLen) protocol.PacketNumber {
+	if pathID == 0 {
+		return a.DecodePacketNumber(wirePN, wirePNLen)
+	}
+	return protocol.DecodePacketNumber(wirePNLen, a.highestRcvdPNOnPath[pathID], wirePN)
+}
+
 func (a *updatableAEAD) Open(dst, src []byte, rcvTime time.Time, pn protocol.PacketNumber, kp protocol.KeyPhaseBit, ad []byte) ([]byte, error) {
-	dec, err := a.open(dst, src, rcvTime, pn, kp, ad)
+	return a.OpenOnPath(dst, src, rcvTime, 0, pn, kp, ad)
+}
+
+// OpenOnPath opens a packet received on a path of a multipath connection.
+// The path ID is the sequence number of the connection ID that the packet was sent to.
+func (a *updatableAEAD) OpenOnPath(dst, src []byte, rcvTime time.Time, pathID uint64, pn protocol.PacketNumber, kp protocol.KeyPhaseBit, ad []byte) ([]byte, error) {
+	dec, err := a.open(dst, src, rcvTime, pathID, pn, kp, ad)
 	if err == ErrDecryptionFailed {
 		a.invalidPacketCount++
 		if a.invalidPacketCount >= a.invalidPacketLimit {
@@ -172,12 +199,16 @@ func (a *updatableAEAD) Open(dst, src []byte, rcvTime time.Time, pn protocol.Pac
 		}
 	}
 	if err == nil {
-		a.highestRcvdPN = utils.Max(a.highestRcvdPN, pn)
+		if pathID == 0 {
+			a.highestRcvdPN = utils.Max(a.highestRcvdPN, pn)
+		} else {
+			a.highestRcvdPNOnPath[pathID] = utils.Max(a.highestRcvdPNOnPath[pathID], pn)
+		}
 	}
 	return dec, err
 }
 
-func (a *updatableAEAD) open(dst, src []byte, rcvTime time.Time, pn protocol.PacketNumber, kp protocol.KeyPhaseBit, ad []byte) ([]byte, error) {
+func (a *updatableAEAD) open(dst, src []byte, rcvTime time.Time, pathID uint64, pn protocol.PacketNumber, kp protocol.KeyPhaseBit, ad []byte) ([]byte, error) {
 	if a.prevRcvAEAD != nil && !a.prevRcvAEADExpiry.IsZero() && rcvTime.After(a.prevRcvAEADExpiry) {
 		a.prevRcvAEAD = nil
 		a.logger.Debugf("Dropping key phase %d", a.keyPhase-1)
@@ -186,7 +217,19 @@ func (a *updatableAEAD) open(dst, src []byte, rcvTime time.Time, pn protocol.Pac
 			a.tracer.DroppedKey(a.keyPhase - 1)
 		}
 	}
-	binary.BigEndian.PutUint64(a.nonceBuf[len(a.nonceBuf)-8:], uint64(pn))
+	a.setNonce(pathID, pn)
+	if kp != a.keyPhase.Bit() && pathID != 0 {
+		// Key updates are only processed on the initial path.
+		// A packet on any other path might have been sent before the peer updated its keys.
+		if a.prevRcvAEAD == nil {
+			return nil, ErrDecryptionFailed
+		}
+		dec, err := a.prevRcvAEAD.Open(dst, a.nonceBuf, src, ad)
+		if err != nil {
+			err = ErrDecryptionFailed
+		}
+		return dec, err
+	}
 	if kp != a.keyPhase.Bit() {
 		if a.keyPhase > 0 && a.firstRcvdWithCurrentKey == protocol.InvalidPacketNumber || pn < a.firstRcvdWithCurrentKey {
 			if a.prevRcvAEAD == nil {
@@ -229,6 +272,9 @@ func (a *updatableAEAD) open(dst, src []byte, rcvTime time.Time, pn protocol.Pac
 		return dec, ErrDecryptionFailed
 	}
 	a.numRcvdWithCurrentKey++
+	if pathID != 0 {
+		return dec, nil
+	}
 	if a.firstRcvdWithCurrentKey == protocol.InvalidPacketNumber {
 		// We initiated the key updated, and now we received the first packet protected with the new key phase.
 		// Therefore, we are certain that the peer rolled its keys as well. Start a timer to drop the old keys.
@@ -248,13 +294,27 @@ func (a *updatableAEAD) Seal(dst, src []byte, pn protocol.PacketNumber, ad []byt
 	if a.firstPacketNumber == protocol.InvalidPacketNumber {
 		a.firstPacketNumber = pn
 	}
+	return a.SealOnPath(dst, src, 0, pn, ad)
+}
+
+// SealOnPath seals a packet sent on a path of a multipath connection.
+// The path ID is the sequence number of the connection ID that the packet is sent to.
+func (a *updatableAEAD) SealOnPath(dst, src []byte, pathID uint64, pn protocol.PacketNumber, ad []byte) []byte {
 	a.numSentWithCurrentKey++
-	binary.BigEndian.PutUint64(a.nonceBuf[len(a.nonceBuf)-8:], uint64(pn))
+	a.setNonce(pathID, pn)
 	// The AEAD we're using here will be the qtls.aeadAESGCM13.
 	// It uses the nonce provided here and XOR it with the IV.
 	return a.sendAEAD.Seal(dst, a.nonceBuf, src, ad)
 }
 
+// setNonce sets the path-and-packet-number nonce, see section 9.1 of draft-ietf-quic-multipath-04:
+// The least significant 32 bits of the path ID, followed by the 64 bits of the packet number.
+// For the initial path, this is the nonce defined in RFC 9001.
+func (a *updatableAEAD) setNonce(pathID uint64, pn protocol.PacketNumber) {
+	binary.BigEndian.PutUint32(a.nonceBuf[len(a.nonceBuf)-12:], uint32(pathID))
+	binary.BigEndian.PutUint64(a.nonceBuf[len(a.nonceBuf)-8:], uint64(pn))
+}
+
 func (a *updatableAEAD) SetLargestAcked(pn protocol.PacketNumber) error {
 	if a.firstSentWithCurrentKey != protocol.InvalidPacketNumber &&
 		pn >= a.firstSentWithCurrentKey && a.numRcvdWithCurrentKey == 0 {
@@ -272,7 +332,7 @@ func (a *updatableAEAD) SetHandshakeConfirmed() {
 }
 
 func (a *updatableAEAD) updateAllowed() bool {
-	if !a.handshakeConfirmed {
+	if !a.handshakeConfirmed || a.multipath {
 		return false
 	}
 	// the first key update is allowed as soon as the handshake is confirmed
@@ -160,6 +160,57 @@ var _ = Describe("Updatable AEAD", func() {
 							Expect(err.(*qerr.TransportError).ErrorCode).To(Equal(qerr.AEADLimitReached))
 						})
 
+						Context("multipath", func() {
+							BeforeEach(func() {
+								client.SetMultipath()
+								server.SetMultipath()
+							})
+
+							It("encrypts and decrypts a message on a path", func() {
+								encrypted := server.SealOnPath(nil, msg, 3, 0x1337, ad)
+								opened, err := client.OpenOnPath(nil, encrypted, time.Now(), 3, 0x1337, protocol.KeyPhaseZero, ad)
+								Expect(err).ToNot(HaveOccurred())
+								Expect(opened).To(Equal(msg))
+							})
+
+							It("uses the RFC 9001 nonce on the initial path", func() {
+								encrypted := server.SealOnPath(nil, msg, 0, 0x1337, ad)
+								Expect(encrypted).To(Equal(server.Seal(nil, msg, 0x1337, ad)))
+							})
+
+							It("fails to open a message if the path ID is not the same", func() {
+								encrypted := server.SealOnPath(nil, msg, 3, 0x1337, ad)
+								_, err := client.OpenOnPath(nil, encrypted, time.Now(), 4, 0x1337, protocol.KeyPhaseZero, ad)
+								Expect(err).To(MatchError(ErrDecryptionFailed))
+								_, err = client.Open(nil, encrypted, time.Now(), 0x1337, protocol.KeyPhaseZero, ad)
+								Expect(err).To(MatchError(ErrDecryptionFailed))
+							})
+
+							It("decodes packet numbers separately for every path", func() {
+								encrypted := server.SealOnPath(nil, msg, 3, 0x1337, ad)
+								_, err := client.OpenOnPath(nil, encrypted, time.Now(), 3, 0x1337, protocol.KeyPhaseZero, ad)
+								Expect(err).ToNot(HaveOccurred())
+								Expect(client.DecodePacketNumberOnPath(3, 0x38, protocol.PacketNumberLen1)).To(BeEquivalentTo(0x1338))
+								Expect(client.DecodePacketNumberOnPath(5, 0x38, protocol.PacketNumberLen1)).To(BeEquivalentTo(0x38))
+								Expect(client.DecodePacketNumber(0x38, protocol.PacketNumberLen1)).To(BeEquivalentTo(0x38))
+								Expect(client.DecodePacketNumberOnPath(0, 0x38, protocol.PacketNumberLen1)).To(BeEquivalentTo(0x38))
+							})
+
+							It("doesn't initiate key updates", func() {
+								server.SetHandshakeConfirmed()
+								for i := 0; i < int(FirstKeyUpdateInterval)+10; i++ {
+									server.Seal(nil, msg, protocol.PacketNumber(i), ad)
+								}
+								Expect(server.KeyPhase()).To(Equal(protocol.KeyPhaseZero))
+							})
+
+							It("rejects packets using the next key phase on paths other than the initial path", func() {
+								encrypted := server.SealOnPath(nil, msg, 3, 0x1337, ad)
+								_, err := client.OpenOnPath(nil, encrypted, time.Now(), 3, 0x1337, protocol.KeyPhaseOne, ad)
+								Expect(err).To(MatchError(ErrDecryptionFailed))
+							})
+						})
+
 						Context("key updates", func() {
 							Context("receiving key updates", func() {
 								It("updates keys", func() {
@@ -153,6 +153,44 @@ func (c *SentPacketHandlerGetLossDetectionTimeoutCall) DoAndReturn(f func() time
 	return c
 }
 
+// MigratedPath mocks base method.
+func (m *MockSentPacketHandler) MigratedPath(arg0 time.Time, arg1, arg2 bool) *ackhandler.PathCongestionState {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MigratedPath", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*ackhandler.PathCongestionState)
+	return ret0
+}
+
+// MigratedPath indicates an expected call of MigratedPath.
+func (mr *MockSentPacketHandlerMockRecorder) MigratedPath(arg0, arg1, arg2 any) *SentPacketHandlerMigratedPathCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MigratedPath", reflect.TypeOf((*MockSentPacketHandler)(nil).MigratedPath), arg0, arg1, arg2)
+	return &SentPacketHandlerMigratedPathCall{Call: call}
+}
+
+// SentPacketHandlerMigratedPathCall wrap *gomock.Call
+type SentPacketHandlerMigratedPathCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *SentPacketHandlerMigratedPathCall) Return(arg0 *ackhandler.PathCongestionState) *SentPacketHandlerMigratedPathCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *SentPacketHandlerMigratedPathCall) Do(f func(time.Time, bool, bool) *ackhandler.PathCongestionState) *SentPacketHandlerMigratedPathCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *SentPacketHandlerMigratedPathCall) DoAndReturn(f func(time.Time, bool, bool) *ackhandler.PathCongestionState) *SentPacketHandlerMigratedPathCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // OnLossDetectionTimeout mocks base method.
 func (m *MockSentPacketHandler) OnLossDetectionTimeout() error {
 	m.ctrl.T.Helper()
@@ -419,6 +457,42 @@ func (c *SentPacketHandlerResetForRetryCall) DoAndReturn(f func(time.Time) error
 	return c
 }
 
+// RestorePath mocks base method.
+func (m *MockSentPacketHandler) RestorePath(arg0 time.Time, arg1 *ackhandler.PathCongestionState) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RestorePath", arg0, arg1)
+}
+
+// RestorePath indicates an expected call of RestorePath.
+func (mr *MockSentPacketHandlerMockRecorder) RestorePath(arg0, arg1 any) *SentPacketHandlerRestorePathCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestorePath", reflect.TypeOf((*MockSentPacketHandler)(nil).RestorePath), arg0, arg1)
+	return &SentPacketHandlerRestorePathCall{Call: call}
+}
+
+// SentPacketHandlerRestorePathCall wrap *gomock.Call
+type SentPacketHandlerRestorePathCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *SentPacketHandlerRestorePathCall) Return() *SentPacketHandlerRestorePathCall {
+	c.Call = c.Call.Return()
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *SentPacketHandlerRestorePathCall) Do(f func(time.Time, *ackhandler.PathCongestionState)) *SentPacketHandlerRestorePathCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *SentPacketHandlerRestorePathCall) DoAndReturn(f func(time.Time, *ackhandler.PathCongestionState)) *SentPacketHandlerRestorePathCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // SendMode mocks base method.
 func (m *MockSentPacketHandler) SendMode(arg0 time.Time) ackhandler.SendMode {
 	m.ctrl.T.Helper()
@@ -458,15 +532,15 @@ func (c *SentPacketHandlerSendModeCall) DoAndReturn(f func(time.Time) ackhandler
 }
 
 // SentPacket mocks base method.
-func (m *MockSentPacketHandler) SentPacket(arg0 time.Time, arg1, arg2 protocol.PacketNumber, arg3 []ackhandler.StreamFrame, arg4 []ackhandler.Frame, arg5 protocol.EncryptionLevel, arg6 protocol.ECN, arg7 protocol.ByteCount, arg8 bool) {
+func (m *MockSentPacketHandler) SentPacket(arg0 time.Time, arg1, arg2 protocol.PacketNumber, arg3 []ackhandler.StreamFrame, arg4 []ackhandler.Frame, arg5 protocol.EncryptionLevel, arg6 protocol.ECN, arg7 protocol.ByteCount, arg8, arg9 bool) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "SentPacket", arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8)
+	m.ctrl.Call(m, "SentPacket", arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9)
 }
 
 // SentPacket indicates an expected call of SentPacket.
-func (mr *MockSentPacketHandlerMockRecorder) SentPacket(arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8 any) *SentPacketHandlerSentPacketCall {
+func (mr *MockSentPacketHandlerMockRecorder) SentPacket(arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9 any) *SentPacketHandlerSentPacketCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SentPacket", reflect.TypeOf((*MockSentPacketHandler)(nil).SentPacket), arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SentPacket", reflect.TypeOf((*MockSentPacketHandler)(nil).SentPacket), arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9)
 	return &SentPacketHandlerSentPacketCall{Call: call}
 }
 
@@ -482,13 +556,13 @@ func (c *SentPacketHandlerSentPacketCall) Return() *SentPacketHandlerSentPacketC
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *SentPacketHandlerSentPacketCall) Do(f func(time.Time, protocol.PacketNumber, protocol.PacketNumber, []ackhandler.StreamFrame, []ackhandler.Frame, protocol.EncryptionLevel, protocol.ECN, protocol.ByteCount, bool)) *SentPacketHandlerSentPacketCall {
+func (c *SentPacketHandlerSentPacketCall) Do(f func(time.Time, protocol.PacketNumber, protocol.PacketNumber, []ackhandler.StreamFrame, []ackhandler.Frame, protocol.EncryptionLevel, protocol.ECN, protocol.ByteCount, bool, bool)) *SentPacketHandlerSentPacketCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *SentPacketHandlerSentPacketCall) DoAndReturn(f func(time.Time, protocol.PacketNumber, protocol.PacketNumber, []ackhandler.StreamFrame, []ackhandler.Frame, protocol.EncryptionLevel, protocol.ECN, protocol.ByteCount, bool)) *SentPacketHandlerSentPacketCall {
+func (c *SentPacketHandlerSentPacketCall) DoAndReturn(f func(time.Time, protocol.PacketNumber, protocol.PacketNumber, []ackhandler.StreamFrame, []ackhandler.Frame, protocol.EncryptionLevel, protocol.ECN, protocol.ByteCount, bool, bool)) *SentPacketHandlerSentPacketCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
@@ -565,6 +639,80 @@ func (c *SentPacketHandlerSetMaxDatagramSizeCall) DoAndReturn(f func(protocol.By
 	return c
 }
 
+// SetPathValidated mocks base method.
+func (m *MockSentPacketHandler) SetPathValidated() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetPathValidated")
+}
+
+// SetPathValidated indicates an expected call of SetPathValidated.
+func (mr *MockSentPacketHandlerMockRecorder) SetPathValidated() *SentPacketHandlerSetPathValidatedCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPathValidated", reflect.TypeOf((*MockSentPacketHandler)(nil).SetPathValidated))
+	return &SentPacketHandlerSetPathValidatedCall{Call: call}
+}
+
+// SentPacketHandlerSetPathValidatedCall wrap *gomock.Call
+type SentPacketHandlerSetPathValidatedCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *SentPacketHandlerSetPathValidatedCall) Return() *SentPacketHandlerSetPathValidatedCall {
+	c.Call = c.Call.Return()
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *SentPacketHandlerSetPathValidatedCall) Do(f func()) *SentPacketHandlerSetPathValidatedCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *SentPacketHandlerSetPathValidatedCall) DoAndReturn(f func()) *SentPacketHandlerSetPathValidatedCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Stats mocks base method.
+func (m *MockSentPacketHandler) Stats() ackhandler.Stats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stats")
+	ret0, _ := ret[0].(ackhandler.Stats)
+	return ret0
+}
+
+// Stats indicates an expected call of Stats.
+func (mr *MockSentPacketHandlerMockRecorder) Stats() *SentPacketHandlerStatsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stats", reflect.TypeOf((*MockSentPacketHandler)(nil).Stats))
+	return &SentPacketHandlerStatsCall{Call: call}
+}
+
+// SentPacketHandlerStatsCall wrap *gomock.Call
+type SentPacketHandlerStatsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *SentPacketHandlerStatsCall) Return(arg0 ackhandler.Stats) *SentPacketHandlerStatsCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *SentPacketHandlerStatsCall) Do(f func() ackhandler.Stats) *SentPacketHandlerStatsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *SentPacketHandlerStatsCall) DoAndReturn(f func() ackhandler.Stats) *SentPacketHandlerStatsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // TimeUntilSend mocks base method.
 func (m *MockSentPacketHandler) TimeUntilSend() time.Time {
 	m.ctrl.T.Helper()
@@ -1,9 +1,9 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/quic-go/quic-go/internal/congestion (interfaces: SendAlgorithmWithDebugInfos)
+// Source: github.com/quic-go/quic-go/congestion (interfaces: SendAlgorithm)
 //
 // Generated by this command:
 //
-//	mockgen -typed -build_flags=-tags=gomock -package mocks -destination congestion.go github.com/quic-go/quic-go/internal/congestion SendAlgorithmWithDebugInfos
+//	mockgen -typed -build_flags=-tags=gomock -package mocks -destination congestion.go github.com/quic-go/quic-go/congestion SendAlgorithm
 //
 // Package mocks is a generated GoMock package.
 package mocks
@@ -16,31 +16,31 @@ import (
 	gomock "go.uber.org/mock/gomock"
 )
 
-// MockSendAlgorithmWithDebugInfos is a mock of SendAlgorithmWithDebugInfos interface.
-type MockSendAlgorithmWithDebugInfos struct {
+// MockSendAlgorithm is a mock of SendAlgorithm interface.
+type MockSendAlgorithm struct {
 	ctrl     *gomock.Controller
-	recorder *MockSendAlgorithmWithDebugInfosMockRecorder
+	recorder *MockSendAlgorithmMockRecorder
 }
 
-// MockSendAlgorithmWithDebugInfosMockRecorder is the mock recorder for MockSendAlgorithmWithDebugInfos.
-type MockSendAlgorithmWithDebugInfosMockRecorder struct {
-	mock *MockSendAlgorithmWithDebugInfos
+// MockSendAlgorithmMockRecorder is the mock recorder for MockSendAlgorithm.
+type MockSendAlgorithmMockRecorder struct {
+	mock *MockSendAlgorithm
 }
 
-// NewMockSendAlgorithmWithDebugInfos creates a new mock instance.
-func NewMockSendAlgorithmWithDebugInfos(ctrl *gomock.Controller) *MockSendAlgorithmWithDebugInfos {
-	mock := &MockSendAlgorithmWithDebugInfos{ctrl: ctrl}
-	mock.recorder = &MockSendAlgorithmWithDebugInfosMockRecorder{mock}
+// NewMockSendAlgorithm creates a new mock instance.
+func NewMockSendAlgorithm(ctrl *gomock.Controller) *MockSendAlgorithm {
+	mock := &MockSendAlgorithm{ctrl: ctrl}
+	mock.recorder = &MockSendAlgorithmMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockSendAlgorithmWithDebugInfos) EXPECT() *MockSendAlgorithmWithDebugInfosMockRecorder {
+func (m *MockSendAlgorithm) EXPECT() *MockSendAlgorithmMockRecorder {
 	return m.recorder
 }
 
 // CanSend mocks base method.
-func (m *MockSendAlgorithmWithDebugInfos) CanSend(arg0 protocol.ByteCount) bool {
+func (m *MockSendAlgorithm) CanSend(arg0 protocol.ByteCount) bool {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CanSend", arg0)
 	ret0, _ := ret[0].(bool)
@@ -48,37 +48,73 @@ func (m *MockSendAlgorithmWithDebugInfos) CanSend(arg0 protocol.ByteCount) bool
 }
 
 // CanSend indicates an expected call of CanSend.
-func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) CanSend(arg0 any) *SendAlgorithmWithDebugInfosCanSendCall {
+func (mr *MockSendAlgorithmMockRecorder) CanSend(arg0 any) *SendAlgorithmCanSendCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CanSend", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).CanSend), arg0)
-	return &SendAlgorithmWithDebugInfosCanSendCall{Call: call}
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CanSend", reflect.TypeOf((*MockSendAlgorithm)(nil).CanSend), arg0)
+	return &SendAlgorithmCanSendCall{Call: call}
 }
 
-// SendAlgorithmWithDebugInfosCanSendCall wrap *gomock.Call
-type SendAlgorithmWithDebugInfosCanSendCall struct {
+// SendAlgorithmCanSendCall wrap *gomock.Call
+type SendAlgorithmCanSendCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *SendAlgorithmWithDebugInfosCanSendCall) Return(arg0 bool) *SendAlgorithmWithDebugInfosCanSendCall {
+func (c *SendAlgorithmCanSendCall) Return(arg0 bool) *SendAlgorithmCanSendCall {
 	c.Call = c.Call.Return(arg0)
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *SendAlgorithmWithDebugInfosCanSendCall) Do(f func(protocol.ByteCount) bool) *SendAlgorithmWithDebugInfosCanSendCall {
+func (c *SendAlgorithmCanSendCall) Do(f func(protocol.ByteCount) bool) *SendAlgorithmCanSendCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *SendAlgorithmWithDebugInfosCanSendCall) DoAndReturn(f func(protocol.ByteCount) bool) *SendAlgorithmWithDebugInfosCanSendCall {
+func (c *SendAlgorithmCanSendCall) DoAndReturn(f func(protocol.ByteCount) bool) *SendAlgorithmCanSendCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// DropPacketNumberSpace mocks base method.
+func (m *MockSendAlgorithm) DropPacketNumberSpace(arg0 protocol.PacketNumberSpace) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "DropPacketNumberSpace", arg0)
+}
+
+// DropPacketNumberSpace indicates an expected call of DropPacketNumberSpace.
+func (mr *MockSendAlgorithmMockRecorder) DropPacketNumberSpace(arg0 any) *SendAlgorithmDropPacketNumberSpaceCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DropPacketNumberSpace", reflect.TypeOf((*MockSendAlgorithm)(nil).DropPacketNumberSpace), arg0)
+	return &SendAlgorithmDropPacketNumberSpaceCall{Call: call}
+}
+
+// SendAlgorithmDropPacketNumberSpaceCall wrap *gomock.Call
+type SendAlgorithmDropPacketNumberSpaceCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *SendAlgorithmDropPacketNumberSpaceCall) Return() *SendAlgorithmDropPacketNumberSpaceCall {
+	c.Call = c.Call.Return()
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *SendAlgorithmDropPacketNumberSpaceCall) Do(f func(protocol.PacketNumberSpace)) *SendAlgorithmDropPacketNumberSpaceCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *SendAlgorithmDropPacketNumberSpaceCall) DoAndReturn(f func(protocol.PacketNumberSpace)) *SendAlgorithmDropPacketNumberSpaceCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
 
 // GetCongestionWindow mocks base method.
-func (m *MockSendAlgorithmWithDebugInfos) GetCongestionWindow() protocol.ByteCount {
+func (m *MockSendAlgorithm) GetCongestionWindow() protocol.ByteCount {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetCongestionWindow")
 	ret0, _ := ret[0].(protocol.ByteCount)
@@ -86,37 +122,37 @@ func (m *MockSendAlgorithmWithDebugInfos) GetCongestionWindow() protocol.ByteCou
 }
 
 // GetCongestionWindow indicates an expected call of GetCongestionWindow.
-func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) GetCongestionWindow() *SendAlgorithmWithDebugInfosGetCongestionWindowCall {
+func (mr *MockSendAlgorithmMockRecorder) GetCongestionWindow() *SendAlgorithmGetCongestionWindowCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCongestionWindow", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).GetCongestionWindow))
-	return &SendAlgorithmWithDebugInfosGetCongestionWindowCall{Call: call}
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCongestionWindow", reflect.TypeOf((*MockSendAlgorithm)(nil).GetCongestionWindow))
+	return &SendAlgorithmGetCongestionWindowCall{Call: call}
 }
 
-// SendAlgorithmWithDebugInfosGetCongestionWindowCall wrap *gomock.Call
-type SendAlgorithmWithDebugInfosGetCongestionWindowCall struct {
+// SendAlgorithmGetCongestionWindowCall wrap *gomock.Call
+type SendAlgorithmGetCongestionWindowCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *SendAlgorithmWithDebugInfosGetCongestionWindowCall) Return(arg0 protocol.ByteCount) *SendAlgorithmWithDebugInfosGetCongestionWindowCall {
+func (c *SendAlgorithmGetCongestionWindowCall) Return(arg0 protocol.ByteCount) *SendAlgorithmGetCongestionWindowCall {
 	c.Call = c.Call.Return(arg0)
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *SendAlgorithmWithDebugInfosGetCongestionWindowCall) Do(f func() protocol.ByteCount) *SendAlgorithmWithDebugInfosGetCongestionWindowCall {
+func (c *SendAlgorithmGetCongestionWindowCall) Do(f func() protocol.ByteCount) *SendAlgorithmGetCongestionWindowCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *SendAlgorithmWithDebugInfosGetCongestionWindowCall) DoAndReturn(f func() protocol.ByteCount) *SendAlgorithmWithDebugInfosGetCongestionWindowCall {
+func (c *SendAlgorithmGetCongestionWindowCall) DoAndReturn(f func() protocol.ByteCount) *SendAlgorithmGetCongestionWindowCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
 
 // HasPacingBudget mocks base method.
-func (m *MockSendAlgorithmWithDebugInfos) HasPacingBudget(arg0 time.Time) bool {
+func (m *MockSendAlgorithm) HasPacingBudget(arg0 time.Time) bool {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "HasPacingBudget", arg0)
 	ret0, _ := ret[0].(bool)
@@ -124,329 +160,325 @@ func (m *MockSendAlgorithmWithDebugInfos) HasPacingBudget(arg0 time.Time) bool {
 }
 
 // HasPacingBudget indicates an expected call of HasPacingBudget.
-func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) HasPacingBudget(arg0 any) *SendAlgorithmWithDebugInfosHasPacingBudgetCall {
+func (mr *MockSendAlgorithmMockRecorder) HasPacingBudget(arg0 any) *SendAlgorithmHasPacingBudgetCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasPacingBudget", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).HasPacingBudget), arg0)
-	return &SendAlgorithmWithDebugInfosHasPacingBudgetCall{Call: call}
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasPacingBudget", reflect.TypeOf((*MockSendAlgorithm)(nil).HasPacingBudget), arg0)
+	return &SendAlgorithmHasPacingBudgetCall{Call: call}
 }
 
-// SendAlgorithmWithDebugInfosHasPacingBudgetCall wrap *gomock.Call
-type SendAlgorithmWithDebugInfosHasPacingBudgetCall struct {
+// SendAlgorithmHasPacingBudgetCall wrap *gomock.Call
+type SendAlgorithmHasPacingBudgetCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *SendAlgorithmWithDebugInfosHasPacingBudgetCall) Return(arg0 bool) *SendAlgorithmWithDebugInfosHasPacingBudgetCall {
+func (c *SendAlgorithmHasPacingBudgetCall) Return(arg0 bool) *SendAlgorithmHasPacingBudgetCall {
 	c.Call = c.Call.Return(arg0)
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *SendAlgorithmWithDebugInfosHasPacingBudgetCall) Do(f func(time.Time) bool) *SendAlgorithmWithDebugInfosHasPacingBudgetCall {
+func (c *SendAlgorithmHasPacingBudgetCall) Do(f func(time.Time) bool) *SendAlgorithmHasPacingBudgetCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *SendAlgorithmWithDebugInfosHasPacingBudgetCall) DoAndReturn(f func(time.Time) bool) *SendAlgorithmWithDebugInfosHasPacingBudgetCall {
+func (c *SendAlgorithmHasPacingBudgetCall) DoAndReturn(f func(time.Time) bool) *SendAlgorithmHasPacingBudgetCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
 
-// InRecovery mocks base method.
-func (m *MockSendAlgorithmWithDebugInfos) InRecovery() bool {
+// MaybeExitSlowStart mocks base method.
+func (m *MockSendAlgorithm) MaybeExitSlowStart() {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "InRecovery")
-	ret0, _ := ret[0].(bool)
-	return ret0
+	m.ctrl.Call(m, "MaybeExitSlowStart")
 }
 
-// InRecovery indicates an expected call of InRecovery.
-func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) InRecovery() *SendAlgorithmWithDebugInfosInRecoveryCall {
+// MaybeExitSlowStart indicates an expected call of MaybeExitSlowStart.
+func (mr *MockSendAlgorithmMockRecorder) MaybeExitSlowStart() *SendAlgorithmMaybeExitSlowStartCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InRecovery", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).InRecovery))
-	return &SendAlgorithmWithDebugInfosInRecoveryCall{Call: call}
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaybeExitSlowStart", reflect.TypeOf((*MockSendAlgorithm)(nil).MaybeExitSlowStart))
+	return &SendAlgorithmMaybeExitSlowStartCall{Call: call}
 }
 
-// SendAlgorithmWithDebugInfosInRecoveryCall wrap *gomock.Call
-type SendAlgorithmWithDebugInfosInRecoveryCall struct {
+// SendAlgorithmMaybeExitSlowStartCall wrap *gomock.Call
+type SendAlgorithmMaybeExitSlowStartCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *SendAlgorithmWithDebugInfosInRecoveryCall) Return(arg0 bool) *SendAlgorithmWithDebugInfosInRecoveryCall {
-	c.Call = c.Call.Return(arg0)
+func (c *SendAlgorithmMaybeExitSlowStartCall) Return() *SendAlgorithmMaybeExitSlowStartCall {
+	c.Call = c.Call.Return()
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *SendAlgorithmWithDebugInfosInRecoveryCall) Do(f func() bool) *SendAlgorithmWithDebugInfosInRecoveryCall {
+func (c *SendAlgorithmMaybeExitSlowStartCall) Do(f func()) *SendAlgorithmMaybeExitSlowStartCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *SendAlgorithmWithDebugInfosInRecoveryCall) DoAndReturn(f func() bool) *SendAlgorithmWithDebugInfosInRecoveryCall {
+func (c *SendAlgorithmMaybeExitSlowStartCall) DoAndReturn(f func()) *SendAlgorithmMaybeExitSlowStartCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
 
-// InSlowStart mocks base method.
-func (m *MockSendAlgorithmWithDebugInfos) InSlowStart() bool {
+// OnCongestionEvent mocks base method.
+func (m *MockSendAlgorithm) OnCongestionEvent(arg0 protocol.PacketNumberSpace, arg1 protocol.PacketNumber, arg2, arg3 protocol.ByteCount) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "InSlowStart")
-	ret0, _ := ret[0].(bool)
-	return ret0
+	m.ctrl.Call(m, "OnCongestionEvent", arg0, arg1, arg2, arg3)
 }
 
-// InSlowStart indicates an expected call of InSlowStart.
-func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) InSlowStart() *SendAlgorithmWithDebugInfosInSlowStartCall {
+// OnCongestionEvent indicates an expected call of OnCongestionEvent.
+func (mr *MockSendAlgorithmMockRecorder) OnCongestionEvent(arg0, arg1, arg2, arg3 any) *SendAlgorithmOnCongestionEventCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InSlowStart", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).InSlowStart))
-	return &SendAlgorithmWithDebugInfosInSlowStartCall{Call: call}
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnCongestionEvent", reflect.TypeOf((*MockSendAlgorithm)(nil).OnCongestionEvent), arg0, arg1, arg2, arg3)
+	return &SendAlgorithmOnCongestionEventCall{Call: call}
 }
 
-// SendAlgorithmWithDebugInfosInSlowStartCall wrap *gomock.Call
-type SendAlgorithmWithDebugInfosInSlowStartCall struct {
+// SendAlgorithmOnCongestionEventCall wrap *gomock.Call
+type SendAlgorithmOnCongestionEventCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *SendAlgorithmWithDebugInfosInSlowStartCall) Return(arg0 bool) *SendAlgorithmWithDebugInfosInSlowStartCall {
-	c.Call = c.Call.Return(arg0)
+func (c *SendAlgorithmOnCongestionEventCall) Return() *SendAlgorithmOnCongestionEventCall {
+	c.Call = c.Call.Return()
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *SendAlgorithmWithDebugInfosInSlowStartCall) Do(f func() bool) *SendAlgorithmWithDebugInfosInSlowStartCall {
+func (c *SendAlgorithmOnCongestionEventCall) Do(f func(protocol.PacketNumberSpace, protocol.PacketNumber, protocol.ByteCount, protocol.ByteCount)) *SendAlgorithmOnCongestionEventCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *SendAlgorithmWithDebugInfosInSlowStartCall) DoAndReturn(f func() bool) *SendAlgorithmWithDebugInfosInSlowStartCall {
+func (c *SendAlgorithmOnCongestionEventCall) DoAndReturn(f func(protocol.PacketNumberSpace, protocol.PacketNumber, protocol.ByteCount, protocol.ByteCount)) *SendAlgorithmOnCongestionEventCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
 
-// MaybeExitSlowStart mocks base method.
-func (m *MockSendAlgorithmWithDebugInfos) MaybeExitSlowStart() {
+// OnECNCongestionEvent mocks base method.
+func (m *MockSendAlgorithm) OnECNCongestionEvent(arg0 protocol.PacketNumber, arg1 int64, arg2 protocol.ByteCount) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "MaybeExitSlowStart")
+	m.ctrl.Call(m, "OnECNCongestionEvent", arg0, arg1, arg2)
 }
 
-// MaybeExitSlowStart indicates an expected call of MaybeExitSlowStart.
-func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) MaybeExitSlowStart() *SendAlgorithmWithDebugInfosMaybeExitSlowStartCall {
+// OnECNCongestionEvent indicates an expected call of OnECNCongestionEvent.
+func (mr *MockSendAlgorithmMockRecorder) OnECNCongestionEvent(arg0, arg1, arg2 any) *SendAlgorithmOnECNCongestionEventCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaybeExitSlowStart", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).MaybeExitSlowStart))
-	return &SendAlgorithmWithDebugInfosMaybeExitSlowStartCall{Call: call}
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnECNCongestionEvent", reflect.TypeOf((*MockSendAlgorithm)(nil).OnECNCongestionEvent), arg0, arg1, arg2)
+	return &SendAlgorithmOnECNCongestionEventCall{Call: call}
 }
 
-// SendAlgorithmWithDebugInfosMaybeExitSlowStartCall wrap *gomock.Call
-type SendAlgorithmWithDebugInfosMaybeExitSlowStartCall struct {
+// SendAlgorithmOnECNCongestionEventCall wrap *gomock.Call
+type SendAlgorithmOnECNCongestionEventCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *SendAlgorithmWithDebugInfosMaybeExitSlowStartCall) Return() *SendAlgorithmWithDebugInfosMaybeExitSlowStartCall {
+func (c *SendAlgorithmOnECNCongestionEventCall) Return() *SendAlgorithmOnECNCongestionEventCall {
 	c.Call = c.Call.Return()
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *SendAlgorithmWithDebugInfosMaybeExitSlowStartCall) Do(f func()) *SendAlgorithmWithDebugInfosMaybeExitSlowStartCall {
+func (c *SendAlgorithmOnECNCongestionEventCall) Do(f func(protocol.PacketNumber, int64, protocol.ByteCount)) *SendAlgorithmOnECNCongestionEventCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *SendAlgorithmWithDebugInfosMaybeExitSlowStartCall) DoAndReturn(f func()) *SendAlgorithmWithDebugInfosMaybeExitSlowStartCall {
+func (c *SendAlgorithmOnECNCongestionEventCall) DoAndReturn(f func(protocol.PacketNumber, int64, protocol.ByteCount)) *SendAlgorithmOnECNCongestionEventCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
 
-// OnCongestionEvent mocks base method.
-func (m *MockSendAlgorithmWithDebugInfos) OnCongestionEvent(arg0 protocol.PacketNumber, arg1, arg2 protocol.ByteCount) {
+// OnPacketAcked mocks base method.
+func (m *MockSendAlgorithm) OnPacketAcked(arg0 protocol.PacketNumberSpace, arg1 protocol.PacketNumber, arg2, arg3 protocol.ByteCount, arg4 time.Time) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "OnCongestionEvent", arg0, arg1, arg2)
+	m.ctrl.Call(m, "OnPacketAcked", arg0, arg1, arg2, arg3, arg4)
 }
 
-// OnCongestionEvent indicates an expected call of OnCongestionEvent.
-func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) OnCongestionEvent(arg0, arg1, arg2 any) *SendAlgorithmWithDebugInfosOnCongestionEventCall {
+// OnPacketAcked indicates an expected call of OnPacketAcked.
+func (mr *MockSendAlgorithmMockRecorder) OnPacketAcked(arg0, arg1, arg2, arg3, arg4 any) *SendAlgorithmOnPacketAckedCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnCongestionEvent", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).OnCongestionEvent), arg0, arg1, arg2)
-	return &SendAlgorithmWithDebugInfosOnCongestionEventCall{Call: call}
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnPacketAcked", reflect.TypeOf((*MockSendAlgorithm)(nil).OnPacketAcked), arg0, arg1, arg2, arg3, arg4)
+	return &SendAlgorithmOnPacketAckedCall{Call: call}
 }
 
-// SendAlgorithmWithDebugInfosOnCongestionEventCall wrap *gomock.Call
-type SendAlgorithmWithDebugInfosOnCongestionEventCall struct {
+// SendAlgorithmOnPacketAckedCall wrap *gomock.Call
+type SendAlgorithmOnPacketAckedCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *SendAlgorithmWithDebugInfosOnCongestionEventCall) Return() *SendAlgorithmWithDebugInfosOnCongestionEventCall {
+func (c *SendAlgorithmOnPacketAckedCall) Return() *SendAlgorithmOnPacketAckedCall {
 	c.Call = c.Call.Return()
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *SendAlgorithmWithDebugInfosOnCongestionEventCall) Do(f func(protocol.PacketNumber, protocol.ByteCount, protocol.ByteCount)) *SendAlgorithmWithDebugInfosOnCongestionEventCall {
+func (c *SendAlgorithmOnPacketAckedCall) Do(f func(protocol.PacketNumberSpace, protocol.PacketNumber, protocol.ByteCount, protocol.ByteCount, time.Time)) *SendAlgorithmOnPacketAckedCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *SendAlgorithmWithDebugInfosOnCongestionEventCall) DoAndReturn(f func(protocol.PacketNumber, protocol.ByteCount, protocol.ByteCount)) *SendAlgorithmWithDebugInfosOnCongestionEventCall {
+func (c *SendAlgorithmOnPacketAckedCall) DoAndReturn(f func(protocol.PacketNumberSpace, protocol.PacketNumber, protocol.ByteCount, protocol.ByteCount, time.Time)) *SendAlgorithmOnPacketAckedCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
 
-// OnPacketAcked mocks base method.
-func (m *MockSendAlgorithmWithDebugInfos) OnPacketAcked(arg0 protocol.PacketNumber, arg1, arg2 protocol.ByteCount, arg3 time.Time) {
+// OnPacketSent mocks base method.
+func (m *MockSendAlgorithm) OnPacketSent(arg0 time.Time, arg1 protocol.ByteCount, arg2 protocol.PacketNumberSpace, arg3 protocol.PacketNumber, arg4 protocol.ByteCount, arg5 bool) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "OnPacketAcked", arg0, arg1, arg2, arg3)
+	m.ctrl.Call(m, "OnPacketSent", arg0, arg1, arg2, arg3, arg4, arg5)
 }
 
-// OnPacketAcked indicates an expected call of OnPacketAcked.
-func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) OnPacketAcked(arg0, arg1, arg2, arg3 any) *SendAlgorithmWithDebugInfosOnPacketAckedCall {
+// OnPacketSent indicates an expected call of OnPacketSent.
+func (mr *MockSendAlgorithmMockRecorder) OnPacketSent(arg0, arg1, arg2, arg3, arg4, arg5 any) *SendAlgorithmOnPacketSentCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnPacketAcked", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).OnPacketAcked), arg0, arg1, arg2, arg3)
-	return &SendAlgorithmWithDebugInfosOnPacketAckedCall{Call: call}
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnPacketSent", reflect.TypeOf((*MockSendAlgorithm)(nil).OnPacketSent), arg0, arg1, arg2, arg3, arg4, arg5)
+	return &SendAlgorithmOnPacketSentCall{Call: call}
 }
 
-// SendAlgorithmWithDebugInfosOnPacketAckedCall wrap *gomock.Call
-type SendAlgorithmWithDebugInfosOnPacketAckedCall struct {
+// SendAlgorithmOnPacketSentCall wrap *gomock.Call
+type SendAlgorithmOnPacketSentCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *SendAlgorithmWithDebugInfosOnPacketAckedCall) Return() *SendAlgorithmWithDebugInfosOnPacketAckedCall {
+func (c *SendAlgorithmOnPacketSentCall) Return() *SendAlgorithmOnPacketSentCall {
 	c.Call = c.Call.Return()
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *SendAlgorithmWithDebugInfosOnPacketAckedCall) Do(f func(protocol.PacketNumber, protocol.ByteCount, protocol.ByteCount, time.Time)) *SendAlgorithmWithDebugInfosOnPacketAckedCall {
+func (c *SendAlgorithmOnPacketSentCall) Do(f func(time.Time, protocol.ByteCount, protocol.PacketNumberSpace, protocol.PacketNumber, protocol.ByteCount, bool)) *SendAlgorithmOnPacketSentCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *SendAlgorithmWithDebugInfosOnPacketAckedCall) DoAndReturn(f func(protocol.PacketNumber, protocol.ByteCount, protocol.ByteCount, time.Time)) *SendAlgorithmWithDebugInfosOnPacketAckedCall {
+func (c *SendAlgorithmOnPacketSentCall) DoAndReturn(f func(time.Time, protocol.ByteCount, protocol.PacketNumberSpace, protocol.PacketNumber, protocol.ByteCount, bool)) *SendAlgorithmOnPacketSentCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
 
-// OnPacketSent mocks base method.
-func (m *MockSendAlgorithmWithDebugInfos) OnPacketSent(arg0 time.Time, arg1 protocol.ByteCount, arg2 protocol.PacketNumber, arg3 protocol.ByteCount, arg4 bool) {
+// OnPersistentCongestion mocks base method.
+func (m *MockSendAlgorithm) OnPersistentCongestion() {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "OnPacketSent", arg0, arg1, arg2, arg3, arg4)
+	m.ctrl.Call(m, "OnPersistentCongestion")
 }
 
-// OnPacketSent indicates an expected call of OnPacketSent.
-func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) OnPacketSent(arg0, arg1, arg2, arg3, arg4 any) *SendAlgorithmWithDebugInfosOnPacketSentCall {
+// OnPersistentCongestion indicates an expected call of OnPersistentCongestion.
+func (mr *MockSendAlgorithmMockRecorder) OnPersistentCongestion() *SendAlgorithmOnPersistentCongestionCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnPacketSent", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).OnPacketSent), arg0, arg1, arg2, arg3, arg4)
-	return &SendAlgorithmWithDebugInfosOnPacketSentCall{Call: call}
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnPersistentCongestion", reflect.TypeOf((*MockSendAlgorithm)(nil).OnPersistentCongestion))
+	return &SendAlgorithmOnPersistentCongestionCall{Call: call}
 }
 
-// SendAlgorithmWithDebugInfosOnPacketSentCall wrap *gomock.Call
-type SendAlgorithmWithDebugInfosOnPacketSentCall struct {
+// SendAlgorithmOnPersistentCongestionCall wrap *gomock.Call
+type SendAlgorithmOnPersistentCongestionCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *SendAlgorithmWithDebugInfosOnPacketSentCall) Return() *SendAlgorithmWithDebugInfosOnPacketSentCall {
+func (c *SendAlgorithmOnPersistentCongestionCall) Return() *SendAlgorithmOnPersistentCongestionCall {
 	c.Call = c.Call.Return()
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *SendAlgorithmWithDebugInfosOnPacketSentCall) Do(f func(time.Time, protocol.ByteCount, protocol.PacketNumber, protocol.ByteCount, bool)) *SendAlgorithmWithDebugInfosOnPacketSentCall {
+func (c *SendAlgorithmOnPersistentCongestionCall) Do(f func()) *SendAlgorithmOnPersistentCongestionCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *SendAlgorithmWithDebugInfosOnPacketSentCall) DoAndReturn(f func(time.Time, protocol.ByteCount, protocol.PacketNumber, protocol.ByteCount, bool)) *SendAlgorithmWithDebugInfosOnPacketSentCall {
+func (c *SendAlgorithmOnPersistentCongestionCall) DoAndReturn(f func()) *SendAlgorithmOnPersistentCongestionCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
 
 // OnRetransmissionTimeout mocks base method.
-func (m *MockSendAlgorithmWithDebugInfos) OnRetransmissionTimeout(arg0 bool) {
+func (m *MockSendAlgorithm) OnRetransmissionTimeout(arg0 bool) {
 	m.ctrl.T.Helper()
 	m.ctrl.Call(m, "OnRetransmissionTimeout", arg0)
 }
 
 // OnRetransmissionTimeout indicates an expected call of OnRetransmissionTimeout.
-func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) OnRetransmissionTimeout(arg0 any) *SendAlgorithmWithDebugInfosOnRetransmissionTimeoutCall {
+func (mr *MockSendAlgorithmMockRecorder) OnRetransmissionTimeout(arg0 any) *SendAlgorithmOnRetransmissionTimeoutCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnRetransmissionTimeout", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).OnRetransmissionTimeout), arg0)
-	return &SendAlgorithmWithDebugInfosOnRetransmissionTimeoutCall{Call: call}
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnRetransmissionTimeout", reflect.TypeOf((*MockSendAlgorithm)(nil).OnRetransmissionTimeout), arg0)
+	return &SendAlgorithmOnRetransmissionTimeoutCall{Call: call}
 }
 
-// SendAlgorithmWithDebugInfosOnRetransmissionTimeoutCall wrap *gomock.Call
-type SendAlgorithmWithDebugInfosOnRetransmissionTimeoutCall struct {
+// SendAlgorithmOnRetransmissionTimeoutCall wrap *gomock.Call
+type SendAlgorithmOnRetransmissionTimeoutCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *SendAlgorithmWithDebugInfosOnRetransmissionTimeoutCall) Return() *SendAlgorithmWithDebugInfosOnRetransmissionTimeoutCall {
+func (c *SendAlgorithmOnRetransmissionTimeoutCall) Return() *SendAlgorithmOnRetransmissionTimeoutCall {
 	c.Call = c.Call.Return()
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *SendAlgorithmWithDebugInfosOnRetransmissionTimeoutCall) Do(f func(bool)) *SendAlgorithmWithDebugInfosOnRetransmissionTimeoutCall {
+func (c *SendAlgorithmOnRetransmissionTimeoutCall) Do(f func(bool)) *SendAlgorithmOnRetransmissionTimeoutCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *SendAlgorithmWithDebugInfosOnRetransmissionTimeoutCall) DoAndReturn(f func(bool)) *SendAlgorithmWithDebugInfosOnRetransmissionTimeoutCall {
+func (c *SendAlgorithmOnRetransmissionTimeoutCall) DoAndReturn(f func(bool)) *SendAlgorithmOnRetransmissionTimeoutCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
 
 // SetMaxDatagramSize mocks base method.
-func (m *MockSendAlgorithmWithDebugInfos) SetMaxDatagramSize(arg0 protocol.ByteCount) {
+func (m *MockSendAlgorithm) SetMaxDatagramSize(arg0 protocol.ByteCount) {
 	m.ctrl.T.Helper()
 	m.ctrl.Call(m, "SetMaxDatagramSize", arg0)
 }
 
 // SetMaxDatagramSize indicates an expected call of SetMaxDatagramSize.
-func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) SetMaxDatagramSize(arg0 any) *SendAlgorithmWithDebugInfosSetMaxDatagramSizeCall {
+func (mr *MockSendAlgorithmMockRecorder) SetMaxDatagramSize(arg0 any) *SendAlgorithmSetMaxDatagramSizeCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMaxDatagramSize", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).SetMaxDatagramSize), arg0)
-	return &SendAlgorithmWithDebugInfosSetMaxDatagramSizeCall{Call: call}
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMaxDatagramSize", reflect.TypeOf((*MockSendAlgorithm)(nil).SetMaxDatagramSize), arg0)
+	return &SendAlgorithmSetMaxDatagramSizeCall{Call: call}
 }
 
-// SendAlgorithmWithDebugInfosSetMaxDatagramSizeCall wrap *gomock.Call
-type SendAlgorithmWithDebugInfosSetMaxDatagramSizeCall struct {
+// SendAlgorithmSetMaxDatagramSizeCall wrap *gomock.Call
+type SendAlgorithmSetMaxDatagramSizeCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *SendAlgorithmWithDebugInfosSetMaxDatagramSizeCall) Return() *SendAlgorithmWithDebugInfosSetMaxDatagramSizeCall {
+func (c *SendAlgorithmSetMaxDatagramSizeCall) Return() *SendAlgorithmSetMaxDatagramSizeCall {
 	c.Call = c.Call.Return()
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *SendAlgorithmWithDebugInfosSetMaxDatagramSizeCall) Do(f func(protocol.ByteCount)) *SendAlgorithmWithDebugInfosSetMaxDatagramSizeCall {
+func (c *SendAlgorithmSetMaxDatagramSizeCall) Do(f func(protocol.ByteCount)) *SendAlgorithmSetMaxDatagramSizeCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *SendAlgorithmWithDebugInfosSetMaxDatagramSizeCall) DoAndReturn(f func(protocol.ByteCount)) *SendAlgorithmWithDebugInfosSetMaxDatagramSizeCall {
+func (c *SendAlgorithmSetMaxDatagramSizeCall) DoAndReturn(f func(protocol.ByteCount)) *SendAlgorithmSetMaxDatagramSizeCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
 
 // TimeUntilSend mocks base method.
-func (m *MockSendAlgorithmWithDebugInfos) TimeUntilSend(arg0 protocol.ByteCount) time.Time {
+func (m *MockSendAlgorithm) TimeUntilSend(arg0 protocol.ByteCount) time.Time {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "TimeUntilSend", arg0)
 	ret0, _ := ret[0].(time.Time)
@@ -454,31 +486,31 @@ func (m *MockSendAlgorithmWithDebugInfos) TimeUntilSend(arg0 protocol.ByteCount)
 }
 
 // TimeUntilSend indicates an expected call of TimeUntilSend.
-func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) TimeUntilSend(arg0 any) *SendAlgorithmWithDebugInfosTimeUntilSendCall {
+func (mr *MockSendAlgorithmMockRecorder) TimeUntilSend(arg0 any) *SendAlgorithmTimeUntilSendCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TimeUntilSend", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).TimeUntilSend), arg0)
-	return &SendAlgorithmWithDebugInfosTimeUntilSendCall{Call: call}
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TimeUntilSend", reflect.TypeOf((*MockSendAlgorithm)(nil).TimeUntilSend), arg0)
+	return &SendAlgorithmTimeUntilSendCall{Call: call}
 }
 
-// SendAlgorithmWithDebugInfosTimeUntilSendCall wrap *gomock.Call
-type SendAlgorithmWithDebugInfosTimeUntilSendCall struct {
+// SendAlgorithmTimeUntilSendCall wrap *gomock.Call
+type SendAlgorithmTimeUntilSendCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *SendAlgorithmWithDebugInfosTimeUntilSendCall) Return(arg0 time.Time) *SendAlgorithmWithDebugInfosTimeUntilSendCall {
+func (c *SendAlgorithmTimeUntilSendCall) Return(arg0 time.Time) *SendAlgorithmTimeUntilSendCall {
 	c.Call = c.Call.Return(arg0)
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *SendAlgorithmWithDebugInfosTimeUntilSendCall) Do(f func(protocol.ByteCount) time.Time) *SendAlgorithmWithDebugInfosTimeUntilSendCall {
+func (c *SendAlgorithmTimeUntilSendCall) Do(f func(protocol.ByteCount) time.Time) *SendAlgorithmTimeUntilSendCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *SendAlgorithmWithDebugInfosTimeUntilSendCall) DoAndReturn(f func(protocol.ByteCount) time.Time) *SendAlgorithmWithDebugInfosTimeUntilSendCall {
+func (c *SendAlgorithmTimeUntilSendCall) DoAndReturn(f func(protocol.ByteCount) time.Time) *SendAlgorithmTimeUntilSendCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
@@ -11,6 +11,7 @@ package mocks
 import (
 	reflect "reflect"
 
+	flowcontrol "github.com/quic-go/quic-go/internal/flowcontrol"
 	protocol "github.com/quic-go/quic-go/internal/protocol"
 	gomock "go.uber.org/mock/gomock"
 )
@@ -263,6 +264,44 @@ func (c *ConnectionFlowControllerSendWindowSizeCall) DoAndReturn(f func() protoc
 	return c
 }
 
+// Stats mocks base method.
+func (m *MockConnectionFlowController) Stats() flowcontrol.Stats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stats")
+	ret0, _ := ret[0].(flowcontrol.Stats)
+	return ret0
+}
+
+// Stats indicates an expected call of Stats.
+func (mr *MockConnectionFlowControllerMockRecorder) Stats() *ConnectionFlowControllerStatsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stats", reflect.TypeOf((*MockConnectionFlowController)(nil).Stats))
+	return &ConnectionFlowControllerStatsCall{Call: call}
+}
+
+// ConnectionFlowControllerStatsCall wrap *gomock.Call
+type ConnectionFlowControllerStatsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *ConnectionFlowControllerStatsCall) Return(arg0 flowcontrol.Stats) *ConnectionFlowControllerStatsCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *ConnectionFlowControllerStatsCall) Do(f func() flowcontrol.Stats) *ConnectionFlowControllerStatsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *ConnectionFlowControllerStatsCall) DoAndReturn(f func() flowcontrol.Stats) *ConnectionFlowControllerStatsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // UpdateSendWindow mocks base method.
 func (m *MockConnectionFlowController) UpdateSendWindow(arg0 protocol.ByteCount) {
 	m.ctrl.T.Helper()
@@ -98,6 +98,21 @@ func NewMockConnectionTracer(ctrl *gomock.Controller) (*logging.ConnectionTracer
 		ECNStateUpdated: func(state logging.ECNState, trigger logging.ECNStateTrigger) {
 			t.ECNStateUpdated(state, trigger)
 		},
+		ECNCongestionWindowReduced: func(ceMarked int64, congestionWindow logging.ByteCount) {
+			t.ECNCongestionWindowReduced(ceMarked, congestionWindow)
+		},
+		DetectedPersistentCongestion: func(congestionWindow logging.ByteCount) {
+			t.DetectedPersistentCongestion(congestionWindow)
+		},
+		UpdatedPath: func(local, remote net.Addr) {
+			t.UpdatedPath(local, remote)
+		},
+		StartedPathValidation: func(remote net.Addr) {
+			t.StartedPathValidation(remote)
+		},
+		CompletedPathValidation: func(remote net.Addr, success bool) {
+			t.CompletedPathValidation(remote, success)
+		},
 		Close: func() {
 			t.Close()
 		},
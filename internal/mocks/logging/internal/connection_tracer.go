@@ -187,6 +187,42 @@ func (c *ConnectionTracerClosedConnectionCall) DoAndReturn(f func(error)) *Conne
 	return c
 }
 
+// CompletedPathValidation mocks base method.
+func (m *MockConnectionTracer) CompletedPathValidation(arg0 net.Addr, arg1 bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "CompletedPathValidation", arg0, arg1)
+}
+
+// CompletedPathValidation indicates an expected call of CompletedPathValidation.
+func (mr *MockConnectionTracerMockRecorder) CompletedPathValidation(arg0, arg1 any) *ConnectionTracerCompletedPathValidationCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompletedPathValidation", reflect.TypeOf((*MockConnectionTracer)(nil).CompletedPathValidation), arg0, arg1)
+	return &ConnectionTracerCompletedPathValidationCall{Call: call}
+}
+
+// ConnectionTracerCompletedPathValidationCall wrap *gomock.Call
+type ConnectionTracerCompletedPathValidationCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *ConnectionTracerCompletedPathValidationCall) Return() *ConnectionTracerCompletedPathValidationCall {
+	c.Call = c.Call.Return()
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *ConnectionTracerCompletedPathValidationCall) Do(f func(net.Addr, bool)) *ConnectionTracerCompletedPathValidationCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *ConnectionTracerCompletedPathValidationCall) DoAndReturn(f func(net.Addr, bool)) *ConnectionTracerCompletedPathValidationCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // Debug mocks base method.
 func (m *MockConnectionTracer) Debug(arg0, arg1 string) {
 	m.ctrl.T.Helper()
@@ -223,6 +259,42 @@ func (c *ConnectionTracerDebugCall) DoAndReturn(f func(string, string)) *Connect
 	return c
 }
 
+// DetectedPersistentCongestion mocks base method.
+func (m *MockConnectionTracer) DetectedPersistentCongestion(arg0 protocol.ByteCount) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "DetectedPersistentCongestion", arg0)
+}
+
+// DetectedPersistentCongestion indicates an expected call of DetectedPersistentCongestion.
+func (mr *MockConnectionTracerMockRecorder) DetectedPersistentCongestion(arg0 any) *ConnectionTracerDetectedPersistentCongestionCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DetectedPersistentCongestion", reflect.TypeOf((*MockConnectionTracer)(nil).DetectedPersistentCongestion), arg0)
+	return &ConnectionTracerDetectedPersistentCongestionCall{Call: call}
+}
+
+// ConnectionTracerDetectedPersistentCongestionCall wrap *gomock.Call
+type ConnectionTracerDetectedPersistentCongestionCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *ConnectionTracerDetectedPersistentCongestionCall) Return() *ConnectionTracerDetectedPersistentCongestionCall {
+	c.Call = c.Call.Return()
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *ConnectionTracerDetectedPersistentCongestionCall) Do(f func(protocol.ByteCount)) *ConnectionTracerDetectedPersistentCongestionCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *ConnectionTracerDetectedPersistentCongestionCall) DoAndReturn(f func(protocol.ByteCount)) *ConnectionTracerDetectedPersistentCongestionCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // DroppedEncryptionLevel mocks base method.
 func (m *MockConnectionTracer) DroppedEncryptionLevel(arg0 protocol.EncryptionLevel) {
 	m.ctrl.T.Helper()
@@ -331,6 +403,42 @@ func (c *ConnectionTracerDroppedPacketCall) DoAndReturn(f func(logging.PacketTyp
 	return c
 }
 
+// ECNCongestionWindowReduced mocks base method.
+func (m *MockConnectionTracer) ECNCongestionWindowReduced(arg0 int64, arg1 protocol.ByteCount) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ECNCongestionWindowReduced", arg0, arg1)
+}
+
+// ECNCongestionWindowReduced indicates an expected call of ECNCongestionWindowReduced.
+func (mr *MockConnectionTracerMockRecorder) ECNCongestionWindowReduced(arg0, arg1 any) *ConnectionTracerECNCongestionWindowReducedCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ECNCongestionWindowReduced", reflect.TypeOf((*MockConnectionTracer)(nil).ECNCongestionWindowReduced), arg0, arg1)
+	return &ConnectionTracerECNCongestionWindowReducedCall{Call: call}
+}
+
+// ConnectionTracerECNCongestionWindowReducedCall wrap *gomock.Call
+type ConnectionTracerECNCongestionWindowReducedCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *ConnectionTracerECNCongestionWindowReducedCall) Return() *ConnectionTracerECNCongestionWindowReducedCall {
+	c.Call = c.Call.Return()
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *ConnectionTracerECNCongestionWindowReducedCall) Do(f func(int64, protocol.ByteCount)) *ConnectionTracerECNCongestionWindowReducedCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *ConnectionTracerECNCongestionWindowReducedCall) DoAndReturn(f func(int64, protocol.ByteCount)) *ConnectionTracerECNCongestionWindowReducedCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // ECNStateUpdated mocks base method.
 func (m *MockConnectionTracer) ECNStateUpdated(arg0 logging.ECNState, arg1 logging.ECNStateTrigger) {
 	m.ctrl.T.Helper()
@@ -907,6 +1015,42 @@ func (c *ConnectionTracerStartedConnectionCall) DoAndReturn(f func(net.Addr, net
 	return c
 }
 
+// StartedPathValidation mocks base method.
+func (m *MockConnectionTracer) StartedPathValidation(arg0 net.Addr) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "StartedPathValidation", arg0)
+}
+
+// StartedPathValidation indicates an expected call of StartedPathValidation.
+func (mr *MockConnectionTracerMockRecorder) StartedPathValidation(arg0 any) *ConnectionTracerStartedPathValidationCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartedPathValidation", reflect.TypeOf((*MockConnectionTracer)(nil).StartedPathValidation), arg0)
+	return &ConnectionTracerStartedPathValidationCall{Call: call}
+}
+
+// ConnectionTracerStartedPathValidationCall wrap *gomock.Call
+type ConnectionTracerStartedPathValidationCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *ConnectionTracerStartedPathValidationCall) Return() *ConnectionTracerStartedPathValidationCall {
+	c.Call = c.Call.Return()
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *ConnectionTracerStartedPathValidationCall) Do(f func(net.Addr)) *ConnectionTracerStartedPathValidationCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *ConnectionTracerStartedPathValidationCall) DoAndReturn(f func(net.Addr)) *ConnectionTracerStartedPathValidationCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // UpdatedCongestionState mocks base method.
 func (m *MockConnectionTracer) UpdatedCongestionState(arg0 logging.CongestionState) {
 	m.ctrl.T.Helper()
@@ -1086,3 +1230,39 @@ func (c *ConnectionTracerUpdatedPTOCountCall) DoAndReturn(f func(uint32)) *Conne
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
+
+// UpdatedPath mocks base method.
+func (m *MockConnectionTracer) UpdatedPath(arg0, arg1 net.Addr) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UpdatedPath", arg0, arg1)
+}
+
+// UpdatedPath indicates an expected call of UpdatedPath.
+func (mr *MockConnectionTracerMockRecorder) UpdatedPath(arg0, arg1 any) *ConnectionTracerUpdatedPathCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatedPath", reflect.TypeOf((*MockConnectionTracer)(nil).UpdatedPath), arg0, arg1)
+	return &ConnectionTracerUpdatedPathCall{Call: call}
+}
+
+// ConnectionTracerUpdatedPathCall wrap *gomock.Call
+type ConnectionTracerUpdatedPathCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *ConnectionTracerUpdatedPathCall) Return() *ConnectionTracerUpdatedPathCall {
+	c.Call = c.Call.Return()
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *ConnectionTracerUpdatedPathCall) Do(f func(net.Addr, net.Addr)) *ConnectionTracerUpdatedPathCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *ConnectionTracerUpdatedPathCall) DoAndReturn(f func(net.Addr, net.Addr)) *ConnectionTracerUpdatedPathCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
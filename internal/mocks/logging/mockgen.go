@@ -45,6 +45,11 @@ type ConnectionTracer interface {
 	LossTimerExpired(logging.TimerType, logging.EncryptionLevel)
 	LossTimerCanceled()
 	ECNStateUpdated(state logging.ECNState, trigger logging.ECNStateTrigger)
+	ECNCongestionWindowReduced(ceMarked int64, congestionWindow logging.ByteCount)
+	DetectedPersistentCongestion(congestionWindow logging.ByteCount)
+	UpdatedPath(local, remote net.Addr)
+	StartedPathValidation(remote net.Addr)
+	CompletedPathValidation(remote net.Addr, success bool)
 	// Close is called when the connection is closed.
 	Close()
 	Debug(name, msg string)
@@ -119,6 +119,45 @@ func (c *EarlyConnectionAcceptUniStreamCall) DoAndReturn(f func(context.Context)
 	return c
 }
 
+// AddPath mocks base method.
+func (m *MockEarlyConnection) AddPath(arg0 *quic.Transport) (*quic.Path, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddPath", arg0)
+	ret0, _ := ret[0].(*quic.Path)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddPath indicates an expected call of AddPath.
+func (mr *MockEarlyConnectionMockRecorder) AddPath(arg0 any) *EarlyConnectionAddPathCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddPath", reflect.TypeOf((*MockEarlyConnection)(nil).AddPath), arg0)
+	return &EarlyConnectionAddPathCall{Call: call}
+}
+
+// EarlyConnectionAddPathCall wrap *gomock.Call
+type EarlyConnectionAddPathCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *EarlyConnectionAddPathCall) Return(arg0 *quic.Path, arg1 error) *EarlyConnectionAddPathCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *EarlyConnectionAddPathCall) Do(f func(*quic.Transport) (*quic.Path, error)) *EarlyConnectionAddPathCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *EarlyConnectionAddPathCall) DoAndReturn(f func(*quic.Transport) (*quic.Path, error)) *EarlyConnectionAddPathCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // CloseWithError mocks base method.
 func (m *MockEarlyConnection) CloseWithError(arg0 qerr.ApplicationErrorCode, arg1 string) error {
 	m.ctrl.T.Helper()
@@ -617,3 +656,41 @@ func (c *EarlyConnectionSendDatagramCall) DoAndReturn(f func([]byte) error) *Ear
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
+
+// Stats mocks base method.
+func (m *MockEarlyConnection) Stats() quic.ConnectionStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stats")
+	ret0, _ := ret[0].(quic.ConnectionStats)
+	return ret0
+}
+
+// Stats indicates an expected call of Stats.
+func (mr *MockEarlyConnectionMockRecorder) Stats() *EarlyConnectionStatsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stats", reflect.TypeOf((*MockEarlyConnection)(nil).Stats))
+	return &EarlyConnectionStatsCall{Call: call}
+}
+
+// EarlyConnectionStatsCall wrap *gomock.Call
+type EarlyConnectionStatsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *EarlyConnectionStatsCall) Return(arg0 quic.ConnectionStats) *EarlyConnectionStatsCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *EarlyConnectionStatsCall) Do(f func() quic.ConnectionStats) *EarlyConnectionStatsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *EarlyConnectionStatsCall) DoAndReturn(f func() quic.ConnectionStats) *EarlyConnectionStatsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
@@ -13,6 +13,7 @@ import (
 	reflect "reflect"
 	time "time"
 
+	quic "github.com/quic-go/quic-go"
 	protocol "github.com/quic-go/quic-go/internal/protocol"
 	qerr "github.com/quic-go/quic-go/internal/qerr"
 	gomock "go.uber.org/mock/gomock"
@@ -266,6 +267,42 @@ func (c *StreamSetDeadlineCall) DoAndReturn(f func(time.Time) error) *StreamSetD
 	return c
 }
 
+// SetPriority mocks base method.
+func (m *MockStream) SetPriority(arg0 quic.StreamPriority) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetPriority", arg0)
+}
+
+// SetPriority indicates an expected call of SetPriority.
+func (mr *MockStreamMockRecorder) SetPriority(arg0 any) *StreamSetPriorityCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPriority", reflect.TypeOf((*MockStream)(nil).SetPriority), arg0)
+	return &StreamSetPriorityCall{Call: call}
+}
+
+// StreamSetPriorityCall wrap *gomock.Call
+type StreamSetPriorityCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *StreamSetPriorityCall) Return() *StreamSetPriorityCall {
+	c.Call = c.Call.Return()
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *StreamSetPriorityCall) Do(f func(quic.StreamPriority)) *StreamSetPriorityCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *StreamSetPriorityCall) DoAndReturn(f func(quic.StreamPriority)) *StreamSetPriorityCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // SetReadDeadline mocks base method.
 func (m *MockStream) SetReadDeadline(arg0 time.Time) error {
 	m.ctrl.T.Helper()
@@ -77,6 +77,44 @@ func (c *ShortHeaderOpenerDecodePacketNumberCall) DoAndReturn(f func(protocol.Pa
 	return c
 }
 
+// DecodePacketNumberOnPath mocks base method.
+func (m *MockShortHeaderOpener) DecodePacketNumberOnPath(arg0 uint64, arg1 protocol.PacketNumber, arg2 protocol.PacketNumberLen) protocol.PacketNumber {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DecodePacketNumberOnPath", arg0, arg1, arg2)
+	ret0, _ := ret[0].(protocol.PacketNumber)
+	return ret0
+}
+
+// DecodePacketNumberOnPath indicates an expected call of DecodePacketNumberOnPath.
+func (mr *MockShortHeaderOpenerMockRecorder) DecodePacketNumberOnPath(arg0, arg1, arg2 any) *ShortHeaderOpenerDecodePacketNumberOnPathCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DecodePacketNumberOnPath", reflect.TypeOf((*MockShortHeaderOpener)(nil).DecodePacketNumberOnPath), arg0, arg1, arg2)
+	return &ShortHeaderOpenerDecodePacketNumberOnPathCall{Call: call}
+}
+
+// ShortHeaderOpenerDecodePacketNumberOnPathCall wrap *gomock.Call
+type ShortHeaderOpenerDecodePacketNumberOnPathCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *ShortHeaderOpenerDecodePacketNumberOnPathCall) Return(arg0 protocol.PacketNumber) *ShortHeaderOpenerDecodePacketNumberOnPathCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *ShortHeaderOpenerDecodePacketNumberOnPathCall) Do(f func(uint64, protocol.PacketNumber, protocol.PacketNumberLen) protocol.PacketNumber) *ShortHeaderOpenerDecodePacketNumberOnPathCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *ShortHeaderOpenerDecodePacketNumberOnPathCall) DoAndReturn(f func(uint64, protocol.PacketNumber, protocol.PacketNumberLen) protocol.PacketNumber) *ShortHeaderOpenerDecodePacketNumberOnPathCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // DecryptHeader mocks base method.
 func (m *MockShortHeaderOpener) DecryptHeader(arg0 []byte, arg1 *byte, arg2 []byte) {
 	m.ctrl.T.Helper()
@@ -151,3 +189,42 @@ func (c *ShortHeaderOpenerOpenCall) DoAndReturn(f func([]byte, []byte, time.Time
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
+
+// OpenOnPath mocks base method.
+func (m *MockShortHeaderOpener) OpenOnPath(arg0, arg1 []byte, arg2 time.Time, arg3 uint64, arg4 protocol.PacketNumber, arg5 protocol.KeyPhaseBit, arg6 []byte) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenOnPath", arg0, arg1, arg2, arg3, arg4, arg5, arg6)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OpenOnPath indicates an expected call of OpenOnPath.
+func (mr *MockShortHeaderOpenerMockRecorder) OpenOnPath(arg0, arg1, arg2, arg3, arg4, arg5, arg6 any) *ShortHeaderOpenerOpenOnPathCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenOnPath", reflect.TypeOf((*MockShortHeaderOpener)(nil).OpenOnPath), arg0, arg1, arg2, arg3, arg4, arg5, arg6)
+	return &ShortHeaderOpenerOpenOnPathCall{Call: call}
+}
+
+// ShortHeaderOpenerOpenOnPathCall wrap *gomock.Call
+type ShortHeaderOpenerOpenOnPathCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *ShortHeaderOpenerOpenOnPathCall) Return(arg0 []byte, arg1 error) *ShortHeaderOpenerOpenOnPathCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *ShortHeaderOpenerOpenOnPathCall) Do(f func([]byte, []byte, time.Time, uint64, protocol.PacketNumber, protocol.KeyPhaseBit, []byte) ([]byte, error)) *ShortHeaderOpenerOpenOnPathCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *ShortHeaderOpenerOpenOnPathCall) DoAndReturn(f func([]byte, []byte, time.Time, uint64, protocol.PacketNumber, protocol.KeyPhaseBit, []byte) ([]byte, error)) *ShortHeaderOpenerOpenOnPathCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
@@ -187,3 +187,41 @@ func (c *ShortHeaderSealerSealCall) DoAndReturn(f func([]byte, []byte, protocol.
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
+
+// SealOnPath mocks base method.
+func (m *MockShortHeaderSealer) SealOnPath(arg0, arg1 []byte, arg2 uint64, arg3 protocol.PacketNumber, arg4 []byte) []byte {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SealOnPath", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].([]byte)
+	return ret0
+}
+
+// SealOnPath indicates an expected call of SealOnPath.
+func (mr *MockShortHeaderSealerMockRecorder) SealOnPath(arg0, arg1, arg2, arg3, arg4 any) *ShortHeaderSealerSealOnPathCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SealOnPath", reflect.TypeOf((*MockShortHeaderSealer)(nil).SealOnPath), arg0, arg1, arg2, arg3, arg4)
+	return &ShortHeaderSealerSealOnPathCall{Call: call}
+}
+
+// ShortHeaderSealerSealOnPathCall wrap *gomock.Call
+type ShortHeaderSealerSealOnPathCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *ShortHeaderSealerSealOnPathCall) Return(arg0 []byte) *ShortHeaderSealerSealOnPathCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *ShortHeaderSealerSealOnPathCall) Do(f func([]byte, []byte, uint64, protocol.PacketNumber, []byte) []byte) *ShortHeaderSealerSealOnPathCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *ShortHeaderSealerSealOnPathCall) DoAndReturn(f func([]byte, []byte, uint64, protocol.PacketNumber, []byte) []byte) *ShortHeaderSealerSealOnPathCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
@@ -28,3 +28,42 @@ func (e EncryptionLevel) String() string {
 	}
 	return "unknown"
 }
+
+// PacketNumberSpace returns the packet number space that packets of this encryption level are sent in.
+// 0-RTT and 1-RTT packets share the application data packet number space.
+func (e EncryptionLevel) PacketNumberSpace() PacketNumberSpace {
+	switch e {
+	case EncryptionInitial:
+		return PacketNumberSpaceInitial
+	case EncryptionHandshake:
+		return PacketNumberSpaceHandshake
+	case Encryption0RTT, Encryption1RTT:
+		return PacketNumberSpaceApplicationData
+	}
+	return 0
+}
+
+// A PacketNumberSpace is a packet number space.
+// Packet numbers are only unique within a packet number space.
+type PacketNumberSpace uint8
+
+const (
+	// PacketNumberSpaceInitial is the Initial packet number space
+	PacketNumberSpaceInitial PacketNumberSpace = 1 + iota
+	// PacketNumberSpaceHandshake is the Handshake packet number space
+	PacketNumberSpaceHandshake
+	// PacketNumberSpaceApplicationData is the application data packet number space, used by 0-RTT and 1-RTT packets
+	PacketNumberSpaceApplicationData
+)
+
+func (s PacketNumberSpace) String() string {
+	switch s {
+	case PacketNumberSpaceInitial:
+		return "Initial"
+	case PacketNumberSpaceHandshake:
+		return "Handshake"
+	case PacketNumberSpaceApplicationData:
+		return "Application Data"
+	}
+	return "unknown"
+}
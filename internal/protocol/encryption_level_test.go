@@ -17,4 +17,12 @@ var _ = Describe("Encryption Level", func() {
 		Expect(Encryption0RTT.String()).To(Equal("0-RTT"))
 		Expect(Encryption1RTT.String()).To(Equal("1-RTT"))
 	})
+
+	It("returns the packet number space", func() {
+		Expect(EncryptionInitial.PacketNumberSpace()).To(Equal(PacketNumberSpaceInitial))
+		Expect(EncryptionHandshake.PacketNumberSpace()).To(Equal(PacketNumberSpaceHandshake))
+		Expect(Encryption0RTT.PacketNumberSpace()).To(Equal(PacketNumberSpaceApplicationData))
+		Expect(Encryption1RTT.PacketNumberSpace()).To(Equal(PacketNumberSpaceApplicationData))
+		Expect(PacketNumberSpaceApplicationData.String()).To(Equal("Application Data"))
+	})
 })
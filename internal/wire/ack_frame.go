@@ -117,6 +117,12 @@ func (f *AckFrame) Append(b []byte, _ protocol.VersionNumber) ([]byte, error) {
 	} else {
 		b = append(b, ackFrameType)
 	}
+	return f.appendFields(b, hasECN), nil
+}
+
+// appendFields appends all fields of the ACK frame following the frame type.
+// It is also used for PATH_ACK frames.
+func (f *AckFrame) appendFields(b []byte, hasECN bool) []byte {
 	b = quicvarint.Append(b, uint64(f.LargestAcked()))
 	b = quicvarint.Append(b, encodeAckDelay(f.DelayTime))
 
@@ -139,15 +145,19 @@ func (f *AckFrame) Append(b []byte, _ protocol.VersionNumber) ([]byte, error) {
 		b = quicvarint.Append(b, f.ECT1)
 		b = quicvarint.Append(b, f.ECNCE)
 	}
-	return b, nil
+	return b
 }
 
 // Length of a written frame
 func (f *AckFrame) Length(_ protocol.VersionNumber) protocol.ByteCount {
+	return 1 + f.fieldsLength()
+}
+
+func (f *AckFrame) fieldsLength() protocol.ByteCount {
 	largestAcked := f.AckRanges[0].Largest
 	numRanges := f.numEncodableAckRanges()
 
-	length := 1 + quicvarint.Len(uint64(largestAcked)) + quicvarint.Len(encodeAckDelay(f.DelayTime))
+	length := quicvarint.Len(uint64(largestAcked)) + quicvarint.Len(encodeAckDelay(f.DelayTime))
 
 	length += quicvarint.Len(uint64(numRanges - 1))
 	lowestInFirstRange := f.AckRanges[0].Smallest
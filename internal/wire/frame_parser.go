@@ -36,11 +36,22 @@ const (
 	handshakeDoneFrameType      = 0x1e
 )
 
+// frame types defined in draft-ietf-quic-multipath-04
+const (
+	pathAckFrameType     = 0x15228c00
+	pathAckECNFrameType  = 0x15228c01
+	pathAbandonFrameType = 0x15228c05
+	pathStatusFrameType  = 0x15228c06
+)
+
+var errUnknownFrameType = errors.New("unknown frame type")
+
 type frameParser struct {
 	r bytes.Reader // cached bytes.Reader, so we don't have to repeatedly allocate them
 
 	ackDelayExponent  uint8
 	supportsDatagrams bool
+	supportsMultipath bool
 
 	// To avoid allocating when parsing, keep a single ACK frame struct.
 	// It is used over and over again.
@@ -149,9 +160,27 @@ func (p *frameParser) parseFrame(r *bytes.Reader, typ uint64, encLevel protocol.
 				frame, err = parseDatagramFrame(r, typ, v)
 				break
 			}
+			err = errUnknownFrameType
+		case pathAckFrameType, pathAckECNFrameType:
+			if p.supportsMultipath {
+				frame, err = parsePathAckFrame(r, typ, p.ackDelayExponent, v)
+				break
+			}
+			err = errUnknownFrameType
+		case pathAbandonFrameType:
+			if p.supportsMultipath {
+				frame, err = parsePathAbandonFrame(r, v)
+				break
+			}
+			err = errUnknownFrameType
+		case pathStatusFrameType:
+			if p.supportsMultipath {
+				frame, err = parsePathStatusFrame(r, v)
+				break
+			}
 			fallthrough
 		default:
-			err = errors.New("unknown frame type")
+			err = errUnknownFrameType
 		}
 	}
 	if err != nil {
@@ -174,7 +203,8 @@ func (p *frameParser) isAllowedAtEncLevel(f Frame, encLevel protocol.EncryptionL
 		}
 	case protocol.Encryption0RTT:
 		switch f.(type) {
-		case *CryptoFrame, *AckFrame, *ConnectionCloseFrame, *NewTokenFrame, *PathResponseFrame, *RetireConnectionIDFrame:
+		case *CryptoFrame, *AckFrame, *ConnectionCloseFrame, *NewTokenFrame, *PathResponseFrame, *RetireConnectionIDFrame,
+			*PathAckFrame, *PathAbandonFrame, *PathStatusFrame:
 			return false
 		default:
 			return true
@@ -189,3 +219,9 @@ func (p *frameParser) isAllowedAtEncLevel(f Frame, encLevel protocol.EncryptionL
 func (p *frameParser) SetAckDelayExponent(exp uint8) {
 	p.ackDelayExponent = exp
 }
+
+// EnableMultipath enables parsing of the frames defined by the multipath extension.
+// It is called once both endpoints negotiated the use of multipath.
+func (p *frameParser) EnableMultipath() {
+	p.supportsMultipath = true
+}
@@ -327,6 +327,57 @@ var _ = Describe("Frame parsing", func() {
 		}))
 	})
 
+	It("unpacks PATH_ACK frames", func() {
+		parser.EnableMultipath()
+		f := &PathAckFrame{
+			PathID:   3,
+			AckFrame: AckFrame{AckRanges: []AckRange{{Smallest: 1, Largest: 0x13}}, ECT1: 42},
+		}
+		b, err := f.Append(nil, protocol.Version1)
+		Expect(err).ToNot(HaveOccurred())
+		l, frame, err := parser.ParseNext(b, protocol.Encryption1RTT, protocol.Version1)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(frame).To(Equal(f))
+		Expect(l).To(Equal(len(b)))
+	})
+
+	It("unpacks PATH_ABANDON frames", func() {
+		parser.EnableMultipath()
+		f := &PathAbandonFrame{PathID: 5, ErrorCode: 0x42, ReasonPhrase: "foobar"}
+		b, err := f.Append(nil, protocol.Version1)
+		Expect(err).ToNot(HaveOccurred())
+		l, frame, err := parser.ParseNext(b, protocol.Encryption1RTT, protocol.Version1)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(frame).To(Equal(f))
+		Expect(l).To(Equal(len(b)))
+	})
+
+	It("unpacks PATH_STATUS frames", func() {
+		parser.EnableMultipath()
+		f := &PathStatusFrame{PathID: 5, SequenceNumber: 2, Status: PathStatusStandby}
+		b, err := f.Append(nil, protocol.Version1)
+		Expect(err).ToNot(HaveOccurred())
+		l, frame, err := parser.ParseNext(b, protocol.Encryption1RTT, protocol.Version1)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(frame).To(Equal(f))
+		Expect(l).To(Equal(len(b)))
+	})
+
+	It("errors when multipath frames are not supported", func() {
+		for _, f := range []Frame{
+			&PathAckFrame{AckFrame: AckFrame{AckRanges: []AckRange{{Smallest: 1, Largest: 0x13}}}},
+			&PathAbandonFrame{},
+			&PathStatusFrame{Status: PathStatusAvailable},
+		} {
+			b, err := f.Append(nil, protocol.Version1)
+			Expect(err).ToNot(HaveOccurred())
+			_, _, err = parser.ParseNext(b, protocol.Encryption1RTT, protocol.Version1)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(*qerr.TransportError).ErrorCode).To(Equal(qerr.FrameEncodingError))
+			Expect(err.(*qerr.TransportError).ErrorMessage).To(Equal("unknown frame type"))
+		}
+	})
+
 	It("errors on invalid type", func() {
 		_, _, err := parser.ParseNext(encodeVarInt(0x42), protocol.Encryption1RTT, protocol.Version1)
 		Expect(err).To(MatchError(&qerr.TransportError{
@@ -370,11 +421,15 @@ var _ = Describe("Frame parsing", func() {
 			&ConnectionCloseFrame{},
 			&HandshakeDoneFrame{},
 			&DatagramFrame{},
+			&PathAckFrame{AckFrame: AckFrame{AckRanges: []AckRange{{Smallest: 1, Largest: 42}}}},
+			&PathAbandonFrame{},
+			&PathStatusFrame{Status: PathStatusAvailable},
 		}
 
 		var framesSerialized [][]byte
 
 		BeforeEach(func() {
+			parser.EnableMultipath()
 			framesSerialized = nil
 			for _, frame := range frames {
 				b, err := frame.Append(nil, protocol.Version1)
@@ -411,11 +466,12 @@ var _ = Describe("Frame parsing", func() {
 			}
 		})
 
-		It("rejects all frames but ACK, CRYPTO, CONNECTION_CLOSE, NEW_TOKEN, PATH_RESPONSE and RETIRE_CONNECTION_ID in 0-RTT packets", func() {
+		It("rejects all frames but ACK, CRYPTO, CONNECTION_CLOSE, NEW_TOKEN, PATH_RESPONSE, RETIRE_CONNECTION_ID and the multipath frames in 0-RTT packets", func() {
 			for i, b := range framesSerialized {
 				_, _, err := parser.ParseNext(b, protocol.Encryption0RTT, protocol.Version1)
 				switch frames[i].(type) {
-				case *AckFrame, *ConnectionCloseFrame, *CryptoFrame, *NewTokenFrame, *PathResponseFrame, *RetireConnectionIDFrame:
+				case *AckFrame, *ConnectionCloseFrame, *CryptoFrame, *NewTokenFrame, *PathResponseFrame, *RetireConnectionIDFrame,
+					*PathAckFrame, *PathAbandonFrame, *PathStatusFrame:
 					Expect(err).To(BeAssignableToTypeOf(&qerr.TransportError{}))
 					Expect(err.(*qerr.TransportError).ErrorCode).To(Equal(qerr.FrameEncodingError))
 					Expect(err.(*qerr.TransportError).ErrorMessage).To(ContainSubstring("not allowed at encryption level 0-RTT"))
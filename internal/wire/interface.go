@@ -14,4 +14,5 @@ type Frame interface {
 type FrameParser interface {
 	ParseNext([]byte, protocol.EncryptionLevel, protocol.VersionNumber) (int, Frame, error)
 	SetAckDelayExponent(uint8)
+	EnableMultipath()
 }
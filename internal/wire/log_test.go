@@ -98,6 +98,15 @@ var _ = Describe("Frame logging", func() {
 		Expect(buf.String()).To(ContainSubstring("\t<- &wire.AckFrame{LargestAcked: 8, LowestAcked: 2, AckRanges: {{Largest: 8, Smallest: 5}, {Largest: 3, Smallest: 2}}, DelayTime: 12ms}\n"))
 	})
 
+	It("logs PATH_ACK frames", func() {
+		frame := &PathAckFrame{
+			PathID:   3,
+			AckFrame: AckFrame{AckRanges: []AckRange{{Smallest: 42, Largest: 1337}}, DelayTime: time.Millisecond},
+		}
+		LogFrame(logger, frame, true)
+		Expect(buf.String()).To(ContainSubstring("\t-> &wire.PathAckFrame{PathID: 3, LargestAcked: 1337, LowestAcked: 42, DelayTime: 1ms}\n"))
+	})
+
 	It("logs MAX_STREAMS frames", func() {
 		frame := &MaxStreamsFrame{
 			Type:         protocol.StreamTypeBidi,
@@ -0,0 +1,61 @@
+package wire
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/quicvarint"
+)
+
+// A PathAbandonFrame is a PATH_ABANDON frame.
+// It is sent to close a path of a multipath connection.
+type PathAbandonFrame struct {
+	// PathID is the sequence number of the destination connection ID used on the path
+	PathID       uint64
+	ErrorCode    uint64
+	ReasonPhrase string
+}
+
+func parsePathAbandonFrame(r *bytes.Reader, _ protocol.VersionNumber) (*PathAbandonFrame, error) {
+	pathID, err := quicvarint.Read(r)
+	if err != nil {
+		return nil, err
+	}
+	ec, err := quicvarint.Read(r)
+	if err != nil {
+		return nil, err
+	}
+	reasonPhraseLen, err := quicvarint.Read(r)
+	if err != nil {
+		return nil, err
+	}
+	// don't allocate a huge buffer if the reason phrase length exceeds the remaining length of the packet
+	if int(reasonPhraseLen) > r.Len() {
+		return nil, io.EOF
+	}
+	reasonPhrase := make([]byte, reasonPhraseLen)
+	if _, err := io.ReadFull(r, reasonPhrase); err != nil {
+		return nil, err
+	}
+	return &PathAbandonFrame{
+		PathID:       pathID,
+		ErrorCode:    ec,
+		ReasonPhrase: string(reasonPhrase),
+	}, nil
+}
+
+func (f *PathAbandonFrame) Append(b []byte, _ protocol.VersionNumber) ([]byte, error) {
+	b = quicvarint.Append(b, pathAbandonFrameType)
+	b = quicvarint.Append(b, f.PathID)
+	b = quicvarint.Append(b, f.ErrorCode)
+	b = quicvarint.Append(b, uint64(len(f.ReasonPhrase)))
+	b = append(b, []byte(f.ReasonPhrase)...)
+	return b, nil
+}
+
+// Length of a written frame
+func (f *PathAbandonFrame) Length(protocol.VersionNumber) protocol.ByteCount {
+	return quicvarint.Len(pathAbandonFrameType) + quicvarint.Len(f.PathID) + quicvarint.Len(f.ErrorCode) +
+		quicvarint.Len(uint64(len(f.ReasonPhrase))) + protocol.ByteCount(len(f.ReasonPhrase))
+}
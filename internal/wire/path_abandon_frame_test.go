@@ -0,0 +1,74 @@
+package wire
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/quicvarint"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PATH_ABANDON frame", func() {
+	Context("when parsing", func() {
+		It("accepts a sample frame", func() {
+			reason := "path no longer needed"
+			data := encodeVarInt(0xdeadbeef)                          // path ID
+			data = append(data, encodeVarInt(0x1337)...)              // error code
+			data = append(data, encodeVarInt(uint64(len(reason)))...) // reason phrase length
+			data = append(data, []byte(reason)...)
+			b := bytes.NewReader(data)
+			frame, err := parsePathAbandonFrame(b, protocol.Version1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame.PathID).To(BeEquivalentTo(0xdeadbeef))
+			Expect(frame.ErrorCode).To(BeEquivalentTo(0x1337))
+			Expect(frame.ReasonPhrase).To(Equal(reason))
+			Expect(b.Len()).To(BeZero())
+		})
+
+		It("rejects long reason phrases", func() {
+			data := encodeVarInt(1)                      // path ID
+			data = append(data, encodeVarInt(0x42)...)   // error code
+			data = append(data, encodeVarInt(0xffff)...) // reason phrase length
+			_, err := parsePathAbandonFrame(bytes.NewReader(data), protocol.Version1)
+			Expect(err).To(MatchError(io.EOF))
+		})
+
+		It("errors on EOFs", func() {
+			reason := "foobar"
+			data := encodeVarInt(0xdeadbeef)                          // path ID
+			data = append(data, encodeVarInt(0x1337)...)              // error code
+			data = append(data, encodeVarInt(uint64(len(reason)))...) // reason phrase length
+			data = append(data, []byte(reason)...)
+			_, err := parsePathAbandonFrame(bytes.NewReader(data), protocol.Version1)
+			Expect(err).NotTo(HaveOccurred())
+			for i := range data {
+				_, err := parsePathAbandonFrame(bytes.NewReader(data[:i]), protocol.Version1)
+				Expect(err).To(MatchError(io.EOF))
+			}
+		})
+	})
+
+	Context("when writing", func() {
+		It("writes a sample frame", func() {
+			frame := &PathAbandonFrame{PathID: 0x1337, ErrorCode: 0xcafe, ReasonPhrase: "foobar"}
+			b, err := frame.Append(nil, protocol.Version1)
+			Expect(err).ToNot(HaveOccurred())
+			expected := quicvarint.Append(nil, pathAbandonFrameType)
+			expected = append(expected, encodeVarInt(0x1337)...)
+			expected = append(expected, encodeVarInt(0xcafe)...)
+			expected = append(expected, encodeVarInt(6)...)
+			expected = append(expected, []byte("foobar")...)
+			Expect(b).To(Equal(expected))
+		})
+
+		It("has the correct length", func() {
+			frame := &PathAbandonFrame{PathID: 0xdecafbad, ErrorCode: 0x1234567, ReasonPhrase: "foobar"}
+			b, err := frame.Append(nil, protocol.Version1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(b).To(HaveLen(int(frame.Length(protocol.Version1))))
+		})
+	})
+})
@@ -0,0 +1,51 @@
+package wire
+
+import (
+	"bytes"
+
+	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/quicvarint"
+)
+
+// A PathAckFrame is a PATH_ACK frame (called ACK_MP in draft-ietf-quic-multipath-04).
+// It acknowledges packets sent in the packet number space of a path of a multipath connection.
+type PathAckFrame struct {
+	// PathID is the sequence number of the destination connection ID
+	// that the acknowledged packets were sent with.
+	PathID uint64
+	AckFrame
+}
+
+func parsePathAckFrame(r *bytes.Reader, typ uint64, ackDelayExponent uint8, v protocol.VersionNumber) (*PathAckFrame, error) {
+	pathID, err := quicvarint.Read(r)
+	if err != nil {
+		return nil, err
+	}
+	f := &PathAckFrame{PathID: pathID}
+	ackTyp := uint64(ackFrameType)
+	if typ == pathAckECNFrameType {
+		ackTyp = ackECNFrameType
+	}
+	if err := parseAckFrame(&f.AckFrame, r, ackTyp, ackDelayExponent, v); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Append appends a PATH_ACK frame.
+func (f *PathAckFrame) Append(b []byte, _ protocol.VersionNumber) ([]byte, error) {
+	hasECN := f.ECT0 > 0 || f.ECT1 > 0 || f.ECNCE > 0
+	if hasECN {
+		b = quicvarint.Append(b, pathAckECNFrameType)
+	} else {
+		b = quicvarint.Append(b, pathAckFrameType)
+	}
+	b = quicvarint.Append(b, f.PathID)
+	return f.appendFields(b, hasECN), nil
+}
+
+// Length of a written frame
+func (f *PathAckFrame) Length(_ protocol.VersionNumber) protocol.ByteCount {
+	// Both frame types have the same length when encoded as a varint.
+	return quicvarint.Len(pathAckFrameType) + quicvarint.Len(f.PathID) + f.fieldsLength()
+}
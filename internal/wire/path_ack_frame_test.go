@@ -0,0 +1,110 @@
+package wire
+
+import (
+	"bytes"
+	"io"
+	"time"
+
+	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/quicvarint"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PATH_ACK frame", func() {
+	Context("when parsing", func() {
+		It("parses a frame", func() {
+			data := encodeVarInt(7)                   // path ID
+			data = append(data, encodeVarInt(100)...) // largest acked
+			data = append(data, encodeVarInt(0)...)   // delay
+			data = append(data, encodeVarInt(0)...)   // num blocks
+			data = append(data, encodeVarInt(10)...)  // first ack block
+			b := bytes.NewReader(data)
+			frame, err := parsePathAckFrame(b, pathAckFrameType, protocol.AckDelayExponent, protocol.Version1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame.PathID).To(BeEquivalentTo(7))
+			Expect(frame.LargestAcked()).To(Equal(protocol.PacketNumber(100)))
+			Expect(frame.LowestAcked()).To(Equal(protocol.PacketNumber(90)))
+			Expect(frame.ECT0).To(BeZero())
+			Expect(b.Len()).To(BeZero())
+		})
+
+		It("parses a frame with ECN counts", func() {
+			data := encodeVarInt(7)                   // path ID
+			data = append(data, encodeVarInt(100)...) // largest acked
+			data = append(data, encodeVarInt(0)...)   // delay
+			data = append(data, encodeVarInt(0)...)   // num blocks
+			data = append(data, encodeVarInt(10)...)  // first ack block
+			data = append(data, encodeVarInt(0x42)...)
+			data = append(data, encodeVarInt(0x12345)...)
+			data = append(data, encodeVarInt(0x12345678)...)
+			b := bytes.NewReader(data)
+			frame, err := parsePathAckFrame(b, pathAckECNFrameType, protocol.AckDelayExponent, protocol.Version1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame.PathID).To(BeEquivalentTo(7))
+			Expect(frame.ECT0).To(BeEquivalentTo(0x42))
+			Expect(frame.ECT1).To(BeEquivalentTo(0x12345))
+			Expect(frame.ECNCE).To(BeEquivalentTo(0x12345678))
+			Expect(b.Len()).To(BeZero())
+		})
+
+		It("errors on EOFs", func() {
+			data := encodeVarInt(7)                   // path ID
+			data = append(data, encodeVarInt(100)...) // largest acked
+			data = append(data, encodeVarInt(0)...)   // delay
+			data = append(data, encodeVarInt(0)...)   // num blocks
+			data = append(data, encodeVarInt(10)...)  // first ack block
+			_, err := parsePathAckFrame(bytes.NewReader(data), pathAckFrameType, protocol.AckDelayExponent, protocol.Version1)
+			Expect(err).NotTo(HaveOccurred())
+			for i := range data {
+				_, err := parsePathAckFrame(bytes.NewReader(data[:i]), pathAckFrameType, protocol.AckDelayExponent, protocol.Version1)
+				Expect(err).To(MatchError(io.EOF))
+			}
+		})
+	})
+
+	Context("when writing", func() {
+		It("writes a frame", func() {
+			f := &PathAckFrame{
+				PathID:   0x1337,
+				AckFrame: AckFrame{AckRanges: []AckRange{{Smallest: 80, Largest: 100}, {Smallest: 10, Largest: 50}}},
+			}
+			b, err := f.Append(nil, protocol.Version1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(b).To(HaveLen(int(f.Length(protocol.Version1))))
+			r := bytes.NewReader(b)
+			typ, err := quicvarint.Read(r)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(typ).To(BeEquivalentTo(pathAckFrameType))
+			frame, err := parsePathAckFrame(r, typ, protocol.AckDelayExponent, protocol.Version1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame).To(Equal(f))
+			Expect(r.Len()).To(BeZero())
+		})
+
+		It("writes a frame with ECN counts and an ACK delay", func() {
+			f := &PathAckFrame{
+				PathID: 3,
+				AckFrame: AckFrame{
+					AckRanges: []AckRange{{Smallest: 10, Largest: 2000}},
+					DelayTime: 8 * time.Millisecond,
+					ECT0:      10,
+					ECT1:      20,
+					ECNCE:     30,
+				},
+			}
+			b, err := f.Append(nil, protocol.Version1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(b).To(HaveLen(int(f.Length(protocol.Version1))))
+			r := bytes.NewReader(b)
+			typ, err := quicvarint.Read(r)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(typ).To(BeEquivalentTo(pathAckECNFrameType))
+			frame, err := parsePathAckFrame(r, typ, protocol.AckDelayExponent, protocol.Version1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame).To(Equal(f))
+			Expect(r.Len()).To(BeZero())
+		})
+	})
+})
@@ -0,0 +1,76 @@
+package wire
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/quicvarint"
+)
+
+// PathStatus is the status of a path of a multipath connection, as sent in the PATH_STATUS frame.
+type PathStatus uint64
+
+const (
+	// PathStatusStandby means that the path should only be used if no other path is available.
+	PathStatusStandby PathStatus = 1
+	// PathStatusAvailable means that the path can be used for sending.
+	PathStatusAvailable PathStatus = 2
+)
+
+func (s PathStatus) String() string {
+	switch s {
+	case PathStatusStandby:
+		return "standby"
+	case PathStatusAvailable:
+		return "available"
+	default:
+		return fmt.Sprintf("unknown path status: %d", uint64(s))
+	}
+}
+
+// A PathStatusFrame is a PATH_STATUS frame.
+type PathStatusFrame struct {
+	// PathID is the sequence number of the destination connection ID used on the path
+	PathID uint64
+	// SequenceNumber is increased for every PATH_STATUS frame sent for a path,
+	// such that the receiver can ignore reordered frames.
+	SequenceNumber uint64
+	Status         PathStatus
+}
+
+func parsePathStatusFrame(r *bytes.Reader, _ protocol.VersionNumber) (*PathStatusFrame, error) {
+	pathID, err := quicvarint.Read(r)
+	if err != nil {
+		return nil, err
+	}
+	seq, err := quicvarint.Read(r)
+	if err != nil {
+		return nil, err
+	}
+	status, err := quicvarint.Read(r)
+	if err != nil {
+		return nil, err
+	}
+	if s := PathStatus(status); s != PathStatusStandby && s != PathStatusAvailable {
+		return nil, fmt.Errorf("invalid path status: %d", status)
+	}
+	return &PathStatusFrame{
+		PathID:         pathID,
+		SequenceNumber: seq,
+		Status:         PathStatus(status),
+	}, nil
+}
+
+func (f *PathStatusFrame) Append(b []byte, _ protocol.VersionNumber) ([]byte, error) {
+	b = quicvarint.Append(b, pathStatusFrameType)
+	b = quicvarint.Append(b, f.PathID)
+	b = quicvarint.Append(b, f.SequenceNumber)
+	b = quicvarint.Append(b, uint64(f.Status))
+	return b, nil
+}
+
+// Length of a written frame
+func (f *PathStatusFrame) Length(protocol.VersionNumber) protocol.ByteCount {
+	return quicvarint.Len(pathStatusFrameType) + quicvarint.Len(f.PathID) + quicvarint.Len(f.SequenceNumber) + quicvarint.Len(uint64(f.Status))
+}
@@ -0,0 +1,69 @@
+package wire
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/quicvarint"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PATH_STATUS frame", func() {
+	Context("when parsing", func() {
+		It("accepts a sample frame", func() {
+			data := encodeVarInt(0xdeadbeef)         // path ID
+			data = append(data, encodeVarInt(42)...) // sequence number
+			data = append(data, encodeVarInt(1)...)  // status
+			b := bytes.NewReader(data)
+			frame, err := parsePathStatusFrame(b, protocol.Version1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame.PathID).To(BeEquivalentTo(0xdeadbeef))
+			Expect(frame.SequenceNumber).To(BeEquivalentTo(42))
+			Expect(frame.Status).To(Equal(PathStatusStandby))
+			Expect(b.Len()).To(BeZero())
+		})
+
+		It("rejects invalid path status values", func() {
+			data := encodeVarInt(1)                  // path ID
+			data = append(data, encodeVarInt(2)...)  // sequence number
+			data = append(data, encodeVarInt(42)...) // status
+			_, err := parsePathStatusFrame(bytes.NewReader(data), protocol.Version1)
+			Expect(err).To(MatchError("invalid path status: 42"))
+		})
+
+		It("errors on EOFs", func() {
+			data := encodeVarInt(0xdeadbeef)         // path ID
+			data = append(data, encodeVarInt(42)...) // sequence number
+			data = append(data, encodeVarInt(2)...)  // status
+			_, err := parsePathStatusFrame(bytes.NewReader(data), protocol.Version1)
+			Expect(err).NotTo(HaveOccurred())
+			for i := range data {
+				_, err := parsePathStatusFrame(bytes.NewReader(data[:i]), protocol.Version1)
+				Expect(err).To(MatchError(io.EOF))
+			}
+		})
+	})
+
+	Context("when writing", func() {
+		It("writes a sample frame", func() {
+			frame := &PathStatusFrame{PathID: 0x1337, SequenceNumber: 3, Status: PathStatusAvailable}
+			b, err := frame.Append(nil, protocol.Version1)
+			Expect(err).ToNot(HaveOccurred())
+			expected := quicvarint.Append(nil, pathStatusFrameType)
+			expected = append(expected, encodeVarInt(0x1337)...)
+			expected = append(expected, encodeVarInt(3)...)
+			expected = append(expected, encodeVarInt(2)...)
+			Expect(b).To(Equal(expected))
+		})
+
+		It("has the correct length", func() {
+			frame := &PathStatusFrame{PathID: 0xdecafbad, SequenceNumber: 0x1337, Status: PathStatusStandby}
+			b, err := frame.Append(nil, protocol.Version1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(b).To(HaveLen(int(frame.Length(protocol.Version1))))
+		})
+	})
+})
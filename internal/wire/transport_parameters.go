@@ -47,6 +47,8 @@ const (
 	retrySourceConnectionIDParameterID         transportParameterID = 0x10
 	// RFC 9221
 	maxDatagramFrameSizeParameterID transportParameterID = 0x20
+	// draft-ietf-quic-multipath-04
+	enableMultipathParameterID transportParameterID = 0x0f739bbc1b666d04
 )
 
 // PreferredAddress is the value encoding in the preferred_address transport parameter
@@ -88,6 +90,8 @@ type TransportParameters struct {
 	ActiveConnectionIDLimit uint64
 
 	MaxDatagramFrameSize protocol.ByteCount
+
+	EnableMultipath bool
 }
 
 // Unmarshal the transport parameters
@@ -159,6 +163,11 @@ func (p *TransportParameters) unmarshal(r *bytes.Reader, sentBy protocol.Perspec
 				return fmt.Errorf("wrong length for disable_active_migration: %d (expected empty)", paramLen)
 			}
 			p.DisableActiveMigration = true
+		case enableMultipathParameterID:
+			if paramLen != 0 {
+				return fmt.Errorf("wrong length for enable_multipath: %d (expected empty)", paramLen)
+			}
+			p.EnableMultipath = true
 		case statelessResetTokenParameterID:
 			if sentBy == protocol.PerspectiveClient {
 				return errors.New("client sent a stateless_reset_token")
@@ -413,6 +422,11 @@ func (p *TransportParameters) Marshal(pers protocol.Perspective) []byte {
 	if p.MaxDatagramFrameSize != protocol.InvalidByteCount {
 		b = p.marshalVarintParam(b, maxDatagramFrameSizeParameterID, uint64(p.MaxDatagramFrameSize))
 	}
+	// enable_multipath
+	if p.EnableMultipath {
+		b = quicvarint.Append(b, uint64(enableMultipathParameterID))
+		b = quicvarint.Append(b, 0)
+	}
 
 	if pers == protocol.PerspectiveClient && len(AdditionalTransportParametersClient) > 0 {
 		for k, v := range AdditionalTransportParametersClient {
@@ -521,6 +535,9 @@ func (p *TransportParameters) String() string {
 		logString += ", MaxDatagramFrameSize: %d"
 		logParams = append(logParams, p.MaxDatagramFrameSize)
 	}
+	if p.EnableMultipath {
+		logString += ", EnableMultipath: true"
+	}
 	logString += "}"
 	return fmt.Sprintf(logString, logParams...)
 }
@@ -34,6 +34,11 @@ type ConnectionTracer struct {
 	LossTimerExpired                 func(TimerType, EncryptionLevel)
 	LossTimerCanceled                func()
 	ECNStateUpdated                  func(state ECNState, trigger ECNStateTrigger)
+	ECNCongestionWindowReduced       func(ceMarked int64, congestionWindow ByteCount)
+	DetectedPersistentCongestion     func(congestionWindow ByteCount)
+	UpdatedPath                      func(local, remote net.Addr)
+	StartedPathValidation            func(remote net.Addr)
+	CompletedPathValidation          func(remote net.Addr, success bool)
 	// Close is called when the connection is closed.
 	Close func()
 	Debug func(name, msg string)
@@ -237,6 +242,41 @@ func NewMultiplexedConnectionTracer(tracers ...*ConnectionTracer) *ConnectionTra
 				}
 			}
 		},
+		ECNCongestionWindowReduced: func(ceMarked int64, congestionWindow ByteCount) {
+			for _, t := range tracers {
+				if t.ECNCongestionWindowReduced != nil {
+					t.ECNCongestionWindowReduced(ceMarked, congestionWindow)
+				}
+			}
+		},
+		DetectedPersistentCongestion: func(congestionWindow ByteCount) {
+			for _, t := range tracers {
+				if t.DetectedPersistentCongestion != nil {
+					t.DetectedPersistentCongestion(congestionWindow)
+				}
+			}
+		},
+		UpdatedPath: func(local, remote net.Addr) {
+			for _, t := range tracers {
+				if t.UpdatedPath != nil {
+					t.UpdatedPath(local, remote)
+				}
+			}
+		},
+		StartedPathValidation: func(remote net.Addr) {
+			for _, t := range tracers {
+				if t.StartedPathValidation != nil {
+					t.StartedPathValidation(remote)
+				}
+			}
+		},
+		CompletedPathValidation: func(remote net.Addr, success bool) {
+			for _, t := range tracers {
+				if t.CompletedPathValidation != nil {
+					t.CompletedPathValidation(remote, success)
+				}
+			}
+		},
 		Close: func() {
 			for _, t := range tracers {
 				if t.Close != nil {
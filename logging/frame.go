@@ -32,6 +32,12 @@ type (
 	PathChallengeFrame = wire.PathChallengeFrame
 	// A PathResponseFrame is a PATH_RESPONSE frame.
 	PathResponseFrame = wire.PathResponseFrame
+	// A PathAckFrame is a PATH_ACK frame (multipath extension).
+	PathAckFrame = wire.PathAckFrame
+	// A PathAbandonFrame is a PATH_ABANDON frame (multipath extension).
+	PathAbandonFrame = wire.PathAbandonFrame
+	// A PathStatusFrame is a PATH_STATUS frame (multipath extension).
+	PathStatusFrame = wire.PathStatusFrame
 	// A PingFrame is a PING frame.
 	PingFrame = wire.PingFrame
 	// A ResetStreamFrame is a RESET_STREAM frame.
@@ -264,6 +264,40 @@ var _ = Describe("Tracing", func() {
 			tracer.LossTimerCanceled()
 		})
 
+		It("traces the ECNCongestionWindowReduced event", func() {
+			tr1.EXPECT().ECNCongestionWindowReduced(int64(3), ByteCount(12345))
+			tr2.EXPECT().ECNCongestionWindowReduced(int64(3), ByteCount(12345))
+			tracer.ECNCongestionWindowReduced(3, 12345)
+		})
+
+		It("traces the DetectedPersistentCongestion event", func() {
+			tr1.EXPECT().DetectedPersistentCongestion(ByteCount(2400))
+			tr2.EXPECT().DetectedPersistentCongestion(ByteCount(2400))
+			tracer.DetectedPersistentCongestion(2400)
+		})
+
+		It("traces the UpdatedPath event", func() {
+			local := &net.UDPAddr{IP: net.IPv4(1, 2, 3, 4), Port: 1234}
+			remote := &net.UDPAddr{IP: net.IPv4(4, 3, 2, 1), Port: 4321}
+			tr1.EXPECT().UpdatedPath(local, remote)
+			tr2.EXPECT().UpdatedPath(local, remote)
+			tracer.UpdatedPath(local, remote)
+		})
+
+		It("traces the StartedPathValidation event", func() {
+			remote := &net.UDPAddr{IP: net.IPv4(4, 3, 2, 1), Port: 4321}
+			tr1.EXPECT().StartedPathValidation(remote)
+			tr2.EXPECT().StartedPathValidation(remote)
+			tracer.StartedPathValidation(remote)
+		})
+
+		It("traces the CompletedPathValidation event", func() {
+			remote := &net.UDPAddr{IP: net.IPv4(4, 3, 2, 1), Port: 4321}
+			tr1.EXPECT().CompletedPathValidation(remote, true)
+			tr2.EXPECT().CompletedPathValidation(remote, true)
+			tracer.CompletedPathValidation(remote, true)
+		})
+
 		It("traces the Close event", func() {
 			tr1.EXPECT().Close()
 			tr2.EXPECT().Close()
@@ -91,6 +91,22 @@ const (
 	CongestionStateRecovery
 	// CongestionStateApplicationLimited means that the congestion controller is application limited
 	CongestionStateApplicationLimited
+	// CongestionStateStartup is the startup phase of BBR
+	CongestionStateStartup
+	// CongestionStateDrain is the drain phase of BBR
+	CongestionStateDrain
+	// CongestionStateProbeBWDown is the ProbeBW_DOWN phase of BBR
+	CongestionStateProbeBWDown
+	// CongestionStateProbeBWCruise is the ProbeBW_CRUISE phase of BBR
+	CongestionStateProbeBWCruise
+	// CongestionStateProbeBWRefill is the ProbeBW_REFILL phase of BBR
+	CongestionStateProbeBWRefill
+	// CongestionStateProbeBWUp is the ProbeBW_UP phase of BBR
+	CongestionStateProbeBWUp
+	// CongestionStateProbeRTT is the ProbeRTT phase of BBR
+	CongestionStateProbeRTT
+	// CongestionStateConservativeSlowStart is the Conservative Slow Start phase of HyStart++ (RFC 9406)
+	CongestionStateConservativeSlowStart
 )
 
 // ECNState is the state of the ECN state machine (see Appendix A.4 of RFC 9000)
@@ -0,0 +1,112 @@
+package masque
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+)
+
+// A Client establishes UDP proxying flows through a CONNECT-UDP proxy (RFC 9298).
+// Flows through the same proxy are multiplexed on a single QUIC connection.
+//
+// The flows are returned as a net.PacketConn, which can be used by a quic.Transport to dial QUIC connections
+// through the proxy. QUIC packets that don't fit into a QUIC DATAGRAM frame are sent in DATAGRAM capsules.
+type Client struct {
+	// TLSClientConfig specifies the TLS configuration used to connect to the proxy.
+	// If nil, the default configuration is used.
+	TLSClientConfig *tls.Config
+
+	// QuicConfig is the quic.Config used for dialing the proxy.
+	// Datagram support is always enabled.
+	// If nil, reasonable default values will be used.
+	QuicConfig *quic.Config
+
+	initOnce     sync.Once
+	roundTripper *http3.RoundTripper
+}
+
+func (c *Client) init() {
+	c.roundTripper = &http3.RoundTripper{
+		TLSClientConfig: c.TLSClientConfig,
+		QuicConfig:      c.QuicConfig,
+		EnableDatagrams: true,
+	}
+}
+
+// Dial establishes a UDP proxying flow to raddr, using the proxy identified by the template.
+// The response to the Extended CONNECT request is returned alongside the net.PacketConn.
+// It is also returned if the proxy refused to establish the flow.
+func (c *Client) Dial(ctx context.Context, template *Template, raddr *net.UDPAddr) (*http.Response, net.PacketConn, error) {
+	return c.dial(ctx, template, raddr.IP.String(), raddr.Port, raddr)
+}
+
+// DialAddr is like Dial, but the target is given in host:port notation.
+// If the host is a domain name, it is resolved by the proxy.
+func (c *Client) DialAddr(ctx context.Context, template *Template, target string) (*http.Response, net.PacketConn, error) {
+	host, portStr, err := net.SplitHostPort(target)
+	if err != nil {
+		return nil, nil, err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return nil, nil, fmt.Errorf("masque: invalid port: %w", err)
+	}
+	return c.dial(ctx, template, host, int(port), proxiedAddr(target))
+}
+
+func (c *Client) dial(ctx context.Context, template *Template, host string, port int, raddr net.Addr) (*http.Response, net.PacketConn, error) {
+	c.initOnce.Do(c.init)
+
+	u, err := url.Parse(template.expand(host, port))
+	if err != nil {
+		return nil, nil, err
+	}
+	req := &http.Request{
+		Method: http.MethodConnect,
+		Proto:  requestProtocol,
+		Header: http.Header{capsuleHeader: []string{capsuleProtocolHeaderValue}},
+		Host:   u.Host,
+		URL:    u,
+	}
+	req = req.WithContext(ctx)
+
+	rsp, err := c.roundTripper.RoundTripOpt(req, http3.RoundTripOpt{DontCloseRequestStream: true})
+	if err != nil {
+		return nil, nil, err
+	}
+	if rsp.StatusCode < 200 || rsp.StatusCode >= 300 {
+		return rsp, nil, fmt.Errorf("masque: proxy responded with status %d", rsp.StatusCode)
+	}
+	str, ok := rsp.Body.(http3.HTTPStreamer)
+	if !ok {
+		return nil, nil, errors.New("masque: failed to take over HTTP stream")
+	}
+	hijacker, ok := rsp.Body.(http3.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("masque: failed to hijack")
+	}
+	qconn, ok := hijacker.StreamCreator().(quic.Connection)
+	if !ok {
+		return nil, nil, errors.New("masque: failed to get the QUIC connection")
+	}
+	datagrams, ok := rsp.Body.(http3.HTTPDatagrammer)
+	if !ok {
+		return nil, nil, errors.New("masque: HTTP Datagrams not supported")
+	}
+	return rsp, newProxiedConn(str.HTTPStream(), datagrams, qconn.LocalAddr(), raddr), nil
+}
+
+// Close closes the QUIC connections to all proxies.
+func (c *Client) Close() error {
+	c.initOnce.Do(c.init)
+	return c.roundTripper.Close()
+}
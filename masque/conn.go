@@ -0,0 +1,155 @@
+package masque
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/quic-go/quic-go/quicvarint"
+)
+
+// proxiedAddr is the address of a target that is resolved by the proxy.
+type proxiedAddr string
+
+func (a proxiedAddr) Network() string { return "udp" }
+func (a proxiedAddr) String() string  { return string(a) }
+
+// flowAddr is the local address of a UDP proxying flow.
+// Multiple flows share the local address of the QUIC connection to the proxy,
+// so the request stream ID is used to tell them apart.
+type flowAddr struct {
+	net.Addr
+	streamID quic.StreamID
+}
+
+func (a *flowAddr) String() string { return fmt.Sprintf("%s (stream %d)", a.Addr, a.streamID) }
+
+// A proxiedConn is a net.PacketConn for a UDP proxying flow.
+// All packets are sent to and received from the target of the flow, the address passed to WriteTo is ignored.
+type proxiedConn struct {
+	str        http3.Stream
+	datagrams  http3.HTTPDatagrammer
+	localAddr  net.Addr
+	remoteAddr net.Addr
+
+	closeOnce sync.Once
+	closed    chan struct{}
+
+	deadlineMutex sync.Mutex
+	readDeadline  time.Time
+	// closed (and replaced) when the read deadline is changed
+	deadlineChanged chan struct{}
+}
+
+var _ net.PacketConn = &proxiedConn{}
+
+func newProxiedConn(str http3.Stream, datagrams http3.HTTPDatagrammer, localAddr, remoteAddr net.Addr) *proxiedConn {
+	return &proxiedConn{
+		str:             str,
+		datagrams:       datagrams,
+		localAddr:       &flowAddr{Addr: localAddr, streamID: str.StreamID()},
+		remoteAddr:      remoteAddr,
+		closed:          make(chan struct{}),
+		deadlineChanged: make(chan struct{}),
+	}
+}
+
+func (c *proxiedConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	for {
+		c.deadlineMutex.Lock()
+		deadline := c.readDeadline
+		deadlineChanged := c.deadlineChanged
+		c.deadlineMutex.Unlock()
+
+		var ctx context.Context
+		var cancel context.CancelFunc
+		if deadline.IsZero() {
+			ctx, cancel = context.WithCancel(context.Background())
+		} else {
+			ctx, cancel = context.WithDeadline(context.Background(), deadline)
+		}
+		go func() {
+			select {
+			case <-deadlineChanged:
+			case <-c.closed:
+			case <-ctx.Done():
+			}
+			cancel()
+		}()
+		data, err := c.datagrams.ReceiveDatagram(ctx)
+		cancel()
+		if err != nil {
+			select {
+			case <-c.closed:
+				return 0, nil, net.ErrClosed
+			case <-deadlineChanged:
+				continue
+			default:
+			}
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return 0, nil, os.ErrDeadlineExceeded
+			}
+			return 0, nil, err
+		}
+		r := bytes.NewReader(data)
+		contextID, err := quicvarint.Read(r)
+		// Datagrams with an unknown Context ID are dropped, see section 4 of RFC 9298.
+		if err != nil || contextID != contextIDZero {
+			continue
+		}
+		return copy(b, data[len(data)-r.Len():]), c.remoteAddr, nil
+	}
+}
+
+// WriteTo sends a packet to the target of the flow. The address is ignored.
+func (c *proxiedConn) WriteTo(b []byte, _ net.Addr) (int, error) {
+	select {
+	case <-c.closed:
+		return 0, net.ErrClosed
+	default:
+	}
+	data := make([]byte, 0, len(b)+1)
+	data = quicvarint.Append(data, contextIDZero)
+	data = append(data, b...)
+	if err := c.datagrams.SendDatagram(data); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Close closes the request stream, which terminates the flow.
+func (c *proxiedConn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		c.str.CancelRead(quic.StreamErrorCode(http3.ErrCodeNoError))
+		err = c.str.Close()
+	})
+	return err
+}
+
+func (c *proxiedConn) LocalAddr() net.Addr { return c.localAddr }
+
+func (c *proxiedConn) SetDeadline(t time.Time) error {
+	_ = c.SetWriteDeadline(t)
+	return c.SetReadDeadline(t)
+}
+
+func (c *proxiedConn) SetReadDeadline(t time.Time) error {
+	c.deadlineMutex.Lock()
+	defer c.deadlineMutex.Unlock()
+	c.readDeadline = t
+	close(c.deadlineChanged)
+	c.deadlineChanged = make(chan struct{})
+	return nil
+}
+
+// SetWriteDeadline is a no-op, deadlines only apply to reading.
+func (c *proxiedConn) SetWriteDeadline(time.Time) error { return nil }
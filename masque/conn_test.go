@@ -0,0 +1,137 @@
+package masque
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	mockquic "github.com/quic-go/quic-go/internal/mocks/quic"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type mockDatagrammer struct {
+	sent     chan []byte
+	received chan []byte
+	// closed when the request stream was closed
+	closed chan struct{}
+}
+
+func newMockDatagrammer() *mockDatagrammer {
+	return &mockDatagrammer{
+		sent:     make(chan []byte, 10),
+		received: make(chan []byte, 10),
+		closed:   make(chan struct{}),
+	}
+}
+
+func (d *mockDatagrammer) SendDatagram(b []byte) error {
+	d.sent <- append([]byte{}, b...)
+	return nil
+}
+
+func (d *mockDatagrammer) ReceiveDatagram(ctx context.Context) ([]byte, error) {
+	select {
+	case b := <-d.received:
+		return b, nil
+	case <-d.closed:
+		return nil, io.EOF
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+var _ = Describe("Proxied Conn", func() {
+	var (
+		str       *mockquic.MockStream
+		datagrams *mockDatagrammer
+		conn      *proxiedConn
+	)
+	localAddr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1234}
+	remoteAddr := &net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 4321}
+
+	BeforeEach(func() {
+		str = mockquic.NewMockStream(mockCtrl)
+		str.EXPECT().StreamID().Return(quic.StreamID(4)).AnyTimes()
+		datagrams = newMockDatagrammer()
+		conn = newProxiedConn(str, datagrams, localAddr, remoteAddr)
+	})
+
+	It("returns the addresses", func() {
+		Expect(conn.LocalAddr().Network()).To(Equal("udp"))
+		Expect(conn.LocalAddr().String()).To(Equal("127.0.0.1:1234 (stream 4)"))
+	})
+
+	It("sends packets", func() {
+		n, err := conn.WriteTo([]byte("foobar"), remoteAddr)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(n).To(Equal(6))
+		Expect(datagrams.sent).To(Receive(Equal([]byte("\x00foobar"))))
+	})
+
+	It("receives packets", func() {
+		datagrams.received <- []byte("\x01ignored")
+		datagrams.received <- []byte("\x00foobar")
+		b := make([]byte, 100)
+		n, addr, err := conn.ReadFrom(b)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(addr).To(Equal(remoteAddr))
+		Expect(b[:n]).To(Equal([]byte("foobar")))
+	})
+
+	It("returns the error once the request stream was closed", func() {
+		close(datagrams.closed)
+		_, _, err := conn.ReadFrom(make([]byte, 100))
+		Expect(err).To(MatchError(io.EOF))
+	})
+
+	It("times out reads", func() {
+		Expect(conn.SetReadDeadline(time.Now().Add(scaleDuration(20 * time.Millisecond)))).To(Succeed())
+		_, _, err := conn.ReadFrom(make([]byte, 100))
+		Expect(err).To(MatchError(os.ErrDeadlineExceeded))
+		nerr, ok := err.(net.Error)
+		Expect(ok).To(BeTrue())
+		Expect(nerr.Timeout()).To(BeTrue())
+	})
+
+	It("unblocks reads when the deadline is changed", func() {
+		errChan := make(chan error, 1)
+		go func() {
+			_, _, err := conn.ReadFrom(make([]byte, 100))
+			errChan <- err
+		}()
+		Consistently(errChan).ShouldNot(Receive())
+		Expect(conn.SetReadDeadline(time.Now())).To(Succeed())
+		Eventually(errChan).Should(Receive(MatchError(os.ErrDeadlineExceeded)))
+		// reads don't time out anymore once the deadline was reset
+		Expect(conn.SetReadDeadline(time.Time{})).To(Succeed())
+		go func() {
+			_, _, err := conn.ReadFrom(make([]byte, 100))
+			errChan <- err
+		}()
+		Consistently(errChan).ShouldNot(Receive())
+		datagrams.received <- []byte("\x00foobar")
+		Eventually(errChan).Should(Receive(BeNil()))
+	})
+
+	It("closes", func() {
+		errChan := make(chan error, 1)
+		go func() {
+			_, _, err := conn.ReadFrom(make([]byte, 100))
+			errChan <- err
+		}()
+		str.EXPECT().CancelRead(quic.StreamErrorCode(http3.ErrCodeNoError))
+		str.EXPECT().Close()
+		Expect(conn.Close()).To(Succeed())
+		Eventually(errChan).Should(Receive(MatchError(net.ErrClosed)))
+		_, err := conn.WriteTo([]byte("foobar"), remoteAddr)
+		Expect(err).To(MatchError(net.ErrClosed))
+		// closing again is a no-op
+		Expect(conn.Close()).To(Succeed())
+	})
+})
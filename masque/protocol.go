@@ -0,0 +1,16 @@
+package masque
+
+// The values used by RFC 9298.
+const (
+	// the value of the :protocol pseudo header field
+	requestProtocol = "connect-udp"
+	// the Context ID of UDP payloads, see section 4 of RFC 9298
+	contextIDZero = 0
+
+	capsuleHeader = "Capsule-Protocol"
+	// the Structured Field boolean true, see section 3.4 of RFC 9297
+	capsuleProtocolHeaderValue = "?1"
+)
+
+// the maximum size of a UDP payload proxied by the Proxy
+const maxUDPPayloadSize = 1500
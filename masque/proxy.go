@@ -0,0 +1,138 @@
+package masque
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/quic-go/quic-go/http3"
+	"github.com/quic-go/quic-go/quicvarint"
+)
+
+// A Proxy proxies UDP flows established by CONNECT-UDP requests (RFC 9298).
+// It is used from an http.Handler of an http3.Server.
+// UDP payloads are sent in QUIC DATAGRAM frames if the http3.Server enabled datagrams (and the client did as well),
+// and in DATAGRAM capsules on the request stream otherwise.
+type Proxy struct {
+	mutex    sync.Mutex
+	closed   bool
+	refCount sync.WaitGroup
+	conns    map[*net.UDPConn]struct{}
+}
+
+// Proxy opens a UDP socket toward the target of the request, and relays UDP payloads
+// until either the request stream or the socket is closed.
+// It sends the response, and must be called before anything was written to w.
+// Access control, e.g. restricting the targets, is left to the caller.
+func (s *Proxy) Proxy(w http.ResponseWriter, r *Request) error {
+	datagrams, ok := w.(http3.HTTPDatagrammer)
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		return errors.New("masque: HTTP Datagrams not supported")
+	}
+	addr, err := net.ResolveUDPAddr("udp", r.Target)
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		return err
+	}
+	conn, err := net.DialUDP("udp", nil, addr)
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		return err
+	}
+	defer conn.Close()
+
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return net.ErrClosed
+	}
+	if s.conns == nil {
+		s.conns = make(map[*net.UDPConn]struct{})
+	}
+	s.conns[conn] = struct{}{}
+	s.refCount.Add(1)
+	s.mutex.Unlock()
+	defer func() {
+		s.mutex.Lock()
+		delete(s.conns, conn)
+		s.mutex.Unlock()
+		s.refCount.Done()
+	}()
+
+	w.Header().Set(capsuleHeader, capsuleProtocolHeaderValue)
+	w.WriteHeader(http.StatusOK)
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var wg sync.WaitGroup
+	wg.Add(2)
+	errChan := make(chan error, 2)
+	go func() {
+		defer wg.Done()
+		errChan <- proxyToTarget(ctx, conn, datagrams)
+	}()
+	go func() {
+		defer wg.Done()
+		errChan <- proxyFromTarget(conn, datagrams)
+	}()
+	err = <-errChan
+	cancel()
+	conn.Close()
+	wg.Wait()
+	return err
+}
+
+// proxyToTarget sends the UDP payloads received from the client to the target.
+func proxyToTarget(ctx context.Context, conn *net.UDPConn, datagrams http3.HTTPDatagrammer) error {
+	for {
+		b, err := datagrams.ReceiveDatagram(ctx)
+		if err != nil {
+			return err
+		}
+		r := bytes.NewReader(b)
+		contextID, err := quicvarint.Read(r)
+		// Datagrams with an unknown Context ID are dropped, see section 4 of RFC 9298.
+		if err != nil || contextID != contextIDZero {
+			continue
+		}
+		if _, err := conn.Write(b[len(b)-r.Len():]); err != nil {
+			return err
+		}
+	}
+}
+
+// proxyFromTarget sends the UDP payloads received from the target to the client.
+func proxyFromTarget(conn *net.UDPConn, datagrams http3.HTTPDatagrammer) error {
+	b := make([]byte, 0, maxUDPPayloadSize+1)
+	b = quicvarint.Append(b, contextIDZero)
+	for {
+		n, err := conn.Read(b[1:cap(b)])
+		if err != nil {
+			return err
+		}
+		if err := datagrams.SendDatagram(b[:n+1]); err != nil {
+			return err
+		}
+	}
+}
+
+// Close closes the UDP sockets of all proxied flows, and waits for the Proxy calls to return.
+// Future calls to Proxy are rejected.
+func (s *Proxy) Close() error {
+	s.mutex.Lock()
+	s.closed = true
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mutex.Unlock()
+	s.refCount.Wait()
+	return nil
+}
@@ -0,0 +1,94 @@
+package masque
+
+import (
+	"net"
+	"net/http"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type mockResponseWriter struct {
+	*mockDatagrammer
+	header http.Header
+	status chan int
+}
+
+var _ http.Flusher = &mockResponseWriter{}
+
+func newMockResponseWriter() *mockResponseWriter {
+	return &mockResponseWriter{
+		mockDatagrammer: newMockDatagrammer(),
+		header:          http.Header{},
+		status:          make(chan int, 1),
+	}
+}
+
+func (w *mockResponseWriter) Header() http.Header         { return w.header }
+func (w *mockResponseWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *mockResponseWriter) WriteHeader(status int)      { w.status <- status }
+func (w *mockResponseWriter) Flush()                      {}
+
+var _ = Describe("Proxy", func() {
+	var target *net.UDPConn
+
+	BeforeEach(func() {
+		var err error
+		target, err = net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() { target.Close() })
+
+	It("proxies UDP payloads", func() {
+		var proxy Proxy
+		w := newMockResponseWriter()
+		errChan := make(chan error, 1)
+		go func() { errChan <- proxy.Proxy(w, &Request{Target: target.LocalAddr().String()}) }()
+		Eventually(w.status).Should(Receive(Equal(http.StatusOK)))
+		Expect(w.header.Get(capsuleHeader)).To(Equal(capsuleProtocolHeaderValue))
+
+		w.received <- []byte("\x00foobar")
+		// datagrams with a Context ID other than 0 are dropped
+		w.received <- []byte("\x01ignored")
+		w.received <- []byte("\x00raboof")
+		b := make([]byte, 100)
+		n, addr, err := target.ReadFrom(b)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(b[:n]).To(Equal([]byte("foobar")))
+		n, _, err = target.ReadFrom(b)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(b[:n]).To(Equal([]byte("raboof")))
+
+		_, err = target.WriteTo([]byte("lorem ipsum"), addr)
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(w.sent).Should(Receive(Equal([]byte("\x00lorem ipsum"))))
+
+		// the client closes the request stream
+		close(w.closed)
+		Eventually(errChan).Should(Receive())
+		Expect(proxy.Close()).To(Succeed())
+	})
+
+	It("terminates flows when closed", func() {
+		var proxy Proxy
+		w := newMockResponseWriter()
+		errChan := make(chan error, 1)
+		go func() { errChan <- proxy.Proxy(w, &Request{Target: target.LocalAddr().String()}) }()
+		Eventually(w.status).Should(Receive(Equal(http.StatusOK)))
+		Consistently(errChan).ShouldNot(Receive())
+		Expect(proxy.Close()).To(Succeed())
+		Eventually(errChan).Should(Receive(MatchError(net.ErrClosed)))
+
+		w = newMockResponseWriter()
+		Expect(proxy.Proxy(w, &Request{Target: target.LocalAddr().String()})).To(MatchError(net.ErrClosed))
+		Expect(w.status).To(Receive(Equal(http.StatusServiceUnavailable)))
+	})
+
+	It("responds with 502 if the target can't be resolved", func() {
+		var proxy Proxy
+		w := newMockResponseWriter()
+		Expect(proxy.Proxy(w, &Request{Target: "foo.invalid:1234"})).ToNot(Succeed())
+		Expect(w.status).To(Receive(Equal(http.StatusBadGateway)))
+	})
+})
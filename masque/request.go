@@ -0,0 +1,77 @@
+package masque
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// A RequestParseError is returned from ParseRequest if the request is invalid.
+// The proxy is expected to respond with HTTPStatus.
+type RequestParseError struct {
+	HTTPStatus int
+	Err        error
+}
+
+func (e *RequestParseError) Error() string { return e.Err.Error() }
+func (e *RequestParseError) Unwrap() error { return e.Err }
+
+// A Request is a validated CONNECT-UDP request.
+type Request struct {
+	// Target is the address of the UDP target, in host:port notation.
+	// The host might be a domain name, which is resolved by the proxy.
+	Target string
+}
+
+// ParseRequest validates an Extended CONNECT request for UDP proxying, see section 3.4 of RFC 9298.
+// The request URI must match the template.
+func ParseRequest(r *http.Request, template *Template) (*Request, error) {
+	if r.Method != http.MethodConnect {
+		return nil, &RequestParseError{
+			HTTPStatus: http.StatusMethodNotAllowed,
+			Err:        fmt.Errorf("expected CONNECT request, got %s", r.Method),
+		}
+	}
+	if r.Proto != requestProtocol {
+		return nil, &RequestParseError{
+			HTTPStatus: http.StatusNotImplemented,
+			Err:        fmt.Errorf("unexpected protocol: %s", r.Proto),
+		}
+	}
+	if !strings.EqualFold(r.Host, template.host) {
+		return nil, &RequestParseError{
+			HTTPStatus: http.StatusBadRequest,
+			Err:        fmt.Errorf("host in :authority (%s) doesn't match the template host (%s)", r.Host, template.host),
+		}
+	}
+	if r.Header.Get(capsuleHeader) != capsuleProtocolHeaderValue {
+		return nil, &RequestParseError{
+			HTTPStatus: http.StatusBadRequest,
+			Err:        fmt.Errorf("missing or invalid %s header", capsuleHeader),
+		}
+	}
+	host, portStr, ok := template.match(r.URL)
+	if !ok {
+		return nil, &RequestParseError{
+			HTTPStatus: http.StatusBadRequest,
+			Err:        errors.New("request URI doesn't match the template"),
+		}
+	}
+	if host == "" {
+		return nil, &RequestParseError{
+			HTTPStatus: http.StatusBadRequest,
+			Err:        errors.New("missing target host"),
+		}
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil || port == 0 {
+		return nil, &RequestParseError{
+			HTTPStatus: http.StatusBadRequest,
+			Err:        fmt.Errorf("invalid target port: %q", portStr),
+		}
+	}
+	return &Request{Target: net.JoinHostPort(host, strconv.Itoa(int(port)))}, nil
+}
@@ -0,0 +1,77 @@
+package masque
+
+import (
+	"net/http"
+	"net/url"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Request", func() {
+	var template *Template
+
+	BeforeEach(func() {
+		var err error
+		template, err = NewTemplate("https://localhost:1234/masque?h={target_host}&p={target_port}")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	newRequest := func(target string) *http.Request {
+		u, err := url.Parse(target)
+		Expect(err).ToNot(HaveOccurred())
+		return &http.Request{
+			Method: http.MethodConnect,
+			Proto:  requestProtocol,
+			Host:   u.Host,
+			URL:    u,
+			Header: http.Header{capsuleHeader: []string{capsuleProtocolHeaderValue}},
+		}
+	}
+
+	It("parses requests", func() {
+		req, err := ParseRequest(newRequest("https://localhost:1234/masque?h=localhost&p=1337"), template)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(req.Target).To(Equal("localhost:1337"))
+	})
+
+	It("parses requests for IPv6 targets", func() {
+		req, err := ParseRequest(newRequest("https://localhost:1234/masque?h=%3A%3A1&p=1337"), template)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(req.Target).To(Equal("[::1]:1337"))
+	})
+
+	It("rejects invalid requests", func() {
+		expectParseError := func(r *http.Request, status int, errMsg string) {
+			_, err := ParseRequest(r, template)
+			ExpectWithOffset(1, err).To(MatchError(errMsg))
+			var parseErr *RequestParseError
+			ExpectWithOffset(1, err).To(BeAssignableToTypeOf(parseErr))
+			ExpectWithOffset(1, err.(*RequestParseError).HTTPStatus).To(Equal(status))
+		}
+
+		req := newRequest("https://localhost:1234/masque?h=localhost&p=1337")
+		req.Method = http.MethodGet
+		expectParseError(req, http.StatusMethodNotAllowed, "expected CONNECT request, got GET")
+
+		req = newRequest("https://localhost:1234/masque?h=localhost&p=1337")
+		req.Proto = "webtransport"
+		expectParseError(req, http.StatusNotImplemented, "unexpected protocol: webtransport")
+
+		expectParseError(
+			newRequest("https://example.com:1234/masque?h=localhost&p=1337"),
+			http.StatusBadRequest,
+			"host in :authority (example.com:1234) doesn't match the template host (localhost:1234)",
+		)
+
+		req = newRequest("https://localhost:1234/masque?h=localhost&p=1337")
+		req.Header.Del(capsuleHeader)
+		expectParseError(req, http.StatusBadRequest, "missing or invalid Capsule-Protocol header")
+
+		expectParseError(newRequest("https://localhost:1234/foobar?h=localhost&p=1337"), http.StatusBadRequest, "request URI doesn't match the template")
+		expectParseError(newRequest("https://localhost:1234/masque?p=1337"), http.StatusBadRequest, "missing target host")
+		expectParseError(newRequest("https://localhost:1234/masque?h=localhost&p=0"), http.StatusBadRequest, `invalid target port: "0"`)
+		expectParseError(newRequest("https://localhost:1234/masque?h=localhost&p=foo"), http.StatusBadRequest, `invalid target port: "foo"`)
+		expectParseError(newRequest("https://localhost:1234/masque?h=localhost&p=100000"), http.StatusBadRequest, `invalid target port: "100000"`)
+	})
+})
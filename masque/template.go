@@ -0,0 +1,313 @@
+package masque
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	varTargetHost = "target_host"
+	varTargetPort = "target_port"
+)
+
+// A Template is the URI Template (RFC 6570) of a UDP proxying endpoint, see section 3 of RFC 9298.
+// It must contain the variables target_host and target_port exactly once, for example:
+//
+//	https://proxy.example.org/.well-known/masque/udp/{target_host}/{target_port}/
+//	https://proxy.example.org/masque?h={target_host}&p={target_port}
+//	https://proxy.example.org/masque{?target_host,target_port}
+//
+// Only simple string expansion ({var}) and form-style query expansion ({?var1,var2}) are supported.
+// Expressions are only allowed in the path and the query.
+type Template struct {
+	raw string
+
+	scheme string
+	host   string
+	path   []templatePart
+	// the parameters of a literal query, e.g. ?h={target_host}&p={target_port}
+	query []queryParam
+	// the variables of a form-style query expansion, e.g. {?target_host,target_port}
+	queryVars []string
+}
+
+// A templatePart is either a literal or a variable.
+type templatePart struct {
+	literal  string
+	variable string
+}
+
+type queryParam struct {
+	name  string
+	value templatePart
+}
+
+// NewTemplate parses a URI Template.
+func NewTemplate(raw string) (*Template, error) {
+	scheme, rest, ok := strings.Cut(raw, "://")
+	if !ok {
+		return nil, errors.New("masque: template is not an absolute URI")
+	}
+	if scheme != "https" {
+		return nil, fmt.Errorf("masque: unsupported scheme: %s", scheme)
+	}
+	t := &Template{raw: raw, scheme: scheme}
+	if i := strings.IndexAny(rest, "/?{"); i >= 0 {
+		t.host, rest = rest[:i], rest[i:]
+	} else {
+		t.host, rest = rest, ""
+	}
+	// a form-style query expansion might directly follow the authority
+	if strings.ContainsRune(t.host, '}') || (strings.HasPrefix(rest, "{") && !strings.HasPrefix(rest, "{?")) {
+		return nil, errors.New("masque: expressions in the authority are not supported")
+	}
+	if t.host == "" {
+		return nil, errors.New("masque: template doesn't contain a host")
+	}
+	if !strings.HasPrefix(rest, "/") {
+		t.path = append(t.path, templatePart{literal: "/"})
+	}
+
+	for len(rest) > 0 {
+		switch {
+		case strings.HasPrefix(rest, "{?"):
+			end := strings.IndexByte(rest, '}')
+			if end == -1 {
+				return nil, errors.New("masque: unterminated expression")
+			}
+			for _, name := range strings.Split(rest[2:end], ",") {
+				if err := validateVariableName(name); err != nil {
+					return nil, err
+				}
+				t.queryVars = append(t.queryVars, name)
+			}
+			if rest[end+1:] != "" {
+				return nil, errors.New("masque: form-style query expansion must be at the end of the template")
+			}
+			rest = ""
+		case rest[0] == '{':
+			end := strings.IndexByte(rest, '}')
+			if end == -1 {
+				return nil, errors.New("masque: unterminated expression")
+			}
+			name := rest[1:end]
+			if err := validateVariableName(name); err != nil {
+				return nil, err
+			}
+			if len(t.path) > 0 && t.path[len(t.path)-1].variable != "" {
+				return nil, errors.New("masque: variables in the path must be separated by a literal")
+			}
+			t.path = append(t.path, templatePart{variable: name})
+			rest = rest[end+1:]
+		case rest[0] == '?':
+			query, err := parseTemplateQuery(rest[1:])
+			if err != nil {
+				return nil, err
+			}
+			t.query = query
+			rest = ""
+		default:
+			end := strings.IndexAny(rest, "{?}")
+			if end == -1 {
+				end = len(rest)
+			} else if rest[end] == '}' {
+				return nil, errors.New("masque: unexpected '}'")
+			}
+			t.path = append(t.path, templatePart{literal: rest[:end]})
+			rest = rest[end:]
+		}
+	}
+
+	if err := t.validateVariables(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func parseTemplateQuery(query string) ([]queryParam, error) {
+	var params []queryParam
+	for _, kv := range strings.Split(query, "&") {
+		name, value, _ := strings.Cut(kv, "=")
+		if name == "" || strings.ContainsAny(name, "{}") {
+			return nil, fmt.Errorf("masque: invalid query parameter: %q", kv)
+		}
+		param := queryParam{name: name}
+		if strings.HasPrefix(value, "{") && strings.HasSuffix(value, "}") {
+			param.value.variable = value[1 : len(value)-1]
+			if err := validateVariableName(param.value.variable); err != nil {
+				return nil, err
+			}
+		} else if strings.ContainsAny(value, "{}") {
+			return nil, fmt.Errorf("masque: invalid query parameter: %q", kv)
+		} else {
+			param.value.literal = value
+		}
+		params = append(params, param)
+	}
+	return params, nil
+}
+
+func validateVariableName(name string) error {
+	switch name {
+	case varTargetHost, varTargetPort:
+		return nil
+	case "":
+		return errors.New("masque: empty expression")
+	}
+	return fmt.Errorf("masque: unsupported expression: {%s}", name)
+}
+
+func (t *Template) variables() []string {
+	vars := append([]string{}, t.queryVars...)
+	for _, p := range t.path {
+		if p.variable != "" {
+			vars = append(vars, p.variable)
+		}
+	}
+	for _, p := range t.query {
+		if p.value.variable != "" {
+			vars = append(vars, p.value.variable)
+		}
+	}
+	return vars
+}
+
+func (t *Template) validateVariables() error {
+	var hasHost, hasPort bool
+	for _, v := range t.variables() {
+		switch v {
+		case varTargetHost:
+			if hasHost {
+				return fmt.Errorf("masque: duplicate variable %s", v)
+			}
+			hasHost = true
+		case varTargetPort:
+			if hasPort {
+				return fmt.Errorf("masque: duplicate variable %s", v)
+			}
+			hasPort = true
+		}
+	}
+	if !hasHost {
+		return fmt.Errorf("masque: template doesn't contain %s", varTargetHost)
+	}
+	if !hasPort {
+		return fmt.Errorf("masque: template doesn't contain %s", varTargetPort)
+	}
+	return nil
+}
+
+// String returns the template as it was passed to NewTemplate.
+func (t *Template) String() string { return t.raw }
+
+// expand expands the template for the given target.
+// An IPv6 address must be passed without square brackets, see section 3 of RFC 9298.
+func (t *Template) expand(host string, port int) string {
+	vals := map[string]string{varTargetHost: host, varTargetPort: strconv.Itoa(port)}
+	var sb strings.Builder
+	sb.WriteString(t.scheme)
+	sb.WriteString("://")
+	sb.WriteString(t.host)
+	for _, p := range t.path {
+		if p.variable != "" {
+			sb.WriteString(escape(vals[p.variable]))
+		} else {
+			sb.WriteString(p.literal)
+		}
+	}
+	for i, p := range t.query {
+		if i == 0 {
+			sb.WriteByte('?')
+		} else {
+			sb.WriteByte('&')
+		}
+		sb.WriteString(p.name)
+		sb.WriteByte('=')
+		if p.value.variable != "" {
+			sb.WriteString(escape(vals[p.value.variable]))
+		} else {
+			sb.WriteString(p.value.literal)
+		}
+	}
+	for i, name := range t.queryVars {
+		if i == 0 {
+			sb.WriteByte('?')
+		} else {
+			sb.WriteByte('&')
+		}
+		sb.WriteString(name)
+		sb.WriteByte('=')
+		sb.WriteString(escape(vals[name]))
+	}
+	return sb.String()
+}
+
+// match matches the path and the query of a request URI against the template.
+// It returns the (unescaped) values of the target_host and target_port variables.
+func (t *Template) match(u *url.URL) (host, port string, ok bool) {
+	vals := make(map[string]string, 2)
+	path := u.EscapedPath()
+	for i, p := range t.path {
+		if p.variable == "" {
+			if !strings.HasPrefix(path, p.literal) {
+				return "", "", false
+			}
+			path = path[len(p.literal):]
+			continue
+		}
+		end := len(path)
+		if i+1 < len(t.path) {
+			end = strings.Index(path, t.path[i+1].literal)
+			if end == -1 {
+				return "", "", false
+			}
+		}
+		if strings.Contains(path[:end], "/") {
+			return "", "", false
+		}
+		v, err := url.PathUnescape(path[:end])
+		if err != nil {
+			return "", "", false
+		}
+		vals[p.variable] = v
+		path = path[end:]
+	}
+	if path != "" {
+		return "", "", false
+	}
+
+	query := u.Query()
+	for _, p := range t.query {
+		if p.value.variable != "" {
+			vals[p.value.variable] = query.Get(p.name)
+		} else if query.Get(p.name) != p.value.literal {
+			return "", "", false
+		}
+	}
+	for _, name := range t.queryVars {
+		vals[name] = query.Get(name)
+	}
+	return vals[varTargetHost], vals[varTargetPort], true
+}
+
+// escape percent-encodes all characters that are not unreserved, as required for simple string expansion.
+func escape(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if isUnreserved(c) {
+			sb.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&sb, "%%%02X", c)
+	}
+	return sb.String()
+}
+
+func isUnreserved(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
@@ -0,0 +1,108 @@
+package masque
+
+import (
+	"net/url"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Template", func() {
+	mustParseURL := func(s string) *url.URL {
+		u, err := url.Parse(s)
+		Expect(err).ToNot(HaveOccurred())
+		return u
+	}
+
+	It("expands and matches templates with variables in the path", func() {
+		t, err := NewTemplate("https://proxy.example.org/.well-known/masque/udp/{target_host}/{target_port}/")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(t.String()).To(Equal("https://proxy.example.org/.well-known/masque/udp/{target_host}/{target_port}/"))
+		expanded := t.expand("192.0.2.6", 443)
+		Expect(expanded).To(Equal("https://proxy.example.org/.well-known/masque/udp/192.0.2.6/443/"))
+		host, port, ok := t.match(mustParseURL(expanded))
+		Expect(ok).To(BeTrue())
+		Expect(host).To(Equal("192.0.2.6"))
+		Expect(port).To(Equal("443"))
+	})
+
+	It("expands and matches templates with variables in a literal query", func() {
+		t, err := NewTemplate("https://proxy.example.org:4443/masque?h={target_host}&p={target_port}")
+		Expect(err).ToNot(HaveOccurred())
+		expanded := t.expand("example.com", 1337)
+		Expect(expanded).To(Equal("https://proxy.example.org:4443/masque?h=example.com&p=1337"))
+		host, port, ok := t.match(mustParseURL(expanded))
+		Expect(ok).To(BeTrue())
+		Expect(host).To(Equal("example.com"))
+		Expect(port).To(Equal("1337"))
+	})
+
+	It("expands and matches templates with a form-style query expansion", func() {
+		t, err := NewTemplate("https://proxy.example.org:4443/masque{?target_host,target_port}")
+		Expect(err).ToNot(HaveOccurred())
+		expanded := t.expand("example.com", 1337)
+		Expect(expanded).To(Equal("https://proxy.example.org:4443/masque?target_host=example.com&target_port=1337"))
+		host, port, ok := t.match(mustParseURL(expanded))
+		Expect(ok).To(BeTrue())
+		Expect(host).To(Equal("example.com"))
+		Expect(port).To(Equal("1337"))
+	})
+
+	It("handles templates without a path", func() {
+		t, err := NewTemplate("https://proxy.example.org{?target_host,target_port}")
+		Expect(err).ToNot(HaveOccurred())
+		expanded := t.expand("example.com", 1337)
+		Expect(expanded).To(Equal("https://proxy.example.org/?target_host=example.com&target_port=1337"))
+		_, _, ok := t.match(mustParseURL(expanded))
+		Expect(ok).To(BeTrue())
+	})
+
+	It("percent-encodes IPv6 addresses", func() {
+		t, err := NewTemplate("https://proxy.example.org/masque/{target_host}/{target_port}/")
+		Expect(err).ToNot(HaveOccurred())
+		expanded := t.expand("2001:db8::42", 443)
+		Expect(expanded).To(Equal("https://proxy.example.org/masque/2001%3Adb8%3A%3A42/443/"))
+		host, port, ok := t.match(mustParseURL(expanded))
+		Expect(ok).To(BeTrue())
+		Expect(host).To(Equal("2001:db8::42"))
+		Expect(port).To(Equal("443"))
+	})
+
+	It("doesn't match URIs that don't match the template", func() {
+		t, err := NewTemplate("https://proxy.example.org/masque/{target_host}/{target_port}/")
+		Expect(err).ToNot(HaveOccurred())
+		for _, u := range []string{
+			"https://proxy.example.org/foobar/example.com/443/",
+			"https://proxy.example.org/masque/example.com/443",
+			"https://proxy.example.org/masque/example.com/443/foo",
+			"https://proxy.example.org/masque/foo/bar/443/",
+		} {
+			_, _, ok := t.match(mustParseURL(u))
+			Expect(ok).To(BeFalse(), u)
+		}
+		t, err = NewTemplate("https://proxy.example.org/masque?h={target_host}&p={target_port}&v=1")
+		Expect(err).ToNot(HaveOccurred())
+		_, _, ok := t.match(mustParseURL("https://proxy.example.org/masque?h=example.com&p=443&v=2"))
+		Expect(ok).To(BeFalse())
+	})
+
+	It("rejects invalid templates", func() {
+		for tmpl, errMsg := range map[string]string{
+			"/masque/{target_host}/{target_port}":                                 "masque: template is not an absolute URI",
+			"http://proxy.example.org/{target_host}/{target_port}":                "masque: unsupported scheme: http",
+			"https:///{target_host}/{target_port}":                                "masque: template doesn't contain a host",
+			"https://{target_host}.example.org/{target_port}":                     "masque: expressions in the authority are not supported",
+			"https://proxy.example.org/{target_host}":                             "masque: template doesn't contain target_port",
+			"https://proxy.example.org/{target_port}":                             "masque: template doesn't contain target_host",
+			"https://proxy.example.org/{target_host}/{target_port}/{foo}":         "masque: unsupported expression: {foo}",
+			"https://proxy.example.org/{+target_host}/{target_port}":              "masque: unsupported expression: {+target_host}",
+			"https://proxy.example.org/{target_host}{target_port}":                "masque: variables in the path must be separated by a literal",
+			"https://proxy.example.org/{target_host}/{target_port":                "masque: unterminated expression",
+			"https://proxy.example.org/{target_host}/{target_host}/{target_port}": "masque: duplicate variable target_host",
+			"https://proxy.example.org{?target_host,target_port}/foo":             "masque: form-style query expansion must be at the end of the template",
+		} {
+			_, err := NewTemplate(tmpl)
+			Expect(err).To(MatchError(errMsg), tmpl)
+		}
+	})
+})
@@ -79,6 +79,81 @@ func (c *PackerAppendPacketCall) DoAndReturn(f func(*packetBuffer, protocol.Byte
 	return c
 }
 
+// AppendPacketOnPath mocks base method.
+func (m *MockPacker) AppendPacketOnPath(arg0 *packetBuffer, arg1 packerPath, arg2 protocol.ByteCount, arg3 protocol.VersionNumber) (shortHeaderPacket, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AppendPacketOnPath", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(shortHeaderPacket)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AppendPacketOnPath indicates an expected call of AppendPacketOnPath.
+func (mr *MockPackerMockRecorder) AppendPacketOnPath(arg0, arg1, arg2, arg3 any) *PackerAppendPacketOnPathCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AppendPacketOnPath", reflect.TypeOf((*MockPacker)(nil).AppendPacketOnPath), arg0, arg1, arg2, arg3)
+	return &PackerAppendPacketOnPathCall{Call: call}
+}
+
+// PackerAppendPacketOnPathCall wrap *gomock.Call
+type PackerAppendPacketOnPathCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *PackerAppendPacketOnPathCall) Return(arg0 shortHeaderPacket, arg1 error) *PackerAppendPacketOnPathCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *PackerAppendPacketOnPathCall) Do(f func(*packetBuffer, packerPath, protocol.ByteCount, protocol.VersionNumber) (shortHeaderPacket, error)) *PackerAppendPacketOnPathCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *PackerAppendPacketOnPathCall) DoAndReturn(f func(*packetBuffer, packerPath, protocol.ByteCount, protocol.VersionNumber) (shortHeaderPacket, error)) *PackerAppendPacketOnPathCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// EnableMultipath mocks base method.
+func (m *MockPacker) EnableMultipath(arg0 pathAckFrameSource) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "EnableMultipath", arg0)
+}
+
+// EnableMultipath indicates an expected call of EnableMultipath.
+func (mr *MockPackerMockRecorder) EnableMultipath(arg0 any) *PackerEnableMultipathCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableMultipath", reflect.TypeOf((*MockPacker)(nil).EnableMultipath), arg0)
+	return &PackerEnableMultipathCall{Call: call}
+}
+
+// PackerEnableMultipathCall wrap *gomock.Call
+type PackerEnableMultipathCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *PackerEnableMultipathCall) Return() *PackerEnableMultipathCall {
+	c.Call = c.Call.Return()
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *PackerEnableMultipathCall) Do(f func(pathAckFrameSource)) *PackerEnableMultipathCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *PackerEnableMultipathCall) DoAndReturn(f func(pathAckFrameSource)) *PackerEnableMultipathCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // MaybePackProbePacket mocks base method.
 func (m *MockPacker) MaybePackProbePacket(arg0 protocol.EncryptionLevel, arg1 protocol.ByteCount, arg2 protocol.VersionNumber) (*coalescedPacket, error) {
 	m.ctrl.T.Helper()
@@ -315,6 +390,86 @@ func (c *PackerPackMTUProbePacketCall) DoAndReturn(f func(ackhandler.Frame, prot
 	return c
 }
 
+// PackPathProbePacket mocks base method.
+func (m *MockPacker) PackPathProbePacket(arg0 protocol.ConnectionID, arg1 []ackhandler.Frame, arg2 protocol.VersionNumber) (shortHeaderPacket, *packetBuffer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PackPathProbePacket", arg0, arg1, arg2)
+	ret0, _ := ret[0].(shortHeaderPacket)
+	ret1, _ := ret[1].(*packetBuffer)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// PackPathProbePacket indicates an expected call of PackPathProbePacket.
+func (mr *MockPackerMockRecorder) PackPathProbePacket(arg0, arg1, arg2 any) *PackerPackPathProbePacketCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PackPathProbePacket", reflect.TypeOf((*MockPacker)(nil).PackPathProbePacket), arg0, arg1, arg2)
+	return &PackerPackPathProbePacketCall{Call: call}
+}
+
+// PackerPackPathProbePacketCall wrap *gomock.Call
+type PackerPackPathProbePacketCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *PackerPackPathProbePacketCall) Return(arg0 shortHeaderPacket, arg1 *packetBuffer, arg2 error) *PackerPackPathProbePacketCall {
+	c.Call = c.Call.Return(arg0, arg1, arg2)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *PackerPackPathProbePacketCall) Do(f func(protocol.ConnectionID, []ackhandler.Frame, protocol.VersionNumber) (shortHeaderPacket, *packetBuffer, error)) *PackerPackPathProbePacketCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *PackerPackPathProbePacketCall) DoAndReturn(f func(protocol.ConnectionID, []ackhandler.Frame, protocol.VersionNumber) (shortHeaderPacket, *packetBuffer, error)) *PackerPackPathProbePacketCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// PackPathProbePacketOnPath mocks base method.
+func (m *MockPacker) PackPathProbePacketOnPath(arg0 packerPath, arg1 []ackhandler.Frame, arg2 protocol.VersionNumber) (shortHeaderPacket, *packetBuffer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PackPathProbePacketOnPath", arg0, arg1, arg2)
+	ret0, _ := ret[0].(shortHeaderPacket)
+	ret1, _ := ret[1].(*packetBuffer)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// PackPathProbePacketOnPath indicates an expected call of PackPathProbePacketOnPath.
+func (mr *MockPackerMockRecorder) PackPathProbePacketOnPath(arg0, arg1, arg2 any) *PackerPackPathProbePacketOnPathCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PackPathProbePacketOnPath", reflect.TypeOf((*MockPacker)(nil).PackPathProbePacketOnPath), arg0, arg1, arg2)
+	return &PackerPackPathProbePacketOnPathCall{Call: call}
+}
+
+// PackerPackPathProbePacketOnPathCall wrap *gomock.Call
+type PackerPackPathProbePacketOnPathCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *PackerPackPathProbePacketOnPathCall) Return(arg0 shortHeaderPacket, arg1 *packetBuffer, arg2 error) *PackerPackPathProbePacketOnPathCall {
+	c.Call = c.Call.Return(arg0, arg1, arg2)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *PackerPackPathProbePacketOnPathCall) Do(f func(packerPath, []ackhandler.Frame, protocol.VersionNumber) (shortHeaderPacket, *packetBuffer, error)) *PackerPackPathProbePacketOnPathCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *PackerPackPathProbePacketOnPathCall) DoAndReturn(f func(packerPath, []ackhandler.Frame, protocol.VersionNumber) (shortHeaderPacket, *packetBuffer, error)) *PackerPackPathProbePacketOnPathCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // SetToken mocks base method.
 func (m *MockPacker) SetToken(arg0 []byte) {
 	m.ctrl.T.Helper()
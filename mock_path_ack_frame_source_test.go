@@ -0,0 +1,77 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/quic-go/quic-go (interfaces: PathAckFrameSource)
+//
+// Generated by this command:
+//
+//	mockgen -typed -build_flags=-tags=gomock -package quic -self_package github.com/quic-go/quic-go -destination mock_path_ack_frame_source_test.go github.com/quic-go/quic-go PathAckFrameSource
+//
+// Package quic is a generated GoMock package.
+package quic
+
+import (
+	reflect "reflect"
+
+	wire "github.com/quic-go/quic-go/internal/wire"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPathAckFrameSource is a mock of PathAckFrameSource interface.
+type MockPathAckFrameSource struct {
+	ctrl     *gomock.Controller
+	recorder *MockPathAckFrameSourceMockRecorder
+}
+
+// MockPathAckFrameSourceMockRecorder is the mock recorder for MockPathAckFrameSource.
+type MockPathAckFrameSourceMockRecorder struct {
+	mock *MockPathAckFrameSource
+}
+
+// NewMockPathAckFrameSource creates a new mock instance.
+func NewMockPathAckFrameSource(ctrl *gomock.Controller) *MockPathAckFrameSource {
+	mock := &MockPathAckFrameSource{ctrl: ctrl}
+	mock.recorder = &MockPathAckFrameSourceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPathAckFrameSource) EXPECT() *MockPathAckFrameSourceMockRecorder {
+	return m.recorder
+}
+
+// GetPathAckFrames mocks base method.
+func (m *MockPathAckFrameSource) GetPathAckFrames(arg0 bool) []*wire.PathAckFrame {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPathAckFrames", arg0)
+	ret0, _ := ret[0].([]*wire.PathAckFrame)
+	return ret0
+}
+
+// GetPathAckFrames indicates an expected call of GetPathAckFrames.
+func (mr *MockPathAckFrameSourceMockRecorder) GetPathAckFrames(arg0 any) *PathAckFrameSourceGetPathAckFramesCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPathAckFrames", reflect.TypeOf((*MockPathAckFrameSource)(nil).GetPathAckFrames), arg0)
+	return &PathAckFrameSourceGetPathAckFramesCall{Call: call}
+}
+
+// PathAckFrameSourceGetPathAckFramesCall wrap *gomock.Call
+type PathAckFrameSourceGetPathAckFramesCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *PathAckFrameSourceGetPathAckFramesCall) Return(arg0 []*wire.PathAckFrame) *PathAckFrameSourceGetPathAckFramesCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *PathAckFrameSourceGetPathAckFramesCall) Do(f func(bool) []*wire.PathAckFrame) *PathAckFrameSourceGetPathAckFramesCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *PathAckFrameSourceGetPathAckFramesCall) DoAndReturn(f func(bool) []*wire.PathAckFrame) *PathAckFrameSourceGetPathAckFramesCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
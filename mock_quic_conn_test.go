@@ -119,6 +119,45 @@ func (c *QUICConnAcceptUniStreamCall) DoAndReturn(f func(context.Context) (Recei
 	return c
 }
 
+// AddPath mocks base method.
+func (m *MockQUICConn) AddPath(arg0 *Transport) (*Path, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddPath", arg0)
+	ret0, _ := ret[0].(*Path)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddPath indicates an expected call of AddPath.
+func (mr *MockQUICConnMockRecorder) AddPath(arg0 any) *QUICConnAddPathCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddPath", reflect.TypeOf((*MockQUICConn)(nil).AddPath), arg0)
+	return &QUICConnAddPathCall{Call: call}
+}
+
+// QUICConnAddPathCall wrap *gomock.Call
+type QUICConnAddPathCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *QUICConnAddPathCall) Return(arg0 *Path, arg1 error) *QUICConnAddPathCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *QUICConnAddPathCall) Do(f func(*Transport) (*Path, error)) *QUICConnAddPathCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *QUICConnAddPathCall) DoAndReturn(f func(*Transport) (*Path, error)) *QUICConnAddPathCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // CloseWithError mocks base method.
 func (m *MockQUICConn) CloseWithError(arg0 qerr.ApplicationErrorCode, arg1 string) error {
 	m.ctrl.T.Helper()
@@ -656,6 +695,44 @@ func (c *QUICConnSendDatagramCall) DoAndReturn(f func([]byte) error) *QUICConnSe
 	return c
 }
 
+// Stats mocks base method.
+func (m *MockQUICConn) Stats() ConnectionStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stats")
+	ret0, _ := ret[0].(ConnectionStats)
+	return ret0
+}
+
+// Stats indicates an expected call of Stats.
+func (mr *MockQUICConnMockRecorder) Stats() *QUICConnStatsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stats", reflect.TypeOf((*MockQUICConn)(nil).Stats))
+	return &QUICConnStatsCall{Call: call}
+}
+
+// QUICConnStatsCall wrap *gomock.Call
+type QUICConnStatsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *QUICConnStatsCall) Return(arg0 ConnectionStats) *QUICConnStatsCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *QUICConnStatsCall) Do(f func() ConnectionStats) *QUICConnStatsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *QUICConnStatsCall) DoAndReturn(f func() ConnectionStats) *QUICConnStatsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // destroy mocks base method.
 func (m *MockQUICConn) destroy(arg0 error) {
 	m.ctrl.T.Helper()
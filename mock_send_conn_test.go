@@ -153,6 +153,44 @@ func (c *SendConnRemoteAddrCall) DoAndReturn(f func() net.Addr) *SendConnRemoteA
 	return c
 }
 
+// WithRemoteAddr mocks base method.
+func (m *MockSendConn) WithRemoteAddr(arg0 net.Addr, arg1 packetInfo) sendConn {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithRemoteAddr", arg0, arg1)
+	ret0, _ := ret[0].(sendConn)
+	return ret0
+}
+
+// WithRemoteAddr indicates an expected call of WithRemoteAddr.
+func (mr *MockSendConnMockRecorder) WithRemoteAddr(arg0, arg1 any) *SendConnWithRemoteAddrCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithRemoteAddr", reflect.TypeOf((*MockSendConn)(nil).WithRemoteAddr), arg0, arg1)
+	return &SendConnWithRemoteAddrCall{Call: call}
+}
+
+// SendConnWithRemoteAddrCall wrap *gomock.Call
+type SendConnWithRemoteAddrCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *SendConnWithRemoteAddrCall) Return(arg0 sendConn) *SendConnWithRemoteAddrCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *SendConnWithRemoteAddrCall) Do(f func(net.Addr, packetInfo) sendConn) *SendConnWithRemoteAddrCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *SendConnWithRemoteAddrCall) DoAndReturn(f func(net.Addr, packetInfo) sendConn) *SendConnWithRemoteAddrCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // Write mocks base method.
 func (m *MockSendConn) Write(arg0 []byte, arg1 uint16, arg2 protocol.ECN) error {
 	m.ctrl.T.Helper()
@@ -191,6 +229,44 @@ func (c *SendConnWriteCall) DoAndReturn(f func([]byte, uint16, protocol.ECN) err
 	return c
 }
 
+// WriteTo mocks base method.
+func (m *MockSendConn) WriteTo(arg0 []byte, arg1 net.Addr) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteTo", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteTo indicates an expected call of WriteTo.
+func (mr *MockSendConnMockRecorder) WriteTo(arg0, arg1 any) *SendConnWriteToCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteTo", reflect.TypeOf((*MockSendConn)(nil).WriteTo), arg0, arg1)
+	return &SendConnWriteToCall{Call: call}
+}
+
+// SendConnWriteToCall wrap *gomock.Call
+type SendConnWriteToCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *SendConnWriteToCall) Return(arg0 error) *SendConnWriteToCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *SendConnWriteToCall) Do(f func([]byte, net.Addr) error) *SendConnWriteToCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *SendConnWriteToCall) DoAndReturn(f func([]byte, net.Addr) error) *SendConnWriteToCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // capabilities mocks base method.
 func (m *MockSendConn) capabilities() connCapabilities {
 	m.ctrl.T.Helper()
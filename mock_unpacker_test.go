@@ -120,3 +120,45 @@ func (c *UnpackerUnpackShortHeaderCall) DoAndReturn(f func(time.Time, []byte) (p
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
+
+// UnpackShortHeaderOnPath mocks base method.
+func (m *MockUnpacker) UnpackShortHeaderOnPath(arg0 time.Time, arg1 []byte, arg2 uint64) (protocol.PacketNumber, protocol.PacketNumberLen, protocol.KeyPhaseBit, []byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnpackShortHeaderOnPath", arg0, arg1, arg2)
+	ret0, _ := ret[0].(protocol.PacketNumber)
+	ret1, _ := ret[1].(protocol.PacketNumberLen)
+	ret2, _ := ret[2].(protocol.KeyPhaseBit)
+	ret3, _ := ret[3].([]byte)
+	ret4, _ := ret[4].(error)
+	return ret0, ret1, ret2, ret3, ret4
+}
+
+// UnpackShortHeaderOnPath indicates an expected call of UnpackShortHeaderOnPath.
+func (mr *MockUnpackerMockRecorder) UnpackShortHeaderOnPath(arg0, arg1, arg2 any) *UnpackerUnpackShortHeaderOnPathCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnpackShortHeaderOnPath", reflect.TypeOf((*MockUnpacker)(nil).UnpackShortHeaderOnPath), arg0, arg1, arg2)
+	return &UnpackerUnpackShortHeaderOnPathCall{Call: call}
+}
+
+// UnpackerUnpackShortHeaderOnPathCall wrap *gomock.Call
+type UnpackerUnpackShortHeaderOnPathCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *UnpackerUnpackShortHeaderOnPathCall) Return(arg0 protocol.PacketNumber, arg1 protocol.PacketNumberLen, arg2 protocol.KeyPhaseBit, arg3 []byte, arg4 error) *UnpackerUnpackShortHeaderOnPathCall {
+	c.Call = c.Call.Return(arg0, arg1, arg2, arg3, arg4)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *UnpackerUnpackShortHeaderOnPathCall) Do(f func(time.Time, []byte, uint64) (protocol.PacketNumber, protocol.PacketNumberLen, protocol.KeyPhaseBit, []byte, error)) *UnpackerUnpackShortHeaderOnPathCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *UnpackerUnpackShortHeaderOnPathCall) DoAndReturn(f func(time.Time, []byte, uint64) (protocol.PacketNumber, protocol.PacketNumberLen, protocol.KeyPhaseBit, []byte, error)) *UnpackerUnpackShortHeaderOnPathCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
@@ -0,0 +1,325 @@
+package quic
+
+import (
+	"crypto/rand"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/quic-go/quic-go/congestion"
+	"github.com/quic-go/quic-go/internal/ackhandler"
+	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/internal/utils"
+	"github.com/quic-go/quic-go/internal/wire"
+)
+
+// A multipathPath is an additional path of a multipath connection that packets are sent on.
+// The initial path is not a multipathPath, it's managed by the connection itself.
+type multipathPath struct {
+	// id is the sequence number of the destination connection ID used on the path
+	id     uint64
+	connID protocol.ConnectionID
+	// outgoingID identifies the path in the connIDManager
+	outgoingID pathID
+
+	conn              sendConn
+	sendQueue         sender
+	sentPacketHandler ackhandler.SentPacketHandler
+	rttStats          *utils.RTTStats
+	// Path MTU discovery is not performed on additional paths.
+	maxPacketSize protocol.ByteCount
+
+	validated         bool
+	pathChallenges    [][8]byte
+	pathChallengeSent time.Time // only set for the server
+	standby           bool
+}
+
+func (p *multipathPath) packerPath() packerPath {
+	return packerPath{ID: p.id, ConnID: p.connID, PNManager: p.sentPacketHandler}
+}
+
+// A multipathReceivePath is the packet number space of packets received on an additional path.
+type multipathReceivePath struct {
+	receivedPacketHandler ackhandler.ReceivedPacketHandler
+	// the address the last packet on this path was received from
+	remoteAddr net.Addr
+	// the highest sequence number of the PATH_STATUS frames received for this path
+	statusSeq uint64
+}
+
+// The multipathManager manages the additional paths of a multipath connection (draft-ietf-quic-multipath-04).
+// A path is identified by the sequence number of the connection ID used on the path.
+// This means that the two directions of a path use different path IDs:
+// Packets are sent using the sequence number of the peer's connection ID,
+// and received using the sequence number of our connection ID.
+type multipathManager struct {
+	perspective protocol.Perspective
+	// used for the ACK delay of the packets received on all paths
+	rttStats *utils.RTTStats
+	// newSendQueue creates a send queue for a new path, and starts running it
+	newSendQueue func(sendConn) sender
+	// newCongestionControl creates the congestion controller for a new path, nil for the default
+	newCongestionControl func(congestion.RTTStats, protocol.ByteCount) congestion.SendAlgorithm
+	logger               utils.Logger
+
+	nextOutgoingID pathID // only used by the server
+	nextStatusSeq  uint64
+
+	paths        map[uint64]*multipathPath
+	receivePaths map[uint64]*multipathReceivePath
+}
+
+var _ pathAckFrameSource = &multipathManager{}
+
+func newMultipathManager(
+	perspective protocol.Perspective,
+	rttStats *utils.RTTStats,
+	newSendQueue func(sendConn) sender,
+	newCongestionControl func(congestion.RTTStats, protocol.ByteCount) congestion.SendAlgorithm,
+	logger utils.Logger,
+) *multipathManager {
+	return &multipathManager{
+		perspective:          perspective,
+		rttStats:             rttStats,
+		newSendQueue:         newSendQueue,
+		newCongestionControl: newCongestionControl,
+		logger:               logger,
+		paths:                make(map[uint64]*multipathPath),
+		receivePaths:         make(map[uint64]*multipathReceivePath),
+	}
+}
+
+// AddPath adds a new path that packets can be sent on.
+// The path can't be used until it's validated.
+func (m *multipathManager) AddPath(id uint64, connID protocol.ConnectionID, outgoingID pathID, conn sendConn) *multipathPath {
+	rttStats := utils.NewRTTStats()
+	rttStats.SetMaxAckDelay(m.rttStats.MaxAckDelay())
+	maxPacketSize := getMaxPacketSize(conn.RemoteAddr())
+	p := &multipathPath{
+		id:                id,
+		connID:            connID,
+		outgoingID:        outgoingID,
+		conn:              conn,
+		sendQueue:         m.newSendQueue(conn),
+		sentPacketHandler: ackhandler.NewPathSentPacketHandler(maxPacketSize, rttStats, false, m.newCongestionControl, m.perspective, m.logger),
+		rttStats:          rttStats,
+		maxPacketSize:     maxPacketSize,
+	}
+	m.paths[id] = p
+	return p
+}
+
+// NextOutgoingID returns the ID used to get a connection ID for a new path from the connIDManager.
+// It is only used by the server, the client uses the ID of the Path.
+func (m *multipathManager) NextOutgoingID() pathID {
+	id := m.nextOutgoingID
+	m.nextOutgoingID++
+	return id
+}
+
+func (m *multipathManager) Path(id uint64) (*multipathPath, bool) {
+	p, ok := m.paths[id]
+	return p, ok
+}
+
+// PathForOutgoingID returns the path for the Path created by the client.
+func (m *multipathManager) PathForOutgoingID(id pathID) (*multipathPath, bool) {
+	for _, p := range m.paths {
+		if p.outgoingID == id {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// PathForRemoteAddr returns the path to a remote address.
+// It is only used by the server, since all paths of the client use the same remote address.
+func (m *multipathManager) PathForRemoteAddr(addr net.Addr) (*multipathPath, bool) {
+	for _, p := range m.paths {
+		if addrsEqual(p.conn.RemoteAddr(), addr) {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// SendPathForReceivePath returns the path that packets are sent on,
+// if the peer sends packets on the receive path id on the same 4-tuple.
+// This can only be determined by the server, using the client's address.
+func (m *multipathManager) SendPathForReceivePath(id uint64) (*multipathPath, bool) {
+	if m.perspective == protocol.PerspectiveClient {
+		return nil, false
+	}
+	rp, ok := m.receivePaths[id]
+	if !ok {
+		return nil, false
+	}
+	return m.PathForRemoteAddr(rp.remoteAddr)
+}
+
+// Paths returns all paths, sorted by their ID.
+func (m *multipathManager) Paths() []*multipathPath {
+	paths := make([]*multipathPath, 0, len(m.paths))
+	for _, p := range m.paths {
+		paths = append(paths, p)
+	}
+	sort.Slice(paths, func(i, j int) bool { return paths[i].id < paths[j].id })
+	return paths
+}
+
+// ReceivedBytes accounts for bytes received from a remote address on the server.
+// Until a path is validated, the server is limited by the anti-amplification limit.
+func (m *multipathManager) ReceivedBytes(addr net.Addr, n protocol.ByteCount) {
+	if p, ok := m.PathForRemoteAddr(addr); ok {
+		p.sentPacketHandler.ReceivedBytes(n)
+	}
+}
+
+// ReceivedPacketHandler returns the ReceivedPacketHandler for packets received on path id.
+// It must only be called after the packet was successfully decrypted.
+func (m *multipathManager) ReceivedPacketHandler(id uint64, remoteAddr net.Addr) ackhandler.ReceivedPacketHandler {
+	rp, ok := m.receivePaths[id]
+	if !ok {
+		rp = &multipathReceivePath{receivedPacketHandler: ackhandler.NewPathReceivedPacketHandler(m.rttStats, m.logger)}
+		m.receivePaths[id] = rp
+	}
+	if remoteAddr != nil {
+		rp.remoteAddr = remoteAddr
+	}
+	return rp.receivedPacketHandler
+}
+
+// GetPathAckFrames returns the PATH_ACK frames for all paths that packets were received on.
+func (m *multipathManager) GetPathAckFrames(onlyIfQueued bool) []*wire.PathAckFrame {
+	var frames []*wire.PathAckFrame
+	for id, rp := range m.receivePaths {
+		ack := rp.receivedPacketHandler.GetAckFrame(protocol.Encryption1RTT, onlyIfQueued)
+		if ack == nil {
+			continue
+		}
+		frames = append(frames, &wire.PathAckFrame{PathID: id, AckFrame: *ack})
+	}
+	sort.Slice(frames, func(i, j int) bool { return frames[i].PathID < frames[j].PathID })
+	return frames
+}
+
+// NewPathChallenge creates a new PATH_CHALLENGE frame for validating the path.
+func (m *multipathManager) NewPathChallenge(p *multipathPath, now time.Time) *wire.PathChallengeFrame {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	p.pathChallenges = append(p.pathChallenges, b)
+	p.pathChallengeSent = now
+	return &wire.PathChallengeFrame{Data: b}
+}
+
+// HandlePathResponseFrame handles a PATH_RESPONSE frame.
+// Just as on the initial path, the PATH_RESPONSE may be received on any path.
+// It returns the path that was validated, if any.
+func (m *multipathManager) HandlePathResponseFrame(f *wire.PathResponseFrame) (*multipathPath, bool) {
+	for _, p := range m.paths {
+		if p.validated {
+			continue
+		}
+		for _, c := range p.pathChallenges {
+			if c == f.Data {
+				p.validated = true
+				p.pathChallenges = nil
+				p.sentPacketHandler.SetPathValidated()
+				return p, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// HandlePathStatusFrame handles a PATH_STATUS frame.
+// Status updates for a path are only applied if they are newer than the last one received.
+func (m *multipathManager) HandlePathStatusFrame(f *wire.PathStatusFrame) {
+	rp, ok := m.receivePaths[f.PathID]
+	if ok {
+		if f.SequenceNumber < rp.statusSeq {
+			return
+		}
+		rp.statusSeq = f.SequenceNumber
+	}
+	if p, ok := m.SendPathForReceivePath(f.PathID); ok {
+		p.standby = f.Status == wire.PathStatusStandby
+	}
+}
+
+// NextStatusSequenceNumber returns the sequence number for the next PATH_STATUS frame.
+func (m *multipathManager) NextStatusSequenceNumber() uint64 {
+	seq := m.nextStatusSeq
+	m.nextStatusSeq++
+	return seq
+}
+
+// AbandonPath stops sending packets on a path.
+// All frames sent on the path that haven't been acknowledged yet are retransmitted on the remaining paths.
+func (m *multipathManager) AbandonPath(p *multipathPath, now time.Time) {
+	p.sentPacketHandler.MigratedPath(now, true, true)
+	p.sendQueue.Close()
+	delete(m.paths, p.id)
+}
+
+// DropReceivePath drops the packet number space of packets received on a path.
+func (m *multipathManager) DropReceivePath(id uint64) {
+	delete(m.receivePaths, id)
+}
+
+// SchedulerPaths returns the additional paths that can be used for sending.
+func (m *multipathManager) SchedulerPaths(now time.Time) []schedulerPath {
+	paths := make([]schedulerPath, 0, len(m.paths))
+	for _, p := range m.paths {
+		if !p.validated {
+			continue
+		}
+		sendMode := p.sentPacketHandler.SendMode(now)
+		if p.sendQueue.WouldBlock() {
+			sendMode = ackhandler.SendNone
+		}
+		paths = append(paths, schedulerPath{
+			id:       p.id,
+			rtt:      p.rttStats.SmoothedRTT(),
+			sendMode: sendMode,
+			standby:  p.standby,
+		})
+	}
+	return paths
+}
+
+// NextTimeout returns the earliest time that a timer of one of the paths expires,
+// or the time the next packet can be sent on a pacing limited path.
+func (m *multipathManager) NextTimeout(now time.Time) time.Time {
+	var deadline time.Time
+	for _, rp := range m.receivePaths {
+		deadline = utils.MinNonZeroTime(deadline, rp.receivedPacketHandler.GetAlarmTimeout())
+	}
+	for _, p := range m.paths {
+		deadline = utils.MinNonZeroTime(deadline, p.sentPacketHandler.GetLossDetectionTimeout())
+		if p.validated && p.sentPacketHandler.SendMode(now) == ackhandler.SendPacingLimited {
+			deadline = utils.MinNonZeroTime(deadline, p.sentPacketHandler.TimeUntilSend())
+		}
+	}
+	return deadline
+}
+
+// OnLossDetectionTimeouts runs the loss detection for all paths whose loss detection timer expired.
+func (m *multipathManager) OnLossDetectionTimeouts(now time.Time) error {
+	for _, p := range m.paths {
+		if timeout := p.sentPacketHandler.GetLossDetectionTimeout(); !timeout.IsZero() && timeout.Before(now) {
+			if err := p.sentPacketHandler.OnLossDetectionTimeout(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Close closes the send queues of all paths.
+func (m *multipathManager) Close() {
+	for _, p := range m.paths {
+		p.sendQueue.Close()
+	}
+}
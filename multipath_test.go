@@ -0,0 +1,212 @@
+package quic
+
+import (
+	"net"
+	"time"
+
+	"github.com/quic-go/quic-go/internal/ackhandler"
+	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/internal/utils"
+	"github.com/quic-go/quic-go/internal/wire"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Multipath Manager", func() {
+	var (
+		m          *multipathManager
+		sendQueues map[sendConn]*MockSender
+	)
+	remoteAddr1 := &net.UDPAddr{IP: net.IPv4(192, 168, 0, 1), Port: 1337}
+	remoteAddr2 := &net.UDPAddr{IP: net.IPv4(192, 168, 0, 2), Port: 1337}
+
+	newManager := func(pers protocol.Perspective) *multipathManager {
+		return newMultipathManager(
+			pers,
+			utils.NewRTTStats(),
+			func(c sendConn) sender {
+				q := NewMockSender(mockCtrl)
+				sendQueues[c] = q
+				return q
+			},
+			nil,
+			utils.DefaultLogger,
+		)
+	}
+
+	newSendConn := func(addr net.Addr) sendConn {
+		conn := NewMockSendConn(mockCtrl)
+		conn.EXPECT().RemoteAddr().Return(addr).AnyTimes()
+		return conn
+	}
+
+	validate := func(p *multipathPath) {
+		f := m.NewPathChallenge(p, time.Now())
+		validated, ok := m.HandlePathResponseFrame(&wire.PathResponseFrame{Data: f.Data})
+		ExpectWithOffset(1, ok).To(BeTrue())
+		ExpectWithOffset(1, validated).To(Equal(p))
+	}
+
+	BeforeEach(func() {
+		sendQueues = make(map[sendConn]*MockSender)
+		m = newManager(protocol.PerspectiveServer)
+	})
+
+	It("adds paths", func() {
+		conn1 := newSendConn(remoteAddr1)
+		conn2 := newSendConn(remoteAddr2)
+		p2 := m.AddPath(2, protocol.ParseConnectionID([]byte{2, 2, 2, 2}), 5, conn2)
+		p1 := m.AddPath(1, protocol.ParseConnectionID([]byte{1, 1, 1, 1}), 3, conn1)
+		Expect(p1.sendQueue).To(Equal(sendQueues[conn1]))
+		Expect(p1.packerPath()).To(Equal(packerPath{
+			ID:        1,
+			ConnID:    protocol.ParseConnectionID([]byte{1, 1, 1, 1}),
+			PNManager: p1.sentPacketHandler,
+		}))
+		Expect(m.Paths()).To(Equal([]*multipathPath{p1, p2}))
+		p, ok := m.Path(2)
+		Expect(ok).To(BeTrue())
+		Expect(p).To(Equal(p2))
+		p, ok = m.PathForOutgoingID(3)
+		Expect(ok).To(BeTrue())
+		Expect(p).To(Equal(p1))
+		_, ok = m.PathForOutgoingID(4)
+		Expect(ok).To(BeFalse())
+		p, ok = m.PathForRemoteAddr(remoteAddr2)
+		Expect(ok).To(BeTrue())
+		Expect(p).To(Equal(p2))
+	})
+
+	It("hands out IDs for paths opened by the server", func() {
+		Expect(m.NextOutgoingID()).To(BeEquivalentTo(0))
+		Expect(m.NextOutgoingID()).To(BeEquivalentTo(1))
+	})
+
+	It("validates paths", func() {
+		p := m.AddPath(1, protocol.ParseConnectionID([]byte{1, 1, 1, 1}), 0, newSendConn(remoteAddr1))
+		f1 := m.NewPathChallenge(p, time.Now())
+		f2 := m.NewPathChallenge(p, time.Now())
+		Expect(f1.Data).ToNot(Equal(f2.Data))
+		_, ok := m.HandlePathResponseFrame(&wire.PathResponseFrame{Data: [8]byte{1, 2, 3, 4, 5, 6, 7, 8}})
+		Expect(ok).To(BeFalse())
+		Expect(p.validated).To(BeFalse())
+		validated, ok := m.HandlePathResponseFrame(&wire.PathResponseFrame{Data: f1.Data})
+		Expect(ok).To(BeTrue())
+		Expect(validated).To(Equal(p))
+		Expect(p.validated).To(BeTrue())
+		// duplicate PATH_RESPONSE frames are ignored
+		_, ok = m.HandlePathResponseFrame(&wire.PathResponseFrame{Data: f2.Data})
+		Expect(ok).To(BeFalse())
+	})
+
+	It("generates PATH_ACK frames", func() {
+		Expect(m.GetPathAckFrames(false)).To(BeEmpty())
+		now := time.Now()
+		rph2 := m.ReceivedPacketHandler(2, remoteAddr2)
+		Expect(rph2.ReceivedPacket(10, protocol.ECNNon, protocol.Encryption1RTT, now, true)).To(Succeed())
+		rph1 := m.ReceivedPacketHandler(1, remoteAddr1)
+		Expect(rph1.ReceivedPacket(5, protocol.ECNNon, protocol.Encryption1RTT, now, false)).To(Succeed())
+		Expect(m.ReceivedPacketHandler(1, nil)).To(Equal(rph1))
+		// only the ack-eliciting packet causes an ACK to be queued
+		frames := m.GetPathAckFrames(true)
+		Expect(frames).To(HaveLen(1))
+		Expect(frames[0].PathID).To(BeEquivalentTo(2))
+		Expect(frames[0].LargestAcked()).To(Equal(protocol.PacketNumber(10)))
+		Expect(rph1.ReceivedPacket(6, protocol.ECNNon, protocol.Encryption1RTT, now, true)).To(Succeed())
+		frames = m.GetPathAckFrames(false)
+		Expect(frames).To(HaveLen(1))
+		Expect(frames[0].PathID).To(BeEquivalentTo(1))
+		Expect(frames[0].LargestAcked()).To(Equal(protocol.PacketNumber(6)))
+		Expect(frames[0].LowestAcked()).To(Equal(protocol.PacketNumber(5)))
+		m.DropReceivePath(1)
+		Expect(rph1.ReceivedPacket(7, protocol.ECNNon, protocol.Encryption1RTT, now, true)).To(Succeed())
+		Expect(m.GetPathAckFrames(false)).To(BeEmpty())
+	})
+
+	It("maps receive paths to send paths on the server", func() {
+		p := m.AddPath(3, protocol.ParseConnectionID([]byte{3, 3, 3, 3}), 0, newSendConn(remoteAddr2))
+		_, ok := m.SendPathForReceivePath(1)
+		Expect(ok).To(BeFalse())
+		m.ReceivedPacketHandler(1, remoteAddr2)
+		sendPath, ok := m.SendPathForReceivePath(1)
+		Expect(ok).To(BeTrue())
+		Expect(sendPath).To(Equal(p))
+	})
+
+	It("doesn't map receive paths to send paths on the client", func() {
+		m = newManager(protocol.PerspectiveClient)
+		m.AddPath(3, protocol.ParseConnectionID([]byte{3, 3, 3, 3}), 0, newSendConn(remoteAddr2))
+		m.ReceivedPacketHandler(1, remoteAddr2)
+		_, ok := m.SendPathForReceivePath(1)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("handles PATH_STATUS frames", func() {
+		p := m.AddPath(3, protocol.ParseConnectionID([]byte{3, 3, 3, 3}), 0, newSendConn(remoteAddr2))
+		m.ReceivedPacketHandler(1, remoteAddr2)
+		m.HandlePathStatusFrame(&wire.PathStatusFrame{PathID: 1, SequenceNumber: 1, Status: wire.PathStatusStandby})
+		Expect(p.standby).To(BeTrue())
+		// reordered PATH_STATUS frames are ignored
+		m.HandlePathStatusFrame(&wire.PathStatusFrame{PathID: 1, SequenceNumber: 0, Status: wire.PathStatusAvailable})
+		Expect(p.standby).To(BeTrue())
+		m.HandlePathStatusFrame(&wire.PathStatusFrame{PathID: 1, SequenceNumber: 2, Status: wire.PathStatusAvailable})
+		Expect(p.standby).To(BeFalse())
+	})
+
+	It("hands out sequence numbers for PATH_STATUS frames", func() {
+		Expect(m.NextStatusSequenceNumber()).To(BeEquivalentTo(0))
+		Expect(m.NextStatusSequenceNumber()).To(BeEquivalentTo(1))
+	})
+
+	It("returns the paths that can be used for sending", func() {
+		conn1 := newSendConn(remoteAddr1)
+		conn2 := newSendConn(remoteAddr2)
+		p1 := m.AddPath(1, protocol.ParseConnectionID([]byte{1, 1, 1, 1}), 0, conn1)
+		m.AddPath(2, protocol.ParseConnectionID([]byte{2, 2, 2, 2}), 1, conn2)
+		Expect(m.SchedulerPaths(time.Now())).To(BeEmpty())
+		// The server is limited by the anti-amplification limit until the path is validated.
+		validate(p1)
+		p1.standby = true
+		sendQueues[conn1].EXPECT().WouldBlock()
+		Expect(m.SchedulerPaths(time.Now())).To(Equal([]schedulerPath{
+			{id: 1, sendMode: ackhandler.SendAny, standby: true},
+		}))
+		sendQueues[conn1].EXPECT().WouldBlock().Return(true)
+		Expect(m.SchedulerPaths(time.Now())).To(Equal([]schedulerPath{
+			{id: 1, sendMode: ackhandler.SendNone, standby: true},
+		}))
+	})
+
+	It("abandons paths", func() {
+		conn := newSendConn(remoteAddr1)
+		p := m.AddPath(1, protocol.ParseConnectionID([]byte{1, 1, 1, 1}), 0, conn)
+		sendQueues[conn].EXPECT().Close()
+		m.AbandonPath(p, time.Now())
+		_, ok := m.Path(1)
+		Expect(ok).To(BeFalse())
+		Expect(m.Paths()).To(BeEmpty())
+	})
+
+	It("closes the send queues of all paths", func() {
+		conn1 := newSendConn(remoteAddr1)
+		conn2 := newSendConn(remoteAddr2)
+		m.AddPath(1, protocol.ParseConnectionID([]byte{1, 1, 1, 1}), 0, conn1)
+		m.AddPath(2, protocol.ParseConnectionID([]byte{2, 2, 2, 2}), 1, conn2)
+		sendQueues[conn1].EXPECT().Close()
+		sendQueues[conn2].EXPECT().Close()
+		m.Close()
+	})
+
+	It("returns the ACK timeout of the receive paths", func() {
+		Expect(m.NextTimeout(time.Now())).To(BeZero())
+		now := time.Now()
+		rph := m.ReceivedPacketHandler(1, remoteAddr1)
+		Expect(rph.ReceivedPacket(1, protocol.ECNNon, protocol.Encryption1RTT, now, true)).To(Succeed())
+		Expect(m.GetPathAckFrames(true)).To(HaveLen(1))
+		// the ACK for the second packet is delayed
+		Expect(rph.ReceivedPacket(2, protocol.ECNNon, protocol.Encryption1RTT, now, true)).To(Succeed())
+		Expect(m.NextTimeout(now)).ToNot(BeZero())
+		Expect(m.NextTimeout(now)).To(Equal(rph.GetAlarmTimeout()))
+	})
+})
@@ -25,8 +25,12 @@ type packer interface {
 	PackConnectionClose(*qerr.TransportError, protocol.ByteCount, protocol.VersionNumber) (*coalescedPacket, error)
 	PackApplicationClose(*qerr.ApplicationError, protocol.ByteCount, protocol.VersionNumber) (*coalescedPacket, error)
 	PackMTUProbePacket(ping ackhandler.Frame, size protocol.ByteCount, v protocol.VersionNumber) (shortHeaderPacket, *packetBuffer, error)
+	PackPathProbePacket(connID protocol.ConnectionID, frames []ackhandler.Frame, v protocol.VersionNumber) (shortHeaderPacket, *packetBuffer, error)
+	AppendPacketOnPath(buf *packetBuffer, path packerPath, maxPacketSize protocol.ByteCount, v protocol.VersionNumber) (shortHeaderPacket, error)
+	PackPathProbePacketOnPath(path packerPath, frames []ackhandler.Frame, v protocol.VersionNumber) (shortHeaderPacket, *packetBuffer, error)
 
 	SetToken([]byte)
+	EnableMultipath(pathAckFrameSource)
 }
 
 type sealer interface {
@@ -114,6 +118,21 @@ type ackFrameSource interface {
 	GetAckFrame(encLevel protocol.EncryptionLevel, onlyIfQueued bool) *wire.AckFrame
 }
 
+// A pathAckFrameSource provides the PATH_ACK frames for the additional paths of a multipath connection.
+type pathAckFrameSource interface {
+	GetPathAckFrames(onlyIfQueued bool) []*wire.PathAckFrame
+}
+
+// A packerPath is the path that a 1-RTT packet is packed for.
+// On multipath connections, every path uses its own packet number space.
+type packerPath struct {
+	// ID is the sequence number of the destination connection ID.
+	// It is 0 for the initial path, and for all packets sent on connections that don't use multipath.
+	ID        uint64
+	ConnID    protocol.ConnectionID
+	PNManager packetNumberManager
+}
+
 type packetPacker struct {
 	srcConnID     protocol.ConnectionID
 	getDestConnID func() protocol.ConnectionID
@@ -129,6 +148,7 @@ type packetPacker struct {
 	pnManager           packetNumberManager
 	framer              frameSource
 	acks                ackFrameSource
+	pathAcks            pathAckFrameSource // only set for multipath connections
 	datagramQueue       *datagramQueue
 	retransmissionQueue *retransmissionQueue
 	rand                rand.Rand
@@ -274,7 +294,7 @@ func (p *packetPacker) packConnectionClose(
 			paddingLen = p.initialPaddingLen(payloads[i].frames, size, maxPacketSize)
 		}
 		if encLevel == protocol.Encryption1RTT {
-			shp, err := p.appendShortHeaderPacket(buffer, connID, oneRTTPacketNumber, oneRTTPacketNumberLen, keyPhase, payloads[i], paddingLen, maxPacketSize, sealers[i], false, v)
+			shp, err := p.appendShortHeaderPacket(buffer, p.initialPath(connID), oneRTTPacketNumber, oneRTTPacketNumberLen, keyPhase, payloads[i], paddingLen, maxPacketSize, sealers[i], false, v)
 			if err != nil {
 				return nil, err
 			}
@@ -431,7 +451,7 @@ func (p *packetPacker) PackCoalescedPacket(onlyAck bool, maxPacketSize protocol.
 		}
 		packet.longHdrPackets = append(packet.longHdrPackets, longHdrPacket)
 	} else if oneRTTPayload.length > 0 {
-		shp, err := p.appendShortHeaderPacket(buffer, connID, oneRTTPacketNumber, oneRTTPacketNumberLen, kp, oneRTTPayload, 0, maxPacketSize, oneRTTSealer, false, v)
+		shp, err := p.appendShortHeaderPacket(buffer, p.initialPath(connID), oneRTTPacketNumber, oneRTTPacketNumberLen, kp, oneRTTPayload, 0, maxPacketSize, oneRTTSealer, false, v)
 		if err != nil {
 			return nil, err
 		}
@@ -454,21 +474,36 @@ func (p *packetPacker) AppendPacket(buf *packetBuffer, maxPacketSize protocol.By
 	return p.appendPacket(buf, false, maxPacketSize, v)
 }
 
+// AppendPacketOnPath packs a packet for an additional path of a multipath connection.
+// ACK frames for the initial path's packet number space are only sent on the initial path.
+// It should be called after the handshake is confirmed.
+func (p *packetPacker) AppendPacketOnPath(buf *packetBuffer, path packerPath, maxPacketSize protocol.ByteCount, v protocol.VersionNumber) (shortHeaderPacket, error) {
+	return p.appendPacketOnPath(buf, path, false, maxPacketSize, v)
+}
+
 func (p *packetPacker) appendPacket(buf *packetBuffer, onlyAck bool, maxPacketSize protocol.ByteCount, v protocol.VersionNumber) (shortHeaderPacket, error) {
+	return p.appendPacketOnPath(buf, p.initialPath(p.getDestConnID()), onlyAck, maxPacketSize, v)
+}
+
+func (p *packetPacker) appendPacketOnPath(buf *packetBuffer, path packerPath, onlyAck bool, maxPacketSize protocol.ByteCount, v protocol.VersionNumber) (shortHeaderPacket, error) {
 	sealer, err := p.cryptoSetup.Get1RTTSealer()
 	if err != nil {
 		return shortHeaderPacket{}, err
 	}
-	pn, pnLen := p.pnManager.PeekPacketNumber(protocol.Encryption1RTT)
-	connID := p.getDestConnID()
-	hdrLen := wire.ShortHeaderLen(connID, pnLen)
-	pl := p.maybeGetShortHeaderPacket(sealer, hdrLen, maxPacketSize, onlyAck, true, v)
+	pn, pnLen := path.PNManager.PeekPacketNumber(protocol.Encryption1RTT)
+	hdrLen := wire.ShortHeaderLen(path.ConnID, pnLen)
+	pl := p.maybeGetShortHeaderPacket(sealer, hdrLen, maxPacketSize, onlyAck, path.ID == 0, v)
 	if pl.length == 0 {
 		return shortHeaderPacket{}, errNothingToPack
 	}
 	kp := sealer.KeyPhase()
 
-	return p.appendShortHeaderPacket(buf, connID, pn, pnLen, kp, pl, 0, maxPacketSize, sealer, false, v)
+	return p.appendShortHeaderPacket(buf, path, pn, pnLen, kp, pl, 0, maxPacketSize, sealer, false, v)
+}
+
+// initialPath is the path used for all packets on connections that don't use multipath.
+func (p *packetPacker) initialPath(connID protocol.ConnectionID) packerPath {
+	return packerPath{ConnID: connID, PNManager: p.pnManager}
 }
 
 func (p *packetPacker) maybeGetCryptoPacket(maxPacketSize protocol.ByteCount, encLevel protocol.EncryptionLevel, onlyAck, ackAllowed bool, v protocol.VersionNumber) (*wire.ExtendedHeader, payload) {
@@ -562,8 +597,8 @@ func (p *packetPacker) maybeGetAppDataPacket(maxPayloadSize protocol.ByteCount,
 	pl := p.composeNextPacket(maxPayloadSize, onlyAck, ackAllowed, v)
 
 	// check if we have anything to send
-	if len(pl.frames) == 0 && len(pl.streamFrames) == 0 {
-		if pl.ack == nil {
+	if len(pl.streamFrames) == 0 && !ackhandler.HasAckElicitingFrames(pl.frames) {
+		if pl.ack == nil && len(pl.frames) == 0 {
 			return payload{}
 		}
 		// the packet only contains an ACK
@@ -583,10 +618,11 @@ func (p *packetPacker) maybeGetAppDataPacket(maxPayloadSize protocol.ByteCount,
 
 func (p *packetPacker) composeNextPacket(maxFrameSize protocol.ByteCount, onlyAck, ackAllowed bool, v protocol.VersionNumber) payload {
 	if onlyAck {
+		var pl payload
 		if ack := p.acks.GetAckFrame(protocol.Encryption1RTT, true); ack != nil {
-			return payload{ack: ack, length: ack.Length(v)}
+			pl = payload{ack: ack, length: ack.Length(v)}
 		}
-		return payload{}
+		return p.appendPathAckFrames(pl, maxFrameSize, true, v)
 	}
 
 	pl := payload{streamFrames: make([]ackhandler.StreamFrame, 0, 1)}
@@ -602,6 +638,11 @@ func (p *packetPacker) composeNextPacket(maxFrameSize protocol.ByteCount, onlyAc
 			hasAck = true
 		}
 	}
+	if p.pathAcks != nil {
+		numFrames := len(pl.frames)
+		pl = p.appendPathAckFrames(pl, maxFrameSize, !hasRetransmission && !hasData, v)
+		hasAck = hasAck || len(pl.frames) > numFrames
+	}
 
 	if p.datagramQueue != nil {
 		if f := p.datagramQueue.Peek(); f != nil {
@@ -649,6 +690,23 @@ func (p *packetPacker) composeNextPacket(maxFrameSize protocol.ByteCount, onlyAc
 	return pl
 }
 
+// appendPathAckFrames adds the PATH_ACK frames for the additional paths of a multipath connection, as long as they fit.
+// PATH_ACK frames can be sent on any path.
+func (p *packetPacker) appendPathAckFrames(pl payload, maxFrameSize protocol.ByteCount, onlyIfQueued bool, v protocol.VersionNumber) payload {
+	if p.pathAcks == nil {
+		return pl
+	}
+	for _, f := range p.pathAcks.GetPathAckFrames(onlyIfQueued) {
+		l := f.Length(v)
+		if pl.length+l > maxFrameSize {
+			break
+		}
+		pl.frames = append(pl.frames, ackhandler.Frame{Frame: f})
+		pl.length += l
+	}
+	return pl
+}
+
 func (p *packetPacker) MaybePackProbePacket(encLevel protocol.EncryptionLevel, maxPacketSize protocol.ByteCount, v protocol.VersionNumber) (*coalescedPacket, error) {
 	if encLevel == protocol.Encryption1RTT {
 		s, err := p.cryptoSetup.Get1RTTSealer()
@@ -665,7 +723,7 @@ func (p *packetPacker) MaybePackProbePacket(encLevel protocol.EncryptionLevel, m
 		}
 		buffer := getPacketBuffer()
 		packet := &coalescedPacket{buffer: buffer}
-		shp, err := p.appendShortHeaderPacket(buffer, connID, pn, pnLen, kp, pl, 0, maxPacketSize, s, false, v)
+		shp, err := p.appendShortHeaderPacket(buffer, p.initialPath(connID), pn, pnLen, kp, pl, 0, maxPacketSize, s, false, v)
 		if err != nil {
 			return nil, err
 		}
@@ -729,7 +787,33 @@ func (p *packetPacker) PackMTUProbePacket(ping ackhandler.Frame, size protocol.B
 	pn, pnLen := p.pnManager.PeekPacketNumber(protocol.Encryption1RTT)
 	padding := size - p.shortHeaderPacketLength(connID, pnLen, pl) - protocol.ByteCount(s.Overhead())
 	kp := s.KeyPhase()
-	packet, err := p.appendShortHeaderPacket(buffer, connID, pn, pnLen, kp, pl, padding, size, s, true, v)
+	packet, err := p.appendShortHeaderPacket(buffer, p.initialPath(connID), pn, pnLen, kp, pl, padding, size, s, true, v)
+	return packet, buffer, err
+}
+
+// PackPathProbePacket packs a packet that is sent on a path other than the active path.
+// The packet is padded to 1200 bytes, as required for packets containing PATH_CHALLENGE and PATH_RESPONSE frames.
+func (p *packetPacker) PackPathProbePacket(connID protocol.ConnectionID, frames []ackhandler.Frame, v protocol.VersionNumber) (shortHeaderPacket, *packetBuffer, error) {
+	return p.PackPathProbePacketOnPath(p.initialPath(connID), frames, v)
+}
+
+// PackPathProbePacketOnPath packs a path probe packet for an additional path of a multipath connection.
+// It uses the packet number space of that path.
+func (p *packetPacker) PackPathProbePacketOnPath(path packerPath, frames []ackhandler.Frame, v protocol.VersionNumber) (shortHeaderPacket, *packetBuffer, error) {
+	pl := payload{frames: frames}
+	for _, f := range frames {
+		pl.length += f.Frame.Length(v)
+	}
+	buffer := getPacketBuffer()
+	s, err := p.cryptoSetup.Get1RTTSealer()
+	if err != nil {
+		buffer.Release()
+		return shortHeaderPacket{}, nil, err
+	}
+	pn, pnLen := path.PNManager.PeekPacketNumber(protocol.Encryption1RTT)
+	padding := protocol.MinInitialPacketSize - p.shortHeaderPacketLength(path.ConnID, pnLen, pl) - protocol.ByteCount(s.Overhead())
+	kp := s.KeyPhase()
+	packet, err := p.appendShortHeaderPacket(buffer, path, pn, pnLen, kp, pl, padding, protocol.MinInitialPacketSize, s, false, v)
 	return packet, buffer, err
 }
 
@@ -794,7 +878,7 @@ func (p *packetPacker) appendLongHeaderPacket(buffer *packetBuffer, header *wire
 
 func (p *packetPacker) appendShortHeaderPacket(
 	buffer *packetBuffer,
-	connID protocol.ConnectionID,
+	path packerPath,
 	pn protocol.PacketNumber,
 	pnLen protocol.PacketNumberLen,
 	kp protocol.KeyPhaseBit,
@@ -812,7 +896,7 @@ func (p *packetPacker) appendShortHeaderPacket(
 
 	startLen := len(buffer.Data)
 	raw := buffer.Data[startLen:]
-	raw, err := wire.AppendShortHeader(raw, connID, pn, pnLen, kp)
+	raw, err := wire.AppendShortHeader(raw, path.ConnID, pn, pnLen, kp)
 	if err != nil {
 		return shortHeaderPacket{}, err
 	}
@@ -827,10 +911,14 @@ func (p *packetPacker) appendShortHeaderPacket(
 			return shortHeaderPacket{}, fmt.Errorf("PacketPacker BUG: packet too large (%d bytes, allowed %d bytes)", size, maxPacketSize)
 		}
 	}
-	raw = p.encryptPacket(raw, sealer, pn, payloadOffset, protocol.ByteCount(pnLen))
+	if path.ID == 0 {
+		raw = p.encryptPacket(raw, sealer, pn, payloadOffset, protocol.ByteCount(pnLen))
+	} else {
+		raw = p.encryptPacketOnPath(raw, sealer.(handshake.ShortHeaderSealer), path.ID, pn, payloadOffset, protocol.ByteCount(pnLen))
+	}
 	buffer.Data = buffer.Data[:len(buffer.Data)+len(raw)]
 
-	if newPN := p.pnManager.PopPacketNumber(protocol.Encryption1RTT); newPN != pn {
+	if newPN := path.PNManager.PopPacketNumber(protocol.Encryption1RTT); newPN != pn {
 		return shortHeaderPacket{}, fmt.Errorf("packetPacker BUG: Peeked and Popped packet numbers do not match: expected %d, got %d", pn, newPN)
 	}
 	return shortHeaderPacket{
@@ -841,7 +929,7 @@ func (p *packetPacker) appendShortHeaderPacket(
 		Frames:               pl.frames,
 		Ack:                  pl.ack,
 		Length:               protocol.ByteCount(len(raw)),
-		DestConnID:           connID,
+		DestConnID:           path.ConnID,
 		IsPathMTUProbePacket: isMTUProbePacket,
 	}, nil
 }
@@ -895,6 +983,21 @@ func (p *packetPacker) encryptPacket(raw []byte, sealer sealer, pn protocol.Pack
 	return raw
 }
 
+func (p *packetPacker) encryptPacketOnPath(raw []byte, sealer handshake.ShortHeaderSealer, pathID uint64, pn protocol.PacketNumber, payloadOffset, pnLen protocol.ByteCount) []byte {
+	_ = sealer.SealOnPath(raw[payloadOffset:payloadOffset], raw[payloadOffset:], pathID, pn, raw[:payloadOffset])
+	raw = raw[:len(raw)+sealer.Overhead()]
+	// apply header protection
+	pnOffset := payloadOffset - pnLen
+	sealer.EncryptHeader(raw[pnOffset+4:pnOffset+4+16], &raw[0], raw[pnOffset:payloadOffset])
+	return raw
+}
+
 func (p *packetPacker) SetToken(token []byte) {
 	p.token = token
 }
+
+// EnableMultipath is called once both endpoints negotiated the use of multipath.
+// From then on, PATH_ACK frames are added to 1-RTT packets.
+func (p *packetPacker) EnableMultipath(pathAcks pathAckFrameSource) {
+	p.pathAcks = pathAcks
+}
@@ -88,7 +88,7 @@ var _ = Describe("Packet packer", func() {
 		rand.Seed(uint64(GinkgoRandomSeed()))
 		retransmissionQueue = newRetransmissionQueue()
 		mockSender := NewMockStreamSender(mockCtrl)
-		mockSender.EXPECT().onHasStreamData(gomock.Any()).AnyTimes()
+		mockSender.EXPECT().onHasStreamData(gomock.Any(), gomock.Any()).AnyTimes()
 		initialStream = NewMockCryptoStream(mockCtrl)
 		handshakeStream = NewMockCryptoStream(mockCtrl)
 		framer = NewMockFrameSource(mockCtrl)
@@ -1485,6 +1485,109 @@ var _ = Describe("Packet packer", func() {
 				Expect(buffer.Data).To(HaveLen(int(probePacketSize)))
 				Expect(p.IsPathMTUProbePacket).To(BeTrue())
 			})
+
+			It("packs a path probe packet", func() {
+				sealingManager.EXPECT().Get1RTTSealer().Return(getSealer(), nil)
+				pnManager.EXPECT().PeekPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x43), protocol.PacketNumberLen2)
+				pnManager.EXPECT().PopPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x43))
+				connID := protocol.ParseConnectionID([]byte{0xde, 0xca, 0xfb, 0xad})
+				f := ackhandler.Frame{Frame: &wire.PathChallengeFrame{Data: [8]byte{1, 2, 3, 4, 5, 6, 7, 8}}}
+				p, buffer, err := packer.PackPathProbePacket(connID, []ackhandler.Frame{f}, protocol.Version1)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(p.Length).To(BeEquivalentTo(protocol.MinInitialPacketSize))
+				Expect(p.PacketNumber).To(Equal(protocol.PacketNumber(0x43)))
+				Expect(p.Frames).To(Equal([]ackhandler.Frame{f}))
+				Expect(p.IsPathMTUProbePacket).To(BeFalse())
+				Expect(buffer.Data).To(HaveLen(protocol.MinInitialPacketSize))
+				// the packet is sent using the connection ID for the path
+				_, _, _, _, err = wire.ParseShortHeader(buffer.Data, connID.Len())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(buffer.Data[1 : 1+connID.Len()]).To(Equal(connID.Bytes()))
+			})
+		})
+
+		Context("multipath", func() {
+			var pathAcks *MockPathAckFrameSource
+
+			BeforeEach(func() {
+				pathAcks = NewMockPathAckFrameSource(mockCtrl)
+				packer.EnableMultipath(pathAcks)
+			})
+
+			getPathSealer := func(pathID uint64) *mocks.MockShortHeaderSealer {
+				sealer := mocks.NewMockShortHeaderSealer(mockCtrl)
+				sealer.EXPECT().KeyPhase().Return(protocol.KeyPhaseZero).AnyTimes()
+				sealer.EXPECT().Overhead().Return(7).AnyTimes()
+				sealer.EXPECT().EncryptHeader(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+				sealer.EXPECT().SealOnPath(gomock.Any(), gomock.Any(), pathID, gomock.Any(), gomock.Any()).DoAndReturn(func(dst, src []byte, _ uint64, _ protocol.PacketNumber, _ []byte) []byte {
+					return append(src, bytes.Repeat([]byte{'s'}, sealer.Overhead())...)
+				})
+				return sealer
+			}
+
+			It("packs PATH_ACK frames together with the ACK", func() {
+				pnManager.EXPECT().PeekPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x42), protocol.PacketNumberLen2)
+				pnManager.EXPECT().PopPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x42))
+				sealingManager.EXPECT().Get1RTTSealer().Return(getSealer(), nil)
+				framer.EXPECT().HasData()
+				ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Largest: 42, Smallest: 1}}}
+				ackFramer.EXPECT().GetAckFrame(protocol.Encryption1RTT, true).Return(ack)
+				pathAck := &wire.PathAckFrame{PathID: 3, AckFrame: wire.AckFrame{AckRanges: []wire.AckRange{{Largest: 10, Smallest: 5}}}}
+				pathAcks.EXPECT().GetPathAckFrames(true).Return([]*wire.PathAckFrame{pathAck})
+				p, err := packer.AppendPacket(getPacketBuffer(), maxPacketSize, protocol.Version1)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(p.Ack).To(Equal(ack))
+				Expect(p.Frames).To(Equal([]ackhandler.Frame{{Frame: pathAck}}))
+			})
+
+			It("packs ACK-only packets that only contain PATH_ACK frames", func() {
+				pnManager.EXPECT().PeekPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x42), protocol.PacketNumberLen2)
+				pnManager.EXPECT().PopPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x42))
+				sealingManager.EXPECT().Get1RTTSealer().Return(getSealer(), nil)
+				ackFramer.EXPECT().GetAckFrame(protocol.Encryption1RTT, true)
+				pathAck := &wire.PathAckFrame{PathID: 3, AckFrame: wire.AckFrame{AckRanges: []wire.AckRange{{Largest: 10, Smallest: 5}}}}
+				pathAcks.EXPECT().GetPathAckFrames(true).Return([]*wire.PathAckFrame{pathAck})
+				p, buffer, err := packer.PackAckOnlyPacket(maxPacketSize, protocol.Version1)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(buffer).ToNot(BeNil())
+				Expect(p.Ack).To(BeNil())
+				Expect(p.Frames).To(Equal([]ackhandler.Frame{{Frame: pathAck}}))
+			})
+
+			It("packs packets on additional paths", func() {
+				pathPNManager := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+				pathPNManager.EXPECT().PeekPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x1337), protocol.PacketNumberLen2)
+				pathPNManager.EXPECT().PopPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x1337))
+				sealingManager.EXPECT().Get1RTTSealer().Return(getPathSealer(2), nil)
+				framer.EXPECT().HasData().Return(true)
+				// the ACK for the packets received on the initial path is only sent on the initial path
+				pathAcks.EXPECT().GetPathAckFrames(false)
+				expectAppendControlFrames()
+				f := &wire.StreamFrame{StreamID: 5, Data: []byte("foobar")}
+				expectAppendStreamFrames(ackhandler.StreamFrame{Frame: f})
+				pathConnID := protocol.ParseConnectionID([]byte{0xde, 0xca, 0xfb, 0xad})
+				buffer := getPacketBuffer()
+				p, err := packer.AppendPacketOnPath(buffer, packerPath{ID: 2, ConnID: pathConnID, PNManager: pathPNManager}, maxPacketSize, protocol.Version1)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(p.PacketNumber).To(Equal(protocol.PacketNumber(0x1337)))
+				Expect(p.DestConnID).To(Equal(pathConnID))
+				Expect(p.StreamFrames).To(HaveLen(1))
+				Expect(buffer.Data[1 : 1+pathConnID.Len()]).To(Equal(pathConnID.Bytes()))
+			})
+
+			It("packs path probe packets on additional paths", func() {
+				pathPNManager := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+				pathPNManager.EXPECT().PeekPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x1337), protocol.PacketNumberLen2)
+				pathPNManager.EXPECT().PopPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x1337))
+				sealingManager.EXPECT().Get1RTTSealer().Return(getPathSealer(2), nil)
+				pathConnID := protocol.ParseConnectionID([]byte{0xde, 0xca, 0xfb, 0xad})
+				f := ackhandler.Frame{Frame: &wire.PathChallengeFrame{Data: [8]byte{1, 2, 3, 4, 5, 6, 7, 8}}}
+				p, buffer, err := packer.PackPathProbePacketOnPath(packerPath{ID: 2, ConnID: pathConnID, PNManager: pathPNManager}, []ackhandler.Frame{f}, protocol.Version1)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(p.PacketNumber).To(Equal(protocol.PacketNumber(0x1337)))
+				Expect(p.Frames).To(Equal([]ackhandler.Frame{f}))
+				Expect(buffer.Data).To(HaveLen(protocol.MinInitialPacketSize))
+			})
 		})
 	})
 })
@@ -108,11 +108,18 @@ func (u *packetUnpacker) UnpackLongHeader(hdr *wire.Header, rcvTime time.Time, d
 }
 
 func (u *packetUnpacker) UnpackShortHeader(rcvTime time.Time, data []byte) (protocol.PacketNumber, protocol.PacketNumberLen, protocol.KeyPhaseBit, []byte, error) {
+	return u.UnpackShortHeaderOnPath(rcvTime, data, 0)
+}
+
+// UnpackShortHeaderOnPath unpacks a short header packet received on a path of a multipath connection.
+// The path ID is the sequence number of the packet's destination connection ID.
+// Every path uses its own packet number space.
+func (u *packetUnpacker) UnpackShortHeaderOnPath(rcvTime time.Time, data []byte, pathID uint64) (protocol.PacketNumber, protocol.PacketNumberLen, protocol.KeyPhaseBit, []byte, error) {
 	opener, err := u.cs.Get1RTTOpener()
 	if err != nil {
 		return 0, 0, 0, nil, err
 	}
-	pn, pnLen, kp, decrypted, err := u.unpackShortHeaderPacket(opener, rcvTime, data)
+	pn, pnLen, kp, decrypted, err := u.unpackShortHeaderPacket(opener, rcvTime, pathID, data)
 	if err != nil {
 		return 0, 0, 0, nil, err
 	}
@@ -145,7 +152,7 @@ func (u *packetUnpacker) unpackLongHeaderPacket(opener handshake.LongHeaderOpene
 	return extHdr, decrypted, nil
 }
 
-func (u *packetUnpacker) unpackShortHeaderPacket(opener handshake.ShortHeaderOpener, rcvTime time.Time, data []byte) (protocol.PacketNumber, protocol.PacketNumberLen, protocol.KeyPhaseBit, []byte, error) {
+func (u *packetUnpacker) unpackShortHeaderPacket(opener handshake.ShortHeaderOpener, rcvTime time.Time, pathID uint64, data []byte) (protocol.PacketNumber, protocol.PacketNumberLen, protocol.KeyPhaseBit, []byte, error) {
 	l, pn, pnLen, kp, parseErr := u.unpackShortHeader(opener, data)
 	// If the reserved bits are set incorrectly, we still need to continue unpacking.
 	// This avoids a timing side-channel, which otherwise might allow an attacker
@@ -153,8 +160,15 @@ func (u *packetUnpacker) unpackShortHeaderPacket(opener handshake.ShortHeaderOpe
 	if parseErr != nil && parseErr != wire.ErrInvalidReservedBits {
 		return 0, 0, 0, nil, &headerParseError{parseErr}
 	}
-	pn = opener.DecodePacketNumber(pn, pnLen)
-	decrypted, err := opener.Open(data[l:l], data[l:], rcvTime, pn, kp, data[:l])
+	var decrypted []byte
+	var err error
+	if pathID == 0 {
+		pn = opener.DecodePacketNumber(pn, pnLen)
+		decrypted, err = opener.Open(data[l:l], data[l:], rcvTime, pn, kp, data[:l])
+	} else {
+		pn = opener.DecodePacketNumberOnPath(pathID, pn, pnLen)
+		decrypted, err = opener.OpenOnPath(data[l:l], data[l:], rcvTime, pathID, pn, kp, data[:l])
+	}
 	if err != nil {
 		return 0, 0, 0, nil, err
 	}
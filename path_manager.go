@@ -0,0 +1,144 @@
+package quic
+
+import (
+	"crypto/rand"
+	"net"
+	"time"
+
+	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/internal/wire"
+)
+
+// pathManager is used by the server to handle changes of the client's address,
+// see section 9.3 of RFC 9000.
+type pathManager struct {
+	// only the packet with the highest packet number can cause a migration
+	largestNonProbingPacketNumber protocol.PacketNumber
+
+	// the last path that was validated
+	validatedConn sendConn
+	// the path that is currently being validated
+	pathConn           sendConn
+	pathChallenge      [8]byte
+	validationDeadline time.Time
+
+	// A connection ID must not be used for sending to more than one address, see section 9.5 of RFC 9000.
+	// Every new address of the client is assigned an ID, which is used to get a connection ID from the connIDManager.
+	addrPaths      map[string]addrPath
+	nextAddrPathID pathID
+}
+
+// An addrPath is an address that the client is probing.
+// If the client doesn't migrate to this address before it expires, the path validation failed or timed out.
+type addrPath struct {
+	id      pathID
+	expires time.Time
+}
+
+func newPathManager(conn sendConn) *pathManager {
+	return &pathManager{
+		largestNonProbingPacketNumber: protocol.InvalidPacketNumber,
+		validatedConn:                 conn,
+	}
+}
+
+// ReceivedNonProbingPacket is called for every non-probing packet.
+// It returns true if this is the packet with the highest packet number received so far.
+func (m *pathManager) ReceivedNonProbingPacket(pn protocol.PacketNumber) bool {
+	if m.largestNonProbingPacketNumber != protocol.InvalidPacketNumber && pn <= m.largestNonProbingPacketNumber {
+		return false
+	}
+	m.largestNonProbingPacketNumber = pn
+	return true
+}
+
+// Migrated is called when the connection switched to a new path.
+// If the path needs to be validated, it returns the PATH_CHALLENGE frame that should be sent.
+func (m *pathManager) Migrated(conn sendConn) *wire.PathChallengeFrame {
+	m.validationDeadline = time.Time{}
+	// The client migrated back to a path that was already validated.
+	if addrsEqual(conn.RemoteAddr(), m.validatedConn.RemoteAddr()) {
+		m.validatedConn = conn
+		m.pathConn = nil
+		return nil
+	}
+	m.pathConn = conn
+	_, _ = rand.Read(m.pathChallenge[:])
+	return &wire.PathChallengeFrame{Data: m.pathChallenge}
+}
+
+// SetValidationDeadline sets the time when the validation of the current path fails.
+func (m *pathManager) SetValidationDeadline(t time.Time) {
+	m.validationDeadline = t
+}
+
+// HandlePathResponseFrame handles a PATH_RESPONSE frame.
+// It returns true if the frame validated the current path.
+func (m *pathManager) HandlePathResponseFrame(f *wire.PathResponseFrame) bool {
+	if m.pathConn == nil || f.Data != m.pathChallenge {
+		return false
+	}
+	m.validatedConn = m.pathConn
+	m.pathConn = nil
+	m.validationDeadline = time.Time{}
+	return true
+}
+
+// ValidationDeadline returns the time when the validation of the current path fails.
+// It returns the zero value if no path is being validated.
+func (m *pathManager) ValidationDeadline() time.Time {
+	return m.validationDeadline
+}
+
+// ValidationFailed is called when the validation deadline expired.
+// It returns the last validated path, which the connection should switch back to.
+func (m *pathManager) ValidationFailed() sendConn {
+	m.pathConn = nil
+	m.validationDeadline = time.Time{}
+	return m.validatedConn
+}
+
+// PathIDForAddr returns the ID used to get a connection ID from the connIDManager,
+// when sending to an address that is not the address of the current path.
+// The ID expires at the given time, unless PathIDForAddr is called for the address again.
+// It returns false if too many addresses are in use.
+func (m *pathManager) PathIDForAddr(addr net.Addr, expires time.Time) (pathID, bool) {
+	if p, ok := m.addrPaths[addr.String()]; ok {
+		p.expires = expires
+		m.addrPaths[addr.String()] = p
+		return p.id, true
+	}
+	if len(m.addrPaths) >= protocol.MaxActiveConnectionIDs {
+		return 0, false
+	}
+	if m.addrPaths == nil {
+		m.addrPaths = make(map[string]addrPath)
+	}
+	id := m.nextAddrPathID
+	m.nextAddrPathID++
+	m.addrPaths[addr.String()] = addrPath{id: id, expires: expires}
+	return id, true
+}
+
+// RemovePathIDForAddr removes the ID of an address, and returns it.
+// It is called when the connection migrates to that address.
+func (m *pathManager) RemovePathIDForAddr(addr net.Addr) (pathID, bool) {
+	p, ok := m.addrPaths[addr.String()]
+	if ok {
+		delete(m.addrPaths, addr.String())
+	}
+	return p.id, ok
+}
+
+// RemoveExpiredPathIDs removes the IDs of the addresses that expired, and returns them.
+// The connection IDs used for these addresses need to be retired.
+func (m *pathManager) RemoveExpiredPathIDs(now time.Time) []pathID {
+	var ids []pathID
+	for addr, p := range m.addrPaths {
+		if !now.Before(p.expires) {
+			ids = append(ids, p.id)
+			delete(m.addrPaths, addr)
+		}
+	}
+	return ids
+}
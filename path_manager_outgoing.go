@@ -0,0 +1,376 @@
+package quic
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/quic-go/quic-go/internal/ackhandler"
+	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/internal/wire"
+)
+
+var (
+	// ErrPathClosed is returned when trying to use a path that was closed.
+	ErrPathClosed = errors.New("path closed")
+	// ErrPathNotValidated is returned when trying to switch to a path that hasn't been validated yet.
+	ErrPathNotValidated = errors.New("path not yet validated")
+)
+
+type pathID int64
+
+const invalidPathID pathID = -1
+
+// The PATH_CHALLENGE is retransmitted with exponential backoff, starting with this timeout.
+const pathProbeInitialTimeout = 100 * time.Millisecond
+
+// The client gives up migrating to the server's preferred address if the path isn't validated within this time.
+const preferredAddressValidationTimeout = 3 * time.Second
+
+// Path is a network path that can be used by a connection.
+// A Path is created by calling Connection.AddPath.
+// It needs to be validated using Probe, before the connection can switch to it using Switch.
+// On multipath connections, the connection uses all validated paths at the same time.
+type Path struct {
+	id          pathID
+	pathManager *pathManagerOutgoing
+
+	validated chan struct{} // closed once the path has been validated
+	closed    chan struct{} // closed when Close is called
+	closeOnce sync.Once
+}
+
+// Probe validates the path by sending PATH_CHALLENGE frames and waiting for the PATH_RESPONSE.
+// It blocks until the path is validated, the context is canceled, or the path or the connection is closed.
+// Probing a path that was already validated returns immediately.
+func (p *Path) Probe(ctx context.Context) error {
+	timeout := pathProbeInitialTimeout
+	for {
+		if err := p.pathManager.enqueueProbe(p); err != nil {
+			return err
+		}
+		timer := time.NewTimer(timeout)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-p.validated:
+			timer.Stop()
+			return nil
+		case <-p.closed:
+			timer.Stop()
+			return ErrPathClosed
+		case <-p.pathManager.connClosed:
+			timer.Stop()
+			return ErrPathClosed
+		case <-timer.C:
+		}
+		timeout *= 2
+	}
+}
+
+// Switch switches the connection to this path.
+// The path needs to be validated first.
+// The switch takes effect the next time the connection sends a packet,
+// at which point the RTT estimate and the congestion controller are reset.
+// It is not possible to switch paths on a multipath connection.
+func (p *Path) Switch() error {
+	return p.pathManager.switchToPath(p)
+}
+
+// SetStandby puts the path into standby mode, or makes it available again.
+// A path in standby mode is only used if no other path is available.
+// The peer is asked to do the same, using a PATH_STATUS frame.
+// It is only possible on multipath connections, once the path has been validated.
+func (p *Path) SetStandby(standby bool) error {
+	return p.pathManager.setStandby(p, standby)
+}
+
+// Close closes the path.
+// The connection ID used on the path is retired,
+// and the connection stops receiving packets on the path's Transport.
+// On multipath connections, the peer is informed using a PATH_ABANDON frame.
+// The active path can't be closed.
+func (p *Path) Close() error {
+	return p.pathManager.closePath(p)
+}
+
+type pathStatusUpdate struct {
+	id      pathID
+	standby bool
+}
+
+type pathOutgoing struct {
+	path           *Path
+	conn           sendConn
+	pathChallenges [][8]byte // all PATH_CHALLENGE values sent on this path
+	isValidated    bool
+	enabled        bool
+	enablePath     func()
+}
+
+// The pathManagerOutgoing manages the paths that the client probes and migrates to.
+// Paths are created and used by the application, but all state that belongs to the connection
+// is only modified from the connection's run loop.
+type pathManagerOutgoing struct {
+	getConnID       func(pathID) (protocol.ConnectionID, bool)
+	retireConnID    func(pathID)
+	scheduleSending func()
+	connClosed      <-chan struct{}
+
+	mx             sync.Mutex
+	multipath      bool
+	nextPathID     pathID
+	activePath     pathID
+	paths          map[pathID]*pathOutgoing
+	pathsToProbe   []pathID
+	pathToSwitchTo *pathOutgoing
+	pathsToClose   []*pathOutgoing
+	statusUpdates  []pathStatusUpdate
+}
+
+func newPathManagerOutgoing(
+	getConnID func(pathID) (protocol.ConnectionID, bool),
+	retireConnID func(pathID),
+	scheduleSending func(),
+	connClosed <-chan struct{},
+) *pathManagerOutgoing {
+	return &pathManagerOutgoing{
+		getConnID:       getConnID,
+		retireConnID:    retireConnID,
+		scheduleSending: scheduleSending,
+		connClosed:      connClosed,
+		activePath:      invalidPathID,
+		paths:           make(map[pathID]*pathOutgoing),
+	}
+}
+
+// NewPath creates a new path.
+// enablePath is called from the run loop when the path is probed for the first time.
+func (pm *pathManagerOutgoing) NewPath(conn sendConn, enablePath func(pathID)) *Path {
+	pm.mx.Lock()
+	defer pm.mx.Unlock()
+
+	id := pm.nextPathID
+	pm.nextPathID++
+	p := &Path{
+		id:          id,
+		pathManager: pm,
+		validated:   make(chan struct{}),
+		closed:      make(chan struct{}),
+	}
+	pm.paths[id] = &pathOutgoing{
+		path:       p,
+		conn:       conn,
+		enablePath: func() { enablePath(id) },
+	}
+	return p
+}
+
+func (pm *pathManagerOutgoing) enqueueProbe(p *Path) error {
+	pm.mx.Lock()
+	path, ok := pm.paths[p.id]
+	if !ok {
+		pm.mx.Unlock()
+		return ErrPathClosed
+	}
+	if path.isValidated {
+		pm.mx.Unlock()
+		return nil
+	}
+	var queued bool
+	for _, id := range pm.pathsToProbe {
+		if id == p.id {
+			queued = true
+			break
+		}
+	}
+	if !queued {
+		pm.pathsToProbe = append(pm.pathsToProbe, p.id)
+	}
+	pm.mx.Unlock()
+
+	pm.scheduleSending()
+	return nil
+}
+
+// SetMultipath is called when multipath was negotiated.
+func (pm *pathManagerOutgoing) SetMultipath() {
+	pm.mx.Lock()
+	pm.multipath = true
+	pm.mx.Unlock()
+}
+
+func (pm *pathManagerOutgoing) switchToPath(p *Path) error {
+	pm.mx.Lock()
+	if pm.multipath {
+		pm.mx.Unlock()
+		return errors.New("cannot switch paths on a multipath connection")
+	}
+	path, ok := pm.paths[p.id]
+	if !ok {
+		pm.mx.Unlock()
+		return ErrPathClosed
+	}
+	if !path.isValidated {
+		pm.mx.Unlock()
+		return ErrPathNotValidated
+	}
+	pm.pathToSwitchTo = path
+	pm.mx.Unlock()
+
+	pm.scheduleSending()
+	return nil
+}
+
+func (pm *pathManagerOutgoing) setStandby(p *Path, standby bool) error {
+	pm.mx.Lock()
+	if !pm.multipath {
+		pm.mx.Unlock()
+		return errors.New("multipath not negotiated")
+	}
+	path, ok := pm.paths[p.id]
+	if !ok {
+		pm.mx.Unlock()
+		return ErrPathClosed
+	}
+	if !path.isValidated {
+		pm.mx.Unlock()
+		return ErrPathNotValidated
+	}
+	pm.statusUpdates = append(pm.statusUpdates, pathStatusUpdate{id: p.id, standby: standby})
+	pm.mx.Unlock()
+
+	pm.scheduleSending()
+	return nil
+}
+
+func (pm *pathManagerOutgoing) closePath(p *Path) error {
+	pm.mx.Lock()
+	path, ok := pm.paths[p.id]
+	if !ok {
+		pm.mx.Unlock()
+		return nil
+	}
+	if pm.activePath == p.id || pm.pathToSwitchTo == path {
+		pm.mx.Unlock()
+		return errors.New("cannot close the active path")
+	}
+	delete(pm.paths, p.id)
+	for i, id := range pm.pathsToProbe {
+		if id == p.id {
+			pm.pathsToProbe = append(pm.pathsToProbe[:i], pm.pathsToProbe[i+1:]...)
+			break
+		}
+	}
+	if path.enabled {
+		pm.pathsToClose = append(pm.pathsToClose, path)
+	}
+	pm.mx.Unlock()
+
+	p.closeOnce.Do(func() { close(p.closed) })
+	pm.scheduleSending()
+	return nil
+}
+
+// NextPathToProbe returns the next path that should be probed,
+// together with the connection ID and the PATH_CHALLENGE frame to use.
+// It must only be called from the run loop.
+func (pm *pathManagerOutgoing) NextPathToProbe() (pathID, protocol.ConnectionID, ackhandler.Frame, sendConn, bool) {
+	pm.mx.Lock()
+	defer pm.mx.Unlock()
+
+	for len(pm.pathsToProbe) > 0 {
+		id := pm.pathsToProbe[0]
+		pm.pathsToProbe = pm.pathsToProbe[1:]
+		path, ok := pm.paths[id]
+		if !ok || path.isValidated {
+			continue
+		}
+		connID, ok := pm.getConnID(id)
+		if !ok {
+			// The peer didn't provide us with an unused connection ID.
+			// The path will be probed again when Probe retransmits.
+			continue
+		}
+		if !path.enabled {
+			path.enabled = true
+			path.enablePath()
+		}
+		var b [8]byte
+		_, _ = rand.Read(b[:])
+		path.pathChallenges = append(path.pathChallenges, b)
+		return id, connID, ackhandler.Frame{Frame: &wire.PathChallengeFrame{Data: b}}, path.conn, true
+	}
+	return invalidPathID, protocol.ConnectionID{}, ackhandler.Frame{}, nil, false
+}
+
+// HandlePathResponseFrame handles a PATH_RESPONSE frame.
+// A PATH_RESPONSE validates the path that the corresponding PATH_CHALLENGE was sent on,
+// no matter which path the PATH_RESPONSE was received on.
+// It must only be called from the run loop.
+func (pm *pathManagerOutgoing) HandlePathResponseFrame(f *wire.PathResponseFrame) {
+	pm.mx.Lock()
+	defer pm.mx.Unlock()
+
+	for _, path := range pm.paths {
+		if path.isValidated {
+			continue
+		}
+		for _, c := range path.pathChallenges {
+			if c == f.Data {
+				path.isValidated = true
+				path.pathChallenges = nil
+				close(path.path.validated)
+				return
+			}
+		}
+	}
+}
+
+// ShouldSwitchPath returns the path the application asked to switch to, if any.
+// It must only be called from the run loop.
+func (pm *pathManagerOutgoing) ShouldSwitchPath() (pathID, sendConn, bool) {
+	pm.mx.Lock()
+	defer pm.mx.Unlock()
+
+	if pm.pathToSwitchTo == nil {
+		return invalidPathID, nil, false
+	}
+	p := pm.pathToSwitchTo
+	pm.pathToSwitchTo = nil
+	pm.activePath = p.path.id
+	return p.path.id, p.conn, true
+}
+
+// ClosedPaths returns the paths that were closed by the application since the last call,
+// such that the connection can release the resources associated with them.
+// It must only be called from the run loop.
+func (pm *pathManagerOutgoing) ClosedPaths() []pathID {
+	pm.mx.Lock()
+	defer pm.mx.Unlock()
+
+	if len(pm.pathsToClose) == 0 {
+		return nil
+	}
+	ids := make([]pathID, 0, len(pm.pathsToClose))
+	for _, p := range pm.pathsToClose {
+		pm.retireConnID(p.path.id)
+		ids = append(ids, p.path.id)
+	}
+	pm.pathsToClose = nil
+	return ids
+}
+
+// StatusUpdates returns the status changes requested by the application since the last call.
+// It must only be called from the run loop.
+func (pm *pathManagerOutgoing) StatusUpdates() []pathStatusUpdate {
+	pm.mx.Lock()
+	defer pm.mx.Unlock()
+
+	updates := pm.statusUpdates
+	pm.statusUpdates = nil
+	return updates
+}
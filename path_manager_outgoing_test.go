@@ -0,0 +1,219 @@
+package quic
+
+import (
+	"context"
+	"time"
+
+	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/internal/wire"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Outgoing Path Manager", func() {
+	var (
+		pm               *pathManagerOutgoing
+		connIDs          map[pathID]protocol.ConnectionID
+		retiredConnIDs   []pathID
+		sendingScheduled chan struct{}
+		connClosed       chan struct{}
+	)
+
+	BeforeEach(func() {
+		connIDs = map[pathID]protocol.ConnectionID{
+			0: protocol.ParseConnectionID([]byte{1, 2, 3, 4}),
+			1: protocol.ParseConnectionID([]byte{5, 6, 7, 8}),
+		}
+		retiredConnIDs = nil
+		sendingScheduled = make(chan struct{}, 100)
+		connClosed = make(chan struct{})
+		pm = newPathManagerOutgoing(
+			func(id pathID) (protocol.ConnectionID, bool) {
+				connID, ok := connIDs[id]
+				return connID, ok
+			},
+			func(id pathID) { retiredConnIDs = append(retiredConnIDs, id) },
+			func() { sendingScheduled <- struct{}{} },
+			connClosed,
+		)
+	})
+
+	probe := func(p *Path) (context.CancelFunc, <-chan error) {
+		ctx, cancel := context.WithCancel(context.Background())
+		errChan := make(chan error, 1)
+		go func() { errChan <- p.Probe(ctx) }()
+		return cancel, errChan
+	}
+
+	It("probes a path", func() {
+		conn := NewMockSendConn(mockCtrl)
+		var enabled []pathID
+		p := pm.NewPath(conn, func(id pathID) { enabled = append(enabled, id) })
+		_, _, _, _, ok := pm.NextPathToProbe()
+		Expect(ok).To(BeFalse())
+
+		cancel, errChan := probe(p)
+		defer cancel()
+		Eventually(sendingScheduled).Should(Receive())
+		id, connID, f, c, ok := pm.NextPathToProbe()
+		Expect(ok).To(BeTrue())
+		Expect(id).To(Equal(p.id))
+		Expect(connID).To(Equal(connIDs[0]))
+		Expect(c).To(Equal(conn))
+		Expect(enabled).To(Equal([]pathID{p.id}))
+		Expect(f.Frame).To(BeAssignableToTypeOf(&wire.PathChallengeFrame{}))
+		_, _, _, _, ok = pm.NextPathToProbe()
+		Expect(ok).To(BeFalse())
+		Consistently(errChan).ShouldNot(Receive())
+
+		// a PATH_RESPONSE that doesn't match the PATH_CHALLENGE is ignored
+		pm.HandlePathResponseFrame(&wire.PathResponseFrame{Data: [8]byte{'f', 'o', 'o', 'b', 'a', 'r'}})
+		Consistently(errChan).ShouldNot(Receive())
+		pm.HandlePathResponseFrame(&wire.PathResponseFrame{Data: f.Frame.(*wire.PathChallengeFrame).Data})
+		Eventually(errChan).Should(Receive(BeNil()))
+		// probing a validated path returns immediately
+		Expect(p.Probe(context.Background())).To(Succeed())
+	})
+
+	It("retransmits PATH_CHALLENGE frames", func() {
+		p := pm.NewPath(NewMockSendConn(mockCtrl), func(pathID) {})
+		cancel, errChan := probe(p)
+		Eventually(sendingScheduled).Should(Receive())
+		_, _, f1, _, ok := pm.NextPathToProbe()
+		Expect(ok).To(BeTrue())
+		Eventually(sendingScheduled).Should(Receive())
+		_, _, f2, _, ok := pm.NextPathToProbe()
+		Expect(ok).To(BeTrue())
+		Expect(f1.Frame).ToNot(Equal(f2.Frame))
+		// a PATH_RESPONSE for the first PATH_CHALLENGE validates the path
+		pm.HandlePathResponseFrame(&wire.PathResponseFrame{Data: f1.Frame.(*wire.PathChallengeFrame).Data})
+		Eventually(errChan).Should(Receive(BeNil()))
+		cancel()
+	})
+
+	It("doesn't probe paths if there's no connection ID available", func() {
+		p := pm.NewPath(NewMockSendConn(mockCtrl), func(pathID) {})
+		p2 := pm.NewPath(NewMockSendConn(mockCtrl), func(pathID) {})
+		delete(connIDs, p.id)
+		cancel, _ := probe(p)
+		defer cancel()
+		Eventually(sendingScheduled).Should(Receive())
+		_, _, _, _, ok := pm.NextPathToProbe()
+		Expect(ok).To(BeFalse())
+		cancel2, _ := probe(p2)
+		defer cancel2()
+		Eventually(sendingScheduled).Should(Receive())
+		id, connID, _, _, ok := pm.NextPathToProbe()
+		Expect(ok).To(BeTrue())
+		Expect(id).To(Equal(p2.id))
+		Expect(connID).To(Equal(connIDs[p2.id]))
+	})
+
+	It("returns when the context is canceled", func() {
+		p := pm.NewPath(NewMockSendConn(mockCtrl), func(pathID) {})
+		cancel, errChan := probe(p)
+		cancel()
+		Eventually(errChan).Should(Receive(MatchError(context.Canceled)))
+	})
+
+	It("returns when the connection is closed", func() {
+		p := pm.NewPath(NewMockSendConn(mockCtrl), func(pathID) {})
+		cancel, errChan := probe(p)
+		defer cancel()
+		close(connClosed)
+		Eventually(errChan).Should(Receive(MatchError(ErrPathClosed)))
+	})
+
+	It("switches to a validated path", func() {
+		conn := NewMockSendConn(mockCtrl)
+		p := pm.NewPath(conn, func(pathID) {})
+		Expect(p.Switch()).To(MatchError(ErrPathNotValidated))
+		_, _, switched := pm.ShouldSwitchPath()
+		Expect(switched).To(BeFalse())
+
+		cancel, errChan := probe(p)
+		defer cancel()
+		Eventually(sendingScheduled).Should(Receive())
+		_, _, f, _, ok := pm.NextPathToProbe()
+		Expect(ok).To(BeTrue())
+		pm.HandlePathResponseFrame(&wire.PathResponseFrame{Data: f.Frame.(*wire.PathChallengeFrame).Data})
+		Eventually(errChan).Should(Receive(BeNil()))
+
+		Expect(p.Switch()).To(Succeed())
+		Eventually(sendingScheduled).Should(Receive())
+		id, c, ok := pm.ShouldSwitchPath()
+		Expect(ok).To(BeTrue())
+		Expect(id).To(Equal(p.id))
+		Expect(c).To(Equal(conn))
+		_, _, ok = pm.ShouldSwitchPath()
+		Expect(ok).To(BeFalse())
+		// the active path can't be closed
+		Expect(p.Close()).To(MatchError("cannot close the active path"))
+	})
+
+	It("sets paths to standby on multipath connections", func() {
+		p := pm.NewPath(NewMockSendConn(mockCtrl), func(pathID) {})
+		Expect(p.SetStandby(true)).To(MatchError("multipath not negotiated"))
+		pm.SetMultipath()
+		Expect(p.SetStandby(true)).To(MatchError(ErrPathNotValidated))
+
+		cancel, errChan := probe(p)
+		defer cancel()
+		Eventually(sendingScheduled).Should(Receive())
+		_, _, f, _, ok := pm.NextPathToProbe()
+		Expect(ok).To(BeTrue())
+		pm.HandlePathResponseFrame(&wire.PathResponseFrame{Data: f.Frame.(*wire.PathChallengeFrame).Data})
+		Eventually(errChan).Should(Receive(BeNil()))
+
+		// it's not possible to switch paths on a multipath connection
+		Expect(p.Switch()).To(MatchError("cannot switch paths on a multipath connection"))
+		Expect(p.SetStandby(true)).To(Succeed())
+		Eventually(sendingScheduled).Should(Receive())
+		Expect(p.SetStandby(false)).To(Succeed())
+		Expect(pm.StatusUpdates()).To(Equal([]pathStatusUpdate{
+			{id: p.id, standby: true},
+			{id: p.id, standby: false},
+		}))
+		Expect(pm.StatusUpdates()).To(BeEmpty())
+		Expect(p.Close()).To(Succeed())
+		Expect(p.SetStandby(true)).To(MatchError(ErrPathClosed))
+	})
+
+	It("closes paths", func() {
+		p := pm.NewPath(NewMockSendConn(mockCtrl), func(pathID) {})
+		cancel, errChan := probe(p)
+		defer cancel()
+		Eventually(sendingScheduled).Should(Receive())
+		_, _, _, _, ok := pm.NextPathToProbe()
+		Expect(ok).To(BeTrue())
+		Expect(p.Close()).To(Succeed())
+		Eventually(errChan).Should(Receive(MatchError(ErrPathClosed)))
+		Expect(pm.ClosedPaths()).To(Equal([]pathID{p.id}))
+		Expect(retiredConnIDs).To(Equal([]pathID{p.id}))
+		Expect(pm.ClosedPaths()).To(BeEmpty())
+		// closing a path multiple times is a no-op
+		Expect(p.Close()).To(Succeed())
+		Expect(p.Probe(context.Background())).To(MatchError(ErrPathClosed))
+		Expect(p.Switch()).To(MatchError(ErrPathClosed))
+	})
+
+	It("doesn't clean up paths that were never probed", func() {
+		p := pm.NewPath(NewMockSendConn(mockCtrl), func(pathID) {})
+		Expect(p.Close()).To(Succeed())
+		Expect(pm.ClosedPaths()).To(BeEmpty())
+		Expect(retiredConnIDs).To(BeEmpty())
+	})
+
+	It("backs off exponentially when probing", func() {
+		p := pm.NewPath(NewMockSendConn(mockCtrl), func(pathID) {})
+		cancel, _ := probe(p)
+		defer cancel()
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			Eventually(sendingScheduled, time.Second).Should(Receive())
+		}
+		// the path is probed at 0, 100ms, 300ms
+		Expect(time.Since(start)).To(BeNumerically(">=", 3*pathProbeInitialTimeout))
+	})
+})
@@ -0,0 +1,141 @@
+package quic
+
+import (
+	"net"
+	"time"
+
+	"github.com/quic-go/quic-go/internal/protocol"
+	"github.com/quic-go/quic-go/internal/wire"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Path Manager", func() {
+	var (
+		pm       *pathManager
+		origConn *MockSendConn
+	)
+	origAddr := &net.UDPAddr{IP: net.IPv4(192, 168, 0, 1), Port: 1337}
+	newAddr := &net.UDPAddr{IP: net.IPv4(192, 168, 0, 1), Port: 4242}
+
+	newConn := func(addr net.Addr) *MockSendConn {
+		conn := NewMockSendConn(mockCtrl)
+		conn.EXPECT().RemoteAddr().Return(addr).AnyTimes()
+		return conn
+	}
+
+	BeforeEach(func() {
+		origConn = newConn(origAddr)
+		pm = newPathManager(origConn)
+	})
+
+	It("only considers the packet with the highest packet number", func() {
+		Expect(pm.ReceivedNonProbingPacket(10)).To(BeTrue())
+		Expect(pm.ReceivedNonProbingPacket(9)).To(BeFalse())
+		Expect(pm.ReceivedNonProbingPacket(10)).To(BeFalse())
+		Expect(pm.ReceivedNonProbingPacket(11)).To(BeTrue())
+	})
+
+	It("considers the first packet with packet number 0", func() {
+		Expect(pm.ReceivedNonProbingPacket(0)).To(BeTrue())
+		Expect(pm.ReceivedNonProbingPacket(0)).To(BeFalse())
+	})
+
+	It("validates a new path", func() {
+		Expect(pm.ValidationDeadline()).To(BeZero())
+		conn := newConn(newAddr)
+		f := pm.Migrated(conn)
+		Expect(f).ToNot(BeNil())
+		deadline := time.Now().Add(time.Second)
+		pm.SetValidationDeadline(deadline)
+		Expect(pm.ValidationDeadline()).To(Equal(deadline))
+
+		// a PATH_RESPONSE with the wrong data is ignored
+		Expect(pm.HandlePathResponseFrame(&wire.PathResponseFrame{Data: [8]byte{'f', 'o', 'o', 'b', 'a', 'r', '4', '2'}})).To(BeFalse())
+		Expect(pm.ValidationDeadline()).To(Equal(deadline))
+		Expect(pm.HandlePathResponseFrame(&wire.PathResponseFrame{Data: f.Data})).To(BeTrue())
+		Expect(pm.ValidationDeadline()).To(BeZero())
+		// duplicate PATH_RESPONSE frames are ignored
+		Expect(pm.HandlePathResponseFrame(&wire.PathResponseFrame{Data: f.Data})).To(BeFalse())
+
+		// migrating back to the original path now requires validation
+		Expect(pm.Migrated(newConn(origAddr))).ToNot(BeNil())
+	})
+
+	It("doesn't validate a path that was already validated", func() {
+		Expect(pm.Migrated(newConn(newAddr))).ToNot(BeNil())
+		pm.SetValidationDeadline(time.Now().Add(time.Second))
+		// NAT rebinding back to the original address
+		conn := newConn(origAddr)
+		Expect(pm.Migrated(conn)).To(BeNil())
+		Expect(pm.ValidationDeadline()).To(BeZero())
+		Expect(pm.ValidationFailed()).To(Equal(conn))
+	})
+
+	It("returns the last validated path when validation fails", func() {
+		f := pm.Migrated(newConn(newAddr))
+		Expect(f).ToNot(BeNil())
+		pm.SetValidationDeadline(time.Now().Add(time.Second))
+		Expect(pm.ValidationFailed()).To(Equal(origConn))
+		Expect(pm.ValidationDeadline()).To(BeZero())
+		// a late PATH_RESPONSE doesn't validate the path anymore
+		Expect(pm.HandlePathResponseFrame(&wire.PathResponseFrame{Data: f.Data})).To(BeFalse())
+	})
+
+	It("uses different PATH_CHALLENGE data for every path", func() {
+		f1 := pm.Migrated(newConn(newAddr))
+		f2 := pm.Migrated(newConn(&net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 4242}))
+		Expect(f1.Data).ToNot(Equal(f2.Data))
+		Expect(pm.HandlePathResponseFrame(&wire.PathResponseFrame{Data: f1.Data})).To(BeFalse())
+		Expect(pm.HandlePathResponseFrame(&wire.PathResponseFrame{Data: f2.Data})).To(BeTrue())
+	})
+
+	It("assigns IDs to addresses", func() {
+		expires := time.Now().Add(time.Second)
+		id1, ok := pm.PathIDForAddr(newAddr, expires)
+		Expect(ok).To(BeTrue())
+		id, ok := pm.PathIDForAddr(newAddr, expires)
+		Expect(ok).To(BeTrue())
+		Expect(id).To(Equal(id1))
+		id2, ok := pm.PathIDForAddr(&net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 4242}, expires)
+		Expect(ok).To(BeTrue())
+		Expect(id2).ToNot(Equal(id1))
+
+		id, ok = pm.RemovePathIDForAddr(newAddr)
+		Expect(ok).To(BeTrue())
+		Expect(id).To(Equal(id1))
+		_, ok = pm.RemovePathIDForAddr(newAddr)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("limits the number of addresses", func() {
+		expires := time.Now().Add(time.Second)
+		for i := 0; i < protocol.MaxActiveConnectionIDs; i++ {
+			_, ok := pm.PathIDForAddr(&net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1000 + i}, expires)
+			Expect(ok).To(BeTrue())
+		}
+		_, ok := pm.PathIDForAddr(newAddr, expires)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("expires IDs", func() {
+		now := time.Now()
+		var ids []pathID
+		for i := 0; i < protocol.MaxActiveConnectionIDs; i++ {
+			id, ok := pm.PathIDForAddr(&net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1000 + i}, now.Add(time.Duration(i+1)*time.Second))
+			Expect(ok).To(BeTrue())
+			ids = append(ids, id)
+		}
+		Expect(pm.RemoveExpiredPathIDs(now)).To(BeEmpty())
+		// using the ID again extends its lifetime
+		_, ok := pm.PathIDForAddr(&net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1000}, now.Add(10*time.Second))
+		Expect(ok).To(BeTrue())
+		Expect(pm.RemoveExpiredPathIDs(now.Add(2 * time.Second))).To(Equal([]pathID{ids[1]}))
+		// there's room for a fifth address now
+		id, ok := pm.PathIDForAddr(newAddr, now.Add(10*time.Second))
+		Expect(ok).To(BeTrue())
+		Expect(ids).ToNot(ContainElement(id))
+		Expect(pm.RemoveExpiredPathIDs(now.Add(5 * time.Second))).To(ConsistOf(ids[2], ids[3]))
+	})
+})
@@ -0,0 +1,49 @@
+package quic
+
+import (
+	"time"
+
+	"github.com/quic-go/quic-go/internal/ackhandler"
+)
+
+// A schedulerPath is a path of a multipath connection, as seen by the path scheduler.
+type schedulerPath struct {
+	id       uint64
+	rtt      time.Duration // the smoothed RTT, 0 if there's no RTT sample yet
+	sendMode ackhandler.SendMode
+	standby  bool
+}
+
+// schedulePath selects the path the next packet is sent on.
+// Only paths that are not limited by congestion control or pacing are considered.
+// Paths in standby are only used if no other path is available.
+// Among the remaining paths, the path with the lowest RTT is selected.
+// Paths without an RTT sample are preferred, so that the RTT of a new path is measured quickly.
+// It returns false if there's no path that can be used for sending.
+func schedulePath(paths []schedulerPath) (uint64, bool) {
+	var hasAvailable bool
+	for _, p := range paths {
+		if !p.standby {
+			hasAvailable = true
+			break
+		}
+	}
+
+	var selected *schedulerPath
+	for i := range paths {
+		p := &paths[i]
+		if p.sendMode != ackhandler.SendAny {
+			continue
+		}
+		if hasAvailable && p.standby {
+			continue
+		}
+		if selected == nil || p.rtt < selected.rtt {
+			selected = p
+		}
+	}
+	if selected == nil {
+		return 0, false
+	}
+	return selected.id, true
+}
@@ -0,0 +1,74 @@
+package quic
+
+import (
+	"time"
+
+	"github.com/quic-go/quic-go/internal/ackhandler"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Path Scheduler", func() {
+	It("returns false if there are no paths", func() {
+		_, ok := schedulePath(nil)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("selects the path with the lowest RTT", func() {
+		id, ok := schedulePath([]schedulerPath{
+			{id: 0, rtt: 20 * time.Millisecond, sendMode: ackhandler.SendAny},
+			{id: 1, rtt: 10 * time.Millisecond, sendMode: ackhandler.SendAny},
+			{id: 2, rtt: 30 * time.Millisecond, sendMode: ackhandler.SendAny},
+		})
+		Expect(ok).To(BeTrue())
+		Expect(id).To(BeEquivalentTo(1))
+	})
+
+	It("prefers paths without an RTT sample", func() {
+		id, ok := schedulePath([]schedulerPath{
+			{id: 0, rtt: 20 * time.Millisecond, sendMode: ackhandler.SendAny},
+			{id: 1, sendMode: ackhandler.SendAny},
+		})
+		Expect(ok).To(BeTrue())
+		Expect(id).To(BeEquivalentTo(1))
+	})
+
+	It("skips paths that are congestion or pacing limited", func() {
+		id, ok := schedulePath([]schedulerPath{
+			{id: 0, rtt: 10 * time.Millisecond, sendMode: ackhandler.SendAck},
+			{id: 1, rtt: 20 * time.Millisecond, sendMode: ackhandler.SendPacingLimited},
+			{id: 2, rtt: 30 * time.Millisecond, sendMode: ackhandler.SendAny},
+		})
+		Expect(ok).To(BeTrue())
+		Expect(id).To(BeEquivalentTo(2))
+		_, ok = schedulePath([]schedulerPath{
+			{id: 0, rtt: 10 * time.Millisecond, sendMode: ackhandler.SendNone},
+			{id: 1, rtt: 20 * time.Millisecond, sendMode: ackhandler.SendPacingLimited},
+		})
+		Expect(ok).To(BeFalse())
+	})
+
+	It("only uses paths in standby if there's no other path", func() {
+		id, ok := schedulePath([]schedulerPath{
+			{id: 0, rtt: 20 * time.Millisecond, sendMode: ackhandler.SendAny},
+			{id: 1, rtt: 10 * time.Millisecond, sendMode: ackhandler.SendAny, standby: true},
+		})
+		Expect(ok).To(BeTrue())
+		Expect(id).To(BeEquivalentTo(0))
+		id, ok = schedulePath([]schedulerPath{
+			{id: 1, rtt: 20 * time.Millisecond, sendMode: ackhandler.SendAny, standby: true},
+			{id: 2, rtt: 10 * time.Millisecond, sendMode: ackhandler.SendAny, standby: true},
+		})
+		Expect(ok).To(BeTrue())
+		Expect(id).To(BeEquivalentTo(2))
+	})
+
+	It("doesn't use paths in standby if the other paths are congestion limited", func() {
+		_, ok := schedulePath([]schedulerPath{
+			{id: 0, rtt: 20 * time.Millisecond, sendMode: ackhandler.SendAck},
+			{id: 1, rtt: 10 * time.Millisecond, sendMode: ackhandler.SendAny, standby: true},
+		})
+		Expect(ok).To(BeFalse())
+	})
+})
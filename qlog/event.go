@@ -542,6 +542,71 @@ func (e eventECNStateUpdated) MarshalJSONObject(enc *gojay.Encoder) {
 	enc.StringKeyOmitEmpty("trigger", ecnStateTrigger(e.trigger).String())
 }
 
+type eventECNCongestionWindowReduced struct {
+	ceMarked         int64
+	congestionWindow logging.ByteCount
+}
+
+func (e eventECNCongestionWindowReduced) Category() category { return categoryRecovery }
+func (e eventECNCongestionWindowReduced) Name() string       { return "ecn_congestion_window_reduced" }
+func (e eventECNCongestionWindowReduced) IsNil() bool        { return false }
+
+func (e eventECNCongestionWindowReduced) MarshalJSONObject(enc *gojay.Encoder) {
+	enc.Int64Key("ce_marked", e.ceMarked)
+	enc.Uint64Key("congestion_window", uint64(e.congestionWindow))
+}
+
+type eventPersistentCongestionDetected struct {
+	congestionWindow logging.ByteCount
+}
+
+func (e eventPersistentCongestionDetected) Category() category { return categoryRecovery }
+func (e eventPersistentCongestionDetected) Name() string       { return "persistent_congestion_detected" }
+func (e eventPersistentCongestionDetected) IsNil() bool        { return false }
+
+func (e eventPersistentCongestionDetected) MarshalJSONObject(enc *gojay.Encoder) {
+	enc.Uint64Key("congestion_window", uint64(e.congestionWindow))
+}
+
+type eventPathUpdated struct {
+	local, remote net.Addr
+}
+
+func (e eventPathUpdated) Category() category { return categoryConnectivity }
+func (e eventPathUpdated) Name() string       { return "path_updated" }
+func (e eventPathUpdated) IsNil() bool        { return false }
+
+func (e eventPathUpdated) MarshalJSONObject(enc *gojay.Encoder) {
+	enc.StringKey("local", e.local.String())
+	enc.StringKey("remote", e.remote.String())
+}
+
+type eventPathValidationStarted struct {
+	remote net.Addr
+}
+
+func (e eventPathValidationStarted) Category() category { return categoryConnectivity }
+func (e eventPathValidationStarted) Name() string       { return "path_validation_started" }
+func (e eventPathValidationStarted) IsNil() bool        { return false }
+
+func (e eventPathValidationStarted) MarshalJSONObject(enc *gojay.Encoder) {
+	enc.StringKey("remote", e.remote.String())
+}
+
+type eventPathValidationCompleted struct {
+	remote  net.Addr
+	success bool
+}
+
+func (e eventPathValidationCompleted) Category() category { return categoryConnectivity }
+func (e eventPathValidationCompleted) Name() string       { return "path_validation_completed" }
+func (e eventPathValidationCompleted) IsNil() bool        { return false }
+
+func (e eventPathValidationCompleted) MarshalJSONObject(enc *gojay.Encoder) {
+	enc.StringKey("remote", e.remote.String())
+	enc.BoolKey("success", e.success)
+}
+
 type eventGeneric struct {
 	name string
 	msg  string
@@ -53,6 +53,12 @@ func (f frame) MarshalJSONObject(enc *gojay.Encoder) {
 		marshalPathChallengeFrame(enc, frame)
 	case *logging.PathResponseFrame:
 		marshalPathResponseFrame(enc, frame)
+	case *logging.PathAckFrame:
+		marshalPathAckFrame(enc, frame)
+	case *logging.PathAbandonFrame:
+		marshalPathAbandonFrame(enc, frame)
+	case *logging.PathStatusFrame:
+		marshalPathStatusFrame(enc, frame)
 	case *logging.ConnectionCloseFrame:
 		marshalConnectionCloseFrame(enc, frame)
 	case *logging.HandshakeDoneFrame:
@@ -201,6 +207,39 @@ func marshalPathResponseFrame(enc *gojay.Encoder, f *logging.PathResponseFrame)
 	enc.StringKey("data", fmt.Sprintf("%x", f.Data[:]))
 }
 
+func marshalPathAckFrame(enc *gojay.Encoder, f *logging.PathAckFrame) {
+	enc.StringKey("frame_type", "path_ack")
+	enc.Uint64Key("path_id", f.PathID)
+	enc.FloatKeyOmitEmpty("ack_delay", milliseconds(f.DelayTime))
+	enc.ArrayKey("acked_ranges", ackRanges(f.AckRanges))
+	if hasECN := f.ECT0 > 0 || f.ECT1 > 0 || f.ECNCE > 0; hasECN {
+		enc.Uint64Key("ect0", f.ECT0)
+		enc.Uint64Key("ect1", f.ECT1)
+		enc.Uint64Key("ce", f.ECNCE)
+	}
+}
+
+func marshalPathAbandonFrame(enc *gojay.Encoder, f *logging.PathAbandonFrame) {
+	enc.StringKey("frame_type", "path_abandon")
+	enc.Uint64Key("path_id", f.PathID)
+	enc.Uint64Key("error_code", f.ErrorCode)
+	enc.StringKey("reason", f.ReasonPhrase)
+}
+
+func marshalPathStatusFrame(enc *gojay.Encoder, f *logging.PathStatusFrame) {
+	enc.StringKey("frame_type", "path_status")
+	enc.Uint64Key("path_id", f.PathID)
+	enc.Uint64Key("sequence_number", f.SequenceNumber)
+	switch f.Status {
+	case wire.PathStatusStandby:
+		enc.StringKey("path_status", "standby")
+	case wire.PathStatusAvailable:
+		enc.StringKey("path_status", "available")
+	default:
+		enc.Uint64Key("path_status", uint64(f.Status))
+	}
+}
+
 func marshalConnectionCloseFrame(enc *gojay.Encoder, f *logging.ConnectionCloseFrame) {
 	errorSpace := "transport"
 	if f.IsApplicationError {
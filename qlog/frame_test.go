@@ -7,6 +7,7 @@ import (
 
 	"github.com/quic-go/quic-go/internal/protocol"
 	"github.com/quic-go/quic-go/internal/qerr"
+	"github.com/quic-go/quic-go/internal/wire"
 	"github.com/quic-go/quic-go/logging"
 
 	"github.com/francoispqt/gojay"
@@ -322,6 +323,56 @@ var _ = Describe("Frames", func() {
 		)
 	})
 
+	It("marshals PATH_ACK frames", func() {
+		check(
+			&logging.PathAckFrame{
+				PathID: 3,
+				AckFrame: logging.AckFrame{
+					DelayTime: 86 * time.Millisecond,
+					AckRanges: []logging.AckRange{{Smallest: 120, Largest: 120}},
+				},
+			},
+			map[string]interface{}{
+				"frame_type":   "path_ack",
+				"path_id":      3,
+				"ack_delay":    86,
+				"acked_ranges": [][]float64{{120}},
+			},
+		)
+	})
+
+	It("marshals PATH_ABANDON frames", func() {
+		check(
+			&logging.PathAbandonFrame{
+				PathID:       3,
+				ErrorCode:    42,
+				ReasonPhrase: "foobar",
+			},
+			map[string]interface{}{
+				"frame_type": "path_abandon",
+				"path_id":    3,
+				"error_code": 42,
+				"reason":     "foobar",
+			},
+		)
+	})
+
+	It("marshals PATH_STATUS frames", func() {
+		check(
+			&logging.PathStatusFrame{
+				PathID:         3,
+				SequenceNumber: 7,
+				Status:         wire.PathStatusStandby,
+			},
+			map[string]interface{}{
+				"frame_type":      "path_status",
+				"path_id":         3,
+				"sequence_number": 7,
+				"path_status":     "standby",
+			},
+		)
+	})
+
 	It("marshals CONNECTION_CLOSE frames, for application error codes", func() {
 		check(
 			&logging.ConnectionCloseFrame{
@@ -145,6 +145,21 @@ func NewConnectionTracer(w io.WriteCloser, p protocol.Perspective, odcid protoco
 		ECNStateUpdated: func(state logging.ECNState, trigger logging.ECNStateTrigger) {
 			t.ECNStateUpdated(state, trigger)
 		},
+		ECNCongestionWindowReduced: func(ceMarked int64, congestionWindow logging.ByteCount) {
+			t.ECNCongestionWindowReduced(ceMarked, congestionWindow)
+		},
+		DetectedPersistentCongestion: func(congestionWindow logging.ByteCount) {
+			t.DetectedPersistentCongestion(congestionWindow)
+		},
+		UpdatedPath: func(local, remote net.Addr) {
+			t.UpdatedPath(local, remote)
+		},
+		StartedPathValidation: func(remote net.Addr) {
+			t.StartedPathValidation(remote)
+		},
+		CompletedPathValidation: func(remote net.Addr, success bool) {
+			t.CompletedPathValidation(remote, success)
+		},
 		Debug: func(name, msg string) {
 			t.Debug(name, msg)
 		},
@@ -585,6 +600,36 @@ func (t *connectionTracer) ECNStateUpdated(state logging.ECNState, trigger loggi
 	t.mutex.Unlock()
 }
 
+func (t *connectionTracer) ECNCongestionWindowReduced(ceMarked int64, cwnd logging.ByteCount) {
+	t.mutex.Lock()
+	t.recordEvent(time.Now(), &eventECNCongestionWindowReduced{ceMarked: ceMarked, congestionWindow: cwnd})
+	t.mutex.Unlock()
+}
+
+func (t *connectionTracer) DetectedPersistentCongestion(cwnd logging.ByteCount) {
+	t.mutex.Lock()
+	t.recordEvent(time.Now(), &eventPersistentCongestionDetected{congestionWindow: cwnd})
+	t.mutex.Unlock()
+}
+
+func (t *connectionTracer) UpdatedPath(local, remote net.Addr) {
+	t.mutex.Lock()
+	t.recordEvent(time.Now(), &eventPathUpdated{local: local, remote: remote})
+	t.mutex.Unlock()
+}
+
+func (t *connectionTracer) StartedPathValidation(remote net.Addr) {
+	t.mutex.Lock()
+	t.recordEvent(time.Now(), &eventPathValidationStarted{remote: remote})
+	t.mutex.Unlock()
+}
+
+func (t *connectionTracer) CompletedPathValidation(remote net.Addr, success bool) {
+	t.mutex.Lock()
+	t.recordEvent(time.Now(), &eventPathValidationCompleted{remote: remote, success: success})
+	t.mutex.Unlock()
+}
+
 func (t *connectionTracer) Debug(name, msg string) {
 	t.mutex.Lock()
 	t.recordEvent(time.Now(), &eventGeneric{
@@ -891,6 +891,62 @@ var _ = Describe("Tracing", func() {
 				Expect(ev).To(HaveKeyWithValue("new", "unknown"))
 			})
 
+			It("records a congestion window reduction caused by ECN-CE marks", func() {
+				tracer.ECNCongestionWindowReduced(3, 12345)
+				entry := exportAndParseSingle()
+				Expect(entry.Time).To(BeTemporally("~", time.Now(), scaleDuration(10*time.Millisecond)))
+				Expect(entry.Name).To(Equal("recovery:ecn_congestion_window_reduced"))
+				ev := entry.Event
+				Expect(ev).To(HaveLen(2))
+				Expect(ev).To(HaveKeyWithValue("ce_marked", float64(3)))
+				Expect(ev).To(HaveKeyWithValue("congestion_window", float64(12345)))
+			})
+
+			It("records persistent congestion", func() {
+				tracer.DetectedPersistentCongestion(2400)
+				entry := exportAndParseSingle()
+				Expect(entry.Time).To(BeTemporally("~", time.Now(), scaleDuration(10*time.Millisecond)))
+				Expect(entry.Name).To(Equal("recovery:persistent_congestion_detected"))
+				ev := entry.Event
+				Expect(ev).To(HaveLen(1))
+				Expect(ev).To(HaveKeyWithValue("congestion_window", float64(2400)))
+			})
+
+			It("records a path update", func() {
+				tracer.UpdatedPath(
+					&net.UDPAddr{IP: net.IPv4(192, 168, 13, 37), Port: 42},
+					&net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 24},
+				)
+				entry := exportAndParseSingle()
+				Expect(entry.Time).To(BeTemporally("~", time.Now(), scaleDuration(10*time.Millisecond)))
+				Expect(entry.Name).To(Equal("connectivity:path_updated"))
+				ev := entry.Event
+				Expect(ev).To(HaveLen(2))
+				Expect(ev).To(HaveKeyWithValue("local", "192.168.13.37:42"))
+				Expect(ev).To(HaveKeyWithValue("remote", "10.0.0.1:24"))
+			})
+
+			It("records the start of a path validation", func() {
+				tracer.StartedPathValidation(&net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 24})
+				entry := exportAndParseSingle()
+				Expect(entry.Time).To(BeTemporally("~", time.Now(), scaleDuration(10*time.Millisecond)))
+				Expect(entry.Name).To(Equal("connectivity:path_validation_started"))
+				ev := entry.Event
+				Expect(ev).To(HaveLen(1))
+				Expect(ev).To(HaveKeyWithValue("remote", "10.0.0.1:24"))
+			})
+
+			It("records the result of a path validation", func() {
+				tracer.CompletedPathValidation(&net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 24}, false)
+				entry := exportAndParseSingle()
+				Expect(entry.Time).To(BeTemporally("~", time.Now(), scaleDuration(10*time.Millisecond)))
+				Expect(entry.Name).To(Equal("connectivity:path_validation_completed"))
+				ev := entry.Event
+				Expect(ev).To(HaveLen(2))
+				Expect(ev).To(HaveKeyWithValue("remote", "10.0.0.1:24"))
+				Expect(ev).To(HaveKeyWithValue("success", false))
+			})
+
 			It("records an ECN state transition, with a trigger", func() {
 				tracer.ECNStateUpdated(logging.ECNStateFailed, logging.ECNFailedNoECNCounts)
 				entry := exportAndParseSingle()
@@ -308,6 +308,22 @@ func (s congestionState) String() string {
 		return "recovery"
 	case logging.CongestionStateApplicationLimited:
 		return "application_limited"
+	case logging.CongestionStateStartup:
+		return "startup"
+	case logging.CongestionStateDrain:
+		return "drain"
+	case logging.CongestionStateProbeBWDown:
+		return "probe_bw_down"
+	case logging.CongestionStateProbeBWCruise:
+		return "probe_bw_cruise"
+	case logging.CongestionStateProbeBWRefill:
+		return "probe_bw_refill"
+	case logging.CongestionStateProbeBWUp:
+		return "probe_bw_up"
+	case logging.CongestionStateProbeRTT:
+		return "probe_rtt"
+	case logging.CongestionStateConservativeSlowStart:
+		return "conservative_slow_start"
 	default:
 		return "unknown congestion state"
 	}
@@ -126,6 +126,14 @@ var _ = Describe("Types", func() {
 		Expect(congestionState(logging.CongestionStateCongestionAvoidance).String()).To(Equal("congestion_avoidance"))
 		Expect(congestionState(logging.CongestionStateApplicationLimited).String()).To(Equal("application_limited"))
 		Expect(congestionState(logging.CongestionStateRecovery).String()).To(Equal("recovery"))
+		Expect(congestionState(logging.CongestionStateStartup).String()).To(Equal("startup"))
+		Expect(congestionState(logging.CongestionStateDrain).String()).To(Equal("drain"))
+		Expect(congestionState(logging.CongestionStateProbeBWDown).String()).To(Equal("probe_bw_down"))
+		Expect(congestionState(logging.CongestionStateProbeBWCruise).String()).To(Equal("probe_bw_cruise"))
+		Expect(congestionState(logging.CongestionStateProbeBWRefill).String()).To(Equal("probe_bw_refill"))
+		Expect(congestionState(logging.CongestionStateProbeBWUp).String()).To(Equal("probe_bw_up"))
+		Expect(congestionState(logging.CongestionStateProbeRTT).String()).To(Equal("probe_rtt"))
+		Expect(congestionState(logging.CongestionStateConservativeSlowStart).String()).To(Equal("conservative_slow_start"))
 	})
 
 	It("has a string representation for the ECN bits", func() {
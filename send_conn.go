@@ -10,6 +10,12 @@ import (
 // A sendConn allows sending using a simple Write() on a non-connected packet conn.
 type sendConn interface {
 	Write(b []byte, gsoSize uint16, ecn protocol.ECN) error
+	// WriteTo sends a single packet to a different remote address.
+	// It is used for packets that need to be sent on a path that's not the active path.
+	WriteTo(b []byte, addr net.Addr) error
+	// WithRemoteAddr returns a sendConn that uses the same socket to send to a different remote address.
+	// It is used when the peer's address changes.
+	WithRemoteAddr(addr net.Addr, info packetInfo) sendConn
 	Close() error
 	LocalAddr() net.Addr
 	RemoteAddr() net.Addr
@@ -82,6 +88,15 @@ func (c *sconn) Write(p []byte, gsoSize uint16, ecn protocol.ECN) error {
 	return err
 }
 
+func (c *sconn) WriteTo(p []byte, addr net.Addr) error {
+	_, err := c.WritePacket(p, addr, c.packetInfoOOB, 0, protocol.ECNUnsupported)
+	return err
+}
+
+func (c *sconn) WithRemoteAddr(addr net.Addr, info packetInfo) sendConn {
+	return newSendConn(c.rawConn, addr, info, c.logger)
+}
+
 func (c *sconn) writePacket(p []byte, addr net.Addr, oob []byte, gsoSize uint16, ecn protocol.ECN) error {
 	_, err := c.WritePacket(p, addr, oob, gsoSize, ecn)
 	if err != nil && !c.wroteFirstPacket && isPermissionError(err) {
@@ -51,6 +51,19 @@ var _ = Describe("Connection (for sending packets)", func() {
 		})
 	}
 
+	It("sends to a different remote address", func() {
+		rawConn := NewMockRawConn(mockCtrl)
+		rawConn.EXPECT().LocalAddr().Times(2)
+		rawConn.EXPECT().capabilities().AnyTimes()
+		c := newSendConn(rawConn, remoteAddr, packetInfo{}, utils.DefaultLogger)
+		newAddr := &net.UDPAddr{IP: net.IPv4(192, 168, 100, 200), Port: 4242}
+		c2 := c.WithRemoteAddr(newAddr, packetInfo{})
+		Expect(c2.RemoteAddr()).To(Equal(newAddr))
+		Expect(c.RemoteAddr()).To(Equal(remoteAddr))
+		rawConn.EXPECT().WritePacket([]byte("foobar"), newAddr, gomock.Any(), uint16(0), protocol.ECNNon)
+		Expect(c2.Write([]byte("foobar"), 0, protocol.ECNNon)).To(Succeed())
+	})
+
 	It("writes", func() {
 		rawConn := NewMockRawConn(mockCtrl)
 		rawConn.EXPECT().LocalAddr()
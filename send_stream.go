@@ -51,6 +51,8 @@ type sendStream struct {
 	writeOnce chan struct{}
 	deadline  time.Time
 
+	priority StreamPriority
+
 	flowController flowcontrol.StreamFlowController
 }
 
@@ -59,6 +61,8 @@ var (
 	_ sendStreamI = &sendStream{}
 )
 
+var defaultStreamPriority = StreamPriority{Urgency: 3, Incremental: true}
+
 func newSendStream(
 	streamID protocol.StreamID,
 	sender streamSender,
@@ -70,6 +74,7 @@ func newSendStream(
 		flowController: flowController,
 		writeChan:      make(chan struct{}, 1),
 		writeOnce:      make(chan struct{}, 1), // cap: 1, to protect against concurrent use of Write
+		priority:       defaultStreamPriority,
 	}
 	s.ctx, s.ctxCancel = context.WithCancelCause(context.Background())
 	return s
@@ -156,9 +161,10 @@ func (s *sendStream) Write(p []byte) (int, error) {
 			}
 		}
 
+		priority := s.priority
 		s.mutex.Unlock()
 		if !notifiedSender {
-			s.sender.onHasStreamData(s.streamID) // must be called without holding the mutex
+			s.sender.onHasStreamData(s.streamID, priority) // must be called without holding the mutex
 			notifiedSender = true
 		}
 		if copied {
@@ -368,9 +374,10 @@ func (s *sendStream) Close() error {
 	}
 	s.ctxCancel(nil)
 	s.finishedWriting = true
+	priority := s.priority
 	s.mutex.Unlock()
 
-	s.sender.onHasStreamData(s.streamID) // need to send the FIN, must be called without holding the mutex
+	s.sender.onHasStreamData(s.streamID, priority) // need to send the FIN, must be called without holding the mutex
 	return nil
 }
 
@@ -406,11 +413,25 @@ func (s *sendStream) cancelWriteImpl(errorCode qerr.StreamErrorCode, remote bool
 func (s *sendStream) updateSendWindow(limit protocol.ByteCount) {
 	s.mutex.Lock()
 	hasStreamData := s.dataForWriting != nil || s.nextFrame != nil
+	priority := s.priority
 	s.mutex.Unlock()
 
 	s.flowController.UpdateSendWindow(limit)
 	if hasStreamData {
-		s.sender.onHasStreamData(s.streamID)
+		s.sender.onHasStreamData(s.streamID, priority)
+	}
+}
+
+func (s *sendStream) SetPriority(priority StreamPriority) {
+	s.mutex.Lock()
+	s.priority = priority
+	// If the stream has data to send, the framer needs to reschedule it.
+	hasStreamData := s.cancelWriteErr == nil && s.closeForShutdownErr == nil &&
+		(s.dataForWriting != nil || s.nextFrame != nil || len(s.retransmissionQueue) > 0 || (s.finishedWriting && !s.finSent))
+	s.mutex.Unlock()
+
+	if hasStreamData {
+		s.sender.onHasStreamData(s.streamID, priority)
 	}
 }
 
@@ -486,7 +507,8 @@ func (s *sendStreamAckHandler) OnLost(f wire.Frame) {
 	if s.numOutstandingFrames < 0 {
 		panic("numOutStandingFrames negative")
 	}
+	priority := s.priority
 	s.mutex.Unlock()
 
-	s.sender.onHasStreamData(s.streamID)
+	s.sender.onHasStreamData(s.streamID, priority)
 }
@@ -80,7 +80,7 @@ var _ = Describe("Send Stream", func() {
 			go func() {
 				defer GinkgoRecover()
 				defer close(done)
-				mockSender.EXPECT().onHasStreamData(streamID)
+				mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 				n, err := strWithTimeout.Write([]byte("foobar"))
 				Expect(err).ToNot(HaveOccurred())
 				Expect(n).To(Equal(6))
@@ -104,7 +104,7 @@ var _ = Describe("Send Stream", func() {
 			done := make(chan struct{})
 			go func() {
 				defer GinkgoRecover()
-				mockSender.EXPECT().onHasStreamData(streamID)
+				mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 				n, err := strWithTimeout.Write([]byte("foobar"))
 				Expect(err).ToNot(HaveOccurred())
 				Expect(n).To(Equal(6))
@@ -136,7 +136,7 @@ var _ = Describe("Send Stream", func() {
 			done := make(chan struct{})
 			go func() {
 				defer GinkgoRecover()
-				mockSender.EXPECT().onHasStreamData(streamID).Times(2)
+				mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority).Times(2)
 				n, err := strWithTimeout.Write([]byte("foo"))
 				Expect(err).ToNot(HaveOccurred())
 				Expect(n).To(Equal(3))
@@ -163,7 +163,7 @@ var _ = Describe("Send Stream", func() {
 			done := make(chan struct{})
 			go func() {
 				defer GinkgoRecover()
-				mockSender.EXPECT().onHasStreamData(streamID)
+				mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 				n, err := strWithTimeout.Write(getData(5000))
 				Expect(err).ToNot(HaveOccurred())
 				Expect(n).To(Equal(5000))
@@ -188,7 +188,7 @@ var _ = Describe("Send Stream", func() {
 			go func() {
 				defer GinkgoRecover()
 				defer close(done)
-				mockSender.EXPECT().onHasStreamData(streamID)
+				mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 				_, err := strWithTimeout.Write(getData(protocol.MaxPacketBufferSize + 3))
 				Expect(err).ToNot(HaveOccurred())
 			}()
@@ -211,14 +211,14 @@ var _ = Describe("Send Stream", func() {
 		})
 
 		It("only unblocks Write once a previously buffered STREAM frame has been fully dequeued", func() {
-			mockSender.EXPECT().onHasStreamData(streamID)
+			mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 			_, err := strWithTimeout.Write([]byte("foobar"))
 			Expect(err).ToNot(HaveOccurred())
 			done := make(chan struct{})
 			go func() {
 				defer GinkgoRecover()
 				defer close(done)
-				mockSender.EXPECT().onHasStreamData(streamID)
+				mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 				_, err := str.Write(getData(protocol.MaxPacketBufferSize))
 				Expect(err).ToNot(HaveOccurred())
 			}()
@@ -251,7 +251,7 @@ var _ = Describe("Send Stream", func() {
 			go func() {
 				defer GinkgoRecover()
 				defer close(done)
-				mockSender.EXPECT().onHasStreamData(streamID)
+				mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 				n, err := strWithTimeout.Write(bytes.Repeat([]byte{0}, 100))
 				Expect(err).ToNot(HaveOccurred())
 				Expect(n).To(Equal(100))
@@ -282,7 +282,7 @@ var _ = Describe("Send Stream", func() {
 			go func() {
 				defer GinkgoRecover()
 				defer close(done)
-				mockSender.EXPECT().onHasStreamData(streamID)
+				mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 				n, err := strWithTimeout.Write(s)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(n).To(Equal(3))
@@ -315,7 +315,7 @@ var _ = Describe("Send Stream", func() {
 		})
 
 		It("cancels the context when Close is called", func() {
-			mockSender.EXPECT().onHasStreamData(streamID)
+			mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 			Expect(str.Context().Done()).ToNot(BeClosed())
 			Expect(str.Close()).To(Succeed())
 			Expect(str.Context().Done()).To(BeClosed())
@@ -334,7 +334,7 @@ var _ = Describe("Send Stream", func() {
 				go func() {
 					defer GinkgoRecover()
 					defer close(done)
-					mockSender.EXPECT().onHasStreamData(streamID)
+					mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 					_, err := str.Write([]byte("foobar"))
 					Expect(err).ToNot(HaveOccurred())
 				}()
@@ -352,7 +352,7 @@ var _ = Describe("Send Stream", func() {
 				go func() {
 					defer GinkgoRecover()
 					defer close(done)
-					mockSender.EXPECT().onHasStreamData(streamID)
+					mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 					_, err := str.Write([]byte("foobar"))
 					Expect(err).ToNot(HaveOccurred())
 				}()
@@ -392,7 +392,7 @@ var _ = Describe("Send Stream", func() {
 			})
 
 			It("unblocks after the deadline", func() {
-				mockSender.EXPECT().onHasStreamData(streamID)
+				mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 				deadline := time.Now().Add(scaleDuration(50 * time.Millisecond))
 				str.SetWriteDeadline(deadline)
 				n, err := strWithTimeout.Write(getData(5000))
@@ -402,7 +402,7 @@ var _ = Describe("Send Stream", func() {
 			})
 
 			It("unblocks when the deadline is changed to the past", func() {
-				mockSender.EXPECT().onHasStreamData(streamID)
+				mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 				str.SetWriteDeadline(time.Now().Add(time.Hour))
 				done := make(chan struct{})
 				go func() {
@@ -426,7 +426,7 @@ var _ = Describe("Send Stream", func() {
 				go func() {
 					defer GinkgoRecover()
 					defer close(writeReturned)
-					mockSender.EXPECT().onHasStreamData(streamID)
+					mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 					var err error
 					n, err = strWithTimeout.Write(getData(5000))
 					Expect(err).To(MatchError(errDeadline))
@@ -450,7 +450,7 @@ var _ = Describe("Send Stream", func() {
 				go func() {
 					defer GinkgoRecover()
 					defer close(writeReturned)
-					mockSender.EXPECT().onHasStreamData(streamID)
+					mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 					_, err := strWithTimeout.Write(getData(5000))
 					Expect(err).To(MatchError(errDeadline))
 				}()
@@ -466,7 +466,7 @@ var _ = Describe("Send Stream", func() {
 			})
 
 			It("doesn't unblock if the deadline is changed before the first one expires", func() {
-				mockSender.EXPECT().onHasStreamData(streamID)
+				mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 				deadline1 := time.Now().Add(scaleDuration(50 * time.Millisecond))
 				deadline2 := time.Now().Add(scaleDuration(100 * time.Millisecond))
 				str.SetWriteDeadline(deadline1)
@@ -488,7 +488,7 @@ var _ = Describe("Send Stream", func() {
 			})
 
 			It("unblocks earlier, when a new deadline is set", func() {
-				mockSender.EXPECT().onHasStreamData(streamID)
+				mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 				deadline1 := time.Now().Add(scaleDuration(200 * time.Millisecond))
 				deadline2 := time.Now().Add(scaleDuration(50 * time.Millisecond))
 				done := make(chan struct{})
@@ -509,7 +509,7 @@ var _ = Describe("Send Stream", func() {
 			})
 
 			It("doesn't unblock if the deadline is removed", func() {
-				mockSender.EXPECT().onHasStreamData(streamID)
+				mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 				deadline := time.Now().Add(scaleDuration(50 * time.Millisecond))
 				str.SetWriteDeadline(deadline)
 				deadlineUnset := make(chan struct{})
@@ -539,14 +539,14 @@ var _ = Describe("Send Stream", func() {
 
 		Context("closing", func() {
 			It("doesn't allow writes after it has been closed", func() {
-				mockSender.EXPECT().onHasStreamData(streamID)
+				mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 				str.Close()
 				_, err := strWithTimeout.Write([]byte("foobar"))
 				Expect(err).To(MatchError("write on closed stream 1337"))
 			})
 
 			It("allows FIN", func() {
-				mockSender.EXPECT().onHasStreamData(streamID)
+				mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 				str.Close()
 				frame, ok, hasMoreData := str.popStreamFrame(1000, protocol.Version1)
 				Expect(ok).To(BeTrue())
@@ -560,7 +560,7 @@ var _ = Describe("Send Stream", func() {
 
 			It("doesn't send a FIN when there's still data", func() {
 				const frameHeaderLen protocol.ByteCount = 4
-				mockSender.EXPECT().onHasStreamData(streamID).Times(2)
+				mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority).Times(2)
 				_, err := strWithTimeout.Write([]byte("foobar"))
 				Expect(err).ToNot(HaveOccurred())
 				Expect(str.Close()).To(Succeed())
@@ -584,10 +584,10 @@ var _ = Describe("Send Stream", func() {
 				go func() {
 					defer GinkgoRecover()
 					defer close(done)
-					mockSender.EXPECT().onHasStreamData(streamID)
+					mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 					_, err := strWithTimeout.Write(getData(5000))
 					Expect(err).ToNot(HaveOccurred())
-					mockSender.EXPECT().onHasStreamData(streamID)
+					mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 					Expect(str.Close()).To(Succeed())
 				}()
 				waitForWrite()
@@ -619,7 +619,7 @@ var _ = Describe("Send Stream", func() {
 			})
 
 			It("doesn't allow FIN twice", func() {
-				mockSender.EXPECT().onHasStreamData(streamID)
+				mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 				str.Close()
 				frame, ok, _ := str.popStreamFrame(1000, protocol.Version1)
 				Expect(ok).To(BeTrue())
@@ -646,7 +646,7 @@ var _ = Describe("Send Stream", func() {
 			It("doesn't get data for writing if an error occurred", func() {
 				mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount)
 				mockFC.EXPECT().AddBytesSent(gomock.Any())
-				mockSender.EXPECT().onHasStreamData(streamID)
+				mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 				done := make(chan struct{})
 				go func() {
 					defer GinkgoRecover()
@@ -683,7 +683,7 @@ var _ = Describe("Send Stream", func() {
 
 		It("says when it has data for sending", func() {
 			mockFC.EXPECT().UpdateSendWindow(gomock.Any())
-			mockSender.EXPECT().onHasStreamData(streamID)
+			mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 			done := make(chan struct{})
 			go func() {
 				defer GinkgoRecover()
@@ -692,7 +692,7 @@ var _ = Describe("Send Stream", func() {
 				close(done)
 			}()
 			waitForWrite()
-			mockSender.EXPECT().onHasStreamData(streamID)
+			mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 			str.updateSendWindow(42)
 			// make sure the Write go routine returns
 			str.closeForShutdown(nil)
@@ -720,7 +720,7 @@ var _ = Describe("Send Stream", func() {
 			// for reliable results it has to be run many times.
 			It("returns a nil error when the whole slice has been sent out", func() {
 				mockSender.EXPECT().queueControlFrame(gomock.Any()).MaxTimes(1)
-				mockSender.EXPECT().onHasStreamData(streamID).MaxTimes(1)
+				mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority).MaxTimes(1)
 				mockSender.EXPECT().onStreamCompleted(streamID).MaxTimes(1)
 				mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount).MaxTimes(1)
 				mockFC.EXPECT().AddBytesSent(gomock.Any()).MaxTimes(1)
@@ -743,7 +743,7 @@ var _ = Describe("Send Stream", func() {
 
 			It("unblocks Write", func() {
 				mockSender.EXPECT().queueControlFrame(gomock.Any())
-				mockSender.EXPECT().onHasStreamData(streamID)
+				mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 				mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount)
 				mockFC.EXPECT().AddBytesSent(gomock.Any())
 				writeReturned := make(chan struct{})
@@ -771,7 +771,7 @@ var _ = Describe("Send Stream", func() {
 
 			It("doesn't pop STREAM frames after being canceled", func() {
 				mockSender.EXPECT().queueControlFrame(gomock.Any())
-				mockSender.EXPECT().onHasStreamData(streamID)
+				mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 				mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount)
 				mockFC.EXPECT().AddBytesSent(gomock.Any())
 				writeReturned := make(chan struct{})
@@ -795,7 +795,7 @@ var _ = Describe("Send Stream", func() {
 
 			It("doesn't pop STREAM frames after being canceled, for large writes", func() {
 				mockSender.EXPECT().queueControlFrame(gomock.Any())
-				mockSender.EXPECT().onHasStreamData(streamID)
+				mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 				mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount)
 				mockFC.EXPECT().AddBytesSent(gomock.Any())
 				writeReturned := make(chan struct{})
@@ -824,7 +824,7 @@ var _ = Describe("Send Stream", func() {
 
 			It("ignores acknowledgements for STREAM frames after it was cancelled", func() {
 				mockSender.EXPECT().queueControlFrame(gomock.Any())
-				mockSender.EXPECT().onHasStreamData(streamID)
+				mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 				mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount)
 				mockFC.EXPECT().AddBytesSent(gomock.Any())
 				writeReturned := make(chan struct{})
@@ -873,7 +873,7 @@ var _ = Describe("Send Stream", func() {
 			})
 
 			It("queues a RESET_STREAM frame, even if the stream was already closed", func() {
-				mockSender.EXPECT().onHasStreamData(streamID)
+				mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 				mockSender.EXPECT().queueControlFrame(gomock.Any()).Do(func(f wire.Frame) {
 					Expect(f).To(BeAssignableToTypeOf(&wire.ResetStreamFrame{}))
 				})
@@ -899,7 +899,7 @@ var _ = Describe("Send Stream", func() {
 			})
 
 			It("unblocks Write", func() {
-				mockSender.EXPECT().onHasStreamData(streamID)
+				mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 				mockSender.EXPECT().queueControlFrame(gomock.Any())
 				mockSender.EXPECT().onStreamCompleted(gomock.Any())
 				done := make(chan struct{})
@@ -946,7 +946,7 @@ var _ = Describe("Send Stream", func() {
 				Offset:         0x42,
 				DataLenPresent: false,
 			}
-			mockSender.EXPECT().onHasStreamData(streamID)
+			mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 			(*sendStreamAckHandler)(str).OnLost(f)
 			frame, ok, _ := str.popStreamFrame(protocol.MaxByteCount, protocol.Version1)
 			Expect(ok).To(BeTrue())
@@ -964,7 +964,7 @@ var _ = Describe("Send Stream", func() {
 				Offset:         0x42,
 				DataLenPresent: false,
 			}
-			mockSender.EXPECT().onHasStreamData(streamID)
+			mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 			(*sendStreamAckHandler)(str).OnLost(sf)
 			frame, ok, hasMoreData := str.popStreamFrame(sf.Length(protocol.Version1)-3, protocol.Version1)
 			Expect(ok).To(BeTrue())
@@ -990,7 +990,7 @@ var _ = Describe("Send Stream", func() {
 				Offset:         0x42,
 				DataLenPresent: false,
 			}
-			mockSender.EXPECT().onHasStreamData(streamID)
+			mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 			(*sendStreamAckHandler)(str).OnLost(f)
 			_, ok, hasMoreData := str.popStreamFrame(2, protocol.Version1)
 			Expect(ok).To(BeFalse())
@@ -998,7 +998,7 @@ var _ = Describe("Send Stream", func() {
 		})
 
 		It("queues lost STREAM frames", func() {
-			mockSender.EXPECT().onHasStreamData(streamID)
+			mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 			mockFC.EXPECT().SendWindowSize().Return(protocol.ByteCount(9999))
 			mockFC.EXPECT().AddBytesSent(protocol.ByteCount(6))
 			done := make(chan struct{})
@@ -1016,7 +1016,7 @@ var _ = Describe("Send Stream", func() {
 			Expect(frame.Frame.Data).To(Equal([]byte("foobar")))
 
 			// now lose the frame
-			mockSender.EXPECT().onHasStreamData(streamID)
+			mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 			frame.Handler.OnLost(frame.Frame)
 			newFrame, ok, _ := str.popStreamFrame(protocol.MaxByteCount, protocol.Version1)
 			Expect(ok).To(BeTrue())
@@ -1025,7 +1025,7 @@ var _ = Describe("Send Stream", func() {
 		})
 
 		It("doesn't queue retransmissions for a stream that was canceled", func() {
-			mockSender.EXPECT().onHasStreamData(streamID)
+			mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 			mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount)
 			mockFC.EXPECT().AddBytesSent(protocol.ByteCount(6))
 			done := make(chan struct{})
@@ -1051,6 +1051,42 @@ var _ = Describe("Send Stream", func() {
 		})
 	})
 
+	Context("setting the priority", func() {
+		prio := StreamPriority{Urgency: 1, Incremental: false}
+
+		It("uses the priority when it has data to send", func() {
+			str.SetPriority(prio) // no data yet, so no call to onHasStreamData
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				defer close(done)
+				mockSender.EXPECT().onHasStreamData(streamID, prio)
+				_, err := str.Write([]byte("foobar"))
+				Expect(err).ToNot(HaveOccurred())
+			}()
+			waitForWrite()
+			mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount)
+			mockFC.EXPECT().AddBytesSent(protocol.ByteCount(6))
+			_, ok, _ := str.popStreamFrame(protocol.MaxByteCount, protocol.Version1)
+			Expect(ok).To(BeTrue())
+			Eventually(done).Should(BeClosed())
+		})
+
+		It("reschedules the stream when the priority changes while it has data to send", func() {
+			mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
+			Expect(str.Close()).To(Succeed())
+			mockSender.EXPECT().onHasStreamData(streamID, prio)
+			str.SetPriority(prio)
+		})
+
+		It("doesn't reschedule the stream after writing was canceled", func() {
+			mockSender.EXPECT().queueControlFrame(gomock.Any())
+			mockSender.EXPECT().onStreamCompleted(streamID)
+			str.CancelWrite(1234)
+			str.SetPriority(prio)
+		})
+	})
+
 	Context("determining when a stream is completed", func() {
 		BeforeEach(func() {
 			mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount).AnyTimes()
@@ -1058,7 +1094,7 @@ var _ = Describe("Send Stream", func() {
 		})
 
 		It("says when a stream is completed", func() {
-			mockSender.EXPECT().onHasStreamData(streamID)
+			mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 			done := make(chan struct{})
 			go func() {
 				defer GinkgoRecover()
@@ -1089,7 +1125,7 @@ var _ = Describe("Send Stream", func() {
 			}
 
 			// Now close the stream and acknowledge the FIN.
-			mockSender.EXPECT().onHasStreamData(streamID)
+			mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 			Expect(str.Close()).To(Succeed())
 			frame, ok, _ := str.popStreamFrame(protocol.MaxByteCount, protocol.Version1)
 			Expect(ok).To(BeTrue())
@@ -1099,7 +1135,7 @@ var _ = Describe("Send Stream", func() {
 		})
 
 		It("says when a stream is completed, if Close() is called before popping the frame", func() {
-			mockSender.EXPECT().onHasStreamData(streamID).Times(2)
+			mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority).Times(2)
 			done := make(chan struct{})
 			go func() {
 				defer GinkgoRecover()
@@ -1122,13 +1158,13 @@ var _ = Describe("Send Stream", func() {
 		})
 
 		It("doesn't say it's completed when there are frames waiting to be retransmitted", func() {
-			mockSender.EXPECT().onHasStreamData(streamID)
+			mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 			done := make(chan struct{})
 			go func() {
 				defer GinkgoRecover()
 				_, err := strWithTimeout.Write(getData(100))
 				Expect(err).ToNot(HaveOccurred())
-				mockSender.EXPECT().onHasStreamData(streamID)
+				mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 				Expect(str.Close()).To(Succeed())
 				close(done)
 			}()
@@ -1152,7 +1188,7 @@ var _ = Describe("Send Stream", func() {
 			for _, f := range frames[1:] {
 				f.Handler.OnAcked(f.Frame)
 			}
-			mockSender.EXPECT().onHasStreamData(streamID)
+			mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority)
 			frames[0].Handler.OnLost(frames[0].Frame)
 
 			// get the retransmission and acknowledge it
@@ -1169,7 +1205,7 @@ var _ = Describe("Send Stream", func() {
 		// and has to be retransmitted.
 		It("retransmits data until everything has been acknowledged", func() {
 			const dataLen = 1 << 22 // 4 MB
-			mockSender.EXPECT().onHasStreamData(streamID).AnyTimes()
+			mockSender.EXPECT().onHasStreamData(streamID, defaultStreamPriority).AnyTimes()
 			mockFC.EXPECT().SendWindowSize().DoAndReturn(func() protocol.ByteCount {
 				return protocol.ByteCount(mrand.Intn(500)) + 50
 			}).AnyTimes()
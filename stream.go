@@ -24,7 +24,7 @@ var errDeadline net.Error = &deadlineError{}
 // The streamSender is notified by the stream about various events.
 type streamSender interface {
 	queueControlFrame(wire.Frame)
-	onHasStreamData(protocol.StreamID)
+	onHasStreamData(protocol.StreamID, StreamPriority)
 	// must be called without holding the mutex that is acquired by closeForShutdown
 	onStreamCompleted(protocol.StreamID)
 }
@@ -40,8 +40,8 @@ func (s *uniStreamSender) queueControlFrame(f wire.Frame) {
 	s.streamSender.queueControlFrame(f)
 }
 
-func (s *uniStreamSender) onHasStreamData(id protocol.StreamID) {
-	s.streamSender.onHasStreamData(id)
+func (s *uniStreamSender) onHasStreamData(id protocol.StreamID, priority StreamPriority) {
+	s.streamSender.onHasStreamData(id, priority)
 }
 
 func (s *uniStreamSender) onStreamCompleted(protocol.StreamID) {
@@ -0,0 +1,121 @@
+package webtransport
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+)
+
+// A Dialer establishes WebTransport sessions.
+// Sessions to the same server are multiplexed on a single QUIC connection.
+type Dialer struct {
+	// TLSClientConfig specifies the TLS configuration to use.
+	// If nil, the default configuration is used.
+	TLSClientConfig *tls.Config
+
+	// QuicConfig is the quic.Config used for dialing new connections.
+	// Datagram support is always enabled.
+	// If nil, reasonable default values will be used.
+	QuicConfig *quic.Config
+
+	// StreamReorderingTimeout is the maximum time an incoming WebTransport stream that cannot be associated
+	// with a session is buffered.
+	// This can happen if the response to a CONNECT request (that creates a new session) is reordered,
+	// and arrives after the first WebTransport stream(s) for that session.
+	// Defaults to 5 seconds.
+	StreamReorderingTimeout time.Duration
+
+	roundTripper *http3.RoundTripper
+
+	initOnce sync.Once
+	conns    *sessionManager
+}
+
+func (d *Dialer) init() {
+	d.conns = newSessionManager(d.StreamReorderingTimeout)
+
+	var quicConf *quic.Config
+	if d.QuicConfig == nil {
+		quicConf = &quic.Config{}
+	} else {
+		quicConf = d.QuicConfig.Clone()
+	}
+	// The server opens WebTransport streams as well.
+	if quicConf.MaxIncomingStreams == 0 {
+		quicConf.MaxIncomingStreams = 100
+	}
+	d.roundTripper = &http3.RoundTripper{
+		TLSClientConfig:    d.TLSClientConfig,
+		QuicConfig:         quicConf,
+		EnableDatagrams:    true,
+		AdditionalSettings: map[uint64]uint64{settingsEnableWebtransport: 1},
+		StreamHijacker:     d.conns.hijackStream,
+		UniStreamHijacker:  d.conns.hijackUniStream,
+	}
+}
+
+// Dial establishes a new WebTransport session to the server at urlStr.
+// The response to the Extended CONNECT request is returned alongside the session.
+// It is also returned if the server refused to establish the session.
+func (d *Dialer) Dial(ctx context.Context, urlStr string, reqHdr http.Header) (*http.Response, *Session, error) {
+	d.initOnce.Do(d.init)
+
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if reqHdr == nil {
+		reqHdr = http.Header{}
+	}
+	reqHdr.Add(webTransportDraftOfferHeaderKey, "1")
+	req := &http.Request{
+		Method: http.MethodConnect,
+		Header: reqHdr,
+		Proto:  protocolHeader,
+		Host:   u.Host,
+		URL:    u,
+	}
+	req = req.WithContext(ctx)
+
+	rsp, err := d.roundTripper.RoundTripOpt(req, http3.RoundTripOpt{DontCloseRequestStream: true})
+	if err != nil {
+		return nil, nil, err
+	}
+	if rsp.StatusCode < 200 || rsp.StatusCode >= 300 {
+		return rsp, nil, fmt.Errorf("received status %d", rsp.StatusCode)
+	}
+	str, ok := rsp.Body.(http3.HTTPStreamer)
+	if !ok {
+		return nil, nil, errors.New("failed to take over HTTP stream")
+	}
+	hijacker, ok := rsp.Body.(http3.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("failed to hijack")
+	}
+	qconn, ok := hijacker.StreamCreator().(quic.Connection)
+	if !ok {
+		return nil, nil, errors.New("failed to get the QUIC connection")
+	}
+	datagrams, ok := rsp.Body.(http3.HTTPDatagrammer)
+	if !ok {
+		return nil, nil, errors.New("HTTP datagrams not supported")
+	}
+	requestStr := str.HTTPStream()
+	return rsp, d.conns.AddSession(qconn, sessionID(requestStr.StreamID()), requestStr, datagrams), nil
+}
+
+// Close closes all QUIC connections.
+func (d *Dialer) Close() error {
+	d.initOnce.Do(d.init)
+	err := d.roundTripper.Close()
+	d.conns.Close()
+	return err
+}
@@ -0,0 +1,107 @@
+package webtransport
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/quic-go/quic-go"
+)
+
+// StreamErrorCode is an error code used for stream termination.
+type StreamErrorCode uint32
+
+// SessionErrorCode is an error code used for session termination.
+type SessionErrorCode uint32
+
+const (
+	// WEBTRANSPORT_BUFFERED_STREAM_REJECTED
+	// Used to reject streams for which the session couldn't be found.
+	bufferedStreamRejectedErrorCode quic.StreamErrorCode = 0x3994bd84
+	// WEBTRANSPORT_SESSION_GONE
+	// Used to reset streams when the session is closed.
+	sessionGoneErrorCode quic.StreamErrorCode = 0x170d7b68
+)
+
+// The WebTransport application error codes are mapped onto a range of the HTTP/3 error code space,
+// skipping the reserved codepoints of the form 0x1f * N + 0x21.
+const firstErrorCode = 0x52e4a40fa8db
+
+var lastErrorCode = webtransportCodeToHTTPCode(math.MaxUint32)
+
+func webtransportCodeToHTTPCode(n StreamErrorCode) quic.StreamErrorCode {
+	return quic.StreamErrorCode(firstErrorCode) + quic.StreamErrorCode(n) + quic.StreamErrorCode(n/0x1e)
+}
+
+func httpCodeToWebtransportCode(h quic.StreamErrorCode) (StreamErrorCode, error) {
+	if h < firstErrorCode || h > lastErrorCode {
+		return 0, errors.New("error code outside of expected range")
+	}
+	if (h-0x21)%0x1f == 0 {
+		return 0, errors.New("invalid error code")
+	}
+	shifted := h - firstErrorCode
+	return StreamErrorCode(shifted - shifted/0x1f), nil
+}
+
+// isWebTransportError says if a stream was reset using a WebTransport error code.
+func isWebTransportError(e error) bool {
+	var strErr *quic.StreamError
+	if !errors.As(e, &strErr) {
+		return false
+	}
+	if strErr.ErrorCode == sessionGoneErrorCode {
+		return true
+	}
+	_, err := httpCodeToWebtransportCode(strErr.ErrorCode)
+	return err == nil
+}
+
+// StreamError is returned by Read and Write when the stream was canceled.
+type StreamError struct {
+	ErrorCode StreamErrorCode
+	Remote    bool
+}
+
+var _ error = &StreamError{}
+
+func (e *StreamError) Error() string {
+	return fmt.Sprintf("stream canceled with error code %d", e.ErrorCode)
+}
+
+// SessionError is returned when the session was closed.
+type SessionError struct {
+	Remote    bool
+	ErrorCode SessionErrorCode
+	Message   string
+}
+
+var _ error = &SessionError{}
+
+func (e *SessionError) Error() string {
+	if e.Remote {
+		return fmt.Sprintf("peer closed the session (error code %d): %s", e.ErrorCode, e.Message)
+	}
+	return fmt.Sprintf("session closed (error code %d): %s", e.ErrorCode, e.Message)
+}
+
+func maybeConvertStreamError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var streamErr *quic.StreamError
+	if errors.As(err, &streamErr) {
+		if streamErr.ErrorCode == sessionGoneErrorCode {
+			return &SessionError{Remote: streamErr.Remote, Message: "session gone"}
+		}
+		errorCode, cerr := httpCodeToWebtransportCode(streamErr.ErrorCode)
+		if cerr != nil {
+			return fmt.Errorf("stream reset, but failed to convert stream error %d: %w", streamErr.ErrorCode, cerr)
+		}
+		return &StreamError{
+			ErrorCode: errorCode,
+			Remote:    streamErr.Remote,
+		}
+	}
+	return err
+}
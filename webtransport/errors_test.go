@@ -0,0 +1,75 @@
+package webtransport
+
+import (
+	"errors"
+	"math"
+
+	"github.com/quic-go/quic-go"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Errors", func() {
+	It("maps error codes to HTTP/3 error codes", func() {
+		Expect(webtransportCodeToHTTPCode(0)).To(BeEquivalentTo(firstErrorCode))
+		Expect(webtransportCodeToHTTPCode(0x1d)).To(BeEquivalentTo(0x52e4a40fa8db + 0x1d))
+		// skips the reserved codepoint 0x52e4a40fa8f9
+		Expect(webtransportCodeToHTTPCode(0x1e)).To(BeEquivalentTo(0x52e4a40fa8fa))
+		Expect(lastErrorCode).To(BeEquivalentTo(0x52e5ac983162))
+	})
+
+	It("converts error codes back and forth", func() {
+		for _, c := range []StreamErrorCode{0, 1, 0x1d, 0x1e, 0x1f, 1337, math.MaxUint32 - 1, math.MaxUint32} {
+			code, err := httpCodeToWebtransportCode(webtransportCodeToHTTPCode(c))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(code).To(Equal(c))
+		}
+	})
+
+	It("rejects error codes outside of the WebTransport range", func() {
+		_, err := httpCodeToWebtransportCode(firstErrorCode - 1)
+		Expect(err).To(MatchError("error code outside of expected range"))
+		_, err = httpCodeToWebtransportCode(lastErrorCode + 1)
+		Expect(err).To(MatchError("error code outside of expected range"))
+	})
+
+	It("rejects reserved error codes", func() {
+		_, err := httpCodeToWebtransportCode(0x52e4a40fa8f9)
+		Expect(err).To(MatchError("invalid error code"))
+	})
+
+	It("recognizes WebTransport stream errors", func() {
+		Expect(isWebTransportError(&quic.StreamError{ErrorCode: webtransportCodeToHTTPCode(42)})).To(BeTrue())
+		Expect(isWebTransportError(&quic.StreamError{ErrorCode: sessionGoneErrorCode})).To(BeTrue())
+		Expect(isWebTransportError(&quic.StreamError{ErrorCode: 0x100})).To(BeFalse())
+		Expect(isWebTransportError(errors.New("foobar"))).To(BeFalse())
+		Expect(isWebTransportError(nil)).To(BeFalse())
+	})
+
+	Context("converting stream errors", func() {
+		It("converts WebTransport error codes", func() {
+			err := maybeConvertStreamError(&quic.StreamError{ErrorCode: webtransportCodeToHTTPCode(42), Remote: true})
+			Expect(err).To(Equal(&StreamError{ErrorCode: 42, Remote: true}))
+		})
+
+		It("converts the session gone error code", func() {
+			err := maybeConvertStreamError(&quic.StreamError{ErrorCode: sessionGoneErrorCode, Remote: true})
+			var sessErr *SessionError
+			Expect(errors.As(err, &sessErr)).To(BeTrue())
+			Expect(sessErr.Remote).To(BeTrue())
+		})
+
+		It("doesn't convert other errors", func() {
+			testErr := errors.New("test error")
+			Expect(maybeConvertStreamError(testErr)).To(Equal(testErr))
+			Expect(maybeConvertStreamError(nil)).To(BeNil())
+		})
+
+		It("errors for stream errors outside of the WebTransport range", func() {
+			err := maybeConvertStreamError(&quic.StreamError{ErrorCode: 0x100})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("failed to convert stream error 256"))
+		})
+	})
+})
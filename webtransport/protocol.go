@@ -0,0 +1,31 @@
+package webtransport
+
+import (
+	"github.com/quic-go/quic-go/http3"
+)
+
+// The values used by draft-ietf-webtrans-http3-02.
+const (
+	// SETTINGS_ENABLE_WEBTRANSPORT
+	settingsEnableWebtransport = 0x2b603742
+	// the frame type used to associate a bidirectional stream with a session
+	webTransportFrameType = http3.FrameType(0x41)
+	// the stream type used to associate a unidirectional stream with a session
+	webTransportUniStreamType = http3.StreamType(0x54)
+	// CLOSE_WEBTRANSPORT_SESSION
+	closeWebtransportSessionCapsuleType = http3.CapsuleType(0x2843)
+	// the maximum length of the message in a CLOSE_WEBTRANSPORT_SESSION capsule
+	maxCloseMessageLen = 1024
+)
+
+const (
+	// the value of the :protocol pseudo header field
+	protocolHeader = "webtransport"
+
+	webTransportDraftOfferHeaderKey = "Sec-Webtransport-Http3-Draft02"
+	webTransportDraftHeaderKey      = "Sec-Webtransport-Http3-Draft"
+	webTransportDraftHeaderValue    = "draft02"
+)
+
+// sessionID is the stream ID of the Extended CONNECT request that established the session.
+type sessionID uint64
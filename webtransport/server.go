@@ -0,0 +1,168 @@
+package webtransport
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+)
+
+// A Server is a WebTransport server.
+// It wraps a HTTP/3 server, and upgrades Extended CONNECT requests to WebTransport sessions.
+type Server struct {
+	H3 http3.Server
+
+	// ReorderingTimeout is the maximum time an incoming WebTransport stream that cannot be associated
+	// with a session is buffered.
+	// This can happen if the CONNECT request (that creates a new session) is reordered, and arrives
+	// after the first WebTransport stream(s) for that session.
+	// Defaults to 5 seconds.
+	ReorderingTimeout time.Duration
+
+	// CheckOrigin is used to validate the request origin, thereby preventing cross-site request forgery.
+	// CheckOrigin returns true if the request Origin header is acceptable.
+	// If unset, a safe default is used: If the Origin header is set, it is checked that it
+	// matches the request's Host header.
+	CheckOrigin func(r *http.Request) bool
+
+	initOnce sync.Once
+	initErr  error
+
+	conns *sessionManager
+}
+
+func (s *Server) initialize() error {
+	s.initOnce.Do(func() {
+		s.initErr = s.init()
+	})
+	return s.initErr
+}
+
+func (s *Server) init() error {
+	if s.H3.StreamHijacker != nil {
+		return errors.New("StreamHijacker already set")
+	}
+	if s.H3.UniStreamHijacker != nil {
+		return errors.New("UniStreamHijacker already set")
+	}
+	s.conns = newSessionManager(s.ReorderingTimeout)
+	if s.H3.AdditionalSettings == nil {
+		s.H3.AdditionalSettings = make(map[uint64]uint64, 1)
+	}
+	s.H3.AdditionalSettings[settingsEnableWebtransport] = 1
+	s.H3.EnableDatagrams = true
+	s.H3.StreamHijacker = s.conns.hijackStream
+	s.H3.UniStreamHijacker = s.conns.hijackUniStream
+	return nil
+}
+
+// Serve serves WebTransport sessions on an existing UDP connection.
+func (s *Server) Serve(conn net.PacketConn) error {
+	if err := s.initialize(); err != nil {
+		return err
+	}
+	return s.H3.Serve(conn)
+}
+
+// ServeQUICConn serves a single QUIC connection.
+func (s *Server) ServeQUICConn(conn quic.Connection) error {
+	if err := s.initialize(); err != nil {
+		return err
+	}
+	return s.H3.ServeQUICConn(conn)
+}
+
+// ListenAndServe listens on the UDP address s.H3.Addr and serves WebTransport sessions.
+func (s *Server) ListenAndServe() error {
+	if err := s.initialize(); err != nil {
+		return err
+	}
+	return s.H3.ListenAndServe()
+}
+
+// ListenAndServeTLS listens on the UDP address s.H3.Addr and serves WebTransport sessions.
+func (s *Server) ListenAndServeTLS(certFile, keyFile string) error {
+	if err := s.initialize(); err != nil {
+		return err
+	}
+	return s.H3.ListenAndServeTLS(certFile, keyFile)
+}
+
+// Close closes the HTTP/3 server.
+// Streams that are buffered waiting for their session are rejected.
+func (s *Server) Close() error {
+	// Make sure that the sessionManager exists, even if the server was never started.
+	s.initialize()
+	err := s.H3.Close()
+	if s.conns != nil {
+		s.conns.Close()
+	}
+	return err
+}
+
+// Upgrade upgrades an Extended CONNECT request to a WebTransport session.
+// On success, the 200 response is sent, and the handler must not use w afterwards.
+func (s *Server) Upgrade(w http.ResponseWriter, r *http.Request) (*Session, error) {
+	if r.Method != http.MethodConnect {
+		return nil, fmt.Errorf("expected CONNECT request, got %s", r.Method)
+	}
+	if r.Proto != protocolHeader {
+		return nil, fmt.Errorf("unexpected protocol: %s", r.Proto)
+	}
+	if v, ok := r.Header[webTransportDraftOfferHeaderKey]; !ok || len(v) != 1 || v[0] != "1" {
+		return nil, fmt.Errorf("missing or invalid %s header", webTransportDraftOfferHeaderKey)
+	}
+	checkOrigin := s.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = checkSameOrigin
+	}
+	if !checkOrigin(r) {
+		return nil, errors.New("webtransport: request origin not allowed")
+	}
+	if err := s.initialize(); err != nil {
+		return nil, err
+	}
+
+	httpStreamer, ok := r.Body.(http3.HTTPStreamer)
+	if !ok {
+		return nil, errors.New("failed to take over HTTP stream")
+	}
+	hijacker, ok := w.(http3.Hijacker)
+	if !ok {
+		return nil, errors.New("failed to hijack")
+	}
+	qconn, ok := hijacker.StreamCreator().(quic.Connection)
+	if !ok {
+		return nil, errors.New("failed to get the QUIC connection")
+	}
+	datagrams, ok := w.(http3.HTTPDatagrammer)
+	if !ok {
+		return nil, errors.New("HTTP datagrams not supported")
+	}
+
+	w.Header().Add(webTransportDraftHeaderKey, webTransportDraftHeaderValue)
+	w.WriteHeader(http.StatusOK)
+	w.(http.Flusher).Flush()
+
+	str := httpStreamer.HTTPStream()
+	return s.conns.AddSession(qconn, sessionID(str.StreamID()), str, datagrams), nil
+}
+
+// copied from https://github.com/gorilla/websocket
+func checkSameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return u.Host == r.Host
+}
@@ -0,0 +1,473 @@
+package webtransport
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/quic-go/quic-go/quicvarint"
+)
+
+type acceptQueue[T any] struct {
+	mutex sync.Mutex
+	// Channel used to notify the consumer when a new item was added to the queue.
+	c     chan struct{}
+	queue []T
+}
+
+func newAcceptQueue[T any]() *acceptQueue[T] {
+	return &acceptQueue[T]{c: make(chan struct{}, 1)}
+}
+
+func (q *acceptQueue[T]) Add(str T) {
+	q.mutex.Lock()
+	q.queue = append(q.queue, str)
+	q.mutex.Unlock()
+
+	select {
+	case q.c <- struct{}{}:
+	default:
+	}
+}
+
+func (q *acceptQueue[T]) Next() (T, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if len(q.queue) == 0 {
+		var zero T
+		return zero, false
+	}
+	str := q.queue[0]
+	q.queue = q.queue[1:]
+	return str, true
+}
+
+func (q *acceptQueue[T]) Chan() <-chan struct{} { return q.c }
+
+// The streamsMap holds all streams of a session, so they can be reset when the session is closed.
+type streamsMap struct {
+	mutex sync.Mutex
+	m     map[quic.StreamID]func()
+}
+
+func newStreamsMap() *streamsMap {
+	return &streamsMap{m: make(map[quic.StreamID]func())}
+}
+
+func (s *streamsMap) AddStream(id quic.StreamID, closeWithSession func()) {
+	s.mutex.Lock()
+	s.m[id] = closeWithSession
+	s.mutex.Unlock()
+}
+
+func (s *streamsMap) RemoveStream(id quic.StreamID) {
+	s.mutex.Lock()
+	delete(s.m, id)
+	s.mutex.Unlock()
+}
+
+func (s *streamsMap) CloseSession() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, closeWithSession := range s.m {
+		closeWithSession()
+	}
+	s.m = nil
+}
+
+// A Session is a WebTransport session.
+// Multiple sessions can share the same QUIC connection.
+type Session struct {
+	sessionID  sessionID
+	qconn      quic.Connection
+	requestStr http3.Stream
+
+	streamHdr    []byte
+	uniStreamHdr []byte
+
+	ctx       context.Context
+	ctxCancel context.CancelFunc
+
+	closeMx  sync.Mutex
+	closeErr error // not nil once the session is closed
+	// cancel functions of the Open{Uni}StreamSync calls that are currently blocked
+	streamCtxs    map[int]context.CancelFunc
+	nextStreamCtx int
+
+	bidiAcceptQueue *acceptQueue[Stream]
+	uniAcceptQueue  *acceptQueue[ReceiveStream]
+
+	streams   *streamsMap
+	datagrams http3.HTTPDatagrammer
+}
+
+func newSession(id sessionID, qconn quic.Connection, requestStr http3.Stream, datagrams http3.HTTPDatagrammer) *Session {
+	ctx, ctxCancel := context.WithCancel(context.Background())
+	s := &Session{
+		sessionID:       id,
+		qconn:           qconn,
+		requestStr:      requestStr,
+		ctx:             ctx,
+		ctxCancel:       ctxCancel,
+		streamCtxs:      make(map[int]context.CancelFunc),
+		bidiAcceptQueue: newAcceptQueue[Stream](),
+		uniAcceptQueue:  newAcceptQueue[ReceiveStream](),
+		streams:         newStreamsMap(),
+		datagrams:       datagrams,
+	}
+	// precompute the headers for unidirectional streams
+	s.uniStreamHdr = make([]byte, 0, 2+quicvarint.Len(uint64(id)))
+	s.uniStreamHdr = quicvarint.Append(s.uniStreamHdr, uint64(webTransportUniStreamType))
+	s.uniStreamHdr = quicvarint.Append(s.uniStreamHdr, uint64(id))
+	// and bidirectional streams
+	s.streamHdr = make([]byte, 0, 2+quicvarint.Len(uint64(id)))
+	s.streamHdr = quicvarint.Append(s.streamHdr, uint64(webTransportFrameType))
+	s.streamHdr = quicvarint.Append(s.streamHdr, uint64(id))
+
+	go func() {
+		defer ctxCancel()
+		s.handleConn()
+	}()
+	return s
+}
+
+func (s *Session) handleConn() {
+	var closeErr *SessionError
+	if err := s.parseNextCapsule(); !errors.As(err, &closeErr) {
+		closeErr = &SessionError{Remote: true}
+	}
+
+	s.closeMx.Lock()
+	defer s.closeMx.Unlock()
+	// If we closed the session, the closeErr was set in CloseWithError.
+	if s.closeErr == nil {
+		s.closeErr = closeErr
+	}
+	for _, cancel := range s.streamCtxs {
+		cancel()
+	}
+	s.streams.CloseSession()
+}
+
+// parseNextCapsule parses the capsules sent on the request stream.
+// It returns a SessionError when a CLOSE_WEBTRANSPORT_SESSION capsule is received.
+func (s *Session) parseNextCapsule() error {
+	for {
+		typ, r, err := http3.ParseCapsule(quicvarint.NewReader(s.requestStr))
+		if err != nil {
+			return err
+		}
+		switch typ {
+		case closeWebtransportSessionCapsuleType:
+			b := make([]byte, 4)
+			if _, err := io.ReadFull(r, b); err != nil {
+				return err
+			}
+			msg, err := io.ReadAll(io.LimitReader(r, maxCloseMessageLen+1))
+			if err != nil {
+				return err
+			}
+			if len(msg) > maxCloseMessageLen {
+				return errors.New("CLOSE_WEBTRANSPORT_SESSION message too long")
+			}
+			return &SessionError{
+				Remote:    true,
+				ErrorCode: SessionErrorCode(binary.BigEndian.Uint32(b)),
+				Message:   string(msg),
+			}
+		default:
+			// unknown capsule, skip it
+			if _, err := io.Copy(io.Discard, r); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *Session) closeError() error {
+	s.closeMx.Lock()
+	defer s.closeMx.Unlock()
+	return s.closeErr
+}
+
+func (s *Session) addStream(qstr quic.Stream, addStreamHeader bool) Stream {
+	var hdr []byte
+	if addStreamHeader {
+		hdr = s.streamHdr
+	}
+	str := newStream(qstr, hdr, func() { s.streams.RemoveStream(qstr.StreamID()) })
+	s.streams.AddStream(qstr.StreamID(), str.closeWithSession)
+	return str
+}
+
+func (s *Session) addReceiveStream(qstr quic.ReceiveStream) ReceiveStream {
+	str := newReceiveStream(qstr, func() { s.streams.RemoveStream(qstr.StreamID()) })
+	s.streams.AddStream(qstr.StreamID(), str.closeWithSession)
+	return str
+}
+
+func (s *Session) addSendStream(qstr quic.SendStream) SendStream {
+	str := newSendStream(qstr, s.uniStreamHdr, func() { s.streams.RemoveStream(qstr.StreamID()) })
+	s.streams.AddStream(qstr.StreamID(), str.closeWithSession)
+	return str
+}
+
+// addIncomingStream adds a bidirectional stream that the peer opened for this session.
+func (s *Session) addIncomingStream(qstr quic.Stream) {
+	s.closeMx.Lock()
+	if s.closeErr != nil {
+		s.closeMx.Unlock()
+		qstr.CancelRead(sessionGoneErrorCode)
+		qstr.CancelWrite(sessionGoneErrorCode)
+		return
+	}
+	str := s.addStream(qstr, false)
+	s.closeMx.Unlock()
+
+	s.bidiAcceptQueue.Add(str)
+}
+
+// addIncomingUniStream adds a unidirectional stream that the peer opened for this session.
+func (s *Session) addIncomingUniStream(qstr quic.ReceiveStream) {
+	s.closeMx.Lock()
+	if s.closeErr != nil {
+		s.closeMx.Unlock()
+		qstr.CancelRead(sessionGoneErrorCode)
+		return
+	}
+	str := s.addReceiveStream(qstr)
+	s.closeMx.Unlock()
+
+	s.uniAcceptQueue.Add(str)
+}
+
+// Context returns a context that is canceled when the session is closed.
+func (s *Session) Context() context.Context {
+	return s.ctx
+}
+
+// AcceptStream accepts a bidirectional stream opened by the peer.
+func (s *Session) AcceptStream(ctx context.Context) (Stream, error) {
+	for {
+		if err := s.closeError(); err != nil {
+			return nil, err
+		}
+		// If there's a stream in the accept queue, return it immediately.
+		if str, ok := s.bidiAcceptQueue.Next(); ok {
+			return str, nil
+		}
+		// No stream in the accept queue. Wait until we accept one.
+		select {
+		case <-s.ctx.Done():
+			return nil, s.closeError()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-s.bidiAcceptQueue.Chan():
+		}
+	}
+}
+
+// AcceptUniStream accepts a unidirectional stream opened by the peer.
+func (s *Session) AcceptUniStream(ctx context.Context) (ReceiveStream, error) {
+	for {
+		if err := s.closeError(); err != nil {
+			return nil, err
+		}
+		// If there's a stream in the accept queue, return it immediately.
+		if str, ok := s.uniAcceptQueue.Next(); ok {
+			return str, nil
+		}
+		// No stream in the accept queue. Wait until we accept one.
+		select {
+		case <-s.ctx.Done():
+			return nil, s.closeError()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-s.uniAcceptQueue.Chan():
+		}
+	}
+}
+
+// OpenStream opens a new bidirectional stream.
+// The WebTransport stream header is sent with the first call to Write (or Close),
+// so the peer doesn't learn about the stream before that.
+func (s *Session) OpenStream() (Stream, error) {
+	s.closeMx.Lock()
+	defer s.closeMx.Unlock()
+
+	if s.closeErr != nil {
+		return nil, s.closeErr
+	}
+	qstr, err := s.qconn.OpenStream()
+	if err != nil {
+		return nil, err
+	}
+	return s.addStream(qstr, true), nil
+}
+
+// OpenStreamSync opens a new bidirectional stream.
+// It blocks until the stream can be opened, or the session is closed.
+func (s *Session) OpenStreamSync(ctx context.Context) (Stream, error) {
+	ctx, id, err := s.registerStreamCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	qstr, err := s.qconn.OpenStreamSync(ctx)
+
+	s.closeMx.Lock()
+	defer s.closeMx.Unlock()
+	delete(s.streamCtxs, id)
+	if s.closeErr != nil {
+		if err == nil {
+			qstr.CancelRead(sessionGoneErrorCode)
+			qstr.CancelWrite(sessionGoneErrorCode)
+		}
+		return nil, s.closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.addStream(qstr, true), nil
+}
+
+// OpenUniStream opens a new unidirectional stream.
+// The WebTransport stream header is sent with the first call to Write (or Close).
+func (s *Session) OpenUniStream() (SendStream, error) {
+	s.closeMx.Lock()
+	defer s.closeMx.Unlock()
+
+	if s.closeErr != nil {
+		return nil, s.closeErr
+	}
+	qstr, err := s.qconn.OpenUniStream()
+	if err != nil {
+		return nil, err
+	}
+	return s.addSendStream(qstr), nil
+}
+
+// OpenUniStreamSync opens a new unidirectional stream.
+// It blocks until the stream can be opened, or the session is closed.
+func (s *Session) OpenUniStreamSync(ctx context.Context) (SendStream, error) {
+	ctx, id, err := s.registerStreamCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	qstr, err := s.qconn.OpenUniStreamSync(ctx)
+
+	s.closeMx.Lock()
+	defer s.closeMx.Unlock()
+	delete(s.streamCtxs, id)
+	if s.closeErr != nil {
+		if err == nil {
+			qstr.CancelWrite(sessionGoneErrorCode)
+		}
+		return nil, s.closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.addSendStream(qstr), nil
+}
+
+// registerStreamCtx derives a context that is canceled when the session is closed.
+func (s *Session) registerStreamCtx(ctx context.Context) (context.Context, int, error) {
+	s.closeMx.Lock()
+	defer s.closeMx.Unlock()
+
+	if s.closeErr != nil {
+		return nil, 0, s.closeErr
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	id := s.nextStreamCtx
+	s.nextStreamCtx++
+	s.streamCtxs[id] = cancel
+	return ctx, id, nil
+}
+
+// SendDatagram sends a datagram associated with this session, see section 4.4 of draft-ietf-webtrans-http3-02.
+// Datagrams are sent as HTTP Datagrams on the CONNECT stream.
+func (s *Session) SendDatagram(b []byte) error {
+	if err := s.closeError(); err != nil {
+		return err
+	}
+	return s.datagrams.SendDatagram(b)
+}
+
+// ReceiveDatagram receives a datagram associated with this session.
+func (s *Session) ReceiveDatagram(ctx context.Context) ([]byte, error) {
+	if err := s.closeError(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-s.ctx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	b, err := s.datagrams.ReceiveDatagram(ctx)
+	if err != nil && s.ctx.Err() != nil {
+		return nil, s.closeError()
+	}
+	return b, err
+}
+
+// LocalAddr returns the local address of the QUIC connection.
+func (s *Session) LocalAddr() net.Addr {
+	return s.qconn.LocalAddr()
+}
+
+// RemoteAddr returns the remote address of the QUIC connection.
+func (s *Session) RemoteAddr() net.Addr {
+	return s.qconn.RemoteAddr()
+}
+
+// ConnectionState returns the state of the QUIC connection.
+func (s *Session) ConnectionState() quic.ConnectionState {
+	return s.qconn.ConnectionState()
+}
+
+// CloseWithError closes the session by sending a CLOSE_WEBTRANSPORT_SESSION capsule on the CONNECT stream.
+// Messages longer than 1024 bytes are truncated.
+// All streams of the session are reset, the QUIC connection is not closed.
+func (s *Session) CloseWithError(code SessionErrorCode, msg string) error {
+	first, err := s.closeWithError(code, msg)
+	if !first {
+		return nil
+	}
+	// This makes handleConn return, which resets all streams.
+	s.requestStr.CancelRead(quic.StreamErrorCode(http3.ErrCodeNoError))
+	<-s.ctx.Done()
+	return err
+}
+
+func (s *Session) closeWithError(code SessionErrorCode, msg string) (bool /* first call to close session */, error) {
+	s.closeMx.Lock()
+	defer s.closeMx.Unlock()
+	// Duplicate call, or the remote already closed this session.
+	if s.closeErr != nil {
+		return false, nil
+	}
+	if len(msg) > maxCloseMessageLen {
+		msg = msg[:maxCloseMessageLen]
+	}
+	s.closeErr = &SessionError{ErrorCode: code, Message: msg}
+
+	b := make([]byte, 4, 4+len(msg))
+	binary.BigEndian.PutUint32(b, uint32(code))
+	b = append(b, msg...)
+	if err := http3.WriteCapsule(quicvarint.NewWriter(s.requestStr), closeWebtransportSessionCapsuleType, b); err != nil {
+		return true, err
+	}
+	return true, s.requestStr.Close()
+}
@@ -0,0 +1,206 @@
+package webtransport
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/quic-go/quic-go/quicvarint"
+)
+
+// the default time that incoming streams are buffered if their session is not known (yet)
+const defaultReorderingTimeout = 5 * time.Second
+
+type sessionEntry struct {
+	// closed once the session was created
+	created chan struct{}
+	session *Session
+}
+
+// The sessionManager associates streams with the sessions on a QUIC connection.
+// Since the CONNECT request that establishes a session can be reordered with the first streams
+// of that session, streams for unknown sessions are buffered until the reordering timeout expires.
+type sessionManager struct {
+	refCount  sync.WaitGroup
+	ctx       context.Context
+	ctxCancel context.CancelFunc
+
+	timeout time.Duration
+
+	mutex sync.Mutex
+	conns map[quic.Connection]map[sessionID]*sessionEntry
+}
+
+func newSessionManager(timeout time.Duration) *sessionManager {
+	if timeout == 0 {
+		timeout = defaultReorderingTimeout
+	}
+	m := &sessionManager{
+		timeout: timeout,
+		conns:   make(map[quic.Connection]map[sessionID]*sessionEntry),
+	}
+	m.ctx, m.ctxCancel = context.WithCancel(context.Background())
+	return m
+}
+
+// getOrCreateSession returns the entry of a session, creating it if it doesn't exist yet.
+// It must be called with the mutex held.
+func (m *sessionManager) getOrCreateSession(qconn quic.Connection, id sessionID) *sessionEntry {
+	sessions, ok := m.conns[qconn]
+	if !ok {
+		sessions = make(map[sessionID]*sessionEntry)
+		m.conns[qconn] = sessions
+		m.refCount.Add(1)
+		go func() {
+			defer m.refCount.Done()
+			select {
+			case <-qconn.Context().Done():
+			case <-m.ctx.Done():
+			}
+			m.mutex.Lock()
+			delete(m.conns, qconn)
+			m.mutex.Unlock()
+		}()
+	}
+	entry, ok := sessions[id]
+	if !ok {
+		entry = &sessionEntry{created: make(chan struct{})}
+		sessions[id] = entry
+	}
+	return entry
+}
+
+// AddSession creates a new session for the Extended CONNECT request sent on requestStr.
+func (m *sessionManager) AddSession(qconn quic.Connection, id sessionID, requestStr http3.Stream, datagrams http3.HTTPDatagrammer) *Session {
+	sess := newSession(id, qconn, requestStr, datagrams)
+
+	m.mutex.Lock()
+	entry := m.getOrCreateSession(qconn, id)
+	entry.session = sess
+	close(entry.created)
+	m.mutex.Unlock()
+
+	m.refCount.Add(1)
+	go func() {
+		defer m.refCount.Done()
+		select {
+		case <-sess.Context().Done():
+		case <-m.ctx.Done():
+		}
+		m.mutex.Lock()
+		delete(m.conns[qconn], id)
+		m.mutex.Unlock()
+	}()
+	return sess
+}
+
+// AddStream associates a bidirectional stream opened by the peer with its session.
+func (m *sessionManager) AddStream(qconn quic.Connection, str quic.Stream, id sessionID) {
+	m.enqueue(
+		qconn,
+		id,
+		func(sess *Session) { sess.addIncomingStream(str) },
+		func() {
+			str.CancelRead(bufferedStreamRejectedErrorCode)
+			str.CancelWrite(bufferedStreamRejectedErrorCode)
+		},
+	)
+}
+
+// AddUniStream associates a unidirectional stream opened by the peer with its session.
+// The stream type was already read from the stream.
+func (m *sessionManager) AddUniStream(qconn quic.Connection, str quic.ReceiveStream) {
+	id, err := quicvarint.Read(quicvarint.NewReader(str))
+	if err != nil {
+		str.CancelRead(bufferedStreamRejectedErrorCode)
+		return
+	}
+	m.enqueue(
+		qconn,
+		sessionID(id),
+		func(sess *Session) { sess.addIncomingUniStream(str) },
+		func() { str.CancelRead(bufferedStreamRejectedErrorCode) },
+	)
+}
+
+func (m *sessionManager) enqueue(qconn quic.Connection, id sessionID, add func(*Session), reject func()) {
+	m.mutex.Lock()
+	entry := m.getOrCreateSession(qconn, id)
+	sess := entry.session
+	m.mutex.Unlock()
+
+	if sess != nil {
+		add(sess)
+		return
+	}
+
+	m.refCount.Add(1)
+	go func() {
+		defer m.refCount.Done()
+
+		timer := time.NewTimer(m.timeout)
+		defer timer.Stop()
+		select {
+		case <-entry.created:
+			add(entry.session)
+			return
+		case <-timer.C:
+		case <-m.ctx.Done():
+		}
+
+		m.mutex.Lock()
+		sess := entry.session
+		if sessions, ok := m.conns[qconn]; sess == nil && ok && sessions[id] == entry {
+			delete(sessions, id)
+		}
+		m.mutex.Unlock()
+		// the session might have been created in the meantime
+		if sess != nil {
+			add(sess)
+			return
+		}
+		reject()
+	}()
+}
+
+// hijackStream is used as the http3 StreamHijacker.
+// It takes over bidirectional streams that start with a WEBTRANSPORT_STREAM frame.
+func (m *sessionManager) hijackStream(ft http3.FrameType, qconn quic.Connection, str quic.Stream, err error) (bool /* hijacked */, error) {
+	if isWebTransportError(err) {
+		return true, nil
+	}
+	if ft != webTransportFrameType {
+		return false, nil
+	}
+	id, err := quicvarint.Read(quicvarint.NewReader(str))
+	if err != nil {
+		if isWebTransportError(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	m.AddStream(qconn, str, sessionID(id))
+	return true, nil
+}
+
+// hijackUniStream is used as the http3 UniStreamHijacker.
+// It takes over unidirectional streams of the WebTransport stream type.
+func (m *sessionManager) hijackUniStream(st http3.StreamType, qconn quic.Connection, str quic.ReceiveStream, err error) bool /* hijacked */ {
+	if isWebTransportError(err) {
+		return true
+	}
+	if st != webTransportUniStreamType {
+		return false
+	}
+	m.AddUniStream(qconn, str)
+	return true
+}
+
+// Close stops buffering streams, and waits for all Go routines to return.
+// It doesn't close the sessions.
+func (m *sessionManager) Close() {
+	m.ctxCancel()
+	m.refCount.Wait()
+}
@@ -0,0 +1,124 @@
+package webtransport
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	mockquic "github.com/quic-go/quic-go/internal/mocks/quic"
+	"github.com/quic-go/quic-go/quicvarint"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = Describe("Session Manager", func() {
+	var (
+		m          *sessionManager
+		qconn      *mockquic.MockEarlyConnection
+		requestStr *mockquic.MockStream
+		// closing this closes the request stream
+		requestStrWriter *io.PipeWriter
+	)
+
+	BeforeEach(func() {
+		m = newSessionManager(scaleDuration(50 * time.Millisecond))
+		qconn = mockquic.NewMockEarlyConnection(mockCtrl)
+		qconn.EXPECT().Context().Return(context.Background()).AnyTimes()
+		requestStr = mockquic.NewMockStream(mockCtrl)
+		var r *io.PipeReader
+		r, requestStrWriter = io.Pipe()
+		requestStr.EXPECT().Read(gomock.Any()).DoAndReturn(r.Read).AnyTimes()
+	})
+
+	AfterEach(func() {
+		requestStrWriter.Close()
+		m.Close()
+	})
+
+	It("associates streams with known sessions", func() {
+		sess := m.AddSession(qconn, 4, requestStr, nil)
+		str := mockquic.NewMockStream(mockCtrl)
+		str.EXPECT().StreamID().Return(quic.StreamID(8)).AnyTimes()
+		m.AddStream(qconn, str, 4)
+		s, err := sess.AcceptStream(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s.StreamID()).To(Equal(quic.StreamID(8)))
+		str.EXPECT().CancelRead(gomock.Any()).AnyTimes()
+		str.EXPECT().CancelWrite(gomock.Any()).AnyTimes()
+	})
+
+	It("buffers streams until the session is created", func() {
+		str := mockquic.NewMockStream(mockCtrl)
+		str.EXPECT().StreamID().Return(quic.StreamID(8)).AnyTimes()
+		m.AddStream(qconn, str, 4)
+		sess := m.AddSession(qconn, 4, requestStr, nil)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		s, err := sess.AcceptStream(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s.StreamID()).To(Equal(quic.StreamID(8)))
+		str.EXPECT().CancelRead(gomock.Any()).AnyTimes()
+		str.EXPECT().CancelWrite(gomock.Any()).AnyTimes()
+	})
+
+	It("rejects buffered streams after the timeout", func() {
+		str := mockquic.NewMockStream(mockCtrl)
+		done := make(chan struct{})
+		str.EXPECT().CancelRead(bufferedStreamRejectedErrorCode)
+		str.EXPECT().CancelWrite(bufferedStreamRejectedErrorCode).Do(func(quic.StreamErrorCode) { close(done) })
+		m.AddStream(qconn, str, 4)
+		Eventually(done).Should(BeClosed())
+	})
+
+	It("reads the session ID from unidirectional streams", func() {
+		str := mockquic.NewMockStream(mockCtrl)
+		str.EXPECT().StreamID().Return(quic.StreamID(10)).AnyTimes()
+		str.EXPECT().Read(gomock.Any()).DoAndReturn(bytes.NewReader(quicvarint.Append(nil, 4)).Read).AnyTimes()
+		m.AddUniStream(qconn, str)
+		sess := m.AddSession(qconn, 4, requestStr, nil)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		s, err := sess.AcceptUniStream(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s.StreamID()).To(Equal(quic.StreamID(10)))
+		str.EXPECT().CancelRead(gomock.Any()).AnyTimes()
+	})
+
+	Context("hijacking streams", func() {
+		It("hijacks WebTransport streams", func() {
+			str := mockquic.NewMockStream(mockCtrl)
+			str.EXPECT().StreamID().Return(quic.StreamID(8)).AnyTimes()
+			str.EXPECT().Read(gomock.Any()).DoAndReturn(bytes.NewReader(quicvarint.Append(nil, 4)).Read).AnyTimes()
+			hijacked, err := m.hijackStream(webTransportFrameType, qconn, str, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hijacked).To(BeTrue())
+			sess := m.AddSession(qconn, 4, requestStr, nil)
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			_, err = sess.AcceptStream(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			str.EXPECT().CancelRead(gomock.Any()).AnyTimes()
+			str.EXPECT().CancelWrite(gomock.Any()).AnyTimes()
+		})
+
+		It("doesn't hijack other streams", func() {
+			str := mockquic.NewMockStream(mockCtrl)
+			hijacked, err := m.hijackStream(0x1337, qconn, str, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hijacked).To(BeFalse())
+			Expect(m.hijackUniStream(0x1337, qconn, str, nil)).To(BeFalse())
+		})
+
+		It("hijacks streams that were reset with a WebTransport error code", func() {
+			str := mockquic.NewMockStream(mockCtrl)
+			hijacked, err := m.hijackStream(0, qconn, str, &quic.StreamError{ErrorCode: sessionGoneErrorCode})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hijacked).To(BeTrue())
+			Expect(m.hijackUniStream(0, qconn, str, &quic.StreamError{ErrorCode: webtransportCodeToHTTPCode(1)})).To(BeTrue())
+		})
+	})
+})
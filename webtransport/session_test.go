@@ -0,0 +1,203 @@
+package webtransport
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	mockquic "github.com/quic-go/quic-go/internal/mocks/quic"
+	"github.com/quic-go/quic-go/quicvarint"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+type mockDatagrammer struct {
+	sent     [][]byte
+	received chan []byte
+}
+
+func (d *mockDatagrammer) SendDatagram(b []byte) error {
+	d.sent = append(d.sent, b)
+	return nil
+}
+
+func (d *mockDatagrammer) ReceiveDatagram(ctx context.Context) ([]byte, error) {
+	select {
+	case b := <-d.received:
+		return b, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+var _ = Describe("Session", func() {
+	var (
+		qconn      *mockquic.MockEarlyConnection
+		requestStr *mockquic.MockStream
+		// data written to the pipe is read from the request stream
+		requestStrWriter *io.PipeWriter
+		datagrams        *mockDatagrammer
+		sess             *Session
+	)
+
+	const id = sessionID(4)
+
+	closeCapsule := func(code SessionErrorCode, msg string) []byte {
+		b := make([]byte, 4, 4+len(msg))
+		binary.BigEndian.PutUint32(b, uint32(code))
+		buf := &bytes.Buffer{}
+		Expect(http3.WriteCapsule(quicvarint.NewWriter(buf), closeWebtransportSessionCapsuleType, append(b, msg...))).To(Succeed())
+		return buf.Bytes()
+	}
+
+	BeforeEach(func() {
+		qconn = mockquic.NewMockEarlyConnection(mockCtrl)
+		requestStr = mockquic.NewMockStream(mockCtrl)
+		var r *io.PipeReader
+		r, requestStrWriter = io.Pipe()
+		requestStr.EXPECT().Read(gomock.Any()).DoAndReturn(r.Read).AnyTimes()
+		requestStr.EXPECT().CancelRead(gomock.Any()).Do(func(quic.StreamErrorCode) {
+			requestStrWriter.CloseWithError(errors.New("canceled"))
+		}).AnyTimes()
+		datagrams = &mockDatagrammer{received: make(chan []byte, 1)}
+		sess = newSession(id, qconn, requestStr, datagrams)
+	})
+
+	AfterEach(func() {
+		requestStrWriter.Close()
+		Eventually(sess.Context().Done()).Should(BeClosed())
+	})
+
+	It("is closed when the peer sends a CLOSE_WEBTRANSPORT_SESSION capsule", func() {
+		go requestStrWriter.Write(closeCapsule(1337, "foobar"))
+		Eventually(sess.Context().Done()).Should(BeClosed())
+		_, err := sess.AcceptStream(context.Background())
+		Expect(err).To(Equal(&SessionError{Remote: true, ErrorCode: 1337, Message: "foobar"}))
+		_, err = sess.OpenStream()
+		Expect(err).To(Equal(&SessionError{Remote: true, ErrorCode: 1337, Message: "foobar"}))
+	})
+
+	It("skips unknown capsules", func() {
+		buf := &bytes.Buffer{}
+		Expect(http3.WriteCapsule(quicvarint.NewWriter(buf), 0x1337, []byte("foobar"))).To(Succeed())
+		buf.Write(closeCapsule(42, ""))
+		go requestStrWriter.Write(buf.Bytes())
+		Eventually(sess.Context().Done()).Should(BeClosed())
+		_, err := sess.AcceptUniStream(context.Background())
+		Expect(err).To(Equal(&SessionError{Remote: true, ErrorCode: 42}))
+	})
+
+	It("is closed when the request stream is closed", func() {
+		requestStrWriter.Close()
+		Eventually(sess.Context().Done()).Should(BeClosed())
+		_, err := sess.AcceptStream(context.Background())
+		Expect(err).To(Equal(&SessionError{Remote: true}))
+	})
+
+	It("sends a CLOSE_WEBTRANSPORT_SESSION capsule when closed", func() {
+		buf := &bytes.Buffer{}
+		requestStr.EXPECT().Write(gomock.Any()).DoAndReturn(buf.Write).AnyTimes()
+		requestStr.EXPECT().Close()
+		Expect(sess.CloseWithError(1337, "foobar")).To(Succeed())
+		Expect(buf.Bytes()).To(Equal(closeCapsule(1337, "foobar")))
+		_, err := sess.OpenUniStream()
+		Expect(err).To(Equal(&SessionError{ErrorCode: 1337, Message: "foobar"}))
+		// closing again is a no-op
+		Expect(sess.CloseWithError(1, "")).To(Succeed())
+	})
+
+	It("truncates long close messages", func() {
+		buf := &bytes.Buffer{}
+		requestStr.EXPECT().Write(gomock.Any()).DoAndReturn(buf.Write).AnyTimes()
+		requestStr.EXPECT().Close()
+		Expect(sess.CloseWithError(0, string(bytes.Repeat([]byte{'a'}, maxCloseMessageLen+10)))).To(Succeed())
+		Expect(buf.Bytes()).To(Equal(closeCapsule(0, string(bytes.Repeat([]byte{'a'}, maxCloseMessageLen)))))
+	})
+
+	It("resets streams when the session is closed", func() {
+		str := mockquic.NewMockStream(mockCtrl)
+		str.EXPECT().StreamID().Return(quic.StreamID(8)).AnyTimes()
+		qconn.EXPECT().OpenStream().Return(str, nil)
+		_, err := sess.OpenStream()
+		Expect(err).ToNot(HaveOccurred())
+
+		str.EXPECT().CancelRead(sessionGoneErrorCode)
+		str.EXPECT().CancelWrite(sessionGoneErrorCode)
+		requestStrWriter.Close()
+		Eventually(sess.Context().Done()).Should(BeClosed())
+	})
+
+	It("rejects incoming streams after the session was closed", func() {
+		requestStrWriter.Close()
+		Eventually(sess.Context().Done()).Should(BeClosed())
+		str := mockquic.NewMockStream(mockCtrl)
+		str.EXPECT().CancelRead(sessionGoneErrorCode)
+		str.EXPECT().CancelWrite(sessionGoneErrorCode)
+		sess.addIncomingStream(str)
+	})
+
+	It("unblocks OpenStreamSync when the session is closed", func() {
+		qconn.EXPECT().OpenStreamSync(gomock.Any()).DoAndReturn(func(ctx context.Context) (quic.Stream, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		errChan := make(chan error, 1)
+		go func() {
+			_, err := sess.OpenStreamSync(context.Background())
+			errChan <- err
+		}()
+		Consistently(errChan).ShouldNot(Receive())
+		go requestStrWriter.Write(closeCapsule(1, "closed"))
+		Eventually(errChan).Should(Receive(Equal(&SessionError{Remote: true, ErrorCode: 1, Message: "closed"})))
+	})
+
+	It("sends the stream header on new streams", func() {
+		str := mockquic.NewMockStream(mockCtrl)
+		str.EXPECT().StreamID().Return(quic.StreamID(2)).AnyTimes()
+		qconn.EXPECT().OpenUniStream().Return(str, nil)
+		ustr, err := sess.OpenUniStream()
+		Expect(err).ToNot(HaveOccurred())
+		buf := &bytes.Buffer{}
+		str.EXPECT().Write(gomock.Any()).DoAndReturn(buf.Write).Times(2)
+		_, err = ustr.Write([]byte("foobar"))
+		Expect(err).ToNot(HaveOccurred())
+		r := bytes.NewReader(buf.Bytes())
+		typ, err := quicvarint.Read(r)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(typ).To(BeEquivalentTo(webTransportUniStreamType))
+		sid, err := quicvarint.Read(r)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sid).To(BeEquivalentTo(id))
+		data, err := io.ReadAll(r)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal([]byte("foobar")))
+		str.EXPECT().CancelWrite(sessionGoneErrorCode).AnyTimes()
+	})
+
+	It("sends and receives datagrams on the CONNECT stream", func() {
+		Expect(sess.SendDatagram([]byte("foobar"))).To(Succeed())
+		Expect(datagrams.sent).To(Equal([][]byte{[]byte("foobar")}))
+		datagrams.received <- []byte("raboof")
+		b, err := sess.ReceiveDatagram(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(b).To(Equal([]byte("raboof")))
+	})
+
+	It("stops receiving datagrams when the session is closed", func() {
+		errChan := make(chan error, 1)
+		go func() {
+			_, err := sess.ReceiveDatagram(context.Background())
+			errChan <- err
+		}()
+		Consistently(errChan).ShouldNot(Receive())
+		go requestStrWriter.Write(closeCapsule(1, "closed"))
+		Eventually(errChan).Should(Receive(Equal(&SessionError{Remote: true, ErrorCode: 1, Message: "closed"})))
+		Expect(sess.SendDatagram([]byte("foobar"))).To(MatchError(&SessionError{Remote: true, ErrorCode: 1, Message: "closed"}))
+	})
+})
@@ -0,0 +1,189 @@
+package webtransport
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/quic-go/quic-go"
+)
+
+// A SendStream is a unidirectional WebTransport send stream.
+type SendStream interface {
+	io.Writer
+	io.Closer
+
+	StreamID() quic.StreamID
+	CancelWrite(StreamErrorCode)
+
+	SetWriteDeadline(time.Time) error
+}
+
+// A ReceiveStream is a unidirectional WebTransport receive stream.
+type ReceiveStream interface {
+	io.Reader
+
+	StreamID() quic.StreamID
+	CancelRead(StreamErrorCode)
+
+	SetReadDeadline(time.Time) error
+}
+
+// A Stream is a bidirectional WebTransport stream.
+type Stream interface {
+	SendStream
+	ReceiveStream
+	SetDeadline(time.Time) error
+}
+
+type sendStream struct {
+	str quic.SendStream
+	// The WebTransport stream header, sent before any data is written.
+	// It is nil for streams opened by the peer, and set to nil once it was sent.
+	streamHdr []byte
+
+	onClose func()
+}
+
+var _ SendStream = &sendStream{}
+
+func newSendStream(str quic.SendStream, hdr []byte, onClose func()) *sendStream {
+	return &sendStream{str: str, streamHdr: hdr, onClose: onClose}
+}
+
+func (s *sendStream) maybeSendStreamHeader() error {
+	if len(s.streamHdr) == 0 {
+		return nil
+	}
+	if _, err := s.str.Write(s.streamHdr); err != nil {
+		return err
+	}
+	s.streamHdr = nil
+	return nil
+}
+
+func (s *sendStream) Write(b []byte) (int, error) {
+	if err := s.maybeSendStreamHeader(); err != nil {
+		return 0, maybeConvertStreamError(err)
+	}
+	n, err := s.str.Write(b)
+	if err != nil && !isTimeoutError(err) {
+		s.onClose()
+	}
+	return n, maybeConvertStreamError(err)
+}
+
+func (s *sendStream) CancelWrite(e StreamErrorCode) {
+	s.str.CancelWrite(webtransportCodeToHTTPCode(e))
+	s.onClose()
+}
+
+func (s *sendStream) closeWithSession() {
+	s.str.CancelWrite(sessionGoneErrorCode)
+}
+
+func (s *sendStream) Close() error {
+	if err := s.maybeSendStreamHeader(); err != nil {
+		return maybeConvertStreamError(err)
+	}
+	s.onClose()
+	return maybeConvertStreamError(s.str.Close())
+}
+
+func (s *sendStream) SetWriteDeadline(t time.Time) error {
+	return maybeConvertStreamError(s.str.SetWriteDeadline(t))
+}
+
+func (s *sendStream) StreamID() quic.StreamID {
+	return s.str.StreamID()
+}
+
+type receiveStream struct {
+	str     quic.ReceiveStream
+	onClose func()
+}
+
+var _ ReceiveStream = &receiveStream{}
+
+func newReceiveStream(str quic.ReceiveStream, onClose func()) *receiveStream {
+	return &receiveStream{str: str, onClose: onClose}
+}
+
+func (s *receiveStream) Read(b []byte) (int, error) {
+	n, err := s.str.Read(b)
+	if err != nil && !isTimeoutError(err) {
+		s.onClose()
+	}
+	return n, maybeConvertStreamError(err)
+}
+
+func (s *receiveStream) CancelRead(e StreamErrorCode) {
+	s.str.CancelRead(webtransportCodeToHTTPCode(e))
+	s.onClose()
+}
+
+func (s *receiveStream) closeWithSession() {
+	s.str.CancelRead(sessionGoneErrorCode)
+}
+
+func (s *receiveStream) SetReadDeadline(t time.Time) error {
+	return maybeConvertStreamError(s.str.SetReadDeadline(t))
+}
+
+func (s *receiveStream) StreamID() quic.StreamID {
+	return s.str.StreamID()
+}
+
+type stream struct {
+	*sendStream
+	*receiveStream
+
+	mutex                          sync.Mutex
+	sendSideClosed, recvSideClosed bool
+	onClose                        func()
+}
+
+var _ Stream = &stream{}
+
+func newStream(str quic.Stream, hdr []byte, onClose func()) *stream {
+	s := &stream{onClose: onClose}
+	s.sendStream = newSendStream(str, hdr, func() { s.registerClose(true) })
+	s.receiveStream = newReceiveStream(str, func() { s.registerClose(false) })
+	return s
+}
+
+// registerClose calls onClose once both the send and the receive side are done.
+func (s *stream) registerClose(isSendSide bool) {
+	s.mutex.Lock()
+	if isSendSide {
+		s.sendSideClosed = true
+	} else {
+		s.recvSideClosed = true
+	}
+	isClosed := s.sendSideClosed && s.recvSideClosed
+	s.mutex.Unlock()
+
+	if isClosed {
+		s.onClose()
+	}
+}
+
+func (s *stream) closeWithSession() {
+	s.sendStream.closeWithSession()
+	s.receiveStream.closeWithSession()
+}
+
+func (s *stream) SetDeadline(t time.Time) error {
+	return errors.Join(s.SetWriteDeadline(t), s.SetReadDeadline(t))
+}
+
+func (s *stream) StreamID() quic.StreamID {
+	return s.receiveStream.StreamID()
+}
+
+func isTimeoutError(err error) bool {
+	nerr, ok := err.(net.Error)
+	return ok && nerr.Timeout()
+}
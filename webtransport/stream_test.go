@@ -0,0 +1,140 @@
+package webtransport
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	mockquic "github.com/quic-go/quic-go/internal/mocks/quic"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+var _ = Describe("Streams", func() {
+	var (
+		qstr   *mockquic.MockStream
+		closed int
+	)
+
+	BeforeEach(func() {
+		qstr = mockquic.NewMockStream(mockCtrl)
+		closed = 0
+	})
+
+	onClose := func() { closed++ }
+
+	Context("send streams", func() {
+		It("sends the stream header with the first write", func() {
+			str := newSendStream(qstr, []byte("hdr"), onClose)
+			buf := &bytes.Buffer{}
+			qstr.EXPECT().Write(gomock.Any()).DoAndReturn(buf.Write).Times(3)
+			_, err := str.Write([]byte("foo"))
+			Expect(err).ToNot(HaveOccurred())
+			_, err = str.Write([]byte("bar"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(buf.String()).To(Equal("hdrfoobar"))
+		})
+
+		It("sends the stream header when the stream is closed without writing", func() {
+			str := newSendStream(qstr, []byte("hdr"), onClose)
+			buf := &bytes.Buffer{}
+			gomock.InOrder(
+				qstr.EXPECT().Write(gomock.Any()).DoAndReturn(buf.Write),
+				qstr.EXPECT().Close(),
+			)
+			Expect(str.Close()).To(Succeed())
+			Expect(buf.String()).To(Equal("hdr"))
+			Expect(closed).To(Equal(1))
+		})
+
+		It("converts the error code when canceling", func() {
+			str := newSendStream(qstr, nil, onClose)
+			qstr.EXPECT().CancelWrite(webtransportCodeToHTTPCode(1337))
+			str.CancelWrite(1337)
+			Expect(closed).To(Equal(1))
+		})
+
+		It("converts errors returned by Write", func() {
+			str := newSendStream(qstr, nil, onClose)
+			qstr.EXPECT().Write(gomock.Any()).Return(0, &quic.StreamError{ErrorCode: webtransportCodeToHTTPCode(42), Remote: true})
+			_, err := str.Write([]byte("foo"))
+			Expect(err).To(Equal(&StreamError{ErrorCode: 42, Remote: true}))
+			Expect(closed).To(Equal(1))
+		})
+
+		It("doesn't consider the stream closed on timeout errors", func() {
+			str := newSendStream(qstr, nil, onClose)
+			qstr.EXPECT().Write(gomock.Any()).Return(0, timeoutError{})
+			_, err := str.Write([]byte("foo"))
+			Expect(err).To(MatchError(timeoutError{}))
+			Expect(closed).To(BeZero())
+		})
+
+		It("resets the stream when the session is closed", func() {
+			str := newSendStream(qstr, nil, onClose)
+			qstr.EXPECT().CancelWrite(sessionGoneErrorCode)
+			str.closeWithSession()
+		})
+	})
+
+	Context("receive streams", func() {
+		It("converts the error code when canceling", func() {
+			str := newReceiveStream(qstr, onClose)
+			qstr.EXPECT().CancelRead(webtransportCodeToHTTPCode(1337))
+			str.CancelRead(1337)
+			Expect(closed).To(Equal(1))
+		})
+
+		It("converts errors returned by Read", func() {
+			str := newReceiveStream(qstr, onClose)
+			qstr.EXPECT().Read(gomock.Any()).Return(0, &quic.StreamError{ErrorCode: sessionGoneErrorCode, Remote: true})
+			_, err := str.Read(make([]byte, 10))
+			var sessErr *SessionError
+			Expect(errors.As(err, &sessErr)).To(BeTrue())
+			Expect(closed).To(Equal(1))
+		})
+
+		It("resets the stream when the session is closed", func() {
+			str := newReceiveStream(qstr, onClose)
+			qstr.EXPECT().CancelRead(sessionGoneErrorCode)
+			str.closeWithSession()
+		})
+	})
+
+	Context("bidirectional streams", func() {
+		It("is closed once both sides are closed", func() {
+			str := newStream(qstr, nil, onClose)
+			qstr.EXPECT().Close()
+			Expect(str.Close()).To(Succeed())
+			Expect(closed).To(BeZero())
+			qstr.EXPECT().CancelRead(webtransportCodeToHTTPCode(0))
+			str.CancelRead(0)
+			Expect(closed).To(Equal(1))
+		})
+
+		It("sets the deadline on both sides", func() {
+			str := newStream(qstr, nil, onClose)
+			deadline := time.Now().Add(time.Hour)
+			testErr := errors.New("test error")
+			qstr.EXPECT().SetWriteDeadline(deadline)
+			qstr.EXPECT().SetReadDeadline(deadline).Return(testErr)
+			Expect(str.SetDeadline(deadline)).To(MatchError(testErr))
+		})
+
+		It("resets both sides when the session is closed", func() {
+			str := newStream(qstr, nil, onClose)
+			qstr.EXPECT().CancelWrite(sessionGoneErrorCode)
+			qstr.EXPECT().CancelRead(sessionGoneErrorCode)
+			str.closeWithSession()
+		})
+	})
+})